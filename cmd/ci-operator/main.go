@@ -28,6 +28,8 @@ import (
 	"github.com/bombsimon/logrusr/v3"
 	"github.com/go-logr/logr"
 	egressfirewallv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/egressfirewall/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
 	"github.com/sirupsen/logrus"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -52,6 +54,7 @@ import (
 	utilpointer "k8s.io/utils/pointer"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	crcontrollerutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	ctrlruntimelog "sigs.k8s.io/controller-runtime/pkg/log"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
@@ -84,11 +87,15 @@ import (
 	"github.com/openshift/ci-tools/pkg/labeledclient"
 	"github.com/openshift/ci-tools/pkg/lease"
 	"github.com/openshift/ci-tools/pkg/load"
+	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+	"github.com/openshift/ci-tools/pkg/quarantine"
 	"github.com/openshift/ci-tools/pkg/registry"
 	"github.com/openshift/ci-tools/pkg/registry/server"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
+	"github.com/openshift/ci-tools/pkg/steps/multi_stage"
+	"github.com/openshift/ci-tools/pkg/trace"
 	"github.com/openshift/ci-tools/pkg/util"
 	"github.com/openshift/ci-tools/pkg/util/gzip"
 	"github.com/openshift/ci-tools/pkg/validation"
@@ -175,10 +182,22 @@ After a successful build the --promote will tag each built image (in "images")
 to the image stream(s) identified by the "promotion" config. You may add
 additional images to promote and their target names via the "additional_images"
 map.
+
+A single multi-stage test can be run without a cluster by passing --local-runtime
+with the name of a docker-compatible container runtime (e.g. podman) and exactly one
+--target. The images used by the steps are supplied with --local-image, credentials
+with --local-credentials-dir and leased resources as --multi-stage-param values.
+SHARED_DIR and the step artifacts are kept in --local-work-dir. Steps are executed
+with the same conditions, retries and parallel groups as in a cluster. Running the
+test pods against a plain Kubernetes cluster such as kind is not supported: the steps
+depend on the ImageStreams and RBAC of an OpenShift test namespace.
 `
 
 const (
 	leaseAcquireTimeout = 120 * time.Minute
+	// leaseConfigMapExpiry is the time after which a lease recorded in a
+	// ConfigMap is considered abandoned if it is not renewed
+	leaseConfigMapExpiry = 30 * time.Minute
 )
 
 var (
@@ -360,9 +379,14 @@ type options struct {
 	targets stringSlice
 	promote bool
 
-	verbose    bool
-	help       bool
-	printGraph bool
+	verbose      bool
+	help         bool
+	printGraph   bool
+	explain      bool
+	explainTrace string
+	dryRun       bool
+	dryRunDir    string
+	resume       bool
 
 	writeParams string
 	artifactDir string
@@ -389,8 +413,19 @@ type options struct {
 	leaseServer                string
 	leaseServerCredentialsFile string
 	leaseAcquireTimeout        time.Duration
+	leaseConfigMap             string
+	jobLabelsFile              string
 	leaseClient                lease.Client
-	clusterProfiles            []clusterProfileForTarget
+	// metrics gathers the metrics of the execution, which are saved as an
+	// artifact when it finishes.
+	metrics         *prometheus.Registry
+	clusterProfiles []clusterProfileForTarget
+
+	failureClassificationConfig string
+	classifier                  *results.Classifier
+
+	quarantineListPath string
+	quarantineList     *quarantine.List
 
 	givePrAuthorAccessToNamespace bool
 	impersonateUser               string
@@ -431,11 +466,19 @@ type options struct {
 	dependencyOverrides      stringSlice
 
 	targetAdditionalSuffix string
+	shardIndex             int
+	shardCount             int
 	manifestToolDockerCfg  string
 	localRegistryDNS       string
 
 	restrictNetworkAccess       bool
 	enableSecretsStoreCSIDriver bool
+
+	localRuntime           string
+	localWorkDir           string
+	localImages            stringSlice
+	localCredentialsDir    string
+	localClusterProfileDir string
 }
 
 func bindOptions(flag *flag.FlagSet) *options {
@@ -455,11 +498,20 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.StringVar(&opt.leaseServer, "lease-server", leaseServerAddress, "Address of the server that manages leases. Required if any test is configured to acquire a lease.")
 	flag.StringVar(&opt.leaseServerCredentialsFile, "lease-server-credentials-file", "", "The path to credentials file used to access the lease server. The content is of the form <username>:<password>.")
 	flag.DurationVar(&opt.leaseAcquireTimeout, "lease-acquire-timeout", leaseAcquireTimeout, "Maximum amount of time to wait for lease acquisition")
+	flag.StringVar(&opt.leaseConfigMap, "lease-configmap", "", "Lease resources recorded in a ConfigMap in the build cluster, given as <namespace>/<name>, instead of using the lease server.")
+	flag.StringVar(&opt.jobLabelsFile, "job-labels-file", "", "Path to the labels of the job's pod, as projected by the downward API. The priority of leases is derived from them.")
+	flag.StringVar(&opt.failureClassificationConfig, "failure-classification-config", "", "Path to the rules classifying failures of steps by matching their logs, pod events and termination messages. Defaults to built-in rules.")
+	flag.StringVar(&opt.quarantineListPath, "quarantine-list", "", "Path to the list of quarantined flaky tests. Failed test cases quarantined for the repo and branch under test are marked as skipped in the jUnit results.")
 	flag.StringVar(&opt.registryPath, "registry", "", "Path to the step registry directory")
 	flag.StringVar(&opt.configSpecPath, "config", "", "The configuration file. If not specified the CONFIG_SPEC environment variable or the configresolver will be used.")
 	flag.StringVar(&opt.unresolvedConfigPath, "unresolved-config", "", "The configuration file, before resolution. If not specified the UNRESOLVED_CONFIG environment variable will be used, if set.")
 	flag.Var(&opt.targets, "target", "One or more targets in the configuration to build. Only steps that are required for this target will be run.")
 	flag.BoolVar(&opt.printGraph, "print-graph", opt.printGraph, "Print a directed graph of the build steps and exit. Intended for use with the golang digraph utility.")
+	flag.BoolVar(&opt.explain, "explain", opt.explain, "Print the critical path to each target, why each step is needed and which steps supplying an image externally would prune, and exit.")
+	flag.BoolVar(&opt.dryRun, "dry-run", opt.dryRun, "Render every object the execution would create in the cluster as YAML and exit, without making any API calls. Releases are not resolved; the configuration is still fetched from the resolver unless --config is passed.")
+	flag.StringVar(&opt.dryRunDir, "dry-run-dir", "dry-run", "Directory to write the objects rendered by --dry-run to.")
+	flag.StringVar(&opt.explainTrace, "explain-trace", "", "Path to the "+trace.Filename+" artifact of a previous execution, used with --explain to determine the critical path from the durations of the steps.")
+	flag.BoolVar(&opt.resume, "resume", opt.resume, "Record the progress of the execution in the namespace and skip steps that finished in an interrupted execution in the same namespace when their outputs still exist. The resources leased by the interrupted execution are leased again if they are free.")
 
 	// add to the graph of things we run or create
 	flag.Var(&opt.templatePaths, "template", "A set of paths to optional templates to add as stages to this job. Each template is expected to contain at least one restart=Never pod. Parameters are filled from environment or from the automatic parameters generated by the operator.")
@@ -507,10 +559,18 @@ func bindOptions(flag *flag.FlagSet) *options {
 	flag.Var(&opt.dependencyOverrides, "dependency-override-param", "A repeatable option used to override dependencies with external pull specs. This parameter should be in the format ENVVARNAME=PULLSPEC, e.g. --dependency-override-param=OO_INDEX=registry.mydomain.com:5000/pushed/myimage. This would override the value for the OO_INDEX environment variable for any tests/steps that currently have that dependency configured.")
 
 	flag.StringVar(&opt.targetAdditionalSuffix, "target-additional-suffix", "", "Inject an additional suffix onto the targeted test's 'as' name. Used for adding an aggregate index")
+	flag.IntVar(&opt.shardIndex, "shard-index", 0, "The 1-based index of the shard of the targeted test to run, passed to the test as $"+api.ShardIndexEnv+". Requires --shard-count.")
+	flag.IntVar(&opt.shardCount, "shard-count", 0, "The number of shards the targeted test is split into, passed to the test as $"+api.ShardCountEnv+". Requires --shard-index.")
 
 	flag.StringVar(&opt.manifestToolDockerCfg, "manifest-tool-dockercfg", "/secrets/manifest-tool/.dockerconfigjson", "The dockercfg file path to be used to push the manifest listed image after build. This is being used by the manifest-tool binary.")
 	flag.StringVar(&opt.localRegistryDNS, "local-registry-dns", "image-registry.openshift-image-registry.svc:5000", "Defines the target image registry.")
 
+	flag.StringVar(&opt.localRuntime, "local-runtime", "", "Run the targeted multi-stage test using this docker-compatible container runtime (e.g. podman) instead of in a cluster.")
+	flag.StringVar(&opt.localWorkDir, "local-work-dir", "", "Directory holding SHARED_DIR, home and artifacts of a --local-runtime run. Defaults to a temporary directory.")
+	flag.Var(&opt.localImages, "local-image", "A repeatable option mapping the image of a step or dependency to a pull spec for --local-runtime runs, in the format NAME=PULLSPEC, e.g. --local-image=src=quay.io/org/repo:src.")
+	flag.StringVar(&opt.localCredentialsDir, "local-credentials-dir", "", "Directory with one <namespace>-<name> sub-directory per credential used by steps of a --local-runtime run.")
+	flag.StringVar(&opt.localClusterProfileDir, "local-cluster-profile-dir", "", "Directory with the cluster profile files for --local-runtime runs.")
+
 	opt.resultsOptions.Bind(flag)
 	return opt
 }
@@ -536,6 +596,11 @@ func (o *options) Complete() error {
 		jobSpec.Refs = spec.Refs
 	}
 	jobSpec.BaseNamespace = o.baseNamespace
+	if o.jobLabelsFile != "" {
+		if jobSpec.Labels, err = api.LoadJobLabels(o.jobLabelsFile); err != nil {
+			return fmt.Errorf("failed to determine job spec: %w", err)
+		}
+	}
 	target := "all"
 	if len(o.targets.values) > 0 {
 		target = o.targets.values[0]
@@ -552,6 +617,31 @@ func (o *options) Complete() error {
 	if o.unresolvedConfigPath != "" && o.resolverAddress == "" {
 		return errors.New("cannot request resolved config with --unresolved-config unless providing --resolver-address")
 	}
+	if o.explainTrace != "" && !o.explain {
+		return errors.New("--explain-trace requires --explain")
+	}
+	if (o.shardIndex != 0 || o.shardCount != 0) && (o.shardIndex < 1 || o.shardIndex > o.shardCount) {
+		return fmt.Errorf("--shard-index must be between 1 and --shard-count, got %d of %d", o.shardIndex, o.shardCount)
+	}
+	if o.leaseConfigMap != "" {
+		if namespace, name, ok := strings.Cut(o.leaseConfigMap, "/"); !ok || namespace == "" || name == "" {
+			return fmt.Errorf("--lease-configmap must be of the form <namespace>/<name>, got %q", o.leaseConfigMap)
+		}
+	}
+
+	if o.failureClassificationConfig != "" {
+		if o.classifier, err = results.LoadClassifier(o.failureClassificationConfig); err != nil {
+			return fmt.Errorf("could not load --failure-classification-config: %w", err)
+		}
+	} else if o.classifier, err = results.NewClassifier(results.DefaultClassifierConfig); err != nil {
+		return fmt.Errorf("invalid default failure classification rules: %w", err)
+	}
+
+	if o.quarantineListPath != "" {
+		if o.quarantineList, err = quarantine.LoadList(o.quarantineListPath); err != nil {
+			return fmt.Errorf("could not load --quarantine-list: %w", err)
+		}
+	}
 
 	injectTest, err := o.getInjectTest()
 	if err != nil {
@@ -684,6 +774,11 @@ func (o *options) Complete() error {
 		o.templates = append(o.templates, template)
 	}
 
+	if o.localRuntime != "" || o.dryRun {
+		// local runs and dry runs never talk to a cluster
+		return o.applyOverrides()
+	}
+
 	clusterConfig, err := util.LoadClusterConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load cluster config: %w", err)
@@ -726,6 +821,12 @@ func (o *options) Complete() error {
 		o.hiveKubeconfig = kubeConfig
 	}
 
+	return o.applyOverrides()
+}
+
+// applyOverrides applies the parameter, suffix and dependency overrides
+// passed to ci-operator to the loaded configuration.
+func (o *options) applyOverrides() error {
 	applyEnvOverrides(o)
 
 	if err := overrideMultiStageParams(o); err != nil {
@@ -733,6 +834,7 @@ func (o *options) Complete() error {
 	}
 
 	handleTargetAdditionalSuffix(o)
+	o.jobSpec.ShardIndex, o.jobSpec.ShardCount = o.shardIndex, o.shardCount
 
 	return overrideTestStepDependencyParams(o)
 }
@@ -914,8 +1016,14 @@ func (o *options) Run() []error {
 		logrus.Infof("error: Process interrupted with signal %s, cancelling execution...", s)
 		cancel()
 	}
+	if o.localRuntime != "" {
+		return o.runLocal(ctx, handler)
+	}
+	if o.dryRun {
+		return o.runDryRun(ctx)
+	}
 	var leaseClient *lease.Client
-	if o.leaseServer != "" && o.leaseServerCredentialsFile != "" {
+	if o.leaseConfigMap != "" || (o.leaseServer != "" && o.leaseServerCredentialsFile != "") {
 		leaseClient = &o.leaseClient
 	}
 
@@ -976,11 +1084,31 @@ func (o *options) Run() []error {
 		}
 		return nil
 	}
+	if o.explain {
+		var durations map[string]time.Duration
+		if o.explainTrace != "" {
+			if durations, err = trace.LoadDurations(o.explainTrace); err != nil {
+				return []error{fmt.Errorf("could not load durations: %w", err)}
+			}
+		}
+		explanations, err := api.Explain(stepList, o.targets.values, durations)
+		if err != nil {
+			return []error{fmt.Errorf("could not explain graph: %w", err)}
+		}
+		if err := printExplanations(os.Stdout, explanations, durations); err != nil {
+			return []error{fmt.Errorf("could not print explanations: %w", err)}
+		}
+		return nil
+	}
 	graph, errs := calculateGraph(stepList)
 	if errs != nil {
 		return errs
 	}
+	var graphStart time.Time
 	defer func() {
+		o.writeTrace(start, graphStart, *graph)
+		o.writeJobRun(start, *graph)
+		o.writeMetrics()
 		serializedGraph, err := json.Marshal(graph)
 		if err != nil {
 			logrus.WithError(err).Error("Failed to marshal graph")
@@ -994,6 +1122,11 @@ func (o *options) Run() []error {
 	if err := o.initializeNamespace(); err != nil {
 		return []error{results.ForReason("initializing_namespace").WithError(err).Errorf("could not initialize namespace: %v", err)}
 	}
+	if o.resume {
+		if err := o.recordProgress(ctx, stepList); err != nil {
+			return []error{fmt.Errorf("could not set up recording of progress: %w", err)}
+		}
+	}
 
 	return interrupt.New(handler, o.saveNamespaceArtifacts).Run(func() []error {
 		if leaseClient != nil {
@@ -1013,7 +1146,8 @@ func (o *options) Run() []error {
 		runtimeObject := &coreapi.ObjectReference{Namespace: o.namespace}
 		eventRecorder.Event(runtimeObject, coreapi.EventTypeNormal, "CiJobStarted", eventJobDescription(o.jobSpec, o.namespace))
 		// execute the graph
-		suites, graphDetails, errs := steps.Run(ctx, nodes)
+		graphStart = time.Now()
+		suites, graphDetails, errs := steps.Run(ctx, nodes, o.classifier)
 		if err := o.writeJUnit(suites, "operator"); err != nil {
 			logrus.WithError(err).Warn("Unable to write JUnit result.")
 		}
@@ -1054,6 +1188,155 @@ func (o *options) Run() []error {
 	})
 }
 
+// runLocal executes the targeted multi-stage test with a local container
+// runtime. Images, credentials and leases have to be provided explicitly.
+func (o *options) runLocal(ctx context.Context, handler func(os.Signal)) []error {
+	if len(o.targets.values) != 1 {
+		return []error{errors.New("--local-runtime requires exactly one --target")}
+	}
+	var test *api.TestStepConfiguration
+	for i := range o.configSpec.Tests {
+		if o.configSpec.Tests[i].As == o.targets.values[0] {
+			test = &o.configSpec.Tests[i]
+		}
+	}
+	if test == nil || test.MultiStageTestConfigurationLiteral == nil {
+		return []error{fmt.Errorf("--local-runtime requires the target to be a multi-stage test, %q is not", o.targets.values[0])}
+	}
+	images, err := parseKeyValParams(o.localImages.values, "local-image")
+	if err != nil {
+		return []error{err}
+	}
+	workDir := o.localWorkDir
+	if workDir == "" {
+		if workDir, err = os.MkdirTemp("", "ci-operator-local-"); err != nil {
+			return []error{fmt.Errorf("could not create local work directory: %w", err)}
+		}
+	}
+	o.namespace = "ci-op-local"
+	o.jobSpec.SetNamespace(o.namespace)
+	step := multi_stage.LocalMultiStageTestStep(*test, o.configSpec, o.jobSpec, multi_stage.NewCommandRuntime(o.localRuntime, os.Stdout, os.Stderr), multi_stage.LocalOptions{
+		WorkDir:           workDir,
+		Images:            images,
+		CredentialsDir:    o.localCredentialsDir,
+		ClusterProfileDir: o.localClusterProfileDir,
+	})
+	return interrupt.New(handler).Run(func() []error {
+		suites, _, errs := steps.Run(ctx, api.StepGraph{{Step: step}}, o.classifier)
+		if err := o.writeJUnit(suites, "operator"); err != nil {
+			logrus.WithError(err).Warn("Unable to write JUnit result.")
+		}
+		logrus.Infof("Local work directory with SHARED_DIR and artifacts: %s", workDir)
+		return errs
+	})
+}
+
+// dryRunNamespace stands in for the test namespace, which is derived from the
+// inputs resolved on the cluster.
+const dryRunNamespace = "ci-op-dry-run"
+
+// runDryRun builds the graph for the targets and renders everything its steps
+// would create in the cluster, without making any API calls.
+func (o *options) runDryRun(ctx context.Context) []error {
+	o.namespace = dryRunNamespace
+	o.jobSpec.SetNamespace(o.namespace)
+	buildSteps, _, err := defaults.FromConfigForDryRun(ctx, o.configSpec, &o.graphConfig, o.jobSpec, o.templates, o.writeParams, o.promote,
+		o.targets.values, o.cloneAuthConfig, o.pullSecret, o.pushSecret, o.censor, o.nodeName, o.targetAdditionalSuffix, o.injectTest != "", o.enableSecretsStoreCSIDriver)
+	if err != nil {
+		return []error{results.ForReason("defaulting_config").WithError(err).Errorf("failed to generate steps from config: %v", err)}
+	}
+	nodes, err := api.BuildPartialGraph(buildSteps, o.targets.values)
+	if err != nil {
+		return []error{results.ForReason("building_graph").WithError(err).Errorf("failed to build graph from steps: %v", err)}
+	}
+	stepList, errs := nodes.TopologicalSort()
+	if errs != nil {
+		return append([]error{results.ForReason("building_graph").ForError(errors.New("could not sort nodes"))}, errs...)
+	}
+	if err := writeDryRun(o.dryRunDir, o.namespace, stepList); err != nil {
+		return []error{fmt.Errorf("could not write dry run output: %w", err)}
+	}
+	logrus.Infof("Wrote the dry run of %s to %s", strings.Join(nodeNames(stepList), ", "), o.dryRunDir)
+	return nil
+}
+
+// writeDryRun writes the objects every step would create into a YAML file
+// for that step. The test namespace gets a file of its own and the leases
+// the steps would acquire are collected in leases.yaml. Steps which cannot be
+// rendered are listed in not-rendered.yaml.
+func writeDryRun(dir, namespace string, stepList api.OrderedStepList) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create directory: %w", err)
+	}
+	if err := writeDryRunObjects(filepath.Join(dir, "namespace.yaml"), []ctrlruntimeclient.Object{
+		&coreapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+		&imageapi.ImageStream{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: api.PipelineImageStream},
+			Spec:       imageapi.ImageStreamSpec{LookupPolicy: imageapi.ImageLookupPolicy{Local: true}},
+		},
+	}); err != nil {
+		return err
+	}
+	leases := map[string][]api.StepLease{}
+	var notRendered []string
+	sanitize := strings.NewReplacer(":", "-", "/", "-", "[", "", "]", "")
+	for _, node := range stepList {
+		dryRunner, ok := node.Step.(steps.DryRunner)
+		if !ok {
+			notRendered = append(notRendered, node.Step.Name())
+			continue
+		}
+		output, err := dryRunner.DryRun()
+		if err != nil {
+			return fmt.Errorf("could not render step %s: %w", node.Step.Name(), err)
+		}
+		if len(output.Leases) > 0 {
+			leases[node.Step.Name()] = output.Leases
+		}
+		if len(output.Objects) == 0 {
+			continue
+		}
+		if err := writeDryRunObjects(filepath.Join(dir, sanitize.Replace(node.Step.Name())+".yaml"), output.Objects); err != nil {
+			return err
+		}
+	}
+	if len(notRendered) > 0 {
+		logrus.Warnf("The objects for %s cannot be rendered without running them", strings.Join(notRendered, ", "))
+		raw, err := yaml.Marshal(notRendered)
+		if err != nil {
+			return fmt.Errorf("could not marshal steps which were not rendered: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "not-rendered.yaml"), raw, 0644); err != nil {
+			return err
+		}
+	}
+	if len(leases) == 0 {
+		return nil
+	}
+	raw, err := yaml.Marshal(leases)
+	if err != nil {
+		return fmt.Errorf("could not marshal leases: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, "leases.yaml"), raw, 0644)
+}
+
+func writeDryRunObjects(path string, objects []ctrlruntimeclient.Object) error {
+	var documents []string
+	for _, obj := range objects {
+		gvk, err := apiutil.GVKForObject(obj, scheme.Scheme)
+		if err != nil {
+			return fmt.Errorf("could not determine kind of %s: %w", obj.GetName(), err)
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		raw, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("could not marshal %s %s: %w", gvk.Kind, obj.GetName(), err)
+		}
+		documents = append(documents, string(raw))
+	}
+	return os.WriteFile(path, []byte(strings.Join(documents, "---\n")), 0644)
+}
+
 func runPromotionStep(ctx context.Context, step api.Step, detailsChan chan<- api.CIOperatorStepDetails, errChan chan<- error) {
 	details, err := runStep(ctx, step)
 	if err != nil {
@@ -1134,6 +1417,93 @@ func runStep(ctx context.Context, step api.Step) (api.CIOperatorStepDetails, err
 	}, err
 }
 
+// writeTrace saves the timeline of the execution as an artifact. Pods and
+// Builds in the namespace are inspected to determine how long they were
+// pending and building.
+func (o *options) writeTrace(start, graphStart time.Time, graph api.CIOperatorStepGraph) {
+	execution := trace.Execution{
+		Start:      start,
+		GraphStart: graphStart,
+		End:        time.Now(),
+		Steps:      graph,
+	}
+	if client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{}); err != nil {
+		logrus.WithError(err).Warn("Could not create client for listing Pods and Builds, the trace will not include them.")
+	} else {
+		pods := &coreapi.PodList{}
+		if err := client.List(context.TODO(), pods, ctrlruntimeclient.InNamespace(o.namespace)); err != nil {
+			logrus.WithError(err).Warn("Could not list Pods, the trace will not include them.")
+		}
+		builds := &buildv1.BuildList{}
+		if err := client.List(context.TODO(), builds, ctrlruntimeclient.InNamespace(o.namespace)); err != nil {
+			logrus.WithError(err).Warn("Could not list Builds, the trace will not include them.")
+		}
+		execution.Pods, execution.Builds = pods.Items, builds.Items
+	}
+	serialized, err := json.Marshal(execution.Trace())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to marshal trace")
+		return
+	}
+	_ = api.SaveArtifact(o.censor, trace.Filename, serialized)
+}
+
+// writeJobRun records how long the multi-stage tests ran and what they held
+// meanwhile, for the pod-scaler to account for their costs.
+func (o *options) writeJobRun(start time.Time, graph api.CIOperatorStepGraph) {
+	run := podscaler.JobRun{Metadata: o.configSpec.Metadata, Started: start}
+	if o.jobSpec != nil {
+		run.Job = o.jobSpec.Job
+	}
+	details := map[string]api.CIOperatorStepDetails{}
+	for _, step := range graph {
+		details[step.StepName] = step
+	}
+	for i := range o.configSpec.Tests {
+		test := &o.configSpec.Tests[i]
+		if test.MultiStageTestConfigurationLiteral == nil {
+			continue
+		}
+		if step, ran := details[test.As]; ran {
+			run.Tests = append(run.Tests, podscaler.TestRunFor(test, step))
+		}
+	}
+	if len(run.Tests) == 0 {
+		return
+	}
+	serialized, err := json.Marshal(run)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to marshal job run")
+		return
+	}
+	_ = api.SaveArtifact(o.censor, podscaler.JobRunFilename, serialized)
+}
+
+// metricsFilename is the artifact holding the metrics of the execution, in
+// the Prometheus text format.
+const metricsFilename = "ci-operator-metrics.prom"
+
+// writeMetrics saves the metrics gathered during the execution as an
+// artifact.
+func (o *options) writeMetrics() {
+	if o.metrics == nil {
+		return
+	}
+	families, err := o.metrics.Gather()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to gather metrics")
+		return
+	}
+	var serialized bytes.Buffer
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&serialized, family); err != nil {
+			logrus.WithError(err).Error("Failed to serialize metrics")
+			return
+		}
+	}
+	_ = api.SaveArtifact(o.censor, metricsFilename, serialized.Bytes())
+}
+
 func (o *options) resolveConsoleHost() {
 	if client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{}); err != nil {
 		logrus.WithError(err).Warn("Could not create client for accessing Routes. Will not resolve console URL.")
@@ -1182,6 +1552,10 @@ func (o *options) resolveInputs(steps []api.Step) error {
 	if len(o.extraInputHash.values) > 0 {
 		inputs = append(inputs, o.extraInputHash.values...)
 	}
+	// shards of a test must not share a namespace
+	if o.shardCount != 0 {
+		inputs = append(inputs, fmt.Sprintf("shard-%d-of-%d", o.shardIndex, o.shardCount))
+	}
 
 	// add the binary modification time and size (in lieu of a content hash)
 	path, _ := exec.LookPath(os.Args[0])
@@ -1197,6 +1571,7 @@ func (o *options) resolveInputs(steps []api.Step) error {
 
 	sort.Strings(inputs)
 	o.inputHash = inputHash(inputs)
+	o.jobSpec.InputHash = o.inputHash
 
 	// input hash is unique for a given job definition and input refs
 	if len(o.namespace) == 0 {
@@ -1217,6 +1592,36 @@ func (o *options) resolveInputs(steps []api.Step) error {
 	return nil
 }
 
+// recordProgress wraps the steps to record when they finish and what they
+// lease in the namespace.  Steps that finished in an interrupted execution in
+// the same namespace are skipped if their outputs still exist, and the
+// resources that execution leased are leased again if they are free.
+func (o *options) recordProgress(ctx context.Context, stepList api.OrderedStepList) error {
+	client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to construct client: %w", err)
+	}
+	progress := steps.NewProgress(client, o.namespace)
+	finished, err := progress.Finished(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Could not load the steps that finished in an interrupted execution, running all steps.")
+		finished = map[string]time.Time{}
+	}
+	for name, at := range finished {
+		logrus.Debugf("Step %s finished in an interrupted execution at %s", name, at.Format(time.RFC3339))
+	}
+	leases, err := progress.Leases(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Could not load the resources leased in an interrupted execution, leasing any resources.")
+		leases = map[string]map[string][]string{}
+	}
+	for _, node := range stepList {
+		_, ok := finished[node.Step.Name()]
+		node.Step = steps.ResumableStep(node.Step, progress, ok, leases[node.Step.Name()])
+	}
+	return nil
+}
+
 func (o *options) initializeNamespace() error {
 	// We have to keep the project client because it return a project for a projectCreationRequest, ctrlruntimeclient can not do dark magic like that
 	projectGetter, err := projectclientset.NewForConfig(o.clusterConfig)
@@ -1816,6 +2221,7 @@ func (o *options) writeJUnit(suites *junit.TestSuites, name string) error {
 	if suites == nil {
 		return nil
 	}
+	o.applyQuarantine(suites)
 	sort.Slice(suites.Suites, func(i, j int) bool {
 		return suites.Suites[i].Name < suites.Suites[j].Name
 	})
@@ -1830,6 +2236,19 @@ func (o *options) writeJUnit(suites *junit.TestSuites, name string) error {
 	return api.SaveArtifact(o.censor, fmt.Sprintf("junit_%s.xml", name), out)
 }
 
+// applyQuarantine marks the failed test cases quarantined for the repo and
+// branch under test as skipped, so they do not block the job.
+func (o *options) applyQuarantine(suites *junit.TestSuites) {
+	if o.quarantineList == nil || o.jobSpec == nil || o.jobSpec.Refs == nil {
+		return
+	}
+	refs := o.jobSpec.Refs
+	scope := quarantine.Scope{Org: refs.Org, Repo: refs.Repo, Branch: refs.BaseRef}
+	for _, test := range quarantine.Apply(o.quarantineList, scope, suites, time.Now()) {
+		logrus.Infof("Test %q is quarantined for %s, marking its failure as skipped.", test, scope)
+	}
+}
+
 // oneWayEncoding can be used to encode hex to a 62-character set (0 and 1 are duplicates) for use in
 // short display names that are safe for use in kubernetes as resource names.
 var oneWayNameEncoding = base32.NewEncoding("bcdfghijklmnpqrstvwxyz0123456789").WithPadding(base32.NoPadding)
@@ -1906,14 +2325,33 @@ func loadLeaseCredentials(leaseServerCredentialsFile string) (string, func() []b
 }
 
 func (o *options) initializeLeaseClient() error {
-	var err error
+	if o.metrics == nil {
+		o.metrics = prometheus.NewRegistry()
+	}
+	if err := lease.RegisterMetrics(o.metrics); err != nil {
+		return err
+	}
 	owner := o.namespace + "-" + o.jobSpec.UniqueHash()
-	username, passwordGetter, err := loadLeaseCredentials(o.leaseServerCredentialsFile)
-	if err != nil {
-		return fmt.Errorf("failed to load lease credentials: %w", err)
+	var org string
+	if o.configSpec != nil {
+		org = o.configSpec.Metadata.Org
 	}
-	if o.leaseClient, err = lease.NewClient(owner, o.leaseServer, username, passwordGetter, 60, o.leaseAcquireTimeout); err != nil {
-		return fmt.Errorf("failed to create the lease client: %w", err)
+	if o.leaseConfigMap != "" {
+		client, err := ctrlruntimeclient.New(o.clusterConfig, ctrlruntimeclient.Options{})
+		if err != nil {
+			return fmt.Errorf("failed to construct client: %w", err)
+		}
+		namespace, name, _ := strings.Cut(o.leaseConfigMap, "/")
+		backend := lease.NewConfigMapBackend(client, namespace, name, owner, org, leaseConfigMapExpiry)
+		o.leaseClient = lease.NewClientWithBackend(backend, 60, o.leaseAcquireTimeout)
+	} else {
+		username, passwordGetter, err := loadLeaseCredentials(o.leaseServerCredentialsFile)
+		if err != nil {
+			return fmt.Errorf("failed to load lease credentials: %w", err)
+		}
+		if o.leaseClient, err = lease.NewClient(owner, org, o.leaseServer, username, passwordGetter, 60, o.leaseAcquireTimeout); err != nil {
+			return fmt.Errorf("failed to create the lease client: %w", err)
+		}
 	}
 	t := time.NewTicker(30 * time.Second)
 	go func() {
@@ -2037,6 +2475,65 @@ func printDigraph(w io.Writer, steps api.OrderedStepList) error {
 	return nil
 }
 
+// printExplanations renders the explanation of the graph for every target.
+func printExplanations(w io.Writer, explanations []api.Explanation, durations map[string]time.Duration) error {
+	describe := func(step string) string {
+		if duration, ok := durations[step]; ok {
+			return fmt.Sprintf("%s (%s)", step, duration.Round(time.Second))
+		}
+		return step
+	}
+	for i, explanation := range explanations {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "Target %s:\n", explanation.Target); err != nil {
+			return err
+		}
+		var path []string
+		for _, step := range explanation.CriticalPath {
+			path = append(path, describe(step))
+		}
+		criticalPath := fmt.Sprintf("  Critical path: %s\n", strings.Join(path, " -> "))
+		if durations != nil {
+			criticalPath = fmt.Sprintf("  Critical path (%s): %s\n", explanation.Duration.Round(time.Second), strings.Join(path, " -> "))
+		}
+		if _, err := fmt.Fprint(w, criticalPath); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, "  Steps:"); err != nil {
+			return err
+		}
+		for _, step := range explanation.Steps {
+			reason := "the target"
+			if requiredBy := explanation.RequiredBy[step]; len(requiredBy) > 0 {
+				reason = strings.Join(requiredBy, ", ")
+			}
+			if _, err := fmt.Fprintf(w, "    %s: required by %s\n", describe(step), reason); err != nil {
+				return err
+			}
+		}
+		if len(explanation.PrunedBy) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, "  Supplying an image externally would prune:"); err != nil {
+			return err
+		}
+		for _, step := range explanation.Steps {
+			pruned, ok := explanation.PrunedBy[step]
+			if !ok {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "    %s: %s\n", step, strings.Join(pruned, ", ")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func calculateGraph(nodes api.OrderedStepList) (*api.CIOperatorStepGraph, []error) {
 	if err := validateSteps(nodes); err != nil {
 		return nil, err
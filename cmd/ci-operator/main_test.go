@@ -9,10 +9,13 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
+	coreapi "k8s.io/api/core/v1"
 	rbacapi "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -24,9 +27,13 @@ import (
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 
+	buildapi "github.com/openshift/api/build/v1"
 	imagev1 "github.com/openshift/api/image/v1"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+	"github.com/openshift/ci-tools/pkg/quarantine"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/secrets"
 	"github.com/openshift/ci-tools/pkg/steps"
@@ -1849,3 +1856,260 @@ func TestGetClusterProfileNamesFromTargets(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintExplanations(t *testing.T) {
+	explanations := []api.Explanation{{
+		Target:       "e2e",
+		CriticalPath: []string{"src", "e2e"},
+		Duration:     90 * time.Minute,
+		Steps:        []string{"src", "release:latest", "e2e"},
+		RequiredBy: map[string][]string{
+			"src":            {"e2e"},
+			"release:latest": {"e2e"},
+		},
+		PrunedBy: map[string][]string{"src": {"src"}},
+	}, {
+		Target:       "unit",
+		CriticalPath: []string{"src", "unit"},
+		Steps:        []string{"src", "unit"},
+		RequiredBy:   map[string][]string{"src": {"unit"}},
+		PrunedBy:     map[string][]string{},
+	}}
+	for _, tc := range []struct {
+		name      string
+		durations map[string]time.Duration
+		expected  string
+	}{{
+		name: "without durations",
+		expected: `Target e2e:
+  Critical path: src -> e2e
+  Steps:
+    src: required by e2e
+    release:latest: required by e2e
+    e2e: required by the target
+  Supplying an image externally would prune:
+    src: src
+
+Target unit:
+  Critical path: src -> unit
+  Steps:
+    src: required by unit
+    unit: required by the target
+`,
+	}, {
+		name:      "with durations",
+		durations: map[string]time.Duration{"src": 10 * time.Minute, "e2e": 80*time.Minute + 100*time.Millisecond},
+		expected: `Target e2e:
+  Critical path (1h30m0s): src (10m0s) -> e2e (1h20m0s)
+  Steps:
+    src (10m0s): required by e2e
+    release:latest: required by e2e
+    e2e (1h20m0s): required by the target
+  Supplying an image externally would prune:
+    src: src
+
+Target unit:
+  Critical path (0s): src (10m0s) -> unit
+  Steps:
+    src (10m0s): required by unit
+    unit: required by the target
+`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual strings.Builder
+			if err := printExplanations(&actual, explanations, tc.durations); err != nil {
+				t.Fatalf("failed to print explanations: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual.String()); diff != "" {
+				t.Errorf("unexpected output: %s", diff)
+			}
+		})
+	}
+}
+
+type fakeDryRunStep struct {
+	fakeValidationStep
+	output steps.DryRunOutput
+}
+
+func (f *fakeDryRunStep) DryRun() (*steps.DryRunOutput, error) { return &f.output, nil }
+
+func TestWriteDryRun(t *testing.T) {
+	if err := addSchemes(); err != nil {
+		t.Fatalf("failed to add schemes: %v", err)
+	}
+	jobSpec := &api.JobSpec{
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+		JobSpec: downwardapi.JobSpec{
+			Job:       "pull-ci-org-repo-master-unit",
+			BuildID:   "1",
+			ProwJobID: "uuid",
+			Type:      prowapi.PresubmitJob,
+			Refs: &prowapi.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				BaseSHA: "base-sha",
+				Pulls:   []prowapi.Pull{{Number: 1, SHA: "pull-sha"}},
+			},
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout:     &prowapi.Duration{Duration: time.Hour},
+				GracePeriod: &prowapi.Duration{Duration: time.Minute},
+				UtilityImages: &prowapi.UtilityImages{
+					Sidecar:    "sidecar",
+					Entrypoint: "entrypoint",
+				},
+			},
+		},
+	}
+	jobSpec.SetNamespace("ci-op-dry-run")
+	unit := steps.TestStep(api.TestStepConfiguration{
+		As:                         "unit",
+		Commands:                   "make test",
+		ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src", Clone: pointer.Bool(false)},
+	}, api.ResourceConfiguration{"*": {Requests: api.ResourceList{"cpu": "100m"}}}, nil, jobSpec, "")
+	stepList := api.OrderedStepList{
+		{Step: &fakeValidationStep{name: "no-dry-run"}},
+		{Step: unit},
+		{Step: &fakeDryRunStep{
+			fakeValidationStep: fakeValidationStep{name: "src"},
+			output: steps.DryRunOutput{Objects: []ctrlruntimeclient.Object{
+				&buildapi.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-dry-run", Name: "src-amd64"}},
+			}},
+		}},
+		{Step: &fakeDryRunStep{
+			fakeValidationStep: fakeValidationStep{name: "e2e"},
+			output: steps.DryRunOutput{
+				Objects: []ctrlruntimeclient.Object{
+					&coreapi.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-dry-run", Name: "e2e"}},
+					&coreapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ci-op-dry-run", Name: "e2e-test"}},
+				},
+				Leases: []api.StepLease{{ResourceType: "aws-quota-slice", Env: "LEASED_RESOURCE", Count: 1}},
+			},
+		}},
+		{Step: &fakeDryRunStep{fakeValidationStep: fakeValidationStep{name: "release:latest"}}},
+	}
+	dir := t.TempDir()
+	if err := writeDryRun(dir, "ci-op-dry-run", stepList); err != nil {
+		t.Fatalf("failed to write dry run: %v", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read dry run directory: %v", err)
+	}
+	written := map[string]string{}
+	for _, file := range files {
+		raw, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file.Name(), err)
+		}
+		written[file.Name()] = string(raw)
+	}
+	testhelper.CompareWithFixture(t, written)
+}
+
+func TestApplyQuarantine(t *testing.T) {
+	list := &quarantine.List{Scopes: map[string]map[string]quarantine.Entry{
+		"org/repo@master": {
+			"flaky": {Reason: "flaky", Expires: time.Now().Add(time.Hour)},
+		},
+	}}
+	suites := func() *junit.TestSuites {
+		return &junit.TestSuites{Suites: []*junit.TestSuite{{
+			Name:      "operator",
+			NumTests:  2,
+			NumFailed: 2,
+			TestCases: []*junit.TestCase{
+				{Name: "flaky", FailureOutput: &junit.FailureOutput{Output: "boom"}},
+				{Name: "broken", FailureOutput: &junit.FailureOutput{Output: "boom"}},
+			},
+		}}}
+	}
+	testCases := []struct {
+		name            string
+		list            *quarantine.List
+		refs            *prowapi.Refs
+		expectedSkipped []string
+	}{
+		{
+			name: "no quarantine list",
+			refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master"},
+		},
+		{
+			name: "no refs",
+			list: list,
+		},
+		{
+			name: "other branch",
+			list: list,
+			refs: &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "release-4.16"},
+		},
+		{
+			name:            "quarantined test is skipped",
+			list:            list,
+			refs:            &prowapi.Refs{Org: "org", Repo: "repo", BaseRef: "master"},
+			expectedSkipped: []string{"flaky"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &options{quarantineList: tc.list, jobSpec: &api.JobSpec{}}
+			o.jobSpec.Refs = tc.refs
+			s := suites()
+			o.applyQuarantine(s)
+			var skipped []string
+			for _, test := range s.Suites[0].TestCases {
+				if test.SkipMessage != nil {
+					skipped = append(skipped, test.Name)
+				}
+			}
+			if diff := cmp.Diff(tc.expectedSkipped, skipped); diff != "" {
+				t.Errorf("unexpected skipped tests: %s", diff)
+			}
+			if expected := uint(2 - len(tc.expectedSkipped)); s.Suites[0].NumFailed != expected {
+				t.Errorf("expected %d failures, got %d", expected, s.Suites[0].NumFailed)
+			}
+		})
+	}
+}
+
+func TestWriteJobRun(t *testing.T) {
+	artifacts := t.TempDir()
+	t.Setenv("ARTIFACTS", artifacts)
+	start := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	hour := time.Hour
+	censor := secrets.NewDynamicCensor()
+	o := &options{
+		censor:  &censor,
+		jobSpec: &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "pull-ci-org-repo-master-e2e"}},
+		configSpec: &api.ReleaseBuildConfiguration{
+			Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+			Tests: []api.TestStepConfiguration{
+				{As: "unit", ContainerTestConfiguration: &api.ContainerTestConfiguration{From: "src"}},
+				{As: "e2e", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{ClusterProfile: api.ClusterProfileAWS}},
+				{As: "skipped", MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{}},
+			},
+		},
+	}
+	o.writeJobRun(start, api.CIOperatorStepGraph{
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "unit", Duration: &hour}},
+		{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "e2e", Duration: &hour}},
+	})
+	raw, err := os.ReadFile(filepath.Join(artifacts, podscaler.JobRunFilename))
+	if err != nil {
+		t.Fatalf("failed to read job run: %v", err)
+	}
+	var run podscaler.JobRun
+	if err := json.Unmarshal(raw, &run); err != nil {
+		t.Fatalf("failed to unmarshal job run: %v", err)
+	}
+	expected := podscaler.JobRun{
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+		Job:      "pull-ci-org-repo-master-e2e",
+		Started:  start,
+		Tests:    []podscaler.TestRun{{Target: "e2e", ClusterProfile: "aws", Duration: time.Hour}},
+	}
+	if diff := cmp.Diff(expected, run); diff != "" {
+		t.Errorf("unexpected job run: %s", diff)
+	}
+}
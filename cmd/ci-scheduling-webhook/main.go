@@ -25,6 +25,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -42,9 +43,15 @@ var (
 	codecs          = serializer.NewCodecFactory(runtime.NewScheme())
 	logger          = log.New(os.Stdout, "http: ", log.LstdFlags)
 
-	shrinkTestCPU  float32
-	shrinkBuildCPU float32
-	prioritization Prioritization
+	shrinkTestCPU      float32
+	shrinkBuildCPU     float32
+	podClassPolicyPath string
+	prioritization     *Prioritization
+
+	snapshotPath         string
+	snapshotInterval     time.Duration
+	snapshotFrames       int
+	simulationOutputPath string
 )
 
 func generateTestCertificate() (*tls.Certificate, error) {
@@ -115,8 +122,21 @@ func Run(_ *cobra.Command, _ []string) {
 		cert = &certP
 	}
 
-	kubeConfigPath, kubeConfigPresent := os.LookupEnv("KUBECONFIG")
 	ctx := context.TODO()
+	clientSet, dynamicClient := newClients()
+	policies := loadPodClassPolicies()
+
+	prioritization = newPrioritization(ctx, clientSet, dynamicClient, policies)
+	err = prioritization.initializePrioritization()
+	if err != nil {
+		klog.Errorf("Error initializing node prioritization processes: %v", err)
+		os.Exit(1)
+	}
+	runWebhookServer(cert)
+}
+
+func newClients() (kubernetes.Interface, dynamic.Interface) {
+	kubeConfigPath, kubeConfigPresent := os.LookupEnv("KUBECONFIG")
 
 	kubeConfig := ""
 	if kubeConfigPresent {
@@ -146,18 +166,71 @@ func Run(_ *cobra.Command, _ []string) {
 		klog.Errorf("Error initializing dynamic client: %v", err)
 		os.Exit(1)
 	}
+	return clientSet, dynamicClient
+}
 
-	prioritization = Prioritization{
-		context:       ctx,
-		k8sClientSet:  clientSet,
-		dynamicClient: dynamicClient,
+func loadPodClassPolicies() *podClassPolicyStore {
+	policies, err := newPodClassPolicyStore(podClassPolicyPath, defaultPodClassPolicy(shrinkTestCPU, shrinkBuildCPU))
+	if err != nil {
+		klog.Errorf("Error loading pod class policy: %v", err)
+		os.Exit(1)
 	}
-	err = prioritization.initializePrioritization()
+	return policies
+}
+
+// RunRecordSnapshot records the node and pod informer state into a snapshot file
+func RunRecordSnapshot(_ *cobra.Command, _ []string) {
+	if snapshotPath == "" {
+		fmt.Println("--snapshot is required")
+		os.Exit(1)
+	}
+
+	clientSet, dynamicClient := newClients()
+	p := newPrioritization(context.TODO(), clientSet, dynamicClient, nil)
+	if err := p.startInformers(); err != nil {
+		klog.Errorf("Error starting informers: %v", err)
+		os.Exit(1)
+	}
+	if err := p.recordSnapshot(snapshotPath, snapshotInterval, snapshotFrames); err != nil {
+		klog.Errorf("Error recording snapshot: %v", err)
+		os.Exit(1)
+	}
+}
+
+// RunSimulate replays a snapshot through the node avoidance and scale down logic
+func RunSimulate(_ *cobra.Command, _ []string) {
+	if snapshotPath == "" {
+		fmt.Println("--snapshot is required")
+		os.Exit(1)
+	}
+
+	file, err := os.Open(snapshotPath)
 	if err != nil {
-		klog.Errorf("Error initializing node prioritization processes: %v", err)
+		klog.Errorf("Error opening snapshot: %v", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+	frames, err := loadSnapshot(file)
+	if err != nil {
+		klog.Errorf("Error loading snapshot: %v", err)
+		os.Exit(1)
+	}
+
+	result := newSimulator(loadPodClassPolicies()).simulate(frames)
+	raw, err := yaml.Marshal(result)
+	if err != nil {
+		klog.Errorf("Error marshalling simulation: %v", err)
+		os.Exit(1)
+	}
+	if simulationOutputPath == "" {
+		_, err = os.Stdout.Write(raw)
+	} else {
+		err = os.WriteFile(simulationOutputPath, raw, 0644)
+	}
+	if err != nil {
+		klog.Errorf("Error writing simulation: %v", err)
 		os.Exit(1)
 	}
-	runWebhookServer(cert)
 }
 
 var rootCmd = &cobra.Command{
@@ -170,6 +243,27 @@ $ ci-scheduling-webhook --tls-cert <tls_cert> --tls-key <tls_key> --port <port>`
 	Run: Run,
 }
 
+var recordSnapshotCmd = &cobra.Command{
+	Use:   "record-snapshot",
+	Short: "Records the node and pod informer state into a snapshot file",
+	Long: `Records the node and pod informer state, and the machines, into a snapshot file at every interval.
+
+Example:
+$ ci-scheduling-webhook record-snapshot --snapshot build01.jsonl --interval 1m --frames 720`,
+	Run: RunRecordSnapshot,
+}
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Replays a snapshot through the node avoidance and scale down logic",
+	Long: `Replays a snapshot through the node avoidance and scale down logic against a fake clientset
+and reports the taint, cordon and scale down decisions over time.
+
+Example:
+$ ci-scheduling-webhook simulate --snapshot build01.jsonl --pod-class-policy policy.yaml`,
+	Run: RunSimulate,
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -183,10 +277,20 @@ func init() {
 	rootCmd.Flags().StringVar(&tlsCertFile, "tls-cert", "", "Certificate for TLS")
 	rootCmd.Flags().StringVar(&tlsKeyFile, "tls-key", "", "Private key file for TLS")
 	rootCmd.Flags().IntVar(&port, "port", 443, "Port to listen on for HTTPS traffic")
-	rootCmd.Flags().StringVar(&impersonateUser, "as", "", "Impersonate a user, like system:admin")
+	rootCmd.PersistentFlags().StringVar(&impersonateUser, "as", "", "Impersonate a user, like system:admin")
+
+	rootCmd.PersistentFlags().Float32Var(&shrinkTestCPU, "shrink-cpu-requests-tests", 1.0, "Multiply test workload CPU requests by this factor")
+	rootCmd.PersistentFlags().Float32Var(&shrinkBuildCPU, "shrink-cpu-requests-builds", 1.0, "Multiply build workload CPU requests by this factor")
+	rootCmd.PersistentFlags().StringVar(&podClassPolicyPath, "pod-class-policy", "", "Path to the pod class policy, which is reloaded when it changes. Defaults to the built-in builds, tests, longtests and prowjobs classes, which the --shrink-cpu-requests-* flags apply to")
+
+	recordSnapshotCmd.Flags().StringVar(&snapshotPath, "snapshot", "", "Path to the snapshot file, frames are appended to it")
+	recordSnapshotCmd.Flags().DurationVar(&snapshotInterval, "interval", time.Minute, "Interval between the frames of the snapshot")
+	recordSnapshotCmd.Flags().IntVar(&snapshotFrames, "frames", 0, "Number of frames to record, 0 records until stopped")
+	rootCmd.AddCommand(recordSnapshotCmd)
 
-	rootCmd.Flags().Float32Var(&shrinkTestCPU, "shrink-cpu-requests-tests", 1.0, "Multiply test workload CPU requests by this factor")
-	rootCmd.Flags().Float32Var(&shrinkBuildCPU, "shrink-cpu-requests-builds", 1.0, "Multiply build workload CPU requests by this factor")
+	simulateCmd.Flags().StringVar(&snapshotPath, "snapshot", "", "Path to the snapshot file to replay")
+	simulateCmd.Flags().StringVar(&simulationOutputPath, "output", "", "Path to write the simulation to, defaults to stdout")
+	rootCmd.AddCommand(simulateCmd)
 }
 
 func runWebhookServer(cert *tls.Certificate) {
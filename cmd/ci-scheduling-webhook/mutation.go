@@ -30,8 +30,6 @@ var (
 		"ocp":             true,
 		"cert-manager":    true,
 	}
-	memoryThreshold = resource.MustParse("32Gi")
-	cpuThreshold    = resource.MustParse("13")
 )
 
 func admissionReviewFromRequest(r *http.Request, deserializer runtime.Decoder) (*admissionv1.AdmissionReview, error) {
@@ -145,63 +143,18 @@ func mutatePod(w http.ResponseWriter, r *http.Request) {
 		addPatchEntry("add", "/metadata/annotations", annotations)
 	}
 
-	if namespace == CiNamepsace {
-		if _, ok := pod.Labels[CiCreatedByProwLabelName]; ok {
-			// if we are in 'ci' and created by prow, this the direct prowjob pod. Treat as test.
-			podClass = PodClassProwJobs
-		}
-	}
-
 	labels := pod.Labels
 	if labels == nil {
 		labels = make(map[string]string, 0)
 	}
 
-	if strings.HasPrefix(namespace, "ci-op-") || strings.HasPrefix(namespace, "ci-ln-") {
-		skipPod := false // certain pods with special resources we can schedule onto our workload sets
-
-		checkForSpecialContainers := func(containers []corev1.Container) {
-			for i := range containers {
-				c := &containers[i]
-				for key := range c.Resources.Requests {
-					if key != corev1.ResourceCPU && key != corev1.ResourceMemory && key != corev1.ResourceEphemeralStorage {
-						// There is a special resource required - avoid trying to schedule it with build/test machinesets
-						skipPod = true
-					}
-				}
-			}
-		}
-
-		checkForSpecialContainers(pod.Spec.InitContainers)
-		checkForSpecialContainers(pod.Spec.Containers)
-
-		if !skipPod {
-			if _, ok := labels[CiBuildNameLabelName]; ok {
-				podClass = PodClassBuilds
-			} else {
-				podClass = PodClassTests
-			}
-		}
+	class := prioritization.policies.current().classify(&pod, namespace, podName)
+	if class != nil {
+		podClass = class.Name
 	}
 
 	klog.Infof("Pod %s in namespace %s is classified as %s", podName, namespace, podClass)
-	if podClass == PodClassTests {
-		// Segmenting long run tests onto their own node set helps normal tests nodes scale down
-		// more effectively.
-		if strings.HasPrefix(podName, "release-images-") ||
-			strings.HasPrefix(podName, "release-analysis-aggregator-") ||
-			strings.HasPrefix(podName, "e2e-aws-upgrade") ||
-			strings.HasPrefix(podName, "rpm-repo") ||
-			strings.HasPrefix(podName, "osde2e-stage") ||
-			strings.HasPrefix(podName, "e2e-aws-cnv") ||
-			strings.Contains(podName, "ovn-upgrade-ipi") ||
-			strings.Contains(podName, "ovn-upgrade-ovn") ||
-			strings.Contains(podName, "ovn-upgrade-openshift-e2e-test") {
-			podClass = PodClassLongTests
-		}
-	}
-
-	if podClass != PodClassNone {
+	if class != nil {
 		profile("classified request")
 
 		// Setup labels we might want to use in the future to set pod affinity
@@ -248,19 +201,20 @@ func mutatePod(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		var cpuFactor float32
-		if podClass == PodClassTests {
-			cpuFactor = shrinkTestCPU
-		} else {
-			cpuFactor = shrinkBuildCPU
-		}
-		reduceCPURequests("initContainers", pod.Spec.InitContainers, cpuFactor)
-		reduceCPURequests("containers", pod.Spec.Containers, cpuFactor)
+		reduceCPURequests("initContainers", pod.Spec.InitContainers, class.CPURequestFactor)
+		reduceCPURequests("containers", pod.Spec.Containers, class.CPURequestFactor)
 
 		// Setup toleration appropriate for podClass so that it can only land on desired machineset.
 		// This is achieved by virtue of using a RuntimeClass object which specifies the necessary
 		// tolerations for each workload.
-		addPatchEntry("add", "/spec/runtimeClassName", "ci-scheduler-runtime-"+podClass)
+		addPatchEntry("add", "/spec/runtimeClassName", class.RuntimeClassName)
+
+		// Tolerations which are not part of the RuntimeClass can be declared by the policy.
+		// The webhook can be reinvoked, so only add the ones which are missing.
+		if tolerations, added := addMissingTolerations(pod.Spec.Tolerations, class.Tolerations); added {
+			pod.Spec.Tolerations = tolerations
+			addPatchEntry("add", "/spec/tolerations", tolerations)
+		}
 
 		// Set a nodeSelector to ensure this finds our desired machineset nodes
 		nodeSelector := pod.Spec.NodeSelector
@@ -305,15 +259,18 @@ func mutatePod(w http.ResponseWriter, r *http.Request) {
 		}
 
 		highPerfPod := false
-		if podClass == PodClassBuilds {
+		if class.HighPerformance != nil {
 			// Use high performance nodes for large pods
 			for _, container := range pod.Spec.Containers {
-				if container.Resources.Requests.Memory().Cmp(memoryThreshold) >= 0 || container.Resources.Requests.Cpu().Cmp(cpuThreshold) >= 0 {
+				if container.Resources.Requests.Memory().Cmp(class.HighPerformance.Memory) >= 0 || container.Resources.Requests.Cpu().Cmp(class.HighPerformance.CPU) >= 0 {
 					klog.Infof("Pod %s in namespace %s requests high performance node", podName, namespace)
 					highPerfPod = true
 				}
 			}
-			// If this is a build pod, prefer to be scheduled to spot instances for cost efficiency.
+		}
+
+		if class.PreferSpotInstances {
+			// Prefer to be scheduled to spot instances for cost efficiency, e.g. for builds.
 			// If there are no spot instances, this will be ignored.
 			affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = []corev1.PreferredSchedulingTerm{
 				{
@@ -468,6 +425,46 @@ func patchHighPerfPod(pod *corev1.Pod, podName, namespace string, addPatchEntry
 	addPatchEntry("replace", "/spec/nodeSelector", pod.Spec.NodeSelector)
 }
 
+// addMissingTolerations returns the tolerations with the desired ones which are not
+// tolerated yet and whether any were added
+func addMissingTolerations(tolerations, desired []corev1.Toleration) ([]corev1.Toleration, bool) {
+	added := false
+	for i := range desired {
+		found := false
+		for j := range tolerations {
+			if tolerations[j].MatchToleration(&desired[i]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			tolerations = append(tolerations, desired[i])
+			added = true
+		}
+	}
+	return tolerations, added
+}
+
+// addMissingTaints returns the taints with the desired ones which are not set yet and
+// whether any were added
+func addMissingTaints(taints, desired []corev1.Taint) ([]corev1.Taint, bool) {
+	added := false
+	for i := range desired {
+		found := false
+		for j := range taints {
+			if taints[j].MatchTaint(&desired[i]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			taints = append(taints, desired[i])
+			added = true
+		}
+	}
+	return taints, added
+}
+
 func mutateNode(admissionReviewRequest *admissionv1.AdmissionReview, w http.ResponseWriter) {
 	start := time.Now()
 	lastProfileTime := &start
@@ -532,10 +529,11 @@ func mutateNode(admissionReviewRequest *admissionv1.AdmissionReview, w http.Resp
 		}
 	}
 
-	if podClass != PodClassNone {
+	class := prioritization.policies.current().class(podClass)
+	if class != nil {
 		profile("classified request")
 
-		if _, ok := node.Annotations[NodeDisableScaleDownAnnotationKey]; !ok {
+		if _, ok := node.Annotations[NodeDisableScaleDownAnnotationKey]; !ok && !class.ScaleDown.Disabled {
 			// If this webhook owns this class of node, then we own its scale down in order to prevent
 			// contention with the autoscaler. Ideally, we would apply this annotation declaratively
 			// in the machineset, but it doesn't appear to support annotations. Instead,
@@ -544,6 +542,11 @@ func mutateNode(admissionReviewRequest *admissionv1.AdmissionReview, w http.Resp
 			escapedKey := strings.ReplaceAll(NodeDisableScaleDownAnnotationKey, "/", "~1")
 			addPatchEntry("add", "/metadata/annotations/"+escapedKey, "true")
 		}
+
+		// Taints declared by the policy keep other workloads off the nodes of the class.
+		if taints, added := addMissingTaints(node.Spec.Taints, class.NodeTaints); added {
+			addPatchEntry("add", "/spec/taints", taints)
+		}
 	}
 
 	admissionResponse := &admissionv1.AdmissionResponse{}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// policyReloadInterval is how often the policy file is checked for changes
+	policyReloadInterval = 30 * time.Second
+
+	defaultMinNodeAge               = 15 * time.Minute
+	defaultAvoidanceFraction        = 0.25
+	defaultPrecludedNodes           = 1
+	defaultTerminatedPodGracePeriod = 5 * time.Minute
+)
+
+// PodClassPolicy declares the pod classes of the webhook: which pods belong to each
+// class, how they are scheduled onto the nodes of the class and how those nodes are
+// scaled down. A pod belongs to the first class which matches it.
+type PodClassPolicy struct {
+	Classes []PodClassSpec `json:"classes"`
+}
+
+// PodClassSpec describes a single pod class
+type PodClassSpec struct {
+	// Name is the value of the ci-workload label on the pods and nodes of the class
+	Name PodClass `json:"name"`
+	// Match decides which pods belong to the class
+	Match PodClassMatch `json:"match"`
+	// RuntimeClassName is set on the pods of the class. The RuntimeClass carries the
+	// overhead and the tolerations for the nodes of the class. Defaults to
+	// ci-scheduler-runtime-<name>.
+	RuntimeClassName string `json:"runtimeClassName,omitempty"`
+	// Tolerations are added to the pods of the class
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// NodeTaints are added to the nodes of the class when they are admitted
+	NodeTaints []corev1.Taint `json:"nodeTaints,omitempty"`
+	// CPURequestFactor multiplies the CPU requests of the pods of the class. Factors
+	// of 1 and above leave the requests alone. Defaults to 1.
+	CPURequestFactor float32 `json:"cpuRequestFactor,omitempty"`
+	// PreferSpotInstances makes the pods of the class prefer spot instances
+	PreferSpotInstances bool `json:"preferSpotInstances,omitempty"`
+	// HighPerformance sends the pods of the class which request at least the given
+	// resources to the high performance nodes
+	HighPerformance *HighPerformanceThresholds `json:"highPerformance,omitempty"`
+	// ScaleDown configures how the nodes of the class are scaled down
+	ScaleDown ScaleDownPolicy `json:"scaleDown,omitempty"`
+}
+
+// PodClassMatch matches pods by their namespace, labels, owners, names and requested
+// resources. Every criterion which is set has to match.
+type PodClassMatch struct {
+	// Namespaces matches the pods in any of the namespaces
+	Namespaces []string `json:"namespaces,omitempty"`
+	// NamespacePrefixes matches the pods in namespaces starting with any of the prefixes
+	NamespacePrefixes []string `json:"namespacePrefixes,omitempty"`
+	// LabelSelector matches the labels of the pods
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// OwnerKinds matches the pods owned by any of the kinds
+	OwnerKinds []string `json:"ownerKinds,omitempty"`
+	// NamePrefixes and NameSubstrings match the pods with a name starting with any of
+	// the prefixes or containing any of the substrings
+	NamePrefixes   []string `json:"namePrefixes,omitempty"`
+	NameSubstrings []string `json:"nameSubstrings,omitempty"`
+	// ExtendedResources matches the pods requesting any of the resources, like GPUs
+	ExtendedResources []corev1.ResourceName `json:"extendedResources,omitempty"`
+	// StandardResourcesOnly matches the pods which request nothing but cpu, memory
+	// and ephemeral storage
+	StandardResourcesOnly bool `json:"standardResourcesOnly,omitempty"`
+
+	selector labels.Selector
+}
+
+// HighPerformanceThresholds are the requests of a single container above which a pod
+// needs a high performance node
+type HighPerformanceThresholds struct {
+	Memory resource.Quantity `json:"memory"`
+	CPU    resource.Quantity `json:"cpu"`
+}
+
+// ScaleDownPolicy configures how the webhook scales down the nodes of a class
+type ScaleDownPolicy struct {
+	// Disabled leaves the scale down of the nodes of the class to the autoscaler
+	Disabled bool `json:"disabled,omitempty"`
+	// MinNodeAge is how old nodes have to be before they are considered for scale
+	// down. Defaults to 15m.
+	MinNodeAge metav1.Duration `json:"minNodeAge,omitempty"`
+	// AvoidanceFraction is the fraction of the nodes of the class which pods are
+	// steered away from. Defaults to 0.25, 0 disables avoidance.
+	AvoidanceFraction *float64 `json:"avoidanceFraction,omitempty"`
+	// PrecludedNodes is how many of the nodes most likely to be scaled down next are
+	// precluded from new pods. Defaults to 1, 0 disables preclusion.
+	PrecludedNodes *int `json:"precludedNodes,omitempty"`
+	// TerminatedPodGracePeriod is how long terminated pods keep a node from being
+	// scaled down, so that their status and logs can be collected. Defaults to 5m.
+	TerminatedPodGracePeriod metav1.Duration `json:"terminatedPodGracePeriod,omitempty"`
+}
+
+// defaultPodClassPolicy returns the policy used when no policy file is given. It
+// classifies the pods into builds, tests, long tests and prowjobs.
+func defaultPodClassPolicy(shrinkTestCPU, shrinkBuildCPU float32) *PodClassPolicy {
+	ciOperatorNamespacePrefixes := []string{"ci-op-", "ci-ln-"}
+	return &PodClassPolicy{
+		Classes: []PodClassSpec{
+			{
+				// if we are in 'ci' and created by prow, this the direct prowjob pod.
+				Name: PodClassProwJobs,
+				Match: PodClassMatch{
+					Namespaces: []string{CiNamepsace},
+					LabelSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: CiCreatedByProwLabelName, Operator: metav1.LabelSelectorOpExists}},
+					},
+				},
+				CPURequestFactor: shrinkBuildCPU,
+			},
+			{
+				Name: PodClassBuilds,
+				Match: PodClassMatch{
+					NamespacePrefixes: ciOperatorNamespacePrefixes,
+					LabelSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: CiBuildNameLabelName, Operator: metav1.LabelSelectorOpExists}},
+					},
+					StandardResourcesOnly: true,
+				},
+				CPURequestFactor:    shrinkBuildCPU,
+				PreferSpotInstances: true,
+				HighPerformance: &HighPerformanceThresholds{
+					Memory: resource.MustParse("32Gi"),
+					CPU:    resource.MustParse("13"),
+				},
+			},
+			{
+				// Segmenting long run tests onto their own node set helps normal tests nodes scale down
+				// more effectively.
+				Name: PodClassLongTests,
+				Match: PodClassMatch{
+					NamespacePrefixes: ciOperatorNamespacePrefixes,
+					NamePrefixes: []string{
+						"release-images-",
+						"release-analysis-aggregator-",
+						"e2e-aws-upgrade",
+						"rpm-repo",
+						"osde2e-stage",
+						"e2e-aws-cnv",
+					},
+					NameSubstrings: []string{
+						"ovn-upgrade-ipi",
+						"ovn-upgrade-ovn",
+						"ovn-upgrade-openshift-e2e-test",
+					},
+					StandardResourcesOnly: true,
+				},
+				CPURequestFactor: shrinkBuildCPU,
+			},
+			{
+				Name: PodClassTests,
+				Match: PodClassMatch{
+					NamespacePrefixes:     ciOperatorNamespacePrefixes,
+					StandardResourcesOnly: true,
+				},
+				CPURequestFactor: shrinkTestCPU,
+			},
+		},
+	}
+}
+
+// loadPodClassPolicy parses, defaults and validates a policy
+func loadPodClassPolicy(raw []byte) (*PodClassPolicy, error) {
+	policy := &PodClassPolicy{}
+	if err := yaml.UnmarshalStrict(raw, policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the pod class policy: %w", err)
+	}
+	if err := policy.complete(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// complete defaults the policy and validates it
+func (p *PodClassPolicy) complete() error {
+	var errs []error
+	seen := sets.New[PodClass]()
+	for i := range p.Classes {
+		class := &p.Classes[i]
+		if class.Name == PodClassNone {
+			errs = append(errs, fmt.Errorf("classes[%d]: name must be set", i))
+			continue
+		}
+		if seen.Has(class.Name) {
+			errs = append(errs, fmt.Errorf("classes[%d]: duplicate class %s", i, class.Name))
+		}
+		seen.Insert(class.Name)
+		for _, msg := range validation.IsValidLabelValue(string(class.Name)) {
+			errs = append(errs, fmt.Errorf("class %s: invalid name: %s", class.Name, msg))
+		}
+		if err := class.complete(); err != nil {
+			errs = append(errs, fmt.Errorf("class %s: %w", class.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *PodClassSpec) complete() error {
+	var errs []error
+	if c.Match.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(c.Match.LabelSelector)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid label selector: %w", err))
+		}
+		c.Match.selector = selector
+	}
+	if c.Match.StandardResourcesOnly && len(c.Match.ExtendedResources) > 0 {
+		errs = append(errs, fmt.Errorf("standardResourcesOnly and extendedResources are mutually exclusive"))
+	}
+	if c.RuntimeClassName == "" {
+		c.RuntimeClassName = "ci-scheduler-runtime-" + string(c.Name)
+	}
+	if c.CPURequestFactor < 0 {
+		errs = append(errs, fmt.Errorf("cpuRequestFactor must not be negative, got %v", c.CPURequestFactor))
+	}
+	if c.CPURequestFactor == 0 {
+		c.CPURequestFactor = 1
+	}
+	if c.HighPerformance != nil && (c.HighPerformance.Memory.IsZero() || c.HighPerformance.CPU.IsZero()) {
+		// a zero threshold would send every pod of the class to the high performance nodes
+		errs = append(errs, fmt.Errorf("highPerformance must set both memory and cpu"))
+	}
+
+	scaleDown := &c.ScaleDown
+	if scaleDown.MinNodeAge.Duration < 0 {
+		errs = append(errs, fmt.Errorf("scaleDown.minNodeAge must not be negative, got %v", scaleDown.MinNodeAge.Duration))
+	}
+	if scaleDown.MinNodeAge.Duration == 0 {
+		scaleDown.MinNodeAge.Duration = defaultMinNodeAge
+	}
+	if scaleDown.AvoidanceFraction == nil {
+		avoidanceFraction := defaultAvoidanceFraction
+		scaleDown.AvoidanceFraction = &avoidanceFraction
+	} else if *scaleDown.AvoidanceFraction < 0 || *scaleDown.AvoidanceFraction > 1 {
+		errs = append(errs, fmt.Errorf("scaleDown.avoidanceFraction must be between 0 and 1, got %v", *scaleDown.AvoidanceFraction))
+	}
+	if scaleDown.PrecludedNodes == nil {
+		precludedNodes := defaultPrecludedNodes
+		scaleDown.PrecludedNodes = &precludedNodes
+	} else if *scaleDown.PrecludedNodes < 0 {
+		errs = append(errs, fmt.Errorf("scaleDown.precludedNodes must not be negative, got %d", *scaleDown.PrecludedNodes))
+	}
+	if scaleDown.TerminatedPodGracePeriod.Duration < 0 {
+		errs = append(errs, fmt.Errorf("scaleDown.terminatedPodGracePeriod must not be negative, got %v", scaleDown.TerminatedPodGracePeriod.Duration))
+	}
+	if scaleDown.TerminatedPodGracePeriod.Duration == 0 {
+		scaleDown.TerminatedPodGracePeriod.Duration = defaultTerminatedPodGracePeriod
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// class returns the spec of the pod class, or nil if the policy does not declare it
+func (p *PodClassPolicy) class(podClass PodClass) *PodClassSpec {
+	for i := range p.Classes {
+		if p.Classes[i].Name == podClass {
+			return &p.Classes[i]
+		}
+	}
+	return nil
+}
+
+// classify returns the first class which matches the pod, or nil if none does. The name
+// and namespace are passed separately as they may not be set on pods which are created.
+func (p *PodClassPolicy) classify(pod *corev1.Pod, namespace, name string) *PodClassSpec {
+	for i := range p.Classes {
+		if p.Classes[i].Match.matches(pod, namespace, name) {
+			return &p.Classes[i]
+		}
+	}
+	return nil
+}
+
+func (m *PodClassMatch) matches(pod *corev1.Pod, namespace, name string) bool {
+	if len(m.Namespaces) > 0 && !sets.New(m.Namespaces...).Has(namespace) {
+		return false
+	}
+	if len(m.NamespacePrefixes) > 0 && !hasAnyPrefix(namespace, m.NamespacePrefixes) {
+		return false
+	}
+	if m.selector != nil && !m.selector.Matches(labels.Set(pod.Labels)) {
+		return false
+	}
+	if len(m.OwnerKinds) > 0 {
+		kinds := sets.New(m.OwnerKinds...)
+		owned := false
+		for _, owner := range pod.OwnerReferences {
+			if kinds.Has(owner.Kind) {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			return false
+		}
+	}
+	if len(m.NamePrefixes) > 0 || len(m.NameSubstrings) > 0 {
+		if !hasAnyPrefix(name, m.NamePrefixes) && !containsAny(name, m.NameSubstrings) {
+			return false
+		}
+	}
+	requested := requestedResources(pod)
+	if len(m.ExtendedResources) > 0 && !requested.HasAny(m.ExtendedResources...) {
+		return false
+	}
+	if m.StandardResourcesOnly && requested.Delete(corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage).Len() > 0 {
+		// There is a special resource required - avoid trying to schedule it with build/test machinesets
+		return false
+	}
+	return true
+}
+
+func requestedResources(pod *corev1.Pod) sets.Set[corev1.ResourceName] {
+	requested := sets.New[corev1.ResourceName]()
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range containers {
+			for key := range containers[i].Resources.Requests {
+				requested.Insert(key)
+			}
+		}
+	}
+	return requested
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// podClassPolicyStore holds the current policy. When it is backed by a file, the
+// policy is reloaded whenever the file changes. A policy which fails to load is
+// reported and the previous one stays in effect.
+type podClassPolicyStore struct {
+	path   string
+	lock   sync.RWMutex
+	policy *PodClassPolicy
+	raw    []byte
+}
+
+// newPodClassPolicyStore loads the policy from the path, or uses the fallback policy
+// if the path is empty
+func newPodClassPolicyStore(path string, fallback *PodClassPolicy) (*podClassPolicyStore, error) {
+	store := &podClassPolicyStore{path: path}
+	if path == "" {
+		if err := fallback.complete(); err != nil {
+			return nil, fmt.Errorf("invalid default pod class policy: %w", err)
+		}
+		store.policy = fallback
+		return store, nil
+	}
+	if _, err := store.reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *podClassPolicyStore) current() *PodClassPolicy {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.policy
+}
+
+// reload loads the policy file if it changed and reports whether it did
+func (s *podClassPolicyStore) reload() (bool, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read the pod class policy %s: %w", s.path, err)
+	}
+	s.lock.RLock()
+	unchanged := s.policy != nil && bytes.Equal(raw, s.raw)
+	s.lock.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	policy, err := loadPodClassPolicy(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid pod class policy %s: %w", s.path, err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.policy = policy
+	s.raw = raw
+	return true, nil
+}
+
+// pollForChanges reloads the policy file periodically. The file is polled rather than
+// watched because ConfigMap volumes are updated by swapping symlinks.
+func (s *podClassPolicyStore) pollForChanges() {
+	if s.path == "" {
+		return
+	}
+	for range time.Tick(policyReloadInterval) {
+		changed, err := s.reload()
+		if err != nil {
+			klog.Errorf("Unable to reload the pod class policy, keeping the previous one: %v", err)
+			continue
+		}
+		if changed {
+			var names []string
+			for _, class := range s.current().Classes {
+				names = append(names, string(class.Name))
+			}
+			klog.Infof("Reloaded the pod class policy from %v with classes: %v", s.path, names)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+var policyCmpOpts = []cmp.Option{
+	cmpopts.IgnoreUnexported(PodClassMatch{}),
+	cmp.Comparer(func(a, b resource.Quantity) bool { return a.Cmp(b) == 0 }),
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestLoadPodClassPolicy(t *testing.T) {
+	testCases := []struct {
+		name          string
+		raw           string
+		expected      *PodClassPolicy
+		expectedError error
+	}{
+		{
+			name: "a gpu class is defaulted",
+			raw: `classes:
+- name: gpu
+  match:
+    namespacePrefixes: [ci-op-]
+    extendedResources: [nvidia.com/gpu]
+  tolerations:
+  - key: nvidia.com/gpu
+    operator: Exists
+    effect: NoSchedule
+  nodeTaints:
+  - key: nvidia.com/gpu
+    effect: NoSchedule
+  highPerformance:
+    memory: 64Gi
+    cpu: "30"
+  scaleDown:
+    minNodeAge: 30m
+    precludedNodes: 0
+`,
+			expected: &PodClassPolicy{Classes: []PodClassSpec{
+				{
+					Name: "gpu",
+					Match: PodClassMatch{
+						NamespacePrefixes: []string{"ci-op-"},
+						ExtendedResources: []corev1.ResourceName{"nvidia.com/gpu"},
+					},
+					RuntimeClassName: "ci-scheduler-runtime-gpu",
+					Tolerations:      []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+					NodeTaints:       []corev1.Taint{{Key: "nvidia.com/gpu", Effect: corev1.TaintEffectNoSchedule}},
+					CPURequestFactor: 1,
+					HighPerformance:  &HighPerformanceThresholds{Memory: resource.MustParse("64Gi"), CPU: resource.MustParse("30")},
+					ScaleDown: ScaleDownPolicy{
+						MinNodeAge:               metav1.Duration{Duration: 30 * time.Minute},
+						AvoidanceFraction:        floatPtr(0.25),
+						PrecludedNodes:           intPtr(0),
+						TerminatedPodGracePeriod: metav1.Duration{Duration: 5 * time.Minute},
+					},
+				},
+			}},
+		},
+		{
+			name: "avoidance can be disabled",
+			raw: `classes:
+- name: tests
+  match:
+    namespacePrefixes: [ci-op-]
+  scaleDown:
+    avoidanceFraction: 0
+`,
+			expected: &PodClassPolicy{Classes: []PodClassSpec{
+				{
+					Name:             "tests",
+					Match:            PodClassMatch{NamespacePrefixes: []string{"ci-op-"}},
+					RuntimeClassName: "ci-scheduler-runtime-tests",
+					CPURequestFactor: 1,
+					ScaleDown: ScaleDownPolicy{
+						MinNodeAge:               metav1.Duration{Duration: 15 * time.Minute},
+						AvoidanceFraction:        floatPtr(0),
+						PrecludedNodes:           intPtr(1),
+						TerminatedPodGracePeriod: metav1.Duration{Duration: 5 * time.Minute},
+					},
+				},
+			}},
+		},
+		{
+			name:          "unknown fields are rejected",
+			raw:           "classes:\n- name: gpu\n  runtimeClass: gpu\n",
+			expectedError: errors.New(`failed to unmarshal the pod class policy: error unmarshaling JSON: while decoding JSON: json: unknown field "runtimeClass"`),
+		},
+		{
+			name: "invalid classes are rejected",
+			raw: `classes:
+- match:
+    namespaces: [ci]
+- name: tests
+- name: tests
+  cpuRequestFactor: -1
+- name: high/mem
+- name: broken
+  match:
+    labelSelector:
+      matchExpressions:
+      - key: app
+        operator: Within
+    extendedResources: [nvidia.com/gpu]
+    standardResourcesOnly: true
+  highPerformance:
+    memory: 64Gi
+  scaleDown:
+    avoidanceFraction: 2
+    precludedNodes: -1
+`,
+			expectedError: errors.New("[classes[0]: name must be set, classes[2]: duplicate class tests, class tests: cpuRequestFactor must not be negative, got -1, " +
+				"class high/mem: invalid name: a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?'), " +
+				"class broken: [invalid label selector: \"Within\" is not a valid label selector operator, standardResourcesOnly and extendedResources are mutually exclusive, highPerformance must set both memory and cpu, scaleDown.avoidanceFraction must be between 0 and 1, got 2, scaleDown.precludedNodes must not be negative, got -1]]"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := loadPodClassPolicy([]byte(tc.raw))
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("%s: error does not match expected, diff: %s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual, policyCmpOpts...); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	policy := defaultPodClassPolicy(0.5, 1)
+	gpuClass := PodClassSpec{
+		Name: "gpu",
+		Match: PodClassMatch{
+			NamespacePrefixes: []string{"ci-op-"},
+			OwnerKinds:        []string{"Pod"},
+			ExtendedResources: []corev1.ResourceName{"nvidia.com/gpu"},
+		},
+	}
+	policy.Classes = append([]PodClassSpec{gpuClass}, policy.Classes...)
+	if err := policy.complete(); err != nil {
+		t.Fatalf("failed to complete the policy: %v", err)
+	}
+
+	requesting := func(resources ...corev1.ResourceName) corev1.PodSpec {
+		requests := corev1.ResourceList{}
+		for _, name := range resources {
+			requests[name] = resource.MustParse("1")
+		}
+		return corev1.PodSpec{Containers: []corev1.Container{{Resources: corev1.ResourceRequirements{Requests: requests}}}}
+	}
+	testCases := []struct {
+		name      string
+		namespace string
+		podName   string
+		pod       corev1.Pod
+		expected  PodClass
+	}{
+		{
+			name:      "prowjob pod",
+			namespace: "ci",
+			podName:   "5b1ee1a6-4f4c-11ee-a8f3-0a580a80028b",
+			pod:       corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{CiCreatedByProwLabelName: "true"}}},
+			expected:  PodClassProwJobs,
+		},
+		{
+			name:      "pod in ci not created by prow",
+			namespace: "ci",
+			podName:   "deck-7d9f8c",
+			expected:  PodClassNone,
+		},
+		{
+			name:      "build pod",
+			namespace: "ci-op-1234",
+			podName:   "src-build",
+			pod:       corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{CiBuildNameLabelName: "src"}}, Spec: requesting(corev1.ResourceCPU, corev1.ResourceMemory)},
+			expected:  PodClassBuilds,
+		},
+		{
+			name:      "build pod with a long test name stays a build",
+			namespace: "ci-op-1234",
+			podName:   "release-images-build",
+			pod:       corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{CiBuildNameLabelName: "release-images"}}},
+			expected:  PodClassBuilds,
+		},
+		{
+			name:      "test pod",
+			namespace: "ci-ln-abcd",
+			podName:   "unit",
+			pod:       corev1.Pod{Spec: requesting(corev1.ResourceCPU, corev1.ResourceEphemeralStorage)},
+			expected:  PodClassTests,
+		},
+		{
+			name:      "long test by prefix",
+			namespace: "ci-op-1234",
+			podName:   "e2e-aws-upgrade-openshift-e2e-test",
+			expected:  PodClassLongTests,
+		},
+		{
+			name:      "long test by substring",
+			namespace: "ci-op-1234",
+			podName:   "e2e-gcp-ovn-upgrade-ovn-gather",
+			expected:  PodClassLongTests,
+		},
+		{
+			name:      "pod requesting special resources is not classified",
+			namespace: "ci-op-1234",
+			podName:   "unit",
+			pod:       corev1.Pod{Spec: requesting(corev1.ResourceCPU, "devices.kubevirt.io/kvm")},
+			expected:  PodClassNone,
+		},
+		{
+			name:      "gpu pod owned by a pod",
+			namespace: "ci-op-1234",
+			podName:   "e2e-gpu",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "Pod", Name: "e2e"}}},
+				Spec:       requesting(corev1.ResourceCPU, "nvidia.com/gpu"),
+			},
+			expected: "gpu",
+		},
+		{
+			name:      "gpu pod without a matching owner",
+			namespace: "ci-op-1234",
+			podName:   "e2e-gpu",
+			pod:       corev1.Pod{Spec: requesting(corev1.ResourceCPU, "nvidia.com/gpu")},
+			expected:  PodClassNone,
+		},
+		{
+			name:      "pod in another namespace",
+			namespace: "openshift-monitoring",
+			podName:   "prometheus-k8s-0",
+			expected:  PodClassNone,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := PodClassNone
+			if class := policy.classify(&tc.pod, tc.namespace, tc.podName); class != nil {
+				actual = class.Name
+			}
+			if actual != tc.expected {
+				t.Errorf("%s: expected class %q, got %q", tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDefaultPodClassPolicy(t *testing.T) {
+	policy := defaultPodClassPolicy(0.5, 0.8)
+	if err := policy.complete(); err != nil {
+		t.Fatalf("the default policy is invalid: %v", err)
+	}
+	expected := map[PodClass]float32{PodClassProwJobs: 0.8, PodClassBuilds: 0.8, PodClassLongTests: 0.8, PodClassTests: 0.5}
+	actual := map[PodClass]float32{}
+	for _, class := range policy.Classes {
+		actual[class.Name] = class.CPURequestFactor
+		if expected := "ci-scheduler-runtime-" + string(class.Name); class.RuntimeClassName != expected {
+			t.Errorf("class %s: expected runtime class %s, got %s", class.Name, expected, class.RuntimeClassName)
+		}
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("CPU request factors do not match expected, diff: %s", diff)
+	}
+}
+
+func TestPodClassPolicyStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	write := func(raw string) {
+		if err := os.WriteFile(path, []byte(raw), 0644); err != nil {
+			t.Fatalf("failed to write the policy: %v", err)
+		}
+	}
+	classes := func(store *podClassPolicyStore) []PodClass {
+		var names []PodClass
+		for _, class := range store.current().Classes {
+			names = append(names, class.Name)
+		}
+		return names
+	}
+
+	write("classes:\n- name: tests\n")
+	store, err := newPodClassPolicyStore(path, nil)
+	if err != nil {
+		t.Fatalf("failed to create the store: %v", err)
+	}
+
+	if changed, err := store.reload(); err != nil || changed {
+		t.Errorf("expected an unchanged policy without an error, got changed %t, error %v", changed, err)
+	}
+
+	write("classes:\n- name: tests\n- name: highmem\n")
+	if changed, err := store.reload(); err != nil || !changed {
+		t.Errorf("expected a changed policy without an error, got changed %t, error %v", changed, err)
+	}
+	if diff := cmp.Diff([]PodClass{"tests", "highmem"}, classes(store)); diff != "" {
+		t.Errorf("classes after reload do not match expected, diff: %s", diff)
+	}
+
+	write("classes:\n- name: tests\n- name: tests\n")
+	if _, err := store.reload(); err == nil {
+		t.Error("expected an error for an invalid policy")
+	}
+	if diff := cmp.Diff([]PodClass{"tests", "highmem"}, classes(store)); diff != "" {
+		t.Errorf("an invalid policy replaced the previous one, diff: %s", diff)
+	}
+
+	fallback, err := newPodClassPolicyStore("", defaultPodClassPolicy(1, 1))
+	if err != nil {
+		t.Fatalf("failed to create the store with the default policy: %v", err)
+	}
+	if diff := cmp.Diff([]PodClass{PodClassProwJobs, PodClassBuilds, PodClassLongTests, PodClassTests}, classes(fallback)); diff != "" {
+		t.Errorf("default classes do not match expected, diff: %s", diff)
+	}
+}
@@ -19,12 +19,14 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/util/taints"
+	"k8s.io/utils/clock"
 )
 
 type PodClass string
@@ -61,40 +63,112 @@ const (
 )
 
 var (
-	nodesInformer      cache.SharedIndexInformer
-	podsInformer       cache.SharedIndexInformer
 	machineSetResource = schema.GroupVersionResource{Group: "machine.openshift.io", Version: "v1beta1", Resource: "machinesets"}
 	machineResource    = schema.GroupVersionResource{Group: "machine.openshift.io", Version: "v1beta1", Resource: "machines"}
 
-	// If a node name exists in this map, scale down operations are being attempted for it.
-	scalingDownNodesByClass = map[PodClass]*sync.Map{
-		PodClassBuilds:    {},
-		PodClassTests:     {},
-		PodClassLongTests: {},
-		PodClassProwJobs:  {},
-	}
-	scalingDownAddLock sync.Mutex
+	// Maps pod classes to maps of node names. If a node name exists in the map of its class,
+	// scale down operations are being attempted for it.
+	scalingDownNodesByClass sync.Map
+	scalingDownAddLock      sync.Mutex
 
-	// Locks used to make sure access to machineset and other races are prevented for scale down operations.
-	nodeClassScaleDownLock = map[PodClass]*sync.Mutex{
-		PodClassBuilds:    {},
-		PodClassTests:     {},
-		PodClassLongTests: {},
-		PodClassProwJobs:  {},
-	}
+	// Maps pod classes to locks used to make sure access to machineset and other races are
+	// prevented for scale down operations.
+	nodeClassScaleDownLock sync.Map
 
 	nodeAvoidanceLock sync.Mutex
 )
 
 type Prioritization struct {
 	context       context.Context
-	k8sClientSet  *kubernetes.Clientset
+	k8sClientSet  kubernetes.Interface
 	dynamicClient dynamic.Interface
+	policies      *podClassPolicyStore
+
+	// nodes and pods are the indexers of the node and pod informers
+	nodes cache.Indexer
+	pods  cache.Indexer
+
+	clock clock.Clock
+	// async runs the second stage of node scale downs in the background
+	async func(func())
+}
+
+func newPrioritization(ctx context.Context, clientSet kubernetes.Interface, dynamicClient dynamic.Interface, policies *podClassPolicyStore) *Prioritization {
+	return &Prioritization{
+		context:       ctx,
+		k8sClientSet:  clientSet,
+		dynamicClient: dynamicClient,
+		policies:      policies,
+		clock:         clock.RealClock{},
+		async:         func(f func()) { go f() },
+	}
+}
+
+// scalingDownNodesFor returns the nodes of the pod class which are being scaled down
+func scalingDownNodesFor(podClass PodClass) *sync.Map {
+	nodes, _ := scalingDownNodesByClass.LoadOrStore(podClass, &sync.Map{})
+	return nodes.(*sync.Map)
+}
+
+// scaleDownLockFor returns the lock for scale down operations of the pod class
+func scaleDownLockFor(podClass PodClass) *sync.Mutex {
+	lock, _ := nodeClassScaleDownLock.LoadOrStore(podClass, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// scaleDownPolicy returns how the nodes of the pod class are scaled down. Scale down is
+// disabled for classes which are not in the policy (anymore).
+func (p *Prioritization) scaleDownPolicy(podClass PodClass) ScaleDownPolicy {
+	if class := p.policies.current().class(podClass); class != nil {
+		return class.ScaleDown
+	}
+	avoidanceFraction := defaultAvoidanceFraction
+	precludedNodes := 0
+	return ScaleDownPolicy{
+		Disabled:                 true,
+		MinNodeAge:               metav1.Duration{Duration: defaultMinNodeAge},
+		AvoidanceFraction:        &avoidanceFraction,
+		PrecludedNodes:           &precludedNodes,
+		TerminatedPodGracePeriod: metav1.Duration{Duration: defaultTerminatedPodGracePeriod},
+	}
 }
 
 const IndexPodsByNode = "IndexPodsByNode"
 const IndexNodesByCiWorkload = "IndexNodesByCiWorkload"
 
+var (
+	nodeIndexers = cache.Indexers{
+		IndexNodesByCiWorkload: func(obj interface{}) ([]string, error) {
+			node := obj.(*corev1.Node)
+			workloads := []string{""}
+			if workload, ok := node.Labels[CiWorkloadLabelName]; ok {
+				workloads = []string{workload}
+			}
+			return workloads, nil
+		},
+	}
+
+	podIndexers = cache.Indexers{
+		// Index pods by the nodes they are assigned to
+		IndexPodsByNode: func(obj interface{}) ([]string, error) {
+			nodeNames := []string{obj.(*corev1.Pod).Spec.NodeName}
+			return nodeNames, nil
+		},
+		IndexNodesByCiWorkload: func(obj interface{}) ([]string, error) {
+			pod := obj.(*corev1.Pod)
+			ciWorkloadClasses := make([]string, 0) // this should be
+			if pod.Labels != nil {
+				if workloadClass, ok := pod.Labels[CiWorkloadLabelName]; ok {
+					ciWorkloadClasses = append(ciWorkloadClasses, workloadClass)
+				}
+			} else {
+				ciWorkloadClasses = append(ciWorkloadClasses, fmt.Sprintf("%v", PodClassNone))
+			}
+			return ciWorkloadClasses, nil
+		},
+	}
+)
+
 func (p *Prioritization) nodeUpdated(old, new interface{}) {
 	oldNode := old.(*corev1.Node)
 	newNode := new.(*corev1.Node)
@@ -122,10 +196,11 @@ func (p *Prioritization) nodeUpdated(old, new interface{}) {
 	}
 }
 
-func (p *Prioritization) initializePrioritization() error {
+// startInformers starts the node and pod informers and waits for their caches to sync
+func (p *Prioritization) startInformers() error {
 
 	informerFactory := informers.NewSharedInformerFactory(p.k8sClientSet, 0)
-	nodesInformer = informerFactory.Core().V1().Nodes().Informer()
+	nodesInformer := informerFactory.Core().V1().Nodes().Informer()
 
 	_, err := nodesInformer.AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
@@ -138,49 +213,15 @@ func (p *Prioritization) initializePrioritization() error {
 		return fmt.Errorf("unable to create new node informer: %w", err)
 	}
 
-	err = nodesInformer.AddIndexers(map[string]cache.IndexFunc{
-		IndexNodesByCiWorkload: func(obj interface{}) ([]string, error) {
-			node := obj.(*corev1.Node)
-			workloads := []string{""}
-			if workload, ok := node.Labels[CiWorkloadLabelName]; ok {
-				workloads = []string{workload}
-			}
-			return workloads, nil
-		},
-	})
+	err = nodesInformer.AddIndexers(nodeIndexers)
 
 	if err != nil {
 		return fmt.Errorf("unable to create new node informer index: %w", err)
 	}
 
-	podsInformer = informerFactory.Core().V1().Pods().Informer()
+	podsInformer := informerFactory.Core().V1().Pods().Informer()
 
-	// Index pods by the nodes they are assigned to
-	err = podsInformer.AddIndexers(map[string]cache.IndexFunc{
-		IndexPodsByNode: func(obj interface{}) ([]string, error) {
-			nodeNames := []string{obj.(*corev1.Pod).Spec.NodeName}
-			return nodeNames, nil
-		},
-	})
-
-	if err != nil {
-		return fmt.Errorf("unable to create new pod informer index: %w", err)
-	}
-
-	err = podsInformer.AddIndexers(map[string]cache.IndexFunc{
-		IndexNodesByCiWorkload: func(obj interface{}) ([]string, error) {
-			pod := obj.(*corev1.Pod)
-			ciWorkloadClasses := make([]string, 0) // this should be
-			if pod.Labels != nil {
-				if workloadClass, ok := pod.Labels[CiWorkloadLabelName]; ok {
-					ciWorkloadClasses = append(ciWorkloadClasses, workloadClass)
-				}
-			} else {
-				ciWorkloadClasses = append(ciWorkloadClasses, fmt.Sprintf("%v", PodClassNone))
-			}
-			return ciWorkloadClasses, nil
-		},
-	})
+	err = podsInformer.AddIndexers(podIndexers)
 
 	if err != nil {
 		return fmt.Errorf("unable to create new pod informer index: %w", err)
@@ -190,11 +231,20 @@ func (p *Prioritization) initializePrioritization() error {
 	informerFactory.Start(stopCh) // runs in background
 	informerFactory.WaitForCacheSync(stopCh)
 
-	for podClass := range nodeClassScaleDownLock {
-		// Setup a timer which will help scale down nodes supporting this pod class
-		go p.pollNodeClassForScaleDown(podClass)
+	p.nodes = nodesInformer.GetIndexer()
+	p.pods = podsInformer.GetIndexer()
+	return nil
+}
+
+func (p *Prioritization) initializePrioritization() error {
+
+	if err := p.startInformers(); err != nil {
+		return err
 	}
 
+	go p.pollScaleDown()
+	go p.policies.pollForChanges()
+
 	// go p.encourageSpotInstances()
 
 	return nil
@@ -330,7 +380,7 @@ func (p *Prioritization) encourageSpotInstances() { //nolint: unused
 			interruptiblesToAllocate--
 		}
 
-		nodeClassScaleDownLock[PodClassBuilds].Lock()
+		scaleDownLockFor(PodClassBuilds).Lock()
 		for i, interruptibleMachineSet := range adjustableInterruptibleMachineSets {
 			msName := interruptibleMachineSet.GetName()
 
@@ -360,7 +410,26 @@ func (p *Prioritization) encourageSpotInstances() { //nolint: unused
 			}
 
 		}
-		nodeClassScaleDownLock[PodClassBuilds].Unlock()
+		scaleDownLockFor(PodClassBuilds).Unlock()
+	}
+}
+
+// pollScaleDown starts a scale down loop for every class in the policy, including
+// the classes which are added when the policy is reloaded.
+func (p *Prioritization) pollScaleDown() {
+	polled := sets.New[PodClass]()
+	startPolling := func() {
+		for _, class := range p.policies.current().Classes {
+			if !polled.Has(class.Name) {
+				polled.Insert(class.Name)
+				// Setup a timer which will help scale down nodes supporting this pod class
+				go p.pollNodeClassForScaleDown(class.Name)
+			}
+		}
+	}
+	startPolling()
+	for range time.Tick(time.Minute) {
+		startPolling()
 	}
 }
 
@@ -388,15 +457,15 @@ func (p *Prioritization) isNodeSchedulable(node *corev1.Node) bool {
 // getWorkloadNodes returns all nodes presently available which support a given
 // podClass (workload type).
 func (p *Prioritization) getWorkloadNodes(podClass PodClass, schedulableNodesOnly bool, minNodeAge time.Duration) ([]*corev1.Node, error) {
-	items, err := nodesInformer.GetIndexer().ByIndex(IndexNodesByCiWorkload, string(podClass))
+	items, err := p.nodes.ByIndex(IndexNodesByCiWorkload, string(podClass))
 	if err != nil {
 		return nil, err
 	}
 	nodes := make([]*corev1.Node, 0)
-	now := time.Now()
+	now := p.clock.Now()
 	for i := range items {
 		nodeByIndex := items[i].(*corev1.Node)
-		nodeObj, exists, err := nodesInformer.GetIndexer().GetByKey(nodeByIndex.Name)
+		nodeObj, exists, err := p.nodes.GetByKey(nodeByIndex.Name)
 
 		if err != nil {
 			klog.Errorf("Error trying to find node object %v: %v", nodeByIndex.Name, err)
@@ -477,7 +546,7 @@ func (p *Prioritization) isPodActive(pod *corev1.Pod, within time.Duration) bool
 			if cs.State.Terminated == nil {
 				return true
 			}
-			if time.Since(cs.State.Terminated.FinishedAt.Time) < within {
+			if p.clock.Since(cs.State.Terminated.FinishedAt.Time) < within {
 				return true
 			}
 		}
@@ -486,7 +555,7 @@ func (p *Prioritization) isPodActive(pod *corev1.Pod, within time.Duration) bool
 }
 
 func (p *Prioritization) getPodsUsingNode(nodeName string, classedPodsOnly bool, activeWithin time.Duration) ([]*corev1.Pod, error) { //nolint: unparam
-	items, err := podsInformer.GetIndexer().ByIndex(IndexPodsByNode, nodeName)
+	items, err := p.pods.ByIndex(IndexPodsByNode, nodeName)
 	if err != nil {
 		return nil, err
 	}
@@ -523,7 +592,7 @@ func (p *Prioritization) evaluateNodeScaleDown(podClass PodClass, node *corev1.N
 
 	// Prevent multiple evaluations on the same node at the same time
 	scalingDownAddLock.Lock()
-	scalingDownNodes := scalingDownNodesByClass[podClass]
+	scalingDownNodes := scalingDownNodesFor(podClass)
 	if _, ok := scalingDownNodes.Load(node.Name); ok {
 		// work is ongoing for this node in another thread. Nothing to do.
 		scalingDownAddLock.Unlock()
@@ -576,9 +645,9 @@ func (p *Prioritization) evaluateNodeScaleDown(podClass PodClass, node *corev1.N
 	// - Machineset says that it is reconciled AND machine is in the "running" phase
 
 	for i := 0; i < 60; i++ {
-		time.Sleep(1 * time.Minute)
+		p.clock.Sleep(1 * time.Minute)
 
-		_, exists, err := nodesInformer.GetIndexer().GetByKey(node.Name)
+		_, exists, err := p.nodes.GetByKey(node.Name)
 		if err != nil {
 			klog.Errorf("Error checking scaled down node %v existence: %v", node.Name, err)
 		} else {
@@ -653,12 +722,18 @@ func (p *Prioritization) evaluateNodeScaleDown(podClass PodClass, node *corev1.N
 // nodes should be updated in order to scale down or to encourage scale down conditions.
 func (p *Prioritization) evaluateNodeClassScaleDown(podClass PodClass) {
 
+	scaleDownPolicy := p.scaleDownPolicy(podClass)
+	if scaleDownPolicy.Disabled {
+		// The policy leaves the nodes of this class alone.
+		return
+	}
+
 	// First, check to see if any nodes have been targeted for scale down in this class.
 	// Nodes which have been targeted have getNodeAvoidanceState of TaintEffectNoSchedule
 	// and they are actually cordoned on the cluster.
-	// Make sure the nodes are at least 15 minutes old, or you might catch one that is cordoned
-	// during initialization.
-	allWorkloadNodes, err := p.getWorkloadNodes(podClass, false, 15*time.Minute)
+	// Make sure the nodes are at least minNodeAge (15 minutes by default) old, or you might
+	// catch one that is cordoned during initialization.
+	allWorkloadNodes, err := p.getWorkloadNodes(podClass, false, scaleDownPolicy.MinNodeAge.Duration)
 	if err != nil {
 		klog.Errorf("Error finding workload nodes for scale down assessment of podClass %v: %v", podClass, err)
 		return
@@ -683,9 +758,9 @@ func (p *Prioritization) evaluateNodeClassScaleDown(podClass PodClass) {
 				// node (e.g. a race between our patch and a pod being scheduled might
 				// have violated that expectation). Time to try scale it down if the operation
 				// is not already underway.
-				scalingDownNodes := scalingDownNodesByClass[podClass]
+				scalingDownNodes := scalingDownNodesFor(podClass)
 				if _, ok := scalingDownNodes.Load(node.Name); !ok { // avoid spawning a thread if it appears work is in progress for this node already
-					go p.evaluateNodeScaleDown(podClass, node)
+					p.async(func() { p.evaluateNodeScaleDown(podClass, node) })
 				}
 			} else {
 				klog.Warningf("Pods are still running on node targeted for scale down: %v", node.Name)
@@ -694,7 +769,7 @@ func (p *Prioritization) evaluateNodeClassScaleDown(podClass PodClass) {
 	}
 
 	nodeNamesUnderActiveScaleDown := make([]string, 0)
-	scalingDownNodes := scalingDownNodesByClass[podClass]
+	scalingDownNodes := scalingDownNodesFor(podClass)
 	scalingDownNodes.Range(func(key, value interface{}) bool {
 		nodeNamesUnderActiveScaleDown = append(nodeNamesUnderActiveScaleDown, fmt.Sprintf("%v", key))
 		return true
@@ -708,7 +783,7 @@ func (p *Prioritization) evaluateNodeClassScaleDown(podClass PodClass) {
 	// a portion of them become idle and targets for scale down.
 
 	// find all nodes that are relevant to this workload class and at least x minutes old
-	workloadNodes, err := p.getWorkloadNodesInAvoidanceOrder(podClass)
+	workloadNodes, err := p.getWorkloadNodesInAvoidanceOrder(podClass, scaleDownPolicy.MinNodeAge.Duration)
 	if err != nil {
 		klog.Errorf("Error finding avoidance workload nodes for scale down assessment of podClass %v: %v", podClass, err)
 		return
@@ -720,7 +795,7 @@ func (p *Prioritization) evaluateNodeClassScaleDown(podClass PodClass) {
 	}
 
 	avoidanceNodes := make([]*corev1.Node, 0)
-	maxAvoidanceTargets := int(math.Ceil(float64(len(workloadNodes)) / 4)) // find appox 25% of nodes
+	maxAvoidanceTargets := int(math.Ceil(float64(len(workloadNodes)) * *scaleDownPolicy.AvoidanceFraction)) // find appox 25% of nodes by default
 	avoidanceInfo := make([]string, 0)
 
 	for _, node := range workloadNodes {
@@ -757,7 +832,7 @@ func (p *Prioritization) evaluateNodeClassScaleDown(podClass PodClass) {
 					}
 				}
 			} else {
-				// The node is the in top 25% of nodes close to being able to scale down. Encourage pods
+				// The node is the in top 25% (by default) of nodes close to being able to scale down. Encourage pods
 				// not to land on it unless necessary. We do this even for spot.io nodes to make it easier
 				// for the service to find empty scale down candidates.
 				err := p.setNodeAvoidanceState(node, podClass, corev1.TaintEffectPreferNoSchedule)
@@ -775,9 +850,9 @@ func (p *Prioritization) evaluateNodeClassScaleDown(podClass PodClass) {
 	klog.Infof("Avoidance info for podClass %v ; avoiding: %v", podClass, avoidanceInfo)
 }
 
-func (p *Prioritization) getWorkloadNodesInAvoidanceOrder(podClass PodClass) ([]*corev1.Node, error) {
+func (p *Prioritization) getWorkloadNodesInAvoidanceOrder(podClass PodClass, minNodeAge time.Duration) ([]*corev1.Node, error) {
 	// find all nodes that are relevant to this workload class and have been around at least x minutes.
-	workloadNodes, err := p.getWorkloadNodes(podClass, true, 15*time.Minute)
+	workloadNodes, err := p.getWorkloadNodes(podClass, true, minNodeAge)
 
 	if err != nil {
 		return nil, fmt.Errorf("unable to find workload nodes for %v: %w", podClass, err)
@@ -816,7 +891,7 @@ func (p *Prioritization) getWorkloadNodesInAvoidanceOrder(podClass PodClass) ([]
 	}
 
 	// Sort first by podCount then by oldest. The goal is to always be pseuedo-draining the node
-	// with the fewest pods which is at least 15 minutes old. Sorting by oldest helps make this
+	// with the fewest pods which is at least minNodeAge old. Sorting by oldest helps make this
 	// search deterministic -- we want to report the same node consistently unless there is a node
 	// with fewer pods.
 	sort.Slice(workloadNodes, func(i, j int) bool {
@@ -837,10 +912,15 @@ func (p *Prioritization) getWorkloadNodesInAvoidanceOrder(podClass PodClass) ([]
 }
 
 func (p *Prioritization) findNodesToPreclude(podClass PodClass) ([]*corev1.Node, error) {
+	scaleDownPolicy := p.scaleDownPolicy(podClass)
+	if scaleDownPolicy.Disabled || *scaleDownPolicy.PrecludedNodes == 0 {
+		return nil, nil
+	}
+
 	nodeAvoidanceLock.Lock()
 	defer nodeAvoidanceLock.Unlock()
 
-	workloadNodes, err := p.getWorkloadNodesInAvoidanceOrder(podClass)
+	workloadNodes, err := p.getWorkloadNodesInAvoidanceOrder(podClass, scaleDownPolicy.MinNodeAge.Duration)
 
 	if err != nil {
 		return nil, fmt.Errorf("unable to get sorted workload nodes for %v: %w", podClass, err)
@@ -853,12 +933,16 @@ func (p *Prioritization) findNodesToPreclude(podClass PodClass) ([]*corev1.Node,
 		return nil, nil
 	}
 
-	precludeNodes := make([]*corev1.Node, 0)
-
-	// this is the most likely node to be scaled down next.
+	// these are the most likely nodes to be scaled down next.
 	// don't let pods schedule in order to help our scale
-	// down loop eliminate it.
-	precludeNodes = append(precludeNodes, workloadNodes[0])
+	// down loop eliminate them. Always leave at least one
+	// node for the pod.
+	precludeCount := *scaleDownPolicy.PrecludedNodes
+	if precludeCount > len(workloadNodes)-1 {
+		precludeCount = len(workloadNodes) - 1
+	}
+	precludeNodes := make([]*corev1.Node, 0, precludeCount)
+	precludeNodes = append(precludeNodes, workloadNodes[:precludeCount]...)
 
 	return precludeNodes, nil
 }
@@ -912,10 +996,11 @@ func (p *Prioritization) scaleDown(podClass PodClass, node *corev1.Node) (machin
 		return machineSetNamespace, "", machineName, fmt.Errorf("error checking machine phase %v / node %v: %w", machineName, node.Name, err)
 	}
 
+	terminatedPodGracePeriod := p.scaleDownPolicy(podClass).TerminatedPodGracePeriod.Duration
 	for {
 		// Wait until terminated pods are X minutes old so that prow / ci-operator have a change to check final status
 		// extract logs / etc (they poll).
-		pods, err := p.getPodsUsingNode(node.Name, true, 5*time.Minute)
+		pods, err := p.getPodsUsingNode(node.Name, true, terminatedPodGracePeriod)
 		if err != nil {
 			klog.Errorf("Unable to query for pod age requirement. Encountered error: %v", err)
 			break
@@ -924,7 +1009,7 @@ func (p *Prioritization) scaleDown(podClass PodClass, node *corev1.Node) (machin
 			break
 		}
 		klog.Infof("Waiting for all terminated pods on machine %v / node %v to have been so for several minutes' %v remaining", machineName, node.Name, len(pods))
-		time.Sleep(1 * time.Minute)
+		p.clock.Sleep(1 * time.Minute)
 	}
 
 	_, machineExists, machineObj, err := p.getMachinePhase(machineSetNamespace, machineName)
@@ -976,12 +1061,12 @@ func (p *Prioritization) scaleDown(podClass PodClass, node *corev1.Node) (machin
 	}
 
 	klog.Infof("Sleeping to allow graceful DNS pod termination on %v / %v", machineName, node.Name)
-	time.Sleep(40 * time.Second)
+	p.clock.Sleep(40 * time.Second)
 
 	attempt := 0
 	for {
 		if attempt > 0 {
-			time.Sleep(10 * time.Second)
+			p.clock.Sleep(10 * time.Second)
 		}
 
 		klog.Infof("Setting machine deletion annotation on machine %v for node %v [attempt=%v]", machineName, node.Name, attempt)
@@ -1019,13 +1104,14 @@ func (p *Prioritization) scaleDown(podClass PodClass, node *corev1.Node) (machin
 
 	// We will now interact with the machineset for this pod class. Hold a lock until we successfully
 	// get rid of this machine or initiate its deletion.
-	nodeClassScaleDownLock[podClass].Lock()
-	defer nodeClassScaleDownLock[podClass].Unlock()
+	scaleDownLock := scaleDownLockFor(podClass)
+	scaleDownLock.Lock()
+	defer scaleDownLock.Unlock()
 
 	attempt = 0
 	for {
 		if attempt > 0 {
-			time.Sleep(10 * time.Second)
+			p.clock.Sleep(10 * time.Second)
 		}
 
 		ms, err := machineSetClient.Get(p.context, machineSetName, metav1.GetOptions{})
@@ -1244,7 +1330,7 @@ func (p *Prioritization) setNodeAvoidanceState(node *corev1.Node, podClass PodCl
 }
 
 func (p *Prioritization) setNoExecuteTaint(nodeName string, podClass PodClass) error {
-	nodeObj, exists, err := nodesInformer.GetIndexer().GetByKey(nodeName)
+	nodeObj, exists, err := p.nodes.GetByKey(nodeName)
 
 	if err != nil {
 		return fmt.Errorf("error getting node to set NoExecute: %w", err)
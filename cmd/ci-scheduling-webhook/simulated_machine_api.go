@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v5"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// simulatedMachineAPI is an in-memory dynamic client for machines and machinesets, used
+// to replay snapshots. It stands in for the machine controller as well: when a machineset
+// is patched, machines annotated for deletion are deleted until the replicas match.
+type simulatedMachineAPI struct {
+	objects map[schema.GroupVersionResource]map[string]*unstructured.Unstructured
+	// machineDeleted is called for every machine the machine controller deletes
+	machineDeleted func(machine *unstructured.Unstructured)
+}
+
+func newSimulatedMachineAPI(machines, machineSets []unstructured.Unstructured, machineDeleted func(machine *unstructured.Unstructured)) *simulatedMachineAPI {
+	api := &simulatedMachineAPI{
+		objects: map[schema.GroupVersionResource]map[string]*unstructured.Unstructured{
+			machineResource:    {},
+			machineSetResource: {},
+		},
+		machineDeleted: machineDeleted,
+	}
+	for gvr, objs := range map[schema.GroupVersionResource][]unstructured.Unstructured{machineResource: machines, machineSetResource: machineSets} {
+		for i := range objs {
+			obj := objs[i].DeepCopy()
+			api.objects[gvr][obj.GetNamespace()+"/"+obj.GetName()] = obj
+		}
+	}
+	return api
+}
+
+func (c *simulatedMachineAPI) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &simulatedMachineAPIResource{api: c, resource: resource}
+}
+
+// reconcileMachineSet deletes the machines of the machineset which are annotated for
+// deletion until the number of machines matches its replicas
+func (c *simulatedMachineAPI) reconcileMachineSet(machineSet *unstructured.Unstructured) {
+	replicas, _, _ := unstructured.NestedInt64(machineSet.Object, "spec", "replicas")
+	var owned []*unstructured.Unstructured
+	for _, machine := range c.objects[machineResource] {
+		if machine.GetNamespace() != machineSet.GetNamespace() {
+			continue
+		}
+		for _, owner := range machine.GetOwnerReferences() {
+			if owner.Kind == "MachineSet" && owner.Name == machineSet.GetName() {
+				owned = append(owned, machine)
+			}
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool { return owned[i].GetName() < owned[j].GetName() })
+
+	remaining := int64(len(owned))
+	for _, machine := range owned {
+		if remaining <= replicas {
+			break
+		}
+		if _, ok := machine.GetAnnotations()[MachineDeleteAnnotationKey]; !ok {
+			continue
+		}
+		delete(c.objects[machineResource], machine.GetNamespace()+"/"+machine.GetName())
+		remaining--
+		if c.machineDeleted != nil {
+			c.machineDeleted(machine)
+		}
+	}
+	_ = unstructured.SetNestedField(machineSet.Object, remaining, "status", "replicas")
+	_ = unstructured.SetNestedField(machineSet.Object, remaining, "status", "readyReplicas")
+}
+
+// simulatedMachineAPIResource implements the parts of the dynamic client the
+// prioritization logic uses. Calling any other method panics.
+type simulatedMachineAPIResource struct {
+	dynamic.NamespaceableResourceInterface
+	api       *simulatedMachineAPI
+	resource  schema.GroupVersionResource
+	namespace string
+}
+
+func (r *simulatedMachineAPIResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &simulatedMachineAPIResource{api: r.api, resource: r.resource, namespace: namespace}
+}
+
+func (r *simulatedMachineAPIResource) Get(_ context.Context, name string, _ metav1.GetOptions, _ ...string) (*unstructured.Unstructured, error) {
+	obj, ok := r.api.objects[r.resource][r.namespace+"/"+name]
+	if !ok {
+		return nil, kerrors.NewNotFound(r.resource.GroupResource(), name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+func (r *simulatedMachineAPIResource) List(_ context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	selector, err := metav1.ParseToLabelSelector(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{}
+	for _, obj := range r.api.objects[r.resource] {
+		if r.namespace != metav1.NamespaceAll && obj.GetNamespace() != r.namespace {
+			continue
+		}
+		if labelSelector.Matches(labels.Set(obj.GetLabels())) {
+			list.Items = append(list.Items, *obj.DeepCopy())
+		}
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].GetName() < list.Items[j].GetName() })
+	return list, nil
+}
+
+func (r *simulatedMachineAPIResource) Patch(_ context.Context, name string, pt types.PatchType, data []byte, _ metav1.PatchOptions, _ ...string) (*unstructured.Unstructured, error) {
+	key := r.namespace + "/" + name
+	obj, ok := r.api.objects[r.resource][key]
+	if !ok {
+		return nil, kerrors.NewNotFound(r.resource.GroupResource(), name)
+	}
+	if pt != types.JSONPatchType {
+		return nil, fmt.Errorf("unsupported patch type %v", pt)
+	}
+	patch, err := jsonpatch.DecodePatch(data)
+	if err != nil {
+		return nil, kerrors.NewBadRequest(err.Error())
+	}
+	raw, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := patch.Apply(raw)
+	if err != nil {
+		return nil, kerrors.NewBadRequest(err.Error())
+	}
+	updated := &unstructured.Unstructured{}
+	if err := updated.UnmarshalJSON(patched); err != nil {
+		return nil, err
+	}
+	r.api.objects[r.resource][key] = updated
+	if r.resource == machineSetResource {
+		r.api.reconcileMachineSet(updated)
+	}
+	return updated.DeepCopy(), nil
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+type decisionAction string
+
+const (
+	// decisionAvoid is the PreferNoSchedule avoidance taint being added to a node
+	decisionAvoid decisionAction = "avoid"
+	// decisionStopAvoiding is the avoidance taint being removed from a node
+	decisionStopAvoiding decisionAction = "stop-avoiding"
+	// decisionCordon is an idle node being cordoned as it is targeted for scale down
+	decisionCordon decisionAction = "cordon"
+	// decisionEvict is the NoExecute taint being added to a node right before its machine is deleted
+	decisionEvict decisionAction = "evict"
+	// decisionScaleDown is the machine of a node being deleted
+	decisionScaleDown decisionAction = "scale-down"
+	// decisionPreclude is a node being precluded from new pods
+	decisionPreclude decisionAction = "preclude"
+)
+
+// scaleDownDecision is a decision the prioritization logic made about a node
+type scaleDownDecision struct {
+	Time   metav1.Time    `json:"time"`
+	Class  PodClass       `json:"class"`
+	Node   string         `json:"node"`
+	Action decisionAction `json:"action"`
+}
+
+// classSimulation summarizes the simulation of a pod class
+type classSimulation struct {
+	Class PodClass `json:"class"`
+	// Nodes is the number of nodes of the class in the snapshot
+	Nodes int `json:"nodes"`
+	// ScaledDown is the number of nodes the simulation scaled down
+	ScaledDown int `json:"scaledDown"`
+	// DisplacedPods is the number of pods which were recorded as running on nodes the
+	// simulation had scaled down by then, and would have been scheduled elsewhere
+	DisplacedPods int `json:"displacedPods"`
+	// RecordedNodeHours and SimulatedNodeHours are the node hours of the class as
+	// recorded and as simulated, up to the last frame
+	RecordedNodeHours  float64 `json:"recordedNodeHours"`
+	SimulatedNodeHours float64 `json:"simulatedNodeHours"`
+}
+
+// simulation is the outcome of replaying a snapshot through the prioritization logic
+type simulation struct {
+	Frames    int                 `json:"frames"`
+	Start     metav1.Time         `json:"start"`
+	End       metav1.Time         `json:"end"`
+	Classes   []classSimulation   `json:"classes"`
+	Decisions []scaleDownDecision `json:"decisions"`
+}
+
+// simulatedNodeState is the state the simulation gave a node. It replaces the state the
+// webhook gave the node in the recording.
+type simulatedNodeState struct {
+	// taints are the avoidance and eviction taints of the webhook
+	taints   []corev1.Taint
+	cordoned bool
+}
+
+func (s simulatedNodeState) hasTaint(key string) bool {
+	for _, taint := range s.taints {
+		if taint.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func isWebhookTaint(taint corev1.Taint) bool {
+	return taint.Key == CiWorkloadPreferNoScheduleTaintName || taint.Key == CiWorkloadPreferNoExecuteTaintName
+}
+
+// simulator replays the frames of a snapshot through the prioritization logic, against
+// a fake clientset and an in-memory machine API. The nodes and pods of every frame are
+// taken from the recording, with the taints and cordons of the webhook replaced by the
+// ones of the simulation, and without the nodes the simulation scaled down. The frames are
+// evaluated at their recorded time. Scale downs run concurrently in the webhook, so each
+// one is replayed on its own clock starting at the time of the frame, on which sleeping
+// advances the clock instead of blocking.
+type simulator struct {
+	policies *podClassPolicyStore
+
+	nodeStates map[string]simulatedNodeState
+	scaledDown map[string]PodClass
+	// deletedMachines are the namespace/name keys of the machines the simulation deleted
+	deletedMachines sets.Set[string]
+	precluded       map[PodClass]sets.Set[string]
+	displaced       map[PodClass]sets.Set[string]
+	classes         map[PodClass]*classSimulation
+	nodesSeen       map[PodClass]sets.Set[string]
+	decisions       []scaleDownDecision
+
+	// the state of the frame being replayed
+	frameTime           metav1.Time
+	client              *fake.Clientset
+	nodes               cache.Indexer
+	recordedUnscheduled sets.Set[string]
+}
+
+func newSimulator(policies *podClassPolicyStore) *simulator {
+	return &simulator{
+		policies:        policies,
+		nodeStates:      map[string]simulatedNodeState{},
+		scaledDown:      map[string]PodClass{},
+		deletedMachines: sets.New[string](),
+		precluded:       map[PodClass]sets.Set[string]{},
+		displaced:       map[PodClass]sets.Set[string]{},
+		classes:         map[PodClass]*classSimulation{},
+		nodesSeen:       map[PodClass]sets.Set[string]{},
+	}
+}
+
+// simulate replays the frames, which have to be sorted by time
+func (s *simulator) simulate(frames []snapshotFrame) *simulation {
+	result := &simulation{Frames: len(frames), Classes: []classSimulation{}, Decisions: []scaleDownDecision{}}
+	if len(frames) == 0 {
+		return result
+	}
+	result.Start = frames[0].Time
+	result.End = frames[len(frames)-1].Time
+
+	for i, frame := range frames {
+		nodes := s.step(frame)
+		if i+1 < len(frames) {
+			hours := frames[i+1].Time.Sub(frame.Time.Time).Hours()
+			for _, node := range frame.Nodes {
+				if class, ok := node.Labels[CiWorkloadLabelName]; ok {
+					s.class(PodClass(class)).RecordedNodeHours += hours
+				}
+			}
+			for _, node := range nodes {
+				if class, ok := node.Labels[CiWorkloadLabelName]; ok {
+					s.class(PodClass(class)).SimulatedNodeHours += hours
+				}
+			}
+		}
+	}
+
+	for _, name := range sets.List(sets.KeySet(s.classes)) {
+		class := s.classes[name]
+		class.Nodes = s.nodesSeen[name].Len()
+		class.DisplacedPods = s.displaced[name].Len()
+		result.Classes = append(result.Classes, *class)
+	}
+	result.Decisions = append(result.Decisions, s.decisions...)
+	return result
+}
+
+func (s *simulator) class(podClass PodClass) *classSimulation {
+	if _, ok := s.classes[podClass]; !ok {
+		s.classes[podClass] = &classSimulation{Class: podClass}
+		s.nodesSeen[podClass] = sets.New[string]()
+		s.displaced[podClass] = sets.New[string]()
+	}
+	return s.classes[podClass]
+}
+
+// step replays a single frame and returns the nodes which are left after it
+func (s *simulator) step(frame snapshotFrame) []*corev1.Node {
+	s.frameTime = frame.Time
+	frameDecisions := len(s.decisions)
+
+	nodes := s.prepareNodes(frame)
+	pods := s.preparePods(frame)
+	machines, machineSets := s.prepareMachines(frame, nodes)
+
+	var objects []runtime.Object
+	s.nodes = cache.NewIndexer(cache.MetaNamespaceKeyFunc, nodeIndexers)
+	for _, node := range nodes {
+		objects = append(objects, node)
+		_ = s.nodes.Add(node)
+	}
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, podIndexers)
+	for _, pod := range pods {
+		objects = append(objects, pod)
+		_ = podIndexer.Add(pod)
+	}
+	s.client = fake.NewSimpleClientset(objects...)
+	s.client.PrependReactor("list", "pods", listPodsByFieldSelector(s.client))
+
+	frameClock := clocktesting.NewFakeClock(frame.Time.Time)
+	p := &Prioritization{
+		context:       context.Background(),
+		k8sClientSet:  s.client,
+		dynamicClient: newSimulatedMachineAPI(machines, machineSets, s.machineDeleted),
+		policies:      s.policies,
+		nodes:         s.nodes,
+		pods:          podIndexer,
+		clock:         frameClock,
+	}
+	// Scale downs are replayed inline so that their decisions belong to this frame, each
+	// on its own clock so that their sleeps do not add up
+	p.async = func(f func()) {
+		p.clock = clocktesting.NewFakeClock(frame.Time.Time)
+		defer func() { p.clock = frameClock }()
+		f()
+	}
+	for _, class := range s.policies.current().Classes {
+		p.evaluateNodeClassScaleDown(class.Name)
+		s.observePreclusion(p, class.Name)
+	}
+
+	nodeList, err := s.client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	var remaining []*corev1.Node
+	if err == nil {
+		sort.Slice(nodeList.Items, func(i, j int) bool { return nodeList.Items[i].Name < nodeList.Items[j].Name })
+		for i := range nodeList.Items {
+			s.observe(&nodeList.Items[i])
+			remaining = append(remaining, &nodeList.Items[i])
+		}
+	}
+
+	// Order the decisions of the frame by class and node, keeping the order of the
+	// decisions about the same node.
+	decisions := s.decisions[frameDecisions:]
+	sort.SliceStable(decisions, func(i, j int) bool {
+		if decisions[i].Class != decisions[j].Class {
+			return decisions[i].Class < decisions[j].Class
+		}
+		return decisions[i].Node < decisions[j].Node
+	})
+	return remaining
+}
+
+// prepareNodes returns the recorded nodes which were not scaled down, with the state the
+// simulation gave them
+func (s *simulator) prepareNodes(frame snapshotFrame) []*corev1.Node {
+	s.recordedUnscheduled = sets.New[string]()
+	var nodes []*corev1.Node
+	for i := range frame.Nodes {
+		node := frame.Nodes[i].DeepCopy()
+		if class, ok := node.Labels[CiWorkloadLabelName]; ok {
+			s.class(PodClass(class))
+			s.nodesSeen[PodClass(class)].Insert(node.Name)
+		}
+		if _, ok := s.scaledDown[node.Name]; ok {
+			continue
+		}
+
+		// The webhook cordons the nodes it taints with NoSchedule avoidance. Other cordons
+		// were made by humans or the machine config operator and are kept.
+		var taints []corev1.Taint
+		cordonedByWebhook := false
+		for _, taint := range node.Spec.Taints {
+			if isWebhookTaint(taint) {
+				cordonedByWebhook = cordonedByWebhook || taint.Key == CiWorkloadPreferNoScheduleTaintName && node.Spec.Unschedulable
+				continue
+			}
+			taints = append(taints, taint)
+		}
+		if cordonedByWebhook {
+			node.Spec.Unschedulable = false
+		}
+		if node.Spec.Unschedulable {
+			s.recordedUnscheduled.Insert(node.Name)
+		}
+		if state, ok := s.nodeStates[node.Name]; ok {
+			taints = append(taints, state.taints...)
+			node.Spec.Unschedulable = node.Spec.Unschedulable || state.cordoned
+		}
+		node.Spec.Taints = taints
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// preparePods returns the recorded pods, without the ones on nodes the simulation scaled
+// down. The active ones among those are counted as displaced.
+func (s *simulator) preparePods(frame snapshotFrame) []*corev1.Pod {
+	var pods []*corev1.Pod
+	for i := range frame.Pods {
+		pod := &frame.Pods[i]
+		if class, ok := s.scaledDown[pod.Spec.NodeName]; ok {
+			if pod.Status.Phase == corev1.PodPending || pod.Status.Phase == corev1.PodRunning {
+				s.displaced[class].Insert(pod.Namespace + "/" + pod.Name)
+			}
+			continue
+		}
+		pods = append(pods, pod.DeepCopy())
+	}
+	return pods
+}
+
+// prepareMachines returns the recorded machines and machinesets, without the machines the
+// simulation deleted. Without recorded machines, they are derived from the nodes. The
+// machines of the nodes are running and the replicas of the machinesets match their
+// machines, so that the machine controller of the simulation can reconcile them.
+func (s *simulator) prepareMachines(frame snapshotFrame, nodes []*corev1.Node) ([]unstructured.Unstructured, []unstructured.Unstructured) {
+	machinesOfNodes := sets.New[string]()
+	for _, node := range nodes {
+		if machine, ok := node.Annotations[NodeMachineAnnotationKey]; ok {
+			machinesOfNodes.Insert(machine)
+		}
+	}
+
+	recordedMachines, recordedMachineSets := frame.Machines, frame.MachineSets
+	if len(recordedMachines) == 0 {
+		recordedMachines, recordedMachineSets = machinesFromNodes(nodes)
+	}
+
+	var machines []unstructured.Unstructured
+	replicas := map[string]int64{}
+	for i := range recordedMachines {
+		machine := recordedMachines[i].DeepCopy()
+		key := machine.GetNamespace() + "/" + machine.GetName()
+		if s.deletedMachines.Has(key) {
+			continue
+		}
+		if machine.GetAnnotations() == nil {
+			// The deletion annotation is added with a JSON patch, which needs the map
+			_ = unstructured.SetNestedStringMap(machine.Object, map[string]string{}, "metadata", "annotations")
+		}
+		if machinesOfNodes.Has(key) {
+			_ = unstructured.SetNestedField(machine.Object, "Running", "status", "phase")
+		}
+		for _, owner := range machine.GetOwnerReferences() {
+			if owner.Kind == "MachineSet" {
+				replicas[machine.GetNamespace()+"/"+owner.Name]++
+			}
+		}
+		machines = append(machines, *machine)
+	}
+
+	var machineSets []unstructured.Unstructured
+	for i := range recordedMachineSets {
+		machineSet := recordedMachineSets[i].DeepCopy()
+		count := replicas[machineSet.GetNamespace()+"/"+machineSet.GetName()]
+		for _, field := range [][]string{{"spec", "replicas"}, {"status", "replicas"}, {"status", "readyReplicas"}} {
+			_ = unstructured.SetNestedField(machineSet.Object, count, field...)
+		}
+		machineSets = append(machineSets, *machineSet)
+	}
+	return machines, machineSets
+}
+
+// machinesFromNodes derives a machine for every node which names one, owned by one
+// machineset per namespace and class
+func machinesFromNodes(nodes []*corev1.Node) ([]unstructured.Unstructured, []unstructured.Unstructured) {
+	var machines, machineSets []unstructured.Unstructured
+	seen := sets.New[string]()
+	for _, node := range nodes {
+		machineKey, ok := node.Annotations[NodeMachineAnnotationKey]
+		class, classified := node.Labels[CiWorkloadLabelName]
+		if !ok || !classified {
+			continue
+		}
+		namespace, name, found := strings.Cut(machineKey, "/")
+		if !found {
+			continue
+		}
+		machineSetName := "simulated-" + class
+		machine := unstructured.Unstructured{Object: map[string]interface{}{}}
+		machine.SetAPIVersion(machineResource.GroupVersion().String())
+		machine.SetKind("Machine")
+		machine.SetNamespace(namespace)
+		machine.SetName(name)
+		machine.SetOwnerReferences([]metav1.OwnerReference{{Kind: "MachineSet", Name: machineSetName}})
+		machines = append(machines, machine)
+
+		if !seen.Has(namespace + "/" + machineSetName) {
+			seen.Insert(namespace + "/" + machineSetName)
+			machineSet := unstructured.Unstructured{Object: map[string]interface{}{}}
+			machineSet.SetAPIVersion(machineSetResource.GroupVersion().String())
+			machineSet.SetKind("MachineSet")
+			machineSet.SetNamespace(namespace)
+			machineSet.SetName(machineSetName)
+			machineSets = append(machineSets, machineSet)
+		}
+	}
+	return machines, machineSets
+}
+
+// machineDeleted scales down the node of a machine the machine controller deleted
+func (s *simulator) machineDeleted(machine *unstructured.Unstructured) {
+	machineKey := machine.GetNamespace() + "/" + machine.GetName()
+	s.deletedMachines.Insert(machineKey)
+	nodeList, err := s.client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return
+	}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if node.Annotations[NodeMachineAnnotationKey] != machineKey {
+			continue
+		}
+		// Record the eviction taint which was set right before the machine was deleted
+		s.observe(node)
+		class := PodClass(node.Labels[CiWorkloadLabelName])
+		s.decide(class, node.Name, decisionScaleDown)
+		s.scaledDown[node.Name] = class
+		s.class(class).ScaledDown++
+		delete(s.nodeStates, node.Name)
+		_ = s.client.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("nodes"), "", node.Name)
+		_ = s.nodes.Delete(node)
+	}
+}
+
+// observe records the changes of the webhook taints and cordon of a node
+func (s *simulator) observe(node *corev1.Node) {
+	if _, ok := s.scaledDown[node.Name]; ok {
+		return
+	}
+	state := simulatedNodeState{cordoned: node.Spec.Unschedulable && !s.recordedUnscheduled.Has(node.Name)}
+	for _, taint := range node.Spec.Taints {
+		if isWebhookTaint(taint) {
+			state.taints = append(state.taints, taint)
+		}
+	}
+	previous := s.nodeStates[node.Name]
+	class := PodClass(node.Labels[CiWorkloadLabelName])
+	if !previous.hasTaint(CiWorkloadPreferNoScheduleTaintName) && state.hasTaint(CiWorkloadPreferNoScheduleTaintName) {
+		s.decide(class, node.Name, decisionAvoid)
+	}
+	if previous.hasTaint(CiWorkloadPreferNoScheduleTaintName) && !state.hasTaint(CiWorkloadPreferNoScheduleTaintName) {
+		s.decide(class, node.Name, decisionStopAvoiding)
+	}
+	if !previous.cordoned && state.cordoned {
+		s.decide(class, node.Name, decisionCordon)
+	}
+	if !previous.hasTaint(CiWorkloadPreferNoExecuteTaintName) && state.hasTaint(CiWorkloadPreferNoExecuteTaintName) {
+		s.decide(class, node.Name, decisionEvict)
+	}
+	s.nodeStates[node.Name] = state
+}
+
+// observePreclusion records the nodes which new pods of the class would newly preclude
+func (s *simulator) observePreclusion(p *Prioritization, podClass PodClass) {
+	nodes, err := p.findNodesToPreclude(podClass)
+	if err != nil {
+		return
+	}
+	precluded := sets.New[string]()
+	for _, node := range nodes {
+		precluded.Insert(node.Name)
+		if s.precluded[podClass] == nil || !s.precluded[podClass].Has(node.Name) {
+			s.decide(podClass, node.Name, decisionPreclude)
+		}
+	}
+	s.precluded[podClass] = precluded
+}
+
+func (s *simulator) decide(podClass PodClass, node string, action decisionAction) {
+	s.decisions = append(s.decisions, scaleDownDecision{Time: s.frameTime, Class: podClass, Node: node, Action: action})
+}
+
+// listPodsByFieldSelector lists pods honoring the spec.nodeName field selector, which the
+// fake clientset ignores
+func listPodsByFieldSelector(client *fake.Clientset) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		listAction, ok := action.(k8stesting.ListAction)
+		if !ok || listAction.GetListRestrictions().Fields.Empty() {
+			return false, nil, nil
+		}
+		obj, err := client.Tracker().List(corev1.SchemeGroupVersion.WithResource("pods"), corev1.SchemeGroupVersion.WithKind("Pod"), action.GetNamespace())
+		if err != nil {
+			return true, nil, fmt.Errorf("unable to list pods: %w", err)
+		}
+		pods := obj.(*corev1.PodList)
+		filtered := &corev1.PodList{}
+		for _, pod := range pods.Items {
+			if listAction.GetListRestrictions().Fields.Matches(fields.Set{"spec.nodeName": pod.Spec.NodeName}) {
+				filtered.Items = append(filtered.Items, pod)
+			}
+		}
+		return true, filtered, nil
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+var simulationStart = time.Date(2024, time.March, 4, 12, 0, 0, 0, time.UTC)
+
+func testNode(name string, class PodClass, age time.Duration, now time.Time) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				CiWorkloadLabelName:         string(class),
+				KubernetesHostnameLabelName: name,
+			},
+			Annotations: map[string]string{
+				NodeMachineAnnotationKey:                   "openshift-machine-api/" + name,
+				NodeMachineConfigurationStateAnnotationKey: "Done",
+			},
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func testPod(name, node string, class PodClass, finishedAt *time.Time) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ci-op-1234",
+			Labels:    map[string]string{CiWorkloadLabelName: string(class)},
+		},
+		Spec:   corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if finishedAt != nil {
+		pod.Status.Phase = corev1.PodSucceeded
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "test",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(*finishedAt)}},
+		}}
+	}
+	return pod
+}
+
+// testFrames returns a recording in which the tests nodes become idle one after the other
+// and a young node joins, while the builds node stays busy
+func testFrames() []snapshotFrame {
+	var frames []snapshotFrame
+	for i, offset := range []time.Duration{0, time.Minute, 2 * time.Minute, 10 * time.Minute, 20 * time.Minute} {
+		now := simulationStart.Add(offset)
+		frame := snapshotFrame{
+			Time: metav1.NewTime(now),
+			Nodes: []corev1.Node{
+				testNode("tests-a", PodClassTests, time.Hour+offset, now),
+				testNode("tests-b", PodClassTests, time.Hour+offset, now),
+				testNode("tests-c", PodClassTests, 2*time.Hour+offset, now),
+				testNode("tests-d", PodClassTests, 2*time.Hour+offset, now),
+				testNode("builds-a", PodClassBuilds, time.Hour+offset, now),
+			},
+			Pods: []corev1.Pod{
+				testPod("c-1", "tests-c", PodClassTests, nil),
+				testPod("c-2", "tests-c", PodClassTests, nil),
+				testPod("c-3", "tests-c", PodClassTests, nil),
+				testPod("d-1", "tests-d", PodClassTests, nil),
+				testPod("d-2", "tests-d", PodClassTests, nil),
+				testPod("d-3", "tests-d", PodClassTests, nil),
+				testPod("d-4", "tests-d", PodClassTests, nil),
+				testPod("build-1", "builds-a", PodClassBuilds, nil),
+			},
+		}
+		if i < 2 {
+			frame.Pods = append(frame.Pods, testPod("b-1", "tests-b", PodClassTests, nil), testPod("b-2", "tests-b", PodClassTests, nil))
+		} else {
+			finishedAt := simulationStart.Add(90 * time.Second)
+			frame.Pods = append(frame.Pods, testPod("b-1", "tests-b", PodClassTests, &finishedAt), testPod("b-2", "tests-b", PodClassTests, &finishedAt))
+		}
+		if i >= 2 {
+			// The recorded cluster kept tests-a and scheduled onto it
+			frame.Pods = append(frame.Pods, testPod("a-1", "tests-a", PodClassTests, nil))
+		}
+		if i >= 3 {
+			frame.Nodes = append(frame.Nodes, testNode("tests-e", PodClassTests, offset-5*time.Minute, now))
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// idleFrames returns a recording in which four idle tests nodes can be scaled down at
+// once, while a young node joins and only gets old enough to be scaled down minutes later
+func idleFrames() []snapshotFrame {
+	var frames []snapshotFrame
+	for _, offset := range []time.Duration{0, time.Minute, 2 * time.Minute, 5 * time.Minute, 6 * time.Minute} {
+		now := simulationStart.Add(offset)
+		frame := snapshotFrame{Time: metav1.NewTime(now)}
+		for _, name := range []string{"tests-a", "tests-b", "tests-c", "tests-d"} {
+			frame.Nodes = append(frame.Nodes, testNode(name, PodClassTests, time.Hour+offset, now))
+		}
+		frame.Nodes = append(frame.Nodes, testNode("tests-young", PodClassTests, 11*time.Minute+offset, now))
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func TestSimulate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy string
+		frames []snapshotFrame
+	}{
+		{
+			name: "default policy",
+		},
+		{
+			name: "aggressive avoidance without preclusion",
+			policy: `classes:
+- name: builds
+  scaleDown:
+    disabled: true
+- name: tests
+  scaleDown:
+    minNodeAge: 1m
+    avoidanceFraction: 0.5
+    precludedNodes: 0
+    terminatedPodGracePeriod: 15m
+`,
+		},
+		{
+			name: "several scale downs in one frame",
+			policy: `classes:
+- name: tests
+  scaleDown:
+    avoidanceFraction: 1
+    precludedNodes: 0
+`,
+			frames: idleFrames(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := defaultPodClassPolicy(1, 1)
+			if tc.policy != "" {
+				var err error
+				if policy, err = loadPodClassPolicy([]byte(tc.policy)); err != nil {
+					t.Fatalf("failed to load the policy: %v", err)
+				}
+			}
+			policies, err := newPodClassPolicyStore("", policy)
+			if err != nil {
+				t.Fatalf("failed to create the policy store: %v", err)
+			}
+			frames := tc.frames
+			if frames == nil {
+				frames = testFrames()
+			}
+			testhelper.CompareWithFixture(t, newSimulator(policies).simulate(frames))
+		})
+	}
+}
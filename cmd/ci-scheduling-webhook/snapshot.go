@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// snapshotFrame is the state of the node and pod informers, and of the machine API,
+// at a point in time. Only the fields the prioritization logic looks at are kept.
+type snapshotFrame struct {
+	Time        metav1.Time                 `json:"time"`
+	Nodes       []corev1.Node               `json:"nodes"`
+	Pods        []corev1.Pod                `json:"pods"`
+	Machines    []unstructured.Unstructured `json:"machines,omitempty"`
+	MachineSets []unstructured.Unstructured `json:"machineSets,omitempty"`
+}
+
+// trimNode keeps the metadata, scheduling state and ready condition of a node
+func trimNode(node *corev1.Node) corev1.Node {
+	trimmed := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              node.Name,
+			Labels:            node.Labels,
+			Annotations:       node.Annotations,
+			CreationTimestamp: node.CreationTimestamp,
+		},
+		Spec: corev1.NodeSpec{
+			Taints:        node.Spec.Taints,
+			Unschedulable: node.Spec.Unschedulable,
+		},
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			trimmed.Status.Conditions = []corev1.NodeCondition{{Type: condition.Type, Status: condition.Status}}
+		}
+	}
+	return trimmed
+}
+
+// trimPod keeps what decides whether a pod keeps its node busy
+func trimPod(pod *corev1.Pod) corev1.Pod {
+	trimmed := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       pod.Name,
+			Namespace:  pod.Namespace,
+			Labels:     pod.Labels,
+			Finalizers: pod.Finalizers,
+		},
+		Spec: corev1.PodSpec{
+			NodeName: pod.Spec.NodeName,
+		},
+		Status: corev1.PodStatus{
+			Phase: pod.Status.Phase,
+		},
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		trimmedStatus := corev1.ContainerStatus{Name: status.Name}
+		if status.State.Terminated != nil {
+			trimmedStatus.State.Terminated = &corev1.ContainerStateTerminated{FinishedAt: status.State.Terminated.FinishedAt}
+		}
+		trimmed.Status.ContainerStatuses = append(trimmed.Status.ContainerStatuses, trimmedStatus)
+	}
+	return trimmed
+}
+
+// trimMachineAPIObject keeps the metadata, replicas and phase of machines and machinesets
+func trimMachineAPIObject(obj *unstructured.Unstructured) unstructured.Unstructured {
+	trimmed := unstructured.Unstructured{Object: map[string]interface{}{}}
+	trimmed.SetAPIVersion(obj.GetAPIVersion())
+	trimmed.SetKind(obj.GetKind())
+	trimmed.SetName(obj.GetName())
+	trimmed.SetNamespace(obj.GetNamespace())
+	trimmed.SetLabels(obj.GetLabels())
+	trimmed.SetAnnotations(obj.GetAnnotations())
+	trimmed.SetOwnerReferences(obj.GetOwnerReferences())
+	for _, field := range [][]string{{"spec", "replicas"}, {"status", "replicas"}, {"status", "readyReplicas"}, {"status", "phase"}} {
+		if value, found, err := unstructured.NestedFieldCopy(obj.Object, field...); err == nil && found {
+			_ = unstructured.SetNestedField(trimmed.Object, value, field...)
+		}
+	}
+	return trimmed
+}
+
+// takeSnapshotFrame captures the current state of the informers and the machine API.
+// The machines are optional, the simulator derives them from the nodes without them.
+func (p *Prioritization) takeSnapshotFrame() snapshotFrame {
+	frame := snapshotFrame{Time: metav1.NewTime(p.clock.Now())}
+	for _, obj := range p.nodes.List() {
+		frame.Nodes = append(frame.Nodes, trimNode(obj.(*corev1.Node)))
+	}
+	for _, obj := range p.pods.List() {
+		pod := obj.(*corev1.Pod)
+		if pod.Spec.NodeName == "" {
+			// Pods which are not scheduled do not keep any node busy
+			continue
+		}
+		frame.Pods = append(frame.Pods, trimPod(pod))
+	}
+	sort.Slice(frame.Nodes, func(i, j int) bool { return frame.Nodes[i].Name < frame.Nodes[j].Name })
+	sort.Slice(frame.Pods, func(i, j int) bool {
+		if frame.Pods[i].Namespace != frame.Pods[j].Namespace {
+			return frame.Pods[i].Namespace < frame.Pods[j].Namespace
+		}
+		return frame.Pods[i].Name < frame.Pods[j].Name
+	})
+
+	machines, err := p.listMachineAPIObjects(machineResource)
+	if err != nil {
+		klog.Warningf("Recording the snapshot frame without machines: %v", err)
+		return frame
+	}
+	machineSets, err := p.listMachineAPIObjects(machineSetResource)
+	if err != nil {
+		klog.Warningf("Recording the snapshot frame without machines: %v", err)
+		return frame
+	}
+	frame.Machines = machines
+	frame.MachineSets = machineSets
+	return frame
+}
+
+func (p *Prioritization) listMachineAPIObjects(resource schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	list, err := p.dynamicClient.Resource(resource).Namespace(metav1.NamespaceAll).List(p.context, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list %v: %w", resource.Resource, err)
+	}
+	var objs []unstructured.Unstructured
+	for i := range list.Items {
+		objs = append(objs, trimMachineAPIObject(&list.Items[i]))
+	}
+	return objs, nil
+}
+
+// recordSnapshot appends a frame to the snapshot file at every interval. Each frame is
+// a line of JSON, so an interrupted recording can still be replayed. A frames count of
+// zero records until the process is stopped.
+func (p *Prioritization) recordSnapshot(path string, interval time.Duration, frames int) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open snapshot file %v: %w", path, err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for recorded := 0; frames == 0 || recorded < frames; recorded++ {
+		if recorded > 0 {
+			p.clock.Sleep(interval)
+		}
+		frame := p.takeSnapshotFrame()
+		if err := encoder.Encode(frame); err != nil {
+			return fmt.Errorf("unable to write snapshot frame: %w", err)
+		}
+		klog.Infof("Recorded snapshot frame %v with %v nodes and %v pods", recorded, len(frame.Nodes), len(frame.Pods))
+	}
+	return nil
+}
+
+// loadSnapshot reads the frames of a snapshot, sorted by time
+func loadSnapshot(r io.Reader) ([]snapshotFrame, error) {
+	var frames []snapshotFrame
+	decoder := json.NewDecoder(r)
+	for {
+		var frame snapshotFrame
+		if err := decoder.Decode(&frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("unable to decode snapshot frame %d: %w", len(frames), err)
+		}
+		frames = append(frames, frame)
+	}
+	sort.SliceStable(frames, func(i, j int) bool { return frames[i].Time.Before(&frames[j].Time) })
+	return frames, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestRecordAndLoadSnapshot(t *testing.T) {
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, nodeIndexers)
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, podIndexers)
+	node := testNode("tests-a", PodClassTests, time.Hour, simulationStart)
+	node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse})
+	node.Status.Capacity = corev1.ResourceList{}
+	running := testPod("running", "tests-a", PodClassTests, nil)
+	running.Spec.Containers = []corev1.Container{{Name: "test", Image: "registry/test:latest"}}
+	pending := testPod("pending", "", PodClassTests, nil)
+	for _, obj := range []interface{}{&node, &running, &pending} {
+		var err error
+		if _, ok := obj.(*corev1.Node); ok {
+			err = nodes.Add(obj)
+		} else {
+			err = pods.Add(obj)
+		}
+		if err != nil {
+			t.Fatalf("failed to add %T: %v", obj, err)
+		}
+	}
+
+	machine := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "machine.openshift.io/v1beta1",
+		"kind":       "Machine",
+		"metadata":   map[string]interface{}{"name": "tests-a", "namespace": "openshift-machine-api"},
+		"spec":       map[string]interface{}{"providerSpec": map[string]interface{}{"value": "dropped"}},
+		"status":     map[string]interface{}{"phase": "Running"},
+	}}
+	p := &Prioritization{
+		context:       context.Background(),
+		dynamicClient: newSimulatedMachineAPI([]unstructured.Unstructured{machine}, nil, nil),
+		nodes:         nodes,
+		pods:          pods,
+		clock:         clocktesting.NewFakeClock(simulationStart),
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	if err := p.recordSnapshot(path, time.Minute, 2); err != nil {
+		t.Fatalf("failed to record the snapshot: %v", err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open the snapshot: %v", err)
+	}
+	defer file.Close()
+	actual, err := loadSnapshot(file)
+	if err != nil {
+		t.Fatalf("failed to load the snapshot: %v", err)
+	}
+
+	expectedNode := testNode("tests-a", PodClassTests, time.Hour, simulationStart)
+	expectedPod := testPod("running", "tests-a", PodClassTests, nil)
+	expectedMachine := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "machine.openshift.io/v1beta1",
+		"kind":       "Machine",
+		"metadata":   map[string]interface{}{"name": "tests-a", "namespace": "openshift-machine-api"},
+		"status":     map[string]interface{}{"phase": "Running"},
+	}}
+	var expected []snapshotFrame
+	for _, offset := range []time.Duration{0, time.Minute} {
+		expected = append(expected, snapshotFrame{
+			Time:     metav1.NewTime(simulationStart.Add(offset)),
+			Nodes:    []corev1.Node{expectedNode},
+			Pods:     []corev1.Pod{expectedPod},
+			Machines: []unstructured.Unstructured{expectedMachine},
+		})
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("snapshot does not match expected, diff: %s", diff)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
@@ -29,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreclientset "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/util"
@@ -159,6 +161,10 @@ func (o *options) run() (exitCode int, err error) {
 	if err := copyDir(o.dstPath, o.srcPath); err != nil {
 		return errorCode, fmt.Errorf("failed to copy secret mount: %w", err)
 	}
+	initial, err := util.SecretFromDir(o.dstPath)
+	if err != nil {
+		return errorCode, fmt.Errorf("failed to read initial secret content: %w", err)
+	}
 	if o.waitPath != "" {
 		if err := waitForFile(o.waitPath, o.waitTimeout); err != nil {
 			return errorCode, fmt.Errorf("failed to wait for file: %w", err)
@@ -167,7 +173,7 @@ func (o *options) run() (exitCode int, err error) {
 	var errs []error
 	ctx, cancel := context.WithCancel(context.Background())
 	if o.uploadKubeconfig {
-		go uploadKubeconfig(ctx, o.client, o.name, o.dstPath, o.dry)
+		go uploadKubeconfig(ctx, o.client, o.name, o.dstPath, initial.Data, o.dry)
 	}
 	if exitCode, err = o.execCmd(); err != nil {
 		errs = append(errs, fmt.Errorf("failed to execute wrapped command: %w", err))
@@ -177,7 +183,7 @@ func (o *options) run() (exitCode int, err error) {
 	// not to race with the post-execution one
 	cancel()
 	if o.updateSharedDir {
-		if err := createSecret(o.client, o.name, o.dstPath, o.dry); err != nil {
+		if err := createSecret(o.client, o.name, o.dstPath, initial.Data, o.dry); err != nil {
 			errs = append(errs, fmt.Errorf("failed to create/update secret: %w", err))
 			return errorCode, utilerrors.NewAggregate(errs)
 		}
@@ -416,7 +422,11 @@ func manageKubeconfig(proc *exec.Cmd) error {
 	return nil
 }
 
-func createSecret(client coreclientset.SecretInterface, name, dir string, dry bool) error {
+// createSecret updates the secret with the changes made to the content of the
+// directory since its `initial` state was recorded.  Steps executing in
+// parallel may have updated the secret in the meantime: only the changes made
+// by this step are applied to its latest version.
+func createSecret(client coreclientset.SecretInterface, name, dir string, initial map[string][]byte, dry bool) error {
 	if _, err := os.Stat(dir); err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -437,12 +447,46 @@ func createSecret(client coreclientset.SecretInterface, name, dir string, dry bo
 		if err != nil {
 			return fmt.Errorf("failed to log secret: %w", err)
 		}
-	} else if _, err := client.Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
+		return nil
+	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current.Data = mergeSharedDir(current.Data, initial, secret.Data)
+		if current.Labels == nil {
+			current.Labels = map[string]string{}
+		}
+		current.Labels[api.SkipCensoringLabel] = "true"
+		_, err = client.Update(context.TODO(), current, metav1.UpdateOptions{})
+		return err
+	}); err != nil {
 		return fmt.Errorf("failed to update secret: %w", err)
 	}
 	return nil
 }
 
+// mergeSharedDir applies the changes between the `initial` and `updated`
+// content of the shared directory to its `latest` content.
+func mergeSharedDir(latest, initial, updated map[string][]byte) map[string][]byte {
+	ret := make(map[string][]byte, len(latest))
+	for k, v := range latest {
+		ret[k] = v
+	}
+	for k, v := range updated {
+		if old, ok := initial[k]; !ok || !bytes.Equal(old, v) {
+			ret[k] = v
+		}
+	}
+	for k := range initial {
+		if _, ok := updated[k]; !ok {
+			delete(ret, k)
+		}
+	}
+	return ret
+}
+
 // uploadKubeconfig will do a best-effort attempt at uploading a kubeconfig
 // file if one does not exist at the time we start running but one does get
 // created while executing the command
@@ -451,7 +495,7 @@ func createSecret(client coreclientset.SecretInterface, name, dir string, dry bo
 // make a minimally functional kubeconfig available for tasks that need to run
 // before the final complete kubeconfig is available for general usage. An example
 // use case is for observers to start observing while install is still in progress.
-func uploadKubeconfig(ctx context.Context, client coreclientset.SecretInterface, name, dir string, dry bool) {
+func uploadKubeconfig(ctx context.Context, client coreclientset.SecretInterface, name, dir string, initial map[string][]byte, dry bool) {
 	if _, err := os.Stat(path.Join(dir, "kubeconfig")); err == nil {
 		// kubeconfig already exists, no need to do anything
 		return
@@ -461,7 +505,7 @@ func uploadKubeconfig(ctx context.Context, client coreclientset.SecretInterface,
 	if err := wait.PollUntil(time.Second, func() (done bool, err error) {
 		if !minimalUploaded {
 			if _, uploadErr = os.Stat(path.Join(dir, "kubeconfig-minimal")); uploadErr == nil {
-				uploadErr = createSecret(client, name, dir, dry)
+				uploadErr = createSecret(client, name, dir, initial, dry)
 				if uploadErr == nil {
 					minimalUploaded = true
 				}
@@ -472,7 +516,7 @@ func uploadKubeconfig(ctx context.Context, client coreclientset.SecretInterface,
 			return false, nil
 		}
 		// kubeconfig exists, we can upload it
-		uploadErr = createSecret(client, name, dir, dry)
+		uploadErr = createSecret(client, name, dir, initial, dry)
 		return uploadErr == nil, nil // retry errors
 	}, ctx.Done()); err != nil && !errors.Is(err, wait.ErrWaitTimeout) {
 		log.Printf("Failed to upload $KUBECONFIG: %v: %v\n", err, uploadErr)
@@ -122,3 +122,35 @@ func TestManageHome(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeSharedDir(t *testing.T) {
+	for _, tc := range []struct {
+		name                     string
+		latest, initial, updated map[string][]byte
+		expected                 map[string][]byte
+	}{{
+		name:     "no changes keeps the latest content",
+		latest:   map[string][]byte{"a": []byte("a"), "b": []byte("from other step")},
+		initial:  map[string][]byte{"a": []byte("a")},
+		updated:  map[string][]byte{"a": []byte("a")},
+		expected: map[string][]byte{"a": []byte("a"), "b": []byte("from other step")},
+	}, {
+		name:     "added and modified files are applied",
+		latest:   map[string][]byte{"a": []byte("a"), "b": []byte("from other step")},
+		initial:  map[string][]byte{"a": []byte("a")},
+		updated:  map[string][]byte{"a": []byte("modified"), "c": []byte("c")},
+		expected: map[string][]byte{"a": []byte("modified"), "b": []byte("from other step"), "c": []byte("c")},
+	}, {
+		name:     "removed files are deleted",
+		latest:   map[string][]byte{"a": []byte("a"), "b": []byte("from other step")},
+		initial:  map[string][]byte{"a": []byte("a")},
+		updated:  map[string][]byte{},
+		expected: map[string][]byte{"b": []byte("from other step")},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, mergeSharedDir(tc.latest, tc.initial, tc.updated)); diff != "" {
+				t.Errorf("unexpected content: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// flake-quarantine scores the tests of a repo and branch from jUnit results
+// and the job run history recorded by the job run aggregator, and quarantines
+// the flaky ones in a list read by ci-operator and the retester.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	"sigs.k8s.io/prow/pkg/interrupts"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/quarantine"
+)
+
+// aggregatedHistoryFrequency is the summary of test runs the job run
+// aggregator keeps for every job.
+const aggregatedHistoryFrequency = "ByOneWeek"
+
+type options struct {
+	org    string
+	repo   string
+	branch string
+
+	junit []string
+	jobs  []string
+
+	list     string
+	auditLog string
+
+	threshold float64
+	minRuns   int
+	ttl       time.Duration
+
+	dryRun bool
+
+	auth        *jobrunaggregatorlib.GoogleAuthenticationFlags
+	coordinates *jobrunaggregatorlib.BigQueryDataCoordinates
+}
+
+func gatherOptions(args []string) (*options, error) {
+	o := &options{
+		auth:        jobrunaggregatorlib.NewGoogleAuthenticationFlags(),
+		coordinates: jobrunaggregatorlib.NewBigQueryDataCoordinates(),
+	}
+	fs := pflag.NewFlagSet("flake-quarantine", pflag.ContinueOnError)
+	fs.StringVar(&o.org, "org", "", "Organization of the repo to quarantine tests for.")
+	fs.StringVar(&o.repo, "repo", "", "Repo to quarantine tests for.")
+	fs.StringVar(&o.branch, "branch", "", "Branch to quarantine tests for.")
+	fs.StringSliceVar(&o.junit, "junit", nil, "jUnit files, or directories holding junit*.xml files, with results of the tests. Each file counts as one run of the tests in it.")
+	fs.StringSliceVar(&o.jobs, "job", nil, "Jobs whose test run history recorded by the job run aggregator is used to score the tests.")
+	fs.StringVar(&o.list, "quarantine-list", "", "File with the quarantine list to update.")
+	fs.StringVar(&o.auditLog, "audit-log", "", "File to append every quarantine decision to, as JSON lines.")
+	fs.Float64Var(&o.threshold, "threshold", 0.05, "Flake score at or above which a test is quarantined.")
+	fs.IntVar(&o.minRuns, "min-runs", 20, "Number of runs needed to score a test.")
+	fs.DurationVar(&o.ttl, "ttl", 7*24*time.Hour, "How long a quarantine lasts unless the test is scored again.")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Log the decisions without updating the quarantine list or the audit log.")
+	o.auth.BindFlags(fs)
+	o.coordinates.BindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *options) validate() error {
+	var errs []error
+	if o.org == "" || o.repo == "" || o.branch == "" {
+		errs = append(errs, errors.New("--org, --repo and --branch are required"))
+	}
+	if o.list == "" {
+		errs = append(errs, errors.New("--quarantine-list is required"))
+	}
+	if o.auditLog == "" && !o.dryRun {
+		errs = append(errs, errors.New("--audit-log is required"))
+	}
+	if len(o.junit) == 0 && len(o.jobs) == 0 {
+		errs = append(errs, errors.New("at least one of --junit or --job is required"))
+	}
+	if len(o.jobs) > 0 {
+		if err := o.auth.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := o.coordinates.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := o.policy().Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (o *options) policy() quarantine.Policy {
+	return quarantine.Policy{Threshold: o.threshold, MinRuns: o.minRuns, TTL: o.ttl}
+}
+
+func main() {
+	o, err := gatherOptions(os.Args[1:])
+	if err != nil {
+		logrus.WithError(err).Fatal("could not parse input")
+	}
+	if err := o.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid options")
+	}
+	ctx := interrupts.Context()
+
+	var history history
+	if len(o.jobs) > 0 {
+		client, err := o.auth.NewBigQueryClient(ctx, o.coordinates.ProjectID)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not create the BigQuery client")
+		}
+		history = jobrunaggregatorlib.NewCIDataClient(*o.coordinates, client)
+	}
+	if err := run(ctx, o, history, time.Now()); err != nil {
+		logrus.WithError(err).Fatal("failed to update the quarantine list")
+	}
+}
+
+// history provides the results of the tests of a job recorded by the job run
+// aggregator.
+type history interface {
+	ListAggregatedTestRunsForJob(ctx context.Context, frequency, jobName string, startDay time.Time) ([]jobrunaggregatorapi.AggregatedTestRunRow, error)
+}
+
+func run(ctx context.Context, o *options, history history, now time.Time) error {
+	counts, err := countsFromJUnit(o.junit)
+	if err != nil {
+		return err
+	}
+	for _, job := range o.jobs {
+		rows, err := history.ListAggregatedTestRunsForJob(ctx, aggregatedHistoryFrequency, job, jobrunaggregatorlib.GetUTCDay(now))
+		if err != nil {
+			return fmt.Errorf("could not list the test runs of job %s: %w", job, err)
+		}
+		counts = quarantine.Add(counts, quarantine.CountsFromAggregatedRuns(rows))
+	}
+
+	list, err := quarantine.LoadList(o.list)
+	if err != nil {
+		return err
+	}
+	decisions := quarantine.Update(list, quarantine.Scope{Org: o.org, Repo: o.repo, Branch: o.branch}, counts, o.policy(), now)
+	for _, decision := range decisions {
+		logrus.WithFields(logrus.Fields{
+			"scope":  decision.Scope,
+			"test":   decision.Test,
+			"action": decision.Action,
+			"score":  decision.Score,
+			"runs":   decision.Runs,
+		}).Info(decision.Reason)
+	}
+	if o.dryRun {
+		logrus.Infof("Dry run: not updating the quarantine list with %d decisions", len(decisions))
+		return nil
+	}
+	// the audit log is written first so that no change to the list goes unrecorded
+	if err := quarantine.AppendAudit(o.auditLog, decisions); err != nil {
+		return err
+	}
+	return list.Write(o.list)
+}
+
+// countsFromJUnit loads the jUnit files, or the junit*.xml files in the
+// directories, and counts the outcomes of their tests.
+func countsFromJUnit(paths []string) (map[string]quarantine.Counts, error) {
+	results, err := junit.Load(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return quarantine.CountsFromJUnit(results...), nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorlib"
+	"github.com/openshift/ci-tools/pkg/quarantine"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() *options {
+		return &options{
+			org:         "org",
+			repo:        "repo",
+			branch:      "master",
+			junit:       []string{"artifacts"},
+			list:        "quarantine.yaml",
+			auditLog:    "audit.jsonl",
+			threshold:   0.05,
+			minRuns:     20,
+			ttl:         time.Hour,
+			auth:        &jobrunaggregatorlib.GoogleAuthenticationFlags{},
+			coordinates: jobrunaggregatorlib.NewBigQueryDataCoordinates(),
+		}
+	}
+	testCases := []struct {
+		name     string
+		modify   func(*options)
+		expected string
+	}{
+		{
+			name:   "valid",
+			modify: func(*options) {},
+		},
+		{
+			name:     "missing scope",
+			modify:   func(o *options) { o.branch = "" },
+			expected: "--org, --repo and --branch are required",
+		},
+		{
+			name:     "missing list",
+			modify:   func(o *options) { o.list = "" },
+			expected: "--quarantine-list is required",
+		},
+		{
+			name:     "missing audit log",
+			modify:   func(o *options) { o.auditLog = "" },
+			expected: "--audit-log is required",
+		},
+		{
+			name: "dry run does not need an audit log",
+			modify: func(o *options) {
+				o.auditLog = ""
+				o.dryRun = true
+			},
+		},
+		{
+			name:     "no results",
+			modify:   func(o *options) { o.junit = nil },
+			expected: "at least one of --junit or --job is required",
+		},
+		{
+			name:     "jobs need credentials",
+			modify:   func(o *options) { o.jobs = []string{"periodic"} },
+			expected: "one of --google-service-account-credential-file or --google-oauth-credential-file must be specified",
+		},
+		{
+			name: "jobs with credentials",
+			modify: func(o *options) {
+				o.jobs = []string{"periodic"}
+				o.auth.GoogleServiceAccountCredentialFile = "credentials.json"
+			},
+		},
+		{
+			name:     "invalid policy",
+			modify:   func(o *options) { o.threshold = 2 },
+			expected: "threshold must be in (0, 1], got 2",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := valid()
+			tc.modify(o)
+			var actual string
+			if err := o.validate(); err != nil {
+				actual = err.Error()
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+type fakeHistory map[string][]jobrunaggregatorapi.AggregatedTestRunRow
+
+func (f fakeHistory) ListAggregatedTestRunsForJob(_ context.Context, frequency, jobName string, _ time.Time) ([]jobrunaggregatorapi.AggregatedTestRunRow, error) {
+	if frequency != aggregatedHistoryFrequency {
+		return nil, nil
+	}
+	return f[jobName], nil
+}
+
+const (
+	passingRun = `<testsuites><testsuite name="e2e"><testcase name="flaky"></testcase><testcase name="stable"></testcase></testsuite></testsuites>`
+	failingRun = `<testsuite name="e2e"><testcase name="flaky"><failure message="">boom</failure></testcase><testcase name="stable"></testcase></testsuite>`
+)
+
+func TestRun(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	artifacts := filepath.Join(dir, "artifacts")
+	for path, content := range map[string]string{
+		"run-1/junit_e2e.xml": passingRun,
+		"run-2/junit_e2e.xml": failingRun,
+		"run-2/e2e.xml":       failingRun,
+	} {
+		path = filepath.Join(artifacts, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history := fakeHistory{
+		"periodic": {
+			{TestName: "flaky", PassCount: 6, FailCount: 1, FlakeCount: 1},
+			{TestName: "stable", PassCount: 8},
+		},
+	}
+	o := &options{
+		org:       "org",
+		repo:      "repo",
+		branch:    "master",
+		junit:     []string{artifacts},
+		jobs:      []string{"periodic"},
+		list:      filepath.Join(dir, "quarantine.yaml"),
+		auditLog:  filepath.Join(dir, "audit.jsonl"),
+		threshold: 0.2,
+		minRuns:   10,
+		ttl:       time.Hour,
+	}
+	if err := run(context.Background(), o, history, now); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	list, err := quarantine.LoadList(o.list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reason := "flake score 0.300 over 10 runs is at least 0.200"
+	expected := &quarantine.List{Scopes: map[string]map[string]quarantine.Entry{
+		"org/repo@master": {
+			"flaky": {Score: 0.3, Runs: 10, Reason: reason, Since: now, Expires: now.Add(time.Hour)},
+		},
+	}}
+	if diff := cmp.Diff(expected, list); diff != "" {
+		t.Errorf("unexpected quarantine list: %s", diff)
+	}
+	audit, err := os.ReadFile(o.auditLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedAudit := `{"time":"2024-03-01T12:00:00Z","scope":"org/repo@master","test":"flaky","action":"quarantine","score":0.3,"runs":10,"reason":"` + reason + `"}` + "\n"
+	if diff := cmp.Diff(expectedAudit, string(audit)); diff != "" {
+		t.Errorf("unexpected audit log: %s", diff)
+	}
+
+	o.dryRun = true
+	if err := run(context.Background(), o, history, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if list, err = quarantine.LoadList(o.list); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expected, list); diff != "" {
+		t.Errorf("dry run changed the quarantine list: %s", diff)
+	}
+}
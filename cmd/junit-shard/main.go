@@ -0,0 +1,220 @@
+// junit-shard sizes and splits sharded tests from the jUnit results of their
+// previous runs.  The `durations` command records how long a test takes in the
+// sharding configuration of ci-operator-prowgen, which picks the number of
+// shards of the test from it.  The `partition` command runs in a shard of a
+// test and prints its part of the tests, so that the shards finish at about
+// the same time.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/config"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+const usage = `usage: junit-shard partition --junit=<path> [--shard-index=<index> --shard-count=<count>] < tests
+       junit-shard durations --junit=<path> --test=<name> --prowgen-config-dir=<dir>`
+
+type partitionOptions struct {
+	junit      []string
+	shardIndex int
+	shardCount int
+}
+
+func gatherPartitionOptions(args []string) (*partitionOptions, error) {
+	o := &partitionOptions{}
+	fs := pflag.NewFlagSet("partition", pflag.ContinueOnError)
+	fs.StringSliceVar(&o.junit, "junit", nil, "jUnit files, or directories holding junit*.xml files, with results of previous runs of the tests.")
+	fs.IntVar(&o.shardIndex, "shard-index", 0, "The 1-based index of the shard to print the tests of. Defaults to $"+api.ShardIndexEnv+".")
+	fs.IntVar(&o.shardCount, "shard-count", 0, "The number of shards. Defaults to $"+api.ShardCountEnv+".")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	for flag, value := range map[string]*int{api.ShardIndexEnv: &o.shardIndex, api.ShardCountEnv: &o.shardCount} {
+		if *value != 0 {
+			continue
+		}
+		if raw := os.Getenv(flag); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid $%s: %w", flag, err)
+			}
+			*value = parsed
+		}
+	}
+	return o, nil
+}
+
+func (o *partitionOptions) validate() error {
+	if o.shardIndex < 1 || o.shardIndex > o.shardCount {
+		return fmt.Errorf("the shard index must be between 1 and the shard count, got %d of %d", o.shardIndex, o.shardCount)
+	}
+	return nil
+}
+
+type durationsOptions struct {
+	junit     []string
+	test      string
+	configDir string
+}
+
+func gatherDurationsOptions(args []string) (*durationsOptions, error) {
+	o := &durationsOptions{}
+	fs := pflag.NewFlagSet("durations", pflag.ContinueOnError)
+	fs.StringSliceVar(&o.junit, "junit", nil, "jUnit files, or directories holding junit*.xml files, with results of previous runs of the test. Each file counts as one run.")
+	fs.StringVar(&o.test, "test", "", "Name of the test in the ci-operator configuration.")
+	fs.StringVar(&o.configDir, "prowgen-config-dir", "", "Directory holding the "+config.ProwgenFile+" file to record the duration of the test in.")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *durationsOptions) validate() error {
+	var errs []error
+	if len(o.junit) == 0 {
+		errs = append(errs, errors.New("--junit is required"))
+	}
+	if o.test == "" {
+		errs = append(errs, errors.New("--test is required"))
+	}
+	if o.configDir == "" {
+		errs = append(errs, errors.New("--prowgen-config-dir is required"))
+	}
+	return errors.Join(errs...)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		logrus.Fatal(usage)
+	}
+	switch os.Args[1] {
+	case "partition":
+		o, err := gatherPartitionOptions(os.Args[2:])
+		if err != nil {
+			logrus.WithError(err).Fatal("could not parse input")
+		}
+		if err := o.validate(); err != nil {
+			logrus.WithError(err).Fatal("invalid options")
+		}
+		if err := partition(o, os.Stdin, os.Stdout); err != nil {
+			logrus.WithError(err).Fatal("failed to partition the tests")
+		}
+	case "durations":
+		o, err := gatherDurationsOptions(os.Args[2:])
+		if err != nil {
+			logrus.WithError(err).Fatal("could not parse input")
+		}
+		if err := o.validate(); err != nil {
+			logrus.WithError(err).Fatal("invalid options")
+		}
+		if err := recordDuration(o); err != nil {
+			logrus.WithError(err).Fatal("failed to record the duration of the test")
+		}
+	default:
+		logrus.Fatal(usage)
+	}
+}
+
+// partition reads the names of the tests, one per line, and writes those of
+// the shard.
+func partition(o *partitionOptions, in io.Reader, out io.Writer) error {
+	results, err := junit.Load(o.junit...)
+	if err != nil {
+		return err
+	}
+	var tests []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if test := strings.TrimSpace(scanner.Text()); test != "" {
+			tests = append(tests, test)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("could not read the tests: %w", err)
+	}
+	for _, test := range junit.Partition(tests, junit.Durations(results...), o.shardCount)[o.shardIndex-1] {
+		if _, err := fmt.Fprintln(out, test); err != nil {
+			return fmt.Errorf("could not write the tests: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordDuration sets the duration of the test in the sharding configuration
+// to how long its previous runs took on average. Only the duration of the
+// test is edited, so the rest of the file keeps its comments and order.
+func recordDuration(o *durationsOptions) error {
+	results, err := junit.Load(o.junit...)
+	if err != nil {
+		return err
+	}
+	prowgen, err := config.LoadProwgenConfig(o.configDir)
+	if err != nil {
+		return err
+	}
+	if prowgen == nil || prowgen.Sharding == nil {
+		return fmt.Errorf("%s in %s has no sharding configuration", config.ProwgenFile, o.configDir)
+	}
+	var seconds float64
+	for _, duration := range junit.Durations(results...) {
+		seconds += duration
+	}
+	duration := time.Duration(math.Round(seconds)) * time.Second
+	logrus.Infof("Test %s takes %s", o.test, duration)
+	path := filepath.Join(o.configDir, config.ProwgenFile)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", path, err)
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(raw, &document); err != nil {
+		return fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	durations := mappingValue(mappingValue(document.Content[0], "sharding"), "durations")
+	mappingValue(durations, o.test).SetString(duration.String())
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return fmt.Errorf("could not marshal the prowgen configuration: %w", err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	return nil
+}
+
+// mappingValue returns the value of the key in the mapping, adding the key
+// with an empty mapping if it is missing or null.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		value := mapping.Content[i+1]
+		if value.Tag == "!!null" {
+			*value = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		return value
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+
+	"github.com/openshift/ci-tools/pkg/config"
+)
+
+const previousRun = `<testsuites>
+  <testsuite name="e2e">
+    <testcase name="slow" time="60"></testcase>
+    <testcase name="medium" time="40"></testcase>
+    <testcase name="fast" time="19.6"></testcase>
+    <testcase name="skipped" time="0"><skipped message="not run"></skipped></testcase>
+  </testsuite>
+</testsuites>`
+
+func writeJUnit(t *testing.T) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "junit_e2e.xml"), []byte(previousRun), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestPartition(t *testing.T) {
+	junitDir := writeJUnit(t)
+	testCases := []struct {
+		name       string
+		shardIndex int
+		expected   []string
+	}{
+		{
+			name:       "first shard",
+			shardIndex: 1,
+			expected:   []string{"fast", "slow"},
+		},
+		{
+			name:       "second shard runs the test without a known duration",
+			shardIndex: 2,
+			expected:   []string{"medium", "new"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			o := &partitionOptions{junit: []string{junitDir}, shardIndex: tc.shardIndex, shardCount: 2}
+			if err := partition(o, strings.NewReader("slow\nmedium\nfast\nnew\n"), &out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, strings.Fields(out.String())); diff != "" {
+				t.Errorf("unexpected tests, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPartitionOptions(t *testing.T) {
+	t.Setenv("SHARD_INDEX", "2")
+	t.Setenv("SHARD_COUNT", "3")
+	testCases := []struct {
+		name     string
+		args     []string
+		expected partitionOptions
+	}{
+		{
+			name:     "shard from the environment",
+			args:     []string{"--junit=dir"},
+			expected: partitionOptions{junit: []string{"dir"}, shardIndex: 2, shardCount: 3},
+		},
+		{
+			name:     "flags take precedence",
+			args:     []string{"--junit=dir", "--shard-index=1", "--shard-count=2"},
+			expected: partitionOptions{junit: []string{"dir"}, shardIndex: 1, shardCount: 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o, err := gatherPartitionOptions(tc.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, *o, cmp.AllowUnexported(partitionOptions{})); diff != "" {
+				t.Errorf("unexpected options, diff: %s", diff)
+			}
+			if err := o.validate(); err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRecordDuration(t *testing.T) {
+	junitDir := writeJUnit(t)
+	testCases := []struct {
+		name          string
+		prowgen       string
+		expected      *config.Sharding
+		expectedFile  string
+		expectedError string
+	}{
+		{
+			name:    "duration is recorded",
+			prowgen: "sharding:\n  target_duration: 1m\n  durations:\n    other: 1h\n",
+			expected: &config.Sharding{
+				TargetDuration: prowv1.Duration{Duration: time.Minute},
+				Durations: map[string]*prowv1.Duration{
+					"other": {Duration: time.Hour},
+					"e2e":   {Duration: 2 * time.Minute},
+				},
+			},
+			expectedFile: "sharding:\n  target_duration: 1m\n  durations:\n    other: 1h\n    e2e: 2m0s\n",
+		},
+		{
+			name:    "previous duration is replaced",
+			prowgen: "sharding:\n  target_duration: 1m\n  durations:\n    e2e: 1h\n",
+			expected: &config.Sharding{
+				TargetDuration: prowv1.Duration{Duration: time.Minute},
+				Durations: map[string]*prowv1.Duration{
+					"e2e": {Duration: 2 * time.Minute},
+				},
+			},
+			expectedFile: "sharding:\n  target_duration: 1m\n  durations:\n    e2e: 2m0s\n",
+		},
+		{
+			name:    "comments and order of the configuration are kept",
+			prowgen: "# sizes the shards of e2e\nsharding:\n  target_duration: 1m # wall time\n  durations:\nprivate: true\n",
+			expected: &config.Sharding{
+				TargetDuration: prowv1.Duration{Duration: time.Minute},
+				Durations: map[string]*prowv1.Duration{
+					"e2e": {Duration: 2 * time.Minute},
+				},
+			},
+			expectedFile: "# sizes the shards of e2e\nsharding:\n  target_duration: 1m # wall time\n  durations:\n    e2e: 2m0s\nprivate: true\n",
+		},
+		{
+			name:          "configuration without sharding is rejected",
+			prowgen:       "private: true\n",
+			expectedError: "has no sharding configuration",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, config.ProwgenFile), []byte(tc.prowgen), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := recordDuration(&durationsOptions{junit: []string{junitDir}, test: "e2e", configDir: dir})
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			prowgen, err := config.LoadProwgenConfig(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, prowgen.Sharding); diff != "" {
+				t.Errorf("unexpected sharding configuration, diff: %s", diff)
+			}
+			raw, err := os.ReadFile(filepath.Join(dir, config.ProwgenFile))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expectedFile, string(raw)); diff != "" {
+				t.Errorf("unexpected %s, diff: %s", config.ProwgenFile, diff)
+			}
+		})
+	}
+}
@@ -33,11 +33,11 @@ import (
 	"github.com/openshift/ci-tools/pkg/steps"
 )
 
-func admit(port, healthPort int, certDir string, client buildclientv1.BuildV1Interface, loaders map[string][]*cacheReloader, mutateResourceLimits bool, cpuCap int64, memoryCap string, cpuPriorityScheduling int64, reporter results.PodScalerReporter) {
+func admit(port, healthPort int, certDir string, client buildclientv1.BuildV1Interface, loaders map[string][]*cacheReloader, policies *podscaler.Policies, mutateResourceLimits bool, cpuCap int64, memoryCap string, cpuPriorityScheduling int64, reporter results.PodScalerReporter) {
 	logger := logrus.WithField("component", "pod-scaler admission")
 	logger.Infof("Initializing admission webhook server with %d loaders.", len(loaders))
 	health := pjutil.NewHealthOnPort(healthPort)
-	resources := newResourceServer(loaders, health)
+	resources := newResourceServer(loaders, policies, health)
 	decoder := admission.NewDecoder(scheme.Scheme)
 
 	server := webhook.NewServer(webhook.Options{
@@ -292,15 +292,52 @@ func preventUnschedulable(resources *corev1.ResourceRequirements, cpuCap int64,
 	}
 }
 
+// oomKilledMemoryFactor is the factor by which the memory recommendation is
+// raised for workloads that were recently OOM-killed, as their usage data does
+// not reflect how much memory they would have used had they not been killed.
+const oomKilledMemoryFactor = 1.5
+
+// raiseMemoryForOOMKilled raises the memory request and limit in the resources.
+func raiseMemoryForOOMKilled(resources *corev1.ResourceRequirements) {
+	for _, list := range []corev1.ResourceList{resources.Requests, resources.Limits} {
+		value, set := list[corev1.ResourceMemory]
+		if !set {
+			continue
+		}
+		value.Set(int64(value.AsApproximateFloat64() * oomKilledMemoryFactor))
+		list[corev1.ResourceMemory] = value
+	}
+}
+
+// policyAnnotation records the recommendation policies configured for the
+// containers of a Pod, when they differ from the defaults.
+const policyAnnotation = "ci-workload-autoscaler.openshift.io/policy"
+
 func mutatePodResources(pod *corev1.Pod, server *resourceServer, mutateResourceLimits bool, cpuCap int64, memoryCap string, reporter results.PodScalerReporter, logger *logrus.Entry) {
+	policies := map[string]podscaler.ResourcePolicies{}
 	mutateResources := func(containers []corev1.Container) {
 		for i := range containers {
 			meta := podscaler.MetadataFor(pod.ObjectMeta.Labels, pod.ObjectMeta.Name, containers[i].Name)
 			resources, recommendationExists := server.recommendedRequestFor(meta)
 			if recommendationExists {
+				if policy, configured := server.effectivePolicyFor(meta); configured {
+					policies[containers[i].Name] = policy
+				}
 				logger.Debugf("recommendation exists for: %s", containers[i].Name)
 				workloadType := determineWorkloadType(pod.Annotations, pod.Labels)
 				workloadName := determineWorkloadName(pod.Name, containers[i].Name, workloadType, pod.Labels)
+				if !mutateResourceLimits || containers[i].Resources.Limits.Memory().IsZero() {
+					// Never set a limit where there isn't one defined
+					delete(resources.Limits, corev1.ResourceMemory)
+				}
+				if killed, recently := server.recentlyOOMKilled(meta); recently {
+					logger.Debugf("raising memory for recently OOM-killed container: %s", containers[i].Name)
+					raiseMemoryForOOMKilled(&resources)
+					if server.firstReportOfOOMKill(meta, killed) {
+						determined := resources.Requests[corev1.ResourceMemory]
+						reporter.ReportOOMKilledWorkload(workloadName, workloadType, determined.String())
+					}
+				}
 				useOursIfLarger(&resources, &containers[i].Resources, workloadName, workloadType, reporter, logger)
 				if mutateResourceLimits {
 					reconcileLimits(&containers[i].Resources)
@@ -311,6 +348,18 @@ func mutatePodResources(pod *corev1.Pod, server *resourceServer, mutateResourceL
 	}
 	mutateResources(pod.Spec.InitContainers)
 	mutateResources(pod.Spec.Containers)
+	if len(policies) == 0 {
+		return
+	}
+	raw, err := json.Marshal(policies)
+	if err != nil {
+		logger.WithError(err).Warn("Could not marshal recommendation policies.")
+		return
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[policyAnnotation] = string(raw)
 }
 
 const (
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/sirupsen/logrus"
@@ -39,6 +40,10 @@ func (r *mockReporter) ReportResourceConfigurationWarning(string, string, string
 	r.called = true
 }
 
+func (r *mockReporter) ReportOOMKilledWorkload(string, string, string) {
+	r.called = true
+}
+
 var defaultReporter = mockReporter{client: &http.Client{}}
 
 func TestMutatePods(t *testing.T) {
@@ -549,6 +554,119 @@ func TestMutatePodResources(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "resources to add with a configured policy",
+			server: &resourceServer{
+				logger: logger,
+				lock:   sync.RWMutex{},
+				byMetaData: map[podscaler.FullMetadata]corev1.ResourceRequirements{
+					baseWithContainer(&metaBase, "test"): {
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    *resource.NewQuantity(1, resource.DecimalSI),
+							corev1.ResourceMemory: *resource.NewQuantity(2e8, resource.BinarySI),
+						},
+					},
+					baseWithContainer(&metaBase, "sidecar"): {
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    *resource.NewQuantity(1, resource.DecimalSI),
+							corev1.ResourceMemory: *resource.NewQuantity(2e8, resource.BinarySI),
+						},
+					},
+				},
+				policies: &podscaler.Policies{
+					Rules: []podscaler.PolicyRule{{
+						Selector: podscaler.PolicySelector{Org: "org", Step: "step", Container: "test"},
+						ResourcePolicies: podscaler.ResourcePolicies{
+							Memory: &podscaler.RecommendationPolicy{Quantile: 0.95, Headroom: 1.5},
+						},
+					}},
+				},
+			},
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "tomutate",
+					Labels: map[string]string{
+						"ci.openshift.io/metadata.org":     "org",
+						"ci.openshift.io/metadata.repo":    "repo",
+						"ci.openshift.io/metadata.branch":  "branch",
+						"ci.openshift.io/metadata.variant": "variant",
+						"ci.openshift.io/metadata.target":  "target",
+						"ci.openshift.io/metadata.step":    "step",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "test"}, {Name: "sidecar"}},
+				},
+			},
+		},
+		{
+			name: "resources to add for a recently OOM-killed workload",
+			server: &resourceServer{
+				logger: logger,
+				lock:   sync.RWMutex{},
+				byMetaData: map[podscaler.FullMetadata]corev1.ResourceRequirements{
+					baseWithContainer(&metaBase, "oomkilled"): {
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    *resource.NewQuantity(1, resource.DecimalSI),
+							corev1.ResourceMemory: *resource.NewQuantity(2e8, resource.BinarySI),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: *resource.NewQuantity(5e8, resource.BinarySI),
+						},
+					},
+					baseWithContainer(&metaBase, "unlimited"): {
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    *resource.NewQuantity(1, resource.DecimalSI),
+							corev1.ResourceMemory: *resource.NewQuantity(2e8, resource.BinarySI),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: *resource.NewQuantity(5e8, resource.BinarySI),
+						},
+					},
+				},
+				oomKilled: map[podscaler.FullMetadata]time.Time{
+					baseWithContainer(&metaBase, "oomkilled"): time.Now().Add(-time.Hour),
+				},
+				oomReported: map[podscaler.FullMetadata]time.Time{},
+			},
+			mutateResourceLimits: true,
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "tomutate",
+					Labels: map[string]string{
+						"ci.openshift.io/metadata.org":     "org",
+						"ci.openshift.io/metadata.repo":    "repo",
+						"ci.openshift.io/metadata.branch":  "branch",
+						"ci.openshift.io/metadata.variant": "variant",
+						"ci.openshift.io/metadata.target":  "target",
+						"ci.openshift.io/metadata.step":    "step",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "oomkilled", // memory request and limit are raised past the recommendation
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: *resource.NewQuantity(1e8, resource.BinarySI),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: *resource.NewQuantity(2e8, resource.BinarySI),
+								},
+							},
+						},
+						{
+							Name: "unlimited", // no limit is configured, so none is set
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: *resource.NewQuantity(1e8, resource.BinarySI),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/iterator"
+
+	"sigs.k8s.io/prow/pkg/interrupts"
+	"sigs.k8s.io/prow/pkg/metrics"
+
+	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+)
+
+const (
+	GroupQuery  = "group"
+	TopQuery    = "top"
+	FormatQuery = "format"
+
+	formatJSON = "json"
+	formatCSV  = "csv"
+
+	defaultTopOffenders = 10
+
+	// JobRunsCacheName is where the producer collects the job runs for the
+	// UI to account for their costs.
+	JobRunsCacheName = "costs/job-runs.json"
+	// jobRunRetention is how long collected job runs are accounted for.
+	jobRunRetention = 365 * 24 * time.Hour
+)
+
+// collectedJobRuns are the job runs ci-operator recorded in the artifacts of
+// jobs, as collected by the producer.
+type collectedJobRuns struct {
+	// Collected is when the newest artifact collected was written.
+	Collected time.Time `json:"collected"`
+	// Runs are keyed by the name of the artifact they were read from.
+	Runs map[string]podscaler.JobRun `json:"runs"`
+}
+
+// jobRunArtifacts closes over how the job runs ci-operator records in the
+// artifacts of jobs are found and read.
+type jobRunArtifacts interface {
+	loader
+	// updatedSince lists the artifacts written after the time, with when
+	// they were written.
+	updatedSince(ctx context.Context, since time.Time) (map[string]time.Time, error)
+}
+
+// bucketJobRunArtifacts finds the job runs in the GCS bucket jobs upload
+// their artifacts to.
+type bucketJobRunArtifacts struct {
+	*BucketCache
+}
+
+func (b *bucketJobRunArtifacts) updatedSince(ctx context.Context, since time.Time) (map[string]time.Time, error) {
+	query := &storage.Query{MatchGlob: "**/" + podscaler.JobRunFilename}
+	if err := query.SetAttrSelection([]string{"Name", "Updated"}); err != nil {
+		return nil, fmt.Errorf("could not select attributes: %w", err)
+	}
+	updated := map[string]time.Time{}
+	objects := b.Bucket.Objects(ctx, query)
+	for {
+		attrs, err := objects.Next()
+		if errors.Is(err, iterator.Done) {
+			return updated, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not list job runs: %w", err)
+		}
+		if attrs.Updated.After(since) {
+			updated[attrs.Name] = attrs.Updated
+		}
+	}
+}
+
+// collectJobRuns adds the job runs written since the last collection to those
+// collected in the cache, forgetting the runs which started before the
+// retention period.
+func collectJobRuns(ctx context.Context, artifacts jobRunArtifacts, cache Cache, now time.Time, logger *logrus.Entry) error {
+	collected, err := loadCollectedJobRuns(ctx, cache)
+	if err != nil {
+		return err
+	}
+	updated, err := artifacts.updatedSince(ctx, collected.Collected)
+	if err != nil {
+		return err
+	}
+	for name, at := range updated {
+		run, err := loadJobRun(ctx, artifacts, name)
+		if err != nil {
+			logger.WithError(err).Warnf("Failed to read job run from %s, skipping.", name)
+			continue
+		}
+		collected.Runs[name] = run
+		if at.After(collected.Collected) {
+			collected.Collected = at
+		}
+	}
+	for name, run := range collected.Runs {
+		if run.Started.Before(now.Add(-jobRunRetention)) {
+			delete(collected.Runs, name)
+		}
+	}
+	writer, err := cache.store(ctx, JobRunsCacheName)
+	if err != nil {
+		return fmt.Errorf("could not store job runs: %w", err)
+	}
+	if err := json.NewEncoder(writer).Encode(collected); err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("could not write job runs: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("could not write job runs: %w", err)
+	}
+	logger.Debugf("Collected %d new job runs, holding %d.", len(updated), len(collected.Runs))
+	return nil
+}
+
+func loadJobRun(ctx context.Context, artifacts loader, name string) (podscaler.JobRun, error) {
+	var run podscaler.JobRun
+	reader, err := artifacts.load(ctx, name)
+	if err != nil {
+		return run, fmt.Errorf("could not read job run: %w", err)
+	}
+	defer reader.Close()
+	if err := json.NewDecoder(reader).Decode(&run); err != nil {
+		return run, fmt.Errorf("could not unmarshal job run: %w", err)
+	}
+	return run, nil
+}
+
+// loadCollectedJobRuns reads the job runs collected in the cache, if any.
+func loadCollectedJobRuns(ctx context.Context, cache loader) (*collectedJobRuns, error) {
+	collected := &collectedJobRuns{Runs: map[string]podscaler.JobRun{}}
+	reader, err := cache.load(ctx, JobRunsCacheName)
+	if errors.Is(err, notExist{}) {
+		return collected, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read collected job runs: %w", err)
+	}
+	defer reader.Close()
+	if err := json.NewDecoder(reader).Decode(collected); err != nil {
+		return nil, fmt.Errorf("could not unmarshal collected job runs: %w", err)
+	}
+	if collected.Runs == nil {
+		collected.Runs = map[string]podscaler.JobRun{}
+	}
+	return collected, nil
+}
+
+// reloadJobRuns replaces the job runs accounted for with those collected in
+// the cache.
+func reloadJobRuns(costs *podscaler.Costs, cache loader, logger *logrus.Entry) func() {
+	return func() {
+		collected, err := loadCollectedJobRuns(interrupts.Context(), cache)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to load job runs, won't reload this tick.")
+			return
+		}
+		runs := make([]podscaler.JobRun, 0, len(collected.Runs))
+		for _, run := range collected.Runs {
+			runs = append(runs, run)
+		}
+		costs.SetJobRuns(runs)
+		logger.Debugf("Loaded %d job runs.", len(runs))
+	}
+}
+
+// getCosts serves a report of the costs, grouped by org, repo, job or step and
+// optionally filtered to an org and repo, as JSON or CSV.
+func (s *frontendServer) getCosts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotImplemented)
+			_, _ = w.Write([]byte(http.StatusText(http.StatusNotImplemented)))
+			return
+		}
+		query := r.URL.Query()
+		grouping := podscaler.CostGroupingRepo
+		if group := query.Get(GroupQuery); group != "" {
+			grouping = podscaler.CostGrouping(group)
+		}
+		if !validGrouping(grouping) {
+			metrics.RecordError("invalid query", uiMetrics.ErrorRate)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "%s query must be one of %v", GroupQuery, podscaler.CostGroupings)
+			return
+		}
+		top := defaultTopOffenders
+		if raw := query.Get(TopQuery); raw != "" {
+			var err error
+			if top, err = strconv.Atoi(raw); err != nil || top < 0 {
+				metrics.RecordError("invalid query", uiMetrics.ErrorRate)
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "%s query must be a non-negative number", TopQuery)
+				return
+			}
+		}
+		format := query.Get(FormatQuery)
+		if format == "" {
+			format = formatJSON
+		}
+		if format != formatJSON && format != formatCSV {
+			metrics.RecordError("invalid query", uiMetrics.ErrorRate)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "%s query must be %s or %s", FormatQuery, formatJSON, formatCSV)
+			return
+		}
+
+		report := s.costs.Report(grouping, podscaler.CostFilter{Org: query.Get(OrgQuery), Repo: query.Get(RepoQuery)}, top)
+		if format == formatCSV {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=costs-by-%s.csv", grouping))
+			if err := report.WriteCSV(w); err != nil {
+				s.logger.WithError(err).Error("Failed to write cost report.")
+			}
+			return
+		}
+		raw, err := json.Marshal(report)
+		if err != nil {
+			metrics.RecordError("failed to marshal costs", uiMetrics.ErrorRate)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "failed to marshal costs to JSON: %v", err)
+			s.logger.WithError(err).Error("Failed to marshal costs to JSON.")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(raw); err != nil {
+			s.logger.WithError(err).Error("Failed to write cost report.")
+		}
+	}
+}
+
+func validGrouping(grouping podscaler.CostGrouping) bool {
+	for _, valid := range podscaler.CostGroupings {
+		if grouping == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+)
+
+// fakeJobRunArtifacts serves job runs from a directory, written at the given times.
+type fakeJobRunArtifacts struct {
+	*LocalCache
+	updated map[string]time.Time
+}
+
+func (f *fakeJobRunArtifacts) updatedSince(_ context.Context, since time.Time) (map[string]time.Time, error) {
+	updated := map[string]time.Time{}
+	for name, at := range f.updated {
+		if at.After(since) {
+			updated[name] = at
+		}
+	}
+	return updated, nil
+}
+
+func TestCollectJobRuns(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	artifactsDir := t.TempDir()
+	for name, content := range map[string]string{
+		"logs/e2e/1/artifacts/" + podscaler.JobRunFilename: `{"metadata":{"org":"org","repo":"repo","branch":"master"},"job":"periodic-ci-org-repo-master-e2e","started":"2024-02-01T00:00:00Z","tests":[{"target":"e2e","cluster_profile":"aws","duration":3600000000000}]}`,
+		"logs/e2e/2/artifacts/" + podscaler.JobRunFilename: `{"metadata":{"org":"org","repo":"repo","branch":"master"},"job":"periodic-ci-org-repo-master-e2e","started":"2024-02-02T00:00:00Z"}`,
+		"logs/e2e/3/artifacts/" + podscaler.JobRunFilename: `not a job run`,
+	} {
+		path := filepath.Join(artifactsDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	artifacts := &fakeJobRunArtifacts{LocalCache: &LocalCache{Dir: artifactsDir}, updated: map[string]time.Time{
+		"logs/e2e/1/artifacts/" + podscaler.JobRunFilename: now.Add(-2 * time.Hour),
+		"logs/e2e/3/artifacts/" + podscaler.JobRunFilename: now.Add(-2 * time.Hour),
+	}}
+	cache := &LocalCache{Dir: t.TempDir()}
+	logger := logrus.WithField("test", t.Name())
+
+	// the previous collection holds a run which is no longer retained
+	writer, err := cache.store(context.Background(), JobRunsCacheName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte(`{"collected":"2024-02-29T00:00:00Z","runs":{"logs/e2e/0/artifacts/ci-operator-job-run.json":{"metadata":{"org":"org"},"started":"2022-01-01T00:00:00Z"}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := collectJobRuns(context.Background(), artifacts, cache, now, logger); err != nil {
+		t.Fatalf("failed to collect job runs: %v", err)
+	}
+	// only the run written since the last collection is read again
+	artifacts.updated["logs/e2e/2/artifacts/"+podscaler.JobRunFilename] = now.Add(-time.Hour)
+	if err := collectJobRuns(context.Background(), artifacts, cache, now, logger); err != nil {
+		t.Fatalf("failed to collect job runs: %v", err)
+	}
+
+	collected, err := loadCollectedJobRuns(context.Background(), cache)
+	if err != nil {
+		t.Fatalf("failed to load collected job runs: %v", err)
+	}
+	expected := &collectedJobRuns{
+		Collected: now.Add(-time.Hour),
+		Runs: map[string]podscaler.JobRun{
+			"logs/e2e/1/artifacts/" + podscaler.JobRunFilename: {
+				Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+				Job:      "periodic-ci-org-repo-master-e2e",
+				Started:  time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				Tests:    []podscaler.TestRun{{Target: "e2e", ClusterProfile: "aws", Duration: time.Hour}},
+			},
+			"logs/e2e/2/artifacts/" + podscaler.JobRunFilename: {
+				Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+				Job:      "periodic-ci-org-repo-master-e2e",
+				Started:  time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, collected); diff != "" {
+		t.Errorf("unexpected job runs: %s", diff)
+	}
+
+	costs := podscaler.NewCosts(podscaler.Prices{ClusterProfiles: map[string]float64{"aws": 2}})
+	reloadJobRuns(costs, cache, logger)()
+	if report := costs.Report(podscaler.CostGroupingJob, podscaler.CostFilter{}, 1); len(report.Entries) != 1 || report.Entries[0].Job != "periodic-ci-org-repo-master-e2e" || report.Entries[0].TotalCost != 2 {
+		t.Errorf("unexpected report from the reloaded job runs: %+v", report.Entries)
+	}
+}
+
+func TestGetCosts(t *testing.T) {
+	costs := podscaler.NewCosts(podscaler.Prices{ClusterProfiles: map[string]float64{"aws": 2}})
+	costs.SetJobRuns([]podscaler.JobRun{{
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+		Started:  time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Tests:    []podscaler.TestRun{{Target: "e2e", ClusterProfile: "aws", Duration: time.Hour}},
+	}})
+	server := &frontendServer{logger: logrus.WithField("test", t.Name()), costs: costs}
+
+	for _, tc := range []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedBody string
+	}{{
+		name:         "JSON by repo",
+		query:        "",
+		expectedCode: http.StatusOK,
+		expectedBody: `{"grouping":"repo","entries":[{"org":"org","repo":"repo","cpu_core_hours":0,"memory_gib_hours":0,"duration_hours":0,"compute_cost":0,"cluster_profile_cost":2,"lease_cost":0,"total_cost":2}],"trend":[{"month":"2024-02","total_cost":2}],"top_offenders":[{"org":"org","repo":"repo","month":"2024-02","cpu_core_hours":0,"memory_gib_hours":0,"duration_hours":0,"compute_cost":0,"cluster_profile_cost":2,"lease_cost":0,"total_cost":2}]}`,
+	}, {
+		name:         "CSV by job",
+		query:        "?group=job&format=csv",
+		expectedCode: http.StatusOK,
+		expectedBody: "org,repo,branch,variant,target,job,step,cpu_core_hours,memory_gib_hours,duration_hours,compute_cost,cluster_profile_cost,lease_cost,total_cost\norg,repo,master,,e2e,,,0.0000,0.0000,0.0000,0.0000,2.0000,0.0000,2.0000\n",
+	}, {
+		name:         "filtered out",
+		query:        "?org=other&top=0",
+		expectedCode: http.StatusOK,
+		expectedBody: `{"grouping":"repo","entries":[],"trend":[],"top_offenders":[]}`,
+	}, {
+		name:         "invalid grouping",
+		query:        "?group=cluster",
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "group query must be one of [org repo job step]",
+	}, {
+		name:         "invalid top",
+		query:        "?top=-1",
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "top query must be a non-negative number",
+	}, {
+		name:         "invalid format",
+		query:        "?format=xml",
+		expectedCode: http.StatusBadRequest,
+		expectedBody: "format query must be json or csv",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			server.getCosts().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/costs"+tc.query, nil))
+			if recorder.Code != tc.expectedCode {
+				t.Errorf("expected code %d, got %d", tc.expectedCode, recorder.Code)
+			}
+			if diff := cmp.Diff(tc.expectedBody, recorder.Body.String()); diff != "" {
+				t.Errorf("unexpected body: %s", diff)
+			}
+		})
+	}
+}
@@ -201,7 +201,7 @@ var (
 	static embed.FS
 )
 
-func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheReloader) {
+func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheReloader, policies *podscaler.Policies, costs *podscaler.Costs) {
 	logger := logrus.WithField("component", "pod-scaler frontend")
 	server := &frontendServer{
 		logger:   logger,
@@ -209,6 +209,8 @@ func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheRe
 		mappings: endpoints(),
 		indices:  map[string][]*IndexNode{},
 		dataDir:  dataDir,
+		policies: policies,
+		costs:    costs,
 	}
 	health := pjutil.NewHealthOnPort(healthPort)
 	digestAll(loaders, map[string]digester{
@@ -224,6 +226,7 @@ func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheRe
 	simplifier := simplifypath.NewSimplifier(l("", // shadow element mimicing the root
 		l(""), // actual UI
 		l("api",
+			l("costs"),
 			l("data",
 				nodes...,
 			),
@@ -259,6 +262,9 @@ func serveUI(port, healthPort int, dataDir string, loaders map[string][]*cacheRe
 		mux.HandleFunc(fmt.Sprintf("/api/data/%s", name), handler(server.getData(name)).ServeHTTP)
 		mux.HandleFunc(fmt.Sprintf("/api/indices/%s", name), handler(server.getIndex(name)).ServeHTTP)
 	}
+	if costs != nil {
+		mux.HandleFunc("/api/costs", handler(server.getCosts()).ServeHTTP)
+	}
 	httpServer := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: mux}
 	interrupts.ListenAndServe(httpServer, 5*time.Second)
 	logger.Debug("Ready to serve HTTP requests.")
@@ -323,14 +329,22 @@ type frontendServer struct {
 
 	// dataDir is where we hold sharded data by metadata identifier
 	dataDir string
+
+	// policies determine how recommendations are derived from usage data
+	policies *podscaler.Policies
+
+	// costs account for what workloads cost, when prices are configured
+	costs *podscaler.Costs
 }
 
 // dataForDisplay caches precomputed values for displaying data
 type dataForDisplay struct {
-	Cutoff     float64                     `json:"cutoff"`
-	LowerBound float64                     `json:"lower_bound"`
-	Merged     *circonusllhist.Histogram   `json:"merged"`
-	Histograms []*circonusllhist.Histogram `json:"histograms"`
+	// Cutoff is the recommendation made for the data under the policy
+	Cutoff     float64                        `json:"cutoff"`
+	Policy     podscaler.RecommendationPolicy `json:"policy"`
+	LowerBound float64                        `json:"lower_bound"`
+	Merged     *circonusllhist.Histogram      `json:"merged"`
+	Histograms []*circonusllhist.Histogram    `json:"histograms"`
 }
 
 func (s *frontendServer) getIndex(index string) http.HandlerFunc {
@@ -463,15 +477,21 @@ func (s *frontendServer) setDatum(meta podscaler.FullMetadata, resource corev1.R
 
 func (s *frontendServer) digestCPU(data *podscaler.CachedQuery) {
 	s.logger.Debugf("Digesting new CPU consumption metrics.")
-	s.digestData(data, corev1.ResourceCPU, cpuRequestQuantile)
+	s.digestData(data, corev1.ResourceCPU)
+	if s.costs != nil {
+		s.costs.SetUsage(data, corev1.ResourceCPU)
+	}
 }
 
 func (s *frontendServer) digestMemory(data *podscaler.CachedQuery) {
 	s.logger.Debugf("Digesting new Memory consumption metrics.")
-	s.digestData(data, corev1.ResourceMemory, memRequestQuantile)
+	s.digestData(data, corev1.ResourceMemory)
+	if s.costs != nil {
+		s.costs.SetUsage(data, corev1.ResourceMemory)
+	}
 }
 
-func (s *frontendServer) digestData(data *podscaler.CachedQuery, metric corev1.ResourceName, quantile float64) {
+func (s *frontendServer) digestData(data *podscaler.CachedQuery, metric corev1.ResourceName) {
 	s.logger.Debugf("Digesting %d identifiers.", len(data.DataByMetaData))
 	for meta, fingerprintTimes := range data.DataByMetaData {
 		s.lock.Lock()
@@ -496,8 +516,10 @@ func (s *frontendServer) digestData(data *podscaler.CachedQuery, metric corev1.R
 			overall.Merge(data.Data[fingerprint].Histogram())
 			members = append(members, data.Data[fingerprint].Histogram())
 		}
+		policy, _ := s.policies.For(meta, metric)
 		if err := s.setDatum(meta, metric, dataForDisplay{
-			Cutoff:     overall.ValueAtQuantile(quantile),
+			Cutoff:     policy.Recommend(overall),
+			Policy:     policy,
 			LowerBound: overall.ValueAtQuantile(.001),
 			Merged:     overall,
 			Histograms: members,
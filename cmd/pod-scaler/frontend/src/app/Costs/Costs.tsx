@@ -0,0 +1,218 @@
+import * as React from 'react';
+import {
+  ActionGroup,
+  Alert,
+  Button,
+  Divider,
+  Form,
+  FormGroup,
+  FormSelect,
+  FormSelectOption,
+  Page,
+  PageSection,
+  PageSectionVariants,
+  Spinner,
+  Text,
+  TextContent,
+  TextInput,
+} from '@patternfly/react-core';
+import {css} from '@patternfly/react-styles';
+import styles from '@patternfly/react-styles/css/components/Table/table';
+
+export interface CostEntry {
+  org?: string;
+  repo?: string;
+  branch?: string;
+  variant?: string;
+  target?: string;
+  job?: string;
+  step?: string;
+  month?: string;
+  cpu_core_hours: number;
+  memory_gib_hours: number;
+  duration_hours: number;
+  compute_cost: number;
+  cluster_profile_cost: number;
+  lease_cost: number;
+  total_cost: number;
+}
+
+export interface MonthlyCost {
+  month: string;
+  total_cost: number;
+}
+
+export interface CostReport {
+  grouping: string;
+  entries: CostEntry[] | null;
+  trend: MonthlyCost[] | null;
+  top_offenders: CostEntry[] | null;
+}
+
+interface CostQuery {
+  group: string;
+  org: string;
+  repo: string;
+  top: string;
+}
+
+const groupings = ["org", "repo", "job", "step"];
+
+const parametersFor = (query: CostQuery): URLSearchParams => {
+  const parameters = new URLSearchParams();
+  for (const [key, value] of Object.entries(query)) {
+    if (value) {
+      parameters.set(key, value);
+    }
+  }
+  return parameters;
+}
+
+const nameOf = (entry: CostEntry): string => {
+  return [entry.org, entry.repo, entry.branch, entry.variant, entry.target, entry.job, entry.step]
+    .filter(part => part)
+    .join("/");
+}
+
+const CostTable: React.FunctionComponent<{ caption: string, entries: CostEntry[] }> = (
+  {caption, entries}: { caption: string, entries: CostEntry[] }) => {
+  return <table className={css(styles.table, styles.modifiers.compact)} aria-label={caption}>
+    <caption>{caption}</caption>
+    <thead>
+    <tr>
+      <th>Name</th>
+      <th>Month</th>
+      <th>CPU Core Hours</th>
+      <th>Memory GiB Hours</th>
+      <th>Duration Hours</th>
+      <th>Compute Cost</th>
+      <th>Cluster Profile Cost</th>
+      <th>Lease Cost</th>
+      <th>Total Cost</th>
+    </tr>
+    </thead>
+    <tbody>
+    {entries.map((entry, idx) => <tr key={idx}>
+      <td>{nameOf(entry)}</td>
+      <td>{entry.month}</td>
+      <td>{entry.cpu_core_hours.toFixed(2)}</td>
+      <td>{entry.memory_gib_hours.toFixed(2)}</td>
+      <td>{entry.duration_hours.toFixed(2)}</td>
+      <td>{entry.compute_cost.toFixed(2)}</td>
+      <td>{entry.cluster_profile_cost.toFixed(2)}</td>
+      <td>{entry.lease_cost.toFixed(2)}</td>
+      <td>{entry.total_cost.toFixed(2)}</td>
+    </tr>)}
+    </tbody>
+  </table>;
+}
+
+const TrendTable: React.FunctionComponent<{ trend: MonthlyCost[] }> = ({trend}: { trend: MonthlyCost[] }) => {
+  return <table className={css(styles.table, styles.modifiers.compact)} aria-label="Monthly trend">
+    <caption>Monthly Trend</caption>
+    <thead>
+    <tr>
+      <th>Month</th>
+      <th>Total Cost</th>
+    </tr>
+    </thead>
+    <tbody>
+    {trend.map(month => <tr key={month.month}>
+      <td>{month.month}</td>
+      <td>{month.total_cost.toFixed(2)}</td>
+    </tr>)}
+    </tbody>
+  </table>;
+}
+
+export const Costs: React.FunctionComponent = () => {
+  const [form, setForm] = React.useState<CostQuery>({group: "repo", org: "", repo: "", top: "10"});
+  const [query, setQuery] = React.useState<CostQuery>(form);
+  const [report, setReport] = React.useState<CostReport>();
+  const [fetchError, setFetchError] = React.useState<string>("");
+
+  React.useEffect(() => {
+    let mounted = true;
+    setReport(undefined);
+    setFetchError("");
+    fetch("/api/costs?" + parametersFor(query), {headers: {"Accept": "application/json"}}).then(async (res) => {
+      if (!res.ok) {
+        const raw = await res.text();
+        throw new Error(res.status + ": " + raw);
+      }
+      const raw: CostReport = await res.json();
+      if (mounted) {
+        setReport(raw);
+      }
+    }).catch((error) => {
+      if (mounted) {
+        setFetchError(String(error));
+      }
+    })
+    return () => {
+      mounted = false
+    };
+  }, [query]);
+
+  const csvParameters = parametersFor(query);
+  csvParameters.set("format", "csv");
+
+  let body: JSX.Element;
+  if (fetchError) {
+    body = <Alert variant="danger" title={fetchError}/>;
+  } else if (!report) {
+    body = <div><Spinner isSVG size="xl"/>Loading costs...</div>;
+  } else {
+    body = <React.Fragment>
+      <a href={"/api/costs?" + csvParameters} download>Download as CSV</a>
+      <TrendTable trend={report.trend || []}/>
+      <CostTable caption="Top Offenders" entries={report.top_offenders || []}/>
+      <CostTable caption="Costs" entries={report.entries || []}/>
+    </React.Fragment>;
+  }
+
+  return (
+    <React.Fragment>
+      <Page>
+        <PageSection variant={PageSectionVariants.light}>
+          <TextContent>
+            <Text component="h1">Costs of CI Workloads</Text>
+            <Text component="p">
+              Choose how to group the costs of recent job runs, optionally limited to an org and repo.
+            </Text>
+          </TextContent>
+        </PageSection>
+        <Divider component="div"/>
+        <PageSection>
+          <Form isHorizontal onSubmit={(event) => {
+            event.preventDefault();
+            setQuery(form);
+          }}>
+            <FormGroup label="Group by" fieldId="costs-group">
+              <FormSelect id="costs-group" value={form.group} aria-label="Group by"
+                          onChange={(group) => setForm({...form, group: group})}>
+                {groupings.map(grouping => <FormSelectOption key={grouping} value={grouping} label={grouping}/>)}
+              </FormSelect>
+            </FormGroup>
+            <FormGroup label="Org" fieldId="costs-org">
+              <TextInput id="costs-org" value={form.org} onChange={(org) => setForm({...form, org: org})}/>
+            </FormGroup>
+            <FormGroup label="Repo" fieldId="costs-repo">
+              <TextInput id="costs-repo" value={form.repo} onChange={(repo) => setForm({...form, repo: repo})}/>
+            </FormGroup>
+            <FormGroup label="Top offenders" fieldId="costs-top">
+              <TextInput id="costs-top" type="number" value={form.top}
+                         onChange={(top) => setForm({...form, top: top})}/>
+            </FormGroup>
+            <ActionGroup>
+              <Button type="submit" variant="primary">Show</Button>
+            </ActionGroup>
+          </Form>
+          {body}
+        </PageSection>
+      </Page>
+    </React.Fragment>
+  );
+};
+
+Costs.displayName = 'Costs';
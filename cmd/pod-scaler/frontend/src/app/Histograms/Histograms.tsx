@@ -1,6 +1,15 @@
 import {Buffer} from 'buffer';
 import * as React from 'react';
-import {Alert, Flex, Spinner} from '@patternfly/react-core';
+import {
+    Alert,
+    DescriptionList,
+    DescriptionListDescription,
+    DescriptionListGroup,
+    DescriptionListTerm,
+    Flex,
+    FlexItem,
+    Spinner
+} from '@patternfly/react-core';
 import {DeserializeHistogram, Histogram} from "@app/CircLLHist/CircLLHist";
 import {LogarithmicComparativePlot} from "@app/CircLLHist/LogarithmicComparativePlot";
 
@@ -11,8 +20,17 @@ export interface HistogramsProps {
     parameters: string;
 }
 
+/** the recommendation policy in effect for the data, quantities are serialized as strings */
+export interface Policy {
+    quantile?: number;
+    headroom?: number;
+    min?: string;
+    max?: string;
+}
+
 export interface rawData {
     cutoff: string;
+    policy?: Policy;
     lower_bound: string;
     merged: string;
     histograms: string[];
@@ -20,6 +38,7 @@ export interface rawData {
 
 export interface Data {
     cutoff: number;
+    policy: Policy;
     lower_bound: number;
     merged: Histogram;
     histograms: Histogram[];
@@ -32,6 +51,7 @@ const process = (raw: Record<string, rawData>): HistogramData => {
     for (const resource in raw) {
             const datum: Data = {
                 cutoff: parseFloat(raw[resource].cutoff),
+                policy: raw[resource].policy || {},
                 lower_bound: parseFloat(raw[resource].lower_bound),
                 merged: DeserializeHistogram(Buffer.from(raw[resource].merged, 'base64')),
                 histograms: [],
@@ -44,6 +64,29 @@ const process = (raw: Record<string, rawData>): HistogramData => {
     return data;
 }
 
+interface PolicySummaryProps {
+    policy: Policy;
+    /** the recommendation, formatted with its unit */
+    cutoff: string;
+}
+
+/** PolicySummary shows the recommendation policy which produced the cutoff */
+const PolicySummary: React.FunctionComponent<PolicySummaryProps> = ({policy, cutoff}: PolicySummaryProps) => {
+    const terms: [string, string][] = [
+        ["Recommendation", cutoff],
+        ["Quantile", policy.quantile !== undefined ? policy.quantile.toString() : "-"],
+        ["Headroom", policy.headroom !== undefined ? policy.headroom.toString() : "-"],
+        ["Minimum", policy.min || "-"],
+        ["Maximum", policy.max || "-"],
+    ];
+    return <DescriptionList isHorizontal>
+        {terms.map(([term, description]) => <DescriptionListGroup key={term}>
+            <DescriptionListTerm>{term}</DescriptionListTerm>
+            <DescriptionListDescription>{description}</DescriptionListDescription>
+        </DescriptionListGroup>)}
+    </DescriptionList>;
+}
+
 export const Histograms: React.FunctionComponent<HistogramsProps> = (
     {
         dataUrl,
@@ -82,41 +125,56 @@ export const Histograms: React.FunctionComponent<HistogramsProps> = (
         return <div><Spinner isSVG size="xl"/>Loading resource usage data...</div>
     }
 
+    const formatCPU = (value: number): string => {
+        const n: number = value * 1000;
+        if (value > 10) {
+            Math.round(n).toString();
+        }
+        return n.toFixed(2);
+    };
+    const formatMemory = (value: number): string => {
+        const n: number = value / Math.pow(2, 20);
+        if (value > 10) {
+            Math.round(n).toString();
+        }
+        return n.toFixed(2);
+    };
+
     return <Flex direction={{default: 'row'}}
                  flexWrap={{default: 'wrap', lg: "nowrap", xl: "nowrap", '2xl': "nowrap"}}
                  justifyContent={{default: 'justifyContentSpaceAround'}}
                  alignItems={{default: 'alignItemsCenter'}}
                  alignContent={{default: 'alignContentStretch'}}>
-        {data["cpu"] && <LogarithmicComparativePlot
-            {...data["cpu"]}
-            canvasProps={{
-                title: "CPU Usage",
-                yAxisFormatter(value: number): string {
-                    const n: number = value * 1000;
-                    if (value > 10) {
-                        Math.round(n).toString();
-                    }
-                    return n.toFixed(2);
-                },
-                yAxisMin: 1e-5,
-                yAxisTitle: "CPU Used",
-                yAxisUnit: "mCPU",
-            }}/>}
-        {data["memory"] && <LogarithmicComparativePlot
-            {...data["memory"]}
-            canvasProps={{
-                title: "Memory Usage",
-                yAxisFormatter(value: number): string {
-                    const n: number = value / Math.pow(2, 20);
-                    if (value > 10) {
-                        Math.round(n).toString();
-                    }
-                    return n.toFixed(2);
-                },
-                yAxisMin: 10 * Math.pow(2, 20),
-                yAxisTitle: "Memory Used",
-                yAxisUnit: "MiB",
-            }}/>}
+        {data["cpu"] && <FlexItem>
+            <LogarithmicComparativePlot
+                cutoff={data["cpu"].cutoff}
+                lower_bound={data["cpu"].lower_bound}
+                merged={data["cpu"].merged}
+                histograms={data["cpu"].histograms}
+                canvasProps={{
+                    title: "CPU Usage",
+                    yAxisFormatter: formatCPU,
+                    yAxisMin: 1e-5,
+                    yAxisTitle: "CPU Used",
+                    yAxisUnit: "mCPU",
+                }}/>
+            <PolicySummary policy={data["cpu"].policy} cutoff={formatCPU(data["cpu"].cutoff) + " mCPU"}/>
+        </FlexItem>}
+        {data["memory"] && <FlexItem>
+            <LogarithmicComparativePlot
+                cutoff={data["memory"].cutoff}
+                lower_bound={data["memory"].lower_bound}
+                merged={data["memory"].merged}
+                histograms={data["memory"].histograms}
+                canvasProps={{
+                    title: "Memory Usage",
+                    yAxisFormatter: formatMemory,
+                    yAxisMin: 10 * Math.pow(2, 20),
+                    yAxisTitle: "Memory Used",
+                    yAxisUnit: "MiB",
+                }}/>
+            <PolicySummary policy={data["memory"].policy} cutoff={formatMemory(data["memory"].cutoff) + " MiB"}/>
+        </FlexItem>}
     </Flex>;
 };
 
@@ -10,6 +10,7 @@ import { ProwJobs } from '@app/ResourceUsage/ProwJobs/ProwJobs';
 import { Pods } from '@app/ResourceUsage/Pods/Pods';
 import { RPMs } from '@app/ResourceUsage/RPMRepos/RPMRepos';
 import { Landing } from '@app/Landing/Landing';
+import { Costs } from '@app/Costs/Costs';
 
 let routeFocusTimer: number;
 export interface IAppRoute {
@@ -79,6 +80,13 @@ const routes: AppRouteConfig[] = [
       },
     ],
   },
+  {
+    component: Costs,
+    exact: true,
+    label: 'Costs',
+    path: '/costs',
+    title: 'Resource Usage | Costs',
+  },
 ];
 
 // a custom hook for sending focus to the primary content container
@@ -29,6 +29,7 @@ import (
 	buildclientset "github.com/openshift/client-go/build/clientset/versioned/typed/build/v1"
 	routeclientset "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
 
+	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
 	"github.com/openshift/ci-tools/pkg/prowconfigutils"
 	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/util"
@@ -55,6 +56,7 @@ type producerOptions struct {
 	kubernetesOptions prowflagutil.KubernetesOptions
 	once              bool
 	ignoreLatest      time.Duration
+	jobRunsBucket     string
 }
 
 type consumerOptions struct {
@@ -67,6 +69,8 @@ type consumerOptions struct {
 	cpuCap                int64
 	memoryCap             string
 	cpuPriorityScheduling int64
+	policyFile            string
+	pricesFile            string
 }
 
 func bindOptions(fs *flag.FlagSet) *options {
@@ -89,6 +93,9 @@ func bindOptions(fs *flag.FlagSet) *options {
 	fs.Int64Var(&o.cpuCap, "cpu-cap", 10, "The maximum CPU request value, ex: 10")
 	fs.StringVar(&o.memoryCap, "memory-cap", "20Gi", "The maximum memory request value, ex: '20Gi'")
 	fs.Int64Var(&o.cpuPriorityScheduling, "cpu-priority-scheduling", 8, "Pods with CPU requests at, or above, this value will be admitted with priority scheduling")
+	fs.StringVar(&o.policyFile, "policy-file", "", "Path to a file configuring the quantile, headroom and bounds of recommendations per workload.")
+	fs.StringVar(&o.pricesFile, "cost-prices-file", "", "Path to a file with the prices of resources, cluster profiles and leases. When set, the UI serves cost reports.")
+	fs.StringVar(&o.jobRunsBucket, "cost-job-runs-bucket", "", "GCS bucket holding the artifacts of jobs, to collect the "+podscaler.JobRunFilename+" records of ci-operator from for the UI to account for the costs of cluster profiles and leases.")
 	o.resultsOptions.Bind(fs)
 	return &o
 }
@@ -101,6 +108,9 @@ const (
 func (o *options) validate() error {
 	switch o.mode {
 	case "producer":
+		if o.jobRunsBucket != "" && o.gcsCredentialsFile == "" {
+			return errors.New("--cost-job-runs-bucket requires --gcs-credentials-file")
+		}
 		return o.kubernetesOptions.Validate(false)
 	case "consumer.ui":
 		if o.uiPort == 0 {
@@ -241,12 +251,44 @@ func mainProduce(opts *options, cache Cache) {
 		logger.Debugf("Loaded Prometheus client.")
 	}
 
+	if opts.jobRunsBucket != "" {
+		gcsClient, err := storage.NewClient(interrupts.Context(), option.WithCredentialsFile(opts.gcsCredentialsFile))
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not initialize GCS client.")
+		}
+		artifacts := &bucketJobRunArtifacts{BucketCache: &BucketCache{Bucket: gcsClient.Bucket(opts.jobRunsBucket)}}
+		logger := logrus.WithField("component", "pod-scaler costs")
+		collect := func() {
+			if err := collectJobRuns(interrupts.Context(), artifacts, cache, time.Now(), logger); err != nil {
+				logger.WithError(err).Error("Failed to collect job runs.")
+			}
+		}
+		if opts.once {
+			collect()
+		} else {
+			interrupts.TickLiteral(collect, 2*time.Hour)
+		}
+	}
+
 	produce(clients, cache, opts.ignoreLatest, opts.once)
 
 }
 
 func mainUI(opts *options, cache Cache) {
-	go serveUI(opts.uiPort, opts.instrumentationOptions.HealthPort, opts.dataDir, loaders(cache))
+	policies, err := podscaler.LoadPolicies(opts.policyFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load recommendation policies.")
+	}
+	var costs *podscaler.Costs
+	if opts.pricesFile != "" {
+		prices, err := podscaler.LoadPrices(opts.pricesFile)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to load prices.")
+		}
+		costs = podscaler.NewCosts(*prices)
+		interrupts.TickLiteral(reloadJobRuns(costs, cache, logrus.WithField("component", "pod-scaler costs")), time.Hour)
+	}
+	go serveUI(opts.uiPort, opts.instrumentationOptions.HealthPort, opts.dataDir, loaders(cache, MetricNameCPUUsage, MetricNameMemoryWorkingSet), policies, costs)
 }
 
 func mainAdmission(opts *options, cache Cache) {
@@ -264,15 +306,20 @@ func mainAdmission(opts *options, cache Cache) {
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to create pod-scaler reporter.")
 	}
+	policies, err := podscaler.LoadPolicies(opts.policyFile)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load recommendation policies.")
+	}
 
-	go admit(opts.port, opts.instrumentationOptions.HealthPort, opts.certDir, client, loaders(cache), opts.mutateResourceLimits, opts.cpuCap, opts.memoryCap, opts.cpuPriorityScheduling, reporter)
+	go admit(opts.port, opts.instrumentationOptions.HealthPort, opts.certDir, client, loaders(cache, MetricNameCPUUsage, MetricNameMemoryWorkingSet, MetricNameOOMKilled), policies, opts.mutateResourceLimits, opts.cpuCap, opts.memoryCap, opts.cpuPriorityScheduling, reporter)
 }
 
-func loaders(cache Cache) map[string][]*cacheReloader {
+func loaders(cache Cache, metrics ...string) map[string][]*cacheReloader {
 	l := map[string][]*cacheReloader{}
 	for _, prefix := range []string{ProwjobsCachePrefix, PodsCachePrefix, StepsCachePrefix} {
-		l[MetricNameCPUUsage] = append(l[MetricNameCPUUsage], newReloader(prefix+"/"+MetricNameCPUUsage, cache))
-		l[MetricNameMemoryWorkingSet] = append(l[MetricNameMemoryWorkingSet], newReloader(prefix+"/"+MetricNameMemoryWorkingSet, cache))
+		for _, metric := range metrics {
+			l[metric] = append(l[metric], newReloader(prefix+"/"+metric, cache))
+		}
 	}
 	return l
 }
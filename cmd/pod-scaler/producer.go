@@ -23,8 +23,12 @@ import (
 const (
 	MetricNameCPUUsage         = `container_cpu_usage_seconds_total`
 	MetricNameMemoryWorkingSet = `container_memory_working_set_bytes`
+	MetricNameOOMKilled        = `kube_pod_container_status_last_terminated_reason`
+	// metricNameTerminated holds the time at which containers last terminated
+	metricNameTerminated = `kube_pod_container_status_last_terminated_timestamp`
 
 	containerFilter = `{container!="POD",container!=""}`
+	oomKilledFilter = `{reason="OOMKilled",container!="POD",container!=""}`
 
 	// MaxSamplesPerRequest is the maximum number of samples that Prometheus will allow a client to ask for in
 	// one request. We also use this to approximate the maximum number of samples we should be asking any one
@@ -63,6 +67,8 @@ func queriesByMetric() map[string]string {
 		for name, metric := range map[string]string{
 			MetricNameCPUUsage:         `rate(` + MetricNameCPUUsage + containerFilter + `[3m])`,
 			MetricNameMemoryWorkingSet: MetricNameMemoryWorkingSet + containerFilter,
+			// the value of the samples is the time at which the container was OOM-killed
+			MetricNameOOMKilled: metricNameTerminated + containerFilter + ` * on(namespace,pod,container) ` + MetricNameOOMKilled + oomKilledFilter,
 		} {
 			queries[fmt.Sprintf("%s/%s", info.prefix, name)] = queryFor(metric, info.selector, info.labels)
 		}
@@ -203,7 +209,7 @@ func (q *querier) execute(ctx context.Context, c *clusterMetadata, until time.Ti
 	r := prometheusapi.Range{
 		Start: time.Now().Add(-time.Duration(retention)),
 		End:   until,
-		Step:  1 * time.Minute,
+		Step:  podscaler.UsageSampleInterval,
 	}
 
 	errLock := &sync.Mutex{}
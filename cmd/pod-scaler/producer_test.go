@@ -45,6 +45,33 @@ func TestQueriesByMetric(t *testing.T) {
     container
   ) (container_memory_working_set_bytes{container!="POD",container!=""})
   * on(namespace,pod) 
+  group_left(
+    label_ci_openshift_io_metadata_org,
+    label_ci_openshift_io_metadata_repo,
+    label_ci_openshift_io_metadata_branch,
+    label_ci_openshift_io_metadata_variant,
+    label_ci_openshift_io_metadata_target,
+    label_openshift_io_build_name,
+    label_ci_openshift_io_release,
+    label_app
+  ) max by (
+    namespace,
+    pod,
+    label_ci_openshift_io_metadata_org,
+    label_ci_openshift_io_metadata_repo,
+    label_ci_openshift_io_metadata_branch,
+    label_ci_openshift_io_metadata_variant,
+    label_ci_openshift_io_metadata_target,
+    label_openshift_io_build_name,
+    label_ci_openshift_io_release,
+    label_app
+  ) (kube_pod_labels{label_created_by_ci="true",label_ci_openshift_io_metadata_step=""})`,
+		"pods/kube_pod_container_status_last_terminated_reason": `sum by (
+    namespace,
+    pod,
+    container
+  ) (kube_pod_container_status_last_terminated_timestamp{container!="POD",container!=""} * on(namespace,pod,container) kube_pod_container_status_last_terminated_reason{reason="OOMKilled",container!="POD",container!=""})
+  * on(namespace,pod) 
   group_left(
     label_ci_openshift_io_metadata_org,
     label_ci_openshift_io_metadata_repo,
@@ -97,6 +124,31 @@ func TestQueriesByMetric(t *testing.T) {
     container
   ) (container_memory_working_set_bytes{container!="POD",container!=""})
   * on(namespace,pod) 
+  group_left(
+    label_created_by_prow,
+    label_prow_k8s_io_context,
+    label_prow_k8s_io_refs_org,
+    label_prow_k8s_io_refs_repo,
+    label_prow_k8s_io_refs_base_ref,
+    label_prow_k8s_io_job,
+    label_prow_k8s_io_type
+  ) max by (
+    namespace,
+    pod,
+    label_created_by_prow,
+    label_prow_k8s_io_context,
+    label_prow_k8s_io_refs_org,
+    label_prow_k8s_io_refs_repo,
+    label_prow_k8s_io_refs_base_ref,
+    label_prow_k8s_io_job,
+    label_prow_k8s_io_type
+  ) (kube_pod_labels{label_created_by_prow="true",label_prow_k8s_io_job!="",label_ci_openshift_org_rehearse=""})`,
+		"prowjobs/kube_pod_container_status_last_terminated_reason": `sum by (
+    namespace,
+    pod,
+    container
+  ) (kube_pod_container_status_last_terminated_timestamp{container!="POD",container!=""} * on(namespace,pod,container) kube_pod_container_status_last_terminated_reason{reason="OOMKilled",container!="POD",container!=""})
+  * on(namespace,pod) 
   group_left(
     label_created_by_prow,
     label_prow_k8s_io_context,
@@ -145,6 +197,29 @@ func TestQueriesByMetric(t *testing.T) {
     container
   ) (container_memory_working_set_bytes{container!="POD",container!=""})
   * on(namespace,pod) 
+  group_left(
+    label_ci_openshift_io_metadata_org,
+    label_ci_openshift_io_metadata_repo,
+    label_ci_openshift_io_metadata_branch,
+    label_ci_openshift_io_metadata_variant,
+    label_ci_openshift_io_metadata_target,
+    label_ci_openshift_io_metadata_step
+  ) max by (
+    namespace,
+    pod,
+    label_ci_openshift_io_metadata_org,
+    label_ci_openshift_io_metadata_repo,
+    label_ci_openshift_io_metadata_branch,
+    label_ci_openshift_io_metadata_variant,
+    label_ci_openshift_io_metadata_target,
+    label_ci_openshift_io_metadata_step
+  ) (kube_pod_labels{label_created_by_ci="true",label_ci_openshift_io_metadata_step!=""})`,
+		"steps/kube_pod_container_status_last_terminated_reason": `sum by (
+    namespace,
+    pod,
+    container
+  ) (kube_pod_container_status_last_terminated_timestamp{container!="POD",container!=""} * on(namespace,pod,container) kube_pod_container_status_last_terminated_reason{reason="OOMKilled",container!="POD",container!=""})
+  * on(namespace,pod) 
   group_left(
     label_ci_openshift_io_metadata_org,
     label_ci_openshift_io_metadata_repo,
@@ -2,6 +2,7 @@ package main
 
 import (
 	"sync"
+	"time"
 
 	"github.com/openhistogram/circonusllhist"
 	"github.com/sirupsen/logrus"
@@ -13,16 +14,20 @@ import (
 	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
 )
 
-func newResourceServer(loaders map[string][]*cacheReloader, health *pjutil.Health) *resourceServer {
+func newResourceServer(loaders map[string][]*cacheReloader, policies *podscaler.Policies, health *pjutil.Health) *resourceServer {
 	logger := logrus.WithField("component", "pod-scaler request server")
 	server := &resourceServer{
-		logger:     logger,
-		lock:       sync.RWMutex{},
-		byMetaData: map[podscaler.FullMetadata]corev1.ResourceRequirements{},
+		logger:      logger,
+		lock:        sync.RWMutex{},
+		byMetaData:  map[podscaler.FullMetadata]corev1.ResourceRequirements{},
+		oomKilled:   map[podscaler.FullMetadata]time.Time{},
+		oomReported: map[podscaler.FullMetadata]time.Time{},
+		policies:    policies,
 	}
 	digestAll(loaders, map[string]digester{
 		MetricNameCPUUsage:         server.digestCPU,
 		MetricNameMemoryWorkingSet: server.digestMemory,
+		MetricNameOOMKilled:        server.digestOOMKilled,
 	}, health, logger)
 
 	return server
@@ -34,43 +39,41 @@ type resourceServer struct {
 	// byMetaData caches resource requirements calculated for the full assortment of
 	// metadata labels.
 	byMetaData map[podscaler.FullMetadata]corev1.ResourceRequirements
+	// oomKilled records the last time at which a container with the metadata
+	// was seen terminated for running out of memory.
+	oomKilled map[podscaler.FullMetadata]time.Time
+	// oomReported records the last OOM-kill reported for the metadata, so
+	// that each one is reported once, not for every Pod admitted afterwards.
+	oomReported map[podscaler.FullMetadata]time.Time
+	// policies determine how recommendations are derived from usage data
+	policies *podscaler.Policies
 }
 
-const (
-	// cpuRequestQuantile is the quantile of CPU core usage data to use as the CPU request
-	cpuRequestQuantile = 0.8
-)
-
 func formatCPU() toQuantity {
-	return func(valueAtQuantile float64) *resource.Quantity {
-		return resource.NewMilliQuantity(int64(valueAtQuantile*1000), resource.DecimalSI)
+	return func(value float64) *resource.Quantity {
+		return resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
 	}
 }
 
 func (s *resourceServer) digestCPU(data *podscaler.CachedQuery) {
 	s.logger.Debugf("Digesting new CPU consumption metrics.")
-	s.digestData(data, cpuRequestQuantile, corev1.ResourceCPU, formatCPU())
+	s.digestData(data, corev1.ResourceCPU, formatCPU())
 }
 
-const (
-	// memRequestQuantile is the quantile of memory usage data to use as the memory request
-	memRequestQuantile = 0.8
-)
-
 func formatMemory() toQuantity {
-	return func(valueAtQuantile float64) *resource.Quantity {
-		return resource.NewQuantity(int64(valueAtQuantile), resource.BinarySI)
+	return func(value float64) *resource.Quantity {
+		return resource.NewQuantity(int64(value), resource.BinarySI)
 	}
 }
 
 func (s *resourceServer) digestMemory(data *podscaler.CachedQuery) {
 	s.logger.Debugf("Digesting new memory consumption metrics.")
-	s.digestData(data, memRequestQuantile, corev1.ResourceMemory, formatMemory())
+	s.digestData(data, corev1.ResourceMemory, formatMemory())
 }
 
-type toQuantity func(valueAtQuantile float64) (quantity *resource.Quantity)
+type toQuantity func(value float64) (quantity *resource.Quantity)
 
-func (s *resourceServer) digestData(data *podscaler.CachedQuery, quantile float64, request corev1.ResourceName, quantity toQuantity) {
+func (s *resourceServer) digestData(data *podscaler.CachedQuery, request corev1.ResourceName, quantity toQuantity) {
 	logger := s.logger.WithField("resource", request)
 	logger.Debugf("Digesting %d identifiers.", len(data.DataByMetaData))
 	for meta, fingerprintTimes := range data.DataByMetaData {
@@ -81,7 +84,8 @@ func (s *resourceServer) digestData(data *podscaler.CachedQuery, quantile float6
 			overall.Merge(data.Data[fingerprintTime.Fingerprint].Histogram())
 		}
 		metaLogger.Trace("merged all fingerprints")
-		valueAtQuantile := overall.ValueAtQuantile(quantile)
+		policy, _ := s.policies.For(meta, request)
+		recommendation := policy.Recommend(overall)
 		metaLogger.Trace("locking for value update")
 		s.lock.Lock()
 		if _, exists := s.byMetaData[meta]; !exists {
@@ -90,17 +94,87 @@ func (s *resourceServer) digestData(data *podscaler.CachedQuery, quantile float6
 				Limits:   corev1.ResourceList{},
 			}
 		}
-		q := quantity(valueAtQuantile)
+		q := quantity(recommendation)
 		s.byMetaData[meta].Requests[request] = *q
+		if request == corev1.ResourceMemory {
+			// the peak usage is the lowest limit under which the workload would
+			// not have been OOM-killed, leave a margin for the next executions
+			s.byMetaData[meta].Limits[request] = *quantity(overall.ValueAtQuantile(1) * memoryLimitMargin)
+		}
 		metaLogger.Trace("unlocking for meta")
 		s.lock.Unlock()
 	}
 	logger.Debug("Finished digesting new data.")
 }
 
+// memoryLimitMargin is the factor by which the memory limit exceeds the peak
+// usage of a workload.
+const memoryLimitMargin = 1.2
+
+// oomKilledLookback is how far back an OOM-kill is considered when making
+// recommendations for a workload.
+const oomKilledLookback = 7 * 24 * time.Hour
+
+// digestOOMKilled records when containers were last OOM-killed. The samples
+// of the metric hold the time at which the container terminated.
+func (s *resourceServer) digestOOMKilled(data *podscaler.CachedQuery) {
+	s.logger.Debugf("Digesting new OOM-kill metrics.")
+	for meta, fingerprintTimes := range data.DataByMetaData {
+		var last time.Time
+		for _, fingerprintTime := range fingerprintTimes {
+			if fingerprintTime.Latest <= 0 {
+				continue
+			}
+			if terminated := time.Unix(int64(fingerprintTime.Latest), 0); terminated.After(last) {
+				last = terminated
+			}
+		}
+		if last.IsZero() {
+			continue
+		}
+		s.lock.Lock()
+		if last.After(s.oomKilled[meta]) {
+			s.oomKilled[meta] = last
+		}
+		s.lock.Unlock()
+	}
+	s.logger.Debug("Finished digesting new OOM-kill data.")
+}
+
 func (s *resourceServer) recommendedRequestFor(meta podscaler.FullMetadata) (corev1.ResourceRequirements, bool) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 	data, ok := s.byMetaData[meta]
-	return data, ok
+	// callers adjust the recommendation for the Pod at hand, so they must not
+	// share the underlying lists
+	return *data.DeepCopy(), ok
+}
+
+// recentlyOOMKilled determines if a container with the metadata was terminated
+// for running out of memory within the lookback period, and when.
+func (s *resourceServer) recentlyOOMKilled(meta podscaler.FullMetadata) (time.Time, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	last, ok := s.oomKilled[meta]
+	return last, ok && time.Since(last) < oomKilledLookback
+}
+
+// firstReportOfOOMKill determines if the OOM-kill of a container with the
+// metadata at the given time was not reported yet, and records that it is.
+func (s *resourceServer) firstReportOfOOMKill(meta podscaler.FullMetadata, killed time.Time) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !killed.After(s.oomReported[meta]) {
+		return false
+	}
+	s.oomReported[meta] = killed
+	return true
+}
+
+// effectivePolicyFor determines the policies used for the recommendations for
+// a workload, if any rule configures them.
+func (s *resourceServer) effectivePolicyFor(meta podscaler.FullMetadata) (podscaler.ResourcePolicies, bool) {
+	cpu, cpuMatched := s.policies.For(meta, corev1.ResourceCPU)
+	memory, memoryMatched := s.policies.For(meta, corev1.ResourceMemory)
+	return podscaler.ResourcePolicies{CPU: &cpu, Memory: &memory}, cpuMatched || memoryMatched
 }
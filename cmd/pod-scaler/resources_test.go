@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	podscaler "github.com/openshift/ci-tools/pkg/pod-scaler"
+)
+
+func TestRecentlyOOMKilled(t *testing.T) {
+	meta := func(container string) podscaler.FullMetadata {
+		return podscaler.FullMetadata{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "branch"}, Target: "target", Step: "step", Container: container}
+	}
+	now := time.Now()
+	terminated := func(ago time.Duration) float64 {
+		return float64(now.Add(-ago).Unix())
+	}
+	server := &resourceServer{
+		logger:    logrus.WithField("test", t.Name()),
+		oomKilled: map[podscaler.FullMetadata]time.Time{},
+	}
+	server.digestOOMKilled(&podscaler.CachedQuery{
+		DataByMetaData: map[podscaler.FullMetadata][]podscaler.FingerprintTime{
+			meta("recent"): {{Fingerprint: 1, Added: now, Latest: terminated(30 * 24 * time.Hour)}, {Fingerprint: 2, Added: now, Latest: terminated(time.Hour)}},
+			// the data was added recently, but the container was killed long ago
+			meta("old"): {{Fingerprint: 3, Added: now, Latest: terminated(30 * 24 * time.Hour)}},
+			// cached before the time of termination was recorded
+			meta("unknown"): {{Fingerprint: 4, Added: now}},
+		},
+	})
+	// data from another cache must not override what was digested before
+	server.digestOOMKilled(&podscaler.CachedQuery{
+		DataByMetaData: map[podscaler.FullMetadata][]podscaler.FingerprintTime{
+			meta("recent"): {{Fingerprint: 5, Added: now, Latest: terminated(30 * 24 * time.Hour)}},
+		},
+	})
+
+	for container, expected := range map[string]bool{
+		"recent":  true,
+		"old":     false,
+		"unknown": false,
+	} {
+		if _, actual := server.recentlyOOMKilled(meta(container)); actual != expected {
+			t.Errorf("%s: expected recently OOM-killed to be %v, got %v", container, expected, actual)
+		}
+	}
+}
+
+func TestFirstReportOfOOMKill(t *testing.T) {
+	meta := podscaler.FullMetadata{Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "branch"}, Target: "target", Step: "step", Container: "container"}
+	killed := time.Now().Add(-time.Hour)
+	server := &resourceServer{oomReported: map[podscaler.FullMetadata]time.Time{}}
+	for i, step := range []struct {
+		killed   time.Time
+		expected bool
+	}{
+		{killed: killed, expected: true},
+		{killed: killed, expected: false},
+		{killed: killed.Add(-time.Minute), expected: false},
+		{killed: killed.Add(time.Minute), expected: true},
+	} {
+		if actual := server.firstReportOfOOMKill(meta, step.killed); actual != step.expected {
+			t.Errorf("%d: expected first report to be %v, got %v", i, step.expected, actual)
+		}
+	}
+}
@@ -52,6 +52,12 @@ type options struct {
 	configPath        string
 	clusterConfigPath string
 	jobsStoragePath   string
+	placementReport   string
+
+	simulate                  bool
+	simulateClusterConfigPath string
+	jobVolumesPath            string
+	jobVolumesSnapshotPath    string
 
 	prometheusDaysBefore int
 
@@ -84,6 +90,11 @@ func gatherOptions() options {
 	fs.StringVar(&o.configPath, "config-path", "", "Path to the config file (core-services/sanitize-prow-jobs/_config.yaml in openshift/release)")
 	fs.StringVar(&o.clusterConfigPath, "cluster-config-path", "core-services/sanitize-prow-jobs/_clusters.yaml", "Path to the config file (core-services/sanitize-prow-jobs/_clusters.yaml in openshift/release)")
 	fs.StringVar(&o.jobsStoragePath, "jobs-storage-path", "", "Path to the file holding only job assignments in Gob format")
+	fs.StringVar(&o.placementReport, "placement-report-path", "", "If passed, write the jobs which moved to another cluster in a dispatch, and why, to this file.")
+	fs.BoolVar(&o.simulate, "simulate", false, "Simulate a dispatch with the cluster config from --simulate-cluster-config-path and the job volumes from --job-volumes-path, print the outcome and exit. Neither Prometheus nor GitHub are accessed.")
+	fs.StringVar(&o.simulateClusterConfigPath, "simulate-cluster-config-path", "", "Path to the hypothetical cluster config to simulate a dispatch with, in the format of --cluster-config-path")
+	fs.StringVar(&o.jobVolumesPath, "job-volumes-path", "", "Path to the snapshot of job volumes to simulate a dispatch with, as written by --job-volumes-snapshot-path")
+	fs.StringVar(&o.jobVolumesSnapshotPath, "job-volumes-snapshot-path", "", "If passed, write the job volumes queried from Prometheus to this file in every dispatch.")
 	fs.IntVar(&o.prometheusDaysBefore, "prometheus-days-before", 14, "Number [1,15] of days before. Time 00-00-00 of that day will be used as time to query Prometheus. E.g., 1 means 00-00-00 of yesterday.")
 
 	fs.BoolVar(&o.createPR, "create-pr", false, "Create a pull request to the change made with this tool.")
@@ -116,6 +127,19 @@ func (o *options) validate() error {
 		return fmt.Errorf("mandatory argument --config-path wasn't set")
 	}
 
+	if o.simulate {
+		if o.clusterConfigPath == "" {
+			return fmt.Errorf("mandatory argument --cluster-config-path wasn't set")
+		}
+		if o.simulateClusterConfigPath == "" {
+			return fmt.Errorf("--simulate-cluster-config-path is mandatory with --simulate")
+		}
+		if o.jobVolumesPath == "" {
+			return fmt.Errorf("--job-volumes-path is mandatory with --simulate")
+		}
+		return nil
+	}
+
 	if o.prometheusDaysBefore < 1 || o.prometheusDaysBefore > 15 {
 		return fmt.Errorf("--prometheus-days-before must be between 1 and 15")
 	}
@@ -197,6 +221,9 @@ type clusterVolume struct {
 	blocked            sets.Set[string]
 	volumeDistribution map[string]float64
 	clusterMap         dispatcher.ClusterMap
+	// scorer is set when the jobs are placed by the scores of the clusters
+	scorer     *dispatcher.Scorer
+	placements map[string]dispatcher.Placement
 }
 
 // findClusterForJobConfig finds a cluster running on a preferred cloud provider for the jobs in a Prow job config.
@@ -213,8 +240,14 @@ func (cv *clusterVolume) findClusterForJobConfig(cloudProvider string, jc *prowc
 	}
 
 	mostUsedCluster := dispatcher.FindMostUsedCluster(jc)
-	// TODO: 75% as we still have manual assignments and these are affecting even distribution, re-evaluate when manual assignments are gone
-	if determinedCloudProvider := config.IsInBuildFarm(api.Cluster(mostUsedCluster)); determinedCloudProvider != "" &&
+	var placement *dispatcher.Placement
+	if cv.scorer != nil {
+		if p, ok := cv.scorer.Place(cv.candidateVolumes(cloudProvider), jobConfigVolume(jc, jobVolumes)); ok {
+			cluster = p.Chosen.Cluster
+			placement = &p
+		}
+	} else if determinedCloudProvider := config.IsInBuildFarm(api.Cluster(mostUsedCluster)); determinedCloudProvider != "" &&
+		// TODO: 75% as we still have manual assignments and these are affecting even distribution, re-evaluate when manual assignments are gone
 		cv.clusterVolumeMap[string(determinedCloudProvider)][mostUsedCluster] < cv.volumeDistribution[mostUsedCluster]*0.75 {
 		cluster = mostUsedCluster
 	} else {
@@ -257,9 +290,62 @@ func (cv *clusterVolume) findClusterForJobConfig(cloudProvider string, jc *prowc
 		}
 	}
 
+	if placement != nil {
+		for _, job := range jobBases(jc) {
+			if cv.pjs[job.Name].Cluster == cluster {
+				cv.placements[job.Name] = *placement
+			}
+		}
+	}
+
 	return cluster, utilerrors.NewAggregate(errs)
 }
 
+// candidateVolumes returns the volumes of the clusters in the build farm on the cloud provider,
+// or of all of them if the cloud provider is empty string. Clusters which do not take their full
+// capacity of jobs are left out, as they are when the jobs are not placed by scores.
+func (cv *clusterVolume) candidateVolumes(cloudProvider string) map[string]float64 {
+	volumes := map[string]float64{}
+	for cp, m := range cv.clusterVolumeMap {
+		if cloudProvider != "" && cloudProvider != cp {
+			continue
+		}
+		for c, v := range m {
+			if cv.clusterMap[c].Capacity != 100 {
+				continue
+			}
+			volumes[c] = v
+		}
+	}
+	return volumes
+}
+
+func jobBases(jc *prowconfig.JobConfig) []prowconfig.JobBase {
+	var bases []prowconfig.JobBase
+	for k := range jc.PresubmitsStatic {
+		for _, job := range jc.PresubmitsStatic[k] {
+			bases = append(bases, job.JobBase)
+		}
+	}
+	for k := range jc.PostsubmitsStatic {
+		for _, job := range jc.PostsubmitsStatic[k] {
+			bases = append(bases, job.JobBase)
+		}
+	}
+	for _, job := range jc.Periodics {
+		bases = append(bases, job.JobBase)
+	}
+	return bases
+}
+
+func jobConfigVolume(jc *prowconfig.JobConfig, jobVolumes map[string]float64) float64 {
+	var volume float64
+	for _, job := range jobBases(jc) {
+		volume += jobVolumes[job.Name]
+	}
+	return volume
+}
+
 func extractCapabilities(labels map[string]string) []string {
 	var capabilities []string
 	prefix := "capability/"
@@ -277,13 +363,13 @@ func findClusterAssigmentsForJobs(jc *prowconfig.JobConfig, path string, config
 	mostUsedCluster := dispatcher.FindMostUsedCluster(jc)
 
 	getClusterForMissingJob := func(cluster string, jobBase prowconfig.JobBase, pjs map[string]dispatcher.ProwJobData) error {
-		determinedCluster, canBeRelocated, err := config.DetermineClusterForJob(jobBase, path, cm)
+		determinedCluster, reason, canBeRelocated, err := config.DetermineClusterAndReasonForJob(jobBase, path, cm)
 		if err != nil {
 			return fmt.Errorf("failed to determine cluster for the job %s in path %q: %w", jobBase.Name, path, err)
 		}
 
-		c := dispatcher.DetermineTargetCluster(cluster, string(determinedCluster), string(config.Default), canBeRelocated, blocked)
-		pjs[jobBase.Name] = dispatcher.ProwJobData{Cluster: c, Capabilities: extractCapabilities(jobBase.Labels)}
+		c, reason := dispatcher.DetermineTargetClusterWithReason(cluster, string(determinedCluster), string(config.Default), canBeRelocated, blocked, reason)
+		pjs[jobBase.Name] = dispatcher.ProwJobData{Cluster: c, Capabilities: extractCapabilities(jobBase.Labels), Reason: reason}
 		logrus.WithField("job", jobBase.Name).WithField("cluster", c).Info("found cluster for job")
 		return nil
 	}
@@ -320,14 +406,14 @@ func findClusterAssigmentsForJobs(jc *prowconfig.JobConfig, path string, config
 }
 
 func (cv *clusterVolume) addToVolume(cluster string, jobBase prowconfig.JobBase, path string, config *dispatcher.Config, jobVolumes map[string]float64) error {
-	determinedCluster, canBeRelocated, err := config.DetermineClusterForJob(jobBase, path, cv.clusterMap)
+	determinedCluster, reason, canBeRelocated, err := config.DetermineClusterAndReasonForJob(jobBase, path, cv.clusterMap)
 
 	if err != nil {
 		return fmt.Errorf("failed to determine cluster for the job %s in path %q: %w", jobBase.Name, path, err)
 	}
 
-	c := dispatcher.DetermineTargetCluster(cluster, string(determinedCluster), string(config.Default), canBeRelocated, cv.blocked)
-	cv.pjs[jobBase.Name] = dispatcher.ProwJobData{Cluster: c, Capabilities: extractCapabilities(jobBase.Labels)}
+	c, reason := dispatcher.DetermineTargetClusterWithReason(cluster, string(determinedCluster), string(config.Default), canBeRelocated, cv.blocked, reason)
+	cv.pjs[jobBase.Name] = dispatcher.ProwJobData{Cluster: c, Capabilities: extractCapabilities(jobBase.Labels), Reason: reason}
 	if determinedCloudProvider := config.IsInBuildFarm(api.Cluster(c)); determinedCloudProvider != "" {
 		cv.clusterVolumeMap[string(determinedCloudProvider)][c] = cv.clusterVolumeMap[string(determinedCloudProvider)][c] + jobVolumes[jobBase.Name]
 		return nil
@@ -368,9 +454,12 @@ type fileSizeInfo struct {
 //   - When all the e2e tests are targeting the same cloud provider, we run the test pod on the that cloud provider too.
 //   - When the e2e tests are targeting different cloud providers, or there is no e2e tests at all, we can run the tests
 //     on any cluster in the build farm. Those jobs are used to load balance the workload of clusters in the build farm.
-func dispatchJobs(prowJobConfigDir string, config *dispatcher.Config, jobVolumes map[string]float64, blocked sets.Set[string], volumeDistribution map[string]float64, cm dispatcher.ClusterMap) (map[string]dispatcher.ProwJobData, error) {
+//
+// When the config enables scoring, the cluster with the lowest score given the health of the clusters is chosen
+// instead, and the returned placements record the scores for the jobs that were placed this way.
+func dispatchJobs(prowJobConfigDir string, config *dispatcher.Config, jobVolumes map[string]float64, blocked sets.Set[string], volumeDistribution map[string]float64, cm dispatcher.ClusterMap, health map[string]dispatcher.ClusterHealth) (map[string]dispatcher.ProwJobData, map[string]dispatcher.Placement, error) {
 	if config == nil {
-		return nil, fmt.Errorf("config is nil")
+		return nil, nil, fmt.Errorf("config is nil")
 	}
 
 	// cv stores the volume for each cluster in the build farm
@@ -381,7 +470,11 @@ func dispatchJobs(prowJobConfigDir string, config *dispatcher.Config, jobVolumes
 		blocked:            blocked,
 		specialClusters:    map[string]float64{},
 		volumeDistribution: volumeDistribution,
-		clusterMap:         cm}
+		clusterMap:         cm,
+		placements:         map[string]dispatcher.Placement{}}
+	if config.Scoring != nil {
+		cv.scorer = dispatcher.NewScorer(*config.Scoring, cm, health, volumeDistribution)
+	}
 	for cloudProvider, v := range config.BuildFarm {
 		for cluster := range v {
 			cloudProviderString := string(cloudProvider)
@@ -397,7 +490,7 @@ func dispatchJobs(prowJobConfigDir string, config *dispatcher.Config, jobVolumes
 
 	// no clusters in the build farm
 	if len(cv.clusterVolumeMap) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	results := map[string][]string{}
@@ -416,10 +509,10 @@ func dispatchJobs(prowJobConfigDir string, config *dispatcher.Config, jobVolumes
 	}
 	fileList, err := composeFileInfoList(prowJobConfigDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to dispatch all Prow jobs: %w", err)
+		return nil, nil, fmt.Errorf("failed to dispatch all Prow jobs: %w", err)
 	}
 
-	sort.Slice(fileList, func(i, j int) bool { return fileList[i].size > fileList[j].size })
+	sortFileList(fileList)
 	if err := dispatchEveryFile(fileList, dispatch); err != nil {
 		errs = append(errs, err)
 	}
@@ -439,7 +532,32 @@ func dispatchJobs(prowJobConfigDir string, config *dispatcher.Config, jobVolumes
 		}
 	}
 
-	return cv.pjs, utilerrors.NewAggregate(errs)
+	return cv.pjs, cv.placements, utilerrors.NewAggregate(errs)
+}
+
+// sortFileList sorts the files by descending size, and by path for the same size to be reproducible
+func sortFileList(fileList []fileSizeInfo) {
+	sort.Slice(fileList, func(i, j int) bool {
+		if fileList[i].size != fileList[j].size {
+			return fileList[i].size > fileList[j].size
+		}
+		return fileList[i].path < fileList[j].path
+	})
+}
+
+// writePlacementReport writes the jobs which moved to another cluster to the file
+func writePlacementReport(path string, moves []dispatcher.JobMove) error {
+	if moves == nil {
+		moves = []dispatcher.JobMove{}
+	}
+	raw, err := yaml.Marshal(moves)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the placement report: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write the placement report: %w", err)
+	}
+	return nil
 }
 
 func dispatchDeltaJobs(prowJobConfigDir string, config *dispatcher.Config, blocked sets.Set[string], pjs map[string]dispatcher.ProwJobData, cm dispatcher.ClusterMap) error {
@@ -454,7 +572,7 @@ func dispatchDeltaJobs(prowJobConfigDir string, config *dispatcher.Config, block
 		return fmt.Errorf("failed to dispatch all Prow jobs: %w", err)
 	}
 
-	sort.Slice(fileList, func(i, j int) bool { return fileList[i].size > fileList[j].size })
+	sortFileList(fileList)
 	if err := dispatchEveryFile(fileList, dispatch); err != nil {
 		errs = append(errs, err)
 	}
@@ -658,6 +776,13 @@ func main() {
 		logrus.WithError(err).Fatal("Failed to complete options.")
 	}
 
+	if o.simulate {
+		if err := simulate(o, os.Stdout); err != nil {
+			logrus.WithError(err).Fatal("Failed to simulate the dispatch.")
+		}
+		return
+	}
+
 	if o.createPR {
 		if err := o.PRCreationOptions.Finalize(); err != nil {
 			logrus.WithError(err).Fatal("Failed to finalize PR creation options")
@@ -757,6 +882,19 @@ func main() {
 			if err != nil {
 				logrus.WithError(err).Fatal("failed to get job volumes")
 			}
+			if o.jobVolumesSnapshotPath != "" {
+				if err := writeJobVolumes(o.jobVolumesSnapshotPath, jobVolumes); err != nil {
+					logrus.WithError(err).Error("failed to write the snapshot of job volumes")
+				}
+			}
+
+			var health map[string]dispatcher.ClusterHealth
+			if config.Scoring != nil {
+				if health, err = promVolumes.GetClusterHealth(); err != nil {
+					logrus.WithError(err).Error("failed to get cluster health")
+					return
+				}
+			}
 
 			addEnabledClusters(config, enabled,
 				func(cluster string) (api.Cloud, error) {
@@ -766,13 +904,22 @@ func main() {
 					}
 					return api.Cloud(info.Provider), nil
 				})
-			pjs, err := dispatchJobs(o.prowJobConfigDir, config, jobVolumes, blocked, promVolumes.CalculateVolumeDistribution(configClusterMap), configClusterMap)
+			previous := prowjobs.GetDataCopy()
+			pjs, placements, err := dispatchJobs(o.prowJobConfigDir, config, jobVolumes, blocked, promVolumes.CalculateVolumeDistribution(configClusterMap), configClusterMap, health)
 			if err != nil {
 				logrus.WithError(err).Error("failed to dispatch")
 				return
 			}
 			prowjobs.Regenerate(pjs)
 
+			moves := dispatcher.DiffAssignments(previous, pjs, placements)
+			logrus.WithField("moved", len(moves)).Info("dispatched the jobs")
+			if o.placementReport != "" {
+				if err := writePlacementReport(o.placementReport, moves); err != nil {
+					logrus.WithError(err).Error("failed to write the placement report")
+				}
+			}
+
 			if err := dispatcher.WriteGob(o.jobsStoragePath, pjs); err != nil {
 				logrus.WithError(err).Errorf("continuing on cache memory, error writing Gob file")
 			}
@@ -838,9 +985,13 @@ func main() {
 		}
 	}(o.clusterConfigPath)
 
-	server := dispatcher.NewServer(prowjobs, dispatchWrapper)
+	server := dispatcher.NewServer(prowjobs, dispatchWrapper, func() (dispatcher.ClusterMap, sets.Set[string], error) {
+		return dispatcher.LoadClusterConfig(o.clusterConfigPath)
+	})
 	http.HandleFunc("/", server.RequestHandler)
 	http.HandleFunc("/event", server.EventHandler)
+	http.HandleFunc(dispatcher.SchedulePath, server.ScheduleHandler)
+	http.HandleFunc(dispatcher.ClustersPath, server.ClustersHandler)
 	logrus.Fatal(http.ListenAndServe(":8080", nil))
 
 }
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
@@ -116,7 +118,7 @@ func TestDispatchJobs(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, actual := dispatchJobs(tc.prowJobConfigDir, tc.config, tc.jobVolumes, sets.New[string](), tc.distribution, tc.clusterMap)
+			_, _, actual := dispatchJobs(tc.prowJobConfigDir, tc.config, tc.jobVolumes, sets.New[string](), tc.distribution, tc.clusterMap, nil)
 			equalError(t, tc.expected, actual)
 			if tc.config != nil && !reflect.DeepEqual(tc.expectedBuildFarm, tc.config.BuildFarm) {
 				t.Errorf("%s: actual differs from expected:\n%s", t.Name(), cmp.Diff(tc.expectedBuildFarm, tc.config.BuildFarm))
@@ -130,6 +132,10 @@ func TestDispatchJobConfig(t *testing.T) {
 		"build01": dispatcher.ClusterInfo{Capacity: 100},
 		"build02": dispatcher.ClusterInfo{Capacity: 100},
 	}
+	drainedClusterMap := dispatcher.ClusterMap{
+		"build01": dispatcher.ClusterInfo{Capacity: 0},
+		"build02": dispatcher.ClusterInfo{Capacity: 100},
+	}
 	testCases := []struct {
 		name        string
 		cv          *clusterVolume
@@ -243,6 +249,68 @@ func TestDispatchJobConfig(t *testing.T) {
 			},
 			expected: "build02",
 		},
+		{
+			name: "scoring: the less loaded build01 is avoided when its pods fail",
+			cv: &clusterVolume{
+				clusterVolumeMap: map[string]map[string]float64{"aws": {"build01": 0}, "gcp": {"build02": 10}},
+				cloudProviders:   sets.New[string]("aws", "gcp"),
+				pjs:              map[string]dispatcher.ProwJobData{},
+				blocked:          sets.New[string](),
+				volumeDistribution: map[string]float64{
+					"build01": 20,
+					"build02": 20,
+				},
+				clusterMap: clusterMap,
+				scorer: dispatcher.NewScorer(dispatcher.ScoringWeights{Load: 1, FailureRate: 2}, clusterMap,
+					map[string]dispatcher.ClusterHealth{"build01": {FailureRate: 0.5}}, map[string]float64{"build01": 20, "build02": 20}),
+				placements: map[string]dispatcher.Placement{},
+			},
+			config: &c,
+			jc: &prowconfig.JobConfig{
+				PresubmitsStatic: map[string][]prowconfig.Presubmit{
+					"repo": {{JobBase: prowconfig.JobBase{Name: "job",
+						Spec: &corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Env: []corev1.EnvVar{{Name: "CLUSTER_TYPE", Value: "openstack"}}},
+							},
+						}}}},
+				},
+			},
+			path:       "repo-presubmits.yaml",
+			jobVolumes: map[string]float64{"job": 2},
+			expected:   "build02",
+		},
+		{
+			name: "scoring: the idle build01 is not chosen without capacity",
+			cv: &clusterVolume{
+				clusterVolumeMap: map[string]map[string]float64{"aws": {"build01": 0}, "gcp": {"build02": 10}},
+				cloudProviders:   sets.New[string]("aws", "gcp"),
+				pjs:              map[string]dispatcher.ProwJobData{},
+				blocked:          sets.New[string](),
+				volumeDistribution: map[string]float64{
+					"build01": 20,
+					"build02": 20,
+				},
+				clusterMap: drainedClusterMap,
+				scorer: dispatcher.NewScorer(dispatcher.ScoringWeights{Load: 1}, drainedClusterMap,
+					map[string]dispatcher.ClusterHealth{}, map[string]float64{"build01": 20, "build02": 20}),
+				placements: map[string]dispatcher.Placement{},
+			},
+			config: &c,
+			jc: &prowconfig.JobConfig{
+				PresubmitsStatic: map[string][]prowconfig.Presubmit{
+					"repo": {{JobBase: prowconfig.JobBase{Name: "job",
+						Spec: &corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Env: []corev1.EnvVar{{Name: "CLUSTER_TYPE", Value: "openstack"}}},
+							},
+						}}}},
+				},
+			},
+			path:       "repo-presubmits.yaml",
+			jobVolumes: map[string]float64{"job": 2},
+			expected:   "build02",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -458,9 +526,9 @@ func TestDispatchDeltaJobs(t *testing.T) {
 			},
 			wantErr: false,
 			expectedPjs: map[string]dispatcher.ProwJobData{
-				"pull-ci-openshift-cluster-api-provider-gcp-master-e2e-gcp":          {Cluster: "build01", Capabilities: []string{"intranet"}},
+				"pull-ci-openshift-cluster-api-provider-gcp-master-e2e-gcp":          {Cluster: "build01", Capabilities: []string{"intranet"}, Reason: dispatcher.ReasonCapability},
 				"pull-ci-openshift-cluster-api-provider-gcp-master-govet":            {Cluster: "build02"},
-				"pull-ci-openshift-cluster-api-provider-gcp-master-e2e-gcp-operator": {Cluster: "build01", Capabilities: []string{"intranet"}},
+				"pull-ci-openshift-cluster-api-provider-gcp-master-e2e-gcp-operator": {Cluster: "build01", Capabilities: []string{"intranet"}, Reason: dispatcher.ReasonCapability},
 				"pull-ci-openshift-cluster-api-provider-gcp-master-goimports":        {Cluster: "build02"},
 			},
 		},
@@ -523,3 +591,141 @@ func TestSendSlackMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestWritePlacementReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.yaml")
+	moves := []dispatcher.JobMove{{Job: "job", From: "build01", To: "build02", Reason: "determined by the rules of the dispatcher config"}}
+	if err := writePlacementReport(path, moves); err != nil {
+		t.Fatalf("failed to write the report: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `- from: build01
+  job: job
+  reason: determined by the rules of the dispatcher config
+  to: build02
+`
+	if diff := cmp.Diff(expected, string(raw)); diff != "" {
+		t.Errorf("unexpected report: %s", diff)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	const (
+		config = `default: app.ci
+buildFarm:
+  aws:
+    build01: {}
+  gcp:
+    build02: {}
+`
+		clusters = `aws:
+- name: build01
+gcp:
+- name: build02
+`
+		volumes = `{"pull-ci-org-unit-master-unit":12,"pull-ci-org-pinned-master-e2e":2,"pull-ci-org-unit-master-gpu":1}`
+	)
+	previous := map[string]dispatcher.ProwJobData{
+		"pull-ci-org-unit-master-unit":  {Cluster: "build02"},
+		"pull-ci-org-pinned-master-e2e": {Cluster: "build02"},
+	}
+	testCases := []struct {
+		name              string
+		simulatedClusters string
+		expected          string
+	}{
+		{
+			name: "draining build02 for a smaller build03 moves the jobs",
+			simulatedClusters: `aws:
+- name: build01
+- name: build03
+  capacity: 50
+`,
+			expected: `clusters:
+- expectedVolume: 10
+  jobs: 1
+  name: build01
+  volume: 12
+- expectedVolume: 0
+  jobs: 1
+  name: build02
+  volume: 2
+- expectedVolume: 5
+  jobs: 0
+  name: build03
+  volume: 0
+fullDispatch: true
+moves:
+- from: build02
+  job: pull-ci-org-unit-master-unit
+  reason: placed by the relocation rule
+  to: build01
+unplaced:
+- job: pull-ci-org-pinned-master-e2e
+  path: testdata/TestSimulate/pinned-presubmits.yaml
+  reason: the job is assigned to the removed cluster build02
+- job: pull-ci-org-unit-master-gpu
+  path: testdata/TestSimulate/unit-presubmits.yaml
+  reason: 'job pull-ci-org-unit-master-gpu can''t be matched with any cluster using
+    provided capabilities: gpu'
+`,
+		},
+		{
+			name:              "unchanged clusters only dispatch new jobs",
+			simulatedClusters: clusters,
+			expected: `clusters:
+- expectedVolume: 7.5
+  jobs: 0
+  name: build01
+  volume: 0
+- expectedVolume: 7.5
+  jobs: 2
+  name: build02
+  volume: 14
+fullDispatch: false
+moves: []
+unplaced:
+- job: pull-ci-org-unit-master-gpu
+  path: testdata/TestSimulate/unit-presubmits.yaml
+  reason: 'job pull-ci-org-unit-master-gpu can''t be matched with any cluster using
+    provided capabilities: gpu'
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			o := options{
+				prowJobConfigDir:          filepath.Join("testdata", "TestSimulate"),
+				configPath:                filepath.Join(dir, "config.yaml"),
+				clusterConfigPath:         filepath.Join(dir, "clusters.yaml"),
+				simulateClusterConfigPath: filepath.Join(dir, "simulated-clusters.yaml"),
+				jobVolumesPath:            filepath.Join(dir, "volumes.json"),
+				jobsStoragePath:           filepath.Join(dir, "jobs.gob"),
+			}
+			for path, content := range map[string]string{
+				o.configPath:                config,
+				o.clusterConfigPath:         clusters,
+				o.simulateClusterConfigPath: tc.simulatedClusters,
+				o.jobVolumesPath:            volumes,
+			} {
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := dispatcher.WriteGob(o.jobsStoragePath, previous); err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			if err := simulate(o, &out); err != nil {
+				t.Fatalf("failed to simulate: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, out.String()); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
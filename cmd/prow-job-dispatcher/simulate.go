@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowconfig "sigs.k8s.io/prow/pkg/config"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/dispatcher"
+)
+
+// simulation is the outcome of dispatching the jobs with a hypothetical cluster config
+type simulation struct {
+	// FullDispatch is true when all the jobs are dispatched again, as the dispatcher would do
+	// for the hypothetical cluster config, and false when only new and changed jobs are
+	FullDispatch bool                 `json:"fullDispatch"`
+	Clusters     []simulatedCluster   `json:"clusters"`
+	Moves        []dispatcher.JobMove `json:"moves"`
+	Unplaced     []unplacedJob        `json:"unplaced"`
+}
+
+type simulatedCluster struct {
+	Name           string  `json:"name"`
+	Jobs           int     `json:"jobs"`
+	Volume         float64 `json:"volume"`
+	ExpectedVolume float64 `json:"expectedVolume"`
+}
+
+type unplacedJob struct {
+	Job    string `json:"job"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// loadJobVolumes loads a snapshot of the job volumes which maps the job names to their volumes
+func loadJobVolumes(path string) (map[string]float64, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job volumes: %w", err)
+	}
+	jobVolumes := map[string]float64{}
+	if err := json.Unmarshal(raw, &jobVolumes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job volumes: %w", err)
+	}
+	return jobVolumes, nil
+}
+
+// writeJobVolumes writes a snapshot of the job volumes to be used in simulations
+func writeJobVolumes(path string, jobVolumes map[string]float64) error {
+	raw, err := json.Marshal(jobVolumes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job volumes: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write job volumes: %w", err)
+	}
+	return nil
+}
+
+// simulate dispatches the jobs as the dispatcher would if the cluster config changed to the simulated one,
+// starting from the stored job assignments, and writes which jobs would move and where the volume would go.
+// It needs neither Prometheus nor GitHub.
+func simulate(o options, out io.Writer) error {
+	config, err := dispatcher.LoadConfig(o.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %q: %w", o.configPath, err)
+	}
+	currentClusterMap, currentBlocked, err := dispatcher.LoadClusterConfig(o.clusterConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load cluster config: %w", err)
+	}
+	clusterMap, blocked, err := dispatcher.LoadClusterConfig(o.simulateClusterConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load simulated cluster config: %w", err)
+	}
+	jobVolumes, err := loadJobVolumes(o.jobVolumesPath)
+	if err != nil {
+		return err
+	}
+	prowjobs := dispatcher.NewProwjobs(o.jobsStoragePath)
+
+	result, err := simulateDispatch(o.prowJobConfigDir, config, prowjobs, currentClusterMap, currentBlocked, clusterMap, blocked, jobVolumes)
+	if err != nil {
+		return err
+	}
+	raw, err := yaml.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the simulation: %w", err)
+	}
+	_, err = out.Write(raw)
+	return err
+}
+
+func simulateDispatch(prowJobConfigDir string, config *dispatcher.Config, prowjobs *dispatcher.Prowjobs, currentClusterMap dispatcher.ClusterMap, currentBlocked sets.Set[string], clusterMap dispatcher.ClusterMap, blocked sets.Set[string], jobVolumes map[string]float64) (*simulation, error) {
+	previous := prowjobs.GetDataCopy()
+
+	enabled, disabled := getDiffClusters(getEnabledClusters(config), clustersMapToSet(clusterMap))
+	if len(disabled) > 0 {
+		removeDisabledClusters(config, disabled)
+	}
+	result := &simulation{
+		FullDispatch: len(previous) == 0 || enabled.Len() > 0 || disabled.Len() > 0 || prowjobs.HasAnyOfClusters(blocked) ||
+			dispatcher.HasCapacityOrCapabilitiesChanged(currentClusterMap, clusterMap),
+	}
+
+	distribution := dispatcher.CalculateVolumeDistribution(clusterMap, jobVolumes)
+	var pjs map[string]dispatcher.ProwJobData
+	var placements map[string]dispatcher.Placement
+	if result.FullDispatch {
+		addEnabledClusters(config, enabled, func(cluster string) (api.Cloud, error) {
+			return api.Cloud(clusterMap[cluster].Provider), nil
+		})
+		var err error
+		if pjs, placements, err = dispatchJobs(prowJobConfigDir, config, jobVolumes, blocked, distribution, clusterMap, nil); err != nil {
+			logrus.WithError(err).Warn("Some jobs could not be dispatched.")
+		}
+		if pjs == nil {
+			pjs = map[string]dispatcher.ProwJobData{}
+		}
+	} else {
+		pjs = prowjobs.GetDataCopy()
+		if err := dispatchDeltaJobs(prowJobConfigDir, config, blocked, pjs, clusterMap); err != nil {
+			logrus.WithError(err).Warn("Some jobs could not be dispatched.")
+		}
+	}
+
+	unplaced, err := findUnplacedJobs(prowJobConfigDir, config, pjs, clusterMap, removedClusters(currentClusterMap, currentBlocked, clusterMap, blocked))
+	if err != nil {
+		return nil, err
+	}
+	result.Unplaced = unplaced
+	result.Moves = dispatcher.DiffAssignments(previous, pjs, placements)
+	if result.Moves == nil {
+		result.Moves = []dispatcher.JobMove{}
+	}
+	result.Clusters = simulatedClusters(pjs, clusterMap, jobVolumes, distribution)
+	return result, nil
+}
+
+// removedClusters returns the clusters which are in the current cluster config but not in the simulated one
+func removedClusters(currentClusterMap dispatcher.ClusterMap, currentBlocked sets.Set[string], clusterMap dispatcher.ClusterMap, blocked sets.Set[string]) sets.Set[string] {
+	current := clustersMapToSet(currentClusterMap).Union(currentBlocked)
+	return current.Difference(clustersMapToSet(clusterMap).Union(blocked))
+}
+
+// findUnplacedJobs returns the jobs which could not be assigned a cluster, or which are assigned to a removed cluster
+func findUnplacedJobs(prowJobConfigDir string, config *dispatcher.Config, pjs map[string]dispatcher.ProwJobData, clusterMap dispatcher.ClusterMap, removed sets.Set[string]) ([]unplacedJob, error) {
+	unplaced := []unplacedJob{}
+	find := func(jobConfig *prowconfig.JobConfig, path string, info fs.DirEntry) {
+		for _, job := range jobBases(jobConfig) {
+			data, ok := pjs[job.Name]
+			if !ok {
+				reason := "the job was not dispatched"
+				if _, _, err := config.DetermineClusterForJob(job, path, clusterMap); err != nil {
+					reason = err.Error()
+				}
+				unplaced = append(unplaced, unplacedJob{Job: job.Name, Path: path, Reason: reason})
+				continue
+			}
+			if removed.Has(data.Cluster) {
+				unplaced = append(unplaced, unplacedJob{Job: job.Name, Path: path, Reason: fmt.Sprintf("the job is assigned to the removed cluster %s", data.Cluster)})
+			}
+		}
+	}
+	fileList, err := composeFileInfoList(prowJobConfigDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unplaced jobs: %w", err)
+	}
+	sortFileList(fileList)
+	if err := dispatchEveryFile(fileList, find); err != nil {
+		return nil, fmt.Errorf("failed to find unplaced jobs: %w", err)
+	}
+	sort.Slice(unplaced, func(i, j int) bool { return unplaced[i].Job < unplaced[j].Job })
+	return unplaced, nil
+}
+
+// simulatedClusters returns the number of jobs and the volume dispatched to each cluster, sorted by name
+func simulatedClusters(pjs map[string]dispatcher.ProwJobData, clusterMap dispatcher.ClusterMap, jobVolumes map[string]float64, distribution map[string]float64) []simulatedCluster {
+	clusters := map[string]*simulatedCluster{}
+	get := func(name string) *simulatedCluster {
+		if _, ok := clusters[name]; !ok {
+			clusters[name] = &simulatedCluster{Name: name, ExpectedVolume: distribution[name]}
+		}
+		return clusters[name]
+	}
+	for name := range clusterMap {
+		get(name)
+	}
+	for job, data := range pjs {
+		cluster := get(data.Cluster)
+		cluster.Jobs++
+		cluster.Volume += jobVolumes[job]
+	}
+	var result []simulatedCluster
+	for _, name := range sets.List(sets.KeySet(clusters)) {
+		result = append(result, *clusters[name])
+	}
+	return result
+}
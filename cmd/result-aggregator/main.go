@@ -40,10 +40,17 @@ var (
 		},
 		[]string{"workload_name", "workload_type", "configured_amount", "determined_amount", "resource_type"},
 	)
+	podScalerOOMKilledCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pod_scaler_admission_oom_killed_workload",
+			Help: "number of times pod-scaler raised the memory of a workload that was recently OOM-killed, sorted by label/type",
+		},
+		[]string{"workload_name", "workload_type"},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(errorRate, podScalerHighResourceCounter)
+	prometheus.MustRegister(errorRate, podScalerHighResourceCounter, podScalerOOMKilledCounter)
 }
 
 type options struct {
@@ -115,6 +122,19 @@ func validatePodScalerRequest(request *results.PodScalerRequest) error {
 	return nil
 }
 
+func validatePodScalerOOMKillRequest(request *results.PodScalerOOMKillRequest) error {
+	if request.WorkloadName == "" {
+		return fmt.Errorf("workload_name field in request is empty")
+	}
+	if request.WorkloadType == "" {
+		return fmt.Errorf("workload_type field in request is empty")
+	}
+	if request.DeterminedAmount == "" {
+		return fmt.Errorf("determined_amount field in request is empty")
+	}
+	return nil
+}
+
 func handleError(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusBadRequest)
 	fmt.Fprint(w, html.EscapeString(err.Error()))
@@ -142,6 +162,14 @@ func recordHighResource(request *results.PodScalerRequest) {
 	podScalerHighResourceCounter.With(labels).Inc()
 }
 
+func recordOOMKilled(request *results.PodScalerOOMKillRequest) {
+	labels := prometheus.Labels{
+		"workload_name": request.WorkloadName,
+		"workload_type": request.WorkloadType,
+	}
+	podScalerOOMKilledCounter.With(labels).Inc()
+}
+
 type validator interface {
 	Validate(username, password string) bool
 }
@@ -212,6 +240,32 @@ func handlePodScalerResult() http.HandlerFunc {
 	}
 }
 
+func handlePodScalerOOMKill() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		bytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			handleError(w, fmt.Errorf("unable to read pod-scaler OOM-kill request body: %w", err))
+			return
+		}
+
+		request := &results.PodScalerOOMKillRequest{}
+		if err = json.Unmarshal(bytes, request); err != nil {
+			handleError(w, fmt.Errorf("unable to decode pod-scaler OOM-kill request body: %w", err))
+			return
+		}
+
+		if err := validatePodScalerOOMKillRequest(request); err != nil {
+			handleError(w, err)
+			return
+		}
+
+		recordOOMKilled(request)
+		w.WriteHeader(http.StatusOK)
+		log.WithFields(log.Fields{"request": request, "duration": time.Since(start).String()}).Info("Pod-scaler OOM-kill request processed")
+	}
+}
+
 func main() {
 	o, err := gatherOptions()
 	if err != nil {
@@ -232,6 +286,7 @@ func main() {
 
 	http.Handle("/result", loginHandler(validator, handleCIOperatorResult()))
 	http.Handle("/pod-scaler", loginHandler(validator, handlePodScalerResult()))
+	http.Handle("/pod-scaler/oom-killed", loginHandler(validator, handlePodScalerOOMKill()))
 
 	metrics.ExposeMetrics("result-aggregator", prowConfig.PushGateway{}, flagutil.DefaultMetricsPort)
 
@@ -234,3 +234,54 @@ func TestValidatePodScalerRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePodScalerOOMKillRequest(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		request  *results.PodScalerOOMKillRequest
+		expected error
+	}{
+		{
+			name: "everything ok",
+			request: &results.PodScalerOOMKillRequest{
+				WorkloadName:     "name",
+				WorkloadType:     "step",
+				DeterminedAmount: "400",
+			},
+			expected: nil,
+		},
+		{
+			name: "empty workload name",
+			request: &results.PodScalerOOMKillRequest{
+				WorkloadType:     "step",
+				DeterminedAmount: "400",
+			},
+			expected: fmt.Errorf("workload_name field in request is empty"),
+		},
+		{
+			name: "empty workload type",
+			request: &results.PodScalerOOMKillRequest{
+				WorkloadName:     "name",
+				DeterminedAmount: "400",
+			},
+			expected: fmt.Errorf("workload_type field in request is empty"),
+		},
+		{
+			name: "empty determined memory",
+			request: &results.PodScalerOOMKillRequest{
+				WorkloadName: "name",
+				WorkloadType: "step",
+			},
+			expected: fmt.Errorf("determined_amount field in request is empty"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := validatePodScalerOOMKillRequest(testCase.request)
+			if diff := cmp.Diff(testCase.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("actual error doesn't match expected error, diff: %v", diff)
+			}
+		})
+	}
+}
@@ -5,12 +5,16 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
+	"cloud.google.com/go/storage"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/api/option"
 
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlruntimeconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
 	prowConfig "sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/flagutil"
 	prowflagutil "sigs.k8s.io/prow/pkg/flagutil"
@@ -33,11 +37,17 @@ type options struct {
 
 	interval time.Duration
 
-	cacheFile      string
-	cacheFileOnS3  bool
-	cacheRecordAge time.Duration
-
-	configFile string
+	cacheFile          string
+	cacheFileOnS3      bool
+	cacheBackend       string
+	cacheBucket        string
+	cacheNamespace     string
+	gcsCredentialsFile string
+	cacheRecordAge     time.Duration
+
+	configFile     string
+	reportFile     string
+	quarantineList string
 }
 
 func (o *options) Validate() error {
@@ -52,9 +62,34 @@ func (o *options) Validate() error {
 	if o.cacheFileOnS3 && o.cacheFile == "" {
 		return fmt.Errorf("--cache-file is required if --cache-file-on-s3 is set to true")
 	}
+	switch o.cacheBackend {
+	case "", cacheBackendFile:
+	case cacheBackendS3, cacheBackendGCS, retester.KubernetesStorageConfigMap, retester.KubernetesStorageSecret:
+		if o.cacheFile == "" {
+			return fmt.Errorf("--cache-file is required with --cache-backend=%s", o.cacheBackend)
+		}
+	default:
+		return fmt.Errorf("--cache-backend must be one of %s", strings.Join(cacheBackends, ", "))
+	}
+	if o.cacheBackend == cacheBackendGCS {
+		if o.cacheBucket == "" {
+			return fmt.Errorf("--cache-bucket is required with --cache-backend=%s", o.cacheBackend)
+		}
+		if o.gcsCredentialsFile == "" {
+			return fmt.Errorf("--gcs-credentials-file is required with --cache-backend=%s", o.cacheBackend)
+		}
+	}
 	return nil
 }
 
+const (
+	cacheBackendFile = "file"
+	cacheBackendS3   = "s3"
+	cacheBackendGCS  = "gcs"
+)
+
+var cacheBackends = []string{cacheBackendFile, cacheBackendS3, cacheBackendGCS, retester.KubernetesStorageConfigMap, retester.KubernetesStorageSecret}
+
 func (o *options) complete() error {
 	var err error
 	o.interval, err = time.ParseDuration(o.intervalRaw)
@@ -65,6 +100,12 @@ func (o *options) complete() error {
 	if err != nil {
 		return fmt.Errorf("invalid --cache-record-age: %w", err)
 	}
+	if o.cacheFileOnS3 {
+		o.cacheBackend = cacheBackendS3
+	}
+	if o.cacheBackend == cacheBackendS3 && o.cacheBucket == "" {
+		o.cacheBucket = retester.DefaultS3Bucket
+	}
 	return nil
 }
 
@@ -74,11 +115,17 @@ func gatherOptions() options {
 
 	fs.BoolVar(&o.dryRun, "dry-run", true, "Dry run for testing. Uses API tokens but does not mutate.")
 	fs.BoolVar(&o.runOnce, "run-once", false, "If true, run only once then quit.")
-	fs.BoolVar(&o.cacheFileOnS3, "cache-file-on-s3", false, "If true, use aws s3 bucket to store the cache file.")
+	fs.BoolVar(&o.cacheFileOnS3, "cache-file-on-s3", false, "DEPRECATED: use --cache-backend=s3. If true, use aws s3 bucket to store the cache file.")
+	fs.StringVar(&o.cacheBackend, "cache-backend", cacheBackendFile, fmt.Sprintf("Where to persist the cache, one of %s.", strings.Join(cacheBackends, ", ")))
+	fs.StringVar(&o.cacheBucket, "cache-bucket", "", fmt.Sprintf("Bucket to persist the cache in with the s3 and gcs backends. Defaults to %s for s3.", retester.DefaultS3Bucket))
+	fs.StringVar(&o.cacheNamespace, "cache-namespace", "ci", "Namespace of the ConfigMap or Secret to persist the cache in with the configmap and secret backends.")
+	fs.StringVar(&o.gcsCredentialsFile, "gcs-credentials-file", "", "File where GCS credentials are stored, for the gcs backend.")
 	fs.StringVar(&o.intervalRaw, "interval", "1h", "Parseable duration string that specifies the sync period")
-	fs.StringVar(&o.cacheFile, "cache-file", "", "File to persist cache. No persistence of cache if not set")
+	fs.StringVar(&o.cacheFile, "cache-file", "", "File to persist cache: the path of the file, the key of the object in the bucket, or the name of the ConfigMap or Secret, depending on the backend. No persistence of cache if not set")
 	fs.StringVar(&o.cacheRecordAgeRaw, "cache-record-age", "168h", "Parseable duration string that specifies how long a cache record lives in cache after the last time it was considered")
 	fs.StringVar(&o.configFile, "config-file", "", "Path to the configure file of the retest.")
+	fs.StringVar(&o.quarantineList, "quarantine-list", "", "File with the list of quarantined flaky tests. Jobs failing only because of quarantined tests are retested regardless of the failure policies.")
+	fs.StringVar(&o.reportFile, "report-file", "", "File to write a report of the pull requests retested, held or paused in each sync to. In dry-run mode, the report lists what would have been done.")
 
 	for _, group := range []flagutil.OptionGroup{&o.github, &o.config} {
 		group.AddFlags(fs)
@@ -122,19 +169,12 @@ func main() {
 
 	ctx := interrupts.Context()
 
-	var awsConfig aws.Config
-	if o.cacheFileOnS3 {
-		awsConfig, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion("us-east-1"))
-		if err != nil {
-			logrus.WithError(err).Fatal("Failed to create AWS config.")
-		}
-		_, err = awsConfig.Credentials.Retrieve(ctx)
-		if err != nil {
-			logrus.WithError(err).Fatal("Error getting AWS credentials.")
-		}
+	storage, err := o.storage(ctx)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to set up the storage of the cache.")
 	}
 
-	c := retester.NewController(ctx, gc, configAgent.Config, gitClient, o.github.AppPrivateKeyPath != "", o.cacheFile, o.cacheRecordAge, config, &awsConfig)
+	c := retester.NewController(ctx, gc, configAgent.Config, gitClient, o.github.AppPrivateKeyPath != "", storage, o.cacheRecordAge, config, o.dryRun, o.reportFile, o.quarantineList)
 
 	metrics.ExposeMetrics("retester", prowConfig.PushGateway{}, prowflagutil.DefaultMetricsPort)
 
@@ -160,6 +200,43 @@ func main() {
 	interrupts.WaitForGracefulShutdown()
 }
 
+// storage determines where the cache is persisted. It is only kept in memory
+// when no cache file is set.
+func (o *options) storage(ctx context.Context) (retester.Storage, error) {
+	if o.cacheFile == "" {
+		return nil, nil
+	}
+	switch o.cacheBackend {
+	case cacheBackendS3:
+		awsConfig, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion("us-east-1"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS config: %w", err)
+		}
+		if _, err := awsConfig.Credentials.Retrieve(ctx); err != nil {
+			return nil, fmt.Errorf("error getting AWS credentials: %w", err)
+		}
+		return retester.NewS3Storage(awsConfig, o.cacheBucket, o.cacheFile), nil
+	case cacheBackendGCS:
+		client, err := storage.NewClient(ctx, option.WithCredentialsFile(o.gcsCredentialsFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCS client: %w", err)
+		}
+		return retester.NewGCSStorage(client, o.cacheBucket, o.cacheFile), nil
+	case retester.KubernetesStorageConfigMap, retester.KubernetesStorageSecret:
+		restConfig, err := ctrlruntimeconfig.GetConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the cluster config: %w", err)
+		}
+		client, err := ctrlruntimeclient.New(restConfig, ctrlruntimeclient.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the cluster client: %w", err)
+		}
+		return retester.NewKubernetesStorage(client, o.cacheBackend, o.cacheNamespace, o.cacheFile)
+	default:
+		return retester.NewFileStorage(o.cacheFile), nil
+	}
+}
+
 func execute(ctx context.Context, c *retester.RetestController) {
 	if err := c.Run(ctx); err != nil {
 		logrus.WithError(err).Error("Error running")
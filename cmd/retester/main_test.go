@@ -117,6 +117,44 @@ func TestValidate(t *testing.T) {
 			},
 			expected: errors.New("--cache-file is required if --cache-file-on-s3 is set to true"),
 		},
+		{
+			name: "cache-file not set when using a configmap",
+			o: options{
+				config:         flagutil.ConfigOptions{ConfigPath: "/etc/config/config.yaml"},
+				configFile:     "/etc/retester/config.yaml",
+				dryRun:         true,
+				interval:       time.Hour,
+				cacheRecordAge: sevenDays,
+				cacheBackend:   "configmap",
+			},
+			expected: errors.New("--cache-file is required with --cache-backend=configmap"),
+		},
+		{
+			name: "bucket not set when using gcs",
+			o: options{
+				config:             flagutil.ConfigOptions{ConfigPath: "/etc/config/config.yaml"},
+				configFile:         "/etc/retester/config.yaml",
+				dryRun:             true,
+				interval:           time.Hour,
+				cacheRecordAge:     sevenDays,
+				cacheBackend:       "gcs",
+				cacheFile:          "cache.yaml",
+				gcsCredentialsFile: "/etc/gcs/service-account.json",
+			},
+			expected: errors.New("--cache-bucket is required with --cache-backend=gcs"),
+		},
+		{
+			name: "unknown backend",
+			o: options{
+				config:         flagutil.ConfigOptions{ConfigPath: "/etc/config/config.yaml"},
+				configFile:     "/etc/retester/config.yaml",
+				dryRun:         true,
+				interval:       time.Hour,
+				cacheRecordAge: sevenDays,
+				cacheBackend:   "azure",
+			},
+			expected: errors.New("--cache-backend must be one of file, s3, gcs, configmap, secret"),
+		},
 		{
 			name: "cache-file not set when using local file cache",
 			o: options{
@@ -145,6 +183,8 @@ func TestComplete(t *testing.T) {
 		expected               error
 		expectedInterval       time.Duration
 		expectedCacheRecordAge time.Duration
+		expectedCacheBackend   string
+		expectedCacheBucket    string
 	}{
 		{
 			name: "basic",
@@ -155,6 +195,18 @@ func TestComplete(t *testing.T) {
 			expectedInterval:       time.Hour,
 			expectedCacheRecordAge: sevenDays,
 		},
+		{
+			name: "deprecated s3 flag selects the s3 backend",
+			o: options{
+				intervalRaw:       "1h",
+				cacheRecordAgeRaw: "168h",
+				cacheFileOnS3:     true,
+			},
+			expectedInterval:       time.Hour,
+			expectedCacheRecordAge: sevenDays,
+			expectedCacheBackend:   "s3",
+			expectedCacheBucket:    "prow-retester",
+		},
 		{
 			name: "wrong format",
 			o: options{
@@ -183,6 +235,12 @@ func TestComplete(t *testing.T) {
 			if diff := cmp.Diff(tc.expectedCacheRecordAge, tc.o.cacheRecordAge); diff != "" {
 				t.Errorf("%s cache record age differs from expected:\n%s", tc.name, diff)
 			}
+			if diff := cmp.Diff(tc.expectedCacheBackend, tc.o.cacheBackend); diff != "" {
+				t.Errorf("%s cache backend differs from expected:\n%s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.expectedCacheBucket, tc.o.cacheBucket); diff != "" {
+				t.Errorf("%s cache bucket differs from expected:\n%s", tc.name, diff)
+			}
 		})
 	}
 }
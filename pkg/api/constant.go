@@ -62,6 +62,13 @@ const (
 	CliEnv                = "CLI_DIR"
 	DefaultLeaseEnv       = "LEASED_RESOURCE"
 	DefaultIPPoolLeaseEnv = "IP_POOL_AVAILABLE"
+	// PreCacheNamespace is the namespace holding the cached results of
+	// multi-stage `pre` phases, shared by all jobs on a build farm.
+	PreCacheNamespace = "ci-pre-cache"
+	// ReleaseControllerJobLabel marks the periodics which the release controller runs
+	ReleaseControllerJobLabel = "ci-operator.openshift.io/release-controller"
+	// RehearsalJobLabel marks the rehearsals created by pj-rehearse
+	RehearsalJobLabel = "ci.openshift.io/rehearse"
 	// SkipCensoringLabel is the label we use to mark a secret as not needing to be censored
 	SkipCensoringLabel = "ci.openshift.io/skip-censoring"
 
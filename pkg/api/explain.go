@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// Explanation describes why steps are part of the graph executed for a target.
+type Explanation struct {
+	Target string
+	// CriticalPath is the longest chain of steps that leads to the target, in
+	// the order in which they execute. When durations are known, the length of
+	// the chain is the sum of the durations of its steps, otherwise it is the
+	// number of steps in it.
+	CriticalPath []string
+	// Duration is the sum of the durations of the steps on the critical path,
+	// when they are known.
+	Duration time.Duration
+	// Steps holds all steps the target needs, in the order in which they execute.
+	Steps []string
+	// RequiredBy maps every step the target needs to the steps needing it directly.
+	RequiredBy map[string][]string
+	// PrunedBy maps every step creating a pipeline image to the steps that would
+	// no longer be needed if the image was supplied externally, including itself.
+	PrunedBy map[string][]string
+}
+
+// Explain determines the critical path to each target and why each step is
+// needed by it. Durations of the steps from a previous execution are optional;
+// without them, every step is considered to take equally long. If no targets
+// are given, the steps no other steps depend on are explained.
+func Explain(steps OrderedStepList, targets []string, durations map[string]time.Duration) ([]Explanation, error) {
+	names := make([]string, len(steps))
+	index := map[string]int{}
+	dependencies := make([][]int, len(steps))
+	dependents := make([][]int, len(steps))
+	for i, node := range steps {
+		names[i] = node.Step.Name()
+		index[names[i]] = i
+		requires := node.Step.Requires()
+		// Only the first `i` elements can fulfill the requirements since
+		// `OrderedStepList` is a topological order.
+		for j, other := range steps[:i] {
+			if HasAnyLinks(requires, other.Step.Creates()) {
+				dependencies[i] = append(dependencies[i], j)
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		for i := range steps {
+			if len(dependents[i]) == 0 {
+				targets = append(targets, names[i])
+			}
+		}
+	}
+
+	cost := func(i int) int64 {
+		if durations == nil {
+			return 1
+		}
+		return int64(durations[names[i]])
+	}
+	// the longest chain ending in each step, and its previous step
+	length := make([]int64, len(steps))
+	previous := make([]int, len(steps))
+	for i := range steps {
+		previous[i] = -1
+		for _, j := range dependencies[i] {
+			if previous[i] == -1 || length[j] > length[previous[i]] {
+				previous[i] = j
+			}
+		}
+		length[i] = cost(i)
+		if previous[i] != -1 {
+			length[i] += length[previous[i]]
+		}
+	}
+
+	var explanations []Explanation
+	for _, target := range targets {
+		t, ok := index[target]
+		if !ok {
+			return nil, fmt.Errorf("target %q is not in the graph", target)
+		}
+		explanation := Explanation{
+			Target:     target,
+			RequiredBy: map[string][]string{},
+			PrunedBy:   map[string][]string{},
+		}
+		for i := t; i != -1; i = previous[i] {
+			explanation.CriticalPath = append([]string{names[i]}, explanation.CriticalPath...)
+			if durations != nil {
+				explanation.Duration += durations[names[i]]
+			}
+		}
+		needed := ancestors(t, dependencies, -1)
+		for i := range steps {
+			if !needed[i] {
+				continue
+			}
+			explanation.Steps = append(explanation.Steps, names[i])
+			for _, j := range dependents[i] {
+				if needed[j] {
+					explanation.RequiredBy[names[i]] = append(explanation.RequiredBy[names[i]], names[j])
+				}
+			}
+			if i == t || !HasAnyLinks([]StepLink{InternalImageLink(PipelineImageStreamTagReference(names[i]))}, steps[i].Step.Creates()) {
+				continue
+			}
+			stillNeeded := ancestors(t, dependencies, i)
+			for j := range steps {
+				if needed[j] && !stillNeeded[j] {
+					explanation.PrunedBy[names[i]] = append(explanation.PrunedBy[names[i]], names[j])
+				}
+			}
+		}
+		explanations = append(explanations, explanation)
+	}
+	return explanations, nil
+}
+
+// ancestors determines the steps needed to run a step, including itself. The
+// supplied step is considered to be available without running it, or its
+// dependencies.
+func ancestors(step int, dependencies [][]int, supplied int) map[int]bool {
+	ret := map[int]bool{}
+	var visit func(int)
+	visit = func(i int) {
+		if ret[i] || i == supplied {
+			return
+		}
+		ret[i] = true
+		for _, j := range dependencies[i] {
+			visit(j)
+		}
+	}
+	visit(step)
+	return ret
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExplain(t *testing.T) {
+	root := &fakeStep{
+		name:     "root",
+		requires: []StepLink{ExternalImageLink(ImageStreamTagReference{Namespace: "ns", Name: "base", Tag: "latest"})},
+		creates:  []StepLink{InternalImageLink(PipelineImageStreamTagReferenceRoot)},
+	}
+	src := &fakeStep{
+		name:     "src",
+		requires: []StepLink{InternalImageLink(PipelineImageStreamTagReferenceRoot)},
+		creates:  []StepLink{InternalImageLink(PipelineImageStreamTagReferenceSource)},
+	}
+	bin := &fakeStep{
+		name:     "bin",
+		requires: []StepLink{InternalImageLink(PipelineImageStreamTagReferenceSource)},
+		creates:  []StepLink{InternalImageLink(PipelineImageStreamTagReferenceBinaries)},
+	}
+	release := &fakeStep{
+		name:     "release:latest",
+		requires: []StepLink{ExternalImageLink(ImageStreamTagReference{Namespace: "ocp", Name: "release", Tag: "latest"})},
+		creates:  []StepLink{ReleaseImagesLink(LatestReleaseName)},
+	}
+	e2e := &fakeStep{
+		name:     "e2e",
+		requires: []StepLink{InternalImageLink(PipelineImageStreamTagReferenceBinaries), ReleaseImagesLink(LatestReleaseName)},
+	}
+	unit := &fakeStep{
+		name:     "unit",
+		requires: []StepLink{InternalImageLink(PipelineImageStreamTagReferenceSource)},
+	}
+	steps := OrderedStepList{{Step: root}, {Step: release}, {Step: src}, {Step: bin}, {Step: e2e}, {Step: unit}}
+
+	for _, tc := range []struct {
+		name        string
+		targets     []string
+		durations   map[string]time.Duration
+		expected    []Explanation
+		expectedErr string
+	}{
+		{
+			name:    "without durations, the longest chain of steps is critical",
+			targets: []string{"e2e"},
+			expected: []Explanation{{
+				Target:       "e2e",
+				CriticalPath: []string{"root", "src", "bin", "e2e"},
+				Steps:        []string{"root", "release:latest", "src", "bin", "e2e"},
+				RequiredBy: map[string][]string{
+					"root":           {"src"},
+					"release:latest": {"e2e"},
+					"src":            {"bin"},
+					"bin":            {"e2e"},
+				},
+				PrunedBy: map[string][]string{
+					"root": {"root"},
+					"src":  {"root", "src"},
+					"bin":  {"root", "src", "bin"},
+				},
+			}},
+		},
+		{
+			name:      "with durations, the slowest chain of steps is critical",
+			targets:   []string{"e2e"},
+			durations: map[string]time.Duration{"root": time.Minute, "src": time.Minute, "bin": time.Minute, "release:latest": time.Hour, "e2e": time.Hour},
+			expected: []Explanation{{
+				Target:       "e2e",
+				CriticalPath: []string{"release:latest", "e2e"},
+				Duration:     2 * time.Hour,
+				Steps:        []string{"root", "release:latest", "src", "bin", "e2e"},
+				RequiredBy: map[string][]string{
+					"root":           {"src"},
+					"release:latest": {"e2e"},
+					"src":            {"bin"},
+					"bin":            {"e2e"},
+				},
+				PrunedBy: map[string][]string{
+					"root": {"root"},
+					"src":  {"root", "src"},
+					"bin":  {"root", "src", "bin"},
+				},
+			}},
+		},
+		{
+			name: "without targets, all leaves are explained",
+			expected: []Explanation{{
+				Target:       "e2e",
+				CriticalPath: []string{"root", "src", "bin", "e2e"},
+				Steps:        []string{"root", "release:latest", "src", "bin", "e2e"},
+				RequiredBy: map[string][]string{
+					"root":           {"src"},
+					"release:latest": {"e2e"},
+					"src":            {"bin"},
+					"bin":            {"e2e"},
+				},
+				PrunedBy: map[string][]string{
+					"root": {"root"},
+					"src":  {"root", "src"},
+					"bin":  {"root", "src", "bin"},
+				},
+			}, {
+				Target:       "unit",
+				CriticalPath: []string{"root", "src", "unit"},
+				Steps:        []string{"root", "src", "unit"},
+				RequiredBy: map[string][]string{
+					"root": {"src"},
+					"src":  {"unit"},
+				},
+				PrunedBy: map[string][]string{
+					"root": {"root"},
+					"src":  {"root", "src"},
+				},
+			}},
+		},
+		{
+			name:        "unknown target",
+			targets:     []string{"missing"},
+			expectedErr: `target "missing" is not in the graph`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Explain(steps, tc.targets, tc.durations)
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, actualErr); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected explanations: %s", diff)
+			}
+		})
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"os"
 	"runtime/debug"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
@@ -30,6 +33,15 @@ type JobSpec struct {
 	Metadata               Metadata
 	Target                 string
 	TargetAdditionalSuffix string
+	// InputHash is the hash of all inputs of the build, also used to name the
+	// test namespace. It is only available once the inputs have been resolved.
+	InputHash string
+	// ShardIndex is the 1-based index of the shard of the targeted test that
+	// this job runs, out of ShardCount. Both are zero if the test is not sharded.
+	ShardIndex int
+	ShardCount int
+	// Labels are the labels of the pod running the job, if they are known.
+	Labels map[string]string `json:"-"`
 }
 
 // Namespace returns the namespace of the job. Must not be evaluated
@@ -85,6 +97,50 @@ func (s JobSpec) UniqueHash() string {
 	return fmt.Sprintf("%x", sha256.Sum256([]byte(job)))[:5]
 }
 
+const (
+	// ShardIndexEnv holds the 1-based index of the shard a test runs.
+	ShardIndexEnv = "SHARD_INDEX"
+	// ShardCountEnv holds the number of shards a test is split into.
+	ShardCountEnv = "SHARD_COUNT"
+)
+
+// ShardEnv returns the environment telling a sharded test which part of its
+// tests to run. It is empty unless the test is the sharded target of the job.
+func (s JobSpec) ShardEnv(test string) map[string]string {
+	if s.ShardCount == 0 || test != s.Target {
+		return nil
+	}
+	return map[string]string{
+		ShardIndexEnv: strconv.Itoa(s.ShardIndex),
+		ShardCountEnv: strconv.Itoa(s.ShardCount),
+	}
+}
+
+// LoadJobLabels reads the labels of the pod running the job from a file
+// projected by the downward API, which holds one key="value" pair per line.
+func LoadJobLabels(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the job labels: %w", err)
+	}
+	labels := map[string]string{}
+	for _, line := range strings.Split(string(raw), "\n") {
+		if line == "" {
+			continue
+		}
+		key, quoted, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed job label %q", line)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("malformed value of job label %s: %w", key, err)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
 // ResolveSpecFromEnv will determine the Refs being
 // tested in by parsing Prow environment variable contents
 func ResolveSpecFromEnv() (*JobSpec, error) {
@@ -1,11 +1,16 @@
 package api
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 
 	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
 func TestUniqueHash(t *testing.T) {
@@ -43,3 +48,75 @@ func TestUniqueHash(t *testing.T) {
 		})
 	}
 }
+
+func TestShardEnv(t *testing.T) {
+	testCases := []struct {
+		name     string
+		jobSpec  JobSpec
+		expected map[string]string
+	}{
+		{
+			name: "not sharded",
+		},
+		{
+			name:     "sharded",
+			jobSpec:  JobSpec{Target: "e2e", ShardIndex: 2, ShardCount: 3},
+			expected: map[string]string{"SHARD_INDEX": "2", "SHARD_COUNT": "3"},
+		},
+		{
+			name:    "other test than the sharded target",
+			jobSpec: JobSpec{Target: "unit", ShardIndex: 2, ShardCount: 3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.jobSpec.ShardEnv("e2e")); diff != "" {
+				t.Fatalf("shard environment doesn't match expected, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadJobLabels(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       string
+		expected      map[string]string
+		expectedError error
+	}{
+		{
+			name:     "labels",
+			content:  "ci-operator.openshift.io/release-controller=\"true\"\nci.openshift.io/rehearse=\"1234\"\nquoted=\"a \\\"b\\\"\"\n",
+			expected: map[string]string{"ci-operator.openshift.io/release-controller": "true", "ci.openshift.io/rehearse": "1234", "quoted": `a "b"`},
+		},
+		{
+			name:     "no labels",
+			expected: map[string]string{},
+		},
+		{
+			name:          "malformed line",
+			content:       "created-by-prow\n",
+			expectedError: errors.New(`malformed job label "created-by-prow"`),
+		},
+		{
+			name:          "unquoted value",
+			content:       "created-by-prow=true\n",
+			expectedError: errors.New("malformed value of job label created-by-prow: invalid syntax"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "labels")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("failed to write the labels: %v", err)
+			}
+			actual, err := LoadJobLabels(path)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Fatalf("error doesn't match expected, diff: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Fatalf("labels don't match expected, diff: %s", diff)
+			}
+		})
+	}
+}
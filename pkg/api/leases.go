@@ -3,6 +3,8 @@ package api
 import (
 	"strconv"
 	"strings"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 )
 
 // LeasesForTest aggregates all the lease configurations in a test.
@@ -23,6 +25,41 @@ func LeasesForTest(s *MultiStageTestConfigurationLiteral) (ret []StepLease) {
 	return
 }
 
+// LeasePriorityForTest derives the priority class of the leases of a test from
+// the type and the labels of the job running it:
+//
+//   - rehearsals and optional presubmits have a low priority
+//   - blocking presubmits and periodics run by the release controller have a high priority
+//   - all other jobs have a normal priority
+func LeasePriorityForTest(spec *JobSpec, test *TestStepConfiguration) LeasePriority {
+	if _, ok := spec.Labels[RehearsalJobLabel]; ok || strings.HasPrefix(spec.Job, rehearsalJobPrefix) {
+		return LeasePriorityLow
+	}
+	switch spec.Type {
+	case prowv1.PresubmitJob:
+		if test.Optional {
+			return LeasePriorityLow
+		}
+		return LeasePriorityHigh
+	case prowv1.PeriodicJob:
+		if spec.Labels[ReleaseControllerJobLabel] == "true" {
+			return LeasePriorityHigh
+		}
+	}
+	return LeasePriorityNormal
+}
+
+// rehearsalJobPrefix is the prefix of the names of jobs created by pj-rehearse
+const rehearsalJobPrefix = "rehearse-"
+
+// PrioritizeLeases sets the priority class of leases.
+func PrioritizeLeases(leases []StepLease, priority LeasePriority) []StepLease {
+	for i := range leases {
+		leases[i].Priority = priority
+	}
+	return leases
+}
+
 const maxAddressesRequired = 13
 
 func IPPoolLeaseForTest(s *MultiStageTestConfigurationLiteral, metadata Metadata) (ret StepLease) {
@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+
+	prowv1 "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 )
 
 func TestLeasesForTest(t *testing.T) {
@@ -94,3 +97,79 @@ func TestIPPoolLeaseForTest(t *testing.T) {
 		})
 	}
 }
+
+func TestLeasePriorityForTest(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		job      string
+		jobType  prowv1.ProwJobType
+		labels   map[string]string
+		test     TestStepConfiguration
+		expected LeasePriority
+	}{{
+		name:     "blocking presubmit",
+		job:      "pull-ci-org-repo-branch-e2e",
+		jobType:  prowv1.PresubmitJob,
+		expected: LeasePriorityHigh,
+	}, {
+		name:     "optional presubmit",
+		job:      "pull-ci-org-repo-branch-e2e",
+		jobType:  prowv1.PresubmitJob,
+		test:     TestStepConfiguration{Optional: true},
+		expected: LeasePriorityLow,
+	}, {
+		name:     "rehearsal",
+		job:      "rehearse-1234-pull-ci-org-repo-branch-e2e",
+		jobType:  prowv1.PresubmitJob,
+		expected: LeasePriorityLow,
+	}, {
+		name:     "rehearsal by label",
+		job:      "pull-ci-org-repo-branch-e2e",
+		jobType:  prowv1.PresubmitJob,
+		labels:   map[string]string{RehearsalJobLabel: "1234"},
+		expected: LeasePriorityLow,
+	}, {
+		name:     "periodic run by the release controller",
+		job:      "periodic-ci-org-repo-branch-e2e",
+		jobType:  prowv1.PeriodicJob,
+		labels:   map[string]string{ReleaseControllerJobLabel: "true"},
+		expected: LeasePriorityHigh,
+	}, {
+		name:     "periodic configured for the release controller without the label",
+		job:      "periodic-ci-org-repo-branch-e2e",
+		jobType:  prowv1.PeriodicJob,
+		test:     TestStepConfiguration{ReleaseController: true},
+		expected: LeasePriorityNormal,
+	}, {
+		name:     "periodic",
+		job:      "periodic-ci-org-repo-branch-e2e",
+		jobType:  prowv1.PeriodicJob,
+		expected: LeasePriorityNormal,
+	}, {
+		name:     "postsubmit",
+		job:      "branch-ci-org-repo-branch-images",
+		jobType:  prowv1.PostsubmitJob,
+		expected: LeasePriorityNormal,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := JobSpec{JobSpec: downwardapi.JobSpec{Job: tc.job, Type: tc.jobType}, Labels: tc.labels}
+			if diff := cmp.Diff(tc.expected, LeasePriorityForTest(&spec, &tc.test)); diff != "" {
+				t.Errorf("unexpected priority: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPrioritizeLeases(t *testing.T) {
+	leases := []StepLease{
+		{ResourceType: "aws-quota-slice"},
+		{ResourceType: "gcp-quota-slice", Priority: LeasePriorityLow},
+	}
+	expected := []StepLease{
+		{ResourceType: "aws-quota-slice", Priority: LeasePriorityHigh},
+		{ResourceType: "gcp-quota-slice", Priority: LeasePriorityHigh},
+	}
+	if diff := cmp.Diff(expected, PrioritizeLeases(leases, LeasePriorityHigh)); diff != "" {
+		t.Errorf("unexpected leases: %s", diff)
+	}
+}
@@ -837,6 +837,7 @@ type TestStepConfiguration struct {
 
 	// ShardCount describes the number of jobs that should be generated as shards for this test
 	// Each generated job will be a duplication, but contain a suffix and the necessary SHARD_ARGS will be passed to the steps
+	// Every container of the test also gets its shard as SHARD_INDEX, starting at 1, and SHARD_COUNT
 	// Only applicable to presubmits and periodics
 	ShardCount *int `json:"shard_count,omitempty"`
 
@@ -959,6 +960,8 @@ type RegistryChain struct {
 	Environment []StepParameter `json:"env,omitempty"`
 	// Leases lists resources that should be acquired for the test.
 	Leases []StepLease `json:"leases,omitempty"`
+	// Parallel makes all steps of the chain execute concurrently.
+	Parallel bool `json:"parallel,omitempty"`
 }
 
 // RegistryWorkflowConfig is the struct that workflow references are unmarshalled into.
@@ -1090,6 +1093,37 @@ type LiteralTestStep struct {
 	// NodeArchitecture is the architecture for the node where the test will run.
 	// If set, the generated test pod will include a nodeSelector for this architecture.
 	NodeArchitecture *NodeArchitecture `json:"node_architecture,omitempty"`
+	// Retry defines if and how this step is retried when it fails.
+	Retry *StepRetry `json:"retry,omitempty"`
+	// When is a condition on the test parameters which determines whether this
+	// step is executed, e.g. `CLUSTER_TYPE == "aws" && !FIPS_ENABLED`. The step
+	// is skipped when it evaluates to false. Conditions are evaluated right
+	// before the step would be executed, after the parameters passed to
+	// ci-operator are applied.
+	When string `json:"when,omitempty"`
+	// ParallelGroup is the name of the group of steps this step is executed
+	// concurrently with. Consecutive steps with the same group are executed
+	// at the same time. It is set when parallel steps are resolved. The steps
+	// of a group belong to the same phase and are either all best-effort or
+	// none of them is.
+	ParallelGroup string `json:"parallel_group,omitempty"`
+}
+
+// StepRetry defines how a failing step is retried. Each attempt is reported
+// as a separate test case.
+type StepRetry struct {
+	// Attempts is the maximum number of times the step is executed, including
+	// the first execution.
+	Attempts int `json:"attempts"`
+	// Backoff is how long to wait before the first retry. The wait time is
+	// doubled for each subsequent retry.
+	Backoff *prowv1.Duration `json:"backoff,omitempty"`
+	// ExitCodes lists the exit codes of the step container that allow a
+	// retry.
+	ExitCodes []int32 `json:"exit_codes,omitempty"`
+	// Reasons lists the failure reasons that allow a retry, e.g. `pod_pending`.
+	// When neither exit codes nor reasons are set, every failure is retried.
+	Reasons []string `json:"reasons,omitempty"`
 }
 
 // StepParameter is a variable set by the test, with an optional default.
@@ -1142,8 +1176,25 @@ type StepLease struct {
 	Env string `json:"env"`
 	// Count is the number of resources to acquire (optional, defaults to 1).
 	Count uint `json:"count,omitempty"`
+	// Priority is the priority class of the lease.  It cannot be configured,
+	// ci-operator derives it from the job which runs the test.
+	Priority LeasePriority `json:"-"`
 }
 
+// LeasePriority is the priority class of a lease.  When resources are scarce,
+// leases of a higher class are granted first.  The Boskos lease server only
+// distinguishes low priority leases from the others.
+type LeasePriority string
+
+const (
+	// LeasePriorityHigh is used by jobs that gate merges or releases.
+	LeasePriorityHigh LeasePriority = "high"
+	// LeasePriorityNormal is the default priority class.
+	LeasePriorityNormal LeasePriority = "normal"
+	// LeasePriorityLow is used by jobs whose results are informational.
+	LeasePriorityLow LeasePriority = "low"
+)
+
 // FromImageTag returns the internal name for the image tag that will be used
 // for this step, if one is configured.
 func (s *LiteralTestStep) FromImageTag() (PipelineImageStreamTagReference, bool) {
@@ -1163,6 +1214,49 @@ type TestStep struct {
 	Reference *string `json:"ref,omitempty"`
 	// Chain is the name of a step chain reference.
 	Chain *string `json:"chain,omitempty"`
+	// Parallel is a group of steps which are executed concurrently. Steps of
+	// chains in the group are also executed concurrently.
+	Parallel []ParallelTestStep `json:"parallel,omitempty"`
+	// When is a condition on the test parameters which determines whether the
+	// step or all steps of the chain are executed. See LiteralTestStep.When.
+	When string `json:"when,omitempty"`
+}
+
+// ParallelTestStep is a member of a parallel group of steps. Groups cannot be
+// nested.
+type ParallelTestStep struct {
+	// LiteralTestStep is a full test step definition.
+	*LiteralTestStep `json:",inline,omitempty"`
+	// Reference is the name of a step reference.
+	Reference *string `json:"ref,omitempty"`
+	// Chain is the name of a step chain reference.
+	Chain *string `json:"chain,omitempty"`
+	// When is a condition on the test parameters which determines whether the
+	// step or all steps of the chain are executed. See LiteralTestStep.When.
+	When string `json:"when,omitempty"`
+}
+
+// ParallelSteps returns the members of the parallel group of a step.
+func (s TestStep) ParallelSteps() []TestStep {
+	var ret []TestStep
+	for _, p := range s.Parallel {
+		ret = append(ret, TestStep{LiteralTestStep: p.LiteralTestStep, Reference: p.Reference, Chain: p.Chain, When: p.When})
+	}
+	return ret
+}
+
+// FlattenTestSteps returns the steps with the members of parallel groups
+// expanded in their place.
+func FlattenTestSteps(steps []TestStep) []TestStep {
+	var ret []TestStep
+	for _, step := range steps {
+		if step.Parallel != nil {
+			ret = append(ret, step.ParallelSteps()...)
+		} else {
+			ret = append(ret, step)
+		}
+	}
+	return ret
 }
 
 // MultiStageTestConfiguration is a flexible configuration mode that allows tighter control over
@@ -1197,6 +1291,13 @@ type MultiStageTestConfiguration struct {
 	// they fail. The given step must explicitly ask for being ignored by setting
 	// the OptionalOnSuccess flag to true.
 	AllowBestEffortPostSteps *bool `json:"allow_best_effort_post_steps,omitempty"`
+	// CachePre defines if the results of the `pre` steps can be reused by later
+	// jobs with the same inputs. When set, a successful `pre` phase stores the
+	// content of the shared directory, which is restored instead of running the
+	// `pre` steps when a job with the same input hash, configuration and leased
+	// resources runs again. The `post` steps must not tear down what the `pre`
+	// steps set up for the cached result to be usable.
+	CachePre *bool `json:"cache_pre,omitempty"`
 	// Observers are the observers that should be running
 	Observers *Observers `json:"observers,omitempty"`
 	// DependencyOverrides allows a step to override a dependency with a fully-qualified pullspec. This will probably only ever
@@ -1242,6 +1343,9 @@ type MultiStageTestConfigurationLiteral struct {
 	// they fail. The given step must explicitly ask for being ignored by setting
 	// the OptionalOnSuccess flag to true.
 	AllowBestEffortPostSteps *bool `json:"allow_best_effort_post_steps,omitempty"`
+	// CachePre defines if the results of the `pre` steps can be reused by later
+	// jobs with the same inputs.
+	CachePre *bool `json:"cache_pre,omitempty"`
 	// Observers are the observers that need to be run
 	Observers []Observer `json:"observers,omitempty"`
 	// DependencyOverrides allows a step to override a dependency with a fully-qualified pullspec. This will probably only ever
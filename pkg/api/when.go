@@ -0,0 +1,273 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EvaluateWhen evaluates the `when` condition of a test step.  Conditions are
+// written in a small expression language:
+//
+//	expr       := or
+//	or         := and ( "||" and )*
+//	and        := unary ( "&&" unary )*
+//	unary      := "!" unary | "(" expr ")" | comparison
+//	comparison := NAME [ ( "==" | "!=" ) STRING ]
+//
+// A bare variable is true when its value is set to anything other than an
+// empty string or a false boolean value (e.g. `false`, `0`).  Values of
+// variables are obtained from `lookup`, which returns an empty string for
+// variables which are not set.
+func EvaluateWhen(expr string, lookup func(name string) string) (bool, error) {
+	node, err := parseWhen(expr)
+	if err != nil {
+		return false, err
+	}
+	return node.evaluate(lookup), nil
+}
+
+// ValidateWhen verifies that a `when` condition is syntactically correct.
+func ValidateWhen(expr string) error {
+	_, err := parseWhen(expr)
+	return err
+}
+
+// WhenVariables returns the names of the variables a `when` condition refers
+// to, in the order in which they first appear.
+func WhenVariables(expr string) ([]string, error) {
+	node, err := parseWhen(expr)
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	seen := map[string]bool{}
+	var visit func(whenNode)
+	visit = func(node whenNode) {
+		var name string
+		switch n := node.(type) {
+		case whenVariable:
+			name = string(n)
+		case whenComparison:
+			name = n.name
+		case whenNot:
+			visit(n.node)
+		case whenAnd:
+			visit(n.left)
+			visit(n.right)
+		case whenOr:
+			visit(n.left)
+			visit(n.right)
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			ret = append(ret, name)
+		}
+	}
+	visit(node)
+	return ret, nil
+}
+
+type whenNode interface {
+	evaluate(lookup func(string) string) bool
+}
+
+type whenVariable string
+
+func (n whenVariable) evaluate(lookup func(string) string) bool {
+	value := lookup(string(n))
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	return value != ""
+}
+
+type whenComparison struct {
+	name   string
+	value  string
+	negate bool
+}
+
+func (n whenComparison) evaluate(lookup func(string) string) bool {
+	return (lookup(n.name) == n.value) != n.negate
+}
+
+type whenNot struct{ node whenNode }
+
+func (n whenNot) evaluate(lookup func(string) string) bool {
+	return !n.node.evaluate(lookup)
+}
+
+type whenAnd struct{ left, right whenNode }
+
+func (n whenAnd) evaluate(lookup func(string) string) bool {
+	return n.left.evaluate(lookup) && n.right.evaluate(lookup)
+}
+
+type whenOr struct{ left, right whenNode }
+
+func (n whenOr) evaluate(lookup func(string) string) bool {
+	return n.left.evaluate(lookup) || n.right.evaluate(lookup)
+}
+
+type whenParser struct {
+	tokens []string
+}
+
+func parseWhen(expr string) (whenNode, error) {
+	tokens, err := tokenizeWhen(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", expr, err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("invalid condition %q: empty expression", expr)
+	}
+	p := whenParser{tokens: tokens}
+	node, err := p.or()
+	if err == nil && len(p.tokens) != 0 {
+		err = fmt.Errorf("unexpected %q", p.tokens[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", expr, err)
+	}
+	return node, nil
+}
+
+func (p *whenParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+func (p *whenParser) next() string {
+	t := p.peek()
+	if len(p.tokens) != 0 {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+func (p *whenParser) or() (whenNode, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = whenOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *whenParser) and() (whenNode, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = whenAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *whenParser) unary() (whenNode, error) {
+	switch t := p.next(); {
+	case t == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case t == "!":
+		node, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return whenNot{node: node}, nil
+	case t == "(":
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return node, nil
+	case isWhenName(t):
+		op := p.peek()
+		if op != "==" && op != "!=" {
+			return whenVariable(t), nil
+		}
+		p.next()
+		value := p.next()
+		if !strings.HasPrefix(value, `"`) {
+			return nil, fmt.Errorf("expected a quoted string after %q, got %q", op, value)
+		}
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s: %w", value, err)
+		}
+		return whenComparison{name: t, value: unquoted, negate: op == "!="}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", t)
+	}
+}
+
+func isWhenName(t string) bool {
+	for i, r := range t {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return t != ""
+}
+
+func tokenizeWhen(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(expr[i:], "&&"), strings.HasPrefix(expr[i:], "||"),
+			strings.HasPrefix(expr[i:], "=="), strings.HasPrefix(expr[i:], "!="):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case c == '!':
+			tokens = append(tokens, "!")
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(expr) && expr[j] != '"'; j++ {
+				if expr[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(expr) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, expr[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(expr) && (expr[j] == '_' || unicode.IsLetter(rune(expr[j])) || unicode.IsDigit(rune(expr[j]))); j++ {
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEvaluateWhen(t *testing.T) {
+	env := map[string]string{
+		"CLUSTER_TYPE": "aws",
+		"FIPS_ENABLED": "true",
+		"DISABLED":     "false",
+		"EMPTY":        "",
+		"VALUE":        "some value",
+	}
+	lookup := func(name string) string {
+		return env[name]
+	}
+	for _, tc := range []struct {
+		name        string
+		expr        string
+		expected    bool
+		expectedErr string
+	}{{
+		name:     "comparison",
+		expr:     `CLUSTER_TYPE == "aws"`,
+		expected: true,
+	}, {
+		name: "negated comparison",
+		expr: `CLUSTER_TYPE != "aws"`,
+	}, {
+		name:     "boolean variable",
+		expr:     "FIPS_ENABLED",
+		expected: true,
+	}, {
+		name: "false boolean variable",
+		expr: "DISABLED",
+	}, {
+		name: "empty variable",
+		expr: "EMPTY",
+	}, {
+		name:     "non-boolean variable",
+		expr:     "VALUE",
+		expected: true,
+	}, {
+		name:     "operators and parentheses",
+		expr:     `!DISABLED && (CLUSTER_TYPE == "gcp" || FIPS_ENABLED)`,
+		expected: true,
+	}, {
+		name: "unset variable",
+		expr: "UNSET",
+	}, {
+		name:     "unset variable compared to an empty string",
+		expr:     `UNSET == ""`,
+		expected: true,
+	}, {
+		name:        "empty expression",
+		expr:        " ",
+		expectedErr: `invalid condition " ": empty expression`,
+	}, {
+		name:        "missing parenthesis",
+		expr:        "(FIPS_ENABLED",
+		expectedErr: `invalid condition "(FIPS_ENABLED": missing closing parenthesis`,
+	}, {
+		name:        "unquoted value",
+		expr:        "CLUSTER_TYPE == aws",
+		expectedErr: `invalid condition "CLUSTER_TYPE == aws": expected a quoted string after "==", got "aws"`,
+	}, {
+		name:        "trailing tokens",
+		expr:        "FIPS_ENABLED CLUSTER_TYPE",
+		expectedErr: `invalid condition "FIPS_ENABLED CLUSTER_TYPE": unexpected "CLUSTER_TYPE"`,
+	}, {
+		name:        "unterminated string",
+		expr:        `CLUSTER_TYPE == "aws`,
+		expectedErr: `invalid condition "CLUSTER_TYPE == \"aws": unterminated string`,
+	}, {
+		name:        "invalid character",
+		expr:        "FIPS_ENABLED & DISABLED",
+		expectedErr: `invalid condition "FIPS_ENABLED & DISABLED": unexpected character '&'`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := EvaluateWhen(tc.expr, lookup)
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, actualErr); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWhenVariables(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		expr        string
+		expected    []string
+		expectedErr string
+	}{{
+		name:     "single variable",
+		expr:     "FIPS_ENABLED",
+		expected: []string{"FIPS_ENABLED"},
+	}, {
+		name:     "variables are listed once in order of appearance",
+		expr:     `!DISABLED && (CLUSTER_TYPE == "gcp" || CLUSTER_TYPE == "aws" || FIPS_ENABLED)`,
+		expected: []string{"DISABLED", "CLUSTER_TYPE", "FIPS_ENABLED"},
+	}, {
+		name:        "invalid expression",
+		expr:        "(FIPS_ENABLED",
+		expectedErr: `invalid condition "(FIPS_ENABLED": missing closing parenthesis`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := WhenVariables(tc.expr)
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, actualErr); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected variables: %s", diff)
+			}
+		})
+	}
+}
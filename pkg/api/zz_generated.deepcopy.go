@@ -721,6 +721,11 @@ func (in *LiteralTestStep) DeepCopyInto(out *LiteralTestStep) {
 		*out = new(NodeArchitecture)
 		**out = **in
 	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(StepRetry)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LiteralTestStep.
@@ -842,6 +847,11 @@ func (in *MultiStageTestConfiguration) DeepCopyInto(out *MultiStageTestConfigura
 		*out = new(bool)
 		**out = **in
 	}
+	if in.CachePre != nil {
+		in, out := &in.CachePre, &out.CachePre
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Observers != nil {
 		in, out := &in.Observers, &out.Observers
 		*out = new(Observers)
@@ -936,6 +946,11 @@ func (in *MultiStageTestConfigurationLiteral) DeepCopyInto(out *MultiStageTestCo
 		*out = new(bool)
 		**out = **in
 	}
+	if in.CachePre != nil {
+		in, out := &in.CachePre, &out.CachePre
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Observers != nil {
 		in, out := &in.Observers, &out.Observers
 		*out = make([]Observer, len(*in))
@@ -1295,6 +1310,36 @@ func (in *OutputImageTagStepConfiguration) DeepCopy() *OutputImageTagStepConfigu
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParallelTestStep) DeepCopyInto(out *ParallelTestStep) {
+	*out = *in
+	if in.LiteralTestStep != nil {
+		in, out := &in.LiteralTestStep, &out.LiteralTestStep
+		*out = new(LiteralTestStep)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Reference != nil {
+		in, out := &in.Reference, &out.Reference
+		*out = new(string)
+		**out = **in
+	}
+	if in.Chain != nil {
+		in, out := &in.Chain, &out.Chain
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParallelTestStep.
+func (in *ParallelTestStep) DeepCopy() *ParallelTestStep {
+	if in == nil {
+		return nil
+	}
+	out := new(ParallelTestStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PipelineImageCacheStepConfiguration) DeepCopyInto(out *PipelineImageCacheStepConfiguration) {
 	*out = *in
@@ -2083,6 +2128,36 @@ func (in *StepParameter) DeepCopy() *StepParameter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepRetry) DeepCopyInto(out *StepRetry) {
+	*out = *in
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExitCodes != nil {
+		in, out := &in.ExitCodes, &out.ExitCodes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepRetry.
+func (in *StepRetry) DeepCopy() *StepRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(StepRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in TestDependencies) DeepCopyInto(out *TestDependencies) {
 	{
@@ -2143,6 +2218,13 @@ func (in *TestStep) DeepCopyInto(out *TestStep) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Parallel != nil {
+		in, out := &in.Parallel, &out.Parallel
+		*out = make([]ParallelTestStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestStep.
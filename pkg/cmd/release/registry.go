@@ -181,7 +181,10 @@ func printTreeLevel(level uint, format string, args ...any) {
 
 func printTreeSteps(o *options, steps []api.TestStep, level uint) {
 	for _, s := range steps {
-		if s.Chain != nil {
+		if s.Parallel != nil {
+			printTreeLevel(level, "parallel:\n")
+			printTreeSteps(o, s.ParallelSteps(), level+1)
+		} else if s.Chain != nil {
 			printTreeChain(o, *s.Chain, level)
 		} else if s.Reference != nil {
 			printTreeStep(*s.Reference, level)
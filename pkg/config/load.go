@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -42,6 +43,42 @@ type Prowgen struct {
 	// EnableSecretsStoreCSIDriver indicates that jobs should use the new CSI Secrets Store
 	// mechanism to handle multi-stage credentials secrets.
 	EnableSecretsStoreCSIDriver bool `json:"enable_secrets_store_csi_driver,omitempty"`
+	// Sharding determines the number of shards for tests that do not set
+	// shard_count explicitly, based on how long they take to run.
+	Sharding *Sharding `json:"sharding,omitempty"`
+}
+
+// Sharding splits long-running tests into shards that each finish in about
+// the target wall-clock duration.
+type Sharding struct {
+	// TargetDuration is how long each shard of a test should take to run.
+	TargetDuration prowv1.Duration `json:"target_duration"`
+	// MaxShardCount limits the number of shards a test is split into.
+	MaxShardCount int `json:"max_shard_count,omitempty"`
+	// Durations holds how long each test takes to run without sharding, keyed
+	// by the name of the test. `junit-shard durations` records them from the
+	// jUnit results of previous runs.
+	Durations map[string]*prowv1.Duration `json:"durations,omitempty"`
+}
+
+// ShardCountFor determines the number of shards the test needs to finish in
+// the target duration. It returns false if the test does not need sharding.
+func (s *Sharding) ShardCountFor(test string) (int, bool) {
+	if s == nil || s.TargetDuration.Duration <= 0 {
+		return 0, false
+	}
+	duration := s.Durations[test]
+	if duration == nil {
+		return 0, false
+	}
+	count := int((duration.Duration + s.TargetDuration.Duration - 1) / s.TargetDuration.Duration)
+	if s.MaxShardCount > 0 && count > s.MaxShardCount {
+		count = s.MaxShardCount
+	}
+	if count <= 1 {
+		return 0, false
+	}
+	return count, true
 }
 
 // SlackReporterConfig groups test names to a channel to report; mimicking Prow's version, with some unnecessary fields removed
@@ -73,6 +110,9 @@ func (p *Prowgen) MergeDefaults(defaults *Prowgen) {
 	if defaults.EnableSecretsStoreCSIDriver {
 		p.EnableSecretsStoreCSIDriver = true
 	}
+	if p.Sharding == nil && defaults.Sharding != nil {
+		p.Sharding = defaults.Sharding
+	}
 	if defaults.Rehearsals.DisableAll {
 		p.Rehearsals.DisableAll = true
 	}
@@ -116,6 +156,14 @@ func validateProwgenConfig(pConfig *Prowgen) error {
 			}
 		}
 	}
+	if sharding := pConfig.Sharding; sharding != nil {
+		if sharding.TargetDuration.Duration <= 0 {
+			errs = append(errs, errors.New("sharding.target_duration must be positive"))
+		}
+		if sharding.MaxShardCount == 1 || sharding.MaxShardCount < 0 {
+			errs = append(errs, errors.New("sharding.max_shard_count must be greater than 1 if provided"))
+		}
+	}
 	return utilerrors.NewAggregate(errs)
 }
 
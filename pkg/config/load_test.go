@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -141,6 +142,24 @@ func TestValidateProwgenConfig(t *testing.T) {
 			},
 			expected: errors.New("job: unit exists in multiple slack_reporter_configs, it should only be in one"),
 		},
+		{
+			name: "valid sharding",
+			pConfig: &Prowgen{
+				Sharding: &Sharding{TargetDuration: prowv1.Duration{Duration: time.Hour}, MaxShardCount: 5},
+			},
+		},
+		{
+			name:     "invalid, sharding without a target duration",
+			pConfig:  &Prowgen{Sharding: &Sharding{}},
+			expected: errors.New("sharding.target_duration must be positive"),
+		},
+		{
+			name: "invalid, sharding into a single shard",
+			pConfig: &Prowgen{
+				Sharding: &Sharding{TargetDuration: prowv1.Duration{Duration: time.Hour}, MaxShardCount: 1},
+			},
+			expected: errors.New("sharding.max_shard_count must be greater than 1 if provided"),
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -273,3 +292,63 @@ func TestProwgen_GetSlackReporterConfigForTest(t *testing.T) {
 		})
 	}
 }
+
+func TestSharding_ShardCountFor(t *testing.T) {
+	durations := map[string]*prowv1.Duration{
+		"unit":  {Duration: 20 * time.Minute},
+		"e2e":   {Duration: 2*time.Hour + time.Minute},
+		"exact": {Duration: 2 * time.Hour},
+		"long":  {Duration: 10 * time.Hour},
+	}
+	testCases := []struct {
+		name          string
+		sharding      *Sharding
+		test          string
+		expected      int
+		expectedShard bool
+	}{
+		{
+			name: "no sharding configured",
+			test: "e2e",
+		},
+		{
+			name:     "test without a known duration",
+			sharding: &Sharding{TargetDuration: prowv1.Duration{Duration: time.Hour}, Durations: durations},
+			test:     "unknown",
+		},
+		{
+			name:     "test shorter than the target",
+			sharding: &Sharding{TargetDuration: prowv1.Duration{Duration: time.Hour}, Durations: durations},
+			test:     "unit",
+		},
+		{
+			name:          "test longer than the target is rounded up",
+			sharding:      &Sharding{TargetDuration: prowv1.Duration{Duration: time.Hour}, Durations: durations},
+			test:          "e2e",
+			expected:      3,
+			expectedShard: true,
+		},
+		{
+			name:          "test that is a multiple of the target",
+			sharding:      &Sharding{TargetDuration: prowv1.Duration{Duration: time.Hour}, Durations: durations},
+			test:          "exact",
+			expected:      2,
+			expectedShard: true,
+		},
+		{
+			name:          "shard count is limited",
+			sharding:      &Sharding{TargetDuration: prowv1.Duration{Duration: time.Hour}, MaxShardCount: 4, Durations: durations},
+			test:          "long",
+			expected:      4,
+			expectedShard: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			count, shard := tc.sharding.ShardCountFor(tc.test)
+			if count != tc.expected || shard != tc.expectedShard {
+				t.Errorf("expected %d, %t, got %d, %t", tc.expected, tc.expectedShard, count, shard)
+			}
+		})
+	}
+}
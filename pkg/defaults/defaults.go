@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"k8s.io/client-go/rest"
 	utilpointer "k8s.io/utils/pointer"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
 	"sigs.k8s.io/prow/pkg/pod-utils/decorate"
 	"sigs.k8s.io/yaml"
@@ -118,6 +120,41 @@ func FromConfig(
 	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, buildClient, templateClient, podClient, leaseClient, hiveClient, httpClient.StandardClient(), requiredTargets, cloneAuthConfig, pullSecret, pushSecret, api.NewDeferredParameters(nil), censor, nodeName, targetAdditionalSuffix, nodeArchitectures, integratedStreams, injectedTest, enableSecretsStoreCSIDriver)
 }
 
+// FromConfigForDryRun generates the final execution graph like FromConfig, but
+// without access to a cluster or the network. The steps use a client that knows
+// no objects and releases are never resolved, so the steps can only be used to
+// render what they would create.
+func FromConfigForDryRun(
+	ctx context.Context,
+	config *api.ReleaseBuildConfiguration,
+	graphConf *api.GraphConfiguration,
+	jobSpec *api.JobSpec,
+	templates []*templateapi.Template,
+	paramFile string,
+	promote bool,
+	requiredTargets []string,
+	cloneAuthConfig *steps.CloneAuthConfig,
+	pullSecret, pushSecret *coreapi.Secret,
+	censor *secrets.DynamicCensor,
+	nodeName string,
+	targetAdditionalSuffix string,
+	injectedTest bool,
+	enableSecretsStoreCSIDriver bool,
+) ([]api.Step, []api.Step, error) {
+	client := loggingclient.New(fakectrlruntimeclient.NewClientBuilder().Build())
+	buildClient := steps.NewBuildClient(client, nil, nil, "", "")
+	templateClient := steps.NewTemplateClient(client, nil)
+	podClient := kubernetes.NewPodClient(client, nil, nil, 0)
+
+	return fromConfig(ctx, config, graphConf, jobSpec, templates, paramFile, promote, client, buildClient, templateClient, podClient, nil, nil, dryRunHTTPClient, requiredTargets, cloneAuthConfig, pullSecret, pushSecret, api.NewDeferredParameters(nil), censor, nodeName, targetAdditionalSuffix, nil, map[string]*configresolver.IntegratedStream{}, injectedTest, enableSecretsStoreCSIDriver)
+}
+
+// dryRunHTTPClient refuses all requests, so that releases are not resolved
+// over the network in a dry run.
+var dryRunHTTPClient = release.NewFakeHTTPClient(func(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("refusing to request %s in a dry run", req.URL)
+})
+
 func fromConfig(
 	ctx context.Context,
 	config *api.ReleaseBuildConfiguration,
@@ -432,8 +469,10 @@ func stepForTest(
 	enableSecretsStoreCSIDriver bool,
 ) ([]api.Step, error) {
 	if test := c.MultiStageTestConfigurationLiteral; test != nil {
-		leases := api.LeasesForTest(test)
+		priority := api.LeasePriorityForTest(jobSpec, c)
+		leases := api.PrioritizeLeases(api.LeasesForTest(test), priority)
 		ipPoolLease := api.IPPoolLeaseForTest(test, config.Metadata)
+		ipPoolLease.Priority = priority
 		if len(leases) != 0 || ipPoolLease.ResourceType != "" {
 			params = api.NewDeferredParameters(params)
 		}
@@ -470,6 +509,7 @@ func stepForTest(
 			ResourceType: test.ClusterProfile.LeaseType(),
 			Env:          api.DefaultLeaseEnv,
 			Count:        1,
+			Priority:     api.LeasePriorityForTest(jobSpec, c),
 		}}, step, jobSpec.Namespace)
 		addProvidesForStep(step, params)
 		return []api.Step{step}, nil
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -1878,6 +1879,33 @@ func TestFromConfig(t *testing.T) {
 	}
 }
 
+func TestFromConfigForDryRunDoesNotResolveReleases(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		InputConfiguration: api.InputConfiguration{
+			Releases: map[string]api.UnresolvedRelease{
+				"release": {Release: &api.Release{Version: "4.1.0"}},
+			},
+		},
+	}
+	jobSpec := api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "job_name"}}
+	jobSpec.SetNamespace("ns")
+	graphConf := FromConfigStatic(&config)
+	configSteps, _, err := FromConfigForDryRun(context.Background(), &config, &graphConf, &jobSpec, nil, "", false, nil, nil, nil, nil, &secrets.DynamicCensor{}, "", "", false, false)
+	if err != nil {
+		t.Fatalf("failed to generate steps: %v", err)
+	}
+	for _, step := range configSteps {
+		if step.Name() != "[release:release]" {
+			continue
+		}
+		if _, err := step.Inputs(); err == nil || !strings.Contains(err.Error(), "in a dry run") {
+			t.Errorf("expected the release not to be resolved in a dry run, got error: %v", err)
+		}
+		return
+	}
+	t.Error("expected a step importing the release")
+}
+
 func TestRegistryDomain(t *testing.T) {
 	var testCases = []struct {
 		name     string
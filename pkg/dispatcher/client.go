@@ -16,10 +16,23 @@ type Client interface {
 	ClusterForJob(jobName string) (string, error)
 }
 
+// APIClient uses the versioned API of the dispatcher, which explains the placement of jobs
+type APIClient interface {
+	Client
+	// ClustersForJobs returns the placements of the jobs in the order they are given
+	ClustersForJobs(jobNames []string) ([]JobPlacement, error)
+	// Clusters lists the clusters known to the dispatcher
+	Clusters() ([]ClusterStatus, error)
+}
+
 func NewClient(address string) Client {
 	return client{Address: address}
 }
 
+func NewAPIClient(address string) APIClient {
+	return client{Address: address}
+}
+
 type client struct {
 	Address string
 }
@@ -46,6 +59,47 @@ func (c client) ClusterForJob(jobName string) (string, error) {
 	return schedulingResponse.Cluster, nil
 }
 
+func (c client) ClustersForJobs(jobNames []string) ([]JobPlacement, error) {
+	body, err := json.Marshal(BatchSchedulingRequest{Jobs: jobNames})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal scheduling request: %w", err)
+	}
+	req, err := http.NewRequest("POST", c.url(SchedulePath), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	response, err := doRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("error performing request: %w", err)
+	}
+	schedulingResponse := BatchSchedulingResponse{}
+	if err = json.Unmarshal(response, &schedulingResponse); err != nil {
+		return nil, fmt.Errorf("could not parse scheduling response: %w", err)
+	}
+	return schedulingResponse.Jobs, nil
+}
+
+func (c client) Clusters() ([]ClusterStatus, error) {
+	req, err := http.NewRequest("GET", c.url(ClustersPath), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	response, err := doRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("error performing request: %w", err)
+	}
+	clustersResponse := ClustersResponse{}
+	if err = json.Unmarshal(response, &clustersResponse); err != nil {
+		return nil, fmt.Errorf("could not parse clusters response: %w", err)
+	}
+	return clustersResponse.Clusters, nil
+}
+
+func (c client) url(path string) string {
+	return strings.TrimSuffix(c.Address, "/") + path
+}
+
 type adapter struct{}
 
 func (a adapter) format(s string, i ...interface{}) string {
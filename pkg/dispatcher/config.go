@@ -18,11 +18,14 @@ import (
 	"github.com/openshift/ci-tools/pkg/util/gzip"
 )
 
-// ClusterInfo holds the provider, capacity, and capabilities.
+// ClusterInfo holds the provider, capacity, capabilities and the cost weight.
 type ClusterInfo struct {
 	Provider     string
 	Capacity     int
 	Capabilities []string
+	// CostWeight is the relative cost of running jobs on the cluster, used by the
+	// score-based placement. Zero means the default weight of 1.
+	CostWeight float64
 }
 
 // ClusterMap maps a cluster name to its corresponding ClusterInfo.
@@ -49,6 +52,9 @@ type Config struct {
 	BuildFarm map[api.Cloud]map[api.Cluster]*BuildFarmConfig `json:"buildFarm,omitempty"`
 	// BuildFarmCloud maps sets of clusters to a cloud provider, like GCP
 	BuildFarmCloud map[api.Cloud][]string `json:"-"`
+	// Scoring enables the score-based placement of the jobs in the build farm
+	// which weighs the health and the cost of the clusters besides their volume
+	Scoring *ScoringWeights `json:"scoring,omitempty"`
 }
 
 type BuildFarmConfig struct {
@@ -69,6 +75,27 @@ type Group struct {
 	PathREs []*regexp.Regexp `json:"-"`
 }
 
+// Reason names the rule which placed a job on its cluster
+type Reason string
+
+const (
+	ReasonVSphere      Reason = "vsphere"
+	ReasonSSHBastion   Reason = "ssh-bastion"
+	ReasonKVM          Reason = "kvm"
+	ReasonClusterLabel Reason = "cluster-label"
+	ReasonCapability   Reason = "capability"
+	ReasonCloudMapping Reason = "cloud-mapping"
+	ReasonNoBuilds     Reason = "no-builds"
+	ReasonGroup        Reason = "group"
+	ReasonPathRegex    Reason = "path-regex"
+	ReasonBuildFarm    Reason = "build-farm"
+	ReasonDefault      Reason = "default"
+	// ReasonRelocation is used when the dispatcher moved the job off the cluster the rules determined
+	ReasonRelocation Reason = "relocation"
+	// ReasonBlocked is used when the job fell back to the default cluster because its cluster is blocked
+	ReasonBlocked Reason = "blocked"
+)
+
 // GetClusterForJob returns a cluster for a prow job
 func (config *Config) GetClusterForJob(jobBase prowconfig.JobBase, path string, cm ClusterMap) (api.Cluster, error) {
 	cluster, _, err := config.DetermineClusterForJob(jobBase, path, cm)
@@ -144,24 +171,30 @@ func matchesAllCapabilities(clusterCapabilities, requiredCapabilities []string)
 
 // DetermineClusterForJob return the cluster for a prow job and if it can be relocated to a cluster in build farm
 func (config *Config) DetermineClusterForJob(jobBase prowconfig.JobBase, path string, cm ClusterMap) (clusterName api.Cluster, mayBeRelocated bool, _ error) {
+	clusterName, _, mayBeRelocated, err := config.DetermineClusterAndReasonForJob(jobBase, path, cm)
+	return clusterName, mayBeRelocated, err
+}
+
+// DetermineClusterAndReasonForJob return the cluster for a prow job, the rule which determined it and if it can be relocated to a cluster in build farm
+func (config *Config) DetermineClusterAndReasonForJob(jobBase prowconfig.JobBase, path string, cm ClusterMap) (clusterName api.Cluster, reason Reason, mayBeRelocated bool, _ error) {
 	if jobBase.Agent != "kubernetes" && jobBase.Agent != "" {
-		return "", false, nil
+		return "", "", false, nil
 	}
 	if strings.Contains(jobBase.Name, "vsphere") && !isApplyConfigJob(jobBase) {
-		return api.ClusterVSphere02, false, nil
+		return api.ClusterVSphere02, ReasonVSphere, false, nil
 	}
 	if isSSHBastionJob(jobBase) && config.SSHBastion != "" {
-		return config.SSHBastion, false, nil
+		return config.SSHBastion, ReasonSSHBastion, false, nil
 	}
 	if jobBase.Labels != nil {
 		if _, ok := jobBase.Labels[api.KVMDeviceLabel]; ok && len(config.KVM) > 0 {
 			// Any deterministic distribution is fine for now.
 			// We could implement more effective distribution when we understand more about the jobs.
-			return config.KVM[len(filepath.Base(path))%len(config.KVM)], false, nil
+			return config.KVM[len(filepath.Base(path))%len(config.KVM)], ReasonKVM, false, nil
 		}
 
 		if cluster, ok := jobBase.Labels[api.ClusterLabel]; ok {
-			return api.Cluster(cluster), false, nil
+			return api.Cluster(cluster), ReasonClusterLabel, false, nil
 		}
 
 		requiredCapabilities := extractRequiredCapabilities(jobBase.Labels)
@@ -185,17 +218,17 @@ func (config *Config) DetermineClusterForJob(jobBase prowconfig.JobBase, path st
 						sort.Strings(clusters)
 						if len(clusters) > 0 {
 							// as in other places in this file, use this method to have basic deterministic distribution
-							return api.Cluster(clusters[len(filepath.Base(path))%len(clusters)]), false, nil
+							return api.Cluster(clusters[len(filepath.Base(path))%len(clusters)]), ReasonCapability, false, nil
 						}
 					}
 				}
 			}
 			if len(matchingClusters) == 0 {
 				sort.Strings(requiredCapabilities)
-				return "", false, fmt.Errorf("job %s can't be matched with any cluster using provided capabilities: %s", jobBase.Name, strings.Join(requiredCapabilities, ","))
+				return "", "", false, fmt.Errorf("job %s can't be matched with any cluster using provided capabilities: %s", jobBase.Name, strings.Join(requiredCapabilities, ","))
 			}
 			// as in other places in this file, use this method to have basic deterministic distribution
-			return api.Cluster(matchingClusters[len(filepath.Base(path))%len(matchingClusters)]), false, nil
+			return api.Cluster(matchingClusters[len(filepath.Base(path))%len(matchingClusters)]), ReasonCapability, false, nil
 
 		}
 	}
@@ -204,7 +237,7 @@ func (config *Config) DetermineClusterForJob(jobBase prowconfig.JobBase, path st
 		if cloud := config.DetermineCloudMapping(jobBase); cloud != "" {
 			if clusters, ok := config.BuildFarmCloud[api.Cloud(cloud)]; ok {
 				if len(clusters) > 0 {
-					return api.Cluster(clusters[len(filepath.Base(path))%len(clusters)]), false, nil
+					return api.Cluster(clusters[len(filepath.Base(path))%len(clusters)]), ReasonCloudMapping, false, nil
 				}
 			}
 		}
@@ -213,7 +246,7 @@ func (config *Config) DetermineClusterForJob(jobBase prowconfig.JobBase, path st
 	if jobBase.Labels != nil {
 		if _, ok := jobBase.Labels[api.NoBuildsLabel]; ok && len(config.NoBuilds) > 0 {
 			// Any deterministic distribution is fine for now.
-			return config.NoBuilds[len(filepath.Base(path))%len(config.NoBuilds)], false, nil
+			return config.NoBuilds[len(filepath.Base(path))%len(config.NoBuilds)], ReasonNoBuilds, false, nil
 		}
 	}
 
@@ -222,6 +255,7 @@ func (config *Config) DetermineClusterForJob(jobBase prowconfig.JobBase, path st
 		for _, job := range group.Jobs {
 			if jobBase.Name == job {
 				clusterName = cluster
+				reason = ReasonGroup
 			}
 		}
 	}
@@ -230,6 +264,7 @@ func (config *Config) DetermineClusterForJob(jobBase prowconfig.JobBase, path st
 			if re.MatchString(path) {
 				if clusterName == "" {
 					clusterName = cluster
+					reason = ReasonPathRegex
 				}
 				matches = append(matches, re.String())
 			}
@@ -241,6 +276,7 @@ func (config *Config) DetermineClusterForJob(jobBase prowconfig.JobBase, path st
 			if filenames.Filenames.Has(filename) {
 				if clusterName == "" {
 					clusterName = cluster
+					reason = ReasonBuildFarm
 					mayBeRelocated = true
 				}
 				matches = append(matches, filename)
@@ -250,14 +286,15 @@ func (config *Config) DetermineClusterForJob(jobBase prowconfig.JobBase, path st
 	// sort for tests
 	sort.Strings(matches)
 	if len(matches) > 1 {
-		return "", false, fmt.Errorf("path %s matches more than 1 regex: %s", path, matches)
+		return "", "", false, fmt.Errorf("path %s matches more than 1 regex: %s", path, matches)
 	}
 
 	if clusterName == "" {
 		clusterName = config.Default
+		reason = ReasonDefault
 		mayBeRelocated = true
 	}
-	return clusterName, mayBeRelocated, nil
+	return clusterName, reason, mayBeRelocated, nil
 }
 
 func isSSHBastionJob(base prowconfig.JobBase) bool {
@@ -356,6 +393,11 @@ func (config *Config) Validate() error {
 	if config.Default == "" {
 		return fmt.Errorf("the default cluster must be set in the config")
 	}
+	if config.Scoring != nil {
+		if err := config.Scoring.Validate(); err != nil {
+			return err
+		}
+	}
 	records := map[string]int{}
 	for _, group := range config.Groups {
 		for _, job := range group.Jobs {
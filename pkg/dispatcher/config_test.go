@@ -584,6 +584,73 @@ func TestDetermineClusterForJob(t *testing.T) {
 	}
 }
 
+func TestDetermineClusterAndReasonForJob(t *testing.T) {
+	testCases := []struct {
+		name           string
+		jobBase        prowconfig.JobBase
+		path           string
+		cm             ClusterMap
+		expected       api.Cluster
+		expectedReason Reason
+	}{
+		{
+			name:           "group by job name",
+			jobBase:        config.JobBase{Agent: "kubernetes", Name: "periodic-build01-upgrade"},
+			expected:       "build01",
+			expectedReason: ReasonGroup,
+		},
+		{
+			name:           "group by path regex",
+			jobBase:        config.JobBase{Agent: "kubernetes", Name: "some-job"},
+			path:           "org/repo/some-postsubmits.yaml",
+			expected:       "api.ci",
+			expectedReason: ReasonPathRegex,
+		},
+		{
+			name:           "build farm file",
+			jobBase:        config.JobBase{Agent: "kubernetes", Name: "some-build-farm-job"},
+			path:           "org/repo/some-build-farm-presubmits.yaml",
+			expected:       "build01",
+			expectedReason: ReasonBuildFarm,
+		},
+		{
+			name:           "capability",
+			jobBase:        config.JobBase{Agent: "kubernetes", Name: "some-job", Labels: map[string]string{"capability/intranet": "intranet"}},
+			path:           "org/repo/some-presubmits.yaml",
+			cm:             ClusterMap{"build03": ClusterInfo{Provider: "aws", Capacity: 100, Capabilities: []string{"intranet"}}},
+			expected:       "build03",
+			expectedReason: ReasonCapability,
+		},
+		{
+			name:           "cluster label",
+			jobBase:        config.JobBase{Agent: "kubernetes", Name: "some-job", Labels: map[string]string{api.ClusterLabel: "build10"}},
+			expected:       "build10",
+			expectedReason: ReasonClusterLabel,
+		},
+		{
+			name:           "default",
+			jobBase:        config.JobBase{Agent: "kubernetes", Name: "some-job"},
+			path:           "org/repo/some-presubmits.yaml",
+			expected:       "api.ci",
+			expectedReason: ReasonDefault,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, reason, _, err := configWithBuildFarmWithJobs.DetermineClusterAndReasonForJob(tc.jobBase, tc.path, tc.cm)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.name, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.expectedReason, reason); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
+
 func TestIsInBuildFarm(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -15,6 +15,7 @@ func loadClusterConfigFromBytes(data []byte) (ClusterMap, sets.Set[string], erro
 		Capacity     int      `yaml:"capacity"`
 		Capabilities []string `yaml:"capabilities"`
 		Blocked      bool     `yaml:"blocked"`
+		CostWeight   float64  `yaml:"costWeight"`
 	}
 	if err := yaml.Unmarshal(data, &clusters); err != nil {
 		return nil, nil, err
@@ -37,6 +38,7 @@ func loadClusterConfigFromBytes(data []byte) (ClusterMap, sets.Set[string], erro
 				Provider:     provider,
 				Capacity:     cluster.Capacity,
 				Capabilities: cluster.Capabilities,
+				CostWeight:   cluster.CostWeight,
 			}
 		}
 	}
@@ -100,6 +102,20 @@ func DetermineTargetCluster(cluster, determinedCluster, defaultCluster string, c
 	return targetCluster
 }
 
+// DetermineTargetClusterWithReason returns the target cluster as DetermineTargetCluster does, and the reason for it
+// given the reason the determined cluster was chosen for
+func DetermineTargetClusterWithReason(cluster, determinedCluster, defaultCluster string, canBeRelocated bool, blocked sets.Set[string], reason Reason) (string, Reason) {
+	target := DetermineTargetCluster(cluster, determinedCluster, defaultCluster, canBeRelocated, blocked)
+	switch {
+	case target == determinedCluster:
+		return target, reason
+	case target == defaultCluster && (blocked.Has(determinedCluster) || blocked.Has(cluster)):
+		return target, ReasonBlocked
+	default:
+		return target, ReasonRelocation
+	}
+}
+
 func HasCapacityOrCapabilitiesChanged(prev, next ClusterMap) bool {
 	for clusterName, info1 := range prev {
 		info2, exists := next[clusterName]
@@ -100,10 +100,11 @@ func TestDetermineTargetCluster(t *testing.T) {
 		canBeRelocated    bool
 	}
 	tests := []struct {
-		name   string
-		fields fields
-		args   args
-		want   string
+		name       string
+		fields     fields
+		args       args
+		want       string
+		wantReason Reason
 	}{
 		{
 			name: "relocate to cluster for a test group",
@@ -116,7 +117,8 @@ func TestDetermineTargetCluster(t *testing.T) {
 				defaultCluster:    "build03",
 				canBeRelocated:    true,
 			},
-			want: "build01",
+			want:       "build01",
+			wantReason: ReasonRelocation,
 		},
 		{
 			name: "can't relocate to cluster for a test group",
@@ -129,7 +131,8 @@ func TestDetermineTargetCluster(t *testing.T) {
 				defaultCluster:    "build03",
 				canBeRelocated:    false,
 			},
-			want: "build02",
+			want:       "build02",
+			wantReason: ReasonGroup,
 		},
 		{
 			name: "both clusters are blocked, relocate to default",
@@ -142,7 +145,8 @@ func TestDetermineTargetCluster(t *testing.T) {
 				defaultCluster:    "build03",
 				canBeRelocated:    false,
 			},
-			want: "build03",
+			want:       "build03",
+			wantReason: ReasonBlocked,
 		},
 		{
 			name: "determined is blocked, relocate to a group cluster despite canBeRelocated=false",
@@ -155,7 +159,8 @@ func TestDetermineTargetCluster(t *testing.T) {
 				defaultCluster:    "build03",
 				canBeRelocated:    false,
 			},
-			want: "build01",
+			want:       "build01",
+			wantReason: ReasonRelocation,
 		},
 		{
 			name: "group cluster is blocked, use determined cluster",
@@ -168,7 +173,8 @@ func TestDetermineTargetCluster(t *testing.T) {
 				defaultCluster:    "build03",
 				canBeRelocated:    false,
 			},
-			want: "build02",
+			want:       "build02",
+			wantReason: ReasonGroup,
 		},
 	}
 	for _, tt := range tests {
@@ -176,6 +182,10 @@ func TestDetermineTargetCluster(t *testing.T) {
 			if got := DetermineTargetCluster(tt.args.cluster, tt.args.determinedCluster, tt.args.defaultCluster, tt.args.canBeRelocated, tt.fields.blocked); got != tt.want {
 				t.Errorf("clusterVolume.determineCluster() = %v, want %v", got, tt.want)
 			}
+			got, reason := DetermineTargetClusterWithReason(tt.args.cluster, tt.args.determinedCluster, tt.args.defaultCluster, tt.args.canBeRelocated, tt.fields.blocked, ReasonGroup)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("DetermineTargetClusterWithReason() = %v, %v, want %v, %v", got, reason, tt.want, tt.wantReason)
+			}
 		})
 	}
 }
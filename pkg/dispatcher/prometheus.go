@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
@@ -92,6 +93,60 @@ func GetJobVolumesFromPrometheus(ctx context.Context, prometheusAPI PrometheusAP
 	return jobVolumes, nil
 }
 
+const (
+	queueLatencyQuery = `avg(kube_pod_start_time{namespace=~"ci-op-.*"} - kube_pod_created{namespace=~"ci-op-.*"}) by (cluster)`
+	failureRateQuery  = `sum(kube_pod_status_phase{namespace=~"ci-op-.*",phase="Failed"}) by (cluster) / sum(kube_pod_status_phase{namespace=~"ci-op-.*",phase=~"Succeeded|Failed"}) by (cluster)`
+)
+
+// GetClusterHealthFromPrometheus gets the queue latency and the pod failure rate of the clusters from a Prometheus server for the given time
+func GetClusterHealthFromPrometheus(ctx context.Context, prometheusAPI PrometheusAPI, ts time.Time) (map[string]ClusterHealth, error) {
+	latencies, err := queryVectorByLabel(ctx, prometheusAPI, queueLatencyQuery, "cluster", ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queue latencies: %w", err)
+	}
+	failureRates, err := queryVectorByLabel(ctx, prometheusAPI, failureRateQuery, "cluster", ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query failure rates: %w", err)
+	}
+	health := map[string]ClusterHealth{}
+	for cluster, latency := range latencies {
+		h := health[cluster]
+		h.QueueLatency = time.Duration(latency * float64(time.Second))
+		health[cluster] = h
+	}
+	for cluster, rate := range failureRates {
+		h := health[cluster]
+		h.FailureRate = rate
+		health[cluster] = h
+	}
+	return health, nil
+}
+
+func queryVectorByLabel(ctx context.Context, prometheusAPI PrometheusAPI, query, label string, ts time.Time) (map[string]float64, error) {
+	result, warnings, err := prometheusAPI.Query(ctx, query, ts)
+	if err != nil {
+		return nil, err
+	}
+	if len(warnings) > 0 {
+		logrus.WithField("Warnings", warnings).Warn("Got warnings from Prometheus")
+	}
+
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("returned result of type %T from Prometheus cannot be cast to vector", result)
+	}
+
+	values := map[string]float64{}
+	for _, v := range vector {
+		value := float64(v.Value)
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		values[string(v.Metric[model.LabelName(label)])] = value
+	}
+	return values, nil
+}
+
 // NewPrometheusClient return a Prometheus client
 func (o *PrometheusOptions) NewPrometheusClient(secretGetter func(string) []byte) (api.Client, error) {
 	roundTripper := api.DefaultRoundTripper
@@ -3,6 +3,7 @@ package dispatcher
 import (
 	"context"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -90,3 +91,57 @@ func TestGetJobVolumesFromPrometheus(t *testing.T) {
 		})
 	}
 }
+
+func TestGetClusterHealthFromPrometheus(t *testing.T) {
+	vector := func(values map[string]float64) model.Vector {
+		var vec model.Vector
+		for cluster, value := range values {
+			vec = append(vec, &model.Sample{
+				Metric: model.Metric(map[model.LabelName]model.LabelValue{model.LabelName("cluster"): model.LabelValue(cluster)}),
+				Value:  model.SampleValue(value),
+			})
+		}
+		return vec
+	}
+	testCases := []struct {
+		name          string
+		results       map[string]model.Value
+		expected      map[string]ClusterHealth
+		expectedError error
+	}{
+		{
+			name: "basic case",
+			results: map[string]model.Value{
+				queueLatencyQuery: vector(map[string]float64{"build01": 90, "build02": 30}),
+				failureRateQuery:  vector(map[string]float64{"build01": 0.25, "build03": math.NaN()}),
+			},
+			expected: map[string]ClusterHealth{
+				"build01": {QueueLatency: 90 * time.Second, FailureRate: 0.25},
+				"build02": {QueueLatency: 30 * time.Second},
+			},
+		},
+		{
+			name: "wrong type",
+			results: map[string]model.Value{
+				queueLatencyQuery: &model.Scalar{Value: model.SampleValue(1)},
+			},
+			expectedError: fmt.Errorf("failed to query queue latencies: returned result of type *model.Scalar from Prometheus cannot be cast to vector"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			supportedQueries.Insert(queueLatencyQuery, failureRateQuery)
+			defer supportedQueries.Delete(queueLatencyQuery, failureRateQuery)
+			queryFunc := func(ctx context.Context, query string, ts time.Time) (model.Value, prometheusapi.Warnings, error) {
+				return tc.results[query], nil, nil
+			}
+			actual, actualError := GetClusterHealthFromPrometheus(context.Background(), &prometheusAPIForTest{queryFunc}, time.Now())
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.expectedError, actualError, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
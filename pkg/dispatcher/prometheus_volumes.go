@@ -52,21 +52,28 @@ func (pv *prometheusVolumes) GetJobVolumes() (map[string]float64, error) {
 	return pv.jobVolumes, nil
 }
 
-func (pv *prometheusVolumes) getTotalVolume() float64 {
-	var totalVolume float64
-	for _, volume := range pv.jobVolumes {
-		totalVolume += volume
-	}
-
-	return totalVolume
+// GetClusterHealth returns the current queue latency and pod failure rate of the clusters
+func (pv *prometheusVolumes) GetClusterHealth() (map[string]ClusterHealth, error) {
+	v1api := prometheusapi.NewAPI(pv.promClient)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return GetClusterHealthFromPrometheus(ctx, v1api, time.Now())
 }
 
 func (pv *prometheusVolumes) CalculateVolumeDistribution(clusterMap ClusterMap) map[string]float64 {
+	return CalculateVolumeDistribution(clusterMap, pv.jobVolumes)
+}
+
+// CalculateVolumeDistribution shares the total volume of the jobs between the clusters by their capacity
+func CalculateVolumeDistribution(clusterMap ClusterMap, jobVolumes map[string]float64) map[string]float64 {
 	totalCapacity := 0
 	for _, cluster := range clusterMap {
 		totalCapacity += cluster.Capacity
 	}
-	totalVolume := pv.getTotalVolume()
+	var totalVolume float64
+	for _, volume := range jobVolumes {
+		totalVolume += volume
+	}
 	volumeDistribution := make(map[string]float64)
 	for clusterName, cluster := range clusterMap {
 		volumeShare := (float64(cluster.Capacity) / float64(totalCapacity)) * totalVolume
@@ -17,6 +17,8 @@ type Prowjobs struct {
 type ProwJobData struct {
 	Cluster      string
 	Capabilities []string
+	// Reason names the rule which placed the job on the cluster
+	Reason Reason
 }
 
 func NewProwjobs(jobsStoragePath string) *Prowjobs {
@@ -63,6 +65,26 @@ func (pjs *Prowjobs) GetCluster(pj string) string {
 	return ""
 }
 
+// Get returns the data for the job, if it is known
+func (pjs *Prowjobs) Get(pj string) (ProwJobData, bool) {
+	pjs.mu.Lock()
+	defer pjs.mu.Unlock()
+
+	data, exists := pjs.data[pj]
+	return data, exists
+}
+
+// CountByCluster returns the number of jobs assigned to each cluster
+func (pjs *Prowjobs) CountByCluster() map[string]int {
+	pjs.mu.Lock()
+	defer pjs.mu.Unlock()
+	counts := map[string]int{}
+	for _, data := range pjs.data {
+		counts[data.Cluster]++
+	}
+	return counts
+}
+
 func (pjs *Prowjobs) HasAnyOfClusters(clusters sets.Set[string]) bool {
 	pjs.mu.Lock()
 	defer pjs.mu.Unlock()
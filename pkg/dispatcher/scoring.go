@@ -0,0 +1,172 @@
+package dispatcher
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ScoringWeights configures how much each signal counts when the clusters in the
+// build farm are scored for a group of jobs. The cluster with the lowest score wins.
+type ScoringWeights struct {
+	// Load weighs the volume of the cluster, including the jobs to place, relative to
+	// its share of the total volume by capacity
+	Load float64 `json:"load,omitempty"`
+	// QueueLatency weighs the observed queue latency of the cluster, in minutes
+	QueueLatency float64 `json:"queueLatency,omitempty"`
+	// FailureRate weighs the observed ratio of failed pods on the cluster
+	FailureRate float64 `json:"failureRate,omitempty"`
+	// Cost weighs the cost weight of the cluster from the cluster config
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// Validate checks if the weights are valid
+func (w ScoringWeights) Validate() error {
+	for name, weight := range map[string]float64{"load": w.Load, "queueLatency": w.QueueLatency, "failureRate": w.FailureRate, "cost": w.Cost} {
+		if weight < 0 {
+			return fmt.Errorf("scoring weight %s must not be negative, got %v", name, weight)
+		}
+	}
+	if w.Load == 0 && w.QueueLatency == 0 && w.FailureRate == 0 && w.Cost == 0 {
+		return fmt.Errorf("at least one scoring weight must be set")
+	}
+	return nil
+}
+
+// ClusterHealth holds the observed health of a cluster
+type ClusterHealth struct {
+	QueueLatency time.Duration
+	FailureRate  float64
+}
+
+// ClusterScore holds the weighted components of the score of a cluster
+type ClusterScore struct {
+	Cluster      string  `json:"cluster"`
+	Load         float64 `json:"load"`
+	QueueLatency float64 `json:"queueLatency"`
+	FailureRate  float64 `json:"failureRate"`
+	Cost         float64 `json:"cost"`
+	Total        float64 `json:"total"`
+}
+
+func (s ClusterScore) String() string {
+	return fmt.Sprintf("%s (score %.2f = load %.2f + latency %.2f + failures %.2f + cost %.2f)", s.Cluster, s.Total, s.Load, s.QueueLatency, s.FailureRate, s.Cost)
+}
+
+// Placement records the scores of the candidate clusters for a group of jobs and the chosen one
+type Placement struct {
+	Chosen ClusterScore   `json:"chosen"`
+	Scores []ClusterScore `json:"scores"`
+}
+
+func (p Placement) scoreOf(cluster string) (ClusterScore, bool) {
+	for _, score := range p.Scores {
+		if score.Cluster == cluster {
+			return score, true
+		}
+	}
+	return ClusterScore{}, false
+}
+
+// explain returns why the jobs moved to the chosen cluster from the given one
+func (p Placement) explain(from string) string {
+	if from == "" {
+		return fmt.Sprintf("placed on the lowest scoring cluster %s", p.Chosen)
+	}
+	if score, ok := p.scoreOf(from); ok {
+		return fmt.Sprintf("%s scored lower than %s", p.Chosen, score)
+	}
+	return fmt.Sprintf("%s is not a candidate anymore, placed on the lowest scoring cluster %s", from, p.Chosen)
+}
+
+// Scorer scores the clusters in the build farm for groups of jobs
+type Scorer struct {
+	weights      ScoringWeights
+	clusters     ClusterMap
+	health       map[string]ClusterHealth
+	distribution map[string]float64
+}
+
+// NewScorer returns a scorer for the clusters. The distribution is the expected volume
+// of each cluster, as returned by CalculateVolumeDistribution.
+func NewScorer(weights ScoringWeights, clusters ClusterMap, health map[string]ClusterHealth, distribution map[string]float64) *Scorer {
+	return &Scorer{weights: weights, clusters: clusters, health: health, distribution: distribution}
+}
+
+// Score scores the cluster when the volume is added to its current volume
+func (s *Scorer) Score(cluster string, current, volume float64) ClusterScore {
+	load := current + volume
+	if expected := s.distribution[cluster]; expected > 0 {
+		load = load / expected
+	}
+	costWeight := s.clusters[cluster].CostWeight
+	if costWeight == 0 {
+		costWeight = 1
+	}
+	health := s.health[cluster]
+	score := ClusterScore{
+		Cluster:      cluster,
+		Load:         s.weights.Load * load,
+		QueueLatency: s.weights.QueueLatency * health.QueueLatency.Minutes(),
+		FailureRate:  s.weights.FailureRate * health.FailureRate,
+		Cost:         s.weights.Cost * costWeight,
+	}
+	score.Total = score.Load + score.QueueLatency + score.FailureRate + score.Cost
+	return score
+}
+
+// Place scores the candidate clusters with their current volumes and chooses the one
+// with the lowest score for the volume. Ties are broken by the cluster name, so the
+// placement is reproducible.
+func (s *Scorer) Place(current map[string]float64, volume float64) (Placement, bool) {
+	var candidates []string
+	for cluster := range current {
+		if _, ok := s.clusters[cluster]; ok {
+			candidates = append(candidates, cluster)
+		}
+	}
+	if len(candidates) == 0 {
+		return Placement{}, false
+	}
+	sort.Strings(candidates)
+	var placement Placement
+	for i, cluster := range candidates {
+		score := s.Score(cluster, current[cluster], volume)
+		placement.Scores = append(placement.Scores, score)
+		if i == 0 || score.Total < placement.Chosen.Total {
+			placement.Chosen = score
+		}
+	}
+	return placement, true
+}
+
+// JobMove describes a job which was assigned to another cluster
+type JobMove struct {
+	Job    string `json:"job"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// DiffAssignments returns the jobs which are assigned to another cluster in next than in
+// previous, sorted by their names. The placements explain the jobs which were scored.
+func DiffAssignments(previous, next map[string]ProwJobData, placements map[string]Placement) []JobMove {
+	var moves []JobMove
+	for job, data := range next {
+		from := previous[job].Cluster
+		if from == data.Cluster {
+			continue
+		}
+		move := JobMove{Job: job, From: from, To: data.Cluster}
+		if placement, ok := placements[job]; ok && placement.Chosen.Cluster == data.Cluster {
+			move.Reason = placement.explain(from)
+		} else if data.Reason != "" {
+			move.Reason = fmt.Sprintf("placed by the %s rule", data.Reason)
+		} else {
+			move.Reason = "determined by the rules of the dispatcher config"
+		}
+		moves = append(moves, move)
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].Job < moves[j].Job })
+	return moves
+}
@@ -0,0 +1,184 @@
+package dispatcher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestScoringWeightsValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		weights  ScoringWeights
+		expected error
+	}{
+		{
+			name:    "valid",
+			weights: ScoringWeights{Load: 1, QueueLatency: 0.1},
+		},
+		{
+			name:     "negative weight",
+			weights:  ScoringWeights{Load: 1, Cost: -1},
+			expected: fmt.Errorf("scoring weight cost must not be negative, got -1"),
+		},
+		{
+			name:     "no weights",
+			expected: fmt.Errorf("at least one scoring weight must be set"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.weights.Validate(), testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestScorerPlace(t *testing.T) {
+	clusters := ClusterMap{
+		"build01": {Provider: "aws", Capacity: 100},
+		"build02": {Provider: "gcp", Capacity: 100, CostWeight: 2},
+		"build03": {Provider: "aws", Capacity: 50},
+	}
+	distribution := map[string]float64{"build01": 40, "build02": 40, "build03": 20}
+	testCases := []struct {
+		name       string
+		weights    ScoringWeights
+		health     map[string]ClusterHealth
+		current    map[string]float64
+		volume     float64
+		expected   Placement
+		expectedOK bool
+	}{
+		{
+			name:    "load only chooses the least loaded cluster relative to its capacity",
+			weights: ScoringWeights{Load: 1},
+			current: map[string]float64{"build01": 20, "build02": 10, "build03": 4},
+			volume:  4,
+			expected: Placement{
+				Chosen: ClusterScore{Cluster: "build02", Load: 0.35, Total: 0.35},
+				Scores: []ClusterScore{
+					{Cluster: "build01", Load: 0.6, Total: 0.6},
+					{Cluster: "build02", Load: 0.35, Total: 0.35},
+					{Cluster: "build03", Load: 0.4, Total: 0.4},
+				},
+			},
+			expectedOK: true,
+		},
+		{
+			name:    "latency, failures and cost steer away from clusters",
+			weights: ScoringWeights{Load: 1, QueueLatency: 0.1, FailureRate: 1, Cost: 0.5},
+			health: map[string]ClusterHealth{
+				"build01": {QueueLatency: 10 * time.Minute},
+				"build03": {FailureRate: 0.5},
+			},
+			current: map[string]float64{"build01": 0, "build02": 10, "build03": 4},
+			volume:  4,
+			expected: Placement{
+				Chosen: ClusterScore{Cluster: "build02", Load: 0.35, Cost: 1, Total: 1.35},
+				Scores: []ClusterScore{
+					{Cluster: "build01", Load: 0.1, QueueLatency: 1, Cost: 0.5, Total: 1.6},
+					{Cluster: "build02", Load: 0.35, Cost: 1, Total: 1.35},
+					{Cluster: "build03", Load: 0.4, FailureRate: 0.5, Cost: 0.5, Total: 1.4},
+				},
+			},
+			expectedOK: true,
+		},
+		{
+			name:    "ties are broken by the name of the cluster",
+			weights: ScoringWeights{Cost: 1},
+			current: map[string]float64{"build03": 0, "build01": 0},
+			expected: Placement{
+				Chosen: ClusterScore{Cluster: "build01", Cost: 1, Total: 1},
+				Scores: []ClusterScore{
+					{Cluster: "build01", Cost: 1, Total: 1},
+					{Cluster: "build03", Cost: 1, Total: 1},
+				},
+			},
+			expectedOK: true,
+		},
+		{
+			name:    "clusters missing from the cluster map are not candidates",
+			weights: ScoringWeights{Load: 1},
+			current: map[string]float64{"build04": 0},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := NewScorer(tc.weights, clusters, tc.health, distribution).Place(tc.current, tc.volume)
+			if ok != tc.expectedOK {
+				t.Errorf("%s: expected ok %t, got %t", tc.name, tc.expectedOK, ok)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestDiffAssignments(t *testing.T) {
+	placement := Placement{
+		Chosen: ClusterScore{Cluster: "build02", Load: 0.5, Total: 0.5},
+		Scores: []ClusterScore{
+			{Cluster: "build01", Load: 0.2, FailureRate: 1, Total: 1.2},
+			{Cluster: "build02", Load: 0.5, Total: 0.5},
+		},
+	}
+	previous := map[string]ProwJobData{
+		"scored":   {Cluster: "build01"},
+		"blocked":  {Cluster: "build03"},
+		"pinned":   {Cluster: "build01"},
+		"stays":    {Cluster: "build02"},
+		"vanished": {Cluster: "build01"},
+		"grouped":  {Cluster: "build01"},
+	}
+	next := map[string]ProwJobData{
+		"scored":  {Cluster: "build02"},
+		"blocked": {Cluster: "build02"},
+		"pinned":  {Cluster: "build04", Reason: ReasonClusterLabel},
+		"grouped": {Cluster: "build02"},
+		"stays":   {Cluster: "build02"},
+		"new":     {Cluster: "build02"},
+	}
+	placements := map[string]Placement{"scored": placement, "blocked": placement, "stays": placement, "new": placement}
+	expected := []JobMove{
+		{
+			Job:    "blocked",
+			From:   "build03",
+			To:     "build02",
+			Reason: "build03 is not a candidate anymore, placed on the lowest scoring cluster build02 (score 0.50 = load 0.50 + latency 0.00 + failures 0.00 + cost 0.00)",
+		},
+		{
+			Job:    "grouped",
+			From:   "build01",
+			To:     "build02",
+			Reason: "determined by the rules of the dispatcher config",
+		},
+		{
+			Job:    "new",
+			To:     "build02",
+			Reason: "placed on the lowest scoring cluster build02 (score 0.50 = load 0.50 + latency 0.00 + failures 0.00 + cost 0.00)",
+		},
+		{
+			Job:    "pinned",
+			From:   "build01",
+			To:     "build04",
+			Reason: "placed by the cluster-label rule",
+		},
+		{
+			Job:    "scored",
+			From:   "build01",
+			To:     "build02",
+			Reason: "build02 (score 0.50 = load 0.50 + latency 0.00 + failures 0.00 + cost 0.00) scored lower than build01 (score 1.20 = load 0.20 + latency 0.00 + failures 1.00 + cost 0.00)",
+		},
+	}
+	if diff := cmp.Diff(expected, DiffAssignments(previous, next, placements)); diff != "" {
+		t.Errorf("actual does not match expected, diff: %s", diff)
+	}
+}
@@ -4,20 +4,35 @@ import (
 	"encoding/json"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 
 	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// SchedulePath is the path of the batch scheduling endpoint of the versioned API
+	SchedulePath = "/v1/schedule"
+	// ClustersPath is the path of the cluster listing endpoint of the versioned API
+	ClustersPath = "/v1/clusters"
 )
 
+// ClusterConfigLoader loads the cluster config, returning a ClusterMap and a set of blocked clusters
+type ClusterConfigLoader func() (ClusterMap, sets.Set[string], error)
+
 type Server struct {
-	pjs      *Prowjobs
-	dispatch func(bool)
+	pjs           *Prowjobs
+	dispatch      func(bool)
+	clusterConfig ClusterConfigLoader
 }
 
-func NewServer(jobs *Prowjobs, dispatch func(bool)) *Server {
+func NewServer(jobs *Prowjobs, dispatch func(bool), clusterConfig ClusterConfigLoader) *Server {
 	return &Server{
-		pjs:      jobs,
-		dispatch: dispatch,
+		pjs:           jobs,
+		dispatch:      dispatch,
+		clusterConfig: clusterConfig,
 	}
 }
 
@@ -31,6 +46,39 @@ type SchedulingResponse struct {
 	Cluster string `json:"cluster"`
 }
 
+// BatchSchedulingRequest represents the incoming request structure of the batch scheduling endpoint
+type BatchSchedulingRequest struct {
+	Jobs []string `json:"jobs"`
+}
+
+// JobPlacement is the cluster of a job and the reason for it, or an error if the job is not known
+type JobPlacement struct {
+	Job     string `json:"job"`
+	Cluster string `json:"cluster,omitempty"`
+	Reason  Reason `json:"reason,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchSchedulingResponse holds the placements in the order of the requested jobs
+type BatchSchedulingResponse struct {
+	Jobs []JobPlacement `json:"jobs"`
+}
+
+// ClusterStatus describes a cluster and the number of jobs assigned to it
+type ClusterStatus struct {
+	Name         string   `json:"name"`
+	Provider     string   `json:"provider,omitempty"`
+	Capacity     int      `json:"capacity"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Blocked      bool     `json:"blocked"`
+	Jobs         int      `json:"jobs"`
+}
+
+// ClustersResponse holds the clusters sorted by name
+type ClustersResponse struct {
+	Clusters []ClusterStatus `json:"clusters"`
+}
+
 func removeRehearsePrefix(jobName string) string {
 	re := regexp.MustCompile(`^rehearse-\d+-`)
 
@@ -74,6 +122,89 @@ func (s *Server) RequestHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ScheduleHandler handles batch scheduling requests which explain the placement of each job
+func (s *Server) ScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Path != SchedulePath {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	var req BatchSchedulingRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	response := BatchSchedulingResponse{Jobs: make([]JobPlacement, 0, len(req.Jobs))}
+	for _, job := range req.Jobs {
+		placement := JobPlacement{Job: job}
+		if data, ok := s.pjs.Get(removeRehearsePrefix(job)); ok && data.Cluster != "" {
+			placement.Cluster = data.Cluster
+			placement.Reason = data.Reason
+		} else {
+			placement.Error = "Cluster not found"
+		}
+		response.Jobs = append(response.Jobs, placement)
+	}
+	writeJSON(w, response)
+}
+
+// ClustersHandler lists the clusters with their capacity, blocked state and the number of jobs assigned to them
+func (s *Server) ClustersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Path != ClustersPath {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	clusterMap, blocked, err := s.clusterConfig()
+	if err != nil {
+		logrus.WithError(err).Error("failed to load cluster config")
+		http.Error(w, "Failed to load cluster config", http.StatusInternalServerError)
+		return
+	}
+	counts := s.pjs.CountByCluster()
+	clusters := map[string]ClusterStatus{}
+	for name, info := range clusterMap {
+		clusters[name] = ClusterStatus{Name: name, Provider: info.Provider, Capacity: info.Capacity, Capabilities: info.Capabilities}
+	}
+	for name := range blocked {
+		clusters[name] = ClusterStatus{Name: name, Blocked: true}
+	}
+	for name := range counts {
+		if _, ok := clusters[name]; !ok && name != "" {
+			clusters[name] = ClusterStatus{Name: name}
+		}
+	}
+
+	response := ClustersResponse{Clusters: make([]ClusterStatus, 0, len(clusters))}
+	for name, cluster := range clusters {
+		cluster.Jobs = counts[name]
+		response.Clusters = append(response.Clusters, cluster)
+	}
+	sort.Slice(response.Clusters, func(i, j int) bool { return response.Clusters[i].Name < response.Clusters[j].Name })
+	writeJSON(w, response)
+}
+
+func writeJSON(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logrus.WithError(err).WithField("response", response).Error("failed to encode response")
+	}
+}
+
 // EventHandler handles the /event route with dispatch logic
 func (s *Server) EventHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
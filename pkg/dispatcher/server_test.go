@@ -1,7 +1,15 @@
 package dispatcher
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 func TestRemoveRehearsePrefix(t *testing.T) {
@@ -35,3 +43,146 @@ func TestRemoveRehearsePrefix(t *testing.T) {
 		}
 	}
 }
+
+func TestScheduleHandler(t *testing.T) {
+	pjs := &Prowjobs{data: map[string]ProwJobData{
+		"pull-ci-org-repo-master-unit": {Cluster: "build01", Reason: ReasonGroup},
+		"periodic-org-repo-e2e":        {Cluster: "build02", Reason: ReasonRelocation},
+	}}
+	server := NewServer(pjs, nil, nil)
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "batch of jobs",
+			method:       http.MethodPost,
+			path:         SchedulePath,
+			body:         `{"jobs":["pull-ci-org-repo-master-unit","rehearse-1234-periodic-org-repo-e2e","unknown"]}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"jobs":[{"job":"pull-ci-org-repo-master-unit","cluster":"build01","reason":"group"},{"job":"rehearse-1234-periodic-org-repo-e2e","cluster":"build02","reason":"relocation"},{"job":"unknown","error":"Cluster not found"}]}` + "\n",
+		},
+		{
+			name:         "no jobs",
+			method:       http.MethodPost,
+			path:         SchedulePath,
+			body:         `{}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"jobs":[]}` + "\n",
+		},
+		{
+			name:         "invalid body",
+			method:       http.MethodPost,
+			path:         SchedulePath,
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "unexpected EOF\n",
+		},
+		{
+			name:         "invalid method",
+			method:       http.MethodGet,
+			path:         SchedulePath,
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedBody: "Invalid request method\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			server.ScheduleHandler(recorder, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			if diff := cmp.Diff(tc.expectedCode, recorder.Code); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.expectedBody, recorder.Body.String()); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestClustersHandler(t *testing.T) {
+	pjs := &Prowjobs{data: map[string]ProwJobData{
+		"a": {Cluster: "build01"},
+		"b": {Cluster: "build01"},
+		"c": {Cluster: "build03"},
+		"d": {Cluster: "vsphere02"},
+	}}
+	testCases := []struct {
+		name          string
+		clusterConfig ClusterConfigLoader
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name: "clusters with assignments",
+			clusterConfig: func() (ClusterMap, sets.Set[string], error) {
+				return ClusterMap{
+					"build01": {Provider: "aws", Capacity: 100, Capabilities: []string{"intranet"}},
+					"build02": {Provider: "gcp", Capacity: 50},
+				}, sets.New[string]("build03"), nil
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"clusters":[{"name":"build01","provider":"aws","capacity":100,"capabilities":["intranet"],"blocked":false,"jobs":2},{"name":"build02","provider":"gcp","capacity":50,"blocked":false,"jobs":0},{"name":"build03","capacity":0,"blocked":true,"jobs":1},{"name":"vsphere02","capacity":0,"blocked":false,"jobs":1}]}` + "\n",
+		},
+		{
+			name: "cluster config can't be loaded",
+			clusterConfig: func() (ClusterMap, sets.Set[string], error) {
+				return nil, nil, errors.New("injected")
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Failed to load cluster config\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			NewServer(pjs, nil, tc.clusterConfig).ClustersHandler(recorder, httptest.NewRequest(http.MethodGet, ClustersPath, nil))
+			if diff := cmp.Diff(tc.expectedCode, recorder.Code); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.expectedBody, recorder.Body.String()); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestAPIClient(t *testing.T) {
+	pjs := &Prowjobs{data: map[string]ProwJobData{"job": {Cluster: "build01", Reason: ReasonCapability}}}
+	server := NewServer(pjs, nil, func() (ClusterMap, sets.Set[string], error) {
+		return ClusterMap{"build01": {Provider: "aws", Capacity: 100}}, sets.New[string](), nil
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", server.RequestHandler)
+	mux.HandleFunc(SchedulePath, server.ScheduleHandler)
+	mux.HandleFunc(ClustersPath, server.ClustersHandler)
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+
+	client := NewAPIClient(httpServer.URL + "/")
+	cluster, err := client.ClusterForJob("job")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff("build01", cluster); diff != "" {
+		t.Errorf("actual does not match expected, diff: %s", diff)
+	}
+	placements, err := client.ClustersForJobs([]string{"job", "other"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]JobPlacement{{Job: "job", Cluster: "build01", Reason: ReasonCapability}, {Job: "other", Error: "Cluster not found"}}, placements); diff != "" {
+		t.Errorf("actual does not match expected, diff: %s", diff)
+	}
+	clusters, err := client.Clusters()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]ClusterStatus{{Name: "build01", Provider: "aws", Capacity: 100, Jobs: 1}}, clusters); diff != "" {
+		t.Errorf("actual does not match expected, diff: %s", diff)
+	}
+}
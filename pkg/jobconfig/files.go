@@ -32,7 +32,7 @@ const (
 	CanBeRehearsedValue          = "true"
 	SSHBastionLabel              = "dptp.openshift.io/ssh-bastion"
 	ProwJobLabelVariant          = "ci-operator.openshift.io/variant"
-	ReleaseControllerLabel       = "ci-operator.openshift.io/release-controller"
+	ReleaseControllerLabel       = cioperatorapi.ReleaseControllerJobLabel
 	LabelBuildFarm               = "ci.openshift.io/build-farm"
 	LabelGenerator               = "ci.openshift.io/generator"
 	ReleaseControllerValue       = "true"
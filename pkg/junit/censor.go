@@ -21,6 +21,10 @@ func CensorTestSuite(censor secretutil.Censorer, testSuite *TestSuite) {
 			testSuite.TestCases[i].FailureOutput.Output = censored(censor, testSuite.TestCases[i].FailureOutput.Output)
 			testSuite.TestCases[i].FailureOutput.Message = censored(censor, testSuite.TestCases[i].FailureOutput.Message)
 		}
+		for j := range testSuite.TestCases[i].Properties {
+			testSuite.TestCases[i].Properties[j].Name = censored(censor, testSuite.TestCases[i].Properties[j].Name)
+			testSuite.TestCases[i].Properties[j].Value = censored(censor, testSuite.TestCases[i].Properties[j].Value)
+		}
 		testSuite.TestCases[i].SystemOut = censored(censor, testSuite.TestCases[i].SystemOut)
 		testSuite.TestCases[i].SystemErr = censored(censor, testSuite.TestCases[i].SystemErr)
 	}
@@ -0,0 +1,75 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Load reads jUnit files, or the junit*.xml files in directories.  Every file
+// holds the results of one run, either as a collection of suites or as a
+// single suite.
+func Load(paths ...string) ([]*TestSuites, error) {
+	var results []*TestSuites
+	load := func(path string) error {
+		result, err := loadFile(path)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+		return nil
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read jUnit results: %w", err)
+		}
+		if !info.IsDir() {
+			if err := load(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), "junit") || filepath.Ext(entry.Name()) != ".xml" {
+				return nil
+			}
+			return load(path)
+		}); err != nil {
+			return nil, fmt.Errorf("could not read jUnit results from %s: %w", path, err)
+		}
+	}
+	return results, nil
+}
+
+func loadFile(path string) (*TestSuites, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read jUnit file: %w", err)
+	}
+	suites, err := Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse jUnit file %s: %w", path, err)
+	}
+	return suites, nil
+}
+
+// Parse reads the results of one run, either as a collection of suites or as
+// a single suite.
+func Parse(raw []byte) (*TestSuites, error) {
+	var suites TestSuites
+	if err := xml.Unmarshal(raw, &suites); err == nil {
+		return &suites, nil
+	}
+	var suite TestSuite
+	if err := xml.Unmarshal(raw, &suite); err != nil {
+		return nil, err
+	}
+	return &TestSuites{Suites: []*TestSuite{&suite}}, nil
+}
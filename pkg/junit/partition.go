@@ -0,0 +1,92 @@
+package junit
+
+import (
+	"sort"
+)
+
+// Durations determines how long each test case took to run from previous
+// jUnit results, keyed by the name of the test case. Tests that ran more than
+// once are averaged and skipped test cases are ignored.
+func Durations(results ...*TestSuites) map[string]float64 {
+	total := map[string]float64{}
+	runs := map[string]int{}
+	var walk func(suites []*TestSuite)
+	walk = func(suites []*TestSuite) {
+		for _, suite := range suites {
+			for _, test := range suite.TestCases {
+				if test.SkipMessage != nil {
+					continue
+				}
+				total[test.Name] += test.Duration
+				runs[test.Name]++
+			}
+			walk(suite.Children)
+		}
+	}
+	for _, result := range results {
+		if result != nil {
+			walk(result.Suites)
+		}
+	}
+	for name, duration := range total {
+		total[name] = duration / float64(runs[name])
+	}
+	return total
+}
+
+// Partition splits the tests into count shards so that the shards finish at
+// about the same time, given how long each test took in previous runs. Tests
+// without a known duration are assumed to take the average of the known ones.
+// The result only depends on the inputs, so every shard of a test can compute
+// the same partition independently and run its part of it.
+func Partition(tests []string, durations map[string]float64, count int) [][]string {
+	if count < 1 {
+		count = 1
+	}
+	var known float64
+	var numKnown int
+	for _, test := range tests {
+		if duration, ok := durations[test]; ok {
+			known += duration
+			numKnown++
+		}
+	}
+	fallback := 1.0
+	if numKnown > 0 && known > 0 {
+		fallback = known / float64(numKnown)
+	}
+	durationOf := func(test string) float64 {
+		if duration, ok := durations[test]; ok {
+			return duration
+		}
+		return fallback
+	}
+
+	sorted := make([]string, len(tests))
+	copy(sorted, tests)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if di, dj := durationOf(sorted[i]), durationOf(sorted[j]); di != dj {
+			return di > dj
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	// assigning the longest tests first to the shard that would finish first
+	// keeps the shards within the duration of the longest test of each other
+	shards := make([][]string, count)
+	totals := make([]float64, count)
+	for _, test := range sorted {
+		shortest := 0
+		for i := range totals {
+			if totals[i] < totals[shortest] {
+				shortest = i
+			}
+		}
+		shards[shortest] = append(shards[shortest], test)
+		totals[shortest] += durationOf(test)
+	}
+	for i := range shards {
+		sort.Strings(shards[i])
+	}
+	return shards
+}
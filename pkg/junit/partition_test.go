@@ -0,0 +1,75 @@
+package junit
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDurations(t *testing.T) {
+	first := &TestSuites{Suites: []*TestSuite{{
+		TestCases: []*TestCase{
+			{Name: "a", Duration: 10},
+			{Name: "b", Duration: 4},
+			{Name: "skipped", Duration: 1, SkipMessage: &SkipMessage{}},
+		},
+		Children: []*TestSuite{{TestCases: []*TestCase{{Name: "nested", Duration: 3}}}},
+	}}}
+	second := &TestSuites{Suites: []*TestSuite{{
+		TestCases: []*TestCase{{Name: "a", Duration: 20}},
+	}}}
+	expected := map[string]float64{"a": 15, "b": 4, "nested": 3}
+	if diff := cmp.Diff(expected, Durations(first, nil, second)); diff != "" {
+		t.Errorf("unexpected durations: %s", diff)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	testCases := []struct {
+		name      string
+		tests     []string
+		durations map[string]float64
+		count     int
+		expected  [][]string
+	}{
+		{
+			name:      "longest tests are spread across shards",
+			tests:     []string{"a", "b", "c", "d", "e"},
+			durations: map[string]float64{"a": 8, "b": 7, "c": 6, "d": 5, "e": 4},
+			count:     2,
+			expected:  [][]string{{"a", "d", "e"}, {"b", "c"}},
+		},
+		{
+			name:      "tests without history take the average duration",
+			tests:     []string{"a", "b", "new"},
+			durations: map[string]float64{"a": 10, "b": 2},
+			count:     2,
+			expected:  [][]string{{"a"}, {"b", "new"}},
+		},
+		{
+			name:     "tests without any history are spread evenly",
+			tests:    []string{"d", "c", "b", "a"},
+			count:    2,
+			expected: [][]string{{"a", "c"}, {"b", "d"}},
+		},
+		{
+			name:     "more shards than tests",
+			tests:    []string{"a"},
+			count:    3,
+			expected: [][]string{{"a"}, nil, nil},
+		},
+		{
+			name:     "a single shard holds every test",
+			tests:    []string{"b", "a"},
+			count:    0,
+			expected: [][]string{{"a", "b"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Partition(tc.tests, tc.durations, tc.count)); diff != "" {
+				t.Errorf("unexpected partition: %s", diff)
+			}
+		})
+	}
+}
@@ -74,6 +74,9 @@ type TestCase struct {
 	// FailureOutput holds the output from a failing test
 	FailureOutput *FailureOutput `xml:"failure"`
 
+	// Properties holds other properties of the test case as a mapping of name to value
+	Properties []*TestSuiteProperty `xml:"properties>property,omitempty"`
+
 	// SystemOut is output written to stdout during the execution of this test case
 	SystemOut string `xml:"system-out,omitempty"`
 
@@ -0,0 +1,203 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/boskos/common"
+)
+
+// Priority is the priority class of a lease request.  When resources are
+// scarce, requests of a higher class are served first.
+type Priority int
+
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// Claim describes a request for a resource.
+type Claim struct {
+	// RequestID identifies the request while it waits for a resource.
+	RequestID string
+	Priority  Priority
+	// Name requests a specific resource of the type, which is only leased if
+	// it is free.  Such claims never wait.
+	Name string
+}
+
+// Backend stores the state of leasable resources.  Each backend acts on
+// behalf of a single owner.  All implementations share the same semantics:
+//
+//   - Acquire moves a free resource of a type to the leased state and returns
+//     its name.  When `wait` is set, it blocks until a resource is available or
+//     `ctx` is done.  Otherwise, it returns ErrNotFound if no resource is free.
+//     Requests with a higher priority are served first, to the extent the
+//     backend supports priorities.  A claim for a named resource returns
+//     ErrNotFound if that resource is not free.
+//   - Heartbeat renews a lease held by the owner and fails if the resource is
+//     no longer leased by it.
+//   - Release returns a resource leased by the owner to the free state.
+//   - Metrics counts the free and leased resources of a type.
+type Backend interface {
+	Acquire(ctx context.Context, rtype string, claim Claim, wait bool) (string, error)
+	Heartbeat(name string) error
+	Release(name string) error
+	Metrics(rtype string) (Metrics, error)
+}
+
+// NewClientWithBackend creates a client that leases resources from a backend.
+func NewClientWithBackend(backend Backend, retries int, acquireTimeout time.Duration) Client {
+	return &client{
+		backend:        backend,
+		retries:        retries,
+		acquireTimeout: acquireTimeout,
+		backoff:        acquireBackoff,
+		leases:         make(map[string]*lease),
+	}
+}
+
+// boskosBackendPollInterval is the time between attempts to acquire a resource
+// from Boskos for a request which is not queued on the server.
+var boskosBackendPollInterval = 30 * time.Second
+
+// boskosBackend leases resources from a Boskos server.  The server serves the
+// requests queued there in the order in which they arrive and knows neither
+// priorities nor organizations, so both are approximated by the client:
+//
+//   - High priority requests are queued on the server.
+//   - Other requests poll for a free resource, so that queued ones are
+//     usually served first when a resource is released.
+//   - Low priority requests, and those of an organization which holds its fair
+//     share of the resources of the type, only take a resource while another
+//     one remains free.
+//
+// The organization of the owner of a lease is recorded in the name of the
+// owner, which is the only information Boskos counts leases by.
+type boskosBackend struct {
+	client boskosClient
+	org    string
+}
+
+// boskosOwner is the name of an owner in Boskos, which carries its
+// organization.
+func boskosOwner(owner, org string) string {
+	if org == "" {
+		return owner
+	}
+	return owner + "@" + org
+}
+
+// boskosOwnerOrg is the organization of an owner in Boskos.  Owners which do
+// not record one are considered an organization of their own.
+func boskosOwnerOrg(owner string) string {
+	if i := strings.LastIndex(owner, "@"); i != -1 {
+		return owner[i+1:]
+	}
+	return owner
+}
+
+func (b *boskosBackend) Acquire(ctx context.Context, rtype string, claim Claim, wait bool) (string, error) {
+	if claim.Name != "" {
+		return b.acquireByName(claim.Name)
+	}
+	if claim.Priority > PriorityNormal && wait {
+		r, err := b.client.AcquireWaitWithPriority(ctx, rtype, freeState, leasedState, claim.RequestID)
+		if err != nil {
+			return "", err
+		}
+		return r.Name, nil
+	}
+	return b.poll(ctx, rtype, claim.Priority, wait)
+}
+
+func (b *boskosBackend) acquireByName(name string) (string, error) {
+	resources, err := b.client.AcquireByState(freeState, leasedState, []string{name})
+	if errors.Is(err, ErrAlreadyInUse) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(resources) != 1 {
+		return "", fmt.Errorf("expected to acquire resource %s, got %d resources", name, len(resources))
+	}
+	return resources[0].Name, nil
+}
+
+// poll acquires a resource once one can be spared for the request.
+func (b *boskosBackend) poll(ctx context.Context, rtype string, priority Priority, wait bool) (string, error) {
+	for {
+		metric, err := b.client.Metric(rtype)
+		if err != nil {
+			return "", err
+		}
+		reserve := 0
+		if priority < PriorityNormal || (priority == PriorityNormal && b.overShare(metric)) {
+			reserve = 1
+		}
+		if metric.Current[freeState] > reserve {
+			r, err := b.client.Acquire(rtype, freeState, leasedState)
+			if err == nil {
+				return r.Name, nil
+			}
+			if !errors.Is(err, ErrNotFound) {
+				return "", err
+			}
+		}
+		if !wait {
+			return "", ErrNotFound
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(boskosBackendPollInterval):
+		}
+	}
+}
+
+// overShare determines whether the organization holds at least its fair share
+// of the resources of the type, split between the organizations which hold
+// any of them.
+func (b *boskosBackend) overShare(metric common.Metric) bool {
+	if b.org == "" {
+		return false
+	}
+	orgs := sets.New[string](b.org)
+	held := map[string]int{}
+	for owner, count := range metric.Owners {
+		if owner == "" {
+			continue
+		}
+		org := boskosOwnerOrg(owner)
+		orgs.Insert(org)
+		held[org] += count
+	}
+	total := metric.Current[freeState] + metric.Current[leasedState]
+	share := (total + orgs.Len() - 1) / orgs.Len()
+	return held[b.org] >= share
+}
+
+func (b *boskosBackend) Heartbeat(name string) error {
+	return b.client.UpdateOne(name, leasedState, nil)
+}
+
+func (b *boskosBackend) Release(name string) error {
+	return b.client.ReleaseOne(name, freeState)
+}
+
+func (b *boskosBackend) Metrics(rtype string) (Metrics, error) {
+	metrics, err := b.client.Metric(rtype)
+	if err != nil {
+		return Metrics{}, err
+	}
+	return Metrics{
+		Free:   metrics.Current[freeState],
+		Leased: metrics.Current[leasedState],
+	}, nil
+}
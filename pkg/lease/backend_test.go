@@ -0,0 +1,315 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// backendFactory creates backends for several owners which share the resources
+// `a0` and `a1` of type `a` and `b0` of type `b`.
+type backendFactory func(t *testing.T, expiry time.Duration) func(owner, org string) *storeBackend
+
+func memoryBackends(_ *testing.T, expiry time.Duration) func(string, string) *storeBackend {
+	pool := NewMemoryPool(map[string][]string{"a": {"a0", "a1"}, "b": {"b0"}})
+	return func(owner, org string) *storeBackend {
+		return pool.Backend(owner, org, expiry).(*storeBackend)
+	}
+}
+
+func configMapBackends(_ *testing.T, expiry time.Duration) func(string, string) *storeBackend {
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "leases"},
+		Data: map[string]string{
+			"a": `[{"name":"a0"},{"name":"a1"}]`,
+			"b": `[{"name":"b0"}]`,
+		},
+	}).Build()
+	return func(owner, org string) *storeBackend {
+		return NewConfigMapBackend(client, "ci", "leases", owner, org, expiry).(*storeBackend)
+	}
+}
+
+// TestBackends verifies that all backends implement identical semantics.
+func TestBackends(t *testing.T) {
+	oldInterval := configMapPollInterval
+	configMapPollInterval = 10 * time.Millisecond
+	defer func() { configMapPollInterval = oldInterval }()
+	for name, factory := range map[string]backendFactory{
+		"memory":    memoryBackends,
+		"configmap": configMapBackends,
+	} {
+		t.Run(name, func(t *testing.T) {
+			testBackend(t, factory)
+		})
+	}
+}
+
+func testBackend(t *testing.T, factory backendFactory) {
+	ctx := context.Background()
+	expectMetrics := func(t *testing.T, backend Backend, rtype string, expected Metrics) {
+		t.Helper()
+		metrics, err := backend.Metrics(rtype)
+		if err != nil {
+			t.Fatalf("failed to get metrics: %v", err)
+		}
+		if diff := cmp.Diff(expected, metrics); diff != "" {
+			t.Fatalf("unexpected metrics: %s", diff)
+		}
+	}
+	t.Run("acquire, heartbeat, and release", func(t *testing.T) {
+		backends := factory(t, 0)
+		owner, other := backends("owner", "org"), backends("other", "org")
+		expectMetrics(t, owner, "a", Metrics{Free: 2})
+		first, err := owner.Acquire(ctx, "a", Claim{RequestID: "request"}, false)
+		if err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		second, err := other.Acquire(ctx, "a", Claim{RequestID: "request"}, false)
+		if err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		if first == second {
+			t.Fatalf("the same resource was leased twice: %q", first)
+		}
+		expectMetrics(t, owner, "a", Metrics{Leased: 2})
+		expectMetrics(t, other, "b", Metrics{Free: 1})
+		if _, err := owner.Acquire(ctx, "a", Claim{RequestID: "request"}, false); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound when no resource is free, got %v", err)
+		}
+		if err := owner.Heartbeat(first); err != nil {
+			t.Fatalf("failed to heartbeat: %v", err)
+		}
+		if err := owner.Heartbeat(second); err == nil {
+			t.Fatal("expected an error when renewing a lease held by another owner")
+		}
+		if err := owner.Release(second); err == nil {
+			t.Fatal("expected an error when releasing a lease held by another owner")
+		}
+		if err := owner.Heartbeat("unknown"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound for an unknown resource, got %v", err)
+		}
+		if err := owner.Release(first); err != nil {
+			t.Fatalf("failed to release: %v", err)
+		}
+		expectMetrics(t, owner, "a", Metrics{Free: 1, Leased: 1})
+		if err := owner.Heartbeat(first); err == nil {
+			t.Fatal("expected an error when renewing a released lease")
+		}
+	})
+	t.Run("acquire waits for a resource to be released", func(t *testing.T) {
+		backends := factory(t, 0)
+		owner, other := backends("owner", "org"), backends("other", "org")
+		name, err := other.Acquire(ctx, "b", Claim{RequestID: "request"}, false)
+		if err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		acquired := make(chan string)
+		go func() {
+			name, err := owner.Acquire(ctx, "b", Claim{RequestID: "request"}, true)
+			if err != nil {
+				t.Errorf("failed to acquire: %v", err)
+			}
+			acquired <- name
+		}()
+		if err := other.Release(name); err != nil {
+			t.Fatalf("failed to release: %v", err)
+		}
+		if got := <-acquired; got != name {
+			t.Fatalf("expected to acquire %q, got %q", name, got)
+		}
+	})
+	t.Run("acquire stops waiting when the context is done", func(t *testing.T) {
+		backends := factory(t, 0)
+		owner := backends("owner", "org")
+		if _, err := owner.Acquire(ctx, "b", Claim{RequestID: "request"}, false); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if _, err := owner.Acquire(ctx, "b", Claim{RequestID: "request"}, true); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the deadline to be exceeded, got %v", err)
+		}
+	})
+	waitFor := func(t *testing.T, backend *storeBackend, rtype string, waiters int) {
+		t.Helper()
+		if err := wait.PollUntilContextTimeout(ctx, time.Millisecond, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+			var n int
+			_, err := backend.store.update(ctx, func(state map[string]*pool) (bool, error) {
+				n = len(state[rtype].Waiters)
+				return false, nil
+			})
+			return n == waiters, err
+		}); err != nil {
+			t.Fatalf("expected %d requests waiting for %s: %v", waiters, rtype, err)
+		}
+	}
+	acquire := func(backend Backend, ctx context.Context, rtype string, claim Claim) <-chan string {
+		acquired := make(chan string, 1)
+		go func() {
+			name, err := backend.Acquire(ctx, rtype, claim, true)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				t.Errorf("failed to acquire: %v", err)
+			}
+			acquired <- name
+		}()
+		return acquired
+	}
+	t.Run("requests with a higher priority are served first", func(t *testing.T) {
+		backends := factory(t, 0)
+		holder, low, high := backends("holder", "org"), backends("low", "org"), backends("high", "org")
+		name, err := holder.Acquire(ctx, "b", Claim{RequestID: "holder"}, false)
+		if err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		lowAcquired := acquire(low, ctx, "b", Claim{RequestID: "low", Priority: PriorityLow})
+		waitFor(t, holder, "b", 1)
+		highAcquired := acquire(high, ctx, "b", Claim{RequestID: "high", Priority: PriorityHigh})
+		waitFor(t, holder, "b", 2)
+		if err := holder.Release(name); err != nil {
+			t.Fatalf("failed to release: %v", err)
+		}
+		if got := <-highAcquired; got != name {
+			t.Fatalf("expected the high-priority request to acquire %q, got %q", name, got)
+		}
+		waitFor(t, holder, "b", 1)
+		if err := high.Release(name); err != nil {
+			t.Fatalf("failed to release: %v", err)
+		}
+		if got := <-lowAcquired; got != name {
+			t.Fatalf("expected the low-priority request to acquire %q, got %q", name, got)
+		}
+		waitFor(t, holder, "b", 0)
+	})
+	t.Run("requests without waiters ignore priority", func(t *testing.T) {
+		backends := factory(t, 0)
+		if _, err := backends("low", "org").Acquire(ctx, "b", Claim{RequestID: "low", Priority: PriorityLow}, false); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+	})
+	t.Run("organizations are limited to a fair share while others wait", func(t *testing.T) {
+		backends := factory(t, 0)
+		first, second := backends("first", "greedy"), backends("second", "greedy")
+		third, other := backends("third", "greedy"), backends("other", "modest")
+		name, err := first.Acquire(ctx, "a", Claim{RequestID: "first"}, false)
+		if err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		if _, err := second.Acquire(ctx, "a", Claim{RequestID: "second"}, false); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		thirdCtx, cancelThird := context.WithCancel(ctx)
+		defer cancelThird()
+		thirdAcquired := acquire(third, thirdCtx, "a", Claim{RequestID: "third"})
+		waitFor(t, first, "a", 1)
+		otherAcquired := acquire(other, ctx, "a", Claim{RequestID: "other"})
+		waitFor(t, first, "a", 2)
+		if err := first.Release(name); err != nil {
+			t.Fatalf("failed to release: %v", err)
+		}
+		if got := <-otherAcquired; got != name {
+			t.Fatalf("expected the other organization to acquire %q, got %q", name, got)
+		}
+		waitFor(t, first, "a", 1)
+		cancelThird()
+		if got := <-thirdAcquired; got != "" {
+			t.Fatalf("expected the organization over its share not to acquire a resource, got %q", got)
+		}
+		waitFor(t, first, "a", 0)
+	})
+	t.Run("expired leases can be acquired", func(t *testing.T) {
+		backends := factory(t, time.Minute)
+		now := time.Now()
+		owner, other := backends("owner", "org"), backends("other", "org")
+		for _, b := range []*storeBackend{owner, other} {
+			b.now = func() time.Time { return now }
+		}
+		name, err := owner.Acquire(ctx, "b", Claim{RequestID: "request"}, false)
+		if err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		expectMetrics(t, other, "b", Metrics{Leased: 1})
+		now = now.Add(2 * time.Minute)
+		expectMetrics(t, other, "b", Metrics{Free: 1})
+		if got, err := other.Acquire(ctx, "b", Claim{RequestID: "request"}, false); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		} else if got != name {
+			t.Fatalf("expected to acquire %q, got %q", name, got)
+		}
+		if err := owner.Heartbeat(name); err == nil {
+			t.Fatal("expected an error when renewing a lease acquired by another owner")
+		}
+	})
+	t.Run("named resources are only leased when free", func(t *testing.T) {
+		backends := factory(t, 0)
+		owner, other := backends("owner", "org"), backends("other", "org")
+		if name, err := owner.Acquire(ctx, "a", Claim{Name: "a1"}, true); err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		} else if name != "a1" {
+			t.Fatalf("expected to acquire a1, got %q", name)
+		}
+		if _, err := other.Acquire(ctx, "a", Claim{Name: "a1"}, true); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound for a leased resource, got %v", err)
+		}
+		if _, err := other.Acquire(ctx, "a", Claim{Name: "b0"}, false); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound for a resource of another type, got %v", err)
+		}
+		expectMetrics(t, other, "a", Metrics{Free: 1, Leased: 1})
+	})
+}
+
+func TestClientAcquiresNamedResources(t *testing.T) {
+	pool := NewMemoryPool(map[string][]string{"a": {"a0", "a1", "a2"}})
+	if _, err := pool.Backend("other", "org", 0).Acquire(context.Background(), "a", Claim{Name: "a2"}, false); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	client := NewClientWithBackend(pool.Backend("owner", "org", 0), 0, time.Minute)
+	names, err := client.AcquireAll([]Request{{ResourceType: "a", Count: 2, Names: []string{"a2", "a1"}}}, context.Background(), func() {})
+	if err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if diff := cmp.Diff([][]string{{"a1", "a0"}}, names); diff != "" {
+		t.Fatalf("unexpected leases: %s", diff)
+	}
+}
+
+func TestClientWithBackend(t *testing.T) {
+	pool := NewMemoryPool(map[string][]string{"a": {"a0", "a1"}})
+	client := NewClientWithBackend(pool.Backend("owner", "org", 0), 0, time.Minute)
+	names, err := client.Acquire("a", 2, context.Background(), func() {})
+	if err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if diff := cmp.Diff([]string{"a0", "a1"}, names); diff != "" {
+		t.Fatalf("unexpected leases: %s", diff)
+	}
+	if _, err := client.AcquireIfAvailableImmediately(Request{ResourceType: "a", Count: 1}, func() {}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := client.Heartbeat(); err != nil {
+		t.Fatalf("failed to heartbeat: %v", err)
+	}
+	if err := client.Release("a0"); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	released, err := client.ReleaseAll()
+	if err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	if diff := cmp.Diff([]string{"a1"}, released); diff != "" {
+		t.Fatalf("unexpected released leases: %s", diff)
+	}
+	if metrics, err := client.Metrics("a"); err != nil {
+		t.Fatalf("failed to get metrics: %v", err)
+	} else if diff := cmp.Diff(Metrics{Free: 2}, metrics); diff != "" {
+		t.Fatalf("unexpected metrics: %s", diff)
+	}
+}
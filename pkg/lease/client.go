@@ -2,8 +2,11 @@ package lease
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -11,6 +14,8 @@ import (
 	"github.com/sirupsen/logrus"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	boskos "sigs.k8s.io/boskos/client"
 	"sigs.k8s.io/boskos/common"
 )
@@ -23,29 +28,49 @@ const (
 type boskosClient interface {
 	AcquireWaitWithPriority(ctx context.Context, rtype, state, dest, requestID string) (*common.Resource, error)
 	Acquire(rtype, state, dest string) (*common.Resource, error)
+	AcquireByState(state, dest string, names []string) ([]common.Resource, error)
 	UpdateOne(name, dest string, _ *common.UserData) error
 	ReleaseOne(name, dest string) error
 	ReleaseAll(dest string) error
 	Metric(rtype string) (common.Metric, error)
 }
 
-var ErrNotFound = boskos.ErrNotFound
+var (
+	ErrNotFound     = boskos.ErrNotFound
+	ErrAlreadyInUse = boskos.ErrAlreadyInUse
+)
 
 type Metrics struct {
 	Free, Leased int
 }
 
+// Request describes a number of resources of a type to be leased.
+type Request struct {
+	ResourceType string
+	Count        uint
+	Priority     Priority
+	// Names are resources of the type to lease again if they are free, such
+	// as those an earlier execution left a cluster on.  Names which are not
+	// free are replaced by any other resource of the type.
+	Names []string
+}
+
 // Client manages resource leases, acquiring, releasing, and keeping them
 // updated.
 type Client interface {
 	// Acquire leases `n` resources and returns the lease names.
-	// Will block until resources are available or 150m pass, `n` must be > 0.
+	// Will block until all resources are available or 150m pass, `n` must be > 0.
 	// `ctx` can be used to abort the operation, `cancel` is called if any
 	// subsequent updates to the lease fail.
 	Acquire(rtype string, n uint, ctx context.Context, cancel context.CancelFunc) ([]string, error)
-	//AcquireIfAvailableImmediately leases `n` resources and returns the lease names.
+	//AcquireIfAvailableImmediately leases the resources described by `request` and returns the lease names.
 	// Does not block, and only leases the resources if they are available right away.
-	AcquireIfAvailableImmediately(rtype string, n uint, cancel context.CancelFunc) ([]string, error)
+	AcquireIfAvailableImmediately(request Request, cancel context.CancelFunc) ([]string, error)
+	// AcquireAll leases all resources described by `requests` and returns the
+	// lease names for each request.  Either all resources are leased or none
+	// are: partial acquisitions are released and retried after a jittered
+	// backoff.  Blocks like `Acquire`.
+	AcquireAll(requests []Request, ctx context.Context, cancel context.CancelFunc) ([][]string, error)
 	// Heartbeat updates all leases. It calls the cancellation function of each
 	// lease it fails to update.
 	Heartbeat() error
@@ -59,38 +84,47 @@ type Client interface {
 	Metrics(rtype string) (Metrics, error)
 }
 
-// NewClient creates a client that leases resources with the specified owner.
-func NewClient(owner, url, username string, passwordGetter func() []byte, retries int, acquireTimeout time.Duration) (Client, error) {
+// NewClient creates a client that leases resources with the specified owner,
+// on behalf of an organization.
+func NewClient(owner, org, url, username string, passwordGetter func() []byte, retries int, acquireTimeout time.Duration) (Client, error) {
 	randId = func() string {
 		return strconv.Itoa(rand.Int())
 	}
-	c, err := boskos.NewClientWithPasswordGetter(owner, url, username, passwordGetter)
+	c, err := boskos.NewClientWithPasswordGetter(boskosOwner(owner, org), url, username, passwordGetter)
 	if err != nil {
 		return nil, err
 	}
-	return newClient(c, retries, acquireTimeout), nil
+	return newClient(c, org, retries, acquireTimeout), nil
 }
 
 // for test mocking
-var randId func() string
-
-func newClient(boskos boskosClient, retries int, acquireTimeout time.Duration) Client {
-	return &client{
-		boskos:         boskos,
-		retries:        retries,
-		acquireTimeout: acquireTimeout,
-		leases:         make(map[string]*lease),
-	}
+var randId = func() string {
+	return strconv.Itoa(rand.Int())
+}
+
+func newClient(boskos boskosClient, org string, retries int, acquireTimeout time.Duration) Client {
+	return NewClientWithBackend(&boskosBackend{client: boskos, org: org}, retries, acquireTimeout)
 }
 
 type client struct {
 	sync.RWMutex
-	boskos         boskosClient
+	backend        Backend
 	retries        int
 	acquireTimeout time.Duration
+	backoff        wait.Backoff
 	leases         map[string]*lease
 }
 
+// acquireBackoff is the delay between attempts to acquire all resources
+// requested at once.
+var acquireBackoff = wait.Backoff{
+	Duration: 5 * time.Second,
+	Factor:   2,
+	Jitter:   1,
+	Steps:    math.MaxInt32,
+	Cap:      5 * time.Minute,
+}
+
 type lease struct {
 	updateFailures int
 	// cancel holds a cancellation function for steps that depend on leases
@@ -101,45 +135,144 @@ type lease struct {
 }
 
 func (c *client) Acquire(rtype string, n uint, ctx context.Context, cancel context.CancelFunc) ([]string, error) {
+	names, err := c.AcquireAll([]Request{{ResourceType: rtype, Count: n}}, ctx, cancel)
+	if err != nil {
+		return nil, err
+	}
+	return names[0], nil
+}
+
+func (c *client) AcquireAll(requests []Request, ctx context.Context, cancel context.CancelFunc) ([][]string, error) {
 	var cancelAcquire context.CancelFunc
 	ctx, cancelAcquire = context.WithTimeout(ctx, c.acquireTimeout)
 	defer cancelAcquire()
-	var ret []string
-	// TODO `m` processes may fight for the last `m * n` remaining leases
-	for i := uint(0); i < n; i++ {
-		r, err := c.boskos.AcquireWaitWithPriority(ctx, rtype, freeState, leasedState, randId())
-		if err != nil {
+	start := time.Now()
+	// The time at which all resources of a type were acquired in the last
+	// attempt, the wait for a type ends then and not after the other ones.
+	acquiredAt := map[string]time.Time{}
+	acquired := func(rtype string) { acquiredAt[rtype] = time.Now() }
+	failed := func() {
+		for _, rtype := range requestTypes(requests) {
+			observeWait(rtype, "failed", time.Since(start).Seconds())
+		}
+	}
+	// Requests keep their IDs across attempts so that they do not lose
+	// their position in the queue of the server.
+	ids := requestIDs(requests)
+	backoff := c.backoff
+	for {
+		// Only the first resource is waited for, all others have to be
+		// available immediately.  Otherwise, `m` processes may each hold a
+		// part of the last `m * n` remaining resources while waiting for the
+		// rest, which no one will ever release.
+		ret, err := c.acquireAll(ctx, requests, ids, cancel, true, acquired)
+		if err == nil {
+			for _, rtype := range requestTypes(requests) {
+				observeWait(rtype, "acquired", acquiredAt[rtype].Sub(start).Seconds())
+			}
+			return ret, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			failed()
 			return nil, err
 		}
-		c.Lock()
-		c.leases[r.Name] = &lease{cancel: cancel}
-		c.Unlock()
-		ret = append(ret, r.Name)
+		delay := backoff.Step()
+		logrus.WithError(err).Debugf("Released partially acquired leases, retrying in %s", delay)
+		select {
+		case <-ctx.Done():
+			failed()
+			return nil, fmt.Errorf("%w while waiting for leases, last attempt: %w", ctx.Err(), err)
+		case <-time.After(delay):
+		}
 	}
-	return ret, nil
 }
 
-func (c *client) AcquireIfAvailableImmediately(rtype string, n uint, cancel context.CancelFunc) ([]string, error) {
-	var ret []string
-	for i := uint(0); i < n; i++ {
-		r, err := c.boskos.Acquire(rtype, freeState, leasedState)
-		if err != nil {
-			return nil, err
+func (c *client) AcquireIfAvailableImmediately(request Request, cancel context.CancelFunc) ([]string, error) {
+	requests := []Request{request}
+	names, err := c.acquireAll(context.Background(), requests, requestIDs(requests), cancel, false, func(string) {})
+	if err != nil {
+		return nil, err
+	}
+	return names[0], nil
+}
+
+// requestIDs generates an ID for each resource in `requests`.
+func requestIDs(requests []Request) [][]string {
+	ret := make([][]string, len(requests))
+	for i, r := range requests {
+		for j := uint(0); j < r.Count; j++ {
+			ret[i] = append(ret[i], randId())
+		}
+	}
+	return ret
+}
+
+// acquireAll makes one attempt at leasing all resources in `requests`, using
+// the IDs in `ids`.  If `wait` is set, it blocks until the first resource is
+// available.  `acquired` is called with the type of a request once all of its
+// resources were acquired.  When any of the resources cannot be acquired,
+// those that were are released.
+func (c *client) acquireAll(ctx context.Context, requests []Request, ids [][]string, cancel context.CancelFunc, wait bool, acquired func(rtype string)) ([][]string, error) {
+	// Acquire in a consistent order to avoid contention between processes
+	// requesting the same resource types.
+	order := make([]int, len(requests))
+	for i := range requests {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return requests[order[i]].ResourceType < requests[order[j]].ResourceType
+	})
+	ret := make([][]string, len(requests))
+	var leased []string
+	for _, i := range order {
+		named := requests[i].Names
+		for j := uint(0); j < requests[i].Count; j++ {
+			var name string
+			var err error
+			for name == "" && len(named) != 0 {
+				claim := Claim{Priority: requests[i].Priority, Name: named[0]}
+				named = named[1:]
+				if name, err = c.backend.Acquire(ctx, requests[i].ResourceType, claim, false); err != nil {
+					logrus.WithError(err).Infof("Could not lease %s again, leasing any other resource of type %s", claim.Name, requests[i].ResourceType)
+				}
+			}
+			if name == "" {
+				claim := Claim{RequestID: ids[i][j], Priority: requests[i].Priority}
+				name, err = c.backend.Acquire(ctx, requests[i].ResourceType, claim, wait && len(leased) == 0)
+			}
+			if err != nil {
+				for _, name := range leased {
+					if err := c.Release(name); err != nil {
+						logrus.WithError(err).Warnf("Failed to release partially acquired lease %q", name)
+					}
+				}
+				return nil, err
+			}
+			c.Lock()
+			c.leases[name] = &lease{cancel: cancel}
+			c.Unlock()
+			leased = append(leased, name)
+			ret[i] = append(ret[i], name)
 		}
-		c.Lock()
-		c.leases[r.Name] = &lease{cancel: cancel}
-		c.Unlock()
-		ret = append(ret, r.Name)
+		acquired(requests[i].ResourceType)
 	}
 	return ret, nil
 }
 
+func requestTypes(requests []Request) []string {
+	types := sets.New[string]()
+	for _, r := range requests {
+		types.Insert(r.ResourceType)
+	}
+	return sets.List(types)
+}
+
 func (c *client) Heartbeat() error {
 	c.Lock()
 	defer c.Unlock()
 	var errs []error
 	for name, lease := range c.leases {
-		err := c.boskos.UpdateOne(name, leasedState, nil)
+		err := c.backend.Heartbeat(name)
 		if err == nil {
 			c.leases[name].updateFailures = 0
 			continue
@@ -159,7 +292,7 @@ func (c *client) Heartbeat() error {
 func (c *client) Release(name string) error {
 	c.Lock()
 	defer c.Unlock()
-	if err := c.boskos.ReleaseOne(name, freeState); err != nil {
+	if err := c.backend.Release(name); err != nil {
 		return err
 	}
 	delete(c.leases, name)
@@ -173,7 +306,7 @@ func (c *client) ReleaseAll() ([]string, error) {
 	var errs []error
 	for l := range c.leases {
 		ret = append(ret, l)
-		if err := c.boskos.ReleaseOne(l, freeState); err != nil {
+		if err := c.backend.Release(l); err != nil {
 			errs = append(errs, err)
 			continue
 		}
@@ -183,12 +316,5 @@ func (c *client) ReleaseAll() ([]string, error) {
 }
 
 func (c *client) Metrics(rtype string) (Metrics, error) {
-	metrics, err := c.boskos.Metric(rtype)
-	if err != nil {
-		return Metrics{}, err
-	}
-	return Metrics{
-		Free:   metrics.Current[freeState],
-		Leased: metrics.Current[leasedState],
-	}, nil
+	return c.backend.Metrics(rtype)
 }
@@ -4,9 +4,17 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/boskos/common"
 )
 
 func TestAcquire(t *testing.T) {
@@ -16,7 +24,7 @@ func TestAcquire(t *testing.T) {
 	if _, err := client.Acquire("rtype", 1, ctx, nil); err != nil {
 		t.Fatal(err)
 	}
-	expected := []string{"acquireWaitWithPriority owner rtype free leased random"}
+	expected := []string{"acquire owner rtype free leased"}
 	if !reflect.DeepEqual(calls, expected) {
 		t.Fatalf("wrong calls to the boskos client: %v", diff.ObjectDiff(calls, expected))
 	}
@@ -24,7 +32,7 @@ func TestAcquire(t *testing.T) {
 		t.Fatal(err)
 	}
 	expected = []string{
-		"acquireWaitWithPriority owner rtype free leased random",
+		"acquire owner rtype free leased",
 		"updateone owner rtype_0 leased 0",
 	}
 	if !reflect.DeepEqual(calls, expected) {
@@ -40,6 +48,27 @@ func TestAcquire(t *testing.T) {
 	}
 }
 
+func TestAcquireNamed(t *testing.T) {
+	var calls []string
+	client := NewFakeClient("owner", "url", 0, map[string]error{"acquirebystate owner free leased rtype_a": ErrAlreadyInUse}, &calls)
+	names, err := client.AcquireAll([]Request{{ResourceType: "rtype", Count: 2, Names: []string{"rtype_a", "rtype_b"}}}, context.Background(), func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]string{{"rtype_b", "rtype_2"}}, names); diff != "" {
+		t.Errorf("unexpected leases: %s", diff)
+	}
+	expected := []string{
+		"acquirebystate owner free leased rtype_a",
+		"acquirebystate owner free leased rtype_b",
+		// only the first resource is waited for
+		"acquire owner rtype free leased",
+	}
+	if diff := cmp.Diff(expected, calls); diff != "" {
+		t.Errorf("wrong calls to the boskos client: %s", diff)
+	}
+}
+
 func TestHeartbeatCancel(t *testing.T) {
 	ctx := context.Background()
 	var calls []string
@@ -131,3 +160,252 @@ func TestHeartbeatRetries(t *testing.T) {
 		})
 	}
 }
+
+func TestAcquireAll(t *testing.T) {
+	pool := NewMemoryPool(map[string][]string{"a": {"a0", "a1"}, "b": {"b0"}})
+	newClient := func(owner string) *client {
+		c := NewClientWithBackend(pool.Backend(owner, "org", 0), 0, time.Minute).(*client)
+		c.backoff = wait.Backoff{Duration: time.Millisecond, Factor: 2, Jitter: 1, Steps: 10, Cap: 10 * time.Millisecond}
+		return c
+	}
+	holder, waiter := newClient("holder"), newClient("waiter")
+	if _, err := holder.Acquire("b", 1, context.Background(), func() {}); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	acquired := make(chan [][]string)
+	go func() {
+		names, err := waiter.AcquireAll([]Request{{ResourceType: "b", Count: 1}, {ResourceType: "a", Count: 2}}, context.Background(), func() {})
+		if err != nil {
+			t.Errorf("failed to acquire: %v", err)
+		}
+		acquired <- names
+	}()
+	// the waiter must not hold on to resources of type `a` while `b` is leased
+	if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, time.Second, true, func(context.Context) (bool, error) {
+		metrics, err := holder.Metrics("a")
+		return err == nil && metrics.Free == 2, err
+	}); err != nil {
+		t.Fatalf("resources of type a were not released: %v", err)
+	}
+	if _, err := holder.ReleaseAll(); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	if diff := cmp.Diff([][]string{{"b0"}, {"a0", "a1"}}, <-acquired); diff != "" {
+		t.Fatalf("unexpected leases: %s", diff)
+	}
+	if diff := cmp.Diff(map[string]*lease{"a0": {}, "a1": {}, "b0": {}}, waiter.leases, cmp.AllowUnexported(lease{}), cmp.Comparer(func(_, _ context.CancelFunc) bool { return true })); diff != "" {
+		t.Fatalf("unexpected leases held by the client: %s", diff)
+	}
+}
+
+type claimRecordingBackend struct {
+	Backend
+	sync.Mutex
+	claims   map[string]sets.Set[string]
+	attempts int
+}
+
+func (b *claimRecordingBackend) Acquire(ctx context.Context, rtype string, claim Claim, wait bool) (string, error) {
+	b.Lock()
+	b.claims[rtype].Insert(claim.RequestID)
+	b.attempts++
+	b.Unlock()
+	return b.Backend.Acquire(ctx, rtype, claim, wait)
+}
+
+func TestAcquireAllKeepsRequestIDs(t *testing.T) {
+	pool := NewMemoryPool(map[string][]string{"a": {"a0"}, "b": {"b0"}})
+	holder := NewClientWithBackend(pool.Backend("holder", "org", 0), 0, time.Minute)
+	if _, err := holder.Acquire("b", 1, context.Background(), func() {}); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	var ids int
+	randId = func() string {
+		ids++
+		return strconv.Itoa(ids)
+	}
+	backend := &claimRecordingBackend{Backend: pool.Backend("waiter", "org", 0), claims: map[string]sets.Set[string]{"a": sets.New[string](), "b": sets.New[string]()}}
+	waiter := NewClientWithBackend(backend, 0, time.Minute).(*client)
+	waiter.backoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 100}
+	acquired := make(chan error)
+	go func() {
+		_, err := waiter.AcquireAll([]Request{{ResourceType: "a", Count: 1}, {ResourceType: "b", Count: 1}}, context.Background(), func() {})
+		acquired <- err
+	}()
+	// let the waiter retry a few times before the resource becomes free
+	if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, time.Second, true, func(context.Context) (bool, error) {
+		backend.Lock()
+		defer backend.Unlock()
+		return backend.attempts >= 6, nil
+	}); err != nil {
+		t.Fatalf("the waiter did not retry: %v", err)
+	}
+	if _, err := holder.ReleaseAll(); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	if err := <-acquired; err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	expected := map[string]sets.Set[string]{"a": sets.New[string]("1"), "b": sets.New[string]("2")}
+	if diff := cmp.Diff(expected, backend.claims); diff != "" {
+		t.Errorf("request IDs: actual does not match expected, diff: %s", diff)
+	}
+}
+
+func TestAcquireAllTimeout(t *testing.T) {
+	pool := NewMemoryPool(map[string][]string{"a": {"a0"}, "b": {"b0"}})
+	if _, err := pool.Backend("other", "org", 0).Acquire(context.Background(), "b", Claim{RequestID: "request"}, false); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	c := NewClientWithBackend(pool.Backend("owner", "org", 0), 0, 50*time.Millisecond).(*client)
+	c.backoff = wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 1}
+	if _, err := c.AcquireAll([]Request{{ResourceType: "a", Count: 1}, {ResourceType: "b", Count: 1}}, context.Background(), func() {}); !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a timeout after ErrNotFound, got %v", err)
+	}
+	if len(c.leases) != 0 {
+		t.Fatalf("expected no leases to be held, got %v", c.leases)
+	}
+	if metrics, err := c.Metrics("a"); err != nil {
+		t.Fatalf("failed to get metrics: %v", err)
+	} else if diff := cmp.Diff(Metrics{Free: 1}, metrics); diff != "" {
+		t.Fatalf("unexpected metrics: %s", diff)
+	}
+}
+
+func TestAcquireAllCancelledWhileBackingOff(t *testing.T) {
+	pool := NewMemoryPool(map[string][]string{"a": {"a0"}, "b": {"b0"}})
+	if _, err := pool.Backend("other", "org", 0).Acquire(context.Background(), "b", Claim{RequestID: "request"}, false); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	backend := &claimRecordingBackend{Backend: pool.Backend("owner", "org", 0), claims: map[string]sets.Set[string]{"a": sets.New[string](), "b": sets.New[string]()}}
+	c := NewClientWithBackend(backend, 0, time.Minute).(*client)
+	c.backoff = wait.Backoff{Duration: time.Hour, Factor: 1, Steps: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	acquired := make(chan error)
+	go func() {
+		_, err := c.AcquireAll([]Request{{ResourceType: "a", Count: 1}, {ResourceType: "b", Count: 1}}, ctx, func() {})
+		acquired <- err
+	}()
+	// cancel once the first attempt failed and the client backs off
+	if err := wait.PollUntilContextTimeout(context.Background(), time.Millisecond, time.Second, true, func(context.Context) (bool, error) {
+		backend.Lock()
+		defer backend.Unlock()
+		return backend.attempts >= 2, nil
+	}); err != nil {
+		t.Fatalf("the client did not attempt to acquire: %v", err)
+	}
+	cancel()
+	err := <-acquired
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancellation to be returned, got %v", err)
+	}
+	if diff := cmp.Diff("context canceled while waiting for leases, last attempt: resources not found", err.Error()); diff != "" {
+		t.Errorf("unexpected error message: %s", diff)
+	}
+	if len(c.leases) != 0 {
+		t.Fatalf("expected no leases to be held, got %v", c.leases)
+	}
+}
+
+func TestAcquireHighPriorityQueues(t *testing.T) {
+	var calls []string
+	client := NewFakeClient("owner", "url", 0, nil, &calls)
+	if _, err := client.AcquireAll([]Request{{ResourceType: "rtype", Count: 1, Priority: PriorityHigh}}, context.Background(), func() {}); err != nil {
+		t.Fatalf("failed to acquire: %v", err)
+	}
+	if diff := cmp.Diff([]string{"acquireWaitWithPriority owner rtype free leased random"}, calls); diff != "" {
+		t.Fatalf("unexpected calls: %s", diff)
+	}
+}
+
+// metricClient is a Boskos client which reports the given metric
+type metricClient struct {
+	fakeClient
+	metric common.Metric
+}
+
+func (c *metricClient) Metric(string) (common.Metric, error) {
+	return c.metric, nil
+}
+
+func TestBoskosBackendFairShare(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		org      string
+		free     int
+		owners   map[string]int
+		expected bool
+	}{{
+		name:     "the only organization takes the last resource",
+		org:      "org",
+		free:     1,
+		owners:   map[string]int{"": 1, "job@org": 3},
+		expected: true,
+	}, {
+		name:     "an organization within its share takes the last resource",
+		org:      "org",
+		free:     1,
+		owners:   map[string]int{"": 1, "job@org": 1, "job@other": 2},
+		expected: true,
+	}, {
+		name:   "an organization over its share leaves the last resource",
+		org:    "org",
+		free:   1,
+		owners: map[string]int{"": 1, "job@org": 2, "job@other": 1},
+	}, {
+		name:     "an organization over its share takes one of several free resources",
+		org:      "org",
+		free:     2,
+		owners:   map[string]int{"": 2, "job@org": 3, "job@other": 1},
+		expected: true,
+	}, {
+		name:     "owners without an organization count as one of their own",
+		org:      "org",
+		free:     1,
+		owners:   map[string]int{"": 1, "job@org": 1, "legacy-job": 1, "other-legacy-job": 1},
+		expected: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			metric := common.NewMetric("rtype")
+			metric.Current[freeState] = tc.free
+			for owner, count := range tc.owners {
+				metric.Owners[owner] = count
+				if owner != "" {
+					metric.Current[leasedState] += count
+				}
+			}
+			backend := &boskosBackend{client: &metricClient{fakeClient: fakeClient{owner: "owner", calls: &calls}, metric: metric}, org: tc.org}
+			_, err := backend.Acquire(context.Background(), "rtype", Claim{RequestID: "request"}, false)
+			if tc.expected && err != nil {
+				t.Fatalf("failed to acquire: %v", err)
+			}
+			if !tc.expected && !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBoskosOwner(t *testing.T) {
+	if owner := boskosOwner("ci-op-1234-5678", "openshift"); owner != "ci-op-1234-5678@openshift" {
+		t.Errorf("unexpected owner: %s", owner)
+	}
+	if org := boskosOwnerOrg("ci-op-1234-5678@openshift"); org != "openshift" {
+		t.Errorf("unexpected organization: %s", org)
+	}
+	if org := boskosOwnerOrg("ci-op-1234-5678"); org != "ci-op-1234-5678" {
+		t.Errorf("unexpected organization: %s", org)
+	}
+}
+
+func TestAcquireLowPriorityKeepsReserve(t *testing.T) {
+	var calls []string
+	client := NewFakeClient("owner", "url", 0, nil, &calls)
+	if _, err := client.AcquireAll([]Request{{ResourceType: "rtype", Count: 1, Priority: PriorityLow}}, context.Background(), func() {}); err == nil {
+		t.Fatal("expected acquisition to fail without free resources to spare")
+	}
+	if len(calls) != 0 {
+		t.Fatalf("expected no resource to be acquired, got calls: %v", calls)
+	}
+}
@@ -0,0 +1,121 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// configMapPollInterval is the time between attempts to acquire a resource
+// while waiting for one to become free.
+var configMapPollInterval = 10 * time.Second
+
+// configMapWaitersSuffix is the suffix of the keys which hold the requests
+// waiting for resources of a type.
+const configMapWaitersSuffix = ".waiters"
+
+// configMapStore records the state of resources in a ConfigMap.  Each key
+// holds a JSON list of the resources of a type, requests waiting for them are
+// held in a separate key.  Concurrent modifications are detected using the
+// resource version of the object.
+type configMapStore struct {
+	client ctrlruntimeclient.Client
+	key    types.NamespacedName
+}
+
+// NewConfigMapBackend creates a backend which leases resources recorded in a
+// ConfigMap on behalf of an owner, which belongs to an organization.  The
+// ConfigMap must exist and list the names of all resources, e.g.:
+//
+//	data:
+//	  aws-quota-slice: '[{"name":"us-east-1--aws-quota-slice-0"}]'
+//
+// Leases which are not renewed within `expiry` can be acquired by other
+// owners; a zero value means leases never expire.
+func NewConfigMapBackend(client ctrlruntimeclient.Client, namespace, name, owner, org string, expiry time.Duration) Backend {
+	return newStoreBackend(&configMapStore{
+		client: client,
+		key:    types.NamespacedName{Namespace: namespace, Name: name},
+	}, owner, org, expiry, 6*configMapPollInterval)
+}
+
+func (s *configMapStore) update(ctx context.Context, fn func(state map[string]*pool) (bool, error)) (<-chan struct{}, error) {
+	var fnErr error
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		fnErr = nil
+		var cm corev1.ConfigMap
+		if err := s.client.Get(ctx, s.key, &cm); err != nil {
+			return err
+		}
+		state, err := decodePools(cm.Data)
+		if err != nil {
+			return err
+		}
+		changed, err := fn(state)
+		if err != nil {
+			fnErr = err
+			return nil
+		}
+		if !changed {
+			return nil
+		}
+		if err := encodePools(state, cm.Data); err != nil {
+			return err
+		}
+		return s.client.Update(ctx, &cm)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update lease ConfigMap %s: %w", s.key, err)
+	}
+	if fnErr != nil {
+		return nil, fnErr
+	}
+	changed := make(chan struct{})
+	time.AfterFunc(configMapPollInterval, func() { close(changed) })
+	return changed, nil
+}
+
+func decodePools(data map[string]string) (map[string]*pool, error) {
+	state := map[string]*pool{}
+	for key, raw := range data {
+		if strings.HasSuffix(key, configMapWaitersSuffix) {
+			continue
+		}
+		p := &pool{}
+		if err := json.Unmarshal([]byte(raw), &p.Resources); err != nil {
+			return nil, fmt.Errorf("failed to parse resources of type %q: %w", key, err)
+		}
+		if raw, ok := data[key+configMapWaitersSuffix]; ok {
+			if err := json.Unmarshal([]byte(raw), &p.Waiters); err != nil {
+				return nil, fmt.Errorf("failed to parse requests waiting for type %q: %w", key, err)
+			}
+		}
+		state[key] = p
+	}
+	return state, nil
+}
+
+func encodePools(state map[string]*pool, data map[string]string) error {
+	for rtype, p := range state {
+		raw, err := json.Marshal(p.Resources)
+		if err != nil {
+			return fmt.Errorf("failed to serialize resources of type %q: %w", rtype, err)
+		}
+		data[rtype] = string(raw)
+		if len(p.Waiters) == 0 {
+			delete(data, rtype+configMapWaitersSuffix)
+			continue
+		}
+		if raw, err = json.Marshal(p.Waiters); err != nil {
+			return fmt.Errorf("failed to serialize requests waiting for type %q: %w", rtype, err)
+		}
+		data[rtype+configMapWaitersSuffix] = string(raw)
+	}
+	return nil
+}
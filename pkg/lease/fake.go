@@ -27,7 +27,7 @@ func NewFakeClient(owner, url string, retries int, failures map[string]error, ca
 		owner:    owner,
 		failures: failures,
 		calls:    calls,
-	}, retries, time.Duration(0))
+	}, "", retries, time.Duration(0))
 }
 
 func (c *fakeClient) addCall(call string, args ...string) error {
@@ -52,6 +52,18 @@ func (c *fakeClient) Acquire(rtype, state, dest string) (*common.Resource, error
 	return &common.Resource{Name: fmt.Sprintf("%s_%d", rtype, len(*c.calls)-1)}, err
 }
 
+func (c *fakeClient) AcquireByState(state, dest string, names []string) ([]common.Resource, error) {
+	err := c.addCall("acquirebystate", append([]string{state, dest}, names...)...)
+	if err != nil {
+		return nil, err
+	}
+	var ret []common.Resource
+	for _, name := range names {
+		ret = append(ret, common.Resource{Name: name})
+	}
+	return ret, nil
+}
+
 func (c *fakeClient) UpdateOne(name, dest string, _ *common.UserData) error {
 	return c.addCall("updateone", name, dest, strconv.Itoa(len(*c.calls)-1))
 }
@@ -64,6 +76,10 @@ func (c *fakeClient) ReleaseAll(dest string) error {
 	return c.addCall("releaseall", dest)
 }
 
+// Metric reports a single free resource, which requests with a normal priority
+// take and those with a low priority leave alone.
 func (*fakeClient) Metric(rtype string) (common.Metric, error) {
-	return common.NewMetric(rtype), nil
+	metric := common.NewMetric(rtype)
+	metric.Current[freeState] = 1
+	return metric, nil
 }
@@ -0,0 +1,51 @@
+package lease
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryPool holds the state of resources in memory.  It can be shared by
+// backends of several owners, which makes it suitable for tests.
+type MemoryPool struct {
+	sync.Mutex
+	state map[string]*pool
+	// changed is closed and replaced whenever the state is modified
+	changed chan struct{}
+}
+
+// NewMemoryPool creates a pool with the given resource names, grouped by type.
+// All resources are initially free.
+func NewMemoryPool(resources map[string][]string) *MemoryPool {
+	state := make(map[string]*pool, len(resources))
+	for rtype, names := range resources {
+		state[rtype] = &pool{}
+		for _, name := range names {
+			state[rtype].Resources = append(state[rtype].Resources, resource{Name: name})
+		}
+	}
+	return &MemoryPool{state: state, changed: make(chan struct{})}
+}
+
+// Backend creates a backend which leases resources from the pool on behalf of
+// an owner, which belongs to an organization.  Leases which are not renewed
+// within `expiry` can be acquired by other owners; a zero value means leases
+// never expire.
+func (p *MemoryPool) Backend(owner, org string, expiry time.Duration) Backend {
+	return newStoreBackend(p, owner, org, expiry, 0)
+}
+
+func (p *MemoryPool) update(_ context.Context, fn func(state map[string]*pool) (bool, error)) (<-chan struct{}, error) {
+	p.Lock()
+	defer p.Unlock()
+	changed, err := fn(p.state)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		close(p.changed)
+		p.changed = make(chan struct{})
+	}
+	return p.changed, nil
+}
@@ -0,0 +1,30 @@
+package lease
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var waitHistogram = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "lease_wait_duration_seconds",
+		Help:    "Time spent waiting for leases to be acquired, in seconds.",
+		Buckets: []float64{1, 10, 30, 60, 300, 600, 1800, 3600, 7200},
+	},
+	[]string{"type", "state"},
+)
+
+// RegisterMetrics registers the metrics of lease clients.
+func RegisterMetrics(registry prometheus.Registerer) error {
+	if err := registry.Register(waitHistogram); err != nil {
+		return fmt.Errorf("failed to register waitHistogram metric: %w", err)
+	}
+	return nil
+}
+
+// observeWait records the time spent waiting for resources of a type, either
+// until they were `acquired` or until the acquisition `failed`.
+func observeWait(rtype, state string, seconds float64) {
+	waitHistogram.WithLabelValues(rtype, state).Observe(seconds)
+}
@@ -0,0 +1,249 @@
+package lease
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// resource is the state of a single leasable resource as recorded by the
+// in-cluster and in-memory backends.
+type resource struct {
+	Name       string    `json:"name"`
+	Owner      string    `json:"owner,omitempty"`
+	Org        string    `json:"org,omitempty"`
+	RequestID  string    `json:"requestID,omitempty"`
+	LastUpdate time.Time `json:"lastUpdate,omitempty"`
+}
+
+// waiter is a request waiting for a resource to become free.
+type waiter struct {
+	RequestID string    `json:"requestID"`
+	Owner     string    `json:"owner"`
+	Org       string    `json:"org,omitempty"`
+	Priority  Priority  `json:"priority,omitempty"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// pool is the state of all resources of a type.
+type pool struct {
+	Resources []resource
+	Waiters   []waiter
+}
+
+// resourceStore persists the state of all resources, grouped by type.
+type resourceStore interface {
+	// update applies `fn` to the current state atomically and persists the
+	// result if `fn` reports a change.  The channel returned is closed when
+	// the state may have changed afterwards.
+	update(ctx context.Context, fn func(state map[string]*pool) (bool, error)) (<-chan struct{}, error)
+}
+
+// storeBackend implements the semantics of a backend on top of a store.
+//
+// Waiting requests are recorded in the store, so that requests of all owners
+// can be ordered: a request is not served while one with a higher priority is
+// waiting for the same type.  When owners of several organizations wait for
+// resources with the same priority, each organization is limited to a fair
+// share of the resources of the type.
+type storeBackend struct {
+	store  resourceStore
+	owner  string
+	org    string
+	expiry time.Duration
+	// waiterExpiry is the time after which a waiting request which was not
+	// renewed is ignored; zero means waiting requests never expire
+	waiterExpiry time.Duration
+	now          func() time.Time
+}
+
+func newStoreBackend(store resourceStore, owner, org string, expiry, waiterExpiry time.Duration) *storeBackend {
+	return &storeBackend{store: store, owner: owner, org: org, expiry: expiry, waiterExpiry: waiterExpiry, now: time.Now}
+}
+
+// free determines whether a resource can be leased, either because it is not
+// leased at all or because its lease was not renewed in time.
+func (b *storeBackend) free(r resource) bool {
+	return r.Owner == "" || (b.expiry > 0 && b.now().Sub(r.LastUpdate) > b.expiry)
+}
+
+func (b *storeBackend) self(w waiter, claim Claim) bool {
+	return w.Owner == b.owner && w.RequestID == claim.RequestID
+}
+
+// eligible determines whether a request may be served now, considering the
+// other requests waiting for resources of the type.
+func (b *storeBackend) eligible(p *pool, claim Claim) bool {
+	contenders := sets.New[string](b.org)
+	for _, w := range p.Waiters {
+		if b.self(w, claim) {
+			continue
+		}
+		if w.Priority > claim.Priority {
+			return false
+		}
+		if w.Priority == claim.Priority {
+			contenders.Insert(w.Org)
+		}
+	}
+	if contenders.Len() == 1 {
+		return true
+	}
+	held := map[string]int{}
+	for _, r := range p.Resources {
+		if !b.free(r) {
+			held[r.Org]++
+			contenders.Insert(r.Org)
+		}
+	}
+	share := (len(p.Resources) + contenders.Len() - 1) / contenders.Len()
+	return held[b.org] < share
+}
+
+// wait records a waiting request, renewing it if it is about to expire.
+// It reports whether the state was changed.
+func (b *storeBackend) wait(p *pool, claim Claim) bool {
+	for i, w := range p.Waiters {
+		if !b.self(w, claim) {
+			continue
+		}
+		if b.waiterExpiry == 0 || b.now().Sub(w.LastSeen) < b.waiterExpiry/2 {
+			return false
+		}
+		p.Waiters[i].LastSeen = b.now()
+		return true
+	}
+	p.Waiters = append(p.Waiters, waiter{RequestID: claim.RequestID, Owner: b.owner, Org: b.org, Priority: claim.Priority, LastSeen: b.now()})
+	return true
+}
+
+// stopWaiting removes a waiting request, if set, and any which expired.  It
+// reports whether the state was changed.
+func (b *storeBackend) stopWaiting(p *pool, claim *Claim) bool {
+	var waiters []waiter
+	for _, w := range p.Waiters {
+		if (claim != nil && b.self(w, *claim)) || (b.waiterExpiry > 0 && b.now().Sub(w.LastSeen) > b.waiterExpiry) {
+			continue
+		}
+		waiters = append(waiters, w)
+	}
+	changed := len(waiters) != len(p.Waiters)
+	p.Waiters = waiters
+	return changed
+}
+
+func (b *storeBackend) Acquire(ctx context.Context, rtype string, claim Claim, wait bool) (string, error) {
+	wait = wait && claim.Name == ""
+	if wait {
+		defer func() {
+			if _, err := b.store.update(context.Background(), func(state map[string]*pool) (bool, error) {
+				p, ok := state[rtype]
+				return ok && b.stopWaiting(p, &claim), nil
+			}); err != nil {
+				logrus.WithError(err).Warnf("Failed to remove waiting request for %s", rtype)
+			}
+		}()
+	}
+	for {
+		var name string
+		changed, err := b.store.update(ctx, func(state map[string]*pool) (bool, error) {
+			p, ok := state[rtype]
+			if !ok {
+				return false, nil
+			}
+			changed := b.stopWaiting(p, nil)
+			if claim.Name != "" {
+				for i, r := range p.Resources {
+					if r.Name == claim.Name && b.free(r) {
+						name = r.Name
+						p.Resources[i] = resource{Name: r.Name, Owner: b.owner, Org: b.org, LastUpdate: b.now()}
+						return true, nil
+					}
+				}
+				return changed, nil
+			}
+			if b.eligible(p, claim) {
+				for i, r := range p.Resources {
+					if !b.free(r) {
+						continue
+					}
+					name = r.Name
+					p.Resources[i] = resource{Name: r.Name, Owner: b.owner, Org: b.org, RequestID: claim.RequestID, LastUpdate: b.now()}
+					b.stopWaiting(p, &claim)
+					return true, nil
+				}
+			}
+			if wait {
+				changed = b.wait(p, claim) || changed
+			}
+			return changed, nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if name != "" {
+			return name, nil
+		}
+		if !wait {
+			return "", ErrNotFound
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// modify applies `fn` to the resource with a given name leased by the owner.
+func (b *storeBackend) modify(name string, fn func(r *resource)) error {
+	_, err := b.store.update(context.Background(), func(state map[string]*pool) (bool, error) {
+		for _, p := range state {
+			for i := range p.Resources {
+				if p.Resources[i].Name != name {
+					continue
+				}
+				if p.Resources[i].Owner != b.owner {
+					return false, fmt.Errorf("resource %q is not leased by %q", name, b.owner)
+				}
+				fn(&p.Resources[i])
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("resource %q: %w", name, ErrNotFound)
+	})
+	return err
+}
+
+func (b *storeBackend) Heartbeat(name string) error {
+	return b.modify(name, func(r *resource) {
+		r.LastUpdate = b.now()
+	})
+}
+
+func (b *storeBackend) Release(name string) error {
+	return b.modify(name, func(r *resource) {
+		*r = resource{Name: r.Name, LastUpdate: b.now()}
+	})
+}
+
+func (b *storeBackend) Metrics(rtype string) (Metrics, error) {
+	var ret Metrics
+	_, err := b.store.update(context.Background(), func(state map[string]*pool) (bool, error) {
+		if p, ok := state[rtype]; ok {
+			for _, r := range p.Resources {
+				if b.free(r) {
+					ret.Free++
+				} else {
+					ret.Leased++
+				}
+			}
+		}
+		return false, nil
+	})
+	return ret, err
+}
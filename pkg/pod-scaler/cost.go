@@ -0,0 +1,537 @@
+package pod_scaler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+// UsageSampleInterval is the resolution at which usage data is queried from
+// Prometheus, so every value recorded in a histogram stands for this long.
+const UsageSampleInterval = time.Minute
+
+// JobRunFilename is the name of the artifact in which ci-operator records
+// the tests it ran, for cost accounting.
+const JobRunFilename = "ci-operator-job-run.json"
+
+// monthFormat formats the months costs are accounted for.
+const monthFormat = "2006-01"
+
+const bytesPerGiB = 1 << 30
+
+// Prices configures what resources cost per hour.
+type Prices struct {
+	// CPUCoreHour is the price of using one CPU core for an hour.
+	CPUCoreHour float64 `json:"cpu_core_hour"`
+	// MemoryGiBHour is the price of using one GiB of memory for an hour.
+	MemoryGiBHour float64 `json:"memory_gib_hour"`
+	// ClusterProfiles are the prices of running a test against a cluster
+	// of the profile for an hour, by the name of the profile.
+	ClusterProfiles map[string]float64 `json:"cluster_profiles,omitempty"`
+	// Leases are the prices of holding one lease of a type for an hour, by
+	// the resource type of the lease.
+	Leases map[string]float64 `json:"leases,omitempty"`
+}
+
+// LoadPrices reads the price table from a YAML file.
+func LoadPrices(path string) (*Prices, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price file: %w", err)
+	}
+	prices := &Prices{}
+	if err := yaml.UnmarshalStrict(raw, prices); err != nil {
+		return nil, fmt.Errorf("failed to parse price file: %w", err)
+	}
+	if err := prices.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid price file: %w", err)
+	}
+	return prices, nil
+}
+
+// Validate ensures that no price is negative.
+func (p *Prices) Validate() error {
+	var errs []error
+	validate := func(field string, price float64) {
+		if price < 0 || math.IsNaN(price) {
+			errs = append(errs, fmt.Errorf("%s: price must not be negative, got %v", field, price))
+		}
+	}
+	validate("cpu_core_hour", p.CPUCoreHour)
+	validate("memory_gib_hour", p.MemoryGiBHour)
+	for _, profile := range sortedKeys(p.ClusterProfiles) {
+		validate(fmt.Sprintf("cluster_profiles.%s", profile), p.ClusterProfiles[profile])
+	}
+	for _, lease := range sortedKeys(p.Leases) {
+		validate(fmt.Sprintf("leases.%s", lease), p.Leases[lease])
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// JobRun records what an execution of ci-operator ran.
+type JobRun struct {
+	// Metadata identifies the ci-operator configuration which ran.
+	api.Metadata `json:"metadata"`
+	// Job is the name of the job which executed ci-operator.
+	Job string `json:"job,omitempty"`
+	// Started is when ci-operator started.
+	Started time.Time `json:"started"`
+	// Tests are the multi-stage tests which ran.
+	Tests []TestRun `json:"tests,omitempty"`
+}
+
+// TestRun records how long a multi-stage test ran and what it held meanwhile.
+type TestRun struct {
+	// Target is the name of the test.
+	Target string `json:"target"`
+	// ClusterProfile is the profile of the cluster the test ran against.
+	ClusterProfile string `json:"cluster_profile,omitempty"`
+	// Leases are the number of leases the test held, by resource type. The
+	// lease for the cluster profile is accounted for by the profile.
+	Leases map[string]int `json:"leases,omitempty"`
+	// Duration is how long the test held its cluster and leases, from when
+	// its first step started to when its last step finished, so that the time
+	// spent waiting to acquire the leases is not accounted for.
+	Duration time.Duration `json:"duration"`
+	// Steps are how long each step of the test ran, by the name of the step.
+	Steps map[string]time.Duration `json:"steps,omitempty"`
+}
+
+// attemptSuffix is appended to the names of the Pods of retried steps.
+var attemptSuffix = regexp.MustCompile(`-attempt-\d+$`)
+
+// TestRunFor determines how long the steps of a multi-stage test ran from the
+// details of the test in the execution graph.
+func TestRunFor(test *api.TestStepConfiguration, details api.CIOperatorStepDetails) TestRun {
+	run := TestRun{Target: test.As, Duration: heldFor(details)}
+	if literal := test.MultiStageTestConfigurationLiteral; literal != nil {
+		run.ClusterProfile = string(literal.ClusterProfile)
+		for _, step := range append(literal.Pre, append(literal.Test, literal.Post...)...) {
+			for _, lease := range step.Leases {
+				run.addLease(lease)
+			}
+		}
+		for _, lease := range literal.Leases {
+			run.addLease(lease)
+		}
+	}
+	for _, subStep := range details.Substeps {
+		if subStep.Duration == nil {
+			continue
+		}
+		name := attemptSuffix.ReplaceAllString(strings.TrimPrefix(subStep.StepName, test.As+"-"), "")
+		if run.Steps == nil {
+			run.Steps = map[string]time.Duration{}
+		}
+		run.Steps[name] += *subStep.Duration
+	}
+	return run
+}
+
+// heldFor determines how long a test held its cluster and leases. The duration
+// of the test includes the wait for its leases, so the time its steps ran is
+// used when they recorded it.
+func heldFor(details api.CIOperatorStepDetails) time.Duration {
+	var started, finished time.Time
+	for _, subStep := range details.Substeps {
+		if subStep.StartedAt == nil || subStep.FinishedAt == nil {
+			continue
+		}
+		if started.IsZero() || subStep.StartedAt.Before(started) {
+			started = *subStep.StartedAt
+		}
+		if subStep.FinishedAt.After(finished) {
+			finished = *subStep.FinishedAt
+		}
+	}
+	if !started.IsZero() {
+		return finished.Sub(started)
+	}
+	if details.Duration != nil {
+		return *details.Duration
+	}
+	return 0
+}
+
+func (r *TestRun) addLease(lease api.StepLease) {
+	if r.Leases == nil {
+		r.Leases = map[string]int{}
+	}
+	count := int(lease.Count)
+	if count == 0 {
+		count = 1
+	}
+	r.Leases[lease.ResourceType] += count
+}
+
+// CostGrouping determines how finely costs are broken down.
+type CostGrouping string
+
+const (
+	CostGroupingOrg  CostGrouping = "org"
+	CostGroupingRepo CostGrouping = "repo"
+	CostGroupingJob  CostGrouping = "job"
+	CostGroupingStep CostGrouping = "step"
+)
+
+// CostGroupings are all the supported groupings, from the coarsest.
+var CostGroupings = []CostGrouping{CostGroupingOrg, CostGroupingRepo, CostGroupingJob, CostGroupingStep}
+
+// CostKey identifies what costs are accounted to. Fields finer than the
+// grouping of a report are empty.
+type CostKey struct {
+	Org     string `json:"org,omitempty"`
+	Repo    string `json:"repo,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	Variant string `json:"variant,omitempty"`
+	Target  string `json:"target,omitempty"`
+	// Job is the name of the job which ran the target, when it is known.
+	Job  string `json:"job,omitempty"`
+	Step string `json:"step,omitempty"`
+}
+
+func (k CostKey) groupedBy(grouping CostGrouping) CostKey {
+	switch grouping {
+	case CostGroupingOrg:
+		return CostKey{Org: k.Org}
+	case CostGroupingRepo:
+		return CostKey{Org: k.Org, Repo: k.Repo}
+	case CostGroupingJob:
+		if k.Job != "" {
+			return CostKey{Org: k.Org, Repo: k.Repo, Job: k.Job}
+		}
+		// the target was never seen running in a job
+		k.Step = ""
+		return k
+	default:
+		return k
+	}
+}
+
+// target identifies the test the costs are accounted to.
+func (k CostKey) target() CostKey {
+	return CostKey{Org: k.Org, Repo: k.Repo, Branch: k.Branch, Variant: k.Variant, Target: k.Target}
+}
+
+// CostFilter selects the costs to report. Empty fields match any value.
+type CostFilter struct {
+	Org  string
+	Repo string
+}
+
+func (f CostFilter) matches(key CostKey) bool {
+	return (f.Org == "" || f.Org == key.Org) && (f.Repo == "" || f.Repo == key.Repo)
+}
+
+// usage is what was used in a month.
+type usage struct {
+	CPUCoreHours   float64
+	MemoryGiBHours float64
+	// DurationHours is how long steps ran.
+	DurationHours float64
+	// ClusterProfileHours are how long tests ran against each cluster profile.
+	ClusterProfileHours map[string]float64
+	// LeaseHours are how long leases were held, by type, times their number.
+	LeaseHours map[string]float64
+}
+
+func (u *usage) add(other *usage) {
+	u.CPUCoreHours += other.CPUCoreHours
+	u.MemoryGiBHours += other.MemoryGiBHours
+	u.DurationHours += other.DurationHours
+	u.ClusterProfileHours = addHours(u.ClusterProfileHours, other.ClusterProfileHours)
+	u.LeaseHours = addHours(u.LeaseHours, other.LeaseHours)
+}
+
+func addHours(into, from map[string]float64) map[string]float64 {
+	if len(from) == 0 {
+		return into
+	}
+	if into == nil {
+		into = map[string]float64{}
+	}
+	for name, hours := range from {
+		into[name] += hours
+	}
+	return into
+}
+
+type monthlyKey struct {
+	CostKey
+	month string
+}
+
+type usageByKey map[monthlyKey]*usage
+
+func (u usageByKey) get(key CostKey, month string) *usage {
+	k := monthlyKey{CostKey: key, month: month}
+	if u[k] == nil {
+		u[k] = &usage{}
+	}
+	return u[k]
+}
+
+// Costs accounts for what CI workloads cost from the resources they used and
+// the tests ci-operator ran. It is safe for concurrent use.
+type Costs struct {
+	lock   sync.RWMutex
+	prices Prices
+	// usage holds the resource usage from each query, so that new data for a
+	// query replaces what was recorded from it before
+	usage map[string]usageByKey
+	runs  usageByKey
+	// jobs are the names of the jobs which last ran each target, as the
+	// usage of resources does not record the job
+	jobs map[CostKey]string
+}
+
+// NewCosts creates an empty cost accounting for the prices.
+func NewCosts(prices Prices) *Costs {
+	return &Costs{prices: prices, usage: map[string]usageByKey{}, runs: usageByKey{}, jobs: map[CostKey]string{}}
+}
+
+// SetUsage records the usage of the resource in the data, replacing what was
+// recorded from the same query before. Each value in the data is the usage
+// over one UsageSampleInterval.
+func (c *Costs) SetUsage(data *CachedQuery, resource corev1.ResourceName) {
+	byKey := usageByKey{}
+	for meta, fingerprintTimes := range data.DataByMetaData {
+		key := costKeyFor(meta)
+		for _, fingerprintTime := range fingerprintTimes {
+			histogram, recorded := data.Data[fingerprintTime.Fingerprint]
+			if !recorded {
+				continue
+			}
+			hours := histogram.Histogram().ApproxSum() * UsageSampleInterval.Hours()
+			u := byKey.get(key, fingerprintTime.Added.UTC().Format(monthFormat))
+			switch resource {
+			case corev1.ResourceCPU:
+				u.CPUCoreHours += hours
+			case corev1.ResourceMemory:
+				u.MemoryGiBHours += hours / bytesPerGiB
+			}
+		}
+	}
+	c.lock.Lock()
+	c.usage[data.Query+"/"+string(resource)] = byKey
+	c.lock.Unlock()
+}
+
+func costKeyFor(meta FullMetadata) CostKey {
+	step := meta.Step
+	if step == "" {
+		step = meta.Pod
+	}
+	if step == "" {
+		step = meta.Container
+	}
+	return CostKey{
+		Org:     meta.Metadata.Org,
+		Repo:    meta.Metadata.Repo,
+		Branch:  meta.Metadata.Branch,
+		Variant: meta.Metadata.Variant,
+		Target:  meta.Target,
+		Step:    step,
+	}
+}
+
+// SetJobRuns records the tests ci-operator ran, replacing the runs recorded
+// before. Clusters and leases are accounted to the tests holding them, while
+// the durations of steps are accounted to the steps. The resources a target
+// used are accounted to the job which ran it last.
+func (c *Costs) SetJobRuns(runs []JobRun) {
+	byKey := usageByKey{}
+	jobs, started := map[CostKey]string{}, map[CostKey]time.Time{}
+	for _, run := range runs {
+		month := run.Started.UTC().Format(monthFormat)
+		for _, test := range run.Tests {
+			key := CostKey{Org: run.Org, Repo: run.Repo, Branch: run.Branch, Variant: run.Variant, Target: test.Target, Job: run.Job, Step: test.Target}
+			if target := key.target(); run.Job != "" && !run.Started.Before(started[target]) {
+				jobs[target], started[target] = run.Job, run.Started
+			}
+			hours := test.Duration.Hours()
+			u := byKey.get(key, month)
+			if test.ClusterProfile != "" {
+				u.ClusterProfileHours = addHours(u.ClusterProfileHours, map[string]float64{test.ClusterProfile: hours})
+			}
+			for lease, count := range test.Leases {
+				u.LeaseHours = addHours(u.LeaseHours, map[string]float64{lease: hours * float64(count)})
+			}
+			for step, duration := range test.Steps {
+				key.Step = step
+				byKey.get(key, month).DurationHours += duration.Hours()
+			}
+		}
+	}
+	c.lock.Lock()
+	c.runs, c.jobs = byKey, jobs
+	c.lock.Unlock()
+}
+
+// CostEntry is what was used and what it cost.
+type CostEntry struct {
+	CostKey `json:",inline"`
+	// Month is set for entries which only account for one month.
+	Month string `json:"month,omitempty"`
+
+	CPUCoreHours   float64 `json:"cpu_core_hours"`
+	MemoryGiBHours float64 `json:"memory_gib_hours"`
+	DurationHours  float64 `json:"duration_hours"`
+
+	ComputeCost        float64 `json:"compute_cost"`
+	ClusterProfileCost float64 `json:"cluster_profile_cost"`
+	LeaseCost          float64 `json:"lease_cost"`
+	TotalCost          float64 `json:"total_cost"`
+}
+
+func (p Prices) entryFor(key CostKey, month string, u *usage) CostEntry {
+	entry := CostEntry{
+		CostKey:        key,
+		Month:          month,
+		CPUCoreHours:   u.CPUCoreHours,
+		MemoryGiBHours: u.MemoryGiBHours,
+		DurationHours:  u.DurationHours,
+		ComputeCost:    u.CPUCoreHours*p.CPUCoreHour + u.MemoryGiBHours*p.MemoryGiBHour,
+	}
+	for profile, hours := range u.ClusterProfileHours {
+		entry.ClusterProfileCost += hours * p.ClusterProfiles[profile]
+	}
+	for lease, hours := range u.LeaseHours {
+		entry.LeaseCost += hours * p.Leases[lease]
+	}
+	entry.TotalCost = entry.ComputeCost + entry.ClusterProfileCost + entry.LeaseCost
+	return entry
+}
+
+// MonthlyCost is the total cost of a month.
+type MonthlyCost struct {
+	Month     string  `json:"month"`
+	TotalCost float64 `json:"total_cost"`
+}
+
+// CostReport breaks costs down by the grouping.
+type CostReport struct {
+	Grouping CostGrouping `json:"grouping"`
+	// Entries are the costs over all months, from the most expensive.
+	Entries []CostEntry `json:"entries"`
+	// Trend is the total cost of every month, in order.
+	Trend []MonthlyCost `json:"trend"`
+	// TopOffenders are the most expensive entries of the latest month.
+	TopOffenders []CostEntry `json:"top_offenders"`
+}
+
+// Report breaks the costs matching the filter down by the grouping, listing
+// the top most expensive entries of the latest month as offenders.
+func (c *Costs) Report(grouping CostGrouping, filter CostFilter, top int) *CostReport {
+	grouped := usageByKey{}
+	overall := usageByKey{}
+	add := func(byKey usageByKey) {
+		for key, u := range byKey {
+			if !filter.matches(key.CostKey) {
+				continue
+			}
+			if key.Job == "" {
+				key.Job = c.jobs[key.target()]
+			}
+			groupedKey := key.CostKey.groupedBy(grouping)
+			grouped.get(groupedKey, key.month).add(u)
+			overall.get(groupedKey, "").add(u)
+		}
+	}
+	c.lock.RLock()
+	for _, query := range sortedKeys(c.usage) {
+		add(c.usage[query])
+	}
+	add(c.runs)
+	prices := c.prices
+	c.lock.RUnlock()
+
+	report := &CostReport{Grouping: grouping, Entries: []CostEntry{}, Trend: []MonthlyCost{}, TopOffenders: []CostEntry{}}
+	for key, u := range overall {
+		report.Entries = append(report.Entries, prices.entryFor(key.CostKey, "", u))
+	}
+	sortByCost(report.Entries)
+
+	totals := map[string]float64{}
+	var latest []CostEntry
+	var latestMonth string
+	for key, u := range grouped {
+		entry := prices.entryFor(key.CostKey, key.month, u)
+		totals[key.month] += entry.TotalCost
+		switch {
+		case key.month > latestMonth:
+			latestMonth, latest = key.month, []CostEntry{entry}
+		case key.month == latestMonth:
+			latest = append(latest, entry)
+		}
+	}
+	for _, month := range sortedKeys(totals) {
+		report.Trend = append(report.Trend, MonthlyCost{Month: month, TotalCost: totals[month]})
+	}
+	sortByCost(latest)
+	if len(latest) > top {
+		latest = latest[:top]
+	}
+	report.TopOffenders = append(report.TopOffenders, latest...)
+	return report
+}
+
+func sortByCost(entries []CostEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].TotalCost != entries[j].TotalCost {
+			return entries[i].TotalCost > entries[j].TotalCost
+		}
+		return entries[i].CostKey.String() < entries[j].CostKey.String()
+	})
+}
+
+func (k CostKey) String() string {
+	return strings.Join([]string{k.Org, k.Repo, k.Branch, k.Variant, k.Target, k.Job, k.Step}, "/")
+}
+
+var costCSVHeader = []string{"org", "repo", "branch", "variant", "target", "job", "step", "cpu_core_hours", "memory_gib_hours", "duration_hours", "compute_cost", "cluster_profile_cost", "lease_cost", "total_cost"}
+
+// WriteCSV writes the entries of the report as CSV.
+func (r *CostReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(costCSVHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 4, 64)
+	}
+	for _, entry := range r.Entries {
+		if err := writer.Write([]string{
+			entry.Org, entry.Repo, entry.Branch, entry.Variant, entry.Target, entry.Job, entry.Step,
+			format(entry.CPUCoreHours), format(entry.MemoryGiBHours), format(entry.DurationHours),
+			format(entry.ComputeCost), format(entry.ClusterProfileCost), format(entry.LeaseCost), format(entry.TotalCost),
+		}); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
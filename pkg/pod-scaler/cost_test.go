@@ -0,0 +1,271 @@
+package pod_scaler
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openhistogram/circonusllhist"
+	"github.com/prometheus/common/model"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func TestLoadPrices(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expected      *Prices
+		expectedError string
+	}{{
+		name:     "valid prices",
+		content:  "cpu_core_hour: 0.04\nmemory_gib_hour: 0.005\ncluster_profiles:\n  aws: 2.5\nleases:\n  aws-quota-slice: 0.1\n",
+		expected: &Prices{CPUCoreHour: 0.04, MemoryGiBHour: 0.005, ClusterProfiles: map[string]float64{"aws": 2.5}, Leases: map[string]float64{"aws-quota-slice": 0.1}},
+	}, {
+		name:          "unknown field",
+		content:       "cpu_hour: 0.04\n",
+		expectedError: `failed to parse price file: error unmarshaling JSON: while decoding JSON: json: unknown field "cpu_hour"`,
+	}, {
+		name:          "negative prices",
+		content:       "cpu_core_hour: -1\nleases:\n  aws-quota-slice: -0.1\n",
+		expectedError: "invalid price file: [cpu_core_hour: price must not be negative, got -1, leases.aws-quota-slice: price must not be negative, got -0.1]",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "prices.yaml")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("failed to write prices: %v", err)
+			}
+			prices, err := LoadPrices(path)
+			var actualError string
+			if err != nil {
+				actualError = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedError, actualError); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, prices); diff != "" {
+				t.Errorf("unexpected prices: %s", diff)
+			}
+		})
+	}
+}
+
+func duration(d time.Duration) *time.Duration {
+	return &d
+}
+
+func timestamp(t time.Time) *time.Time {
+	return &t
+}
+
+func TestTestRunFor(t *testing.T) {
+	test := &api.TestStepConfiguration{
+		As: "e2e",
+		MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+			ClusterProfile: api.ClusterProfileAWS,
+			Pre:            []api.LiteralTestStep{{As: "install", Leases: []api.StepLease{{ResourceType: "ip-pool", Count: 2}}}},
+			Test:           []api.LiteralTestStep{{As: "test"}},
+			Leases:         []api.StepLease{{ResourceType: "ip-pool"}, {ResourceType: "gpu"}},
+		},
+	}
+	// the test waited half an hour for its leases before its steps started
+	leased := time.Date(2024, 2, 1, 0, 30, 0, 0, time.UTC)
+	details := api.CIOperatorStepDetails{
+		CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "e2e", Duration: duration(2*time.Hour + 30*time.Minute)},
+		Substeps: []api.CIOperatorStepDetailInfo{
+			{StepName: "e2e-install", StartedAt: timestamp(leased), FinishedAt: timestamp(leased.Add(time.Hour)), Duration: duration(time.Hour)},
+			{StepName: "e2e-test", StartedAt: timestamp(leased.Add(time.Hour)), FinishedAt: timestamp(leased.Add(80 * time.Minute)), Duration: duration(20 * time.Minute)},
+			{StepName: "e2e-test-attempt-2", StartedAt: timestamp(leased.Add(80 * time.Minute)), FinishedAt: timestamp(leased.Add(110 * time.Minute)), Duration: duration(30 * time.Minute)},
+			{StepName: "e2e-gather", StartedAt: timestamp(leased.Add(110 * time.Minute)), FinishedAt: timestamp(leased.Add(2 * time.Hour))},
+		},
+	}
+	expected := TestRun{
+		Target:         "e2e",
+		ClusterProfile: "aws",
+		Leases:         map[string]int{"ip-pool": 3, "gpu": 1},
+		Duration:       2 * time.Hour,
+		Steps:          map[string]time.Duration{"install": time.Hour, "test": 50 * time.Minute},
+	}
+	if diff := cmp.Diff(expected, TestRunFor(test, details)); diff != "" {
+		t.Errorf("unexpected test run: %s", diff)
+	}
+
+	// without the times of the steps, the duration of the test is all there is
+	details = api.CIOperatorStepDetails{CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "e2e", Duration: duration(time.Hour)}}
+	if actual := TestRunFor(test, details).Duration; actual != time.Hour {
+		t.Errorf("expected the duration of the test, got %s", actual)
+	}
+}
+
+type usageSample struct {
+	meta   FullMetadata
+	added  time.Time
+	values []float64
+}
+
+// usageQuery records one histogram per sample, each value standing for one
+// UsageSampleInterval of usage.
+func usageQuery(query string, samples ...usageSample) *CachedQuery {
+	data := &CachedQuery{
+		Query:          query,
+		Data:           map[model.Fingerprint]*circonusllhist.HistogramWithoutLookups{},
+		DataByMetaData: map[FullMetadata][]FingerprintTime{},
+	}
+	for i, sample := range samples {
+		fingerprint := model.Fingerprint(i)
+		histogram := circonusllhist.New(circonusllhist.NoLookup())
+		for _, value := range sample.values {
+			if err := histogram.RecordValue(value); err != nil {
+				panic(err)
+			}
+		}
+		data.Data[fingerprint] = circonusllhist.NewHistogramWithoutLookups(histogram)
+		data.DataByMetaData[sample.meta] = append(data.DataByMetaData[sample.meta], FingerprintTime{Fingerprint: fingerprint, Added: sample.added})
+	}
+	return data
+}
+
+func repeated(value float64, times int) []float64 {
+	values := make([]float64, times)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+func TestCostsReport(t *testing.T) {
+	january := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+	meta := func(org, repo, target, step string) FullMetadata {
+		return FullMetadata{Metadata: api.Metadata{Org: org, Repo: repo, Branch: "master"}, Target: target, Step: step, Pod: target + "-" + step, Container: "test"}
+	}
+	costs := NewCosts(Prices{
+		CPUCoreHour:     1,
+		MemoryGiBHour:   0.5,
+		ClusterProfiles: map[string]float64{"aws": 10},
+		Leases:          map[string]float64{"ip-pool": 1},
+	})
+	// replaced by the update of the same query below
+	costs.SetUsage(usageQuery("cpu",
+		usageSample{meta: meta("org", "repo", "unit", "test"), added: january, values: repeated(10, 60)},
+	), corev1.ResourceCPU)
+	// an hour of two cores in both months for the unit tests, and an hour of
+	// a core in February for the installation of the e2e test
+	costs.SetUsage(usageQuery("cpu",
+		usageSample{meta: meta("org", "repo", "unit", "test"), added: january, values: repeated(2, 60)},
+		usageSample{meta: meta("org", "repo", "unit", "test"), added: february, values: repeated(2, 60)},
+		usageSample{meta: meta("org", "repo", "e2e", "install"), added: february, values: repeated(1, 60)},
+		usageSample{meta: meta("other", "repo", "lint", "check"), added: february, values: repeated(1, 30)},
+	), corev1.ResourceCPU)
+	costs.SetUsage(usageQuery("memory",
+		usageSample{meta: meta("org", "repo", "unit", "test"), added: february, values: repeated(4*bytesPerGiB, 60)},
+	), corev1.ResourceMemory)
+	costs.SetJobRuns([]JobRun{{
+		Metadata: api.Metadata{Org: "org", Repo: "repo", Branch: "master"},
+		Job:      "pull-ci-org-repo-master-e2e",
+		Started:  february,
+		Tests: []TestRun{{
+			Target:         "e2e",
+			ClusterProfile: "aws",
+			Leases:         map[string]int{"ip-pool": 2},
+			Duration:       2 * time.Hour,
+			Steps:          map[string]time.Duration{"install": time.Hour},
+		}},
+	}})
+
+	approximately := cmpopts.EquateApprox(0.06, 0)
+	for _, tc := range []struct {
+		name     string
+		grouping CostGrouping
+		filter   CostFilter
+		top      int
+		expected *CostReport
+	}{{
+		name:     "by org",
+		grouping: CostGroupingOrg,
+		top:      1,
+		expected: &CostReport{
+			Grouping: CostGroupingOrg,
+			Entries: []CostEntry{
+				{CostKey: CostKey{Org: "org"}, CPUCoreHours: 5, MemoryGiBHours: 4, DurationHours: 1, ComputeCost: 7, ClusterProfileCost: 20, LeaseCost: 4, TotalCost: 31},
+				{CostKey: CostKey{Org: "other"}, CPUCoreHours: 0.5, ComputeCost: 0.5, TotalCost: 0.5},
+			},
+			Trend: []MonthlyCost{{Month: "2024-01", TotalCost: 2}, {Month: "2024-02", TotalCost: 29.5}},
+			TopOffenders: []CostEntry{
+				{CostKey: CostKey{Org: "org"}, Month: "2024-02", CPUCoreHours: 3, MemoryGiBHours: 4, DurationHours: 1, ComputeCost: 5, ClusterProfileCost: 20, LeaseCost: 4, TotalCost: 29},
+			},
+		},
+	}, {
+		name:     "by step of a repo",
+		grouping: CostGroupingStep,
+		filter:   CostFilter{Org: "org", Repo: "repo"},
+		top:      5,
+		expected: &CostReport{
+			Grouping: CostGroupingStep,
+			Entries: []CostEntry{
+				{CostKey: CostKey{Org: "org", Repo: "repo", Branch: "master", Target: "e2e", Job: "pull-ci-org-repo-master-e2e", Step: "e2e"}, ClusterProfileCost: 20, LeaseCost: 4, TotalCost: 24},
+				{CostKey: CostKey{Org: "org", Repo: "repo", Branch: "master", Target: "unit", Step: "test"}, CPUCoreHours: 4, MemoryGiBHours: 4, ComputeCost: 6, TotalCost: 6},
+				{CostKey: CostKey{Org: "org", Repo: "repo", Branch: "master", Target: "e2e", Job: "pull-ci-org-repo-master-e2e", Step: "install"}, CPUCoreHours: 1, DurationHours: 1, ComputeCost: 1, TotalCost: 1},
+			},
+			Trend: []MonthlyCost{{Month: "2024-01", TotalCost: 2}, {Month: "2024-02", TotalCost: 29}},
+			TopOffenders: []CostEntry{
+				{CostKey: CostKey{Org: "org", Repo: "repo", Branch: "master", Target: "e2e", Job: "pull-ci-org-repo-master-e2e", Step: "e2e"}, Month: "2024-02", ClusterProfileCost: 20, LeaseCost: 4, TotalCost: 24},
+				{CostKey: CostKey{Org: "org", Repo: "repo", Branch: "master", Target: "unit", Step: "test"}, Month: "2024-02", CPUCoreHours: 2, MemoryGiBHours: 4, ComputeCost: 4, TotalCost: 4},
+				{CostKey: CostKey{Org: "org", Repo: "repo", Branch: "master", Target: "e2e", Job: "pull-ci-org-repo-master-e2e", Step: "install"}, Month: "2024-02", CPUCoreHours: 1, DurationHours: 1, ComputeCost: 1, TotalCost: 1},
+			},
+		},
+	}, {
+		name:     "by job of a repo",
+		grouping: CostGroupingJob,
+		filter:   CostFilter{Org: "org", Repo: "repo"},
+		top:      5,
+		expected: &CostReport{
+			Grouping: CostGroupingJob,
+			Entries: []CostEntry{
+				{CostKey: CostKey{Org: "org", Repo: "repo", Job: "pull-ci-org-repo-master-e2e"}, CPUCoreHours: 1, DurationHours: 1, ComputeCost: 1, ClusterProfileCost: 20, LeaseCost: 4, TotalCost: 25},
+				{CostKey: CostKey{Org: "org", Repo: "repo", Branch: "master", Target: "unit"}, CPUCoreHours: 4, MemoryGiBHours: 4, ComputeCost: 6, TotalCost: 6},
+			},
+			Trend: []MonthlyCost{{Month: "2024-01", TotalCost: 2}, {Month: "2024-02", TotalCost: 29}},
+			TopOffenders: []CostEntry{
+				{CostKey: CostKey{Org: "org", Repo: "repo", Job: "pull-ci-org-repo-master-e2e"}, Month: "2024-02", CPUCoreHours: 1, DurationHours: 1, ComputeCost: 1, ClusterProfileCost: 20, LeaseCost: 4, TotalCost: 25},
+				{CostKey: CostKey{Org: "org", Repo: "repo", Branch: "master", Target: "unit"}, Month: "2024-02", CPUCoreHours: 2, MemoryGiBHours: 4, ComputeCost: 4, TotalCost: 4},
+			},
+		},
+	}, {
+		name:     "nothing matches",
+		grouping: CostGroupingJob,
+		filter:   CostFilter{Org: "missing"},
+		top:      5,
+		expected: &CostReport{Grouping: CostGroupingJob, Entries: []CostEntry{}, Trend: []MonthlyCost{}, TopOffenders: []CostEntry{}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, costs.Report(tc.grouping, tc.filter, tc.top), approximately); diff != "" {
+				t.Errorf("unexpected report: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCostReportWriteCSV(t *testing.T) {
+	report := &CostReport{Entries: []CostEntry{
+		{CostKey: CostKey{Org: "org", Repo: "repo"}, CPUCoreHours: 1.5, ComputeCost: 0.06, TotalCost: 0.06},
+		{CostKey: CostKey{Org: "org", Repo: "other,repo"}, LeaseCost: 2, TotalCost: 2},
+	}}
+	var out bytes.Buffer
+	if err := report.WriteCSV(&out); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+	expected := `org,repo,branch,variant,target,job,step,cpu_core_hours,memory_gib_hours,duration_hours,compute_cost,cluster_profile_cost,lease_cost,total_cost
+org,repo,,,,,,1.5000,0.0000,0.0000,0.0600,0.0000,0.0000,0.0600
+org,"other,repo",,,,,,0.0000,0.0000,0.0000,0.0000,0.0000,2.0000,2.0000
+`
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("unexpected CSV: %s", diff)
+	}
+}
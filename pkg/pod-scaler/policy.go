@@ -0,0 +1,201 @@
+package pod_scaler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/openhistogram/circonusllhist"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultQuantile is the quantile of usage data used as the recommendation
+	// when no policy configures one.
+	DefaultQuantile = 0.8
+	// DefaultHeadroom is the multiplier applied to the recommendation when no
+	// policy configures one.
+	DefaultHeadroom = 1.0
+)
+
+// RecommendationPolicy determines how a recommendation is derived from usage
+// data: the value at a quantile is multiplied by a headroom and clamped to
+// the minimum and maximum, when those are set.
+type RecommendationPolicy struct {
+	// Quantile of the usage data to use, between 0 and 1.
+	Quantile float64 `json:"quantile,omitempty"`
+	// Headroom is the multiplier applied to the value at the quantile.
+	Headroom float64 `json:"headroom,omitempty"`
+	// Min is the lowest value which will be recommended.
+	Min *resource.Quantity `json:"min,omitempty"`
+	// Max is the highest value which will be recommended.
+	Max *resource.Quantity `json:"max,omitempty"`
+}
+
+// Recommend determines the recommended value from usage data.
+func (p RecommendationPolicy) Recommend(histogram *circonusllhist.Histogram) float64 {
+	value := histogram.ValueAtQuantile(p.Quantile) * p.Headroom
+	if p.Min != nil && value < p.Min.AsApproximateFloat64() {
+		value = p.Min.AsApproximateFloat64()
+	}
+	if p.Max != nil && value > p.Max.AsApproximateFloat64() {
+		value = p.Max.AsApproximateFloat64()
+	}
+	return value
+}
+
+// overlay sets all fields configured in the other policy.
+func (p *RecommendationPolicy) overlay(other *RecommendationPolicy) {
+	if other == nil {
+		return
+	}
+	if other.Quantile != 0 {
+		p.Quantile = other.Quantile
+	}
+	if other.Headroom != 0 {
+		p.Headroom = other.Headroom
+	}
+	if other.Min != nil {
+		p.Min = other.Min
+	}
+	if other.Max != nil {
+		p.Max = other.Max
+	}
+}
+
+func (p *RecommendationPolicy) validate() error {
+	if p == nil {
+		return nil
+	}
+	var errs []error
+	if p.Quantile < 0 || p.Quantile > 1 {
+		errs = append(errs, fmt.Errorf("quantile must be between 0 and 1, got %v", p.Quantile))
+	}
+	if p.Headroom < 0 {
+		errs = append(errs, fmt.Errorf("headroom must not be negative, got %v", p.Headroom))
+	}
+	if p.Min != nil && p.Max != nil && p.Min.Cmp(*p.Max) == 1 {
+		errs = append(errs, fmt.Errorf("min %s must not be larger than max %s", p.Min.String(), p.Max.String()))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// ResourcePolicies holds the recommendation policies for each resource.
+type ResourcePolicies struct {
+	CPU    *RecommendationPolicy `json:"cpu,omitempty"`
+	Memory *RecommendationPolicy `json:"memory,omitempty"`
+}
+
+func (p ResourcePolicies) forResource(name corev1.ResourceName) *RecommendationPolicy {
+	switch name {
+	case corev1.ResourceCPU:
+		return p.CPU
+	case corev1.ResourceMemory:
+		return p.Memory
+	default:
+		return nil
+	}
+}
+
+// PolicySelector matches workloads by their metadata.  Empty fields match
+// any value.
+type PolicySelector struct {
+	Org       string `json:"org,omitempty"`
+	Repo      string `json:"repo,omitempty"`
+	Step      string `json:"step,omitempty"`
+	Container string `json:"container,omitempty"`
+}
+
+func (s PolicySelector) matches(meta FullMetadata) bool {
+	for _, field := range []struct{ selected, actual string }{
+		{selected: s.Org, actual: meta.Metadata.Org},
+		{selected: s.Repo, actual: meta.Metadata.Repo},
+		{selected: s.Step, actual: meta.Step},
+		{selected: s.Container, actual: meta.Container},
+	} {
+		if field.selected != "" && field.selected != field.actual {
+			return false
+		}
+	}
+	return true
+}
+
+// PolicyRule applies policies to the workloads matched by the selector.
+type PolicyRule struct {
+	Selector         PolicySelector `json:"selector"`
+	ResourcePolicies `json:",inline"`
+}
+
+// Policies configures how recommendations are made for workloads.  The
+// default policies are applied to all workloads, then every rule whose
+// selector matches the workload is applied in order, so later rules override
+// the fields set by earlier ones.
+type Policies struct {
+	Default ResourcePolicies `json:"default,omitempty"`
+	Rules   []PolicyRule     `json:"rules,omitempty"`
+}
+
+// LoadPolicies reads policies from a YAML file.  An empty path results in
+// the default policies.
+func LoadPolicies(path string) (*Policies, error) {
+	policies := &Policies{}
+	if path == "" {
+		return policies, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(raw, policies); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	if err := policies.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy file: %w", err)
+	}
+	return policies, nil
+}
+
+// Validate ensures that all policies are valid.
+func (p *Policies) Validate() error {
+	var errs []error
+	validate := func(field string, policies ResourcePolicies) {
+		if err := policies.CPU.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s.cpu: %w", field, err))
+		}
+		if err := policies.Memory.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s.memory: %w", field, err))
+		}
+	}
+	validate("default", p.Default)
+	for i, rule := range p.Rules {
+		if rule.Selector == (PolicySelector{}) {
+			errs = append(errs, fmt.Errorf("rules[%d].selector: %w", i, errors.New("must not be empty")))
+		}
+		validate(fmt.Sprintf("rules[%d]", i), rule.ResourcePolicies)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// For determines the effective policy for a resource of a workload, and
+// whether any rule matched the workload.
+func (p *Policies) For(meta FullMetadata, name corev1.ResourceName) (RecommendationPolicy, bool) {
+	policy := RecommendationPolicy{Quantile: DefaultQuantile, Headroom: DefaultHeadroom}
+	if p == nil {
+		return policy, false
+	}
+	policy.overlay(p.Default.forResource(name))
+	var matched bool
+	for _, rule := range p.Rules {
+		if rule.Selector.matches(meta) {
+			if override := rule.forResource(name); override != nil {
+				policy.overlay(override)
+				matched = true
+			}
+		}
+	}
+	return policy, matched
+}
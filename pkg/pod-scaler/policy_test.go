@@ -0,0 +1,188 @@
+package pod_scaler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openhistogram/circonusllhist"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+func quantity(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+	return &q
+}
+
+func TestPoliciesFor(t *testing.T) {
+	policies := &Policies{
+		Default: ResourcePolicies{
+			CPU: &RecommendationPolicy{Quantile: 0.5},
+		},
+		Rules: []PolicyRule{{
+			Selector:         PolicySelector{Org: "org"},
+			ResourcePolicies: ResourcePolicies{Memory: &RecommendationPolicy{Quantile: 0.9, Max: quantity("8Gi")}},
+		}, {
+			Selector:         PolicySelector{Org: "org", Step: "e2e", Container: "test"},
+			ResourcePolicies: ResourcePolicies{Memory: &RecommendationPolicy{Quantile: 0.99, Headroom: 1.2}},
+		}},
+	}
+	meta := func(org, step, container string) FullMetadata {
+		return FullMetadata{Metadata: api.Metadata{Org: org, Repo: "repo"}, Step: step, Container: container}
+	}
+	for _, tc := range []struct {
+		name            string
+		policies        *Policies
+		meta            FullMetadata
+		resource        corev1.ResourceName
+		expected        RecommendationPolicy
+		expectedMatched bool
+	}{{
+		name:     "no policies",
+		meta:     meta("org", "e2e", "test"),
+		resource: corev1.ResourceMemory,
+		expected: RecommendationPolicy{Quantile: DefaultQuantile, Headroom: DefaultHeadroom},
+	}, {
+		name:     "default policy",
+		policies: policies,
+		meta:     meta("other", "e2e", "test"),
+		resource: corev1.ResourceCPU,
+		expected: RecommendationPolicy{Quantile: 0.5, Headroom: DefaultHeadroom},
+	}, {
+		name:            "matching rule",
+		policies:        policies,
+		meta:            meta("org", "build", "test"),
+		resource:        corev1.ResourceMemory,
+		expected:        RecommendationPolicy{Quantile: 0.9, Headroom: DefaultHeadroom, Max: quantity("8Gi")},
+		expectedMatched: true,
+	}, {
+		name:            "later rules override earlier ones",
+		policies:        policies,
+		meta:            meta("org", "e2e", "test"),
+		resource:        corev1.ResourceMemory,
+		expected:        RecommendationPolicy{Quantile: 0.99, Headroom: 1.2, Max: quantity("8Gi")},
+		expectedMatched: true,
+	}, {
+		name:     "rule for another resource",
+		policies: policies,
+		meta:     meta("org", "e2e", "test"),
+		resource: corev1.ResourceCPU,
+		expected: RecommendationPolicy{Quantile: 0.5, Headroom: DefaultHeadroom},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, matched := tc.policies.For(tc.meta, tc.resource)
+			if diff := cmp.Diff(tc.expected, policy); diff != "" {
+				t.Errorf("unexpected policy: %s", diff)
+			}
+			if matched != tc.expectedMatched {
+				t.Errorf("expected matched to be %v, got %v", tc.expectedMatched, matched)
+			}
+		})
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	histogram := circonusllhist.New()
+	for i := 1; i <= 100; i++ {
+		if err := histogram.RecordValue(float64(i)); err != nil {
+			t.Fatalf("failed to record value: %v", err)
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		policy   RecommendationPolicy
+		expected float64
+	}{{
+		name:     "quantile",
+		policy:   RecommendationPolicy{Quantile: 0.5, Headroom: 1},
+		expected: histogram.ValueAtQuantile(0.5),
+	}, {
+		name:     "headroom",
+		policy:   RecommendationPolicy{Quantile: 0.5, Headroom: 2},
+		expected: 2 * histogram.ValueAtQuantile(0.5),
+	}, {
+		name:     "clamped to minimum",
+		policy:   RecommendationPolicy{Quantile: 0.5, Headroom: 1, Min: quantity("200")},
+		expected: 200,
+	}, {
+		name:     "clamped to maximum",
+		policy:   RecommendationPolicy{Quantile: 0.9, Headroom: 2, Max: quantity("10")},
+		expected: 10,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.policy.Recommend(histogram)); diff != "" {
+				t.Errorf("unexpected recommendation: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		raw         string
+		expected    *Policies
+		expectedErr string
+	}{{
+		name: "valid policies",
+		raw: `default:
+  cpu:
+    quantile: 0.7
+rules:
+- selector:
+    org: openshift
+    step: e2e
+  memory:
+    quantile: 0.95
+    headroom: 1.2
+    min: 1Gi
+    max: 16Gi
+`,
+		expected: &Policies{
+			Default: ResourcePolicies{CPU: &RecommendationPolicy{Quantile: 0.7}},
+			Rules: []PolicyRule{{
+				Selector: PolicySelector{Org: "openshift", Step: "e2e"},
+				ResourcePolicies: ResourcePolicies{
+					Memory: &RecommendationPolicy{Quantile: 0.95, Headroom: 1.2, Min: quantity("1Gi"), Max: quantity("16Gi")},
+				},
+			}},
+		},
+	}, {
+		name: "invalid policies",
+		raw: `rules:
+- selector: {}
+  memory:
+    quantile: 1.5
+    min: 2Gi
+    max: 1Gi
+`,
+		expectedErr: "invalid policy file: [rules[0].selector: must not be empty, rules[0].memory: [quantile must be between 0 and 1, got 1.5, min 2Gi must not be larger than max 1Gi]]",
+	}, {
+		name:        "unknown field",
+		raw:         "rules:\n- selector: {org: org}\n  gpu: {}\n",
+		expectedErr: `failed to parse policy file: error unmarshaling JSON: while decoding JSON: json: unknown field "gpu"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policies.yaml")
+			if err := os.WriteFile(path, []byte(tc.raw), 0644); err != nil {
+				t.Fatalf("failed to write policy file: %v", err)
+			}
+			policies, err := LoadPolicies(path)
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, actualErr); diff != "" {
+				t.Fatalf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, policies); diff != "" {
+				t.Errorf("unexpected policies: %s", diff)
+			}
+		})
+	}
+}
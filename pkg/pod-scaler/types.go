@@ -73,6 +73,9 @@ type FingerprintTime struct {
 	Fingerprint model.Fingerprint `json:"fingerprint"`
 	// Added is the time which this was sourced. This is useful for later pruning of stale data.
 	Added time.Time `json:"added"`
+	// Latest is the value of the most recent sample of the metric. Histograms only hold values
+	// approximately, so this is where metrics whose value is a timestamp are read from.
+	Latest float64 `json:"latest,omitempty"`
 }
 
 // Record adds the data in the matrix to the cache and records that the given cluster has
@@ -95,6 +98,8 @@ func (q *CachedQuery) Record(clusterName string, r TimeRange, matrix model.Matri
 		} else {
 			hist = circonusllhist.New(circonusllhist.NoLookup())
 		}
+		var latest float64
+		var sampled bool
 		for _, value := range stream.Values {
 			if math.IsNaN(float64(value.Value)) {
 				continue
@@ -103,14 +108,22 @@ func (q *CachedQuery) Record(clusterName string, r TimeRange, matrix model.Matri
 			if err != nil {
 				logger.WithError(err).Warn("Failed to insert data into histogram. This should never happen.")
 			}
+			latest, sampled = float64(value.Value), true
 		}
 		q.Data[fingerprint] = circonusllhist.NewHistogramWithoutLookups(hist)
 		if !seen {
 			ft := FingerprintTime{
 				Fingerprint: fingerprint,
 				Added:       r.End, // We use the end time from the range as the added time, it is sufficient for pruning
+				Latest:      latest,
 			}
 			q.DataByMetaData[meta] = append(q.DataByMetaData[meta], ft)
+		} else if sampled {
+			for i := range q.DataByMetaData[meta] {
+				if q.DataByMetaData[meta][i].Fingerprint == fingerprint {
+					q.DataByMetaData[meta][i].Latest = latest
+				}
+			}
 		}
 	}
 }
@@ -618,6 +618,7 @@ func TestCachedQuery_Record(t *testing.T) {
 				{
 					Fingerprint: metrics[0].metric.Fingerprint(),
 					Added:       year(20),
+					Latest:      3,
 				},
 			},
 		},
@@ -651,12 +652,14 @@ func TestCachedQuery_Record(t *testing.T) {
 				{
 					Fingerprint: metrics[0].metric.Fingerprint(),
 					Added:       year(20),
+					Latest:      3,
 				},
 			},
 			metrics[1].meta: {
 				{
 					Fingerprint: metrics[1].metric.Fingerprint(),
 					Added:       year(20),
+					Latest:      3,
 				},
 			},
 		},
@@ -697,12 +700,14 @@ func TestCachedQuery_Record(t *testing.T) {
 				{
 					Fingerprint: metrics[0].metric.Fingerprint(),
 					Added:       year(20),
+					Latest:      3,
 				},
 			},
 			metrics[1].meta: {
 				{
 					Fingerprint: metrics[1].metric.Fingerprint(),
 					Added:       year(20),
+					Latest:      6,
 				},
 			},
 		},
@@ -744,16 +749,19 @@ func TestCachedQuery_Record(t *testing.T) {
 				{
 					Fingerprint: metrics[0].metric.Fingerprint(),
 					Added:       year(20),
+					Latest:      3,
 				},
 			},
 			metrics[1].meta: {
 				{
 					Fingerprint: metrics[1].metric.Fingerprint(),
 					Added:       year(20),
+					Latest:      6,
 				},
 				{
 					Fingerprint: metrics[2].metric.Fingerprint(),
 					Added:       year(35),
+					Latest:      9,
 				},
 			},
 		},
@@ -443,6 +443,8 @@ func Template(template, command, fromImage, target string, profile cioperatorapi
 const (
 	boskosVolumeName           = "boskos"
 	boskosCredentialsParameter = "--lease-server-credentials-file=/etc/boskos/credentials"
+	jobLabelsVolumeName        = "job-labels"
+	jobLabelsParameter         = "--job-labels-file=/etc/job-labels/labels"
 )
 
 var (
@@ -460,20 +462,40 @@ var (
 		MountPath: "/etc/boskos",
 		ReadOnly:  true,
 	}
+	jobLabelsVolume = corev1.Volume{
+		Name: jobLabelsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{
+				Items: []corev1.DownwardAPIVolumeFile{{Path: "labels", FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels"}}},
+			},
+		},
+	}
+	jobLabelsVolumeMount = corev1.VolumeMount{
+		Name:      jobLabelsVolumeName,
+		MountPath: "/etc/job-labels",
+		ReadOnly:  true,
+	}
 )
 
 // LeaseClient configures ci-operator to be able to interact with Boskos (lease
-// server), providing the necessary secrets to do so
+// server), providing the necessary secrets to do so, and exposes the labels of
+// the job from which the priority of its leases is derived
 func LeaseClient() PodSpecMutator {
 	return func(spec *corev1.PodSpec) error {
 		container := &spec.Containers[0]
-		if err := addVolume(spec, boskosVolume); err != nil {
-			return err
-		}
-		if err := addVolumeMount(container, boskosVolumeMount); err != nil {
-			return err
+		for _, volume := range []struct {
+			volume corev1.Volume
+			mount  corev1.VolumeMount
+		}{{boskosVolume, boskosVolumeMount}, {jobLabelsVolume, jobLabelsVolumeMount}} {
+			if err := addVolume(spec, volume.volume); err != nil {
+				return err
+			}
+			if err := addVolumeMount(container, volume.mount); err != nil {
+				return err
+			}
 		}
 		addUniqueParameter(container, boskosCredentialsParameter)
+		addUniqueParameter(container, jobLabelsParameter)
 		return nil
 	}
 
@@ -584,6 +606,16 @@ func TargetAdditionalSuffix(suffix string) PodSpecMutator {
 	}
 }
 
+// Shard configures ci-operator to run the given shard of the targeted test
+func Shard(index, count int) PodSpecMutator {
+	return func(spec *corev1.PodSpec) error {
+		container := &spec.Containers[0]
+		addUniqueParameter(container, fmt.Sprintf("--shard-index=%d", index))
+		addUniqueParameter(container, fmt.Sprintf("--shard-count=%d", count))
+		return nil
+	}
+}
+
 func MultiStageParam(key, value string) PodSpecMutator {
 	return func(spec *corev1.PodSpec) error {
 		container := &spec.Containers[0]
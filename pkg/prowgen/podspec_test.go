@@ -225,6 +225,17 @@ func TestTargetAdditionalSuffix(t *testing.T) {
 	}
 }
 
+func TestShard(t *testing.T) {
+	t.Parallel()
+	g := NewCiOperatorPodSpecGenerator()
+	g.Add(Shard(2, 3))
+	podspec, err := g.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testhelper.CompareWithFixture(t, podspec)
+}
+
 func TestCustomHashInput(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
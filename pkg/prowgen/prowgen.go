@@ -50,7 +50,8 @@ func GenerateJobs(configSpec *cioperatorapi.ReleaseBuildConfiguration, info *Pro
 		shardCount := 1
 		if element.ShardCount != nil {
 			shardCount = *element.ShardCount
-
+		} else if count, ok := info.Config.Sharding.ShardCountFor(element.As); ok && !element.Postsubmit {
+			shardCount = count
 		}
 
 		// Most of the time, this loop will only run once. the exception is if shard_count is set to an integer greater than 1
@@ -61,7 +62,7 @@ func GenerateJobs(configSpec *cioperatorapi.ReleaseBuildConfiguration, info *Pro
 				name = fmt.Sprintf("%s-%dof%d", name, i, shardCount)
 				g.TestName(name)
 				shardArgs := fmt.Sprintf("--shard-count %d --shard-id %d", shardCount, i)
-				g.PodSpec.Add(MultiStageParam("SHARD_ARGS", shardArgs))
+				g.PodSpec.Add(MultiStageParam("SHARD_ARGS", shardArgs), Shard(i, shardCount))
 			}
 
 			if element.NodeArchitecture != "" {
@@ -4,6 +4,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	utilpointer "k8s.io/utils/pointer"
@@ -702,6 +703,31 @@ func TestGenerateJobs(t *testing.T) {
 				Branch: "branch",
 			}},
 		},
+		{
+			id: "presubmit sharded from durations",
+			config: &ciop.ReleaseBuildConfiguration{
+				Tests: []ciop.TestStepConfiguration{
+					{As: "unit", ContainerTestConfiguration: &ciop.ContainerTestConfiguration{From: "bin"}},
+					{As: "e2e", ContainerTestConfiguration: &ciop.ContainerTestConfiguration{From: "bin"}},
+					{As: "explicit", ShardCount: intPointer(2), ContainerTestConfiguration: &ciop.ContainerTestConfiguration{From: "bin"}},
+				},
+			},
+			repoInfo: &ProwgenInfo{
+				Metadata: ciop.Metadata{
+					Org:    "organization",
+					Repo:   "repository",
+					Branch: "branch",
+				},
+				Config: config.Prowgen{Sharding: &config.Sharding{
+					TargetDuration: prowv1.Duration{Duration: time.Hour},
+					Durations: map[string]*prowv1.Duration{
+						"unit":     {Duration: 10 * time.Minute},
+						"e2e":      {Duration: 150 * time.Minute},
+						"explicit": {Duration: 5 * time.Hour},
+					},
+				}},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,46 @@
+package quarantine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// Apply marks the failed test cases which are quarantined for the scope as
+// skipped, keeping their failure output, so they no longer count as failures
+// in the results. It returns the names of the test cases it marked.
+func Apply(list *List, scope Scope, suites *junit.TestSuites, now time.Time) []string {
+	if suites == nil {
+		return nil
+	}
+	var marked []string
+	var walk func(suites []*junit.TestSuite)
+	walk = func(suites []*junit.TestSuite) {
+		for _, suite := range suites {
+			for _, test := range suite.TestCases {
+				if test.FailureOutput == nil {
+					continue
+				}
+				entry, quarantined := list.Quarantined(scope, test.Name, now)
+				if !quarantined {
+					continue
+				}
+				test.SkipMessage = &junit.SkipMessage{Message: fmt.Sprintf("quarantined until %s: %s", entry.Expires.Format(time.RFC3339), entry.Reason)}
+				if test.SystemErr != "" {
+					test.SystemErr += "\n"
+				}
+				test.SystemErr += test.FailureOutput.Output
+				test.FailureOutput = nil
+				if suite.NumFailed > 0 {
+					suite.NumFailed--
+				}
+				suite.NumSkipped++
+				marked = append(marked, test.Name)
+			}
+			walk(suite.Children)
+		}
+	}
+	walk(suites.Suites)
+	return marked
+}
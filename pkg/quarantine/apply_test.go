@@ -0,0 +1,64 @@
+package quarantine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+func TestApply(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+	scope := Scope{Org: "org", Repo: "repo", Branch: "main"}
+	list := &List{Scopes: map[string]map[string]Entry{
+		"org/repo@main": {
+			"flaky":   {Reason: "flake score 0.400 over 10 runs is at least 0.200", Expires: expires},
+			"passing": {Reason: "flaky", Expires: expires},
+			"expired": {Reason: "flaky", Expires: now},
+		},
+	}}
+	suites := &junit.TestSuites{Suites: []*junit.TestSuite{{
+		NumTests:  3,
+		NumFailed: 2,
+		TestCases: []*junit.TestCase{
+			{Name: "flaky", FailureOutput: &junit.FailureOutput{Output: "boom"}},
+			{Name: "passing"},
+			{Name: "expired", FailureOutput: &junit.FailureOutput{Output: "boom"}},
+		},
+		Children: []*junit.TestSuite{{
+			NumTests:  1,
+			NumFailed: 1,
+			TestCases: []*junit.TestCase{{Name: "flaky", FailureOutput: &junit.FailureOutput{Output: "again"}, SystemErr: "stderr"}},
+		}},
+	}}}
+
+	marked := Apply(list, scope, suites, now)
+	if diff := cmp.Diff([]string{"flaky", "flaky"}, marked); diff != "" {
+		t.Errorf("unexpected marked tests: %s", diff)
+	}
+	skip := &junit.SkipMessage{Message: "quarantined until 2024-01-01T01:00:00Z: flake score 0.400 over 10 runs is at least 0.200"}
+	expected := &junit.TestSuites{Suites: []*junit.TestSuite{{
+		NumTests:   3,
+		NumFailed:  1,
+		NumSkipped: 1,
+		TestCases: []*junit.TestCase{
+			{Name: "flaky", SkipMessage: skip, SystemErr: "boom"},
+			{Name: "passing"},
+			{Name: "expired", FailureOutput: &junit.FailureOutput{Output: "boom"}},
+		},
+		Children: []*junit.TestSuite{{
+			NumTests:   1,
+			NumSkipped: 1,
+			TestCases:  []*junit.TestCase{{Name: "flaky", SkipMessage: skip, SystemErr: "stderr\nagain"}},
+		}},
+	}}}
+	if diff := cmp.Diff(expected, suites); diff != "" {
+		t.Errorf("unexpected suites: %s", diff)
+	}
+	if marked := Apply(nil, scope, suites, now); marked != nil {
+		t.Errorf("expected a nil list to mark nothing, got %v", marked)
+	}
+}
@@ -0,0 +1,107 @@
+package quarantine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Scope identifies the repo and branch a test is quarantined for.
+type Scope struct {
+	Org    string
+	Repo   string
+	Branch string
+}
+
+// String formats the scope as it is keyed in a List: org/repo@branch
+func (s Scope) String() string {
+	return fmt.Sprintf("%s/%s@%s", s.Org, s.Repo, s.Branch)
+}
+
+// Entry quarantines a test until it expires.
+type Entry struct {
+	// Score is the flake score of the test when it was last evaluated.
+	Score float64 `json:"score"`
+	// Runs is the number of runs the score was computed from.
+	Runs int `json:"runs"`
+	// Reason explains why the test was quarantined.
+	Reason string `json:"reason"`
+	// Since is when the test was quarantined.
+	Since time.Time `json:"since"`
+	// Expires is when the quarantine ends unless it is renewed.
+	Expires time.Time `json:"expires"`
+}
+
+// Expired determines whether the entry no longer applies.
+func (e Entry) Expired(now time.Time) bool {
+	return !now.Before(e.Expires)
+}
+
+// List holds the quarantined tests, keyed by the scope they are quarantined
+// for and then by the name of the test.
+type List struct {
+	Scopes map[string]map[string]Entry `json:"scopes,omitempty"`
+}
+
+// Quarantined determines whether the test is quarantined for the scope,
+// ignoring expired entries. A nil List quarantines nothing.
+func (l *List) Quarantined(scope Scope, test string, now time.Time) (Entry, bool) {
+	if l == nil {
+		return Entry{}, false
+	}
+	entry, ok := l.Scopes[scope.String()][test]
+	if !ok || entry.Expired(now) {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+func (l *List) set(scope Scope, test string, entry Entry) {
+	if l.Scopes == nil {
+		l.Scopes = map[string]map[string]Entry{}
+	}
+	key := scope.String()
+	if l.Scopes[key] == nil {
+		l.Scopes[key] = map[string]Entry{}
+	}
+	l.Scopes[key][test] = entry
+}
+
+func (l *List) remove(scope Scope, test string) {
+	key := scope.String()
+	delete(l.Scopes[key], test)
+	if len(l.Scopes[key]) == 0 {
+		delete(l.Scopes, key)
+	}
+}
+
+// LoadList loads a List from a YAML file. A missing file is an empty List.
+func LoadList(path string) (*List, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &List{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine list: %w", err)
+	}
+	var list List
+	if err := yaml.UnmarshalStrict(raw, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quarantine list: %w", err)
+	}
+	return &list, nil
+}
+
+// Write stores the List in a YAML file.
+func (l *List) Write(path string) error {
+	raw, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantine list: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write quarantine list: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package quarantine
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuarantined(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	scope := Scope{Org: "org", Repo: "repo", Branch: "main"}
+	entry := Entry{Score: 0.5, Runs: 10, Reason: "flaky", Since: now.Add(-time.Hour), Expires: now.Add(time.Hour)}
+	list := &List{Scopes: map[string]map[string]Entry{
+		"org/repo@main": {
+			"flaky":   entry,
+			"expired": {Expires: now},
+		},
+	}}
+	testCases := []struct {
+		name     string
+		list     *List
+		scope    Scope
+		test     string
+		expected bool
+	}{
+		{name: "quarantined test", list: list, scope: scope, test: "flaky", expected: true},
+		{name: "expired test", list: list, scope: scope, test: "expired"},
+		{name: "other test", list: list, scope: scope, test: "other"},
+		{name: "other branch", list: list, scope: Scope{Org: "org", Repo: "repo", Branch: "release"}, test: "flaky"},
+		{name: "nil list", scope: scope, test: "flaky"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, quarantined := tc.list.Quarantined(tc.scope, tc.test, now)
+			if quarantined != tc.expected {
+				t.Errorf("expected quarantined to be %t, got %t", tc.expected, quarantined)
+			}
+			if quarantined {
+				if diff := cmp.Diff(entry, actual); diff != "" {
+					t.Errorf("unexpected entry: %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestListRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantine.yaml")
+	missing, err := LoadList(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading a missing list: %v", err)
+	}
+	if diff := cmp.Diff(&List{}, missing); diff != "" {
+		t.Errorf("expected an empty list: %s", diff)
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	list := &List{}
+	list.set(Scope{Org: "org", Repo: "repo", Branch: "main"}, "test", Entry{Score: 0.5, Runs: 10, Reason: "flaky", Since: now, Expires: now.Add(time.Hour)})
+	if err := list.Write(path); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+	loaded, err := LoadList(path)
+	if err != nil {
+		t.Fatalf("failed to load list: %v", err)
+	}
+	if diff := cmp.Diff(list, loaded); diff != "" {
+		t.Errorf("loaded list differs: %s", diff)
+	}
+}
@@ -0,0 +1,102 @@
+package quarantine
+
+import (
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// Counts are the outcomes of the runs of a test.
+type Counts struct {
+	Pass  int `json:"pass"`
+	Fail  int `json:"fail"`
+	Flake int `json:"flake"`
+}
+
+// Runs is the total number of runs.
+func (c Counts) Runs() int {
+	return c.Pass + c.Fail + c.Flake
+}
+
+// Score is the fraction of the runs of a test which failed or flaked. A test
+// which never passes is broken rather than flaky, so it scores 0.
+func (c Counts) Score() float64 {
+	if c.Pass == 0 || c.Runs() == 0 {
+		return 0
+	}
+	return float64(c.Fail+c.Flake) / float64(c.Runs())
+}
+
+// Add merges the counts of the other results of the same tests in.
+func Add(counts map[string]Counts, other map[string]Counts) map[string]Counts {
+	if counts == nil {
+		counts = map[string]Counts{}
+	}
+	for test, c := range other {
+		current := counts[test]
+		current.Pass += c.Pass
+		current.Fail += c.Fail
+		current.Flake += c.Flake
+		counts[test] = current
+	}
+	return counts
+}
+
+// CountsFromJUnit counts the outcomes of the tests in jUnit results, each of
+// which is a single run. A test which both failed and passed in a run, like
+// when it is retried, flaked. Skipped tests are ignored.
+func CountsFromJUnit(results ...*junit.TestSuites) map[string]Counts {
+	counts := map[string]Counts{}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		passed, failed := map[string]bool{}, map[string]bool{}
+		var walk func(suites []*junit.TestSuite)
+		walk = func(suites []*junit.TestSuite) {
+			for _, suite := range suites {
+				for _, test := range suite.TestCases {
+					switch {
+					case test.SkipMessage != nil:
+					case test.FailureOutput != nil:
+						failed[test.Name] = true
+					default:
+						passed[test.Name] = true
+					}
+				}
+				walk(suite.Children)
+			}
+		}
+		walk(result.Suites)
+		for test := range failed {
+			c := counts[test]
+			if passed[test] {
+				c.Flake++
+			} else {
+				c.Fail++
+			}
+			counts[test] = c
+		}
+		for test := range passed {
+			if !failed[test] {
+				c := counts[test]
+				c.Pass++
+				counts[test] = c
+			}
+		}
+	}
+	return counts
+}
+
+// CountsFromAggregatedRuns sums the outcomes of the tests recorded by the job
+// run aggregator, across all jobs in the rows.
+func CountsFromAggregatedRuns(rows []jobrunaggregatorapi.AggregatedTestRunRow) map[string]Counts {
+	counts := map[string]Counts{}
+	for _, row := range rows {
+		c := counts[row.TestName]
+		c.Pass += row.PassCount
+		c.Fail += row.FailCount
+		c.Flake += row.FlakeCount
+		counts[row.TestName] = c
+	}
+	return counts
+}
@@ -0,0 +1,77 @@
+package quarantine
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/jobrunaggregator/jobrunaggregatorapi"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+func TestScore(t *testing.T) {
+	testCases := []struct {
+		name     string
+		counts   Counts
+		expected float64
+	}{
+		{name: "no runs"},
+		{name: "always passes", counts: Counts{Pass: 10}},
+		{name: "never passes", counts: Counts{Fail: 10}},
+		{name: "flaky", counts: Counts{Pass: 6, Fail: 2, Flake: 2}, expected: 0.4},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.counts.Score()); diff != "" {
+				t.Errorf("unexpected score: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCountsFromJUnit(t *testing.T) {
+	first := &junit.TestSuites{Suites: []*junit.TestSuite{{
+		TestCases: []*junit.TestCase{
+			{Name: "passes"},
+			{Name: "retried", FailureOutput: &junit.FailureOutput{}},
+			{Name: "retried"},
+			{Name: "fails", FailureOutput: &junit.FailureOutput{}},
+			{Name: "skipped", SkipMessage: &junit.SkipMessage{}},
+		},
+		Children: []*junit.TestSuite{{TestCases: []*junit.TestCase{{Name: "nested"}}}},
+	}}}
+	second := &junit.TestSuites{Suites: []*junit.TestSuite{{
+		TestCases: []*junit.TestCase{
+			{Name: "passes"},
+			{Name: "retried"},
+			{Name: "fails"},
+		},
+	}}}
+	expected := map[string]Counts{
+		"passes":  {Pass: 2},
+		"retried": {Pass: 1, Flake: 1},
+		"fails":   {Pass: 1, Fail: 1},
+		"nested":  {Pass: 1},
+	}
+	if diff := cmp.Diff(expected, CountsFromJUnit(first, nil, second)); diff != "" {
+		t.Errorf("unexpected counts: %s", diff)
+	}
+}
+
+func TestCountsFromAggregatedRuns(t *testing.T) {
+	rows := []jobrunaggregatorapi.AggregatedTestRunRow{
+		{TestName: "a", JobName: "e2e-aws", PassCount: 10, FailCount: 1, FlakeCount: 2},
+		{TestName: "a", JobName: "e2e-gcp", PassCount: 5, FailCount: 2},
+		{TestName: "b", JobName: "e2e-aws", PassCount: 3},
+	}
+	expected := map[string]Counts{
+		"a": {Pass: 15, Fail: 3, Flake: 2},
+		"b": {Pass: 3},
+	}
+	if diff := cmp.Diff(expected, CountsFromAggregatedRuns(rows)); diff != "" {
+		t.Errorf("unexpected counts: %s", diff)
+	}
+	if diff := cmp.Diff(map[string]Counts{"a": {Pass: 16, Fail: 3, Flake: 2}, "b": {Pass: 3}}, Add(expected, map[string]Counts{"a": {Pass: 1}})); diff != "" {
+		t.Errorf("unexpected merged counts: %s", diff)
+	}
+}
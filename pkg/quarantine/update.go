@@ -0,0 +1,142 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Policy decides which tests are quarantined.
+type Policy struct {
+	// Threshold is the flake score at or above which a test is quarantined.
+	Threshold float64
+	// MinRuns is the number of runs needed to score a test. Tests with fewer
+	// runs are left as they are.
+	MinRuns int
+	// TTL is how long a quarantine lasts unless the test is scored again.
+	TTL time.Duration
+}
+
+// Validate checks that the policy can be applied.
+func (p Policy) Validate() error {
+	var errs []error
+	if p.Threshold <= 0 || p.Threshold > 1 {
+		errs = append(errs, fmt.Errorf("threshold must be in (0, 1], got %v", p.Threshold))
+	}
+	if p.MinRuns < 1 {
+		errs = append(errs, fmt.Errorf("minimum number of runs must be positive, got %d", p.MinRuns))
+	}
+	if p.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("TTL must be positive, got %s", p.TTL))
+	}
+	return errors.Join(errs...)
+}
+
+// Action is a change made to the quarantine of a test.
+type Action string
+
+const (
+	// ActionQuarantine quarantines a test which was not quarantined.
+	ActionQuarantine Action = "quarantine"
+	// ActionRenew extends the quarantine of a test which is still flaky.
+	ActionRenew Action = "renew"
+	// ActionRelease ends the quarantine of a test which is no longer flaky.
+	ActionRelease Action = "release"
+	// ActionExpire removes a quarantine which expired without being renewed.
+	ActionExpire Action = "expire"
+)
+
+// Decision records a change made to the List and why, for auditing.
+type Decision struct {
+	Time   time.Time `json:"time"`
+	Scope  string    `json:"scope"`
+	Test   string    `json:"test"`
+	Action Action    `json:"action"`
+	Score  float64   `json:"score"`
+	Runs   int       `json:"runs"`
+	Reason string    `json:"reason"`
+}
+
+// Update scores the tests of the scope from their counts and updates their
+// quarantine according to the policy, returning the decisions made in the
+// order of the tests.
+func Update(list *List, scope Scope, counts map[string]Counts, policy Policy, now time.Time) []Decision {
+	var decisions []Decision
+	decide := func(test string, action Action, c Counts, reason string) {
+		decisions = append(decisions, Decision{Time: now, Scope: scope.String(), Test: test, Action: action, Score: c.Score(), Runs: c.Runs(), Reason: reason})
+	}
+
+	tests := make([]string, 0, len(counts))
+	for test := range counts {
+		tests = append(tests, test)
+	}
+	sort.Strings(tests)
+	scored := map[string]bool{}
+	for _, test := range tests {
+		c := counts[test]
+		if c.Runs() < policy.MinRuns {
+			continue
+		}
+		scored[test] = true
+		current, listed := list.Scopes[scope.String()][test]
+		quarantined := listed && !current.Expired(now)
+		score := c.Score()
+		if score >= policy.Threshold {
+			reason := fmt.Sprintf("flake score %.3f over %d runs is at least %.3f", score, c.Runs(), policy.Threshold)
+			entry := Entry{Score: score, Runs: c.Runs(), Reason: reason, Since: now, Expires: now.Add(policy.TTL)}
+			action := ActionQuarantine
+			if quarantined {
+				entry.Since = current.Since
+				action = ActionRenew
+			}
+			list.set(scope, test, entry)
+			decide(test, action, c, reason)
+		} else if quarantined {
+			list.remove(scope, test)
+			decide(test, ActionRelease, c, fmt.Sprintf("flake score %.3f over %d runs is below %.3f", score, c.Runs(), policy.Threshold))
+		} else if listed {
+			list.remove(scope, test)
+			decide(test, ActionExpire, c, fmt.Sprintf("quarantine expired at %s, flake score %.3f over %d runs is below %.3f", current.Expires.Format(time.RFC3339), score, c.Runs(), policy.Threshold))
+		}
+	}
+
+	var expired []string
+	for test, entry := range list.Scopes[scope.String()] {
+		if !scored[test] && entry.Expired(now) {
+			expired = append(expired, test)
+		}
+	}
+	sort.Strings(expired)
+	for _, test := range expired {
+		entry := list.Scopes[scope.String()][test]
+		list.remove(scope, test)
+		decisions = append(decisions, Decision{Time: now, Scope: scope.String(), Test: test, Action: ActionExpire, Score: entry.Score, Runs: entry.Runs, Reason: fmt.Sprintf("quarantine expired at %s", entry.Expires.Format(time.RFC3339))})
+	}
+	return decisions
+}
+
+// AppendAudit appends the decisions to the audit log, one JSON object per line.
+func AppendAudit(path string, decisions []Decision) (ret error) {
+	if len(decisions) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil && ret == nil {
+			ret = fmt.Errorf("failed to close audit log: %w", err)
+		}
+	}()
+	encoder := json.NewEncoder(f)
+	for _, decision := range decisions {
+		if err := encoder.Encode(decision); err != nil {
+			return fmt.Errorf("failed to write to audit log: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package quarantine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	if err := (Policy{Threshold: 0.1, MinRuns: 10, TTL: time.Hour}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	expected := errors.Join(
+		errors.New("threshold must be in (0, 1], got 2"),
+		errors.New("minimum number of runs must be positive, got 0"),
+		errors.New("TTL must be positive, got 0s"),
+	)
+	if diff := cmp.Diff(expected, Policy{Threshold: 2}.Validate(), testhelper.EquateErrorMessage); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	earlier := now.Add(-24 * time.Hour)
+	scope := Scope{Org: "org", Repo: "repo", Branch: "main"}
+	other := Scope{Org: "org", Repo: "repo", Branch: "release"}
+	policy := Policy{Threshold: 0.2, MinRuns: 5, TTL: 48 * time.Hour}
+	list := &List{Scopes: map[string]map[string]Entry{
+		"org/repo@main": {
+			"still-flaky":   {Score: 0.5, Runs: 10, Reason: "old", Since: earlier, Expires: now.Add(time.Hour)},
+			"fixed":         {Score: 0.5, Runs: 10, Reason: "old", Since: earlier, Expires: now.Add(time.Hour)},
+			"expired":       {Score: 0.3, Runs: 20, Reason: "old", Since: earlier, Expires: now},
+			"expired-fixed": {Score: 0.3, Runs: 20, Reason: "old", Since: earlier, Expires: now},
+			"not-evaluated": {Score: 0.3, Runs: 20, Reason: "old", Since: earlier, Expires: now.Add(time.Hour)},
+		},
+		"org/repo@release": {
+			"expired": {Expires: earlier},
+		},
+	}}
+	counts := map[string]Counts{
+		"new-flake":     {Pass: 6, Flake: 4},
+		"still-flaky":   {Pass: 7, Fail: 3},
+		"fixed":         {Pass: 10},
+		"few-runs":      {Pass: 1, Fail: 2},
+		"broken":        {Fail: 10},
+		"not-evaluated": {Pass: 2},
+		"expired-fixed": {Pass: 9, Flake: 1},
+	}
+
+	decisions := Update(list, scope, counts, policy, now)
+	expectedDecisions := []Decision{
+		{Time: now, Scope: "org/repo@main", Test: "expired-fixed", Action: ActionExpire, Score: 0.1, Runs: 10, Reason: "quarantine expired at 2024-01-01T00:00:00Z, flake score 0.100 over 10 runs is below 0.200"},
+		{Time: now, Scope: "org/repo@main", Test: "fixed", Action: ActionRelease, Score: 0, Runs: 10, Reason: "flake score 0.000 over 10 runs is below 0.200"},
+		{Time: now, Scope: "org/repo@main", Test: "new-flake", Action: ActionQuarantine, Score: 0.4, Runs: 10, Reason: "flake score 0.400 over 10 runs is at least 0.200"},
+		{Time: now, Scope: "org/repo@main", Test: "still-flaky", Action: ActionRenew, Score: 0.3, Runs: 10, Reason: "flake score 0.300 over 10 runs is at least 0.200"},
+		{Time: now, Scope: "org/repo@main", Test: "expired", Action: ActionExpire, Score: 0.3, Runs: 20, Reason: "quarantine expired at 2024-01-01T00:00:00Z"},
+	}
+	if diff := cmp.Diff(expectedDecisions, decisions); diff != "" {
+		t.Errorf("unexpected decisions: %s", diff)
+	}
+	expectedList := &List{Scopes: map[string]map[string]Entry{
+		"org/repo@main": {
+			"new-flake":     {Score: 0.4, Runs: 10, Reason: "flake score 0.400 over 10 runs is at least 0.200", Since: now, Expires: now.Add(48 * time.Hour)},
+			"still-flaky":   {Score: 0.3, Runs: 10, Reason: "flake score 0.300 over 10 runs is at least 0.200", Since: earlier, Expires: now.Add(48 * time.Hour)},
+			"not-evaluated": {Score: 0.3, Runs: 20, Reason: "old", Since: earlier, Expires: now.Add(time.Hour)},
+		},
+		"org/repo@release": {
+			"expired": {Expires: earlier},
+		},
+	}}
+	if diff := cmp.Diff(expectedList, list); diff != "" {
+		t.Errorf("unexpected list: %s", diff)
+	}
+
+	if decisions := Update(list, other, nil, policy, now); len(decisions) != 1 || decisions[0].Action != ActionExpire {
+		t.Errorf("expected the entry of the other scope to expire, got %v", decisions)
+	}
+	if _, ok := list.Scopes[other.String()]; ok {
+		t.Error("expected the empty scope to be removed")
+	}
+}
+
+func TestAppendAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, decision := range []Decision{
+		{Time: now, Scope: "org/repo@main", Test: "a", Action: ActionQuarantine, Score: 0.5, Runs: 10, Reason: "flaky"},
+		{Time: now, Scope: "org/repo@main", Test: "b", Action: ActionRelease, Runs: 10, Reason: "fixed"},
+	} {
+		if err := AppendAudit(path, []Decision{decision}); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		`{"time":"2024-01-01T00:00:00Z","scope":"org/repo@main","test":"a","action":"quarantine","score":0.5,"runs":10,"reason":"flaky"}`,
+		`{"time":"2024-01-01T00:00:00Z","scope":"org/repo@main","test":"b","action":"release","score":0,"runs":10,"reason":"fixed"}`,
+		"",
+	}, "\n")
+	if diff := cmp.Diff(expected, string(raw)); diff != "" {
+		t.Errorf("unexpected audit log: %s", diff)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/api"
 )
 
 // Type identifies the type of registry element a Node refers to
@@ -319,7 +321,7 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 		}
 		chainNodes[name] = node
 		nodesByName.Chains[name] = node
-		for _, step := range chain.Steps {
+		for _, step := range api.FlattenTestSteps(chain.Steps) {
 			if step.Reference != nil {
 				if _, exists := referenceNodes[*step.Reference]; !exists {
 					return nodesByName, fmt.Errorf("Chain %s contains non-existent reference %s", name, *step.Reference)
@@ -370,7 +372,7 @@ func NewGraph(stepsByName ReferenceByName, chainsByName ChainByName, workflowsBy
 			}
 		}
 		steps := append(workflow.Pre, append(workflow.Test, workflow.Post...)...)
-		for _, step := range steps {
+		for _, step := range api.FlattenTestSteps(steps) {
 			if step.Reference != nil {
 				if _, exists := referenceNodes[*step.Reference]; !exists {
 					return nodesByName, fmt.Errorf("Workflow %s contains non-existent reference %s", name, *step.Reference)
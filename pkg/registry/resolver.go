@@ -121,6 +121,9 @@ func (r *registry) mergeWorkflow(config *api.MultiStageTestConfiguration) ([][]a
 	if config.AllowBestEffortPostSteps == nil {
 		config.AllowBestEffortPostSteps = workflow.AllowBestEffortPostSteps
 	}
+	if config.CachePre == nil {
+		config.CachePre = workflow.CachePre
+	}
 	return overridden, errs
 }
 
@@ -134,6 +137,7 @@ func (r *registry) resolveTest(
 		ClusterProfile:           config.ClusterProfile,
 		AllowSkipOnSuccess:       config.AllowSkipOnSuccess,
 		AllowBestEffortPostSteps: config.AllowBestEffortPostSteps,
+		CachePre:                 config.CachePre,
 		Leases:                   config.Leases,
 		DependencyOverrides:      config.DependencyOverrides,
 	}
@@ -266,21 +270,68 @@ func mergeLeases(dst, src []api.StepLease) ([]api.StepLease, error) {
 
 func (r *registry) process(steps []api.TestStep, seen sets.Set[string], stack stack) (ret []api.LiteralTestStep, errs []error) {
 	for _, step := range steps {
-		if step.Chain != nil {
+		var processed []api.LiteralTestStep
+		if step.Parallel != nil {
+			steps, err := r.process(step.ParallelSteps(), seen, stack)
+			errs = append(errs, err...)
+			processed = parallelGroup(steps)
+		} else if step.Chain != nil {
 			steps, err := r.processChain(*step.Chain, seen, stack)
 			errs = append(errs, err...)
-			ret = append(ret, steps...)
+			processed = steps
 		} else {
 			step, err := r.processStep(&step, seen, stack)
 			errs = append(errs, err...)
 			if err == nil {
-				ret = append(ret, step)
+				if step.When == "" {
+					processed = append(processed, step)
+				} else if names, err := api.WhenVariables(step.When); err != nil {
+					errs = append(errs, stack.errorf("step/%s: %v", step.As, err))
+				} else {
+					declareWhenParameters(&step, names, stack)
+					processed = append(processed, step)
+				}
 			}
 		}
+		if step.When == "" {
+			ret = append(ret, processed...)
+			continue
+		}
+		names, err := api.WhenVariables(step.When)
+		if err != nil {
+			errs = append(errs, stack.errorf("%v", err))
+			continue
+		}
+		for _, s := range processed {
+			s.When = combineWhen(step.When, s.When)
+			declareWhenParameters(&s, names, stack)
+			ret = append(ret, s)
+		}
 	}
 	return
 }
 
+// parallelGroup makes steps part of a single parallel group, named after the
+// first step.  Groups nested in the steps are merged into it.
+func parallelGroup(steps []api.LiteralTestStep) []api.LiteralTestStep {
+	for i := range steps {
+		steps[i].ParallelGroup = steps[0].As
+	}
+	return steps
+}
+
+// combineWhen joins two conditions which must both be true.
+func combineWhen(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return fmt.Sprintf("(%s) && (%s)", a, b)
+	}
+}
+
 func (r *registry) processChain(name string, seen sets.Set[string], stack stack) ([]api.LiteralTestStep, []error) {
 	chain, ok := r.chainsByName[name]
 	if !ok {
@@ -291,6 +342,9 @@ func (r *registry) processChain(name string, seen sets.Set[string], stack stack)
 	defer stack.pop()
 	ret, err := r.process(chain.Steps, seen, stack)
 	err = append(err, stack.checkUnused(&rec, nil, r)...)
+	if chain.Parallel {
+		ret = parallelGroup(ret)
+	}
 	return ret, err
 }
 
@@ -345,6 +399,33 @@ func (r *registry) processStep(step *api.TestStep, seen sets.Set[string], stack
 	return ret, errs
 }
 
+// declareWhenParameters adds the parameters a condition refers to, which are
+// known at this point to those of the step.  Conditions are only evaluated
+// right before the step is executed, since the parameters passed to
+// ci-operator override those in the configuration; declaring them makes the
+// values of parameters set for e.g. the chain of the step available then and
+// lets them be overridden like those of the step.
+func declareWhenParameters(step *api.LiteralTestStep, names []string, stack stack) {
+	declared := sets.New[string]()
+	for _, e := range step.Environment {
+		declared.Insert(e.Name)
+	}
+	for _, name := range names {
+		if declared.Has(name) {
+			continue
+		}
+		if v := stack.resolve(name); v != nil {
+			// Never append to the parameters of the registry step
+			step.Environment = append(step.Environment[:len(step.Environment):len(step.Environment)], api.StepParameter{
+				Name:          name,
+				Default:       v,
+				Documentation: "Referenced by the condition of the step.",
+			})
+			declared.Insert(name)
+		}
+	}
+}
+
 func (r *registry) processObservers(observerNames sets.Set[string], stack stack) (ret []api.Observer, errs []error) {
 	for _, name := range sets.List(observerNames) {
 		observer, exists := r.observersByName[name]
@@ -381,6 +462,12 @@ func (r *registry) iterateSteps(s api.TestStep, f func(*api.LiteralTestStep)) er
 				return err
 			}
 		}
+	case s.Parallel != nil:
+		for _, s := range s.ParallelSteps() {
+			if err := r.iterateSteps(s, f); err != nil {
+				return err
+			}
+		}
 	case s.Reference != nil:
 		r, ok := r.stepsByName[*s.Reference]
 		if !ok {
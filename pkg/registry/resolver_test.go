@@ -2042,3 +2042,146 @@ func TestResolveLeasesCopy(t *testing.T) {
 	expected := []api.StepLease{{Count: 42}, {Count: 0}}
 	testhelper.Diff(t, "leases", leases, expected)
 }
+
+func TestResolveWhen(t *testing.T) {
+	fipsRef, awsRef, runtimeRef, awsChain, fipsChain := "fips", "aws", "runtime", "aws-chain", "fips-chain"
+	disabled, enabled, aws := "false", "true", "aws"
+	refs := ReferenceByName{
+		fipsRef: {
+			As:          fipsRef,
+			When:        "FIPS_ENABLED",
+			Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Default: &disabled}},
+		},
+		awsRef:     {As: awsRef},
+		runtimeRef: {As: runtimeRef, When: "RUNTIME_ONLY"},
+	}
+	chains := ChainByName{
+		awsChain: {Steps: []api.TestStep{{Reference: &awsRef}, {Reference: &runtimeRef}}},
+		fipsChain: {
+			Steps:       []api.TestStep{{Reference: &fipsRef}, {Reference: &awsRef, When: "FIPS_ENABLED"}},
+			Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Default: &enabled}},
+		},
+	}
+	declared := func(name string, value *string) []api.StepParameter {
+		return []api.StepParameter{{Name: name, Default: value, Documentation: "Referenced by the condition of the step."}}
+	}
+	type step struct {
+		As, When    string
+		Environment []api.StepParameter
+	}
+	for _, tc := range []struct {
+		name        string
+		test        api.MultiStageTestConfiguration
+		expected    []step
+		expectedErr error
+	}{{
+		name:     "condition of a step false with the default value of its parameter is kept",
+		test:     api.MultiStageTestConfiguration{Test: []api.TestStep{{Reference: &fipsRef}}},
+		expected: []step{{As: fipsRef, When: "FIPS_ENABLED", Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Default: &disabled}}}},
+	}, {
+		name: "condition of a step true with a parameter set in the test is kept",
+		test: api.MultiStageTestConfiguration{
+			Test:        []api.TestStep{{Reference: &fipsRef}},
+			Environment: api.TestEnvironment{"FIPS_ENABLED": "true"},
+		},
+		expected: []step{{As: fipsRef, When: "FIPS_ENABLED", Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Default: &enabled}}}},
+	}, {
+		name: "parameter set in the test is declared for the steps of a chain",
+		test: api.MultiStageTestConfiguration{
+			Test:        []api.TestStep{{Chain: &awsChain, When: `PLATFORM == "aws"`}},
+			Environment: api.TestEnvironment{"PLATFORM": "aws"},
+		},
+		expected: []step{
+			{As: awsRef, When: `PLATFORM == "aws"`, Environment: declared("PLATFORM", &aws)},
+			{As: runtimeRef, When: `(PLATFORM == "aws") && (RUNTIME_ONLY)`, Environment: declared("PLATFORM", &aws)},
+		},
+	}, {
+		name: "parameter of a chain is declared for a step which does not declare it",
+		test: api.MultiStageTestConfiguration{
+			Test: []api.TestStep{{Chain: &fipsChain}},
+		},
+		expected: []step{
+			{As: fipsRef, When: "FIPS_ENABLED", Environment: []api.StepParameter{{Name: "FIPS_ENABLED", Default: &enabled}}},
+			{As: awsRef, When: "FIPS_ENABLED", Environment: declared("FIPS_ENABLED", &enabled)},
+		},
+	}, {
+		name: "unknown parameters are left to the runtime",
+		test: api.MultiStageTestConfiguration{
+			Test: []api.TestStep{{Chain: &awsChain, When: `CLUSTER_TYPE == "aws"`}},
+		},
+		expected: []step{
+			{As: awsRef, When: `CLUSTER_TYPE == "aws"`},
+			{As: runtimeRef, When: `(CLUSTER_TYPE == "aws") && (RUNTIME_ONLY)`},
+		},
+	}, {
+		name: "invalid condition",
+		test: api.MultiStageTestConfiguration{
+			Test: []api.TestStep{{Reference: &awsRef, When: "PLATFORM =="}},
+		},
+		expectedErr: errors.New(`test/test: invalid condition "PLATFORM ==": expected a quoted string after "==", got ""`),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ret, err := NewResolver(refs, chains, nil, nil).Resolve("test", tc.test)
+			if diff := cmp.Diff(tc.expectedErr, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %v", diff)
+			}
+			var steps []step
+			for _, s := range ret.Test {
+				steps = append(steps, step{As: s.As, When: s.When, Environment: s.Environment})
+			}
+			if diff := cmp.Diff(tc.expected, steps); diff != "" {
+				t.Errorf("unexpected steps: %v", diff)
+			}
+		})
+	}
+	if refs[awsRef].Environment != nil {
+		t.Errorf("the parameters of the registry step were modified: %v", refs[awsRef].Environment)
+	}
+}
+
+func TestResolveParallel(t *testing.T) {
+	refA, refB, refC, parallelChain := "a", "b", "c", "parallel-chain"
+	refs := ReferenceByName{
+		refA: {As: refA},
+		refB: {As: refB},
+		refC: {As: refC},
+	}
+	chains := ChainByName{
+		parallelChain: {Steps: []api.TestStep{{Reference: &refB}, {Reference: &refC}}, Parallel: true},
+	}
+	type step struct{ As, ParallelGroup string }
+	for _, tc := range []struct {
+		name     string
+		test     []api.TestStep
+		expected []step
+	}{{
+		name: "parallel group of references",
+		test: []api.TestStep{
+			{Reference: &refA},
+			{Parallel: []api.ParallelTestStep{{Reference: &refB}, {Reference: &refC}}},
+		},
+		expected: []step{{As: refA}, {As: refB, ParallelGroup: refB}, {As: refC, ParallelGroup: refB}},
+	}, {
+		name:     "parallel chain",
+		test:     []api.TestStep{{Chain: &parallelChain}, {Reference: &refA}},
+		expected: []step{{As: refB, ParallelGroup: refB}, {As: refC, ParallelGroup: refB}, {As: refA}},
+	}, {
+		name:     "nested groups are merged",
+		test:     []api.TestStep{{Parallel: []api.ParallelTestStep{{Reference: &refA}, {Chain: &parallelChain}}}},
+		expected: []step{{As: refA, ParallelGroup: refA}, {As: refB, ParallelGroup: refA}, {As: refC, ParallelGroup: refA}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ret, err := NewResolver(refs, chains, nil, nil).Resolve("test", api.MultiStageTestConfiguration{Test: tc.test})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var steps []step
+			for _, s := range ret.Test {
+				steps = append(steps, step{As: s.As, ParallelGroup: s.ParallelGroup})
+			}
+			if diff := cmp.Diff(tc.expected, steps); diff != "" {
+				t.Errorf("unexpected steps: %v", diff)
+			}
+		})
+	}
+}
@@ -42,7 +42,7 @@ import (
 
 const (
 	// Label is the label key for the pull request we are rehearsing for
-	Label = "ci.openshift.io/rehearse"
+	Label = api.RehearsalJobLabel
 	// LabelContext exposes the context the job would have had running normally
 	LabelContext = "ci.openshift.io/rehearse.context"
 
@@ -650,7 +650,7 @@ func selectJobsForRegistryStep(node registry.Node, configs []*config.DataWithInf
 				continue
 			}
 			testSteps := append(test.MultiStageTestConfiguration.Pre, append(test.MultiStageTestConfiguration.Test, test.MultiStageTestConfiguration.Post...)...)
-			for _, testStep := range testSteps {
+			for _, testStep := range api.FlattenTestSteps(testSteps) {
 				hasRef := testStep.Reference != nil && node.Type() == registry.Reference && node.Name() == *testStep.Reference
 				hasChain := testStep.Chain != nil && node.Type() == registry.Chain && node.Name() == *testStep.Chain
 				if hasRef || hasChain {
@@ -0,0 +1,252 @@
+package results
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Fine-grained reasons attached to failures by the default classification
+// rules. The prefix tells infrastructure failures from product and test ones.
+const (
+	ReasonInfraQuota            Reason = "infra:quota"
+	ReasonInfraImagePull        Reason = "infra:image-pull"
+	ReasonProductInstallTimeout Reason = "product:install-timeout"
+	ReasonTestFlake             Reason = "test:flake"
+)
+
+// ClassificationProperty is the property of a jUnit test case holding a
+// fine-grained reason of its failure, once per reason.
+const ClassificationProperty = "classification"
+
+// Source is where evidence of a failure was found.
+type Source string
+
+const (
+	// SourceLog is the output of a step: the message of its error and the
+	// tail of the logs of its failed containers.
+	SourceLog Source = "log"
+	// SourceEvent is an event recorded for a pod of a step.
+	SourceEvent Source = "event"
+	// SourceTerminationMessage is the reason and message of a container that
+	// exited with a non-zero code.
+	SourceTerminationMessage Source = "termination-message"
+)
+
+var sources = map[Source]bool{SourceLog: true, SourceEvent: true, SourceTerminationMessage: true}
+
+// Evidence is text from a source that may explain a failure.
+type Evidence struct {
+	Source Source
+	Text   string
+}
+
+// ClassificationRule attaches a reason to failures with evidence matching
+// the pattern.
+type ClassificationRule struct {
+	// Reason is attached to the failure when the rule matches.
+	Reason Reason `json:"reason"`
+	// Sources limits the evidence the rule is matched against. All sources are
+	// used when it is empty.
+	Sources []Source `json:"sources,omitempty"`
+	// Pattern is a regular expression matched against the evidence.
+	Pattern string `json:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+// ClassifierConfig holds the rules used to classify failures.
+type ClassifierConfig struct {
+	Rules []ClassificationRule `json:"rules"`
+}
+
+// DefaultClassifierConfig recognizes common causes of failures.
+var DefaultClassifierConfig = ClassifierConfig{
+	Rules: []ClassificationRule{
+		{
+			Reason:  ReasonInfraQuota,
+			Pattern: `(?i)exceeded quota|quota exceeded|QuotaExceeded|RequestLimitExceeded|VcpuLimitExceeded`,
+		},
+		{
+			Reason:  ReasonInfraImagePull,
+			Sources: []Source{SourceEvent, SourceTerminationMessage},
+			Pattern: `ErrImagePull|ImagePullBackOff|(?i)failed to pull image`,
+		},
+		{
+			Reason:  ReasonProductInstallTimeout,
+			Sources: []Source{SourceLog, SourceTerminationMessage},
+			Pattern: `(?i)(bootstrap failed to complete|failed to initialize the cluster|failed waiting for kubernetes api|timed out waiting for the condition.*install)`,
+		},
+		{
+			Reason:  ReasonTestFlake,
+			Sources: []Source{SourceLog},
+			Pattern: `(?m)^Flaky tests:`,
+		},
+	},
+}
+
+// Classifier attaches fine-grained reasons to failures from the evidence
+// found for them.
+type Classifier struct {
+	rules []ClassificationRule
+}
+
+// NewClassifier validates the configuration and compiles its patterns.
+func NewClassifier(config ClassifierConfig) (*Classifier, error) {
+	var errs []error
+	rules := make([]ClassificationRule, 0, len(config.Rules))
+	for i, rule := range config.Rules {
+		if rule.Reason == "" {
+			errs = append(errs, fmt.Errorf("rules[%d]: reason must be set", i))
+		}
+		for _, source := range rule.Sources {
+			if !sources[source] {
+				errs = append(errs, fmt.Errorf("rules[%d]: unknown source %q", i, source))
+			}
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rules[%d]: invalid pattern: %w", i, err))
+			continue
+		}
+		rule.pattern = pattern
+		rules = append(rules, rule)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &Classifier{rules: rules}, nil
+}
+
+// LoadClassifier loads a ClassifierConfig from a YAML file.
+func LoadClassifier(path string) (*Classifier, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read classifier config: %w", err)
+	}
+	var config ClassifierConfig
+	if err := yaml.UnmarshalStrict(raw, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal classifier config: %w", err)
+	}
+	return NewClassifier(config)
+}
+
+// Match returns the reasons of all rules matching the evidence, in the order
+// the rules are configured. A nil Classifier matches nothing.
+func (c *Classifier) Match(evidence ...Evidence) []Reason {
+	if c == nil {
+		return nil
+	}
+	var ret []Reason
+	for _, rule := range c.rules {
+		if rule.matches(evidence) {
+			ret = append(ret, rule.Reason)
+		}
+	}
+	return ret
+}
+
+func (r *ClassificationRule) matches(evidence []Evidence) bool {
+	for _, e := range evidence {
+		if len(r.Sources) > 0 && !slices.Contains(r.Sources, e.Source) {
+			continue
+		}
+		if r.pattern.MatchString(e.Text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Classify matches the evidence attached to the error, along with its
+// message, against the rules and attaches the resulting reasons to it. A nil
+// Classifier leaves the error unchanged.
+func (c *Classifier) Classify(err error) error {
+	if c == nil || err == nil {
+		return err
+	}
+	evidence := append(EvidenceFor(err), Evidence{Source: SourceLog, Text: err.Error()})
+	reasons := c.Match(evidence...)
+	if len(reasons) == 0 {
+		return err
+	}
+	return &classifiedError{reasons: reasons, wrapped: err}
+}
+
+type evidenceError struct {
+	evidence []Evidence
+	wrapped  error
+}
+
+func (e *evidenceError) Error() string { return e.wrapped.Error() }
+func (e *evidenceError) Unwrap() error { return e.wrapped }
+
+// WithEvidence attaches evidence explaining the error to it, to be used when
+// the failure is classified.
+func WithEvidence(err error, evidence ...Evidence) error {
+	if err == nil || len(evidence) == 0 {
+		return err
+	}
+	return &evidenceError{evidence: evidence, wrapped: err}
+}
+
+// EvidenceFor collects the evidence attached anywhere in the errors.
+func EvidenceFor(errs ...error) (ret []Evidence) {
+	walk(errs, func(err error) {
+		if e, ok := err.(*evidenceError); ok {
+			ret = append(ret, e.evidence...)
+		}
+	})
+	return
+}
+
+type classifiedError struct {
+	reasons []Reason
+	wrapped error
+}
+
+func (e *classifiedError) Error() string { return e.wrapped.Error() }
+func (e *classifiedError) Unwrap() error { return e.wrapped }
+
+// Classifications provides the sorted, unique fine-grained reasons attached
+// anywhere in the errors by a Classifier.
+func Classifications(errs ...error) []string {
+	seen := map[string]bool{}
+	var ret []string
+	walk(errs, func(err error) {
+		if e, ok := err.(*classifiedError); ok {
+			for _, reason := range e.reasons {
+				if !seen[string(reason)] {
+					seen[string(reason)] = true
+					ret = append(ret, string(reason))
+				}
+			}
+		}
+	})
+	sort.Strings(ret)
+	return ret
+}
+
+// walk visits every error in the chains, expanding aggregate errors the same
+// way Reasons does.
+func walk(errs []error, visit func(error)) {
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		visit(err)
+		switch err := err.(type) {
+		case interface{ Errors() []error }:
+			walk(err.Errors(), visit)
+		case interface{ Unwrap() []error }:
+			walk(err.Unwrap(), visit)
+		case interface{ Unwrap() error }:
+			walk([]error{err.Unwrap()}, visit)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package results
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestNewClassifier(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   ClassifierConfig
+		expected error
+	}{
+		{
+			name:   "default config is valid",
+			config: DefaultClassifierConfig,
+		},
+		{
+			name: "invalid rules",
+			config: ClassifierConfig{Rules: []ClassificationRule{
+				{Pattern: "valid"},
+				{Reason: "infra:other", Sources: []Source{"stdout"}, Pattern: "("},
+			}},
+			expected: errors.Join(
+				errors.New("rules[0]: reason must be set"),
+				errors.New(`rules[1]: unknown source "stdout"`),
+				errors.New("rules[1]: invalid pattern: error parsing regexp: missing closing ): `(`"),
+			),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewClassifier(tc.config)
+			if diff := cmp.Diff(tc.expected, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadClassifier(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(`rules:
+- reason: infra:dns
+  sources:
+  - event
+  pattern: no such host
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	classifier, err := LoadClassifier(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]Reason{"infra:dns"}, classifier.Match(Evidence{Source: SourceEvent, Text: "dial tcp: lookup registry: no such host"})); diff != "" {
+		t.Errorf("unexpected reasons: %s", diff)
+	}
+}
+
+func TestClassifierMatch(t *testing.T) {
+	classifier, err := NewClassifier(DefaultClassifierConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name     string
+		evidence []Evidence
+		expected []Reason
+	}{
+		{
+			name:     "no evidence",
+			expected: nil,
+		},
+		{
+			name: "quota exceeded in any source",
+			evidence: []Evidence{
+				{Source: SourceLog, Text: "Error: VcpuLimitExceeded: You have requested more vCPU capacity"},
+			},
+			expected: []Reason{ReasonInfraQuota},
+		},
+		{
+			name: "image pull failure in an event",
+			evidence: []Evidence{
+				{Source: SourceEvent, Text: "Failed to pull image \"quay.io/foo/bar\": manifest unknown"},
+			},
+			expected: []Reason{ReasonInfraImagePull},
+		},
+		{
+			name: "image pull failure is only matched in events and termination messages",
+			evidence: []Evidence{
+				{Source: SourceLog, Text: "the test checks that ImagePullBackOff is reported"},
+			},
+		},
+		{
+			name: "install timeout and flake",
+			evidence: []Evidence{
+				{Source: SourceLog, Text: "level=error msg=Bootstrap failed to complete: timed out"},
+				{Source: SourceLog, Text: "some output\nFlaky tests:\n\n[sig-network] foo"},
+			},
+			expected: []Reason{ReasonProductInstallTimeout, ReasonTestFlake},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, classifier.Match(tc.evidence...)); diff != "" {
+				t.Errorf("unexpected reasons: %s", diff)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	classifier, err := NewClassifier(DefaultClassifierConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pullFailure := WithEvidence(errors.New("pod failed"), Evidence{Source: SourceEvent, Text: "Back-off pulling image: ImagePullBackOff"})
+	quotaFailure := fmt.Errorf("step failed: %w", errors.New("exceeded quota: compute-resources"))
+	wrapped := ForReason("step_failed").ForError(utilerrors.NewAggregate([]error{pullFailure, quotaFailure}))
+
+	classified := classifier.Classify(wrapped)
+	if diff := cmp.Diff(wrapped.Error(), classified.Error()); diff != "" {
+		t.Errorf("classification changed the message: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"step_failed"}, Reasons(classified)); diff != "" {
+		t.Errorf("classification changed the reasons: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"infra:image-pull", "infra:quota"}, Classifications(ForReason("outer").ForError(classified))); diff != "" {
+		t.Errorf("unexpected classifications: %s", diff)
+	}
+	if !errors.Is(classified, &Error{}) {
+		t.Error("expected the classified error to still be an Error")
+	}
+
+	unclassified := errors.New("something else")
+	if classifier.Classify(unclassified) != unclassified {
+		t.Error("expected an error without matching evidence to be unchanged")
+	}
+	var nilClassifier *Classifier
+	if nilClassifier.Classify(wrapped) != wrapped {
+		t.Error("expected a nil classifier to leave the error unchanged")
+	}
+}
@@ -94,6 +94,9 @@ type Request struct {
 	State string `json:"state"`
 	// Reason is a colon-delimited list of reasons for failure
 	Reason string `json:"reason"`
+	// Classifications are the fine-grained reasons attached to the failure
+	// by matching its evidence against the classification rules
+	Classifications []string `json:"classifications,omitempty"`
 }
 
 // PodScalerRequest holds the data from pod-scaler used to report a result to an aggregation server
@@ -105,6 +108,14 @@ type PodScalerRequest struct {
 	ResourceType     string
 }
 
+// PodScalerOOMKillRequest holds the data from pod-scaler used to report a workload which
+// was recently OOM-killed to an aggregation server
+type PodScalerOOMKillRequest struct {
+	WorkloadName     string
+	WorkloadType     string
+	DeterminedAmount string
+}
+
 const (
 	StateSucceeded string = "succeeded"
 	StateFailed    string = "failed"
@@ -139,13 +150,15 @@ func (r *reporter) Report(err error) {
 	if len(reasons) == 0 {
 		reasons = []string{string(ReasonUnknown)}
 	}
+	classifications := Classifications(err)
 	for _, reason := range reasons {
 		r.report(Request{
-			JobName: r.spec.Job,
-			Type:    string(r.spec.Type),
-			Cluster: r.consoleHost,
-			State:   state,
-			Reason:  reason,
+			JobName:         r.spec.Job,
+			Type:            string(r.spec.Type),
+			Cluster:         r.consoleHost,
+			State:           state,
+			Reason:          reason,
+			Classifications: classifications,
 		})
 	}
 }
@@ -160,6 +173,9 @@ func (r *reporter) report(request Request) {
 	reportMsg := fmt.Sprintf("Reporting job state '%s'", request.State)
 	if request.State != StateSucceeded {
 		reportMsg = fmt.Sprintf("Reporting job state '%s' with reason '%s'", request.State, request.Reason)
+		if len(request.Classifications) > 0 {
+			reportMsg = fmt.Sprintf("%s classified as %s", reportMsg, strings.Join(request.Classifications, ", "))
+		}
 	}
 
 	logrus.Infof("%s", reportMsg)
@@ -173,6 +189,7 @@ func (r *reporter) report(request Request) {
 
 type PodScalerReporter interface {
 	ReportResourceConfigurationWarning(workloadName, workloadType, configuredAmount, determinedAmount, resourceType string)
+	ReportOOMKilledWorkload(workloadName, workloadType, determinedAmount string)
 }
 
 type podScalerReporter struct {
@@ -221,6 +238,30 @@ func (r *podScalerReporter) ReportResourceConfigurationWarning(workloadName, wor
 	sendRequest(httpRequest, r.client, r.username, r.password)
 }
 
+// ReportOOMKilledWorkload is used to send the information about a workload that was recently
+// OOM-killed, and for which pod-scaler-admission raised the memory, to result-aggregator.
+func (r *podScalerReporter) ReportOOMKilledWorkload(workloadName, workloadType, determinedAmount string) {
+	request := PodScalerOOMKillRequest{
+		WorkloadName:     workloadName,
+		WorkloadType:     workloadType,
+		DeterminedAmount: determinedAmount,
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		logrus.Tracef("could not marshal pod-scaler OOM-kill request: %v", err)
+		return
+	}
+
+	httpRequest, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/pod-scaler/oom-killed", r.address), bytes.NewReader(data))
+	if err != nil {
+		logrus.Tracef("could not create pod-scaler OOM-kill request: %v", err)
+		return
+	}
+
+	sendRequest(httpRequest, r.client, r.username, r.password)
+}
+
 func sendRequest(req *http.Request, client *http.Client, username, password string) {
 	req.Header.Set("Content-Type", "application/json")
 	req.SetBasicAuth(username, password)
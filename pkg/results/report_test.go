@@ -58,6 +58,16 @@ func TestReporter_Report(t *testing.T) {
 			err:         ForReason("because").WithError(ForReason("something").ForError(errors.New("oops"))).Errorf("argh"),
 			expected:    `{"job_name":"runme","type":"presubmit","cluster":"foo.com","state":"failed","reason":"because:something"}`,
 		},
+		{
+			name:        "classified err reports failure with classifications",
+			spec:        &api.JobSpec{JobSpec: downwardapi.JobSpec{Job: "runme", Type: v1.PresubmitJob}},
+			consoleHost: "foo.com",
+			err: ForReason("because").ForError(&classifiedError{
+				reasons: []Reason{ReasonInfraQuota},
+				wrapped: errors.New("oops"),
+			}),
+			expected: `{"job_name":"runme","type":"presubmit","cluster":"foo.com","state":"failed","reason":"because","classifications":["infra:quota"]}`,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -273,6 +283,67 @@ func TestReportMemoryConfigurationWarning(t *testing.T) {
 	}
 }
 
+func TestReportOOMKilledWorkload(t *testing.T) {
+	testCases := []struct {
+		name             string
+		workloadName     string
+		workloadType     string
+		determinedMemory string
+		expected         string
+	}{
+		{
+			name:             "valid request",
+			workloadName:     "name",
+			workloadType:     "step",
+			determinedMemory: "300",
+			expected:         `{"WorkloadName":"name","WorkloadType":"step","DeterminedAmount":"300"}`,
+		},
+		{
+			name:             "empty workload name",
+			workloadName:     "",
+			workloadType:     "build",
+			determinedMemory: "300",
+			expected:         `{"WorkloadName":"","WorkloadType":"build","DeterminedAmount":"300"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testServer := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				if request.Method != http.MethodPost {
+					t.Errorf("incorrect method: %s", request.Method)
+					return
+				}
+
+				if !strings.HasSuffix(request.URL.Path, "/pod-scaler/oom-killed") {
+					t.Errorf("incorrect path: %s", request.URL.Path)
+					return
+				}
+
+				requestBody, err := io.ReadAll(request.Body)
+				if err != nil {
+					t.Errorf("failed to read request body: %v", err)
+				}
+
+				if diff := cmp.Diff(tc.expected, string(requestBody)); diff != "" {
+					t.Errorf("actual and expected response don't match, diff: %v", diff)
+				}
+			}))
+			defer testServer.Close()
+
+			podScalerReporter := podScalerReporter{
+				client: &http.Client{
+					Transport: &http.Transport{
+						TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+					},
+				},
+				address: testServer.URL,
+			}
+			podScalerReporter.ReportOOMKilledWorkload(tc.workloadName, tc.workloadType, tc.determinedMemory)
+		})
+	}
+}
+
 func TestOptions_Validate(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -2,8 +2,17 @@ package retester
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/prow/pkg/tide"
+	"sigs.k8s.io/yaml"
 )
 
 type retestBackoffAction int
@@ -15,7 +24,208 @@ const (
 )
 
 type backoffCache interface {
-	check(pr tide.PullRequest, baseSha string, policy RetesterPolicy) (retestBackoffAction, string)
+	check(pr tide.PullRequest, baseSha string, policy RetesterPolicy, failures []jobFailure) (retestBackoffAction, string)
+	// failedRuns returns the runs of required jobs recorded failing on the
+	// HEAD of the pull request, keyed by their URL.
+	failedRuns(pr tide.PullRequest) map[string]failedRun
 	load(ctx context.Context) error
 	save(ctx context.Context) error
 }
+
+// Storage persists the backoff cache between runs of the retester. The
+// retester serializes the cache and prunes old records from it, so a Storage
+// only keeps the content at its location.
+type Storage interface {
+	fmt.Stringer
+	// Load returns the stored content, or nil if nothing was stored yet.
+	Load(ctx context.Context) ([]byte, error)
+	// Save replaces the stored content.
+	Save(ctx context.Context, content []byte) error
+}
+
+// storageBackoffCache keeps the backoff records in memory and persists them
+// in a Storage. Records are only kept in memory when it has no Storage. In
+// dry-run mode, the records are neither updated nor persisted.
+type storageBackoffCache struct {
+	cache          map[string]*pullRequest
+	storage        Storage
+	cacheRecordAge time.Duration
+	dryRun         bool
+	logger         *logrus.Entry
+}
+
+func newBackoffCache(storage Storage, cacheRecordAge time.Duration, dryRun bool, logger *logrus.Entry) *storageBackoffCache {
+	return &storageBackoffCache{cache: map[string]*pullRequest{}, storage: storage, cacheRecordAge: cacheRecordAge, dryRun: dryRun, logger: logger}
+}
+
+func (b *storageBackoffCache) load(ctx context.Context) error {
+	return b.loadNow(ctx, time.Now())
+}
+
+func (b *storageBackoffCache) loadNow(ctx context.Context, now time.Time) error {
+	if b.storage == nil {
+		return nil
+	}
+	b.logger.WithField("storage", b.storage.String()).Info("Loading the backoff cache ...")
+	content, err := b.storage.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if content == nil {
+		b.logger.WithField("storage", b.storage.String()).Info("backoff cache does not exist yet")
+		return nil
+	}
+	cache, err := loadAndDelete(content, b.logger, now, b.cacheRecordAge)
+	if err != nil {
+		return err
+	}
+	b.cache = cache
+	return nil
+}
+
+// loadAndDelete loads content into cache and deletes old records from cache
+func loadAndDelete(content []byte, logger *logrus.Entry, now time.Time, cacheRecordAge time.Duration) (map[string]*pullRequest, error) {
+	cache := map[string]*pullRequest{}
+	if err := yaml.Unmarshal(content, &cache); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal: %w", err)
+	}
+	for key, pr := range cache {
+		if age := now.Sub(pr.LastConsideredTime.Time); age > cacheRecordAge {
+			logger.WithField("key", key).WithField("LastConsideredTime", pr.LastConsideredTime).
+				WithField("age", age).Info("deleting old record from cache")
+			delete(cache, key)
+		}
+	}
+	return cache, nil
+}
+
+func (b *storageBackoffCache) save(ctx context.Context) error {
+	if b.storage == nil || b.dryRun {
+		return nil
+	}
+	content, err := yaml.Marshal(b.cache)
+	if err != nil {
+		return fmt.Errorf("failed to marshal: %w", err)
+	}
+	return b.storage.Save(ctx, content)
+}
+
+func (b *storageBackoffCache) check(pr tide.PullRequest, baseSha string, policy RetesterPolicy, failures []jobFailure) (retestBackoffAction, string) {
+	if b.dryRun {
+		// decide on a copy of the record, so that the actions which are
+		// only logged do not count against the budgets
+		cache := maps.Clone(b.cache)
+		if record, has := cache[prKey(&pr)]; has {
+			cache[prKey(&pr)] = record.deepCopy()
+		}
+		return check(&cache, pr, baseSha, policy, failures, time.Now())
+	}
+	return check(&b.cache, pr, baseSha, policy, failures, time.Now())
+}
+
+func (b *storageBackoffCache) failedRuns(pr tide.PullRequest) map[string]failedRun {
+	if record, has := b.cache[prKey(&pr)]; has && record.PRSha == string(pr.HeadRefOID) {
+		return record.FailedRuns
+	}
+	return nil
+}
+
+// check updates the cache and returns a retestBackoffAction according to baseSha, policy, failures of the required jobs, and number of retests performed for the PR.
+func check(cache *map[string]*pullRequest, pr tide.PullRequest, baseSha string, policy RetesterPolicy, failures []jobFailure, now time.Time) (retestBackoffAction, string) {
+	key := prKey(&pr)
+	if _, has := (*cache)[key]; !has {
+		(*cache)[key] = &pullRequest{}
+	}
+	record := (*cache)[key]
+	record.LastConsideredTime = metav1.NewTime(now)
+	if currentPRSha := string(pr.HeadRefOID); record.PRSha != currentPRSha {
+		record.PRSha = currentPRSha
+		record.RetestsForPrSha = 0
+		record.RetestsForBaseSha = 0
+		record.FailedRuns = nil
+	}
+	if record.BaseSha != baseSha {
+		record.BaseSha = baseSha
+		record.RetestsForBaseSha = 0
+	}
+
+	for _, failure := range failures {
+		if failure.URL == "" || failure.unknown {
+			continue
+		}
+		if record.FailedRuns == nil {
+			record.FailedRuns = map[string]failedRun{}
+		}
+		record.FailedRuns[failure.URL] = failedRun{Job: failure.Name, Reasons: failure.Reasons, Tests: failure.Tests, Quarantined: failure.Quarantined}
+	}
+	for _, failure := range failures {
+		if retestable, why := policy.ForJob(failure.Name).retestable(failure, record.failuresForTests(failure.Name)); !retestable {
+			return retestBackoffPause, fmt.Sprintf("Revision %s is not retested: %s", record.PRSha, why)
+		}
+	}
+
+	if record.RetestsForPrSha == policy.MaxRetestsForSha {
+		record.RetestsForPrSha = 0
+		record.RetestsForBaseSha = 0
+		return retestBackoffHold, fmt.Sprintf("Revision %s was retested %d times: holding", record.PRSha, policy.MaxRetestsForSha)
+	}
+
+	if record.RetestsForBaseSha == policy.MaxRetestsForShaAndBase {
+		return retestBackoffPause, fmt.Sprintf("Revision %s was retested %d times against base HEAD %s: pausing", record.PRSha, policy.MaxRetestsForShaAndBase, record.BaseSha)
+	}
+
+	if policy.MaxRetestsPerHour != 0 {
+		repo := string(pr.Repository.NameWithOwner)
+		if retests := retestsForRepoSince(*cache, repo, now.Add(-time.Hour)); retests >= policy.MaxRetestsPerHour {
+			return retestBackoffPause, fmt.Sprintf("Repo %s was retested %d times in the last hour: pausing", repo, retests)
+		}
+	}
+
+	record.RetestsForBaseSha++
+	record.RetestsForPrSha++
+	record.RetestTimes = append(retestTimesSince(record.RetestTimes, now.Add(-time.Hour)), metav1.NewTime(now))
+
+	return retestBackoffRetest, fmt.Sprintf("Remaining retests: %d against base HEAD %s and %d for PR HEAD %s in total", policy.MaxRetestsForShaAndBase-record.RetestsForBaseSha, record.BaseSha, policy.MaxRetestsForSha-record.RetestsForPrSha, record.PRSha)
+}
+
+func (pr *pullRequest) deepCopy() *pullRequest {
+	out := *pr
+	out.FailedRuns = maps.Clone(pr.FailedRuns)
+	out.RetestTimes = slices.Clone(pr.RetestTimes)
+	return &out
+}
+
+// failuresForTests counts how many recorded runs of the job each test failed in.
+func (pr *pullRequest) failuresForTests(job string) map[string]int {
+	failures := map[string]int{}
+	for _, run := range pr.FailedRuns {
+		if run.Job != job {
+			continue
+		}
+		for _, test := range run.Tests {
+			failures[test]++
+		}
+	}
+	return failures
+}
+
+// retestsForRepoSince counts the retests issued for pull requests of the repo since the given time.
+func retestsForRepoSince(cache map[string]*pullRequest, repo string, since time.Time) int {
+	var retests int
+	for key, record := range cache {
+		if strings.HasPrefix(key, repo+"#") {
+			retests += len(retestTimesSince(record.RetestTimes, since))
+		}
+	}
+	return retests
+}
+
+func retestTimesSince(times []metav1.Time, since time.Time) []metav1.Time {
+	var ret []metav1.Time
+	for _, t := range times {
+		if t.After(since) {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,250 @@
+package retester
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/quarantine"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+// failedJob is a run of a required job that failed on the HEAD of a pull request.
+type failedJob struct {
+	Name    string `json:"name"`
+	Context string `json:"context"`
+	URL     string `json:"url,omitempty"`
+}
+
+// jobFailure describes why a run of a job failed.
+type jobFailure struct {
+	failedJob `json:",inline"`
+	// Reasons are the classifications of the failure, like "infra:quota".
+	Reasons []string `json:"reasons,omitempty"`
+	// Tests are the names of the failed test cases.
+	Tests []string `json:"tests,omitempty"`
+	// Quarantined are the names of the failed test cases which are
+	// quarantined as flaky, and are therefore not in Tests.
+	Quarantined []string `json:"quarantined,omitempty"`
+	// unknown is set when it could not be determined why the job failed,
+	// so that the run is not recorded as failing for no tests.
+	unknown bool
+}
+
+// onlyQuarantined determines whether the job failed only because of
+// quarantined tests.
+func (f jobFailure) onlyQuarantined() bool {
+	return len(f.Quarantined) > 0 && len(f.Tests) == 0
+}
+
+// withoutQuarantined moves the failed tests which are quarantined for the
+// scope out of Tests.
+func (f jobFailure) withoutQuarantined(list *quarantine.List, scope quarantine.Scope, now time.Time) jobFailure {
+	var tests []string
+	for _, test := range f.Tests {
+		if _, quarantined := list.Quarantined(scope, test, now); quarantined {
+			f.Quarantined = append(f.Quarantined, test)
+		} else {
+			tests = append(tests, test)
+		}
+	}
+	f.Tests = tests
+	return f
+}
+
+// failureGetter determines why a run of a job failed.
+type failureGetter interface {
+	failureFor(ctx context.Context, job failedJob) (jobFailure, error)
+}
+
+const (
+	defaultStorageURL = "https://storage.googleapis.com"
+	// operatorJUnit is the artifact in which ci-operator records the results
+	// of its steps, including the classifications of their failures.
+	operatorJUnit = "artifacts/junit_operator.xml"
+	// stepJUnitGlob matches the artifacts in which the steps of a job record
+	// the results of the tests they ran.
+	stepJUnitGlob = "**/junit*.xml"
+)
+
+// artifactFailureGetter reads the classifications of the failure from the
+// jUnit ci-operator uploads with the artifacts of a job run, and the failed
+// tests from the jUnit of its steps.
+type artifactFailureGetter struct {
+	client     *http.Client
+	storageURL string
+}
+
+func newArtifactFailureGetter() *artifactFailureGetter {
+	return &artifactFailureGetter{client: &http.Client{Timeout: time.Minute}, storageURL: defaultStorageURL}
+}
+
+func (g *artifactFailureGetter) failureFor(ctx context.Context, job failedJob) (jobFailure, error) {
+	failure := jobFailure{failedJob: job}
+	run, err := runPath(job.URL)
+	if err != nil {
+		return failure, err
+	}
+	operator, err := g.junit(ctx, run+"/"+operatorJUnit)
+	if err != nil {
+		return failure, err
+	}
+	if operator == nil {
+		// the job does not run ci-operator or failed before it could record anything
+		return failure, nil
+	}
+	failure.Reasons = reasonsIn(operator.Suites)
+	artifacts, err := g.list(ctx, run, stepJUnitGlob)
+	if err != nil {
+		return failure, err
+	}
+	var suites []*junit.TestSuite
+	for _, artifact := range artifacts {
+		if artifact == run+"/"+operatorJUnit {
+			continue
+		}
+		results, err := g.junit(ctx, artifact)
+		if err != nil {
+			return failure, err
+		}
+		if results != nil {
+			suites = append(suites, results.Suites...)
+		}
+	}
+	failure.Tests = failedTestsIn(suites)
+	return failure, nil
+}
+
+// junit reads the jUnit stored at the path in the bucket, returning nil when
+// it does not exist.
+func (g *artifactFailureGetter) junit(ctx context.Context, path string) (*junit.TestSuites, error) {
+	raw, err := g.get(ctx, fmt.Sprintf("%s/%s", strings.TrimSuffix(g.storageURL, "/"), path))
+	if raw == nil || err != nil {
+		return nil, err
+	}
+	suites, err := junit.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+	}
+	return suites, nil
+}
+
+// list determines the paths of the artifacts of the job run matching the
+// glob.
+func (g *artifactFailureGetter) list(ctx context.Context, run, glob string) ([]string, error) {
+	bucket, prefix, _ := strings.Cut(run, "/")
+	query := url.Values{"prefix": {prefix + "/artifacts/"}, "matchGlob": {glob}, "fields": {"items(name),nextPageToken"}}
+	var paths []string
+	for {
+		raw, err := g.get(ctx, fmt.Sprintf("%s/storage/v1/b/%s/o?%s", strings.TrimSuffix(g.storageURL, "/"), bucket, query.Encode()))
+		if raw == nil || err != nil {
+			return paths, err
+		}
+		var objects struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &objects); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the artifacts of %s: %w", run, err)
+		}
+		for _, object := range objects.Items {
+			paths = append(paths, bucket+"/"+object.Name)
+		}
+		if objects.NextPageToken == "" {
+			return paths, nil
+		}
+		query.Set("pageToken", objects.NextPageToken)
+	}
+}
+
+// get reads the body of the response to a GET request, returning nil when
+// nothing is found.
+func (g *artifactFailureGetter) get(ctx context.Context, target string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	response, err := g.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", target, err)
+	}
+	defer response.Body.Close()
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case response.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to get %s: unexpected status code %d", target, response.StatusCode)
+	}
+	raw, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+	return raw, nil
+}
+
+// runPath determines where the artifacts of a job run are stored, as the
+// bucket followed by the path of the run, from the URL the job reports in its
+// status, like:
+// https://prow.ci.openshift.org/view/gs/test-platform-results/pr-logs/pull/openshift_ci-tools/123/pull-ci-openshift-ci-tools-master-unit/456
+func runPath(jobURL string) (string, error) {
+	parsed, err := url.Parse(jobURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse job URL %q: %w", jobURL, err)
+	}
+	path, ok := strings.CutPrefix(parsed.Path, "/view/gs/")
+	if !ok || !strings.Contains(strings.Trim(path, "/"), "/") {
+		return "", fmt.Errorf("job URL %q does not point to a job run stored in GCS", jobURL)
+	}
+	return strings.TrimSuffix(path, "/"), nil
+}
+
+// reasonsIn collects the sorted, unique classifications of the failed test
+// cases in the suites.
+func reasonsIn(suites []*junit.TestSuite) []string {
+	reasons := sets.New[string]()
+	walkTestCases(suites, func(test *junit.TestCase) {
+		if test.FailureOutput == nil {
+			return
+		}
+		for _, property := range test.Properties {
+			if property.Name == results.ClassificationProperty {
+				reasons.Insert(property.Value)
+			}
+		}
+	})
+	return sets.List(reasons)
+}
+
+// failedTestsIn collects the sorted, unique names of the test cases in the
+// suites which failed and did not pass in another attempt.
+func failedTestsIn(suites []*junit.TestSuite) []string {
+	failed, passed := sets.New[string](), sets.New[string]()
+	walkTestCases(suites, func(test *junit.TestCase) {
+		switch {
+		case test.FailureOutput != nil:
+			failed.Insert(test.Name)
+		case test.SkipMessage == nil:
+			passed.Insert(test.Name)
+		}
+	})
+	return sets.List(failed.Difference(passed))
+}
+
+func walkTestCases(suites []*junit.TestSuite, visit func(test *junit.TestCase)) {
+	for _, suite := range suites {
+		for _, test := range suite.TestCases {
+			visit(test)
+		}
+		walkTestCases(suite.Children, visit)
+	}
+}
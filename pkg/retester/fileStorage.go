@@ -0,0 +1,64 @@
+package retester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileStorage stores the backoff cache in a local file.
+type fileStorage struct {
+	file string
+}
+
+// NewFileStorage stores the backoff cache in a local file.
+func NewFileStorage(file string) Storage {
+	return &fileStorage{file: file}
+}
+
+func (s *fileStorage) String() string {
+	return fmt.Sprintf("file %s", s.file)
+}
+
+func (s *fileStorage) Load(_ context.Context) ([]byte, error) {
+	bytes, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", s.file, err)
+	}
+	return bytes, nil
+}
+
+func (s *fileStorage) Save(_ context.Context, content []byte) (ret error) {
+	// write to a temp file and rename it to the cache file to ensure "atomic write":
+	// either it is complete or nothing
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.file), "tmp-backoff-cache")
+	if err != nil {
+		return fmt.Errorf("failed to create a temp file: %w", err)
+	}
+	tmp := tmpFile.Name()
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close file %s: %w", tmp, err)
+	}
+	defer func() {
+		// do nothing when the file does not exist, e.g., write failed, or it has been renamed.
+		if _, err := os.Stat(tmp); errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		if err := os.Remove(tmp); err != nil {
+			ret = fmt.Errorf("failed to delete file %s: %w", tmp, err)
+		}
+	}()
+
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.file); err != nil {
+		return fmt.Errorf("failed to rename file from %s to %s: %w", tmp, s.file, err)
+	}
+	return ret
+}
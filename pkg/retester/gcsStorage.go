@@ -0,0 +1,78 @@
+package retester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+)
+
+// gcsClient is the subset of the GCS API used to store the backoff cache.
+type gcsClient interface {
+	// NewReader returns storage.ErrObjectNotExist when the object does not exist.
+	NewReader(ctx context.Context, bucket, object string) (io.ReadCloser, error)
+	NewWriter(ctx context.Context, bucket, object string) io.WriteCloser
+}
+
+type bucketClient struct {
+	client *storage.Client
+}
+
+func (c *bucketClient) NewReader(ctx context.Context, bucket, object string) (io.ReadCloser, error) {
+	return c.client.Bucket(bucket).Object(object).NewReader(ctx)
+}
+
+func (c *bucketClient) NewWriter(ctx context.Context, bucket, object string) io.WriteCloser {
+	return c.client.Bucket(bucket).Object(object).NewWriter(ctx)
+}
+
+// gcsStorage stores the backoff cache in a GCS bucket.
+type gcsStorage struct {
+	bucket string
+	object string
+	client gcsClient
+}
+
+// NewGCSStorage stores the backoff cache in an object of a GCS bucket.
+func NewGCSStorage(client *storage.Client, bucket, object string) Storage {
+	return &gcsStorage{bucket: bucket, object: object, client: &bucketClient{client: client}}
+}
+
+func (s *gcsStorage) String() string {
+	return fmt.Sprintf("gs://%s/%s", s.bucket, s.object)
+}
+
+func (s *gcsStorage) Load(ctx context.Context) ([]byte, error) {
+	reader, err := s.client.NewReader(ctx, s.bucket, s.object)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", s, err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s, err)
+	}
+	return content, nil
+}
+
+func (s *gcsStorage) Save(ctx context.Context, content []byte) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	writer := s.client.NewWriter(ctx, s.bucket, s.object)
+	if _, err := writer.Write(content); err != nil {
+		// cancelling the context aborts the upload instead of storing partial content
+		cancel()
+		_ = writer.Close()
+		return fmt.Errorf("failed to write %s: %w", s, err)
+	}
+	// the object is only created once the writer is closed
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package retester
+
+import (
+	"context"
+	"fmt"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// KubernetesStorageConfigMap stores the backoff cache in a ConfigMap.
+	KubernetesStorageConfigMap = "configmap"
+	// KubernetesStorageSecret stores the backoff cache in a Secret.
+	KubernetesStorageSecret = "secret"
+
+	// kubernetesStorageKey is the key the backoff cache is stored under.
+	kubernetesStorageKey = "cache.yaml"
+)
+
+// kubernetesStorage stores the backoff cache in a ConfigMap or a Secret.
+type kubernetesStorage struct {
+	client    ctrlruntimeclient.Client
+	kind      string
+	namespace string
+	name      string
+}
+
+// NewKubernetesStorage stores the backoff cache in a ConfigMap or a Secret,
+// depending on the kind.
+func NewKubernetesStorage(client ctrlruntimeclient.Client, kind, namespace, name string) (Storage, error) {
+	if kind != KubernetesStorageConfigMap && kind != KubernetesStorageSecret {
+		return nil, fmt.Errorf("unknown kind %q, must be %s or %s", kind, KubernetesStorageConfigMap, KubernetesStorageSecret)
+	}
+	return &kubernetesStorage{client: client, kind: kind, namespace: namespace, name: name}, nil
+}
+
+func (s *kubernetesStorage) String() string {
+	return fmt.Sprintf("%s %s/%s", s.kind, s.namespace, s.name)
+}
+
+func (s *kubernetesStorage) object() ctrlruntimeclient.Object {
+	objectMeta := meta.ObjectMeta{Namespace: s.namespace, Name: s.name}
+	if s.kind == KubernetesStorageSecret {
+		return &coreapi.Secret{ObjectMeta: objectMeta}
+	}
+	return &coreapi.ConfigMap{ObjectMeta: objectMeta}
+}
+
+func (s *kubernetesStorage) Load(ctx context.Context) ([]byte, error) {
+	obj := s.object()
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKeyFromObject(obj), obj); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s: %w", s, err)
+	}
+	switch obj := obj.(type) {
+	case *coreapi.Secret:
+		return obj.Data[kubernetesStorageKey], nil
+	case *coreapi.ConfigMap:
+		if content, ok := obj.Data[kubernetesStorageKey]; ok {
+			return []byte(content), nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *kubernetesStorage) Save(ctx context.Context, content []byte) error {
+	obj := s.object()
+	err := s.client.Get(ctx, ctrlruntimeclient.ObjectKeyFromObject(obj), obj)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get %s: %w", s, err)
+	}
+	exists := err == nil
+	switch obj := obj.(type) {
+	case *coreapi.Secret:
+		obj.Data = map[string][]byte{kubernetesStorageKey: content}
+	case *coreapi.ConfigMap:
+		obj.Data = map[string]string{kubernetesStorageKey: string(content)}
+	}
+	if exists {
+		err = s.client.Update(ctx, obj)
+	} else {
+		err = s.client.Create(ctx, obj)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", s, err)
+	}
+	return nil
+}
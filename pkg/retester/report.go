@@ -0,0 +1,52 @@
+package retester
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	reportActionRetest = "retest"
+	reportActionHold   = "hold"
+	reportActionPause  = "pause"
+)
+
+// report records what the retester did for the candidate pull requests of a
+// sync or, in dry-run mode, what it would have done.
+type report struct {
+	Time         metav1.Time   `json:"time"`
+	DryRun       bool          `json:"dry_run,omitempty"`
+	PullRequests []reportEntry `json:"pull_requests,omitempty"`
+}
+
+// reportEntry is the action taken for a single pull request.
+type reportEntry struct {
+	PullRequest string       `json:"pull_request"`
+	Action      string       `json:"action"`
+	Message     string       `json:"message"`
+	Failures    []jobFailure `json:"failures,omitempty"`
+}
+
+func (r *report) add(entry reportEntry) {
+	if r != nil {
+		r.PullRequests = append(r.PullRequests, entry)
+	}
+}
+
+func (r *report) write(path string) error {
+	sort.Slice(r.PullRequests, func(i, j int) bool {
+		return r.PullRequests[i].PullRequest < r.PullRequests[j].PullRequest
+	})
+	raw, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write report to %s: %w", path, err)
+	}
+	return nil
+}
@@ -4,22 +4,25 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/prometheus/client_golang/prometheus"
 	githubql "github.com/shurcooL/githubv4"
 	"github.com/sirupsen/logrus"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/prow/pkg/config"
 	"sigs.k8s.io/prow/pkg/git/v2"
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/tide"
 	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/quarantine"
 )
 
 type githubClient interface {
@@ -36,6 +39,18 @@ type pullRequest struct {
 	RetestsForPrSha    int         `json:"retests_for_pr_sha,omitempty"`
 	RetestsForBaseSha  int         `json:"retests_for_base_sha,omitempty"`
 	LastConsideredTime metav1.Time `json:"last_considered_time,omitempty"`
+	// FailedRuns are the runs of required jobs seen failing on PRSha, keyed by their URL.
+	FailedRuns map[string]failedRun `json:"failed_runs,omitempty"`
+	// RetestTimes are the times of the retests issued in the last hour.
+	RetestTimes []metav1.Time `json:"retest_times,omitempty"`
+}
+
+// failedRun is a run of a required job seen failing on a pull request.
+type failedRun struct {
+	Job         string   `json:"job"`
+	Reasons     []string `json:"reasons,omitempty"`
+	Tests       []string `json:"tests,omitempty"`
+	Quarantined []string `json:"quarantined,omitempty"`
 }
 
 var (
@@ -87,6 +102,31 @@ type RetesterPolicy struct {
 	MaxRetestsForShaAndBase int   `json:"max_retests_for_sha_and_base,omitempty"`
 	MaxRetestsForSha        int   `json:"max_retests_for_sha,omitempty"`
 	Enabled                 *bool `json:"enabled,omitempty"`
+	// MaxRetestsPerHour is the budget of retests issued for all pull requests
+	// of a repo per hour. No budget is enforced when it is 0.
+	MaxRetestsPerHour int `json:"max_retests_per_hour,omitempty"`
+	// FailurePolicy is applied to every failing required job without a more
+	// specific policy in Jobs.
+	FailurePolicy `json:",inline"`
+	// Jobs holds failure policies for individual jobs, keyed by job name.
+	// They override the policy of the same job from general levels.
+	Jobs map[string]FailurePolicy `json:"jobs,omitempty"`
+}
+
+// FailurePolicy decides whether a failed job can be retested from the
+// reasons it failed for. When merging policies, unset fields are inherited
+// from the parent policy.
+type FailurePolicy struct {
+	// RetestDisabled prevents retests when the job failed. Setting it to
+	// false enables retests disabled by a general level.
+	RetestDisabled *bool `json:"retest_disabled,omitempty"`
+	// RetestOnReasons limits retests to failures classified with one of the
+	// reasons. An entry ending with a colon matches all reasons with that
+	// prefix, e.g. "infra:" matches "infra:quota".
+	RetestOnReasons []string `json:"retest_on_reasons,omitempty"`
+	// MaxFailuresForTest prevents retests once the same test of the job failed
+	// that many times on the revision of the pull request.
+	MaxFailuresForTest int `json:"max_failures_for_test,omitempty"`
 }
 
 // LoadConfig loads retester configuration via file.
@@ -114,13 +154,24 @@ type RetestController struct {
 
 	usesGitHubApp bool
 	backoff       backoffCache
+	failures      failureGetter
 
 	config *Config
+
+	// dryRun logs the comments instead of creating them
+	dryRun bool
+	// reportFile is where a report of the actions of each sync is written to
+	reportFile string
+	report     *report
+	// quarantineFile holds the list of quarantined tests, whose failures
+	// alone do not stop a job from being retested
+	quarantineFile string
+	quarantined    *quarantine.List
 }
 
 func (c *Config) GetRetesterPolicy(org, repo string) (RetesterPolicy, error) {
 	policy := RetesterPolicy{}
-	if c.Retester.RetesterPolicy == policy && len(c.Retester.Oranizations) == 0 {
+	if reflect.DeepEqual(c.Retester.RetesterPolicy, policy) && len(c.Retester.Oranizations) == 0 {
 		return policy, nil
 	}
 	if orgStruct, ok := c.Retester.Oranizations[org]; ok && orgStruct.Enabled != nil {
@@ -136,6 +187,7 @@ func (c *Config) GetRetesterPolicy(org, repo string) (RetesterPolicy, error) {
 				if repoStruct.MaxRetestsForShaAndBase != 0 {
 					policy.MaxRetestsForShaAndBase = repoStruct.MaxRetestsForShaAndBase
 				}
+				policy.inherit(repoStruct.RetesterPolicy)
 			} else {
 				return RetesterPolicy{}, nil
 			}
@@ -148,6 +200,7 @@ func (c *Config) GetRetesterPolicy(org, repo string) (RetesterPolicy, error) {
 			if orgStruct.MaxRetestsForShaAndBase != 0 && policy.MaxRetestsForShaAndBase == 0 {
 				policy.MaxRetestsForShaAndBase = orgStruct.MaxRetestsForShaAndBase
 			}
+			policy.inherit(orgStruct.RetesterPolicy)
 		}
 		if !*policy.Enabled && (c.Retester.Enabled == nil || !*c.Retester.Enabled) {
 			return RetesterPolicy{}, nil
@@ -162,9 +215,104 @@ func (c *Config) GetRetesterPolicy(org, repo string) (RetesterPolicy, error) {
 	if policy.MaxRetestsForShaAndBase == 0 {
 		policy.MaxRetestsForShaAndBase = c.Retester.MaxRetestsForShaAndBase
 	}
+	policy.inherit(c.Retester.RetesterPolicy)
 	return policy, nil
 }
 
+// inherit sets the budget and the failure policies which are not set in the
+// policy from its parent.
+func (p *RetesterPolicy) inherit(parent RetesterPolicy) {
+	if p.MaxRetestsPerHour == 0 {
+		p.MaxRetestsPerHour = parent.MaxRetestsPerHour
+	}
+	p.FailurePolicy.inherit(parent.FailurePolicy)
+	for name, job := range parent.Jobs {
+		if p.Jobs == nil {
+			p.Jobs = map[string]FailurePolicy{}
+		}
+		if current, ok := p.Jobs[name]; ok {
+			current.inherit(job)
+			p.Jobs[name] = current
+		} else {
+			p.Jobs[name] = job
+		}
+	}
+}
+
+func (p *FailurePolicy) inherit(parent FailurePolicy) {
+	if p.RetestDisabled == nil {
+		p.RetestDisabled = parent.RetestDisabled
+	}
+	if p.RetestOnReasons == nil {
+		p.RetestOnReasons = parent.RetestOnReasons
+	}
+	if p.MaxFailuresForTest == 0 {
+		p.MaxFailuresForTest = parent.MaxFailuresForTest
+	}
+}
+
+// ForJob determines the failure policy of a job, falling back to the
+// policy for all jobs for the fields the job does not set.
+func (p RetesterPolicy) ForJob(name string) FailurePolicy {
+	policy := p.Jobs[name]
+	policy.inherit(p.FailurePolicy)
+	return policy
+}
+
+// needsFailures determines whether the reasons and failed tests of the
+// failing jobs are needed to apply the policy.
+func (p RetesterPolicy) needsFailures() bool {
+	needs := func(policy FailurePolicy) bool {
+		return policy.RetestOnReasons != nil || policy.MaxFailuresForTest != 0
+	}
+	if needs(p.FailurePolicy) {
+		return true
+	}
+	for _, policy := range p.Jobs {
+		if needs(policy) {
+			return true
+		}
+	}
+	return false
+}
+
+// retestable determines whether the failure can be retested, returning the
+// explanation when it cannot.
+func (p FailurePolicy) retestable(failure jobFailure, failuresForTest map[string]int) (bool, string) {
+	if p.RetestDisabled != nil && *p.RetestDisabled {
+		return false, fmt.Sprintf("retests are disabled for job %s", failure.Name)
+	}
+	if failure.onlyQuarantined() {
+		return true, ""
+	}
+	if p.RetestOnReasons != nil && !matchesAnyReason(p.RetestOnReasons, failure.Reasons) {
+		reasons := "unknown reasons"
+		if len(failure.Reasons) > 0 {
+			reasons = strings.Join(failure.Reasons, ", ")
+		}
+		return false, fmt.Sprintf("job %s failed for %s, retesting only on %s", failure.Name, reasons, strings.Join(p.RetestOnReasons, ", "))
+	}
+	if p.MaxFailuresForTest != 0 {
+		for _, test := range failure.Tests {
+			if failures := failuresForTest[test]; failures >= p.MaxFailuresForTest {
+				return false, fmt.Sprintf("test %q of job %s failed %d times", test, failure.Name, failures)
+			}
+		}
+	}
+	return true, ""
+}
+
+func matchesAnyReason(patterns, reasons []string) bool {
+	for _, pattern := range patterns {
+		for _, reason := range reasons {
+			if pattern == reason || (strings.HasSuffix(pattern, ":") && strings.HasPrefix(reason, pattern)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func validatePolicies(policy RetesterPolicy) []error {
 	var errs []error
 	if policy.Enabled != nil {
@@ -178,6 +326,13 @@ func validatePolicies(policy RetesterPolicy) []error {
 			if policy.MaxRetestsForSha < policy.MaxRetestsForShaAndBase {
 				errs = append(errs, fmt.Errorf("max_retest_for_sha value can't be lower than max_retests_for_sha_and_base value: %d < %d", policy.MaxRetestsForSha, policy.MaxRetestsForShaAndBase))
 			}
+			if policy.MaxRetestsPerHour < 0 {
+				errs = append(errs, fmt.Errorf("max_retests_per_hour has invalid value: %d", policy.MaxRetestsPerHour))
+			}
+			errs = append(errs, validateFailurePolicy("", policy.FailurePolicy)...)
+			for _, name := range sets.List(sets.KeySet(policy.Jobs)) {
+				errs = append(errs, validateFailurePolicy(fmt.Sprintf("jobs.%s.", name), policy.Jobs[name])...)
+			}
 		} else {
 			return nil
 		}
@@ -185,27 +340,40 @@ func validatePolicies(policy RetesterPolicy) []error {
 	return errs
 }
 
-// NewController generates a retest controller.
-func NewController(ctx context.Context, ghClient githubClient, cfg config.Getter, gitClient git.ClientFactory, usesApp bool, cacheFile string, cacheRecordAge time.Duration, config *Config, awsConfig *aws.Config) *RetestController {
-	logger := logrus.NewEntry(logrus.StandardLogger())
-	var backoff backoffCache
-	if awsConfig != nil {
-		backoff = &s3BackOffCache{cache: map[string]*pullRequest{}, file: cacheFile, cacheRecordAge: cacheRecordAge, logger: logger, awsClient: s3.NewFromConfig(*awsConfig)}
-	} else {
-		backoff = &fileBackoffCache{cache: map[string]*pullRequest{}, file: cacheFile, cacheRecordAge: cacheRecordAge, logger: logger}
+func validateFailurePolicy(prefix string, policy FailurePolicy) []error {
+	var errs []error
+	if policy.MaxFailuresForTest < 0 {
+		errs = append(errs, fmt.Errorf("%smax_failures_for_test has invalid value: %d", prefix, policy.MaxFailuresForTest))
+	}
+	for i, reason := range policy.RetestOnReasons {
+		if reason == "" {
+			errs = append(errs, fmt.Errorf("%sretest_on_reasons[%d] must not be empty", prefix, i))
+		}
 	}
+	return errs
+}
+
+// NewController generates a retest controller. The backoff cache is persisted
+// in the storage, or only kept in memory when it is nil. The quarantine list
+// is reloaded on every sync when its file is set.
+func NewController(ctx context.Context, ghClient githubClient, cfg config.Getter, gitClient git.ClientFactory, usesApp bool, storage Storage, cacheRecordAge time.Duration, config *Config, dryRun bool, reportFile, quarantineFile string) *RetestController {
+	logger := logrus.NewEntry(logrus.StandardLogger())
 
 	ret := &RetestController{
-		ghClient:      ghClient,
-		gitClient:     gitClient,
-		configGetter:  cfg,
-		logger:        logger,
-		usesGitHubApp: usesApp,
-		backoff:       backoff,
-		config:        config,
+		ghClient:       ghClient,
+		gitClient:      gitClient,
+		configGetter:   cfg,
+		logger:         logger,
+		usesGitHubApp:  usesApp,
+		backoff:        newBackoffCache(storage, cacheRecordAge, dryRun, logger),
+		failures:       newArtifactFailureGetter(),
+		config:         config,
+		dryRun:         dryRun,
+		reportFile:     reportFile,
+		quarantineFile: quarantineFile,
 	}
 	if err := ret.backoff.load(ctx); err != nil {
-		logger.WithError(err).Warn("Failed to load backoff cache from disk")
+		logger.WithError(err).Warn("Failed to load backoff cache")
 	}
 	return ret
 }
@@ -234,23 +402,36 @@ func (c *RetestController) runWithCandidates(ctx context.Context, candidates map
 	candidates = c.enabledPRs(candidates)
 	logrus.Infof("Remaining %d candidates for retest (from an enabled org or repo)", len(candidates))
 
-	candidates, err := c.atLeastOneRequiredJob(candidates)
+	failing, err := c.atLeastOneRequiredJob(candidates)
 	if err != nil {
 		return fmt.Errorf("failed to filter candidate PRs that have at least one required job: %w", err)
 	}
 
-	logrus.Infof("Remaining %d candidates for retest (fail at least one required prowjob)", len(candidates))
-	for _, pr := range candidates {
-		logrus.Infof("Candidate PR: %s", prUrl(pr))
+	logrus.Infof("Remaining %d candidates for retest (fail at least one required prowjob)", len(failing))
+	for _, candidate := range failing {
+		logrus.Infof("Candidate PR: %s", prUrl(candidate.pr))
 	}
 
+	if c.reportFile != "" {
+		c.report = &report{Time: metav1.Now(), DryRun: c.dryRun}
+	}
+	if c.quarantineFile != "" {
+		if c.quarantined, err = quarantine.LoadList(c.quarantineFile); err != nil {
+			return fmt.Errorf("failed to load quarantine list: %w", err)
+		}
+	}
 	var errs []error
-	for _, pr := range candidates {
-		errs = append(errs, c.retestOrBackoff(pr))
+	for _, candidate := range failing {
+		errs = append(errs, c.retestOrBackoff(ctx, candidate.pr, candidate.failedJobs))
 	}
 
 	if err := c.backoff.save(ctx); err != nil {
-		errs = append(errs, fmt.Errorf("failed to save cache to disk: %w", err))
+		errs = append(errs, fmt.Errorf("failed to save cache: %w", err))
+	}
+	if c.report != nil {
+		if err := c.report.write(c.reportFile); err != nil {
+			errs = append(errs, err)
+		}
 	}
 	logrus.Info("Sync finished")
 	return utilerrors.NewAggregate(errs)
@@ -258,6 +439,10 @@ func (c *RetestController) runWithCandidates(ctx context.Context, candidates map
 
 func (c *RetestController) createComment(pr tide.PullRequest, cmd, message string) {
 	comment := fmt.Sprintf("%s\n\n%s\n", cmd, message)
+	if c.dryRun {
+		c.logger.WithField("comment", comment).Infof("%s: would comment in dry-run mode", prUrl(pr))
+		return
+	}
 	if err := c.ghClient.CreateComment(string(pr.Repository.Owner.Login), string(pr.Repository.Name), int(pr.Number), comment); err != nil {
 		c.logger.WithField("comment", comment).WithError(err).Error("failed to create a comment")
 	} else if cmd == "/retest-required" {
@@ -265,7 +450,7 @@ func (c *RetestController) createComment(pr tide.PullRequest, cmd, message strin
 	}
 }
 
-func (c *RetestController) retestOrBackoff(pr tide.PullRequest) error {
+func (c *RetestController) retestOrBackoff(ctx context.Context, pr tide.PullRequest, failedJobs []failedJob) error {
 	branchRef := string(pr.BaseRef.Prefix) + string(pr.BaseRef.Name)
 	baseSha, err := c.ghClient.GetRef(string(pr.Repository.Owner.Login), string(pr.Repository.Name), strings.TrimPrefix(branchRef, "refs/"))
 	if err != nil {
@@ -282,18 +467,49 @@ func (c *RetestController) retestOrBackoff(pr tide.PullRequest) error {
 		return fmt.Errorf("failed to validate retester policy: %v", validationErrors)
 	}
 
-	action, message := c.backoff.check(pr, baseSha, policy)
+	failures := c.failuresFor(ctx, pr, policy, failedJobs)
+	action, message := c.backoff.check(pr, baseSha, policy, failures)
+	entry := reportEntry{PullRequest: prUrl(pr), Message: message, Failures: failures}
 	switch action {
 	case retestBackoffHold:
+		entry.Action = reportActionHold
 		c.createComment(pr, "/hold", message)
 	case retestBackoffPause:
+		entry.Action = reportActionPause
 		c.logger.Infof("%s: %s (%s)", prUrl(pr), "no comment", message)
 	case retestBackoffRetest:
+		entry.Action = reportActionRetest
 		c.createComment(pr, "/retest-required", message)
 	}
+	c.report.add(entry)
 	return nil
 }
 
+// failuresFor determines why the required jobs failed, when the policy or the
+// quarantine list needs it.
+func (c *RetestController) failuresFor(ctx context.Context, pr tide.PullRequest, policy RetesterPolicy, failedJobs []failedJob) []jobFailure {
+	scope := quarantine.Scope{Org: string(pr.Repository.Owner.Login), Repo: string(pr.Repository.Name), Branch: string(pr.BaseRef.Name)}
+	now := time.Now()
+	recorded := c.backoff.failedRuns(pr)
+	failures := make([]jobFailure, 0, len(failedJobs))
+	for _, job := range failedJobs {
+		failure := jobFailure{failedJob: job, unknown: true}
+		if run, ok := recorded[job.URL]; ok && job.URL != "" && run.Job == job.Name {
+			// the run was already seen failing, its artifacts do not change
+			failure = jobFailure{failedJob: job, Reasons: run.Reasons, Tests: append(slices.Clone(run.Tests), run.Quarantined...)}
+			slices.Sort(failure.Tests)
+		} else if c.failures != nil && (policy.needsFailures() || c.quarantined != nil) {
+			var err error
+			if failure, err = c.failures.failureFor(ctx, job); err != nil {
+				c.logger.WithError(err).Warnf("%s: failed to determine why job %s failed", prUrl(pr), job.Name)
+				failure.unknown = true
+			}
+		}
+		failures = append(failures, failure.withoutQuarantined(c.quarantined, scope, now))
+	}
+	return failures
+}
+
 func findCandidates(config config.Getter, gc githubClient, usesGitHubAppsAuth bool, logger *logrus.Entry) (map[string]tide.PullRequest, error) {
 	prs, err := query(config, gc, usesGitHubAppsAuth, logger)
 	if err != nil {
@@ -303,8 +519,14 @@ func findCandidates(config config.Getter, gc githubClient, usesGitHubAppsAuth bo
 	return prs, nil
 }
 
-func (c *RetestController) atLeastOneRequiredJob(candidates map[string]tide.PullRequest) (map[string]tide.PullRequest, error) {
-	output := map[string]tide.PullRequest{}
+// candidate is a pull request failing at least one required job.
+type candidate struct {
+	pr         tide.PullRequest
+	failedJobs []failedJob
+}
+
+func (c *RetestController) atLeastOneRequiredJob(candidates map[string]tide.PullRequest) (map[string]candidate, error) {
+	output := map[string]candidate{}
 	for key, pr := range candidates {
 		// Get all non-optional Prowjobs configured for this org/repo/branch that could run on this PR
 		presubmits := c.presubmitsForPRByContext(pr)
@@ -324,18 +546,22 @@ func (c *RetestController) atLeastOneRequiredJob(candidates map[string]tide.Pull
 		}
 		c.logger.Infof("HEAD commit of PR %s has %d contexts", key, len(contexts))
 
+		var failedJobs []failedJob
 		for _, ctx := range contexts {
 			if ctx.State != githubql.StatusStateFailure {
 				continue
 			}
-			// It is enough to find a single failed context that corresponds to a required Prowjob
-			if ps, has := presubmits[string(ctx.Context)]; has {
-				c.logger.Infof("PR %s fails required job %s (context=%s)", key, ps.Name, ctx.Context)
-				output[key] = pr
-				break
+			// It is enough to find a single failed context that corresponds to a required Prowjob,
+			// but all of them are collected to apply the policies for their failures
+			name := string(ctx.Context.Context)
+			if ps, has := presubmits[name]; has {
+				c.logger.Infof("PR %s fails required job %s (context=%s)", key, ps.Name, name)
+				failedJobs = append(failedJobs, failedJob{Name: ps.Name, Context: name, URL: ctx.TargetURL})
 			}
 		}
-		if _, ok := output[key]; !ok {
+		if len(failedJobs) > 0 {
+			output[key] = candidate{pr: pr, failedJobs: failedJobs}
+		} else {
 			c.logger.Infof("PR %s has no failing context of a required Prowjob", key)
 		}
 	}
@@ -517,6 +743,12 @@ func (c *RetestController) enabledPRs(candidates map[string]tide.PullRequest) ma
 	return output
 }
 
+// statusContext is a status context along with the URL it links to.
+type statusContext struct {
+	tide.Context
+	TargetURL string
+}
+
 // headContexts gets the status contexts for the commit with OID == pr.HeadRefOID
 //
 // First, we try to get this value from the commits we got with the PR query.
@@ -526,7 +758,7 @@ func (c *RetestController) enabledPRs(candidates map[string]tide.PullRequest) ma
 // We list multiple commits with the query to increase our chance of success,
 // but if we don't find the head commit we have to ask GitHub for it
 // specifically (this costs an API token).
-func headContexts(ghc githubClient, pr tide.PullRequest) ([]tide.Context, error) {
+func headContexts(ghc githubClient, pr tide.PullRequest) ([]statusContext, error) {
 	// We didn't get the head commit from the query (the commits must not be
 	// logically ordered) so we need to specifically ask GitHub for the status
 	// and coerce it to a graphql type.
@@ -537,12 +769,15 @@ func headContexts(ghc githubClient, pr tide.PullRequest) ([]tide.Context, error)
 		return nil, fmt.Errorf("failed to get the combined status: %w", err)
 	}
 
-	contexts := make([]tide.Context, 0, len(combined.Statuses))
+	contexts := make([]statusContext, 0, len(combined.Statuses))
 	for _, status := range combined.Statuses {
-		contexts = append(contexts, tide.Context{
-			Context:     githubql.String(status.Context),
-			Description: githubql.String(status.Description),
-			State:       githubql.StatusState(strings.ToUpper(status.State)),
+		contexts = append(contexts, statusContext{
+			Context: tide.Context{
+				Context:     githubql.String(status.Context),
+				Description: githubql.String(status.Description),
+				State:       githubql.StatusState(strings.ToUpper(status.State)),
+			},
+			TargetURL: status.TargetURL,
 		})
 	}
 
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -22,7 +24,9 @@ import (
 	"sigs.k8s.io/prow/pkg/github"
 	"sigs.k8s.io/prow/pkg/github/fakegithub"
 	"sigs.k8s.io/prow/pkg/tide"
+	"sigs.k8s.io/yaml"
 
+	"github.com/openshift/ci-tools/pkg/quarantine"
 	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
@@ -100,6 +104,29 @@ func TestLoadConfig(t *testing.T) {
 			file:     "testdata/testconfig/openshift-config.yaml",
 			expected: configOpenShift,
 		},
+		{
+			name: "failure policies",
+			file: "testdata/testconfig/failure-policies.yaml",
+			expected: &Config{Retester: Retester{
+				RetesterPolicy: RetesterPolicy{
+					MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, MaxRetestsPerHour: 20,
+					FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:"}},
+				},
+				Oranizations: map[string]Oranization{"openshift": {
+					RetesterPolicy: RetesterPolicy{Enabled: &True},
+					Repos: map[string]Repo{
+						"ci-tools": {RetesterPolicy: RetesterPolicy{
+							Enabled:       &True,
+							FailurePolicy: FailurePolicy{MaxFailuresForTest: 2},
+							Jobs: map[string]FailurePolicy{
+								"pull-ci-openshift-ci-tools-master-unit": {RetestOnReasons: []string{"test:flake"}},
+								"pull-ci-openshift-ci-tools-master-lint": {RetestDisabled: &True},
+							},
+						}},
+					},
+				}},
+			}},
+		},
 		{
 			name:     "default",
 			file:     "testdata/testconfig/default.yaml",
@@ -183,21 +210,21 @@ func TestGetRetesterPolicy(t *testing.T) {
 			org:      "openshift",
 			repo:     "ci-tools",
 			config:   c,
-			expected: RetesterPolicy{3, 3, &True},
+			expected: RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 3, Enabled: &True},
 		},
 		{
 			name:     "enabled repo with one max retest value and enabled org",
 			org:      "openshift",
 			repo:     "repo-max",
 			config:   c,
-			expected: RetesterPolicy{2, 6, &True},
+			expected: RetesterPolicy{MaxRetestsForShaAndBase: 2, MaxRetestsForSha: 6, Enabled: &True},
 		},
 		{
 			name:     "enabled repo and disabled org",
 			org:      "no-openshift",
 			repo:     "ci-tools",
 			config:   c,
-			expected: RetesterPolicy{4, 4, &True},
+			expected: RetesterPolicy{MaxRetestsForShaAndBase: 4, MaxRetestsForSha: 4, Enabled: &True},
 		},
 		{
 			name:   "disabled repo and enabled org",
@@ -210,7 +237,7 @@ func TestGetRetesterPolicy(t *testing.T) {
 			org:      "openshift",
 			repo:     "ci-docs",
 			config:   c,
-			expected: RetesterPolicy{2, 2, &True},
+			expected: RetesterPolicy{MaxRetestsForShaAndBase: 2, MaxRetestsForSha: 2, Enabled: &True},
 		},
 		{
 			name:   "not configured repo and disabled org",
@@ -223,7 +250,7 @@ func TestGetRetesterPolicy(t *testing.T) {
 			org:      "no-openshift",
 			repo:     "true",
 			config:   c,
-			expected: RetesterPolicy{3, 9, &True},
+			expected: RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True},
 		},
 		{
 			name:   "not configured repo and not configured org",
@@ -253,6 +280,85 @@ func TestGetRetesterPolicy(t *testing.T) {
 	}
 }
 
+func TestGetRetesterPolicyInheritsFailurePolicies(t *testing.T) {
+	c := &Config{Retester: Retester{
+		RetesterPolicy: RetesterPolicy{
+			MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, MaxRetestsPerHour: 20,
+			FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:"}},
+			Jobs:          map[string]FailurePolicy{"e2e": {MaxFailuresForTest: 1}},
+		},
+		Oranizations: map[string]Oranization{
+			"openshift": {
+				RetesterPolicy: RetesterPolicy{Enabled: &True, MaxRetestsPerHour: 10, Jobs: map[string]FailurePolicy{
+					"e2e":    {RetestOnReasons: []string{"test:flake"}},
+					"images": {RetestDisabled: &True},
+				}},
+				Repos: map[string]Repo{
+					"ci-tools": {RetesterPolicy: RetesterPolicy{Enabled: &True, Jobs: map[string]FailurePolicy{
+						"lint":   {RetestDisabled: &True},
+						"images": {RetestDisabled: &False},
+					}}},
+				},
+			},
+		},
+	}}
+	testCases := []struct {
+		name     string
+		repo     string
+		expected RetesterPolicy
+	}{
+		{
+			name: "repo inherits from org and global levels",
+			repo: "ci-tools",
+			expected: RetesterPolicy{
+				MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, MaxRetestsPerHour: 10,
+				FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:"}},
+				Jobs: map[string]FailurePolicy{
+					"e2e":    {RetestOnReasons: []string{"test:flake"}, MaxFailuresForTest: 1},
+					"lint":   {RetestDisabled: &True},
+					"images": {RetestDisabled: &False},
+				},
+			},
+		},
+		{
+			name: "not configured repo inherits from org and global levels",
+			repo: "ci-docs",
+			expected: RetesterPolicy{
+				MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, MaxRetestsPerHour: 10,
+				FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:"}},
+				Jobs: map[string]FailurePolicy{
+					"e2e":    {RetestOnReasons: []string{"test:flake"}, MaxFailuresForTest: 1},
+					"images": {RetestDisabled: &True},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := c.GetRetesterPolicy("openshift", tc.repo)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("%s differs from expected:\n%s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestForJob(t *testing.T) {
+	policy := RetesterPolicy{
+		FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:"}, MaxFailuresForTest: 2},
+		Jobs:          map[string]FailurePolicy{"e2e": {RetestOnReasons: []string{"test:flake"}}},
+	}
+	if diff := cmp.Diff(FailurePolicy{RetestOnReasons: []string{"test:flake"}, MaxFailuresForTest: 2}, policy.ForJob("e2e")); diff != "" {
+		t.Errorf("unexpected policy for configured job: %s", diff)
+	}
+	if diff := cmp.Diff(policy.FailurePolicy, policy.ForJob("unit")); diff != "" {
+		t.Errorf("unexpected policy for other job: %s", diff)
+	}
+}
+
 func TestValidatePolicies(t *testing.T) {
 
 	testCases := []struct {
@@ -262,25 +368,39 @@ func TestValidatePolicies(t *testing.T) {
 	}{
 		{
 			name:   "basic case",
-			policy: RetesterPolicy{3, 9, &True},
+			policy: RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True},
 		},
 		{
 			name: "empty policy is valid",
 		},
 		{
 			name:   "disable",
-			policy: RetesterPolicy{-1, -1, &False},
+			policy: RetesterPolicy{MaxRetestsForShaAndBase: -1, MaxRetestsForSha: -1, Enabled: &False},
 		},
 		{
 			name:   "negative",
-			policy: RetesterPolicy{-1, -1, &True},
+			policy: RetesterPolicy{MaxRetestsForShaAndBase: -1, MaxRetestsForSha: -1, Enabled: &True},
 			expected: []error{
 				errors.New("max_retest_for_sha has invalid value: -1"),
 				errors.New("max_retests_for_sha_and_base has invalid value: -1")},
 		},
+		{
+			name: "invalid failure policies",
+			policy: RetesterPolicy{
+				MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, MaxRetestsPerHour: -1,
+				FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:", ""}},
+				Jobs:          map[string]FailurePolicy{"b": {MaxFailuresForTest: -2}, "a": {MaxFailuresForTest: -1}},
+			},
+			expected: []error{
+				errors.New("max_retests_per_hour has invalid value: -1"),
+				errors.New("retest_on_reasons[1] must not be empty"),
+				errors.New("jobs.a.max_failures_for_test has invalid value: -1"),
+				errors.New("jobs.b.max_failures_for_test has invalid value: -2"),
+			},
+		},
 		{
 			name:     "lower",
-			policy:   RetesterPolicy{9, 3, &True},
+			policy:   RetesterPolicy{MaxRetestsForShaAndBase: 9, MaxRetestsForSha: 3, Enabled: &True},
 			expected: []error{errors.New("max_retest_for_sha value can't be lower than max_retests_for_sha_and_base value: 3 < 9")},
 		},
 	}
@@ -331,7 +451,7 @@ func TestRetestOrBackoff(t *testing.T) {
 			c: &RetestController{
 				ghClient: ghc,
 				logger:   logger,
-				backoff:  &fileBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
+				backoff:  &storageBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
 				config:   config,
 			},
 			expected: "/retest-required\n\nRemaining retests: 2 against base HEAD abcde and 8 for PR HEAD  in total\n",
@@ -350,7 +470,7 @@ func TestRetestOrBackoff(t *testing.T) {
 			c: &RetestController{
 				ghClient: ghc,
 				logger:   logger,
-				backoff:  &fileBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
+				backoff:  &storageBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
 				config:   config,
 			},
 			expected:      "",
@@ -359,7 +479,7 @@ func TestRetestOrBackoff(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := tc.c.retestOrBackoff(tc.pr)
+			err := tc.c.retestOrBackoff(context.Background(), tc.pr, nil)
 			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
 				t.Errorf("Error differs from expected:\n%s", diff)
 			}
@@ -472,7 +592,7 @@ func TestLoadFromDiskNow(t *testing.T) {
 	logger := logrus.NewEntry(logrus.StandardLogger())
 	testCases := []struct {
 		name        string
-		cache       fileBackoffCache
+		cache       storageBackoffCache
 		now         time.Time
 		file        string
 		expectedMap map[string]*pullRequest
@@ -481,7 +601,7 @@ func TestLoadFromDiskNow(t *testing.T) {
 		{
 			name: "basic case",
 			file: "basic_case.yaml",
-			cache: fileBackoffCache{
+			cache: storageBackoffCache{
 				cacheRecordAge: time.Hour,
 				logger:         logger,
 			},
@@ -496,14 +616,14 @@ func TestLoadFromDiskNow(t *testing.T) {
 		{
 			name: "file no exist",
 			file: "no-exist.cache",
-			cache: fileBackoffCache{
+			cache: storageBackoffCache{
 				logger: logger,
 			},
 		},
 		{
 			name: "wrong format",
 			file: "wrong_format.yaml",
-			cache: fileBackoffCache{
+			cache: storageBackoffCache{
 				logger: logger,
 			},
 			expected: errors.New("failed to unmarshal: error unmarshaling JSON: while decoding JSON: json: cannot unmarshal string into Go value of type map[string]*retester.pullRequest"),
@@ -512,9 +632,9 @@ func TestLoadFromDiskNow(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			if tc.file != "" {
-				tc.cache.file = filepath.Join("testdata", "loadFromDiskNow", tc.file)
+				tc.cache.storage = NewFileStorage(filepath.Join("testdata", "loadFromDiskNow", tc.file))
 			}
-			actual := tc.cache.loadFromDiskNow(tc.now)
+			actual := tc.cache.loadNow(context.TODO(), tc.now)
 			if diff := cmp.Diff(tc.expected, actual, testhelper.EquateErrorMessage); diff != "" {
 				t.Errorf("Error differs from expected:\n%s", diff)
 			}
@@ -535,13 +655,13 @@ func TestSaveFileBackoffCache(t *testing.T) {
 	defer os.RemoveAll(dir)
 	testCases := []struct {
 		name            string
-		cache           fileBackoffCache
+		cache           storageBackoffCache
 		expected        error
 		expectedContent string
 	}{
 		{
 			name: "basic case",
-			cache: fileBackoffCache{cache: map[string]*pullRequest{"pr1": {PRSha: "sha1", RetestsForBaseSha: 2, RetestsForPrSha: 3, LastConsideredTime: now},
+			cache: storageBackoffCache{cache: map[string]*pullRequest{"pr1": {PRSha: "sha1", RetestsForBaseSha: 2, RetestsForPrSha: 3, LastConsideredTime: now},
 				"pr3": {PRSha: "sha2", RetestsForBaseSha: 1, RetestsForPrSha: 3, LastConsideredTime: justNow}}},
 			expectedContent: `pr1:
   last_considered_time: "2022-08-18T00:00:00Z"
@@ -561,17 +681,19 @@ pr3:
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
+			var file string
 			if tc.name != "empty file name" {
-				tc.cache.file = filepath.Join(dir, tc.name)
+				file = filepath.Join(dir, tc.name)
+				tc.cache.storage = NewFileStorage(file)
 			}
 			actual := tc.cache.save(context.TODO())
 			if diff := cmp.Diff(tc.expected, actual, testhelper.EquateErrorMessage); diff != "" {
 				t.Errorf("Error differs from expected:\n%s", diff)
 			}
-			if tc.expected == nil && tc.cache.file != "" {
-				actualBytes, err := os.ReadFile(tc.cache.file)
+			if tc.expected == nil && file != "" {
+				actualBytes, err := os.ReadFile(file)
 				if err != nil {
-					t.Errorf("failed to read file %s: %s", tc.cache.file, err.Error())
+					t.Errorf("failed to read file %s: %s", file, err.Error())
 				}
 				actualContent := string(actualBytes)
 				if diff := cmp.Diff(tc.expectedContent, actualContent); diff != "" {
@@ -609,25 +731,24 @@ func TestSaveS3BackoffCache(t *testing.T) {
 
 	testCases := []struct {
 		name          string
-		s3cache       s3BackOffCache
+		s3cache       storageBackoffCache
 		expectedError error
 	}{
 		{
 			name: "successful case",
-			s3cache: s3BackOffCache{
+			s3cache: storageBackoffCache{
 				cache: map[string]*pullRequest{
 					"pr1": {PRSha: "sha1", RetestsForBaseSha: 2, RetestsForPrSha: 3, LastConsideredTime: now},
 					"pr2": {PRSha: "sha2", RetestsForBaseSha: 1, RetestsForPrSha: 3, LastConsideredTime: justNow},
 				},
-				awsClient: svc,
+				storage: &s3Storage{bucket: DefaultS3Bucket, awsClient: svc},
 			},
 			expectedError: nil,
 		},
 		{
 			name: "unsuccessful case",
-			s3cache: s3BackOffCache{
-				file:      "file-name",
-				awsClient: svcFaulty,
+			s3cache: storageBackoffCache{
+				storage: &s3Storage{bucket: DefaultS3Bucket, file: "file-name", awsClient: svcFaulty},
 			},
 			expectedError: fmt.Errorf("failed to upload file file-name into prow-retester bucket: %s", sampleErrorMsg),
 		},
@@ -717,33 +838,30 @@ func TestLoadFromAwsNow(t *testing.T) {
 
 	testCases := []struct {
 		name          string
-		s3cache       s3BackOffCache
+		s3cache       storageBackoffCache
 		expectedError error
 	}{
 		{
 			name: "successful case",
-			s3cache: s3BackOffCache{
-				file:      "file-name",
-				logger:    logger,
-				awsClient: mockClient,
+			s3cache: storageBackoffCache{
+				logger:  logger,
+				storage: &s3Storage{bucket: DefaultS3Bucket, file: "file-name", awsClient: mockClient},
 			},
 			expectedError: nil,
 		},
 		{
 			name: "unsuccessful case",
-			s3cache: s3BackOffCache{
-				file:      "file-name",
-				logger:    logger,
-				awsClient: faultyMockClient,
+			s3cache: storageBackoffCache{
+				logger:  logger,
+				storage: &s3Storage{bucket: DefaultS3Bucket, file: "file-name", awsClient: faultyMockClient},
 			},
 			expectedError: fmt.Errorf("error getting file-name file from aws s3 bucket prow-retester: %s", sampleErrorMsg),
 		},
 		{
 			name: "file not yet in the bucket",
-			s3cache: s3BackOffCache{
-				file:      "file-name",
-				logger:    logger,
-				awsClient: mockClientForNoFile,
+			s3cache: storageBackoffCache{
+				logger:  logger,
+				storage: &s3Storage{bucket: DefaultS3Bucket, file: "file-name", awsClient: mockClientForNoFile},
 			},
 			expectedError: nil,
 		},
@@ -751,7 +869,7 @@ func TestLoadFromAwsNow(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			actualErr := tc.s3cache.loadFromAwsNow(context.TODO(), now)
+			actualErr := tc.s3cache.loadNow(context.TODO(), now)
 			if diff := cmp.Diff(tc.expectedError, actualErr, testhelper.EquateErrorMessage); diff != "" {
 				t.Errorf("error differs from expected:\n%s", diff)
 			}
@@ -799,7 +917,7 @@ func TestCheck(t *testing.T) {
 
 	testCases := []struct {
 		name           string
-		cache          fileBackoffCache
+		cache          storageBackoffCache
 		pr             tide.PullRequest
 		baseSha        string
 		policy         RetesterPolicy
@@ -808,7 +926,7 @@ func TestCheck(t *testing.T) {
 	}{
 		{
 			name:  "hold PR",
-			cache: fileBackoffCache{cache: map[string]*pullRequest{"org/repo#123": {PRSha: "holdPR", RetestsForBaseSha: 3, RetestsForPrSha: 9}}, logger: logger},
+			cache: storageBackoffCache{cache: map[string]*pullRequest{"org/repo#123": {PRSha: "holdPR", RetestsForBaseSha: 3, RetestsForPrSha: 9}}, logger: logger},
 			pr: tide.PullRequest{Number: githubv4.Int(123),
 				Repository: struct {
 					Name          githubv4.String
@@ -816,13 +934,13 @@ func TestCheck(t *testing.T) {
 					Owner         struct{ Login githubv4.String }
 				}{Name: "repo", NameWithOwner: "org/repo", Owner: struct{ Login githubv4.String }{Login: "org"}},
 				HeadRefOID: "holdPR"},
-			policy:         RetesterPolicy{3, 9, &True},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True},
 			expected:       0,
 			expectedString: "Revision holdPR was retested 9 times: holding",
 		},
 		{
 			name:  "pause PR",
-			cache: fileBackoffCache{cache: map[string]*pullRequest{"org/repo#123": {PRSha: "pausePR", RetestsForBaseSha: 3, RetestsForPrSha: 3}}, logger: logger},
+			cache: storageBackoffCache{cache: map[string]*pullRequest{"org/repo#123": {PRSha: "pausePR", RetestsForBaseSha: 3, RetestsForPrSha: 3}}, logger: logger},
 			pr: tide.PullRequest{Number: githubv4.Int(123),
 				Repository: struct {
 					Name          githubv4.String
@@ -830,22 +948,22 @@ func TestCheck(t *testing.T) {
 					Owner         struct{ Login githubv4.String }
 				}{Name: "repo", NameWithOwner: "org/repo", Owner: struct{ Login githubv4.String }{Login: "org"}},
 				HeadRefOID: "pausePR"},
-			policy:         RetesterPolicy{3, 9, &True},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True},
 			expected:       1,
 			expectedString: "Revision pausePR was retested 3 times against base HEAD : pausing",
 		},
 		{
 			name:           "retest PR",
-			cache:          fileBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
+			cache:          storageBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
 			pr:             tide.PullRequest{HeadRefOID: "retestPR"},
-			policy:         RetesterPolicy{3, 9, &True},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True},
 			expected:       2,
 			expectedString: "Remaining retests: 2 against base HEAD  and 8 for PR HEAD retestPR in total",
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			actual, actualString := tc.cache.check(tc.pr, tc.baseSha, tc.policy)
+			actual, actualString := tc.cache.check(tc.pr, tc.baseSha, tc.policy, nil)
 			if diff := cmp.Diff(tc.expected, actual); diff != "" {
 				t.Errorf("%s differs from expected:\n%s", tc.name, diff)
 			}
@@ -856,6 +974,454 @@ func TestCheck(t *testing.T) {
 	}
 }
 
+func TestCheckFailures(t *testing.T) {
+	now := time.Date(2022, 8, 18, 12, 0, 0, 0, time.UTC)
+	pr := tide.PullRequest{Number: githubv4.Int(123),
+		Repository: struct {
+			Name          githubv4.String
+			NameWithOwner githubv4.String
+			Owner         struct{ Login githubv4.String }
+		}{Name: "repo", NameWithOwner: "org/repo", Owner: struct{ Login githubv4.String }{Login: "org"}},
+		HeadRefOID: "sha"}
+	failure := func(job, url string, reasons []string, tests ...string) jobFailure {
+		return jobFailure{failedJob: failedJob{Name: job, URL: url}, Reasons: reasons, Tests: tests}
+	}
+
+	testCases := []struct {
+		name           string
+		cache          map[string]*pullRequest
+		policy         RetesterPolicy
+		failures       []jobFailure
+		expected       retestBackoffAction
+		expectedString string
+		expectedRecord *pullRequest
+	}{
+		{
+			name:           "failure with a retestable reason is retested",
+			cache:          map[string]*pullRequest{},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:"}}},
+			failures:       []jobFailure{failure("e2e", "https://prow/view/gs/bucket/1", []string{"infra:quota"}, "install")},
+			expected:       retestBackoffRetest,
+			expectedString: "Remaining retests: 2 against base HEAD base and 8 for PR HEAD sha in total",
+			expectedRecord: &pullRequest{
+				PRSha: "sha", BaseSha: "base", RetestsForPrSha: 1, RetestsForBaseSha: 1, LastConsideredTime: metav1.NewTime(now),
+				FailedRuns:  map[string]failedRun{"https://prow/view/gs/bucket/1": {Job: "e2e", Reasons: []string{"infra:quota"}, Tests: []string{"install"}}},
+				RetestTimes: []metav1.Time{metav1.NewTime(now)},
+			},
+		},
+		{
+			name:           "run failing for unknown reasons is not recorded",
+			cache:          map[string]*pullRequest{},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True},
+			failures:       []jobFailure{{failedJob: failedJob{Name: "e2e", URL: "https://prow/view/gs/bucket/1"}, unknown: true}},
+			expected:       retestBackoffRetest,
+			expectedString: "Remaining retests: 2 against base HEAD base and 8 for PR HEAD sha in total",
+			expectedRecord: &pullRequest{
+				PRSha: "sha", BaseSha: "base", RetestsForPrSha: 1, RetestsForBaseSha: 1, LastConsideredTime: metav1.NewTime(now),
+				RetestTimes: []metav1.Time{metav1.NewTime(now)},
+			},
+		},
+		{
+			name:           "failure with other reasons is not retested",
+			cache:          map[string]*pullRequest{},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:"}}},
+			failures:       []jobFailure{failure("e2e", "", []string{"product:install-timeout"})},
+			expected:       retestBackoffPause,
+			expectedString: "Revision sha is not retested: job e2e failed for product:install-timeout, retesting only on infra:",
+			expectedRecord: &pullRequest{PRSha: "sha", BaseSha: "base", LastConsideredTime: metav1.NewTime(now)},
+		},
+		{
+			name:   "unclassified failure of a job with its own policy is not retested",
+			cache:  map[string]*pullRequest{},
+			policy: RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, Jobs: map[string]FailurePolicy{"e2e": {RetestOnReasons: []string{"test:flake"}}}},
+			failures: []jobFailure{
+				failure("unit", "", nil),
+				failure("e2e", "", nil),
+			},
+			expected:       retestBackoffPause,
+			expectedString: "Revision sha is not retested: job e2e failed for unknown reasons, retesting only on test:flake",
+			expectedRecord: &pullRequest{PRSha: "sha", BaseSha: "base", LastConsideredTime: metav1.NewTime(now)},
+		},
+		{
+			name:   "failure only of quarantined tests is retested regardless of its reasons",
+			cache:  map[string]*pullRequest{},
+			policy: RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:"}}},
+			failures: []jobFailure{
+				{failedJob: failedJob{Name: "e2e", URL: "https://prow/view/gs/bucket/1"}, Reasons: []string{"test:flake"}, Quarantined: []string{"flaky"}},
+			},
+			expected:       retestBackoffRetest,
+			expectedString: "Remaining retests: 2 against base HEAD base and 8 for PR HEAD sha in total",
+			expectedRecord: &pullRequest{
+				PRSha: "sha", BaseSha: "base", RetestsForPrSha: 1, RetestsForBaseSha: 1, LastConsideredTime: metav1.NewTime(now),
+				FailedRuns:  map[string]failedRun{"https://prow/view/gs/bucket/1": {Job: "e2e", Reasons: []string{"test:flake"}, Quarantined: []string{"flaky"}}},
+				RetestTimes: []metav1.Time{metav1.NewTime(now)},
+			},
+		},
+		{
+			name:           "job with disabled retests is not retested",
+			cache:          map[string]*pullRequest{},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, Jobs: map[string]FailurePolicy{"lint": {RetestDisabled: &True}}},
+			failures:       []jobFailure{failure("lint", "", nil)},
+			expected:       retestBackoffPause,
+			expectedString: "Revision sha is not retested: retests are disabled for job lint",
+			expectedRecord: &pullRequest{PRSha: "sha", BaseSha: "base", LastConsideredTime: metav1.NewTime(now)},
+		},
+		{
+			name: "test failing twice is not retested",
+			cache: map[string]*pullRequest{"org/repo#123": {
+				PRSha: "sha", BaseSha: "base", RetestsForPrSha: 1, RetestsForBaseSha: 1,
+				FailedRuns: map[string]failedRun{"https://prow/view/gs/bucket/1": {Job: "e2e", Tests: []string{"install", "upgrade"}}},
+			}},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, FailurePolicy: FailurePolicy{MaxFailuresForTest: 2}},
+			failures:       []jobFailure{failure("e2e", "https://prow/view/gs/bucket/2", nil, "upgrade")},
+			expected:       retestBackoffPause,
+			expectedString: `Revision sha is not retested: test "upgrade" of job e2e failed 2 times`,
+			expectedRecord: &pullRequest{
+				PRSha: "sha", BaseSha: "base", RetestsForPrSha: 1, RetestsForBaseSha: 1, LastConsideredTime: metav1.NewTime(now),
+				FailedRuns: map[string]failedRun{
+					"https://prow/view/gs/bucket/1": {Job: "e2e", Tests: []string{"install", "upgrade"}},
+					"https://prow/view/gs/bucket/2": {Job: "e2e", Tests: []string{"upgrade"}},
+				},
+			},
+		},
+		{
+			name: "failures of a previous revision are forgotten",
+			cache: map[string]*pullRequest{"org/repo#123": {
+				PRSha: "old", BaseSha: "base", RetestsForPrSha: 1, RetestsForBaseSha: 1,
+				FailedRuns: map[string]failedRun{"https://prow/view/gs/bucket/1": {Job: "e2e", Tests: []string{"upgrade"}}},
+			}},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, FailurePolicy: FailurePolicy{MaxFailuresForTest: 2}},
+			failures:       []jobFailure{failure("e2e", "https://prow/view/gs/bucket/2", nil, "upgrade")},
+			expected:       retestBackoffRetest,
+			expectedString: "Remaining retests: 2 against base HEAD base and 8 for PR HEAD sha in total",
+			expectedRecord: &pullRequest{
+				PRSha: "sha", BaseSha: "base", RetestsForPrSha: 1, RetestsForBaseSha: 1, LastConsideredTime: metav1.NewTime(now),
+				FailedRuns:  map[string]failedRun{"https://prow/view/gs/bucket/2": {Job: "e2e", Tests: []string{"upgrade"}}},
+				RetestTimes: []metav1.Time{metav1.NewTime(now)},
+			},
+		},
+		{
+			name: "exhausted budget of the repo pauses retests",
+			cache: map[string]*pullRequest{
+				"org/repo#1":  {RetestTimes: []metav1.Time{metav1.NewTime(now.Add(-time.Minute)), metav1.NewTime(now.Add(-2 * time.Hour))}},
+				"org/repo#2":  {RetestTimes: []metav1.Time{metav1.NewTime(now.Add(-30 * time.Minute))}},
+				"org/other#1": {RetestTimes: []metav1.Time{metav1.NewTime(now.Add(-time.Minute))}},
+			},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, MaxRetestsPerHour: 2},
+			expected:       retestBackoffPause,
+			expectedString: "Repo org/repo was retested 2 times in the last hour: pausing",
+			expectedRecord: &pullRequest{PRSha: "sha", BaseSha: "base", LastConsideredTime: metav1.NewTime(now)},
+		},
+		{
+			name: "retests older than an hour do not count against the budget",
+			cache: map[string]*pullRequest{
+				"org/repo#1":   {RetestTimes: []metav1.Time{metav1.NewTime(now.Add(-time.Minute))}},
+				"org/repo#123": {PRSha: "sha", BaseSha: "base", RetestTimes: []metav1.Time{metav1.NewTime(now.Add(-2 * time.Hour))}},
+			},
+			policy:         RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, Enabled: &True, MaxRetestsPerHour: 2},
+			expected:       retestBackoffRetest,
+			expectedString: "Remaining retests: 2 against base HEAD base and 8 for PR HEAD sha in total",
+			expectedRecord: &pullRequest{
+				PRSha: "sha", BaseSha: "base", RetestsForPrSha: 1, RetestsForBaseSha: 1, LastConsideredTime: metav1.NewTime(now),
+				RetestTimes: []metav1.Time{metav1.NewTime(now)},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, actualString := check(&tc.cache, pr, "base", tc.policy, tc.failures, now)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("%s differs from expected:\n%s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.expectedString, actualString); diff != "" {
+				t.Errorf("%s differs from expected:\n%s", tc.name, diff)
+			}
+			if diff := cmp.Diff(tc.expectedRecord, tc.cache["org/repo#123"]); diff != "" {
+				t.Errorf("record differs from expected:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunPath(t *testing.T) {
+	testCases := []struct {
+		name          string
+		jobURL        string
+		expected      string
+		expectedError error
+	}{
+		{
+			name:     "job run in GCS",
+			jobURL:   "https://prow.ci.openshift.org/view/gs/test-platform-results/pr-logs/pull/openshift_ci-tools/123/pull-ci-openshift-ci-tools-master-unit/456/",
+			expected: "test-platform-results/pr-logs/pull/openshift_ci-tools/123/pull-ci-openshift-ci-tools-master-unit/456",
+		},
+		{
+			name:          "other URL",
+			jobURL:        "https://prow.ci.openshift.org/log?job=unit&id=456",
+			expectedError: errors.New(`job URL "https://prow.ci.openshift.org/log?job=unit&id=456" does not point to a job run stored in GCS`),
+		},
+		{
+			name:          "bucket without a job run",
+			jobURL:        "https://prow.ci.openshift.org/view/gs/test-platform-results/",
+			expectedError: errors.New(`job URL "https://prow.ci.openshift.org/view/gs/test-platform-results/" does not point to a job run stored in GCS`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := runPath(tc.jobURL)
+			if diff := cmp.Diff(tc.expectedError, err, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("Error differs from expected:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("path differs from expected:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestArtifactFailureGetter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/storage/v1/b/bucket/o":
+			if glob := r.URL.Query().Get("matchGlob"); glob != stepJUnitGlob {
+				http.Error(w, fmt.Sprintf("unexpected glob %q", glob), http.StatusBadRequest)
+				return
+			}
+			switch r.URL.Query().Get("prefix") + r.URL.Query().Get("pageToken") {
+			case "logs/unit/1/artifacts/":
+				_, _ = w.Write([]byte(`{"items":[{"name":"logs/unit/1/artifacts/junit_operator.xml"},{"name":"logs/unit/1/artifacts/e2e/install/artifacts/junit_install.xml"}],"nextPageToken":"next"}`))
+			case "logs/unit/1/artifacts/next":
+				_, _ = w.Write([]byte(`{"items":[{"name":"logs/unit/1/artifacts/e2e/test/artifacts/junit/junit_e2e.xml"}]}`))
+			default:
+				_, _ = w.Write([]byte(`{}`))
+			}
+		case "/bucket/logs/unit/1/artifacts/junit_operator.xml":
+			_, _ = w.Write([]byte(`<testsuites>
+  <testsuite name="operator" tests="3" skipped="0" failures="2" time="10">
+    <testcase name="Run multi-stage test e2e - e2e-install container test" time="5">
+      <failure message="">quota exceeded</failure>
+      <properties>
+        <property name="classification" value="infra:quota"></property>
+        <property name="classification" value="product:install-timeout"></property>
+      </properties>
+    </testcase>
+    <testcase name="Run multi-stage test e2e - e2e-test container test" time="3">
+      <failure message="">test failed</failure>
+    </testcase>
+    <testcase name="Build image src" time="2"></testcase>
+  </testsuite>
+</testsuites>`))
+		case "/bucket/logs/unit/1/artifacts/e2e/install/artifacts/junit_install.xml":
+			_, _ = w.Write([]byte(`<testsuite name="install">
+  <testcase name="install should succeed: overall"><failure message="">timed out</failure></testcase>
+</testsuite>`))
+		case "/bucket/logs/unit/1/artifacts/e2e/test/artifacts/junit/junit_e2e.xml":
+			_, _ = w.Write([]byte(`<testsuites>
+  <testsuite name="openshift-tests">
+    <testcase name="[sig-network] services should work"><failure message="">failed</failure></testcase>
+    <testcase name="[sig-storage] flaky volume"><failure message="">failed</failure></testcase>
+    <testcase name="[sig-storage] flaky volume"></testcase>
+    <testcase name="[sig-apps] skipped"><skipped message="not run"></skipped></testcase>
+  </testsuite>
+</testsuites>`))
+		case "/bucket/logs/unit/2/artifacts/junit_operator.xml":
+			_, _ = w.Write([]byte("not xml"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	getter := &artifactFailureGetter{client: server.Client(), storageURL: server.URL}
+
+	testCases := []struct {
+		name          string
+		url           string
+		expected      jobFailure
+		expectedError bool
+	}{
+		{
+			name: "classified failures of the tests the steps ran",
+			url:  "https://prow/view/gs/bucket/logs/unit/1",
+			expected: jobFailure{
+				failedJob: failedJob{Name: "unit", URL: "https://prow/view/gs/bucket/logs/unit/1"},
+				Reasons:   []string{"infra:quota", "product:install-timeout"},
+				Tests:     []string{"[sig-network] services should work", "install should succeed: overall"},
+			},
+		},
+		{
+			name:     "missing jUnit",
+			url:      "https://prow/view/gs/bucket/logs/unit/3",
+			expected: jobFailure{failedJob: failedJob{Name: "unit", URL: "https://prow/view/gs/bucket/logs/unit/3"}},
+		},
+		{
+			name:          "invalid jUnit",
+			url:           "https://prow/view/gs/bucket/logs/unit/2",
+			expected:      jobFailure{failedJob: failedJob{Name: "unit", URL: "https://prow/view/gs/bucket/logs/unit/2"}},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := getter.failureFor(context.Background(), failedJob{Name: "unit", URL: tc.url})
+			if (err != nil) != tc.expectedError {
+				t.Errorf("expected error %t, got %v", tc.expectedError, err)
+			}
+			if diff := cmp.Diff(tc.expected, actual, cmp.AllowUnexported(jobFailure{})); diff != "" {
+				t.Errorf("failure differs from expected:\n%s", diff)
+			}
+		})
+	}
+}
+
+type fakeFailureGetter map[string]jobFailure
+
+func (f fakeFailureGetter) failureFor(_ context.Context, job failedJob) (jobFailure, error) {
+	failure := f[job.URL]
+	failure.failedJob = job
+	return failure, nil
+}
+
+func TestFailuresForQuarantine(t *testing.T) {
+	list := &quarantine.List{Scopes: map[string]map[string]quarantine.Entry{
+		"openshift/ci-tools@master": {
+			"flaky":   {Expires: time.Now().Add(time.Hour)},
+			"expired": {Expires: time.Now().Add(-time.Hour)},
+		},
+	}}
+	getter := fakeFailureGetter{
+		"https://prow/view/gs/bucket/1": {Reasons: []string{"test:flake"}, Tests: []string{"flaky"}},
+		"https://prow/view/gs/bucket/2": {Tests: []string{"expired", "flaky"}},
+	}
+	pr := tide.PullRequest{
+		Repository: struct {
+			Name          githubv4.String
+			NameWithOwner githubv4.String
+			Owner         struct{ Login githubv4.String }
+		}{Name: "ci-tools", NameWithOwner: "openshift/ci-tools", Owner: struct{ Login githubv4.String }{Login: "openshift"}},
+	}
+	pr.BaseRef.Name = "master"
+	pr.HeadRefOID = "sha"
+	jobs := []failedJob{{Name: "e2e", URL: "https://prow/view/gs/bucket/1"}, {Name: "unit", URL: "https://prow/view/gs/bucket/2"}}
+
+	testCases := []struct {
+		name        string
+		quarantined *quarantine.List
+		recorded    map[string]failedRun
+		expected    []jobFailure
+	}{
+		{
+			name: "failures are not needed without a quarantine list",
+			expected: []jobFailure{
+				{failedJob: jobs[0], unknown: true},
+				{failedJob: jobs[1], unknown: true},
+			},
+		},
+		{
+			name:        "quarantined tests are set apart",
+			quarantined: list,
+			expected: []jobFailure{
+				{failedJob: jobs[0], Reasons: []string{"test:flake"}, Quarantined: []string{"flaky"}},
+				{failedJob: jobs[1], Tests: []string{"expired"}, Quarantined: []string{"flaky"}},
+			},
+		},
+		{
+			name:        "recorded runs are not read again",
+			quarantined: list,
+			recorded: map[string]failedRun{
+				"https://prow/view/gs/bucket/1": {Job: "e2e", Reasons: []string{"infra:quota"}, Tests: []string{"flaky", "other"}},
+				"https://prow/view/gs/bucket/2": {Job: "unit", Tests: []string{"expired"}, Quarantined: []string{"flaky"}},
+			},
+			expected: []jobFailure{
+				{failedJob: jobs[0], Reasons: []string{"infra:quota"}, Tests: []string{"other"}, Quarantined: []string{"flaky"}},
+				{failedJob: jobs[1], Tests: []string{"expired"}, Quarantined: []string{"flaky"}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger := logrus.NewEntry(logrus.StandardLogger())
+			backoff := &storageBackoffCache{cache: map[string]*pullRequest{}, logger: logger}
+			if tc.recorded != nil {
+				backoff.cache[prKey(&pr)] = &pullRequest{PRSha: "sha", FailedRuns: tc.recorded}
+			}
+			c := &RetestController{logger: logger, backoff: backoff, failures: getter, quarantined: tc.quarantined}
+			actual := c.failuresFor(context.Background(), pr, RetesterPolicy{}, jobs)
+			if diff := cmp.Diff(tc.expected, actual, cmp.AllowUnexported(jobFailure{})); diff != "" {
+				t.Errorf("failures differ from expected:\n%s", diff)
+			}
+			for i, failure := range actual {
+				if onlyQuarantined := tc.quarantined != nil && tc.recorded == nil && i == 0; failure.onlyQuarantined() != onlyQuarantined {
+					t.Errorf("expected failure of %s to be only of quarantined tests: %t", failure.Name, onlyQuarantined)
+				}
+			}
+		})
+	}
+}
+
+func TestRunWithCandidatesDryRunReport(t *testing.T) {
+	config := &Config{Retester: Retester{
+		RetesterPolicy: RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9, FailurePolicy: FailurePolicy{RetestOnReasons: []string{"infra:"}}},
+		Oranizations: map[string]Oranization{
+			"openshift": {RetesterPolicy: RetesterPolicy{Enabled: &True}},
+		},
+	}}
+	ghc := &MyFakeClient{fakegithub.NewFakeClient()}
+	ghc.CombinedStatuses = map[string]*github.CombinedStatus{
+		"a": {Statuses: []github.Status{{State: "failure", Context: "test-presubmit", TargetURL: "https://prow/view/gs/bucket/a"}}},
+		"b": {Statuses: []github.Status{{State: "failure", Context: "test-presubmit", TargetURL: "https://prow/view/gs/bucket/b"}}},
+	}
+	configOpts := configflagutil.ConfigOptions{ConfigPath: "testdata/prowconfig/simple.yaml", JobConfigPath: "testdata/jobconfig/simple.yaml"}
+	configAgent, err := configOpts.ConfigAgent()
+	if err != nil {
+		t.Fatalf("Error starting config agent: %v", err)
+	}
+	logger := logrus.NewEntry(logrus.StandardLogger())
+	reportFile := filepath.Join(t.TempDir(), "report.yaml")
+	c := &RetestController{
+		ghClient:     ghc,
+		configGetter: configAgent.Config,
+		logger:       logger,
+		backoff:      &storageBackoffCache{cache: map[string]*pullRequest{}, dryRun: true, logger: logger},
+		failures: fakeFailureGetter{
+			"https://prow/view/gs/bucket/a": {Reasons: []string{"infra:quota"}},
+			"https://prow/view/gs/bucket/b": {Reasons: []string{"test:flake"}},
+		},
+		config:     config,
+		dryRun:     true,
+		reportFile: reportFile,
+	}
+	candidate := func(number int, sha string) tide.PullRequest {
+		return tide.PullRequest{
+			Number:     githubv4.Int(number),
+			HeadRefOID: githubv4.String(sha),
+			Repository: struct {
+				Name          githubv4.String
+				NameWithOwner githubv4.String
+				Owner         struct{ Login githubv4.String }
+			}{Name: "ci-tools", NameWithOwner: "openshift/ci-tools", Owner: struct{ Login githubv4.String }{Login: "openshift"}},
+		}
+	}
+	if err := c.runWithCandidates(context.Background(), map[string]tide.PullRequest{"a": candidate(1, "a"), "b": candidate(2, "b")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ghc.IssueComments) != 0 {
+		t.Errorf("expected no comments in dry-run mode, got %v", ghc.IssueComments)
+	}
+	if diff := cmp.Diff(map[string]*pullRequest{}, c.backoff.(*storageBackoffCache).cache); diff != "" {
+		t.Errorf("expected no records in dry-run mode, diff:\n%s", diff)
+	}
+	raw, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	var actual report
+	if err := yaml.Unmarshal(raw, &actual); err != nil {
+		t.Fatalf("failed to unmarshal report: %v", err)
+	}
+	actual.Time = metav1.Time{}
+	testhelper.CompareWithFixture(t, actual)
+}
+
 func TestRunWithCandidates(t *testing.T) {
 	config := &Config{Retester: Retester{
 		RetesterPolicy: RetesterPolicy{MaxRetestsForShaAndBase: 3, MaxRetestsForSha: 9}, Oranizations: map[string]Oranization{
@@ -916,7 +1482,7 @@ func TestRunWithCandidates(t *testing.T) {
 				configGetter:  configAgent.Config,
 				logger:        logger,
 				usesGitHubApp: true,
-				backoff:       &fileBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
+				backoff:       &storageBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
 				config:        config,
 			}
 			actual := c.runWithCandidates(context.TODO(), tc.candidates)
@@ -974,7 +1540,7 @@ func TestFindCandidates(t *testing.T) {
 				configGetter:  configAgent.Config,
 				logger:        logger,
 				usesGitHubApp: true,
-				backoff:       &fileBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
+				backoff:       &storageBackoffCache{cache: map[string]*pullRequest{}, logger: logger},
 				config:        config,
 			}
 			actual, err := findCandidates(c.configGetter, c.ghClient, c.usesGitHubApp, c.logger)
@@ -0,0 +1,74 @@
+package retester
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// DefaultS3Bucket is the bucket the backoff cache is stored in on S3 by default.
+	DefaultS3Bucket = "prow-retester"
+)
+
+type s3Client interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// s3Storage stores the backoff cache in an AWS S3 bucket.
+type s3Storage struct {
+	bucket    string
+	file      string
+	awsClient s3Client
+}
+
+// NewS3Storage stores the backoff cache in the file of an AWS S3 bucket.
+func NewS3Storage(awsConfig aws.Config, bucket, file string) Storage {
+	return &s3Storage{bucket: bucket, file: file, awsClient: s3.NewFromConfig(awsConfig)}
+}
+
+func (s *s3Storage) String() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.file)
+}
+
+// Load gets the backoff cache file from the AWS S3 bucket
+func (s *s3Storage) Load(ctx context.Context) ([]byte, error) {
+	result, err := s.awsClient.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.file),
+	})
+	if err != nil {
+		nsk := &s3types.NoSuchKey{}
+		if errors.As(err, &nsk) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting %s file from aws s3 bucket %s: %w", s.file, s.bucket, err)
+	}
+	defer result.Body.Close()
+
+	content, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", s.file, err)
+	}
+	return content, nil
+}
+
+// Save uploads the backoff cache to the AWS S3 bucket
+func (s *s3Storage) Save(ctx context.Context, content []byte) error {
+	_, err := s.awsClient.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.file),
+		Body:   bytes.NewReader(content),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload file %s into %s bucket: %w", s.file, s.bucket, err)
+	}
+	return nil
+}
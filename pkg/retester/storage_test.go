@@ -0,0 +1,231 @@
+package retester
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeS3Client keeps objects in memory.
+type fakeS3Client struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3Client) PutObject(_ context.Context, input *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	content, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.objects[*input.Bucket+"/"+*input.Key] = content
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3Client) GetObject(_ context.Context, input *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	content, ok := f.objects[*input.Bucket+"/"+*input.Key]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(content))}, nil
+}
+
+// fakeGCSClient keeps objects in memory, storing them when their writer is
+// closed like GCS does.
+type fakeGCSClient struct {
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeGCSClient) NewReader(_ context.Context, bucket, object string) (io.ReadCloser, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	content, ok := f.objects[bucket+"/"+object]
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (f *fakeGCSClient) NewWriter(ctx context.Context, bucket, object string) io.WriteCloser {
+	return &fakeGCSWriter{ctx: ctx, client: f, name: bucket + "/" + object}
+}
+
+type fakeGCSWriter struct {
+	bytes.Buffer
+	ctx    context.Context
+	client *fakeGCSClient
+	name   string
+}
+
+func (w *fakeGCSWriter) Close() error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	w.client.lock.Lock()
+	defer w.client.lock.Unlock()
+	w.client.objects[w.name] = w.Bytes()
+	return nil
+}
+
+// storageBackends creates a fresh backend for every test and returns a
+// function providing storages at different locations of it.
+var storageBackends = map[string]func(t *testing.T) func(location string) Storage{
+	"file": func(t *testing.T) func(string) Storage {
+		dir := t.TempDir()
+		return func(location string) Storage {
+			return NewFileStorage(filepath.Join(dir, location))
+		}
+	},
+	"s3": func(t *testing.T) func(string) Storage {
+		client := &fakeS3Client{objects: map[string][]byte{}}
+		return func(location string) Storage {
+			return &s3Storage{bucket: DefaultS3Bucket, file: location, awsClient: client}
+		}
+	},
+	"gcs": func(t *testing.T) func(string) Storage {
+		client := &fakeGCSClient{objects: map[string][]byte{}}
+		return func(location string) Storage {
+			return &gcsStorage{bucket: "bucket", object: location, client: client}
+		}
+	},
+	KubernetesStorageConfigMap: func(t *testing.T) func(string) Storage {
+		client := fakectrlruntimeclient.NewClientBuilder().Build()
+		return func(location string) Storage {
+			storage, err := NewKubernetesStorage(client, KubernetesStorageConfigMap, "ci", location)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return storage
+		}
+	},
+	KubernetesStorageSecret: func(t *testing.T) func(string) Storage {
+		client := fakectrlruntimeclient.NewClientBuilder().Build()
+		return func(location string) Storage {
+			storage, err := NewKubernetesStorage(client, KubernetesStorageSecret, "ci", location)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return storage
+		}
+	},
+}
+
+func TestStorageConformance(t *testing.T) {
+	for name, backend := range storageBackends {
+		t.Run(name, func(t *testing.T) {
+			testStorageConformance(t, backend)
+		})
+	}
+}
+
+// testStorageConformance verifies the behavior the backoff cache relies on
+// from every Storage.
+func testStorageConformance(t *testing.T, backend func(t *testing.T) func(location string) Storage) {
+	ctx := context.Background()
+	load := func(t *testing.T, s Storage) []byte {
+		content, err := s.Load(ctx)
+		if err != nil {
+			t.Fatalf("failed to load from %s: %v", s, err)
+		}
+		return content
+	}
+	save := func(t *testing.T, s Storage, content string) {
+		if err := s.Save(ctx, []byte(content)); err != nil {
+			t.Fatalf("failed to save to %s: %v", s, err)
+		}
+	}
+
+	t.Run("nothing is loaded before anything was saved", func(t *testing.T) {
+		if content := load(t, backend(t)("cache")); content != nil {
+			t.Errorf("expected no content, got %q", string(content))
+		}
+	})
+
+	t.Run("saved content is loaded", func(t *testing.T) {
+		s := backend(t)("cache")
+		save(t, s, "content")
+		if diff := cmp.Diff("content", string(load(t, s))); diff != "" {
+			t.Errorf("unexpected content: %s", diff)
+		}
+	})
+
+	t.Run("saving replaces the content", func(t *testing.T) {
+		s := backend(t)("cache")
+		save(t, s, "first")
+		save(t, s, "second")
+		if diff := cmp.Diff("second", string(load(t, s))); diff != "" {
+			t.Errorf("unexpected content: %s", diff)
+		}
+	})
+
+	t.Run("content is shared between storages at the same location", func(t *testing.T) {
+		storages := backend(t)
+		save(t, storages("cache"), "content")
+		if diff := cmp.Diff("content", string(load(t, storages("cache")))); diff != "" {
+			t.Errorf("unexpected content: %s", diff)
+		}
+	})
+
+	t.Run("storages at different locations are independent", func(t *testing.T) {
+		storages := backend(t)
+		save(t, storages("cache"), "content")
+		if content := load(t, storages("other")); content != nil {
+			t.Errorf("expected no content at another location, got %q", string(content))
+		}
+	})
+
+	t.Run("backoff cache is restored without old records", func(t *testing.T) {
+		s := backend(t)("cache")
+		logger := logrus.NewEntry(logrus.StandardLogger())
+		now := time.Date(2022, 8, 18, 0, 0, 0, 0, time.UTC)
+		recent := &pullRequest{
+			PRSha: "sha1", BaseSha: "base", RetestsForBaseSha: 2, RetestsForPrSha: 3, LastConsideredTime: metav1.NewTime(now.Add(-time.Minute)),
+			FailedRuns:  map[string]failedRun{"https://prow/view/gs/bucket/1": {Job: "e2e", Tests: []string{"install"}}},
+			RetestTimes: []metav1.Time{metav1.NewTime(now.Add(-time.Minute))},
+		}
+		written := newBackoffCache(s, time.Hour, false, logger)
+		written.cache = map[string]*pullRequest{
+			"org/repo#1": recent,
+			"org/repo#2": {PRSha: "sha2", LastConsideredTime: metav1.NewTime(now.Add(-2 * time.Hour))},
+		}
+		if err := written.save(ctx); err != nil {
+			t.Fatalf("failed to save the cache: %v", err)
+		}
+		dryRun := newBackoffCache(s, time.Hour, true, logger)
+		dryRun.cache = map[string]*pullRequest{"org/repo#3": {PRSha: "sha3", LastConsideredTime: metav1.NewTime(now)}}
+		if err := dryRun.save(ctx); err != nil {
+			t.Fatalf("failed to save the cache in dry-run mode: %v", err)
+		}
+		read := newBackoffCache(s, time.Hour, false, logger)
+		if err := read.loadNow(ctx, now); err != nil {
+			t.Fatalf("failed to load the cache: %v", err)
+		}
+		if diff := cmp.Diff(map[string]*pullRequest{"org/repo#1": recent}, read.cache); diff != "" {
+			t.Errorf("unexpected cache: %s", diff)
+		}
+	})
+}
+
+func TestNewKubernetesStorage(t *testing.T) {
+	_, err := NewKubernetesStorage(fakectrlruntimeclient.NewClientBuilder().Build(), "pod", "ci", "cache")
+	if diff := cmp.Diff(`unknown kind "pod", must be configmap or secret`, err.Error()); diff != "" {
+		t.Errorf("unexpected error: %s", diff)
+	}
+}
@@ -48,13 +48,24 @@ func (s *bundleSourceStep) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	fromTag := api.PipelineImageStreamTagReferenceSource
-	fromDigest, err := resolvePipelineImageStreamTagReference(ctx, s.client, fromTag, s.jobSpec)
+	fromDigest, err := resolvePipelineImageStreamTagReference(ctx, s.client, api.PipelineImageStreamTagReferenceSource, s.jobSpec)
 	if err != nil {
 		return err
 	}
-	build := buildFromSource(
-		s.jobSpec, fromTag, api.PipelineImageStreamTagReferenceBundleSource,
+	build := s.build(workingDir, dockerfile, fromDigest)
+
+	// Bundle images are not multi-arch by design. Here we build it without creating a manifest-listed image.
+	// Note that we are not configuring a node selector here, so the build will be scheduled on any available
+	// node no matter the architecture.
+	return handleBuild(ctx, s.client, s.podClient, *build)
+}
+
+// build returns the build for the bundle source image, copying the source from
+// the given working directory.
+func (s *bundleSourceStep) build(workingDir, dockerfile, fromDigest string) *buildapi.Build {
+	source := fmt.Sprintf("%s:%s", api.PipelineImageStream, api.PipelineImageStreamTagReferenceSource)
+	return buildFromSource(
+		s.jobSpec, api.PipelineImageStreamTagReferenceSource, api.PipelineImageStreamTagReferenceBundleSource,
 		buildapi.BuildSource{
 			Type:       buildapi.BuildSourceDockerfile,
 			Dockerfile: &dockerfile,
@@ -78,11 +89,19 @@ func (s *bundleSourceStep) run(ctx context.Context) error {
 		nil,
 		"",
 	)
+}
 
-	// Bundle images are not multi-arch by design. Here we build it without creating a manifest-listed image.
-	// Note that we are not configuring a node selector here, so the build will be scheduled on any available
-	// node no matter the architecture.
-	return handleBuild(ctx, s.client, s.podClient, *build)
+// DryRun renders the build for the bundle source image. The working directory
+// of the source image and the digests of the images substituted into the
+// manifests are only known once the images have been built.
+func (s *bundleSourceStep) DryRun() (*DryRunOutput, error) {
+	dockerfile, err := s.dockerfile(func(string, string) (string, error) {
+		return DryRunPlaceholder, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dryRunBuilds([]buildapi.Build{*s.build(DryRunPlaceholder, dockerfile, DryRunPlaceholder)}), nil
 }
 
 func replaceCommand(pullSpec, with string) string {
@@ -91,11 +110,19 @@ func replaceCommand(pullSpec, with string) string {
 }
 
 func (s *bundleSourceStep) bundleSourceDockerfile() (string, error) {
+	return s.dockerfile(func(streamName, tagName string) (string, error) {
+		return utils.ImageDigestFor(s.client, s.jobSpec.Namespace, streamName, tagName)()
+	})
+}
+
+// dockerfile substitutes the pull specs in the manifests with the images
+// resolved by digestFor.
+func (s *bundleSourceStep) dockerfile(digestFor func(streamName, tagName string) (string, error)) (string, error) {
 	var dockerCommands []string
 	dockerCommands = append(dockerCommands, fmt.Sprintf("FROM %s:%s", api.PipelineImageStream, api.PipelineImageStreamTagReferenceSource))
 	for _, sub := range s.config.Substitutions {
 		streamName, tagName, _ := s.releaseBuildConfig.DependencyParts(api.StepDependency{Name: sub.With}, nil)
-		replaceSpec, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, streamName, tagName)()
+		replaceSpec, err := digestFor(streamName, tagName)
 		if err != nil {
 			return "", fmt.Errorf("failed to get image digest for %s: %w", sub.With, err)
 		}
@@ -62,6 +62,22 @@ func (s *clusterClaimStep) Creates() []api.StepLink             { return s.wrapp
 func (s *clusterClaimStep) Objects() []ctrlruntimeclient.Object { return s.wrapped.Objects() }
 func (s *clusterClaimStep) Provides() api.ParameterMap          { return s.wrapped.Provides() }
 
+func (s *clusterClaimStep) SubSteps() []api.CIOperatorStepDetailInfo {
+	if subSteps, ok := s.wrapped.(SubStepReporter); ok {
+		return subSteps.SubSteps()
+	}
+	return nil
+}
+
+// DryRun reports what the wrapped step would create. The claim itself is not
+// rendered, as the pool it is made from is only known to Hive.
+func (s *clusterClaimStep) DryRun() (*DryRunOutput, error) {
+	if dryRunner, ok := s.wrapped.(DryRunner); ok {
+		return dryRunner.DryRun()
+	}
+	return &DryRunOutput{}, nil
+}
+
 func (s *clusterClaimStep) Run(ctx context.Context) error {
 	return results.ForReason("utilizing_cluster_claim").ForError(s.run(ctx))
 }
@@ -87,19 +87,30 @@ func (s *indexGeneratorStep) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	fromTag := api.PipelineImageStreamTagReferenceSource
-	fromDigest, err := resolvePipelineImageStreamTagReference(ctx, s.client, fromTag, s.jobSpec)
+	fromDigest, err := resolvePipelineImageStreamTagReference(ctx, s.client, api.PipelineImageStreamTagReferenceSource, s.jobSpec)
 	if err != nil {
 		return err
 	}
+	build := s.build(workingDir, dockerfile, fromDigest)
+	err = handleBuilds(ctx, s.client, s.podClient, *build, newImageBuildOptions(s.architectures.UnsortedList()))
+	if err != nil && strings.Contains(err.Error(), "error checking provided apis") {
+		return results.ForReason("generating_index").WithError(err).Errorf("failed to generate operator index due to invalid bundle info: %v", err)
+	}
+	return err
+}
+
+// build returns the build for the index image, copying the source from the
+// given working directory.
+func (s *indexGeneratorStep) build(workingDir, dockerfile, fromDigest string) *buildapi.Build {
+	source := fmt.Sprintf("%s:%s", api.PipelineImageStream, api.PipelineImageStreamTagReferenceSource)
 	var secrets []buildapi.SecretBuildSource
 	if s.pullSecret != nil {
 		secrets = append(secrets, buildapi.SecretBuildSource{
 			Secret: coreapi.LocalObjectReference{Name: s.pullSecret.Name},
 		})
 	}
-	build := buildFromSource(
-		s.jobSpec, fromTag, s.config.To,
+	return buildFromSource(
+		s.jobSpec, api.PipelineImageStreamTagReferenceSource, s.config.To,
 		buildapi.BuildSource{
 			Type:       buildapi.BuildSourceDockerfile,
 			Dockerfile: &dockerfile,
@@ -124,14 +135,32 @@ func (s *indexGeneratorStep) run(ctx context.Context) error {
 		nil,
 		"",
 	)
-	err = handleBuilds(ctx, s.client, s.podClient, *build, newImageBuildOptions(s.architectures.UnsortedList()))
-	if err != nil && strings.Contains(err.Error(), "error checking provided apis") {
-		return results.ForReason("generating_index").WithError(err).Errorf("failed to generate operator index due to invalid bundle info: %v", err)
+}
+
+// DryRun renders the builds for the index image. The working directory of the
+// source image and the digests of the bundles are only known once the images
+// have been built, and a base index which is not a database index skips the
+// build at runtime.
+func (s *indexGeneratorStep) DryRun() (*DryRunOutput, error) {
+	dockerfile, err := s.dockerfile(func(string) (string, error) {
+		return DryRunPlaceholder, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return err
+	build := s.build(DryRunPlaceholder, dockerfile, DryRunPlaceholder)
+	return dryRunBuilds(constructMultiArchBuilds(*build, sets.List(s.architectures))), nil
 }
 
 func (s *indexGeneratorStep) indexGenDockerfile() (string, error) {
+	return s.dockerfile(func(tagName string) (string, error) {
+		return utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.PipelineImageStream, tagName)()
+	})
+}
+
+// dockerfile adds the bundles to the index, referring to them and the base
+// index by the images resolved by digestFor.
+func (s *indexGeneratorStep) dockerfile(digestFor func(tagName string) (string, error)) (string, error) {
 	var dockerCommands []string
 	dockerCommands = append(dockerCommands, "FROM quay.io/operator-framework/upstream-opm-builder AS builder")
 	if s.pullSecret != nil {
@@ -140,7 +169,7 @@ func (s *indexGeneratorStep) indexGenDockerfile() (string, error) {
 	}
 	var bundles []string
 	for _, bundleName := range s.config.OperatorIndex {
-		fullSpec, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.PipelineImageStream, bundleName)()
+		fullSpec, err := digestFor(bundleName)
 		if err != nil {
 			return "", fmt.Errorf("failed to get image digest for bundle `%s`: %w", bundleName, err)
 		}
@@ -148,7 +177,7 @@ func (s *indexGeneratorStep) indexGenDockerfile() (string, error) {
 	}
 	baseIndex := ""
 	if s.config.BaseIndex != "" {
-		fullSpec, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, api.PipelineImageStream, s.config.BaseIndex)()
+		fullSpec, err := digestFor(s.config.BaseIndex)
 		if err != nil {
 			return "", fmt.Errorf("failed to get image digest for bundle `%s`: %w", s.config.BaseIndex, err)
 		}
@@ -79,14 +79,35 @@ func (s *inputImageTagStep) run(ctx context.Context) error {
 		return fmt.Errorf("could not resolve inputs for image tag step: %w", err)
 	}
 
-	var objectReferenceName string
 	if s.config.ExternalImage != nil {
-		externalPullSpec := externalImageReference(s.config)
-		logrus.Infof("Tagging %s into %s:%s.", externalPullSpec, api.PipelineImageStream, s.config.To)
-		objectReferenceName = externalPullSpec
+		logrus.Infof("Tagging %s into %s:%s.", externalImageReference(s.config), api.PipelineImageStream, s.config.To)
 	} else {
 		logrus.Infof("Tagging %s into %s:%s.", s.config.BaseImage.ISTagName(), api.PipelineImageStream, s.config.To)
-		objectReferenceName = api.QuayImageReference(s.config.BaseImage)
+	}
+	ist := s.imageStreamTag(s.imageName)
+	if err := s.client.Create(ctx, ist); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create imagestreamtag for input image: %w", err)
+	}
+
+	if err := waitForTagInSpec(ctx, s.client, s.jobSpec.Namespace(), api.PipelineImageStream, string(s.config.To), 3*time.Minute); err != nil {
+		return fmt.Errorf("failed to wait for the tag %s to show in the spec of imagestream %s/%s", string(s.config.To), s.jobSpec.Namespace(), api.PipelineImageStream)
+	}
+
+	logrus.Debugf("Waiting to import tags on imagestream (after creating pipeline) %s/%s:%s ...", s.jobSpec.Namespace(), api.PipelineImageStream, s.config.To)
+	if err := utils.WaitForImportingISTag(ctx, s.client, s.jobSpec.Namespace(), api.PipelineImageStream, nil, sets.New(string(s.config.To)), utils.DefaultImageImportTimeout); err != nil {
+		return fmt.Errorf("failed to wait for importing imagestreamtags on %s/%s:%s: %w", s.jobSpec.Namespace(), api.PipelineImageStream, s.config.To, err)
+	}
+	logrus.Debugf("Imported tags on imagestream (after creating pipeline) %s/%s:%s", s.jobSpec.Namespace(), api.PipelineImageStream, s.config.To)
+	return nil
+}
+
+// imageStreamTag builds the tag pointing the pipeline image stream at the
+// input image. The image name is only used for images from cluster bot jobs,
+// which are resolved in the namespace of the job.
+func (s *inputImageTagStep) imageStreamTag(imageName string) *imagev1.ImageStreamTag {
+	objectReferenceName := api.QuayImageReference(s.config.BaseImage)
+	if s.config.ExternalImage != nil {
+		objectReferenceName = externalImageReference(s.config)
 	}
 	from := &coreapi.ObjectReference{
 		Kind: "DockerImage",
@@ -95,12 +116,11 @@ func (s *inputImageTagStep) run(ctx context.Context) error {
 	if api.IsCreatedForClusterBotJob(s.config.BaseImage.Namespace) {
 		from = &coreapi.ObjectReference{
 			Kind:      "ImageStreamImage",
-			Name:      fmt.Sprintf("%s@%s", s.config.BaseImage.Name, s.imageName),
+			Name:      fmt.Sprintf("%s@%s", s.config.BaseImage.Name, imageName),
 			Namespace: s.config.BaseImage.Namespace,
 		}
 	}
-
-	ist := &imagev1.ImageStreamTag{
+	return &imagev1.ImageStreamTag{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s:%s", api.PipelineImageStream, s.config.To),
 			Namespace: s.jobSpec.Namespace(),
@@ -115,21 +135,12 @@ func (s *inputImageTagStep) run(ctx context.Context) error {
 			},
 		},
 	}
+}
 
-	if err := s.client.Create(ctx, ist); err != nil && !kerrors.IsAlreadyExists(err) {
-		return fmt.Errorf("failed to create imagestreamtag for input image: %w", err)
-	}
-
-	if err := waitForTagInSpec(ctx, s.client, s.jobSpec.Namespace(), api.PipelineImageStream, string(s.config.To), 3*time.Minute); err != nil {
-		return fmt.Errorf("failed to wait for the tag %s to show in the spec of imagestream %s/%s", string(s.config.To), s.jobSpec.Namespace(), api.PipelineImageStream)
-	}
-
-	logrus.Debugf("Waiting to import tags on imagestream (after creating pipeline) %s/%s:%s ...", s.jobSpec.Namespace(), api.PipelineImageStream, s.config.To)
-	if err := utils.WaitForImportingISTag(ctx, s.client, s.jobSpec.Namespace(), api.PipelineImageStream, nil, sets.New(string(s.config.To)), utils.DefaultImageImportTimeout); err != nil {
-		return fmt.Errorf("failed to wait for importing imagestreamtags on %s/%s:%s: %w", s.jobSpec.Namespace(), api.PipelineImageStream, s.config.To, err)
-	}
-	logrus.Debugf("Imported tags on imagestream (after creating pipeline) %s/%s:%s", s.jobSpec.Namespace(), api.PipelineImageStream, s.config.To)
-	return nil
+// DryRun renders the tag for the input image. Images from cluster bot jobs
+// are resolved to a digest at runtime.
+func (s *inputImageTagStep) DryRun() (*DryRunOutput, error) {
+	return &DryRunOutput{Objects: []ctrlruntimeclient.Object{s.imageStreamTag(DryRunPlaceholder)}}, nil
 }
 
 // waitForTagInSpec waits for the tag on the image stream are to show in spec
@@ -29,6 +29,12 @@ type ipPoolStep struct {
 	ipPoolLease  stepLease
 	wrapped      api.Step
 	params       api.Parameters
+	// leased holds the resources leased by the enclosing lease step
+	leased map[string][]string
+	// resumed are the resources leased by an interrupted execution
+	resumed map[string][]string
+	// recordLeases records the resources leased for the step
+	recordLeases func(leased map[string][]string)
 
 	namespace func() string
 }
@@ -83,6 +89,62 @@ func (s *ipPoolStep) SubTests() []*junit.TestCase {
 	return nil
 }
 
+func (s *ipPoolStep) SubSteps() []api.CIOperatorStepDetailInfo {
+	if subSteps, ok := s.wrapped.(SubStepReporter); ok {
+		return subSteps.SubSteps()
+	}
+	return nil
+}
+
+// DryRun reports the IP pool lease along with what the wrapped step would create.
+func (s *ipPoolStep) DryRun() (*DryRunOutput, error) {
+	ret := &DryRunOutput{}
+	if dryRunner, ok := s.wrapped.(DryRunner); ok {
+		var err error
+		if ret, err = dryRunner.DryRun(); err != nil {
+			return nil, err
+		}
+	}
+	ret.Leases = append(ret.Leases, s.ipPoolLease.StepLease)
+	return ret, nil
+}
+
+// Resume forwards to the wrapped step. Resources leased anew are part of the
+// environment of the wrapped step, so its outputs are not reused with them.
+func (s *ipPoolStep) Resume(ctx context.Context, finished bool) (bool, error) {
+	if resumer, ok := s.wrapped.(Resumer); ok {
+		return resumer.Resume(ctx, finished)
+	}
+	return false, nil
+}
+
+// ReusableLeases forwards to the wrapped step and picks the IP pool
+// resources to lease again.
+func (s *ipPoolStep) ReusableLeases(ctx context.Context) map[string][]string {
+	reuser, ok := s.wrapped.(LeaseReuser)
+	if !ok {
+		return nil
+	}
+	reusable := reuser.ReusableLeases(ctx)
+	s.ipPoolLease.reuse = reusable[s.ipPoolLease.Env]
+	return reusable
+}
+
+// Leased records the resources of the enclosing lease step, which are passed
+// to the wrapped step along with the IP pool resources.
+func (s *ipPoolStep) Leased(resources map[string][]string) {
+	s.leased = resources
+}
+
+// ResumeLeases leases the IP pool resources of the interrupted execution
+// again if they are free and forwards to the wrapped step.
+func (s *ipPoolStep) ResumeLeases(previous map[string][]string, record func(leased map[string][]string)) {
+	s.resumed, s.recordLeases = previous, record
+	if resumer, ok := s.wrapped.(LeaseResumer); ok {
+		resumer.ResumeLeases(previous, record)
+	}
+}
+
 func (s *ipPoolStep) Run(ctx context.Context) error {
 	return results.ForReason("utilizing_ip_pool").ForError(s.run(ctx, time.Minute))
 }
@@ -96,10 +158,13 @@ func (s *ipPoolStep) run(ctx context.Context, minute time.Duration) error {
 	}
 	l.ResourceType = fmt.Sprintf("%s-%s", l.ResourceType, region)
 	logrus.Infof("Acquiring IP Pool leases for test %s: %v", s.Name(), l.ResourceType)
+	if names, ok := s.resumed[l.Env]; ok {
+		l.reuse = names
+	}
 	client := *s.client
 	ctx, cancel := context.WithCancel(ctx)
 
-	names, err := client.AcquireIfAvailableImmediately(l.ResourceType, l.Count, cancel)
+	names, err := client.AcquireIfAvailableImmediately(lease.Request{ResourceType: l.ResourceType, Count: l.Count, Priority: leasePriority(l.Priority), Names: l.reuse}, cancel)
 	if err != nil {
 		if err == lease.ErrNotFound {
 			logrus.Infof("no leases of type: %s available", l.ResourceType)
@@ -109,6 +174,17 @@ func (s *ipPoolStep) run(ctx context.Context, minute time.Duration) error {
 	} else {
 		logrus.Infof("Acquired %d ip pool lease(s) for %s: %v", l.Count, l.ResourceType, names)
 		s.ipPoolLease.resources = names
+		if s.recordLeases != nil {
+			s.recordLeases(leasedResources(*l))
+		}
+	}
+
+	if reuser, ok := s.wrapped.(LeaseReuser); ok {
+		resources := leasedResources(*l)
+		for env, names := range s.leased {
+			resources[env] = names
+		}
+		reuser.Leased(resources)
 	}
 
 	remainingResources := make(chan []string)
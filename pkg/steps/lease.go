@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -23,6 +23,32 @@ var NoLeaseClientErr = errors.New("step needs a lease but no lease client provid
 type stepLease struct {
 	api.StepLease
 	resources []string
+	// reuse are resources to lease again if they are free
+	reuse []string
+}
+
+// LeaseReuser may be implemented by steps wrapped in a lease step whose
+// outputs from an earlier execution, such as a cluster, can only be reused
+// with the resources leased for that execution.
+type LeaseReuser interface {
+	// ReusableLeases returns the resources to lease again if they are free,
+	// by the environment variable of their lease.
+	ReusableLeases(ctx context.Context) map[string][]string
+	// Leased is called with the resources leased for the step, by the
+	// environment variable of their lease, before the step runs.
+	Leased(resources map[string][]string)
+}
+
+// leasedResources returns the resources held by the leases, by environment
+// variable.
+func leasedResources(leases ...stepLease) map[string][]string {
+	ret := map[string][]string{}
+	for _, l := range leases {
+		if len(l.resources) != 0 {
+			ret[l.Env] = l.resources
+		}
+	}
+	return ret
 }
 
 // leaseStep wraps another step and acquires/releases one or more leases.
@@ -33,6 +59,14 @@ type leaseStep struct {
 
 	// for sending heartbeats during lease acquisition
 	namespace func() string
+
+	// acquisition records how long the step waited for its leases
+	acquisition *api.CIOperatorStepDetailInfo
+
+	// resumed are the resources leased by an interrupted execution
+	resumed map[string][]string
+	// recordLeases records the resources leased for the step
+	recordLeases func(leased map[string][]string)
 }
 
 func LeaseStep(client *lease.Client, leases []api.StepLease, wrapped api.Step, namespace func() string) api.Step {
@@ -101,6 +135,52 @@ func (s *leaseStep) SubTests() []*junit.TestCase {
 	return nil
 }
 
+// SubSteps reports the lease acquisition along with the sub-steps of the
+// wrapped step.
+func (s *leaseStep) SubSteps() []api.CIOperatorStepDetailInfo {
+	var ret []api.CIOperatorStepDetailInfo
+	if s.acquisition != nil {
+		ret = append(ret, *s.acquisition)
+	}
+	if subSteps, ok := s.wrapped.(SubStepReporter); ok {
+		ret = append(ret, subSteps.SubSteps()...)
+	}
+	return ret
+}
+
+// DryRun reports the leases along with what the wrapped step would create.
+func (s *leaseStep) DryRun() (*DryRunOutput, error) {
+	ret := &DryRunOutput{}
+	if dryRunner, ok := s.wrapped.(DryRunner); ok {
+		var err error
+		if ret, err = dryRunner.DryRun(); err != nil {
+			return nil, err
+		}
+	}
+	for _, l := range s.leases {
+		ret.Leases = append(ret.Leases, l.StepLease)
+	}
+	return ret, nil
+}
+
+// Resume forwards to the wrapped step. Resources leased anew are part of the
+// environment of the wrapped step, so its outputs are not reused with them.
+func (s *leaseStep) Resume(ctx context.Context, finished bool) (bool, error) {
+	if resumer, ok := s.wrapped.(Resumer); ok {
+		return resumer.Resume(ctx, finished)
+	}
+	return false, nil
+}
+
+// ResumeLeases leases the resources of the interrupted execution again if
+// they are free and forwards to the wrapped step.
+func (s *leaseStep) ResumeLeases(previous map[string][]string, record func(leased map[string][]string)) {
+	s.resumed, s.recordLeases = previous, record
+	if resumer, ok := s.wrapped.(LeaseResumer); ok {
+		resumer.ResumeLeases(previous, record)
+	}
+}
+
 func (s *leaseStep) Run(ctx context.Context) error {
 	return results.ForReason("utilizing_lease").ForError(s.run(ctx))
 }
@@ -111,11 +191,42 @@ func (s *leaseStep) run(ctx context.Context) error {
 		types = append(types, s.leases[i].ResourceType)
 	}
 	logrus.Infof("Acquiring leases for test %s: %v", s.Name(), types)
+	reuser, reuses := s.wrapped.(LeaseReuser)
+	if reuses {
+		reusable := reuser.ReusableLeases(ctx)
+		for i := range s.leases {
+			s.leases[i].reuse = reusable[s.leases[i].Env]
+		}
+	}
+	for i := range s.leases {
+		if names, ok := s.resumed[s.leases[i].Env]; ok {
+			s.leases[i].reuse = names
+		}
+	}
 	client := *s.client
 	ctx, cancel := context.WithCancel(ctx)
-	if err := acquireLeases(client, ctx, cancel, s.leases); err != nil {
+	start := time.Now()
+	err := acquireLeases(client, ctx, cancel, s.leases)
+	finished := time.Now()
+	duration := finished.Sub(start)
+	failed := err != nil
+	s.acquisition = &api.CIOperatorStepDetailInfo{
+		StepName:    s.Name() + "-lease",
+		Description: fmt.Sprintf("Acquire leases for test %s", s.Name()),
+		StartedAt:   &start,
+		FinishedAt:  &finished,
+		Duration:    &duration,
+		Failed:      &failed,
+	}
+	if err != nil {
 		return err
 	}
+	if s.recordLeases != nil {
+		s.recordLeases(leasedResources(s.leases...))
+	}
+	if reuses {
+		reuser.Leased(leasedResources(s.leases...))
+	}
 	wrappedErr := results.ForReason("executing_test").ForError(s.wrapped.Run(ctx))
 	logrus.Infof("Releasing leases for test %s", s.Name())
 	releaseErr := results.ForReason("releasing_lease").ForError(releaseLeases(client, s.leases...))
@@ -140,35 +251,38 @@ func acquireLeases(
 	cancel context.CancelFunc,
 	leases []stepLease,
 ) error {
-	// Sort by resource type to avoid a(n unlikely and temporary) deadlock.
-	var sorted []int
-	for i := range leases {
-		sorted = append(sorted, i)
-	}
-	sort.Slice(sorted, func(i, j int) bool {
-		return leases[i].ResourceType < leases[j].ResourceType
-	})
-	var errs []error
-	for _, i := range sorted {
-		l := &leases[i]
+	var requests []lease.Request
+	for _, l := range leases {
 		logrus.Debugf("Acquiring %d lease(s) for %s", l.Count, l.ResourceType)
-		names, err := client.Acquire(l.ResourceType, l.Count, ctx, cancel)
-		if err != nil {
-			if err == lease.ErrNotFound {
+		requests = append(requests, lease.Request{ResourceType: l.ResourceType, Count: l.Count, Priority: leasePriority(l.Priority), Names: l.reuse})
+	}
+	start := time.Now()
+	names, err := client.AcquireAll(requests, ctx, cancel)
+	if err != nil {
+		if errors.Is(err, lease.ErrNotFound) {
+			for _, l := range leases {
 				printResourceMetrics(client, l.ResourceType)
 			}
-			errs = append(errs, results.ForReason(results.Reason("acquiring_lease")).WithError(err).Errorf("failed to acquire lease for %q: %v", l.ResourceType, err))
-			break
 		}
-		logrus.Infof("Acquired %d lease(s) for %s: %v", l.Count, l.ResourceType, names)
-		l.resources = names
+		return results.ForReason(results.Reason("acquiring_lease")).WithError(err).Errorf("failed to acquire leases: %v", err)
 	}
-	if errs != nil {
-		if err := releaseLeases(client, leases...); err != nil {
-			errs = append(errs, fmt.Errorf("failed to release leases after acquisition failure: %w", err))
-		}
+	for i := range leases {
+		l := &leases[i]
+		logrus.Infof("Acquired %d lease(s) for %s after %s: %v", l.Count, l.ResourceType, time.Since(start).Truncate(time.Second), names[i])
+		l.resources = names[i]
+	}
+	return nil
+}
+
+func leasePriority(priority api.LeasePriority) lease.Priority {
+	switch priority {
+	case api.LeasePriorityHigh:
+		return lease.PriorityHigh
+	case api.LeasePriorityLow:
+		return lease.PriorityLow
+	default:
+		return lease.PriorityNormal
 	}
-	return utilerrors.NewAggregate(errs)
 }
 
 func releaseLeases(client lease.Client, leases ...stepLease) error {
@@ -193,5 +307,5 @@ func printResourceMetrics(client lease.Client, rtype string) {
 		logrus.WithError(err).Warn("Could not get resource metrics.")
 		return
 	}
-	logrus.Errorf("error: Failed to acquire resource, current capacity: %d free, %d leased", m.Free, m.Leased)
+	logrus.Errorf("error: Failed to acquire resource of type %s, current capacity: %d free, %d leased", rtype, m.Free, m.Leased)
 }
@@ -6,6 +6,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"k8s.io/apimachinery/pkg/util/diff"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -133,6 +135,18 @@ func TestProvidesStripsSuffix(t *testing.T) {
 	}
 }
 
+func TestLeaseStepDryRun(t *testing.T) {
+	leases := []api.StepLease{{Env: api.DefaultLeaseEnv, ResourceType: "aws-quota-slice", Count: 1}}
+	withLease := LeaseStep(nil, leases, &stepNeedsLease{}, emptyNamespace)
+	output, err := withLease.(DryRunner).DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&DryRunOutput{Leases: leases}, output); diff != "" {
+		t.Errorf("unexpected dry run output: %s", diff)
+	}
+}
+
 func TestError(t *testing.T) {
 	leases := []api.StepLease{
 		{ResourceType: "rtype0", Count: 1},
@@ -148,19 +162,30 @@ func TestError(t *testing.T) {
 	}{{
 		name: "first acquire fails",
 		failures: map[string]error{
-			"acquireWaitWithPriority owner rtype0 free leased random": errors.New("injected failure"),
+			"acquire owner rtype0 free leased": errors.New("injected failure"),
 		},
 		expectedReasons: []string{"utilizing_lease:acquiring_lease"},
-		expected:        []string{"acquireWaitWithPriority owner rtype0 free leased random"},
+		expected:        []string{"acquire owner rtype0 free leased"},
 	}, {
 		name: "second acquire fails",
 		failures: map[string]error{
-			"acquireWaitWithPriority owner rtype1 free leased random": errors.New("injected failure"),
+			"acquire owner rtype1 free leased": errors.New("injected failure"),
+		},
+		expectedReasons: []string{"utilizing_lease:acquiring_lease"},
+		expected: []string{
+			"acquire owner rtype0 free leased",
+			"acquire owner rtype1 free leased",
+			"releaseone owner rtype0_0 free",
+		},
+	}, {
+		name: "second resource is not available",
+		failures: map[string]error{
+			"acquire owner rtype1 free leased": lease.ErrNotFound,
 		},
 		expectedReasons: []string{"utilizing_lease:acquiring_lease"},
 		expected: []string{
-			"acquireWaitWithPriority owner rtype0 free leased random",
-			"acquireWaitWithPriority owner rtype1 free leased random",
+			"acquire owner rtype0 free leased",
+			"acquire owner rtype1 free leased",
 			"releaseone owner rtype0_0 free",
 		},
 	}, {
@@ -170,8 +195,8 @@ func TestError(t *testing.T) {
 		},
 		expectedReasons: []string{"utilizing_lease:releasing_lease"},
 		expected: []string{
-			"acquireWaitWithPriority owner rtype0 free leased random",
-			"acquireWaitWithPriority owner rtype1 free leased random",
+			"acquire owner rtype0 free leased",
+			"acquire owner rtype1 free leased",
 			"releaseone owner rtype0_0 free",
 			"releaseone owner rtype1_1 free",
 		},
@@ -182,8 +207,8 @@ func TestError(t *testing.T) {
 		},
 		expectedReasons: []string{"utilizing_lease:releasing_lease"},
 		expected: []string{
-			"acquireWaitWithPriority owner rtype0 free leased random",
-			"acquireWaitWithPriority owner rtype1 free leased random",
+			"acquire owner rtype0 free leased",
+			"acquire owner rtype1 free leased",
 			"releaseone owner rtype0_0 free",
 			"releaseone owner rtype1_1 free",
 		},
@@ -192,8 +217,8 @@ func TestError(t *testing.T) {
 		runFails:        true,
 		expectedReasons: []string{"utilizing_lease:executing_test"},
 		expected: []string{
-			"acquireWaitWithPriority owner rtype0 free leased random",
-			"acquireWaitWithPriority owner rtype1 free leased random",
+			"acquire owner rtype0 free leased",
+			"acquire owner rtype1 free leased",
 			"releaseone owner rtype0_0 free",
 			"releaseone owner rtype1_1 free",
 		},
@@ -208,8 +233,8 @@ func TestError(t *testing.T) {
 			"utilizing_lease:releasing_lease",
 		},
 		expected: []string{
-			"acquireWaitWithPriority owner rtype0 free leased random",
-			"acquireWaitWithPriority owner rtype1 free leased random",
+			"acquire owner rtype0 free leased",
+			"acquire owner rtype1 free leased",
 			"releaseone owner rtype0_0 free",
 			"releaseone owner rtype1_1 free",
 		},
@@ -246,9 +271,9 @@ func TestAcquireRelease(t *testing.T) {
 		t.Fatal("step was not executed")
 	}
 	expected := []string{
-		"acquireWaitWithPriority owner rtype0 free leased random",
-		"acquireWaitWithPriority owner rtype0 free leased random",
-		"acquireWaitWithPriority owner rtype1 free leased random",
+		"acquire owner rtype0 free leased",
+		"acquire owner rtype0 free leased",
+		"acquire owner rtype1 free leased",
 		"releaseone owner rtype1_2 free",
 		"releaseone owner rtype0_0 free",
 		"releaseone owner rtype0_1 free",
@@ -256,4 +281,72 @@ func TestAcquireRelease(t *testing.T) {
 	if !reflect.DeepEqual(calls, expected) {
 		t.Fatalf("wrong calls to the lease client: %s", diff.ObjectDiff(calls, expected))
 	}
+	subSteps := withLease.(SubStepReporter).SubSteps()
+	if len(subSteps) != 1 || subSteps[0].StepName != "needs_lease-lease" || subSteps[0].Failed == nil || *subSteps[0].Failed {
+		t.Fatalf("expected a successful lease acquisition sub-step, got %v", subSteps)
+	}
+}
+
+type stepReusesLease struct {
+	stepNeedsLease
+	reusable, leased map[string][]string
+}
+
+func (s *stepReusesLease) ReusableLeases(context.Context) map[string][]string {
+	return s.reusable
+}
+
+func (s *stepReusesLease) Leased(resources map[string][]string) {
+	s.leased = resources
+}
+
+func TestAcquireReusableLeases(t *testing.T) {
+	var calls []string
+	client := lease.NewFakeClient("owner", "url", 0, nil, &calls)
+	leases := []api.StepLease{{ResourceType: "rtype", Count: 1, Env: api.DefaultLeaseEnv}}
+	step := stepReusesLease{reusable: map[string][]string{api.DefaultLeaseEnv: {"rtype_earlier"}}}
+	withLease := LeaseStep(&client, leases, &step, func() string { return "" })
+	if err := withLease.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !step.ran {
+		t.Fatal("step was not executed")
+	}
+	expectedCalls := []string{
+		"acquirebystate owner free leased rtype_earlier",
+		"releaseone owner rtype_earlier free",
+	}
+	if diff := cmp.Diff(expectedCalls, calls); diff != "" {
+		t.Errorf("calls: actual does not match expected, diff: %s", diff)
+	}
+	expectedLeased := map[string][]string{api.DefaultLeaseEnv: {"rtype_earlier"}}
+	if diff := cmp.Diff(expectedLeased, step.leased); diff != "" {
+		t.Errorf("leased: actual does not match expected, diff: %s", diff)
+	}
+}
+
+func TestAcquireResumedLeases(t *testing.T) {
+	var calls []string
+	client := lease.NewFakeClient("owner", "url", 0, nil, &calls)
+	leases := []api.StepLease{{ResourceType: "rtype", Count: 1, Env: api.DefaultLeaseEnv}}
+	step := stepReusesLease{reusable: map[string][]string{api.DefaultLeaseEnv: {"rtype_cached"}}}
+	withLease := LeaseStep(&client, leases, &step, func() string { return "" })
+	var recorded map[string][]string
+	withLease.(LeaseResumer).ResumeLeases(map[string][]string{api.DefaultLeaseEnv: {"rtype_interrupted"}}, func(leased map[string][]string) {
+		recorded = leased
+	})
+	if err := withLease.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expectedCalls := []string{
+		"acquirebystate owner free leased rtype_interrupted",
+		"releaseone owner rtype_interrupted free",
+	}
+	if diff := cmp.Diff(expectedCalls, calls); diff != "" {
+		t.Errorf("calls: actual does not match expected, diff: %s", diff)
+	}
+	expectedLeased := map[string][]string{api.DefaultLeaseEnv: {"rtype_interrupted"}}
+	if diff := cmp.Diff(expectedLeased, recorded); diff != "" {
+		t.Errorf("recorded: actual does not match expected, diff: %s", diff)
+	}
 }
@@ -0,0 +1,284 @@
+package multi_stage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+const (
+	// preCacheTTL is how long the results of a `pre` phase can be reused.
+	preCacheTTL = 6 * time.Hour
+	// preCacheTestLabel marks a cache entry with the test that created it.
+	preCacheTestLabel = "ci.openshift.io/pre-cache-test"
+	// preCacheExpiresAnnotation holds the RFC3339 expiration time of an entry.
+	preCacheExpiresAnnotation = "ci.openshift.io/pre-cache-expires"
+	// preCacheLeasesAnnotation holds the resources leased when the entry was
+	// created, as a JSON object keyed by environment variable.
+	preCacheLeasesAnnotation = "ci.openshift.io/pre-cache-leases"
+)
+
+// preCacheKey identifies the result of the `pre` phase of a test.  It covers
+// the input hash of the build, the literal `pre` steps, the test parameters
+// and the resolved environment.  The leased resources are left out, as they
+// differ between executions: an entry records them instead and is only
+// restored when the same resources were leased again.
+func (s *multiStageTestStep) preCacheKey(env []coreapi.EnvVar) (string, error) {
+	raw, err := yaml.Marshal(struct {
+		InputHash   string                `json:"input_hash"`
+		Profile     api.ClusterProfile    `json:"profile"`
+		Pre         []api.LiteralTestStep `json:"pre"`
+		Parameters  api.TestEnvironment   `json:"parameters"`
+		Environment []coreapi.EnvVar      `json:"environment"`
+		Leases      []api.StepLease       `json:"leases"`
+	}{
+		InputHash:   s.jobSpec.InputHash,
+		Profile:     s.profile,
+		Pre:         s.pre,
+		Parameters:  s.env,
+		Environment: s.withoutLeases(env),
+		Leases:      s.leases,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal pre cache inputs: %w", err)
+	}
+	return fmt.Sprintf("pre-%x", sha256.Sum256(raw))[:36], nil
+}
+
+// withoutLeases removes the variables holding leased resources from env.
+func (s *multiStageTestStep) withoutLeases(env []coreapi.EnvVar) []coreapi.EnvVar {
+	leased := sets.New[string](api.DefaultIPPoolLeaseEnv)
+	for _, l := range s.leases {
+		leased.Insert(l.Env)
+	}
+	var ret []coreapi.EnvVar
+	for _, e := range env {
+		if !leased.Has(e.Name) {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+// ReusableLeases returns the resources leased when the cache entry for the
+// `pre` phase was created, so that they can be leased again.
+func (s *multiStageTestStep) ReusableLeases(ctx context.Context) map[string][]string {
+	if s.flags&cachePre == 0 {
+		return nil
+	}
+	env, err := s.environment()
+	if err != nil {
+		logrus.WithError(err).Debug("Could not determine the environment of the pre cache entry.")
+		return nil
+	}
+	key, err := s.preCacheKey(env)
+	if err != nil {
+		logrus.WithError(err).Debug("Could not determine the pre cache key.")
+		return nil
+	}
+	entry, err := s.preCacheEntry(ctx, key, time.Now())
+	if err != nil || entry == nil {
+		return nil
+	}
+	leases, err := entryLeases(entry)
+	if err != nil {
+		logrus.WithError(err).Debugf("Could not parse the leases of pre cache entry %s.", key)
+		return nil
+	}
+	return leases
+}
+
+// Leased records the resources leased for the test.
+func (s *multiStageTestStep) Leased(resources map[string][]string) {
+	s.leased = resources
+}
+
+// preCacheEntry returns the cache entry with the key unless it does not exist
+// or expired.
+func (s *multiStageTestStep) preCacheEntry(ctx context.Context, key string, now time.Time) (*coreapi.Secret, error) {
+	var entry coreapi.Secret
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: api.PreCacheNamespace, Name: key}, &entry); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not get pre cache entry %s: %w", key, err)
+	}
+	if preCacheExpired(&entry, now) {
+		logrus.Debugf("Pre cache entry %s expired, ignoring", key)
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func preCacheExpired(entry *coreapi.Secret, now time.Time) bool {
+	expires, err := time.Parse(time.RFC3339, entry.Annotations[preCacheExpiresAnnotation])
+	return err != nil || now.After(expires)
+}
+
+func entryLeases(entry *coreapi.Secret) (map[string][]string, error) {
+	leases := map[string][]string{}
+	if raw := entry.Annotations[preCacheLeasesAnnotation]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &leases); err != nil {
+			return nil, err
+		}
+	}
+	return leases, nil
+}
+
+// sameLeases determines whether two sets of leased resources are identical,
+// regardless of the order in which resources were leased.
+func sameLeases(a, b map[string][]string) bool {
+	normalize := func(leases map[string][]string) map[string][]string {
+		ret := map[string][]string{}
+		for env, names := range leases {
+			if len(names) != 0 {
+				ret[env] = sets.List(sets.New[string](names...))
+			}
+		}
+		return ret
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// restorePreCache copies the shared directory content of a valid cache entry
+// into the shared directory of this test.  The entry is only valid with the
+// resources it was created with.  It returns whether an entry was found and
+// restored.
+func (s *multiStageTestStep) restorePreCache(ctx context.Context, key string, now time.Time) (bool, error) {
+	entry, err := s.preCacheEntry(ctx, key, now)
+	if err != nil || entry == nil {
+		return false, err
+	}
+	leases, err := entryLeases(entry)
+	if err != nil {
+		return false, fmt.Errorf("could not parse the leases of pre cache entry %s: %w", key, err)
+	}
+	if !sameLeases(leases, s.leased) {
+		logrus.Infof("Pre cache entry %s was created with other leased resources, ignoring", key)
+		return false, nil
+	}
+	var shared coreapi.Secret
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: s.name}, &shared); err != nil {
+		return false, fmt.Errorf("could not get shared directory %q: %w", s.name, err)
+	}
+	shared.Data = entry.Data
+	if err := s.client.Update(ctx, &shared); err != nil {
+		return false, fmt.Errorf("could not restore shared directory %q: %w", s.name, err)
+	}
+	return true, nil
+}
+
+// storePreCache saves the shared directory content after a successful `pre`
+// phase so that later jobs can restore it, along with the leased resources.
+func (s *multiStageTestStep) storePreCache(ctx context.Context, key string, now time.Time) error {
+	var shared coreapi.Secret
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: s.name}, &shared); err != nil {
+		return fmt.Errorf("could not get shared directory %q: %w", s.name, err)
+	}
+	leases := s.leased
+	if leases == nil {
+		leases = map[string][]string{}
+	}
+	rawLeases, err := json.Marshal(leases)
+	if err != nil {
+		return fmt.Errorf("could not marshal leased resources: %w", err)
+	}
+	entry := &coreapi.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: api.PreCacheNamespace,
+			Name:      key,
+			Labels: map[string]string{
+				preCacheTestLabel:      s.name,
+				api.SkipCensoringLabel: "true",
+			},
+			Annotations: map[string]string{
+				preCacheExpiresAnnotation: now.Add(preCacheTTL).Format(time.RFC3339),
+				preCacheLeasesAnnotation:  string(rawLeases),
+			},
+		},
+		Data: shared.Data,
+	}
+	if err := s.client.Delete(ctx, entry); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("could not delete previous pre cache entry %s: %w", key, err)
+	}
+	return s.client.Create(ctx, entry)
+}
+
+// collectExpiredPreCache deletes the cache entries which expired.  Every test
+// which stores an entry collects the garbage of all tests.
+func (s *multiStageTestStep) collectExpiredPreCache(ctx context.Context, now time.Time) error {
+	var entries coreapi.SecretList
+	if err := s.client.List(ctx, &entries, ctrlruntimeclient.InNamespace(api.PreCacheNamespace), ctrlruntimeclient.HasLabels{preCacheTestLabel}); err != nil {
+		return fmt.Errorf("could not list pre cache entries: %w", err)
+	}
+	var errs []error
+	for i := range entries.Items {
+		if !preCacheExpired(&entries.Items[i], now) {
+			continue
+		}
+		logrus.Debugf("Deleting expired pre cache entry %s", entries.Items[i].Name)
+		if err := s.client.Delete(ctx, &entries.Items[i]); err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("could not delete pre cache entry %s: %w", entries.Items[i].Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// runPreWithCache runs the `pre` phase, restoring a cached result instead when
+// one exists and caching the result when it succeeds.  Failures to access the
+// cache are not fatal: the phase simply runs as usual.
+func (s *multiStageTestStep) runPreWithCache(
+	ctx context.Context,
+	env []coreapi.EnvVar,
+	secretVolumes []coreapi.Volume,
+	secretVolumeMounts []coreapi.VolumeMount,
+) error {
+	if s.flags&cachePre == 0 {
+		return s.runSteps(ctx, "pre", s.pre, env, secretVolumes, secretVolumeMounts)
+	}
+	key, err := s.preCacheKey(env)
+	if err != nil {
+		logrus.WithError(err).Warn("Could not determine pre cache key, running pre steps.")
+		return s.runSteps(ctx, "pre", s.pre, env, secretVolumes, secretVolumeMounts)
+	}
+	if restored, err := s.restorePreCache(ctx, key, time.Now()); err != nil {
+		logrus.WithError(err).Warn("Could not restore pre cache entry, running pre steps.")
+	} else if restored {
+		logrus.Infof("Restored the result of multi-stage phase pre from cache entry %s, skipping pre steps", key)
+		s.subTests = append(s.subTests, s.preCacheTestCase(key))
+		return nil
+	}
+	if err := s.runSteps(ctx, "pre", s.pre, env, secretVolumes, secretVolumeMounts); err != nil {
+		return err
+	}
+	if err := s.storePreCache(ctx, key, time.Now()); err != nil {
+		logrus.WithError(err).Warn("Could not store pre cache entry.")
+	}
+	if err := s.collectExpiredPreCache(ctx, time.Now()); err != nil {
+		logrus.WithError(err).Warn("Could not delete expired pre cache entries.")
+	}
+	return nil
+}
+
+func (s *multiStageTestStep) preCacheTestCase(key string) *junit.TestCase {
+	return &junit.TestCase{
+		Name:        "Run multi-stage test pre phase",
+		SkipMessage: &junit.SkipMessage{Message: fmt.Sprintf("The result of multi-stage phase pre was restored from cache entry %s.", key)},
+	}
+}
@@ -0,0 +1,218 @@
+package multi_stage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+func TestPreCacheKey(t *testing.T) {
+	jobSpec := api.JobSpec{JobSpec: prowdapi.JobSpec{Job: "job"}, InputHash: "hash"}
+	newStep := func(pre ...api.LiteralTestStep) *multiStageTestStep {
+		return newMultiStageTestStep(api.TestStepConfiguration{
+			As:                                 "test",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{Pre: pre},
+		}, &api.ReleaseBuildConfiguration{}, nil, nil, &jobSpec, []api.StepLease{{ResourceType: "aws-quota-slice", Env: api.DefaultLeaseEnv}}, "", "", nil, false)
+	}
+	env := []coreapi.EnvVar{{Name: api.DefaultLeaseEnv, Value: "us-east-1"}}
+	key, err := newStep(api.LiteralTestStep{As: "install"}).preCacheKey(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same, _ := newStep(api.LiteralTestStep{As: "install"}).preCacheKey(env); same != key {
+		t.Errorf("expected identical inputs to produce the same key, got %s and %s", key, same)
+	}
+	if other, _ := newStep(api.LiteralTestStep{As: "install", Commands: "other"}).preCacheKey(env); other == key {
+		t.Errorf("expected different pre steps to produce a different key")
+	}
+	otherLease := []coreapi.EnvVar{{Name: api.DefaultLeaseEnv, Value: "us-west-1"}}
+	if other, _ := newStep(api.LiteralTestStep{As: "install"}).preCacheKey(otherLease); other != key {
+		t.Errorf("expected a different leased resource to produce the same key, got %s and %s", key, other)
+	}
+}
+
+func TestPreCacheStoreRestore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newStep := func(namespace string, data map[string][]byte) (*multiStageTestStep, ctrlruntimeclient.Client) {
+		jobSpec := api.JobSpec{JobSpec: prowdapi.JobSpec{Job: "job"}}
+		jobSpec.SetNamespace(namespace)
+		crclient := fakectrlruntimeclient.NewClientBuilder().WithObjects(&coreapi.Secret{
+			ObjectMeta: meta.ObjectMeta{Namespace: namespace, Name: "test"},
+			Data:       data,
+		}).Build()
+		client := &testhelper_kube.FakePodClient{
+			FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(crclient)},
+		}
+		step := newMultiStageTestStep(api.TestStepConfiguration{
+			As:                                 "test",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{},
+		}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, []api.StepLease{{ResourceType: "aws-quota-slice", Env: api.DefaultLeaseEnv}}, "", "", nil, false)
+		return step, crclient
+	}
+	data := map[string][]byte{"kubeconfig": []byte("config")}
+	leased := map[string][]string{api.DefaultLeaseEnv: {"us-east-1--aws-quota-slice-01"}}
+	first, crclient := newStep("first", data)
+	first.Leased(leased)
+	if err := first.storePreCache(context.Background(), "pre-key", now); err != nil {
+		t.Fatalf("failed to store cache entry: %v", err)
+	}
+	var entry coreapi.Secret
+	if err := crclient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: api.PreCacheNamespace, Name: "pre-key"}, &entry); err != nil {
+		t.Fatalf("failed to get cache entry: %v", err)
+	}
+	if diff := cmp.Diff(`{"LEASED_RESOURCE":["us-east-1--aws-quota-slice-01"]}`, entry.Annotations[preCacheLeasesAnnotation]); diff != "" {
+		t.Errorf("unexpected leases annotation: %s", diff)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		key      string
+		now      time.Time
+		leased   map[string][]string
+		expected bool
+	}{
+		{name: "valid entry is restored", key: "pre-key", now: now.Add(time.Hour), leased: leased, expected: true},
+		{name: "entry created with other leases is ignored", key: "pre-key", now: now.Add(time.Hour), leased: map[string][]string{api.DefaultLeaseEnv: {"us-east-1--aws-quota-slice-02"}}},
+		{name: "expired entry is ignored", key: "pre-key", now: now.Add(preCacheTTL + time.Minute), leased: leased},
+		{name: "missing entry is ignored", key: "pre-other", now: now, leased: leased},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			second, secondClient := newStep("second", nil)
+			second.Leased(tc.leased)
+			toCreate := entry.DeepCopy()
+			toCreate.ResourceVersion = ""
+			if err := secondClient.Create(context.Background(), toCreate); err != nil {
+				t.Fatal(err)
+			}
+			restored, err := second.restorePreCache(context.Background(), tc.key, tc.now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if restored != tc.expected {
+				t.Errorf("expected restored to be %t, got %t", tc.expected, restored)
+			}
+			var shared coreapi.Secret
+			if err := secondClient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "second", Name: "test"}, &shared); err != nil {
+				t.Fatal(err)
+			}
+			var expectedData map[string][]byte
+			if tc.expected {
+				expectedData = data
+			}
+			if diff := cmp.Diff(expectedData, shared.Data); diff != "" {
+				t.Errorf("unexpected shared directory content: %s", diff)
+			}
+		})
+	}
+}
+
+func TestReusableLeases(t *testing.T) {
+	now := time.Now()
+	jobSpec := api.JobSpec{JobSpec: prowdapi.JobSpec{Job: "job"}, InputHash: "hash"}
+	jobSpec.SetNamespace("ns")
+	yes := true
+	newStep := func(objects ...ctrlruntimeclient.Object) *multiStageTestStep {
+		crclient := fakectrlruntimeclient.NewClientBuilder().WithObjects(objects...).Build()
+		client := &testhelper_kube.FakePodClient{
+			FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(crclient)},
+		}
+		return newMultiStageTestStep(api.TestStepConfiguration{
+			As: "test",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Pre:      []api.LiteralTestStep{{As: "install"}},
+				CachePre: &yes,
+			},
+		}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "", "", nil, false)
+	}
+	key, err := newStep().preCacheKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := func(expires time.Time) *coreapi.Secret {
+		return &coreapi.Secret{ObjectMeta: meta.ObjectMeta{
+			Namespace: api.PreCacheNamespace,
+			Name:      key,
+			Labels:    map[string]string{preCacheTestLabel: "test"},
+			Annotations: map[string]string{
+				preCacheExpiresAnnotation: expires.Format(time.RFC3339),
+				preCacheLeasesAnnotation:  `{"LEASED_RESOURCE":["us-east-1--aws-quota-slice-01"]}`,
+			},
+		}}
+	}
+	for _, tc := range []struct {
+		name     string
+		objects  []ctrlruntimeclient.Object
+		expected map[string][]string
+	}{
+		{
+			name:     "leases of a valid entry are reused",
+			objects:  []ctrlruntimeclient.Object{entry(now.Add(time.Hour))},
+			expected: map[string][]string{api.DefaultLeaseEnv: {"us-east-1--aws-quota-slice-01"}},
+		},
+		{
+			name:    "leases of an expired entry are not reused",
+			objects: []ctrlruntimeclient.Object{entry(now.Add(-time.Hour))},
+		},
+		{
+			name: "nothing to reuse without an entry",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, newStep(tc.objects...).ReusableLeases(context.Background())); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestCollectExpiredPreCache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(name, expires string) *coreapi.Secret {
+		return &coreapi.Secret{ObjectMeta: meta.ObjectMeta{
+			Namespace:   api.PreCacheNamespace,
+			Name:        name,
+			Labels:      map[string]string{preCacheTestLabel: "test"},
+			Annotations: map[string]string{preCacheExpiresAnnotation: expires},
+		}}
+	}
+	crclient := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		entry("pre-valid", now.Add(time.Hour).Format(time.RFC3339)),
+		entry("pre-expired", now.Add(-time.Hour).Format(time.RFC3339)),
+		entry("pre-invalid", "tomorrow"),
+		&coreapi.Secret{ObjectMeta: meta.ObjectMeta{Namespace: api.PreCacheNamespace, Name: "unrelated"}},
+	).Build()
+	client := &testhelper_kube.FakePodClient{
+		FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(crclient)},
+	}
+	jobSpec := api.JobSpec{JobSpec: prowdapi.JobSpec{Job: "job"}}
+	step := newMultiStageTestStep(api.TestStepConfiguration{
+		As:                                 "test",
+		MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{},
+	}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "", "", nil, false)
+	if err := step.collectExpiredPreCache(context.Background(), now); err != nil {
+		t.Fatalf("failed to collect expired entries: %v", err)
+	}
+	var secrets coreapi.SecretList
+	if err := crclient.List(context.Background(), &secrets, ctrlruntimeclient.InNamespace(api.PreCacheNamespace)); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, secret := range secrets.Items {
+		names = append(names, secret.Name)
+	}
+	if diff := cmp.Diff([]string{"pre-valid", "unrelated"}, names); diff != "" {
+		t.Errorf("unexpected remaining secrets: %s", diff)
+	}
+}
@@ -195,6 +195,7 @@ func (s *multiStageTestStep) generatePods(
 		}...)
 		container.Env = append(container.Env, env...)
 		container.Env = append(container.Env, s.generateParams(step.Environment)...)
+		container.Env = append(container.Env, s.shardEnv(step.Environment)...)
 		depEnv, depErrs := s.envForDependencies(step)
 		if len(depErrs) != 0 {
 			errs = append(errs, depErrs...)
@@ -392,6 +393,23 @@ func (s *multiStageTestStep) generateParams(env []api.StepParameter) []coreapi.E
 	return ret
 }
 
+// shardEnv returns the shard of the test if it is the sharded target of the
+// job, leaving out the variables the step declares as parameters.
+func (s *multiStageTestStep) shardEnv(params []api.StepParameter) []coreapi.EnvVar {
+	declared := sets.New[string]()
+	for _, param := range params {
+		declared.Insert(param.Name)
+	}
+	shard := s.jobSpec.ShardEnv(s.name)
+	var ret []coreapi.EnvVar
+	for _, name := range sets.List(sets.KeySet(shard)) {
+		if !declared.Has(name) {
+			ret = append(ret, coreapi.EnvVar{Name: name, Value: shard[name]})
+		}
+	}
+	return ret
+}
+
 func (s *multiStageTestStep) envForDependencies(step api.LiteralTestStep) ([]coreapi.EnvVar, []error) {
 	var env []coreapi.EnvVar
 	var errs []error
@@ -407,12 +425,17 @@ func (s *multiStageTestStep) envForDependencies(step api.LiteralTestStep) ([]cor
 			ref = dependency.PullSpec
 		} else {
 			imageStream, name, _ := s.config.DependencyParts(dependency, claimRelease)
-			depRef, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, imageStream, name)()
-			if err != nil {
-				errs = append(errs, fmt.Errorf("could not determine image pull spec for image %s on step %s", dependency.Name, step.As))
-				continue
+			if s.flags&dryRun != 0 {
+				// the digest is only known once the image exists
+				ref = fmt.Sprintf("%s:%s", imageStream, name)
+			} else {
+				depRef, err := utils.ImageDigestFor(s.client, s.jobSpec.Namespace, imageStream, name)()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("could not determine image pull spec for image %s on step %s", dependency.Name, step.As))
+					continue
+				}
+				ref = depRef
 			}
-			ref = depRef
 		}
 		env = append(env, coreapi.EnvVar{
 			Name: dependency.Env, Value: ref,
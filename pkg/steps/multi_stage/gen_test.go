@@ -3,6 +3,7 @@ package multi_stage
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -254,6 +255,76 @@ func TestGeneratePodsEnvironment(t *testing.T) {
 	}
 }
 
+func TestGeneratePodsShardEnvironment(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		target   string
+		test     api.LiteralTestStep
+		expected []coreapi.EnvVar
+	}{{
+		name:   "shard is passed to the sharded target",
+		target: "e2e",
+		expected: []coreapi.EnvVar{
+			{Name: "SHARD_COUNT", Value: "3"},
+			{Name: "SHARD_INDEX", Value: "2"},
+		},
+	}, {
+		name:   "shard is not passed to other tests",
+		target: "unit",
+	}, {
+		name:   "parameters of the step are not overwritten",
+		target: "e2e",
+		test: api.LiteralTestStep{
+			Environment: []api.StepParameter{{Name: "SHARD_INDEX"}},
+		},
+		expected: []coreapi.EnvVar{
+			{Name: "SHARD_INDEX", Value: ""},
+			{Name: "SHARD_COUNT", Value: "3"},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			jobSpec := api.JobSpec{
+				Target:     tc.target,
+				ShardIndex: 2,
+				ShardCount: 3,
+				JobSpec: prowdapi.JobSpec{
+					Job:  "job",
+					Type: prowapi.PeriodicJob,
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Second},
+						UtilityImages: &prowapi.UtilityImages{
+							Sidecar:    "sidecar",
+							Entrypoint: "entrypoint",
+						},
+					},
+				},
+			}
+			jobSpec.SetNamespace("ns")
+			test := []api.LiteralTestStep{tc.test}
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "e2e",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: test,
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, nil, &jobSpec, nil, "node-name", "", nil, false)
+			pods, _, err := step.(*multiStageTestStep).generatePods(test, nil, nil, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var env []coreapi.EnvVar
+			for _, v := range pods[0].Spec.Containers[0].Env {
+				if strings.HasPrefix(v.Name, "SHARD_") {
+					env = append(env, v)
+				}
+			}
+			if diff := cmp.Diff(tc.expected, env); diff != "" {
+				t.Errorf("incorrect shard environment, diff: %s", diff)
+			}
+		})
+	}
+}
+
 func TestGeneratePodBestEffort(t *testing.T) {
 	yes := true
 	no := false
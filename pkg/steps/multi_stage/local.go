@@ -0,0 +1,370 @@
+package multi_stage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	"sigs.k8s.io/prow/pkg/entrypoint"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
+)
+
+const (
+	// LocalArtifactMountPath is where the artifact directory of a step is
+	// mounted when running locally.
+	LocalArtifactMountPath = "/logs/artifacts"
+	localSharedDir         = "shared"
+	localArtifactsDir      = "artifacts"
+	localHomeDir           = "home"
+)
+
+// LocalContainer describes a single step container as it is handed to a
+// local container runtime.
+type LocalContainer struct {
+	Name    string
+	Image   string
+	Command []string
+	Env     []coreapi.EnvVar
+	Mounts  []LocalMount
+}
+
+// LocalMount is a host directory bind-mounted into a LocalContainer.
+type LocalMount struct {
+	HostPath      string
+	ContainerPath string
+	ReadOnly      bool
+}
+
+// LocalRuntime executes step containers outside of a cluster.
+type LocalRuntime interface {
+	Run(ctx context.Context, container LocalContainer) error
+}
+
+// LocalOptions configures how a multi-stage test is materialized locally.
+type LocalOptions struct {
+	// WorkDir holds the SHARED_DIR, home and artifact directories of the test.
+	WorkDir string
+	// Images maps the `from` of a step or the name of a dependency (`tag` or
+	// `stream:tag`, or `namespace/name:tag` for `from_image`) to a pull spec.
+	Images map[string]string
+	// CredentialsDir contains one directory per credential reference, named
+	// `<namespace>-<name>`, holding the files of the secret.
+	CredentialsDir string
+	// ClusterProfileDir holds the files of the cluster profile, if any.
+	ClusterProfileDir string
+}
+
+// commandRuntime runs containers using a docker-compatible CLI, such as
+// `podman` or `docker`.
+type commandRuntime struct {
+	binary         string
+	stdout, stderr io.Writer
+}
+
+// NewCommandRuntime creates a LocalRuntime which shells out to a
+// docker-compatible CLI.
+func NewCommandRuntime(binary string, stdout, stderr io.Writer) LocalRuntime {
+	return &commandRuntime{binary: binary, stdout: stdout, stderr: stderr}
+}
+
+func (r *commandRuntime) Run(ctx context.Context, container LocalContainer) error {
+	cmd := exec.CommandContext(ctx, r.binary, runArgs(container)...)
+	cmd.Stdout = r.stdout
+	cmd.Stderr = r.stderr
+	return cmd.Run()
+}
+
+// runArgs builds the arguments of a `run` invocation for the container.
+func runArgs(container LocalContainer) []string {
+	args := []string{"run", "--rm", "--name", container.Name}
+	for _, env := range container.Env {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", env.Name, env.Value))
+	}
+	for _, mount := range container.Mounts {
+		volume := fmt.Sprintf("%s:%s", mount.HostPath, mount.ContainerPath)
+		if mount.ReadOnly {
+			volume += ":ro"
+		}
+		args = append(args, "--volume", volume)
+	}
+	if len(container.Command) != 0 {
+		args = append(args, "--entrypoint", container.Command[0], container.Image)
+		args = append(args, container.Command[1:]...)
+	} else {
+		args = append(args, container.Image)
+	}
+	return args
+}
+
+// localMultiStageTestStep executes the steps of a multi-stage test using a
+// LocalRuntime instead of creating pods in a test namespace.  Images,
+// credentials and leases which would normally be provided by the build farm
+// have to be supplied explicitly.  Phases are executed by the runner shared
+// with the in-cluster test.
+type localMultiStageTestStep struct {
+	*multiStageTestStep
+	opts    LocalOptions
+	runtime LocalRuntime
+}
+
+// LocalMultiStageTestStep creates a step which runs a resolved multi-stage
+// test against a local container runtime.
+func LocalMultiStageTestStep(
+	testConfig api.TestStepConfiguration,
+	config *api.ReleaseBuildConfiguration,
+	jobSpec *api.JobSpec,
+	runtime LocalRuntime,
+	opts LocalOptions,
+) api.Step {
+	return newLocalMultiStageTestStep(testConfig, config, jobSpec, runtime, opts)
+}
+
+func newLocalMultiStageTestStep(
+	testConfig api.TestStepConfiguration,
+	config *api.ReleaseBuildConfiguration,
+	jobSpec *api.JobSpec,
+	runtime LocalRuntime,
+	opts LocalOptions,
+) *localMultiStageTestStep {
+	return &localMultiStageTestStep{
+		multiStageTestStep: newMultiStageTestStep(testConfig, config, nil, nil, jobSpec, api.LeasesForTest(testConfig.MultiStageTestConfigurationLiteral), "", "", nil, false),
+		opts:               opts,
+		runtime:            runtime,
+	}
+}
+
+func (s *localMultiStageTestStep) Description() string {
+	return fmt.Sprintf("Run multi-stage test %s locally", s.name)
+}
+
+// Requires is empty: all images have to be provided in the options.
+func (s *localMultiStageTestStep) Requires() []api.StepLink { return nil }
+
+func (s *localMultiStageTestStep) Objects() []ctrlruntimeclient.Object { return nil }
+
+func (s *localMultiStageTestStep) Run(ctx context.Context) error {
+	return results.ForReason("executing_multi_stage_test").ForError(s.run(ctx))
+}
+
+func (s *localMultiStageTestStep) run(ctx context.Context) error {
+	logrus.Infof("Running multi-stage test %s locally in %s", s.name, s.opts.WorkDir)
+	for _, dir := range []string{localSharedDir, localArtifactsDir, localHomeDir} {
+		if err := os.MkdirAll(filepath.Join(s.opts.WorkDir, dir), 0777); err != nil {
+			return fmt.Errorf("failed to create local %s directory: %w", dir, err)
+		}
+	}
+	var errs []error
+	s.flags |= shortCircuit
+	if err := s.runLocalSteps(ctx, "pre", s.pre); err != nil {
+		errs = append(errs, fmt.Errorf("%q pre steps failed: %w", s.name, err))
+	} else if err := s.runLocalSteps(ctx, "test", s.test); err != nil {
+		errs = append(errs, fmt.Errorf("%q test steps failed: %w", s.name, err))
+	}
+	s.flags &= ^shortCircuit
+	if err := s.runLocalSteps(context.Background(), "post", s.post); err != nil {
+		errs = append(errs, fmt.Errorf("%q post steps failed: %w", s.name, err))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// runLocalSteps executes a phase with the same runner as the in-cluster
+// test, so conditions, retries, best-effort steps and parallel groups behave
+// the same way.
+func (s *localMultiStageTestStep) runLocalSteps(ctx context.Context, phase string, steps []api.LiteralTestStep) error {
+	start := time.Now()
+	logrus.Infof("Running multi-stage phase %s", phase)
+	executions, err := s.containerExecutions(steps)
+	if err != nil {
+		s.flags |= hasPrevErrs
+		return err
+	}
+	err = s.runExecutions(ctx, executions)
+	if err != nil {
+		s.flags |= hasPrevErrs
+	}
+	s.recordPhase(phase, time.Since(start), err)
+	return err
+}
+
+// containerExecutions is the local counterpart of generatePods.
+func (s *localMultiStageTestStep) containerExecutions(steps []api.LiteralTestStep) ([]stepExecution, error) {
+	var executions []stepExecution
+	var errs []error
+	for _, step := range steps {
+		name := fmt.Sprintf("%s-%s", s.name, step.As)
+		if o := step.OptionalOnSuccess; o != nil && *o && s.flags&allowSkipOnSuccess != 0 && s.flags&hasPrevErrs == 0 {
+			logrus.Infof("Skipping optional step %s", name)
+			continue
+		}
+		container, err := s.generateContainer(name, step)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		executions = append(executions, stepExecution{
+			name:       name,
+			step:       step,
+			env:        container.Env,
+			bestEffort: s.flags&allowBestEffortPostSteps != 0 && step.BestEffort != nil && *step.BestEffort,
+			attempt: func(ctx context.Context, attempt int, record testCaseRecorder) (*int32, error) {
+				return s.runContainer(ctx, container, step.Timeout, attempt, record)
+			},
+		})
+	}
+	return executions, utilerrors.NewAggregate(errs)
+}
+
+// runContainer executes a container once, records it as a sub-step and passes
+// its test case to record.  Attempts after the first are recorded under a
+// distinct name.
+// The exit code is returned if the runtime reports it.
+func (s *localMultiStageTestStep) runContainer(ctx context.Context, container LocalContainer, timeout *prowapi.Duration, attempt int, record testCaseRecorder) (*int32, error) {
+	stepTimeout := entrypoint.DefaultTimeout
+	if timeout != nil {
+		stepTimeout = timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, stepTimeout)
+	defer cancel()
+	name := container.Name
+	if attempt > 1 {
+		name = fmt.Sprintf("%s-attempt-%d", container.Name, attempt)
+	}
+	start := time.Now()
+	logrus.Infof("Running step %s.", name)
+	err := s.runtime.Run(ctx, container)
+	finished := time.Now()
+	duration := finished.Sub(start)
+	verb := "succeeded"
+	var exitCode *int32
+	if err != nil {
+		verb = "failed"
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+			exitCode = utilpointer.Int32(int32(exitErr.ExitCode()))
+		}
+		err = fmt.Errorf("%q step %q failed: %w", s.name, name, err)
+	}
+	logrus.Infof("Step %s %s after %s.", name, verb, duration.Truncate(time.Second))
+	testCase := &junit.TestCase{
+		// named like the test cases of the in-cluster test, so results can be compared
+		Name:     fmt.Sprintf("%s - %s container test", s.multiStageTestStep.Description(), name),
+		Duration: duration.Seconds(),
+	}
+	if err != nil {
+		testCase.FailureOutput = &junit.FailureOutput{Output: err.Error()}
+	}
+	s.subLock.Lock()
+	s.subSteps = append(s.subSteps, api.CIOperatorStepDetailInfo{
+		StepName:    name,
+		Description: fmt.Sprintf("Run container %s", name),
+		StartedAt:   &start,
+		FinishedAt:  &finished,
+		Duration:    &duration,
+		Failed:      utilpointer.Bool(err != nil),
+	})
+	s.subLock.Unlock()
+	record(testCase)
+	return exitCode, err
+}
+
+// generateContainer is the local counterpart of generatePods: it resolves the
+// image, environment and mounts a step would receive in a test pod.
+func (s *localMultiStageTestStep) generateContainer(name string, step api.LiteralTestStep) (LocalContainer, error) {
+	var errs []error
+	imageRef := step.From
+	if step.FromImage != nil {
+		imageRef = fmt.Sprintf("%s/%s:%s", step.FromImage.Namespace, step.FromImage.Name, step.FromImage.Tag)
+	}
+	image, ok := s.opts.Images[imageRef]
+	if !ok {
+		errs = append(errs, fmt.Errorf("no local image provided for %q of step %s", imageRef, step.As))
+	}
+	artifactDir := filepath.Join(s.opts.WorkDir, localArtifactsDir, step.As)
+	if err := os.MkdirAll(artifactDir, 0777); err != nil {
+		errs = append(errs, fmt.Errorf("failed to create artifact directory for step %s: %w", step.As, err))
+	}
+	env := []coreapi.EnvVar{
+		{Name: "NAMESPACE", Value: s.jobSpec.Namespace()},
+		{Name: "JOB_NAME_SAFE", Value: strings.Replace(s.name, "_", "-", -1)},
+		{Name: "JOB_NAME_HASH", Value: s.jobSpec.JobNameHash()},
+		{Name: "UNIQUE_HASH", Value: s.jobSpec.UniqueHash()},
+		{Name: "ARTIFACT_DIR", Value: LocalArtifactMountPath},
+		{Name: "HOME", Value: "/alabama"},
+		{Name: SecretMountEnv, Value: SecretMountPath},
+	}
+	for _, l := range s.leases {
+		val, ok := s.env[l.Env]
+		if !ok {
+			errs = append(errs, fmt.Errorf("lease %s is not available locally, set %s in the test environment", l.ResourceType, l.Env))
+			continue
+		}
+		env = append(env, coreapi.EnvVar{Name: l.Env, Value: val})
+	}
+	env = append(env, s.generateParams(step.Environment)...)
+	for _, dependency := range step.Dependencies {
+		ref := dependency.PullSpec
+		if ref == "" {
+			if ref, ok = s.opts.Images[dependency.Name]; !ok {
+				errs = append(errs, fmt.Errorf("no local image provided for dependency %s of step %s", dependency.Name, step.As))
+				continue
+			}
+		}
+		env = append(env, coreapi.EnvVar{Name: dependency.Env, Value: ref})
+	}
+	mounts := []LocalMount{
+		{HostPath: filepath.Join(s.opts.WorkDir, localSharedDir), ContainerPath: SecretMountPath},
+		{HostPath: filepath.Join(s.opts.WorkDir, localHomeDir), ContainerPath: "/alabama"},
+		{HostPath: artifactDir, ContainerPath: LocalArtifactMountPath},
+	}
+	if isKubeconfigNeeded(&step, defaultGeneratePodOptions()) {
+		env = append(env, []coreapi.EnvVar{
+			{Name: "KUBECONFIG", Value: filepath.Join(SecretMountPath, "kubeconfig")},
+			{Name: "KUBECONFIGMINIMAL", Value: filepath.Join(SecretMountPath, "kubeconfig-minimal")},
+			{Name: "KUBEADMIN_PASSWORD_FILE", Value: filepath.Join(SecretMountPath, "kubeadmin-password")},
+		}...)
+	}
+	if s.profile != "" {
+		if s.opts.ClusterProfileDir == "" {
+			errs = append(errs, fmt.Errorf("test uses cluster profile %s but no local cluster profile directory was provided", s.profile))
+		}
+		mounts = append(mounts, LocalMount{HostPath: s.opts.ClusterProfileDir, ContainerPath: ClusterProfileMountPath, ReadOnly: true})
+		env = append(env, []coreapi.EnvVar{
+			{Name: "CLUSTER_PROFILE_NAME", Value: s.profile.Name()},
+			{Name: "CLUSTER_TYPE", Value: s.profile.ClusterType()},
+			{Name: ClusterProfileMountEnv, Value: ClusterProfileMountPath},
+		}...)
+	}
+	for _, credential := range step.Credentials {
+		mounts = append(mounts, LocalMount{
+			HostPath:      filepath.Join(s.opts.CredentialsDir, fmt.Sprintf("%s-%s", credential.Namespace, credential.Name)),
+			ContainerPath: credential.MountPath,
+			ReadOnly:      true,
+		})
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return LocalContainer{}, err
+	}
+	return LocalContainer{
+		Name:    name,
+		Image:   image,
+		Command: []string{"/bin/bash", "-c", CommandPrefix + step.Commands},
+		Env:     env,
+		Mounts:  mounts,
+	}, nil
+}
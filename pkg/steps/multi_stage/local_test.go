@@ -0,0 +1,274 @@
+package multi_stage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+)
+
+type fakeExitError struct{ code int }
+
+func (e *fakeExitError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+func (e *fakeExitError) ExitCode() int { return e.code }
+
+type fakeLocalRuntime struct {
+	lock     sync.Mutex
+	failures sets.Set[string]
+	// flakes fail the given number of times before succeeding
+	flakes map[string]int
+	ran    []LocalContainer
+}
+
+func (r *fakeLocalRuntime) Run(_ context.Context, container LocalContainer) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.ran = append(r.ran, container)
+	if r.flakes[container.Name] > 0 {
+		r.flakes[container.Name]--
+		return &fakeExitError{code: 3}
+	}
+	if r.failures.Has(container.Name) {
+		return errors.New("exit status 1")
+	}
+	return nil
+}
+
+func TestRunArgs(t *testing.T) {
+	container := LocalContainer{
+		Name:    "test-step",
+		Image:   "quay.io/org/image:latest",
+		Command: []string{"/bin/bash", "-c", "echo hi"},
+		Env:     []coreapi.EnvVar{{Name: "A", Value: "a=b"}},
+		Mounts: []LocalMount{
+			{HostPath: "/tmp/shared", ContainerPath: SecretMountPath},
+			{HostPath: "/tmp/creds", ContainerPath: "/creds", ReadOnly: true},
+		},
+	}
+	expected := []string{
+		"run", "--rm", "--name", "test-step",
+		"--env", "A=a=b",
+		"--volume", "/tmp/shared:" + SecretMountPath,
+		"--volume", "/tmp/creds:/creds:ro",
+		"--entrypoint", "/bin/bash", "quay.io/org/image:latest", "-c", "echo hi",
+	}
+	if diff := cmp.Diff(expected, runArgs(container)); diff != "" {
+		t.Errorf("unexpected arguments: %s", diff)
+	}
+}
+
+func TestLocalRun(t *testing.T) {
+	yes := true
+	for _, tc := range []struct {
+		name          string
+		failures      sets.Set[string]
+		flakes        map[string]int
+		expected      []string
+		expectedTests []string
+		expectedErr   bool
+	}{
+		{
+			name: "no step fails, no error",
+			expected: []string{
+				"test-pre0", "test-pre1",
+				"test-test0", "test-test1",
+				"test-post0",
+			},
+		},
+		{
+			name:     "failure in a pre step, test should not run, post should",
+			failures: sets.New[string]("test-pre0"),
+			expected: []string{
+				"test-pre0",
+				"test-post0", "test-post1",
+			},
+			expectedErr: true,
+		},
+		{
+			name:     "failure in a best-effort post step is ignored",
+			failures: sets.New[string]("test-post0"),
+			expected: []string{
+				"test-pre0", "test-pre1",
+				"test-test0", "test-test1",
+				"test-post0",
+			},
+		},
+		{
+			name:   "failed attempt of a step with a retry policy is retried",
+			flakes: map[string]int{"test-pre1": 1},
+			expected: []string{
+				"test-pre0", "test-pre1", "test-pre1",
+				"test-test0", "test-test1",
+				"test-post0",
+			},
+			expectedTests: []string{
+				"Run multi-stage test test - test-pre0 container test",
+				"Run multi-stage test test - test-pre1 container test",
+				"Run multi-stage test test - test-pre1-attempt-2 container test",
+				"Run multi-stage test pre phase",
+				"Run multi-stage test test - test-test0 container test",
+				"Run multi-stage test test - test-test1 container test",
+				"Run multi-stage test test - parallel group tests",
+				"Run multi-stage test test phase",
+				"Run multi-stage test test - test-post0 container test",
+				"Run multi-stage test post phase",
+			},
+		},
+		{
+			name:     "all members of a parallel group run even when one fails",
+			failures: sets.New[string]("test-test0"),
+			expected: []string{
+				"test-pre0", "test-pre1",
+				"test-test0", "test-test1",
+				"test-post0", "test-post1",
+			},
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			jobSpec := api.JobSpec{JobSpec: prowdapi.JobSpec{Job: "job"}}
+			jobSpec.SetNamespace("local")
+			runtime := &fakeLocalRuntime{failures: tc.failures, flakes: tc.flakes}
+			step := LocalMultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Pre:                      []api.LiteralTestStep{{As: "pre0", From: "src"}, {As: "pre1", From: "src", Retry: &api.StepRetry{Attempts: 2, ExitCodes: []int32{3}}}},
+					Test:                     []api.LiteralTestStep{{As: "test0", From: "src", ParallelGroup: "tests"}, {As: "test1", From: "src", ParallelGroup: "tests"}},
+					Post:                     []api.LiteralTestStep{{As: "post0", From: "src", BestEffort: &yes}, {As: "post1", From: "src", OptionalOnSuccess: &yes}},
+					AllowSkipOnSuccess:       &yes,
+					AllowBestEffortPostSteps: &yes,
+				},
+			}, &api.ReleaseBuildConfiguration{}, &jobSpec, runtime, LocalOptions{
+				WorkDir: t.TempDir(),
+				Images:  map[string]string{"src": "quay.io/org/src:latest"},
+			})
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedErr, err)
+			}
+			var names []string
+			for _, c := range runtime.ran {
+				names = append(names, c.Name)
+			}
+			// members of the parallel group in the test phase start in any order
+			for i := range names {
+				if strings.HasPrefix(names[i], "test-test") {
+					sort.Strings(names[i : i+2])
+					break
+				}
+			}
+			if diff := cmp.Diff(tc.expected, names); diff != "" {
+				t.Errorf("did not execute correct containers: %s", diff)
+			}
+			if tc.expectedTests == nil {
+				return
+			}
+			var tests []string
+			for _, test := range step.(*localMultiStageTestStep).SubTests() {
+				tests = append(tests, test.Name)
+			}
+			if diff := cmp.Diff(tc.expectedTests, tests); diff != "" {
+				t.Errorf("unexpected test cases: %s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateContainer(t *testing.T) {
+	workDir := t.TempDir()
+	jobSpec := api.JobSpec{JobSpec: prowdapi.JobSpec{Job: "job"}}
+	jobSpec.SetNamespace("local")
+	for _, tc := range []struct {
+		name        string
+		config      api.MultiStageTestConfigurationLiteral
+		step        api.LiteralTestStep
+		opts        LocalOptions
+		expected    LocalContainer
+		expectedErr string
+	}{
+		{
+			name: "image, dependency, credential and parameter are materialized",
+			config: api.MultiStageTestConfigurationLiteral{
+				Environment: api.TestEnvironment{"PARAM": "value"},
+			},
+			step: api.LiteralTestStep{
+				As:           "step",
+				From:         "src",
+				Commands:     "make test",
+				NoKubeconfig: func() *bool { b := true; return &b }(),
+				Environment:  []api.StepParameter{{Name: "PARAM"}},
+				Dependencies: []api.StepDependency{{Name: "stable:installer", Env: "INSTALLER"}},
+				Credentials:  []api.CredentialReference{{Namespace: "ns", Name: "secret", MountPath: "/creds"}},
+			},
+			opts: LocalOptions{
+				WorkDir:        workDir,
+				CredentialsDir: "/credentials",
+				Images: map[string]string{
+					"src":              "quay.io/org/src:latest",
+					"stable:installer": "quay.io/org/installer:latest",
+				},
+			},
+			expected: LocalContainer{
+				Name:    "test-step",
+				Image:   "quay.io/org/src:latest",
+				Command: []string{"/bin/bash", "-c", CommandPrefix + "make test"},
+				Env: []coreapi.EnvVar{
+					{Name: "NAMESPACE", Value: "local"},
+					{Name: "JOB_NAME_SAFE", Value: "test"},
+					{Name: "JOB_NAME_HASH", Value: jobSpec.JobNameHash()},
+					{Name: "UNIQUE_HASH", Value: jobSpec.UniqueHash()},
+					{Name: "ARTIFACT_DIR", Value: LocalArtifactMountPath},
+					{Name: "HOME", Value: "/alabama"},
+					{Name: SecretMountEnv, Value: SecretMountPath},
+					{Name: "PARAM", Value: "value"},
+					{Name: "INSTALLER", Value: "quay.io/org/installer:latest"},
+				},
+				Mounts: []LocalMount{
+					{HostPath: filepath.Join(workDir, localSharedDir), ContainerPath: SecretMountPath},
+					{HostPath: filepath.Join(workDir, localHomeDir), ContainerPath: "/alabama"},
+					{HostPath: filepath.Join(workDir, localArtifactsDir, "step"), ContainerPath: LocalArtifactMountPath},
+					{HostPath: "/credentials/ns-secret", ContainerPath: "/creds", ReadOnly: true},
+				},
+			},
+		},
+		{
+			name: "missing image and lease are reported",
+			config: api.MultiStageTestConfigurationLiteral{
+				Leases: []api.StepLease{{ResourceType: "aws-quota-slice", Env: "LEASED_RESOURCE"}},
+			},
+			step:        api.LiteralTestStep{As: "step", From: "src"},
+			opts:        LocalOptions{WorkDir: workDir},
+			expectedErr: `[no local image provided for "src" of step step, lease aws-quota-slice is not available locally, set LEASED_RESOURCE in the test environment]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.Test = []api.LiteralTestStep{tc.step}
+			step := newLocalMultiStageTestStep(api.TestStepConfiguration{
+				As:                                 "test",
+				MultiStageTestConfigurationLiteral: &tc.config,
+			}, &api.ReleaseBuildConfiguration{}, &jobSpec, &fakeLocalRuntime{}, tc.opts)
+			container, err := step.generateContainer("test-step", tc.step)
+			var actualErr string
+			if err != nil {
+				actualErr = err.Error()
+			}
+			if diff := cmp.Diff(tc.expectedErr, actualErr); diff != "" {
+				t.Errorf("unexpected error: %s", diff)
+			}
+			if diff := cmp.Diff(tc.expected, container); diff != "" {
+				t.Errorf("unexpected container: %s", diff)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/kubernetes"
 	"github.com/openshift/ci-tools/pkg/results"
+	base_steps "github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	"github.com/openshift/ci-tools/pkg/steps/utils"
 )
@@ -54,6 +55,14 @@ const (
 	allowSkipOnSuccess
 	// The test was configured to allow best-effort steps.
 	allowBestEffortPostSteps
+	// The test was configured to reuse cached results of the `pre` phase.
+	cachePre
+	// Objects are rendered for a dry run, so nothing can be resolved from the
+	// cluster.
+	dryRun
+	// An interrupted execution is resumed, so the `pre` phase may be skipped
+	// when it had finished.
+	resumePre
 )
 
 const (
@@ -90,17 +99,21 @@ type multiStageTestStep struct {
 	profile          api.ClusterProfile
 	config           *api.ReleaseBuildConfiguration
 	// params exposes getters for variables created by other steps
-	params                      api.Parameters
-	env                         api.TestEnvironment
-	client                      kubernetes.PodClient
-	jobSpec                     *api.JobSpec
-	observers                   []api.Observer
-	pre, test, post             []api.LiteralTestStep
-	subLock                     *sync.Mutex
-	subTests                    []*junit.TestCase
-	subSteps                    []api.CIOperatorStepDetailInfo
-	flags                       stepFlag
-	leases                      []api.StepLease
+	params          api.Parameters
+	env             api.TestEnvironment
+	client          kubernetes.PodClient
+	jobSpec         *api.JobSpec
+	observers       []api.Observer
+	pre, test, post []api.LiteralTestStep
+	subLock         *sync.Mutex
+	subTests        []*junit.TestCase
+	subSteps        []api.CIOperatorStepDetailInfo
+	flags           stepFlag
+	leases          []api.StepLease
+	// leased holds the resources leased for the test, by environment variable
+	leased map[string][]string
+	// resumedLeases holds the resources leased by an interrupted execution
+	resumedLeases               map[string][]string
 	clusterClaim                *api.ClusterClaim
 	vpnConf                     *vpnConf
 	cancelObservers             func(context.CancelFunc)
@@ -143,6 +156,9 @@ func newMultiStageTestStep(
 	if p := ms.AllowBestEffortPostSteps; p != nil && *p {
 		flags |= allowBestEffortPostSteps
 	}
+	if p := ms.CachePre; p != nil && *p {
+		flags |= cachePre
+	}
 	return &multiStageTestStep{
 		name:                        testConfig.As,
 		additionalSuffix:            targetAdditionalSuffix,
@@ -196,8 +212,11 @@ func (s *multiStageTestStep) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	if err := s.createSharedDirSecret(ctx); err != nil {
-		return fmt.Errorf("failed to create secret: %w", err)
+	preKey, preResumed := s.resumablePre(ctx, env)
+	if !preResumed {
+		if err := s.createSharedDirSecret(ctx); err != nil {
+			return fmt.Errorf("failed to create secret: %w", err)
+		}
 	}
 	if s.enableSecretsStoreCSIDriver {
 		if err := s.createSPCs(ctx); err != nil {
@@ -239,13 +258,14 @@ func (s *multiStageTestStep) run(ctx context.Context) error {
 	observerDone := make(chan struct{})
 	go s.runObservers(observerContext, ctx, observers, observerDone)
 	s.flags |= shortCircuit
-	if err := s.runSteps(ctx, "pre", s.pre, env, secretVolumes, secretVolumeMounts); err != nil {
+	if err := s.runPre(ctx, preKey, preResumed, env, secretVolumes, secretVolumeMounts); err != nil {
 		errs = append(errs, fmt.Errorf("%q pre steps failed: %w", s.name, err))
 	} else if err := s.runSteps(ctx, "test", s.test, env, secretVolumes, secretVolumeMounts); err != nil {
 		errs = append(errs, fmt.Errorf("%q test steps failed: %w", s.name, err))
 	}
 	s.cancelObserversContext(cancel) // signal to observers that we're tearing down
 	s.flags &= ^shortCircuit
+	s.invalidatePre(context.Background())
 	if err := s.runSteps(context.Background(), "post", s.post, env, secretVolumes, secretVolumeMounts); err != nil {
 		errs = append(errs, fmt.Errorf("%q post steps failed: %w", s.name, err))
 	}
@@ -253,6 +273,43 @@ func (s *multiStageTestStep) run(ctx context.Context) error {
 	return utilerrors.NewAggregate(errs)
 }
 
+// DryRun renders the shared directory, the commands, the RBAC and the Pods of
+// the test. Credentials and the cluster profile are copied from other
+// namespaces and parameters are provided by other steps while the test runs,
+// so they are not part of the output.
+func (s *multiStageTestStep) DryRun() (*base_steps.DryRunOutput, error) {
+	s.flags |= dryRun
+	defer func() { s.flags &= ^dryRun }()
+	ret := &base_steps.DryRunOutput{}
+	ret.Objects = append(ret.Objects, s.sharedDirSecret(), s.commandConfigMap())
+	sa, role, bindings := s.rbac()
+	ret.Objects = append(ret.Objects, sa, role)
+	for i := range bindings {
+		ret.Objects = append(ret.Objects, &bindings[i])
+	}
+	generateObserverOpt := defaultGeneratePodOptions()
+	generateObserverOpt.IsObserver = true
+	generateObserverOpt.enableSecretsStoreCSIDriver = s.enableSecretsStoreCSIDriver
+	observers, err := s.generateObservers(s.observers, nil, nil, generateObserverOpt)
+	if err != nil {
+		return nil, err
+	}
+	pods := observers
+	for _, phase := range [][]api.LiteralTestStep{s.pre, s.test, s.post} {
+		phasePods, _, err := s.generatePods(phase, nil, nil, nil, &generatePodOptions{
+			enableSecretsStoreCSIDriver: s.enableSecretsStoreCSIDriver,
+		})
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, phasePods...)
+	}
+	for i := range pods {
+		ret.Objects = append(ret.Objects, &pods[i])
+	}
+	return ret, nil
+}
+
 func (s *multiStageTestStep) Name() string { return s.name }
 func (s *multiStageTestStep) Description() string {
 	return fmt.Sprintf("Run multi-stage test %s", s.name)
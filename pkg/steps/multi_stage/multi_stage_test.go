@@ -6,21 +6,28 @@ import (
 	"path"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
 	coreapi "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowapi "sigs.k8s.io/prow/pkg/apis/prowjobs/v1"
+	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	"github.com/openshift/ci-tools/pkg/testhelper"
 )
 
 // the multiStageTestStep implements the subStepReporter interface
 var _ steps.SubStepReporter = &multiStageTestStep{}
 
+// the multiStageTestStep implements the DryRunner interface
+var _ steps.DryRunner = &multiStageTestStep{}
+
 func TestRequires(t *testing.T) {
 	for _, tc := range []struct {
 		name         string
@@ -408,3 +415,52 @@ func TestProfileSecretName(t *testing.T) {
 		})
 	}
 }
+
+func TestDryRun(t *testing.T) {
+	config := api.ReleaseBuildConfiguration{
+		Tests: []api.TestStepConfiguration{{
+			As: "e2e",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Pre: []api.LiteralTestStep{{
+					As: "setup", From: "src", Commands: "setup",
+				}},
+				Test: []api.LiteralTestStep{{
+					As: "test", From: "src", Commands: "test",
+					Dependencies: []api.StepDependency{{Name: "installer", Env: "INSTALLER"}},
+				}},
+				Post: []api.LiteralTestStep{{
+					As: "teardown", From: "src", Commands: "teardown",
+				}},
+				Observers: []api.Observer{{
+					Name: "watcher", From: "src", Commands: "watch",
+				}},
+			},
+		}},
+	}
+	jobSpec := api.JobSpec{
+		JobSpec: prowdapi.JobSpec{
+			Job:       "job",
+			BuildID:   "build id",
+			ProwJobID: "prow job id",
+			Type:      "periodic",
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout:     &prowapi.Duration{Duration: time.Minute},
+				GracePeriod: &prowapi.Duration{Duration: time.Second},
+				UtilityImages: &prowapi.UtilityImages{
+					Sidecar:    "sidecar",
+					Entrypoint: "entrypoint",
+				},
+			},
+		},
+	}
+	jobSpec.SetNamespace("namespace")
+	step := newMultiStageTestStep(config.Tests[0], &config, nil, nil, &jobSpec, nil, "node-name", "", nil, false)
+	output, err := step.DryRun()
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	testhelper.CompareWithFixture(t, output)
+	if step.flags&dryRun != 0 {
+		t.Error("dry run flag was not reset")
+	}
+}
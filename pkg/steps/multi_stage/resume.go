@@ -0,0 +1,122 @@
+package multi_stage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+// preFinishedAnnotation marks the shared directory with the key of the `pre`
+// phase that populated it.  It is removed before the `post` phase starts, as
+// that tears down whatever `pre` set up.
+const preFinishedAnnotation = "ci.openshift.io/pre-finished"
+
+// Resume prepares the test to skip its `pre` phase when an interrupted
+// execution had finished it and the shared directory it populated still
+// exists.  A test that finished successfully does not need to run again.
+func (s *multiStageTestStep) Resume(_ context.Context, finished bool) (bool, error) {
+	if finished {
+		return true, nil
+	}
+	s.flags |= resumePre
+	return false, nil
+}
+
+// ResumeLeases records the resources leased by the interrupted execution, as
+// the outputs of its `pre` phase are only valid with them.
+func (s *multiStageTestStep) ResumeLeases(previous map[string][]string, _ func(map[string][]string)) {
+	s.resumedLeases = previous
+}
+
+// resumablePre determines the key identifying the `pre` phase and whether the
+// phase can be skipped because it finished in an interrupted execution which
+// had leased the same resources.  The key does not cover the leased resources,
+// which are only known once they are acquired.
+func (s *multiStageTestStep) resumablePre(ctx context.Context, env []coreapi.EnvVar) (string, bool) {
+	key, err := s.preCacheKey(env)
+	if err != nil {
+		logrus.WithError(err).Warn("Could not determine pre key, the pre phase will not be resumable.")
+		return "", false
+	}
+	if s.flags&resumePre == 0 || len(s.pre) == 0 {
+		return key, false
+	}
+	var shared coreapi.Secret
+	if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: s.name}, &shared); err != nil {
+		logrus.WithError(err).Debugf("Could not get shared directory %q, running pre steps.", s.name)
+		return key, false
+	}
+	if shared.Annotations[preFinishedAnnotation] != key {
+		return key, false
+	}
+	if !sameLeases(s.resumedLeases, s.leased) {
+		logrus.Infof("Multi-stage phase pre finished in an interrupted execution with other leased resources, running pre steps")
+		return key, false
+	}
+	return key, true
+}
+
+// runPre runs the `pre` phase unless it is resumed and marks the shared
+// directory when the phase succeeds.
+func (s *multiStageTestStep) runPre(
+	ctx context.Context,
+	key string,
+	resumed bool,
+	env []coreapi.EnvVar,
+	secretVolumes []coreapi.Volume,
+	secretVolumeMounts []coreapi.VolumeMount,
+) error {
+	if resumed {
+		logrus.Infof("Multi-stage phase pre finished in an interrupted execution, skipping pre steps")
+		s.subTests = append(s.subTests, &junit.TestCase{
+			Name:        "Run multi-stage test pre phase",
+			SkipMessage: &junit.SkipMessage{Message: "Multi-stage phase pre finished in an interrupted execution."},
+		})
+		return nil
+	}
+	if err := s.runPreWithCache(ctx, env, secretVolumes, secretVolumeMounts); err != nil {
+		return err
+	}
+	if key != "" {
+		if err := s.annotateSharedDir(ctx, key); err != nil {
+			logrus.WithError(err).Warn("Could not mark the pre phase as finished.")
+		}
+	}
+	return nil
+}
+
+// invalidatePre removes the mark of a finished `pre` phase from the shared
+// directory so that it is not resumed once `post` started.
+func (s *multiStageTestStep) invalidatePre(ctx context.Context) {
+	if err := s.annotateSharedDir(ctx, ""); err != nil {
+		logrus.WithError(err).Warn("Could not remove the mark of the finished pre phase.")
+	}
+}
+
+func (s *multiStageTestStep) annotateSharedDir(ctx context.Context, key string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var shared coreapi.Secret
+		if err := s.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: s.jobSpec.Namespace(), Name: s.name}, &shared); err != nil {
+			return fmt.Errorf("could not get shared directory %q: %w", s.name, err)
+		}
+		if shared.Annotations[preFinishedAnnotation] == key {
+			return nil
+		}
+		if key == "" {
+			delete(shared.Annotations, preFinishedAnnotation)
+		} else {
+			if shared.Annotations == nil {
+				shared.Annotations = map[string]string{}
+			}
+			shared.Annotations[preFinishedAnnotation] = key
+		}
+		return s.client.Update(ctx, &shared)
+	})
+}
@@ -0,0 +1,144 @@
+package multi_stage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	coreapi "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
+	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
+)
+
+func TestResumablePre(t *testing.T) {
+	env := []coreapi.EnvVar{{Name: api.DefaultLeaseEnv, Value: "us-east-1"}}
+	newStep := func(annotations map[string]string, shared bool) (*multiStageTestStep, ctrlruntimeclient.Client) {
+		jobSpec := api.JobSpec{JobSpec: prowdapi.JobSpec{Job: "job"}, InputHash: "hash"}
+		jobSpec.SetNamespace("ns")
+		builder := fakectrlruntimeclient.NewClientBuilder()
+		if shared {
+			builder = builder.WithObjects(&coreapi.Secret{
+				ObjectMeta: meta.ObjectMeta{Namespace: "ns", Name: "test", Annotations: annotations},
+			})
+		}
+		crclient := builder.Build()
+		client := &testhelper_kube.FakePodClient{
+			FakePodExecutor: &testhelper_kube.FakePodExecutor{LoggingClient: loggingclient.New(crclient)},
+		}
+		step := newMultiStageTestStep(api.TestStepConfiguration{
+			As: "test",
+			MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+				Pre: []api.LiteralTestStep{{As: "install"}},
+			},
+		}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "", "", nil, false)
+		return step, crclient
+	}
+	key, err := func() (string, error) {
+		step, _ := newStep(nil, false)
+		return step.preCacheKey(env)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		shared      bool
+		resume      bool
+		resumed     map[string][]string
+		leased      map[string][]string
+		expected    bool
+	}{
+		{
+			name:        "finished pre is resumed",
+			annotations: map[string]string{preFinishedAnnotation: key},
+			shared:      true,
+			resume:      true,
+			expected:    true,
+		},
+		{
+			name:        "finished pre is resumed with the same leased resources",
+			annotations: map[string]string{preFinishedAnnotation: key},
+			shared:      true,
+			resume:      true,
+			resumed:     map[string][]string{api.DefaultLeaseEnv: {"us-east-1--aws-quota-slice-00"}},
+			leased:      map[string][]string{api.DefaultLeaseEnv: {"us-east-1--aws-quota-slice-00"}},
+			expected:    true,
+		},
+		{
+			name:        "finished pre is not resumed with other leased resources",
+			annotations: map[string]string{preFinishedAnnotation: key},
+			shared:      true,
+			resume:      true,
+			resumed:     map[string][]string{api.DefaultLeaseEnv: {"us-east-1--aws-quota-slice-00"}},
+			leased:      map[string][]string{api.DefaultLeaseEnv: {"us-east-1--aws-quota-slice-01"}},
+		},
+		{
+			name:        "finished pre is not resumed when not resuming",
+			annotations: map[string]string{preFinishedAnnotation: key},
+			shared:      true,
+		},
+		{
+			name:        "pre finished with other inputs is not resumed",
+			annotations: map[string]string{preFinishedAnnotation: "pre-other"},
+			shared:      true,
+			resume:      true,
+		},
+		{
+			name:   "unfinished pre is not resumed",
+			shared: true,
+			resume: true,
+		},
+		{
+			name:   "missing shared directory is not resumed",
+			resume: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			step, _ := newStep(tc.annotations, tc.shared)
+			if tc.resume {
+				if skip, err := step.Resume(context.Background(), false); err != nil || skip {
+					t.Fatalf("expected an unfinished test not to be skipped, got %t, %v", skip, err)
+				}
+			}
+			step.ResumeLeases(tc.resumed, nil)
+			step.Leased(tc.leased)
+			actualKey, resumed := step.resumablePre(context.Background(), env)
+			if actualKey != key {
+				t.Errorf("expected key %s, got %s", key, actualKey)
+			}
+			if resumed != tc.expected {
+				t.Errorf("expected resumed to be %t, got %t", tc.expected, resumed)
+			}
+		})
+	}
+
+	t.Run("shared directory is marked and invalidated", func(t *testing.T) {
+		step, crclient := newStep(nil, true)
+		get := func() map[string]string {
+			var shared coreapi.Secret
+			if err := crclient.Get(context.Background(), ctrlruntimeclient.ObjectKey{Namespace: "ns", Name: "test"}, &shared); err != nil {
+				t.Fatal(err)
+			}
+			return shared.Annotations
+		}
+		if err := step.annotateSharedDir(context.Background(), key); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff := cmp.Diff(map[string]string{preFinishedAnnotation: key}, get()); diff != "" {
+			t.Errorf("unexpected annotations after pre: %s", diff)
+		}
+		step.invalidatePre(context.Background())
+		if annotations := get(); len(annotations) != 0 {
+			t.Errorf("expected no annotations after invalidation, got %v", annotations)
+		}
+	})
+}
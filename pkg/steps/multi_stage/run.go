@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
 	base_steps "github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/util"
 )
@@ -61,8 +63,12 @@ func (s *multiStageTestStep) runSteps(
 	}
 
 	err = utilerrors.NewAggregate(errs)
-	finished := time.Now()
-	duration := finished.Sub(start)
+	s.recordPhase(phase, time.Since(start), err)
+	return err
+}
+
+// recordPhase adds the test case of a finished phase.
+func (s *multiStageTestStep) recordPhase(phase string, duration time.Duration, err error) {
 	testCase := &junit.TestCase{
 		Name:      fmt.Sprintf("Run multi-stage test %s phase", phase),
 		Duration:  duration.Seconds(),
@@ -77,29 +83,155 @@ func (s *multiStageTestStep) runSteps(
 	}
 	s.subTests = append(s.subTests, testCase)
 	logrus.Infof("Step phase %s %s after %s.", phase, verb, duration.Truncate(time.Second))
+}
 
-	return err
+// stepExecution is a step of a phase ready to be executed, either as a pod in
+// the test namespace or as a container of a local runtime.
+type stepExecution struct {
+	name string
+	step api.LiteralTestStep
+	// env is the environment of the test container, used to evaluate the
+	// condition of the step.
+	env []coreapi.EnvVar
+	// bestEffort failures do not fail the phase.
+	bestEffort bool
+	// attempt executes the step once and passes its test cases to record.
+	// The exit code of the test container is returned when it terminated.
+	attempt func(ctx context.Context, attempt int, record testCaseRecorder) (*int32, error)
+}
+
+// testCaseRecorder receives the test cases of a step.
+type testCaseRecorder func(testCases ...*junit.TestCase)
+
+// recordTestCases adds test cases to those reported by the step.
+func (s *multiStageTestStep) recordTestCases(testCases ...*junit.TestCase) {
+	s.subLock.Lock()
+	s.subTests = append(s.subTests, testCases...)
+	s.subLock.Unlock()
+}
+
+// podExecutions prepares the pods of a phase for execution.
+func (s *multiStageTestStep) podExecutions(pods []coreapi.Pod, bestEffortSteps sets.Set[string]) []stepExecution {
+	executions := make([]stepExecution, 0, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		executions = append(executions, stepExecution{
+			name:       pod.Name,
+			step:       s.stepFor(pod.Name),
+			env:        testContainerEnv(pod),
+			bestEffort: bestEffortSteps != nil && bestEffortSteps.Has(pod.Name),
+			attempt: func(ctx context.Context, attempt int, record testCaseRecorder) (*int32, error) {
+				attemptPod := pod.DeepCopy()
+				err := s.runPodAttempt(ctx, attemptPod, base_steps.NewTestCaseNotifier(util.NopNotifier), util.WaitForPodFlag(0), attempt, record)
+				return testContainerExitCode(attemptPod), err
+			},
+		})
+	}
+	return executions
 }
 
 func (s *multiStageTestStep) runPods(ctx context.Context, pods []coreapi.Pod, bestEffortSteps sets.Set[string]) error {
+	return s.runExecutions(ctx, s.podExecutions(pods, bestEffortSteps))
+}
+
+// runExecutions executes the steps of a phase in order, running consecutive
+// members of a parallel group concurrently.
+func (s *multiStageTestStep) runExecutions(ctx context.Context, executions []stepExecution) error {
 	var errs []error
-	for _, pod := range pods {
-		err := s.runPod(ctx, &pod, base_steps.NewTestCaseNotifier(util.NopNotifier), util.WaitForPodFlag(0))
-		if err == nil {
-			continue
+	for len(executions) != 0 {
+		n, group := 1, executions[0].step.ParallelGroup
+		for group != "" && n < len(executions) && executions[n].step.ParallelGroup == group {
+			n++
 		}
-		if bestEffortSteps != nil && bestEffortSteps.Has(pod.Name) {
-			logrus.Infof("Pod %s is running in best-effort mode, ignoring the failure...", pod.Name)
-			continue
+		var stepErrs []error
+		if n == 1 {
+			if err := s.runStep(ctx, executions[0], s.recordTestCases); err != nil {
+				stepErrs = append(stepErrs, err)
+			}
+		} else {
+			stepErrs = s.runParallelSteps(ctx, group, executions[:n])
 		}
-		errs = append(errs, err)
-		if s.flags&shortCircuit != 0 {
+		executions = executions[n:]
+		errs = append(errs, stepErrs...)
+		if len(stepErrs) != 0 && s.flags&shortCircuit != 0 {
 			break
 		}
 	}
 	return utilerrors.NewAggregate(errs)
 }
 
+// runStep executes a step if its condition allows it.  Failures of
+// best-effort steps are ignored.
+func (s *multiStageTestStep) runStep(ctx context.Context, execution stepExecution, record testCaseRecorder) error {
+	run, err := s.evaluateWhen(execution.name, execution.env, execution.step)
+	if err == nil && !run {
+		record(skippedStepTestCase(s.Description(), execution.name, execution.step.When))
+		return nil
+	}
+	if err == nil {
+		err = s.runWithRetry(ctx, execution, record)
+	}
+	if err != nil && execution.bestEffort {
+		logrus.Infof("Step %s is running in best-effort mode, ignoring the failure...", execution.name)
+		return nil
+	}
+	return err
+}
+
+// runParallelSteps executes the members of a parallel group concurrently.
+// The test cases of the members are collected and added in a stable order
+// once all of them finished, followed by one for the whole group, so they are
+// not interleaved with those of e.g. observers.  Members share the same $SHARED_DIR: the entrypoint
+// wrapper of each only applies its own changes to the secret.
+func (s *multiStageTestStep) runParallelSteps(ctx context.Context, group string, executions []stepExecution) []error {
+	start := time.Now()
+	names := make([]string, 0, len(executions))
+	for _, execution := range executions {
+		names = append(names, execution.name)
+	}
+	logrus.Infof("Running steps %s in parallel.", strings.Join(names, ", "))
+	memberErrs := make([]error, len(executions))
+	memberTestCases := make([][]*junit.TestCase, len(executions))
+	var wg sync.WaitGroup
+	for i := range executions {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			memberErrs[i] = s.runStep(ctx, executions[i], func(testCases ...*junit.TestCase) {
+				memberTestCases[i] = append(memberTestCases[i], testCases...)
+			})
+		}(i)
+	}
+	wg.Wait()
+	var errs []error
+	for _, err := range memberErrs {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	duration := time.Since(start)
+	testCase := &junit.TestCase{
+		Name:      fmt.Sprintf("%s - parallel group %s", s.Description(), group),
+		Duration:  duration.Seconds(),
+		SystemOut: fmt.Sprintf("The steps %s executed in parallel.", strings.Join(names, ", ")),
+	}
+	verb := "succeeded"
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		verb = "failed"
+		testCase.FailureOutput = &junit.FailureOutput{Output: err.Error()}
+	}
+	logrus.Infof("Parallel group %s %s after %s.", group, verb, duration.Truncate(time.Second))
+	var members []*junit.TestCase
+	for _, testCases := range memberTestCases {
+		members = append(members, testCases...)
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].Name < members[j].Name
+	})
+	s.recordTestCases(append(members, testCase)...)
+	return errs
+}
+
 func (s *multiStageTestStep) runObservers(ctx, textCtx context.Context, pods []coreapi.Pod, done chan<- struct{}) {
 	wg := sync.WaitGroup{}
 	wg.Add(len(pods))
@@ -134,7 +266,130 @@ func (s *multiStageTestStep) runObservers(ctx, textCtx context.Context, pods []c
 	done <- struct{}{}
 }
 
+// stepFor returns the definition of the step executed by a pod.
+func (s *multiStageTestStep) stepFor(podName string) api.LiteralTestStep {
+	for _, step := range append(s.pre, append(s.test, s.post...)...) {
+		if fmt.Sprintf("%s-%s", s.name, step.As) == podName {
+			return step
+		}
+	}
+	return api.LiteralTestStep{}
+}
+
+// testContainerEnv returns the environment of the test container of a pod.
+func testContainerEnv(pod *coreapi.Pod) []coreapi.EnvVar {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == containerName {
+			return c.Env
+		}
+	}
+	return nil
+}
+
+// evaluateWhen determines whether a step is executed based on its condition,
+// using the environment of the step and the parameters of the test.
+// Variables not present in the environment are considered unset.
+func (s *multiStageTestStep) evaluateWhen(name string, env []coreapi.EnvVar, step api.LiteralTestStep) (bool, error) {
+	if step.When == "" {
+		return true, nil
+	}
+	lookup := func(variable string) string {
+		for _, e := range env {
+			if e.Name == variable {
+				return e.Value
+			}
+		}
+		return s.env[variable]
+	}
+	run, err := api.EvaluateWhen(step.When, lookup)
+	if err != nil {
+		return false, fmt.Errorf("could not evaluate condition of step %s: %w", name, err)
+	}
+	if !run {
+		logrus.Infof("Skipping step %s, condition %q is false.", name, step.When)
+	}
+	return run, nil
+}
+
+func skippedStepTestCase(description, name, when string) *junit.TestCase {
+	return &junit.TestCase{
+		Name:        fmt.Sprintf("%s - %s container test", description, name),
+		SkipMessage: &junit.SkipMessage{Message: fmt.Sprintf("Condition %q is false.", when)},
+	}
+}
+
+// runWithRetry executes a step, executing it again as configured when an
+// attempt fails in a way which can be retried.
+func (s *multiStageTestStep) runWithRetry(ctx context.Context, execution stepExecution, record testCaseRecorder) error {
+	retry := execution.step.Retry
+	if retry == nil || retry.Attempts <= 1 {
+		_, err := execution.attempt(ctx, 1, record)
+		return err
+	}
+	var backoff time.Duration
+	if retry.Backoff != nil {
+		backoff = retry.Backoff.Duration
+	}
+	var err error
+	for attempt := 1; attempt <= retry.Attempts; attempt++ {
+		var exitCode *int32
+		exitCode, err = execution.attempt(ctx, attempt, record)
+		if err == nil || attempt == retry.Attempts || ctx.Err() != nil || !retryable(retry, exitCode, err) {
+			break
+		}
+		logrus.Infof("Step %s failed on attempt %d/%d, retrying after %s.", execution.name, attempt, retry.Attempts, backoff)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+// testContainerExitCode returns the exit code of the test container of a pod
+// if it terminated.
+func testContainerExitCode(pod *coreapi.Pod) *int32 {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName && status.State.Terminated != nil {
+			return &status.State.Terminated.ExitCode
+		}
+	}
+	return nil
+}
+
+// retryable determines whether a failed attempt of a step can be retried.
+func retryable(retry *api.StepRetry, exitCode *int32, err error) bool {
+	if len(retry.ExitCodes) == 0 && len(retry.Reasons) == 0 {
+		return true
+	}
+	if exitCode != nil {
+		for _, code := range retry.ExitCodes {
+			if *exitCode == code {
+				return true
+			}
+		}
+	}
+	reasons := sets.New[string](retry.Reasons...)
+	for _, chain := range results.Reasons(err) {
+		for _, reason := range strings.Split(chain, ":") {
+			if reasons.Has(reason) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notifier *base_steps.TestCaseNotifier, flags util.WaitForPodFlag) error {
+	return s.runPodAttempt(ctx, pod, notifier, flags, 1, s.recordTestCases)
+}
+
+// runPodAttempt executes a pod, records it as a sub-step and passes its test
+// cases to record.  Attempts after the first are recorded under a distinct
+// name.  The pod is updated with its final state.
+func (s *multiStageTestStep) runPodAttempt(ctx context.Context, pod *coreapi.Pod, notifier *base_steps.TestCaseNotifier, flags util.WaitForPodFlag, attempt int, record testCaseRecorder) error {
 	start := time.Now()
 	logrus.Infof("Running step %s.", pod.Name)
 	client := s.client.WithNewLoggingClient()
@@ -143,7 +398,7 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	}
 	newPod, err := util.WaitForPodCompletion(ctx, client, pod.Namespace, pod.Name, notifier, flags)
 	if newPod != nil {
-		pod = newPod
+		*pod = *newPod
 	}
 	finished := time.Now()
 	duration := finished.Sub(start)
@@ -151,19 +406,23 @@ func (s *multiStageTestStep) runPod(ctx context.Context, pod *coreapi.Pod, notif
 	if err != nil {
 		verb = "failed"
 	}
-	logrus.Infof("Step %s %s after %s.", pod.Name, verb, duration.Truncate(time.Second))
+	name := pod.Name
+	if attempt > 1 {
+		name = fmt.Sprintf("%s-attempt-%d", pod.Name, attempt)
+	}
+	logrus.Infof("Step %s %s after %s.", name, verb, duration.Truncate(time.Second))
 	s.subLock.Lock()
 	s.subSteps = append(s.subSteps, api.CIOperatorStepDetailInfo{
-		StepName:    pod.Name,
-		Description: fmt.Sprintf("Run pod %s", pod.Name),
+		StepName:    name,
+		Description: fmt.Sprintf("Run pod %s", name),
 		StartedAt:   &start,
 		FinishedAt:  &finished,
 		Duration:    &duration,
 		Failed:      utilpointer.Bool(err != nil),
 		Manifests:   client.Objects(),
 	})
-	s.subTests = append(s.subTests, notifier.SubTests(fmt.Sprintf("%s - %s ", s.Description(), pod.Name))...)
 	s.subLock.Unlock()
+	record(notifier.SubTests(fmt.Sprintf("%s - %s ", s.Description(), name))...)
 	if err != nil {
 		linksText := strings.Builder{}
 		linksText.WriteString(fmt.Sprintf("Link to step on registry info site: https://steps.ci.openshift.org/reference/%s", strings.TrimPrefix(pod.Name, s.name+"-")))
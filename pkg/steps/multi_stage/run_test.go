@@ -2,8 +2,11 @@ package multi_stage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +22,8 @@ import (
 	prowdapi "sigs.k8s.io/prow/pkg/pod-utils/downwardapi"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+	"github.com/openshift/ci-tools/pkg/results"
 	"github.com/openshift/ci-tools/pkg/steps"
 	"github.com/openshift/ci-tools/pkg/steps/loggingclient"
 	testhelper_kube "github.com/openshift/ci-tools/pkg/testhelper/kubernetes"
@@ -277,6 +282,413 @@ func TestJUnit(t *testing.T) {
 	}
 }
 
+func TestRunRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		retry             *api.StepRetry
+		failures          sets.Set[string]
+		transientFailures map[string]int
+		expectedErr       bool
+		expectedPods      []string
+		expectedTests     []string
+	}{{
+		name:              "transient failure is retried and succeeds",
+		retry:             &api.StepRetry{Attempts: 3},
+		transientFailures: map[string]int{"test-test0": 1},
+		expectedPods:      []string{"test-test0", "test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test - test-test0-attempt-2 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name:         "persistent failure exhausts all attempts",
+		retry:        &api.StepRetry{Attempts: 2},
+		failures:     sets.New[string]("test-test0"),
+		expectedErr:  true,
+		expectedPods: []string{"test-test0", "test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test - test-test0-attempt-2 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name:              "failure with a matching exit code is retried",
+		retry:             &api.StepRetry{Attempts: 2, ExitCodes: []int32{1}},
+		transientFailures: map[string]int{"test-test0": 1},
+		expectedPods:      []string{"test-test0", "test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test - test-test0-attempt-2 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name:              "failure with a different exit code is not retried",
+		retry:             &api.StepRetry{Attempts: 2, ExitCodes: []int32{2}},
+		transientFailures: map[string]int{"test-test0": 1},
+		expectedErr:       true,
+		expectedPods:      []string{"test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name:              "failure without a retry policy is not retried",
+		transientFailures: map[string]int{"test-test0": 1},
+		expectedErr:       true,
+		expectedPods:      []string{"test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace", Labels: map[string]string{"ci.openshift.io/multi-stage-test": "test"}}}
+			crclient := &testhelper_kube.FakePodExecutor{
+				LoggingClient: loggingclient.New(
+					fakectrlruntimeclient.NewClientBuilder().
+						WithIndex(&v1.Pod{}, "metadata.name", fakePodNameIndexer).
+						WithObjects(sa).
+						Build()),
+				Failures:          tc.failures,
+				TransientFailures: tc.transientFailures,
+			}
+			jobSpec := api.JobSpec{
+				JobSpec: prowdapi.JobSpec{
+					Job:       "job",
+					BuildID:   "build_id",
+					ProwJobID: "prow_job_id",
+					Type:      prowapi.PeriodicJob,
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Second},
+						UtilityImages: &prowapi.UtilityImages{
+							Sidecar:    "sidecar",
+							Entrypoint: "entrypoint",
+						},
+					},
+				},
+			}
+			jobSpec.SetNamespace("test-namespace")
+			client := &testhelper_kube.FakePodClient{FakePodExecutor: crclient}
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{{As: "test0", Retry: tc.retry}},
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "", nil, false)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedErr, err)
+			}
+			var pods []string
+			for _, pod := range crclient.CreatedPods {
+				pods = append(pods, pod.Name)
+			}
+			if diff := cmp.Diff(tc.expectedPods, pods); diff != "" {
+				t.Errorf("did not execute correct pods: %s", diff)
+			}
+			var tests []string
+			for _, t := range step.(steps.SubtestReporter).SubTests() {
+				tests = append(tests, t.Name)
+			}
+			if diff := cmp.Diff(tc.expectedTests, tests); diff != "" {
+				t.Errorf("unexpected test cases: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunWhen(t *testing.T) {
+	value := "value"
+	for _, tc := range []struct {
+		name          string
+		when          string
+		overrides     api.TestEnvironment
+		expectedErr   bool
+		expectedPods  []string
+		expectedTests []string
+	}{{
+		name:         "condition on a test parameter is true",
+		when:         `PLATFORM == "gcp"`,
+		expectedPods: []string{"test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name:         "condition on a step parameter is true",
+		when:         `STEP_PARAM == "value" && !UNSET`,
+		expectedPods: []string{"test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name:         "condition on a step parameter overridden for the test is true",
+		when:         `STEP_PARAM == "overridden"`,
+		overrides:    api.TestEnvironment{"STEP_PARAM": "overridden"},
+		expectedPods: []string{"test-test0"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name: "condition is false, step is skipped",
+		when: `PLATFORM == "aws"`,
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name:        "invalid condition fails the step",
+		when:        `PLATFORM ==`,
+		expectedErr: true,
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace", Labels: map[string]string{"ci.openshift.io/multi-stage-test": "test"}}}
+			crclient := &testhelper_kube.FakePodExecutor{
+				LoggingClient: loggingclient.New(
+					fakectrlruntimeclient.NewClientBuilder().
+						WithIndex(&v1.Pod{}, "metadata.name", fakePodNameIndexer).
+						WithObjects(sa).
+						Build()),
+			}
+			jobSpec := api.JobSpec{
+				JobSpec: prowdapi.JobSpec{
+					Job:       "job",
+					BuildID:   "build_id",
+					ProwJobID: "prow_job_id",
+					Type:      prowapi.PeriodicJob,
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Second},
+						UtilityImages: &prowapi.UtilityImages{
+							Sidecar:    "sidecar",
+							Entrypoint: "entrypoint",
+						},
+					},
+				},
+			}
+			jobSpec.SetNamespace("test-namespace")
+			client := &testhelper_kube.FakePodClient{FakePodExecutor: crclient}
+			env := api.TestEnvironment{"PLATFORM": "gcp"}
+			for k, v := range tc.overrides {
+				env[k] = v
+			}
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{{
+						As:          "test0",
+						When:        tc.when,
+						Environment: []api.StepParameter{{Name: "STEP_PARAM", Default: &value}},
+					}},
+					Environment: env,
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "", nil, false)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedErr, err)
+			}
+			var pods []string
+			for _, pod := range crclient.CreatedPods {
+				pods = append(pods, pod.Name)
+			}
+			if diff := cmp.Diff(tc.expectedPods, pods); diff != "" {
+				t.Errorf("did not execute correct pods: %s", diff)
+			}
+			var tests []string
+			for _, t := range step.(steps.SubtestReporter).SubTests() {
+				tests = append(tests, t.Name)
+			}
+			if diff := cmp.Diff(tc.expectedTests, tests); diff != "" {
+				t.Errorf("unexpected test cases: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		failures      sets.Set[string]
+		expectedErr   bool
+		expectedPods  []string
+		expectedTests []string
+	}{{
+		name:         "all steps of the group are executed",
+		expectedPods: []string{"test-test0", "test-test1", "test-test2"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test - test-test1 container test",
+			"Run multi-stage test test - parallel group test0",
+			"Run multi-stage test test - test-test2 container test",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}, {
+		name:         "failure in the group does not stop other members, but later steps",
+		failures:     sets.New[string]("test-test0"),
+		expectedErr:  true,
+		expectedPods: []string{"test-test0", "test-test1"},
+		expectedTests: []string{
+			"Run multi-stage test pre phase",
+			"Run multi-stage test test - test-test0 container test",
+			"Run multi-stage test test - test-test1 container test",
+			"Run multi-stage test test - parallel group test0",
+			"Run multi-stage test test phase",
+			"Run multi-stage test post phase",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			sa := &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace", Labels: map[string]string{"ci.openshift.io/multi-stage-test": "test"}}}
+			crclient := &testhelper_kube.FakePodExecutor{
+				LoggingClient: loggingclient.New(
+					fakectrlruntimeclient.NewClientBuilder().
+						WithIndex(&v1.Pod{}, "metadata.name", fakePodNameIndexer).
+						WithObjects(sa).
+						Build()),
+				Failures: tc.failures,
+			}
+			jobSpec := api.JobSpec{
+				JobSpec: prowdapi.JobSpec{
+					Job:       "job",
+					BuildID:   "build_id",
+					ProwJobID: "prow_job_id",
+					Type:      prowapi.PeriodicJob,
+					DecorationConfig: &prowapi.DecorationConfig{
+						Timeout:     &prowapi.Duration{Duration: time.Minute},
+						GracePeriod: &prowapi.Duration{Duration: time.Second},
+						UtilityImages: &prowapi.UtilityImages{
+							Sidecar:    "sidecar",
+							Entrypoint: "entrypoint",
+						},
+					},
+				},
+			}
+			jobSpec.SetNamespace("test-namespace")
+			client := &testhelper_kube.FakePodClient{FakePodExecutor: crclient}
+			step := MultiStageTestStep(api.TestStepConfiguration{
+				As: "test",
+				MultiStageTestConfigurationLiteral: &api.MultiStageTestConfigurationLiteral{
+					Test: []api.LiteralTestStep{
+						{As: "test0", ParallelGroup: "test0"},
+						{As: "test1", ParallelGroup: "test0"},
+						{As: "test2"},
+					},
+				},
+			}, &api.ReleaseBuildConfiguration{}, nil, client, &jobSpec, nil, "node-name", "", nil, false)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Errorf("expected error: %t, got error: %v", tc.expectedErr, err)
+			}
+			var pods []string
+			for _, pod := range crclient.CreatedPods {
+				pods = append(pods, pod.Name)
+			}
+			sort.Strings(pods)
+			if diff := cmp.Diff(tc.expectedPods, pods); diff != "" {
+				t.Errorf("did not execute correct pods: %s", diff)
+			}
+			var tests []string
+			for _, t := range step.(steps.SubtestReporter).SubTests() {
+				tests = append(tests, t.Name)
+			}
+			if diff := cmp.Diff(tc.expectedTests, tests); diff != "" {
+				t.Errorf("unexpected test cases: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRunParallelStepsWithConcurrentTestCases(t *testing.T) {
+	s := &multiStageTestStep{name: "test", subLock: &sync.Mutex{}}
+	execution := func(name string, observed bool) stepExecution {
+		return stepExecution{
+			name: name,
+			attempt: func(_ context.Context, _ int, record testCaseRecorder) (*int32, error) {
+				if observed {
+					// an observer finishes while the group is executing
+					s.recordTestCases(&junit.TestCase{Name: "observer"})
+				}
+				record(&junit.TestCase{Name: name})
+				return nil, nil
+			},
+		}
+	}
+	if errs := s.runParallelSteps(context.Background(), "b", []stepExecution{execution("b", true), execution("a", false)}); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	var actual []string
+	for _, testCase := range s.subTests {
+		actual = append(actual, testCase.Name)
+	}
+	expected := []string{"observer", "a", "b", "Run multi-stage test test - parallel group b"}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("test cases: actual does not match expected, diff: %s", diff)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	failedPod := &v1.Pod{Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{
+		Name:  "test",
+		State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 3}},
+	}}}}
+	exitCode := testContainerExitCode(failedPod)
+	err := results.ForReason("step_failed").ForError(results.ForReason("pod_pending").ForError(errors.New("pod pending for too long")))
+	for _, tc := range []struct {
+		name     string
+		retry    api.StepRetry
+		expected bool
+	}{{
+		name:     "no conditions, every failure is retried",
+		retry:    api.StepRetry{Attempts: 2},
+		expected: true,
+	}, {
+		name:     "matching exit code",
+		retry:    api.StepRetry{Attempts: 2, ExitCodes: []int32{1, 3}},
+		expected: true,
+	}, {
+		name:  "different exit code",
+		retry: api.StepRetry{Attempts: 2, ExitCodes: []int32{1}},
+	}, {
+		name:     "matching nested reason",
+		retry:    api.StepRetry{Attempts: 2, Reasons: []string{"pod_pending"}},
+		expected: true,
+	}, {
+		name:  "different reason",
+		retry: api.StepRetry{Attempts: 2, Reasons: []string{"pod_evicted"}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := retryable(&tc.retry, exitCode, err); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
 func fakePodNameIndexer(object ctrlruntimeclient.Object) []string {
 	p, ok := object.(*v1.Pod)
 	if !ok {
@@ -89,26 +89,12 @@ func (s *podStep) run(ctx context.Context) error {
 	if !util.IsBitSet(s.config.WaitFlags, util.SkipLogs) {
 		logrus.Infof("Executing %s %s", s.name, s.config.As)
 	}
-	containerResources, err := ResourcesFor(s.resources.RequirementsForStep(s.config.As))
-	if err != nil {
-		return fmt.Errorf("unable to calculate %s pod resources for %s: %w", s.name, s.config.As, err)
-	}
-
-	if s.config.From.Namespace != "" {
-		return errors.New("pod step does not support an image stream tag reference outside the namespace")
-	}
-	image := fmt.Sprintf("%s:%s", s.config.From.Name, s.config.From.Tag)
-
-	pod, err := s.generatePodForStep(image, containerResources, s.config.Clone)
+	pod, err := s.pod()
 	if err != nil {
-		return fmt.Errorf("pod step was invalid: %w", err)
+		return err
 	}
 	testCaseNotifier := NewTestCaseNotifier(util.NopNotifier)
 
-	if owner := s.jobSpec.Owner(); owner != nil {
-		pod.OwnerReferences = append(pod.OwnerReferences, *owner)
-	}
-
 	go func() {
 		<-ctx.Done()
 		logrus.Infof("cleanup: Deleting %s pod %s", s.name, s.config.As)
@@ -131,6 +117,37 @@ func (s *podStep) run(ctx context.Context) error {
 	return nil
 }
 
+// pod builds the pod running the commands of the step.
+func (s *podStep) pod() (*coreapi.Pod, error) {
+	containerResources, err := ResourcesFor(s.resources.RequirementsForStep(s.config.As))
+	if err != nil {
+		return nil, fmt.Errorf("unable to calculate %s pod resources for %s: %w", s.name, s.config.As, err)
+	}
+
+	if s.config.From.Namespace != "" {
+		return nil, errors.New("pod step does not support an image stream tag reference outside the namespace")
+	}
+	image := fmt.Sprintf("%s:%s", s.config.From.Name, s.config.From.Tag)
+
+	pod, err := s.generatePodForStep(image, containerResources, s.config.Clone)
+	if err != nil {
+		return nil, fmt.Errorf("pod step was invalid: %w", err)
+	}
+	if owner := s.jobSpec.Owner(); owner != nil {
+		pod.OwnerReferences = append(pod.OwnerReferences, *owner)
+	}
+	return pod, nil
+}
+
+// DryRun renders the pod running the commands of the step.
+func (s *podStep) DryRun() (*DryRunOutput, error) {
+	pod, err := s.pod()
+	if err != nil {
+		return nil, err
+	}
+	return &DryRunOutput{Objects: []ctrlruntimeclient.Object{pod}}, nil
+}
+
 func (s *podStep) SubTests() []*junit.TestCase {
 	return s.subTests
 }
@@ -317,6 +334,7 @@ func (s *podStep) generatePodForStep(image string, containerResources coreapi.Re
 	}
 	pod.Spec.ServiceAccountName = s.config.ServiceAccountName
 	container := &pod.Spec.Containers[0]
+	container.Env = append(container.Env, decorate.KubeEnv(s.jobSpec.ShardEnv(s.config.As))...)
 	container.VolumeMounts = append(container.VolumeMounts, secretVolumeMounts...)
 	if s.clusterClaim != nil {
 		container.Env = append(container.Env, []coreapi.EnvVar{
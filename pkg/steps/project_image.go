@@ -76,6 +76,43 @@ func (s *projectDirectoryImageBuildStep) run(ctx context.Context) error {
 	return handleBuilds(ctx, s.client, s.podClient, *build, newImageBuildOptions(s.architectures.UnsortedList()))
 }
 
+// Resume reuses the image built before an interruption.
+func (s *projectDirectoryImageBuildStep) Resume(ctx context.Context, finished bool) (bool, error) {
+	if !finished {
+		return false, nil
+	}
+	return pipelineImageExists(ctx, s.client, s.jobSpec.Namespace(), s.config.To)
+}
+
+// DryRun renders the builds for the image. The working directory of the source
+// image and its digest are only known once the source has been built.
+func (s *projectDirectoryImageBuildStep) DryRun() (*DryRunOutput, error) {
+	_, images, err := imagesFor(s.config, func(string) (string, error) {
+		return DryRunPlaceholder, nil
+	}, s.releaseBuildConfig.IsBundleImage)
+	if err != nil {
+		return nil, err
+	}
+	build := buildFromSource(
+		s.jobSpec, s.config.From, s.config.To,
+		buildapi.BuildSource{
+			Type:       buildapi.BuildSourceImage,
+			Dockerfile: s.config.DockerfileLiteral,
+			Images:     images,
+		},
+		DryRunPlaceholder,
+		s.config.DockerfilePath,
+		s.resources,
+		s.pullSecret,
+		s.config.BuildArgs,
+		s.config.Ref,
+	)
+	if s.config.IsBundleImage() {
+		return dryRunBuilds([]buildapi.Build{*build}), nil
+	}
+	return dryRunBuilds(constructMultiArchBuilds(*build, sets.List(s.architectures))), nil
+}
+
 type workingDir func(tag string) (string, error)
 type isBundleImage func(tag string) bool
 
@@ -0,0 +1,264 @@
+package steps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	coreapi "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+const (
+	// ProgressConfigMap is the name of the ConfigMap in the test namespace
+	// recording the steps that finished successfully, so that an execution
+	// which was interrupted can be resumed in the same namespace.
+	ProgressConfigMap = "ci-operator-progress"
+	// progressKey holds the finished steps as a JSON object, as the names of
+	// steps are not valid keys in a ConfigMap.
+	progressKey = "finished"
+	// leasesKey holds the resources leased by the steps as a JSON object, by
+	// step and environment variable of the lease.
+	leasesKey = "leases"
+)
+
+// Resumer may be implemented by steps that can pick up the work of an
+// execution that was interrupted in the same namespace.
+type Resumer interface {
+	// Resume determines whether the outputs of the step from the interrupted
+	// execution are still valid, in which case the step does not need to run
+	// again. Steps may also prepare to skip a part of their work when they
+	// run. finished is whether the step had finished successfully.
+	Resume(ctx context.Context, finished bool) (bool, error)
+}
+
+// LeaseResumer may be implemented by steps which lease resources, or which
+// wrap such steps.  The outputs of an interrupted execution, such as a
+// cluster, may only be valid with the resources it had leased, so those are
+// leased again when it is resumed.
+type LeaseResumer interface {
+	// ResumeLeases is called before the step runs with the resources leased
+	// by the interrupted execution, by the environment variable of their
+	// lease, and with a function recording the resources the step leases.
+	ResumeLeases(previous map[string][]string, record func(leased map[string][]string))
+}
+
+// Progress records the steps that finished successfully in a namespace.
+type Progress struct {
+	client    ctrlruntimeclient.Client
+	namespace string
+	lock      sync.Mutex
+}
+
+func NewProgress(client ctrlruntimeclient.Client, namespace string) *Progress {
+	return &Progress{client: client, namespace: namespace}
+}
+
+// Finished loads the steps that finished in an earlier execution and the time
+// at which they did.
+func (p *Progress) Finished(ctx context.Context) (map[string]time.Time, error) {
+	cm := &coreapi.ConfigMap{}
+	if err := p.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: p.namespace, Name: ProgressConfigMap}, cm); err != nil {
+		if kerrors.IsNotFound(err) {
+			return map[string]time.Time{}, nil
+		}
+		return nil, fmt.Errorf("could not get progress: %w", err)
+	}
+	return parseProgress(cm)
+}
+
+// Leases loads the resources leased by the steps of an earlier execution, by
+// step and environment variable of the lease.
+func (p *Progress) Leases(ctx context.Context) (map[string]map[string][]string, error) {
+	cm := &coreapi.ConfigMap{}
+	if err := p.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: p.namespace, Name: ProgressConfigMap}, cm); err != nil {
+		if kerrors.IsNotFound(err) {
+			return map[string]map[string][]string{}, nil
+		}
+		return nil, fmt.Errorf("could not get progress: %w", err)
+	}
+	return parseLeases(cm)
+}
+
+// Record marks the step as finished.
+func (p *Progress) Record(ctx context.Context, step string, at time.Time) error {
+	return p.update(ctx, func(cm *coreapi.ConfigMap) error {
+		finished, err := parseProgress(cm)
+		if err != nil {
+			return err
+		}
+		finished[step] = at
+		raw, err := json.Marshal(finished)
+		if err != nil {
+			return fmt.Errorf("could not marshal progress: %w", err)
+		}
+		cm.Data[progressKey] = string(raw)
+		return nil
+	})
+}
+
+// RecordLeases adds resources leased by the step, by the environment
+// variable of their lease.
+func (p *Progress) RecordLeases(ctx context.Context, step string, leased map[string][]string) error {
+	return p.update(ctx, func(cm *coreapi.ConfigMap) error {
+		leases, err := parseLeases(cm)
+		if err != nil {
+			return err
+		}
+		if leases[step] == nil {
+			leases[step] = map[string][]string{}
+		}
+		for env, names := range leased {
+			leases[step][env] = names
+		}
+		raw, err := json.Marshal(leases)
+		if err != nil {
+			return fmt.Errorf("could not marshal leases: %w", err)
+		}
+		cm.Data[leasesKey] = string(raw)
+		return nil
+	})
+}
+
+func (p *Progress) update(ctx context.Context, mutate func(cm *coreapi.ConfigMap) error) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &coreapi.ConfigMap{}
+		if err := p.client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: p.namespace, Name: ProgressConfigMap}, cm); err != nil {
+			if !kerrors.IsNotFound(err) {
+				return fmt.Errorf("could not get progress: %w", err)
+			}
+			cm = &coreapi.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: p.namespace, Name: ProgressConfigMap}}
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		if err := mutate(cm); err != nil {
+			return err
+		}
+		if cm.ResourceVersion == "" {
+			return p.client.Create(ctx, cm)
+		}
+		return p.client.Update(ctx, cm)
+	})
+}
+
+func parseProgress(cm *coreapi.ConfigMap) (map[string]time.Time, error) {
+	finished := map[string]time.Time{}
+	if raw, ok := cm.Data[progressKey]; ok {
+		if err := json.Unmarshal([]byte(raw), &finished); err != nil {
+			return nil, fmt.Errorf("could not parse progress: %w", err)
+		}
+	}
+	return finished, nil
+}
+
+func parseLeases(cm *coreapi.ConfigMap) (map[string]map[string][]string, error) {
+	leases := map[string]map[string][]string{}
+	if raw, ok := cm.Data[leasesKey]; ok {
+		if err := json.Unmarshal([]byte(raw), &leases); err != nil {
+			return nil, fmt.Errorf("could not parse leases: %w", err)
+		}
+	}
+	return leases, nil
+}
+
+// resumableStep records when the wrapped step finishes successfully and, when
+// resuming an interrupted execution, skips it if its outputs are still valid.
+type resumableStep struct {
+	wrapped  api.Step
+	progress *Progress
+	finished bool
+	leases   map[string][]string
+
+	skipped bool
+}
+
+// ResumableStep wraps a step to record its completion and the resources it
+// leases in the progress. The wrapped step is given the chance to reuse its
+// outputs from an interrupted execution, in which it had finished if finished
+// is set and had leased the resources in leases.
+func ResumableStep(wrapped api.Step, progress *Progress, finished bool, leases map[string][]string) api.Step {
+	return &resumableStep{wrapped: wrapped, progress: progress, finished: finished, leases: leases}
+}
+
+func (s *resumableStep) Inputs() (api.InputDefinition, error) { return s.wrapped.Inputs() }
+func (s *resumableStep) Validate() error                      { return s.wrapped.Validate() }
+func (s *resumableStep) Name() string                         { return s.wrapped.Name() }
+func (s *resumableStep) Description() string                  { return s.wrapped.Description() }
+func (s *resumableStep) Requires() []api.StepLink             { return s.wrapped.Requires() }
+func (s *resumableStep) Creates() []api.StepLink              { return s.wrapped.Creates() }
+func (s *resumableStep) Provides() api.ParameterMap           { return s.wrapped.Provides() }
+func (s *resumableStep) Objects() []ctrlruntimeclient.Object  { return s.wrapped.Objects() }
+
+func (s *resumableStep) SubTests() []*junit.TestCase {
+	if s.skipped {
+		return []*junit.TestCase{{
+			Name:        s.Description(),
+			SkipMessage: &junit.SkipMessage{Message: "The outputs of the step from an interrupted execution were reused."},
+		}}
+	}
+	if subTests, ok := s.wrapped.(SubtestReporter); ok {
+		return subTests.SubTests()
+	}
+	return nil
+}
+
+func (s *resumableStep) SubSteps() []api.CIOperatorStepDetailInfo {
+	if subSteps, ok := s.wrapped.(SubStepReporter); ok {
+		return subSteps.SubSteps()
+	}
+	return nil
+}
+
+func (s *resumableStep) Run(ctx context.Context) error {
+	if resumer, ok := s.wrapped.(LeaseResumer); ok {
+		resumer.ResumeLeases(s.leases, func(leased map[string][]string) {
+			if err := s.progress.RecordLeases(ctx, s.Name(), leased); err != nil {
+				logrus.WithError(err).Warnf("Could not record the resources leased by %s.", s.Name())
+			}
+		})
+	}
+	if resumer, ok := s.wrapped.(Resumer); ok {
+		if skip, err := resumer.Resume(ctx, s.finished); err != nil {
+			logrus.WithError(err).Warnf("Could not determine whether the outputs of %s can be reused, running it.", s.Name())
+		} else if skip {
+			logrus.Infof("Skipping %s, its outputs from an interrupted execution still exist.", s.Name())
+			s.skipped = true
+			return nil
+		}
+	}
+	if err := s.wrapped.Run(ctx); err != nil {
+		return err
+	}
+	if err := s.progress.Record(ctx, s.Name(), time.Now()); err != nil {
+		logrus.WithError(err).Warnf("Could not record that %s finished.", s.Name())
+	}
+	return nil
+}
+
+// pipelineImageExists determines whether a tag in the pipeline image stream of
+// the namespace holds an image.
+func pipelineImageExists(ctx context.Context, client ctrlruntimeclient.Client, namespace string, tag api.PipelineImageStreamTagReference) (bool, error) {
+	ist := &imagev1.ImageStreamTag{}
+	if err := client.Get(ctx, ctrlruntimeclient.ObjectKey{Namespace: namespace, Name: fmt.Sprintf("%s:%s", api.PipelineImageStream, tag)}, ist); err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("could not get image stream tag %s:%s: %w", api.PipelineImageStream, tag, err)
+	}
+	return ist.Image.Name != "", nil
+}
@@ -0,0 +1,212 @@
+package steps
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagev1 "github.com/openshift/api/image/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
+)
+
+type resumerStep struct {
+	stepNeedsLease
+	skip     bool
+	err      error
+	finished *bool
+}
+
+func (s *resumerStep) Resume(_ context.Context, finished bool) (bool, error) {
+	s.finished = &finished
+	return s.skip, s.err
+}
+
+func TestProgress(t *testing.T) {
+	progress := NewProgress(fakectrlruntimeclient.NewClientBuilder().Build(), "ns")
+	ctx := context.Background()
+	finished, err := progress.Finished(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]time.Time{}, finished); diff != "" {
+		t.Errorf("unexpected progress without a ConfigMap: %s", diff)
+	}
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	if err := progress.Record(ctx, "src", first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := progress.Record(ctx, "release:latest", second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	finished, err = progress.Finished(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]time.Time{"src": first, "release:latest": second}
+	if diff := cmp.Diff(expected, finished); diff != "" {
+		t.Errorf("unexpected progress: %s", diff)
+	}
+	if err := progress.RecordLeases(ctx, "e2e", map[string][]string{"LEASED_RESOURCE": {"us-east-1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := progress.RecordLeases(ctx, "e2e", map[string][]string{"IP_POOL_LEASED_RESOURCE": {"ip-1", "ip-2"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := progress.Record(ctx, "e2e", second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	leases, err := progress.Leases(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedLeases := map[string]map[string][]string{"e2e": {"LEASED_RESOURCE": {"us-east-1"}, "IP_POOL_LEASED_RESOURCE": {"ip-1", "ip-2"}}}
+	if diff := cmp.Diff(expectedLeases, leases); diff != "" {
+		t.Errorf("unexpected leases: %s", diff)
+	}
+}
+
+type leaseResumerStep struct {
+	stepNeedsLease
+	previous map[string][]string
+	leased   map[string][]string
+}
+
+func (s *leaseResumerStep) ResumeLeases(previous map[string][]string, record func(map[string][]string)) {
+	s.previous = previous
+	record(s.leased)
+}
+
+func TestResumableStepResumesLeases(t *testing.T) {
+	progress := NewProgress(fakectrlruntimeclient.NewClientBuilder().Build(), "ns")
+	previous := map[string][]string{"LEASED_RESOURCE": {"us-east-1"}}
+	wrapped := &leaseResumerStep{leased: map[string][]string{"LEASED_RESOURCE": {"us-west-2"}}}
+	step := ResumableStep(wrapped, progress, false, previous)
+	if err := step.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(previous, wrapped.previous); diff != "" {
+		t.Errorf("unexpected resources passed to the step: %s", diff)
+	}
+	leases, err := progress.Leases(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]map[string][]string{step.Name(): wrapped.leased}, leases); diff != "" {
+		t.Errorf("unexpected recorded leases: %s", diff)
+	}
+}
+
+func TestResumableStep(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		step             *resumerStep
+		finished         bool
+		expectedRan      bool
+		expectedFinished *bool
+		expectedRecorded bool
+		expectedSkipped  bool
+		expectedErr      bool
+	}{
+		{
+			name:             "resuming a step with outputs skips it",
+			step:             &resumerStep{skip: true},
+			finished:         true,
+			expectedFinished: func() *bool { b := true; return &b }(),
+			expectedSkipped:  true,
+		},
+		{
+			name:             "resuming a step without outputs runs it",
+			step:             &resumerStep{},
+			expectedRan:      true,
+			expectedFinished: func() *bool { b := false; return &b }(),
+			expectedRecorded: true,
+		},
+		{
+			name:             "failure to resume runs the step",
+			step:             &resumerStep{skip: true, err: errors.New("injected failure")},
+			finished:         true,
+			expectedRan:      true,
+			expectedFinished: func() *bool { b := true; return &b }(),
+			expectedRecorded: true,
+		},
+		{
+			name:             "failed step is not recorded",
+			step:             &resumerStep{stepNeedsLease: stepNeedsLease{fail: true}},
+			expectedRan:      true,
+			expectedFinished: func() *bool { b := false; return &b }(),
+			expectedErr:      true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			progress := NewProgress(fakectrlruntimeclient.NewClientBuilder().Build(), "ns")
+			step := ResumableStep(tc.step, progress, tc.finished, nil)
+			if err := step.Run(context.Background()); (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %t, got %v", tc.expectedErr, err)
+			}
+			if tc.step.ran != tc.expectedRan {
+				t.Errorf("expected ran to be %t, got %t", tc.expectedRan, tc.step.ran)
+			}
+			if diff := cmp.Diff(tc.expectedFinished, tc.step.finished); diff != "" {
+				t.Errorf("unexpected resumed state: %s", diff)
+			}
+			finished, err := progress.Finished(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, recorded := finished[step.Name()]; recorded != tc.expectedRecorded {
+				t.Errorf("expected recorded to be %t, got %t", tc.expectedRecorded, recorded)
+			}
+			subTests := step.(SubtestReporter).SubTests()
+			skipped := len(subTests) == 1 && subTests[0].SkipMessage != nil
+			if skipped != tc.expectedSkipped {
+				t.Errorf("expected skipped to be %t, got %t: %v", tc.expectedSkipped, skipped, subTests)
+			}
+		})
+	}
+}
+
+func TestResumableStepForwardsSubTests(t *testing.T) {
+	step := ResumableStep(&resumerStep{}, nil, false, nil)
+	if diff := cmp.Diff([]*junit.TestCase{{}}, step.(SubtestReporter).SubTests()); diff != "" {
+		t.Errorf("sub-tests not forwarded: %s", diff)
+	}
+}
+
+func TestPipelineImageExists(t *testing.T) {
+	client := fakectrlruntimeclient.NewClientBuilder().WithObjects(
+		&imagev1.ImageStreamTag{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline:src"},
+			Image:      imagev1.Image{ObjectMeta: metav1.ObjectMeta{Name: "sha256:abc"}},
+		},
+		&imagev1.ImageStreamTag{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline:bin"},
+		},
+	).Build()
+	for _, tc := range []struct {
+		tag      api.PipelineImageStreamTagReference
+		expected bool
+	}{
+		{tag: api.PipelineImageStreamTagReferenceSource, expected: true},
+		{tag: api.PipelineImageStreamTagReferenceBinaries},
+		{tag: api.PipelineImageStreamTagReferenceRPMs},
+	} {
+		t.Run(string(tc.tag), func(t *testing.T) {
+			exists, err := pipelineImageExists(context.Background(), client, "ns", tc.tag)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exists != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, exists)
+			}
+		})
+	}
+}
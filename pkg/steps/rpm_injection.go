@@ -47,12 +47,18 @@ func (s *rpmImageInjectionStep) run(ctx context.Context) error {
 		return fmt.Errorf("could not get Route for RPM server: %w", err)
 	}
 
-	dockerfile := rpmInjectionDockerfile(s.config.From, route.Spec.Host)
 	fromDigest, err := resolvePipelineImageStreamTagReference(ctx, s.client, s.config.From, s.jobSpec)
 	if err != nil {
 		return err
 	}
-	return handleBuilds(ctx, s.client, s.podClient, *buildFromSource(
+	return handleBuilds(ctx, s.client, s.podClient, *s.build(route.Spec.Host, fromDigest), newImageBuildOptions(s.architectures.UnsortedList()))
+}
+
+// build returns the build injecting a repository pointing at the RPM server on
+// the given host.
+func (s *rpmImageInjectionStep) build(host, fromDigest string) *buildapi.Build {
+	dockerfile := rpmInjectionDockerfile(s.config.From, host)
+	return buildFromSource(
 		s.jobSpec, s.config.From, s.config.To,
 		buildapi.BuildSource{
 			Type:       buildapi.BuildSourceDockerfile,
@@ -64,7 +70,15 @@ func (s *rpmImageInjectionStep) run(ctx context.Context) error {
 		s.pullSecret,
 		nil,
 		"",
-	), newImageBuildOptions(s.architectures.UnsortedList()))
+	)
+}
+
+// DryRun renders the builds injecting the RPM repository. The host of the RPM
+// server and the digest of the image the repository is injected into are only
+// known once both exist.
+func (s *rpmImageInjectionStep) DryRun() (*DryRunOutput, error) {
+	build := s.build(DryRunPlaceholder, DryRunPlaceholder)
+	return dryRunBuilds(constructMultiArchBuilds(*build, sets.List(s.architectures))), nil
 }
 
 func (s *rpmImageInjectionStep) Requires() []api.StepLink {
@@ -60,6 +60,26 @@ func (s *rpmServerStep) run(ctx context.Context) error {
 		return fmt.Errorf("could not find source ImageStreamTag for RPM repo deployment: %w", err)
 	}
 
+	deployment, service, route := s.objects(ist.Image.DockerImageReference)
+	if err := s.client.Create(ctx, deployment); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create RPM repo server deployment: %w", err)
+	}
+
+	if err := s.client.Create(ctx, service); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create RPM repo server service: %w", err)
+	}
+	if err := s.client.Create(ctx, route); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("could not create RPM repo server route: %w", err)
+	}
+	if err := waitForDeployment(ctx, ctrlruntimeclient.NewNamespacedClient(s.client, s.jobSpec.Namespace()), deployment.Name); err != nil {
+		return fmt.Errorf("could not wait for RPM repo server to deploy: %w", err)
+	}
+	return waitForRouteReachable(ctx, s.client, s.jobSpec.Namespace(), route.Name, "http")
+}
+
+// objects builds the deployment serving the RPMs from the given image and the
+// service and route exposing it.
+func (s *rpmServerStep) objects(image string) (*appsapi.Deployment, *coreapi.Service, *routev1.Route) {
 	labelSet := LabelsFor(s.jobSpec, map[string]string{AppLabel: RPMRepoName, TTLIgnoreLabel: "true"}, s.config.Ref)
 	selectorSet := map[string]string{
 		AppLabel: RPMRepoName,
@@ -101,7 +121,7 @@ func (s *rpmServerStep) run(ctx context.Context) error {
 				Spec: coreapi.PodSpec{
 					Containers: []coreapi.Container{{
 						Name:            RPMRepoName,
-						Image:           ist.Image.DockerImageReference,
+						Image:           image,
 						ImagePullPolicy: coreapi.PullAlways,
 
 						// SimpleHTTPServer is too simple - it can't handle threading. Use a threaded implementation
@@ -196,10 +216,6 @@ fi
 		deployment.OwnerReferences = append(deployment.OwnerReferences, *owner)
 	}
 
-	if err := s.client.Create(ctx, deployment); err != nil && !kerrors.IsAlreadyExists(err) {
-		return fmt.Errorf("could not create RPM repo server deployment: %w", err)
-	}
-
 	service := &coreapi.Service{
 		ObjectMeta: commonMeta,
 		Spec: coreapi.ServiceSpec{
@@ -215,9 +231,6 @@ fi
 		service.OwnerReferences = append(service.OwnerReferences, *owner)
 	}
 
-	if err := s.client.Create(ctx, service); err != nil && !kerrors.IsAlreadyExists(err) {
-		return fmt.Errorf("could not create RPM repo server service: %w", err)
-	}
 	route := &routev1.Route{
 		ObjectMeta: commonMeta,
 		Spec: routev1.RouteSpec{
@@ -233,13 +246,14 @@ fi
 		route.OwnerReferences = append(route.OwnerReferences, *owner)
 	}
 
-	if err := s.client.Create(ctx, route); err != nil && !kerrors.IsAlreadyExists(err) {
-		return fmt.Errorf("could not create RPM repo server route: %w", err)
-	}
-	if err := waitForDeployment(ctx, ctrlruntimeclient.NewNamespacedClient(s.client, s.jobSpec.Namespace()), deployment.Name); err != nil {
-		return fmt.Errorf("could not wait for RPM repo server to deploy: %w", err)
-	}
-	return waitForRouteReachable(ctx, s.client, s.jobSpec.Namespace(), route.Name, "http")
+	return deployment, service, route
+}
+
+// DryRun renders the objects serving the RPMs. The pull spec of the image the
+// RPMs are served from is only known once it has been built.
+func (s *rpmServerStep) DryRun() (*DryRunOutput, error) {
+	deployment, service, route := s.objects(DryRunPlaceholder)
+	return &DryRunOutput{Objects: []ctrlruntimeclient.Object{deployment, service, route}}, nil
 }
 
 func waitForDeployment(ctx context.Context, client ctrlruntimeclient.Client, name string) error {
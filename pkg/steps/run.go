@@ -6,6 +6,9 @@ import (
 	"sync"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
 	"github.com/openshift/ci-tools/pkg/api"
 	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/results"
@@ -19,7 +22,9 @@ type message struct {
 	stepDetails     api.CIOperatorStepDetails
 }
 
-func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
+// Run executes the steps of the graph, reporting each as a test case.  The
+// failures of steps are classified with the classifier, which may be nil.
+func Run(ctx context.Context, graph api.StepGraph, classifier *results.Classifier) (*junit.TestSuites, []api.CIOperatorStepDetails, []error) {
 	var seen []api.StepLink
 	executionResults := make(chan message)
 	done := make(chan bool)
@@ -57,8 +62,10 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 			testCase := &junit.TestCase{Name: out.node.Step.Description(), Duration: out.duration.Seconds()}
 			stepDetails = append(stepDetails, out.stepDetails)
 			if out.err != nil {
-				testCase.FailureOutput = &junit.FailureOutput{Output: out.err.Error()}
-				executionErrors = append(executionErrors, results.ForReason("step_failed").WithError(out.err).Errorf("step %s failed: %v", out.node.Step.Name(), out.err))
+				err := classifier.Classify(out.err)
+				testCase.FailureOutput = &junit.FailureOutput{Output: err.Error()}
+				testCase.Properties = classificationProperties(results.Classifications(err))
+				executionErrors = append(executionErrors, results.ForReason("step_failed").WithError(err).Errorf("step %s failed: %v", out.node.Step.Name(), err))
 			} else {
 				seen = append(seen, out.node.Step.Creates()...)
 				if !interrupted {
@@ -86,6 +93,9 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 			for _, test := range testCases {
 				switch {
 				case test.FailureOutput != nil:
+					if !hasClassifications(test) {
+						test.Properties = append(test.Properties, classificationProperties(classifyOutput(classifier, test.FailureOutput.Output))...)
+					}
 					suite.NumFailed++
 				case test.SkipMessage != nil:
 					suite.NumSkipped++
@@ -104,6 +114,35 @@ func Run(ctx context.Context, graph api.StepGraph) (*junit.TestSuites, []api.CIO
 	}
 }
 
+// classifyOutput determines the classifications of a failed test case which
+// was reported by a step, from its output.
+func classifyOutput(classifier *results.Classifier, output string) []string {
+	reasons := sets.New[string]()
+	for _, reason := range classifier.Match(results.Evidence{Source: results.SourceLog, Text: output}) {
+		reasons.Insert(string(reason))
+	}
+	return sets.List(reasons)
+}
+
+// classificationProperties records the classifications of a failure as
+// properties of its test case, as the message of the failure is its own.
+func classificationProperties(classifications []string) []*junit.TestSuiteProperty {
+	var ret []*junit.TestSuiteProperty
+	for _, classification := range classifications {
+		ret = append(ret, &junit.TestSuiteProperty{Name: results.ClassificationProperty, Value: classification})
+	}
+	return ret
+}
+
+func hasClassifications(test *junit.TestCase) bool {
+	for _, property := range test.Properties {
+		if property.Name == results.ClassificationProperty {
+			return true
+		}
+	}
+	return false
+}
+
 // SubtestReporter may be implemented by steps that can return an optional set of
 // additional JUnit tests to report to the cluster.
 type SubtestReporter interface {
@@ -116,6 +155,24 @@ type SubStepReporter interface {
 	SubSteps() []api.CIOperatorStepDetailInfo
 }
 
+// DryRunner may be implemented by steps that can determine what they would
+// create in the cluster without making any API calls.
+type DryRunner interface {
+	DryRun() (*DryRunOutput, error)
+}
+
+// DryRunOutput holds everything a step would create or request when executed.
+type DryRunOutput struct {
+	// Objects would be created in the cluster by the step.
+	Objects []ctrlruntimeclient.Object
+	// Leases would be acquired for the duration of the step.
+	Leases []api.StepLease
+}
+
+// DryRunPlaceholder stands in for values that can only be determined from the
+// state of the cluster while the step executes.
+const DryRunPlaceholder = "<determined-at-runtime>"
+
 func runStep(ctx context.Context, node *api.StepNode, out chan<- message) {
 	start := time.Now()
 	err := node.Step.Run(ctx)
@@ -14,6 +14,7 @@ import (
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/junit"
 	"github.com/openshift/ci-tools/pkg/results"
 )
 
@@ -290,7 +291,7 @@ func TestStepsRun(t *testing.T) {
 			if tc.cancelled {
 				cancel()
 			}
-			suites, _, errs := Run(ctx, api.BuildGraph(steps))
+			suites, _, errs := Run(ctx, api.BuildGraph(steps), nil)
 			if errs == nil && len(tc.errExpected) > 0 {
 				t.Error("got no error but expected one")
 			}
@@ -336,3 +337,33 @@ func TestStepsRun(t *testing.T) {
 		})
 	}
 }
+
+func TestStepsRunClassifiesFailures(t *testing.T) {
+	classifier, err := results.NewClassifier(results.DefaultClassifierConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := results.WithEvidence(errors.New("the pod failed"), results.Evidence{Source: results.SourceEvent, Text: "Error: ImagePullBackOff"})
+	steps := []api.Step{
+		&fakeStep{name: "pull", runErr: failure},
+		&fakeStep{name: "quota", runErr: errors.New("exceeded quota: compute")},
+	}
+	suites, _, errs := Run(context.Background(), api.BuildGraph(steps), classifier)
+	properties := map[string][]*junit.TestSuiteProperty{}
+	for _, test := range suites.Suites[0].TestCases {
+		if test.FailureOutput.Message != "" {
+			t.Errorf("expected the failure message of %s to be left alone, got %q", test.Name, test.FailureOutput.Message)
+		}
+		properties[test.Name] = test.Properties
+	}
+	expected := map[string][]*junit.TestSuiteProperty{
+		"pull":  {{Name: "classification", Value: "infra:image-pull"}},
+		"quota": {{Name: "classification", Value: "infra:quota"}},
+	}
+	if diff := cmp.Diff(expected, properties); diff != "" {
+		t.Errorf("unexpected classifications in junit: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"infra:image-pull", "infra:quota"}, results.Classifications(errs...)); diff != "" {
+		t.Errorf("unexpected classifications of errors: %s", diff)
+	}
+}
@@ -199,6 +199,35 @@ func (s *sourceStep) run(ctx context.Context) error {
 	)
 }
 
+// Resume reuses the source image built before an interruption.
+func (s *sourceStep) Resume(ctx context.Context, finished bool) (bool, error) {
+	if !finished {
+		return false, nil
+	}
+	return pipelineImageExists(ctx, s.client, s.jobSpec.Namespace(), s.config.To)
+}
+
+// DryRun renders the builds cloning the source. The clonerefs image and the
+// digest of the image the source is built on are resolved from the cluster at
+// runtime, so they are not known ahead of time.
+func (s *sourceStep) DryRun() (*DryRunOutput, error) {
+	clonerefsRef := corev1.ObjectReference{
+		Kind:      "ImageStreamTag",
+		Namespace: s.config.ClonerefsImage.Namespace,
+		Name:      fmt.Sprintf("%s:%s", s.config.ClonerefsImage.Name, s.config.ClonerefsImage.Tag),
+	}
+	build := createBuild(s.config, s.jobSpec, clonerefsRef, s.resources, s.cloneAuthConfig, s.pullSecret, DryRunPlaceholder)
+	return dryRunBuilds(constructMultiArchBuilds(*build, sets.List(s.architectures))), nil
+}
+
+func dryRunBuilds(builds []buildapi.Build) *DryRunOutput {
+	ret := &DryRunOutput{}
+	for i := range builds {
+		ret.Objects = append(ret.Objects, &builds[i])
+	}
+	return ret
+}
+
 func createBuild(config api.SourceStepConfiguration, jobSpec *api.JobSpec, clonerefsRef corev1.ObjectReference, resources api.ResourceConfiguration, cloneAuthConfig *CloneAuthConfig, pullSecret *corev1.Secret, fromDigest string) *buildapi.Build {
 	var refs []prowv1.Refs
 	if jobSpec.Refs != nil {
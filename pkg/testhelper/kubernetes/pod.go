@@ -24,9 +24,12 @@ import (
 
 type FakePodExecutor struct {
 	loggingclient.LoggingClient
-	Failures    sets.Set[string]
-	CreatedPods []*coreapi.Pod
-	lock        sync.Mutex
+	Failures sets.Set[string]
+	// TransientFailures holds the number of times a pod fails before
+	// succeeding when it is created again.
+	TransientFailures map[string]int
+	CreatedPods       []*coreapi.Pod
+	lock              sync.Mutex
 }
 
 func (f *FakePodExecutor) Create(ctx context.Context, o ctrlruntimeclient.Object, opts ...ctrlruntimeclient.CreateOption) error {
@@ -69,7 +72,7 @@ func (f *FakePodExecutor) Watch(ctx context.Context, list ctrlruntimeclient.Obje
 }
 
 func (f *FakePodExecutor) process(pod *coreapi.Pod) {
-	fail := f.Failures.Has(pod.Name)
+	fail := f.Failures.Has(pod.Name) || f.transientFailure(pod.Name)
 	if fail {
 		pod.Status.Phase = coreapi.PodFailed
 	} else {
@@ -86,6 +89,22 @@ func (f *FakePodExecutor) process(pod *coreapi.Pod) {
 	}
 }
 
+func (f *FakePodExecutor) transientFailure(name string) bool {
+	failures, ok := f.TransientFailures[name]
+	if !ok {
+		return false
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var attempts int
+	for _, pod := range f.CreatedPods {
+		if pod.Name == name {
+			attempts++
+		}
+	}
+	return attempts <= failures
+}
+
 // The fake client version we use (v0.12.3) does not implement field selectors.
 func filter(list ctrlruntimeclient.ObjectList, opts ...ctrlruntimeclient.ListOption) {
 	var o ctrlruntimeclient.ListOptions
@@ -0,0 +1,256 @@
+// Package trace renders the timeline of a ci-operator execution in the trace
+// event format understood by chrome://tracing, Perfetto and similar tools:
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	buildapi "github.com/openshift/api/build/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/steps"
+)
+
+// Filename is the name of the artifact holding the trace.
+const Filename = "ci-operator-trace.json"
+
+// Categories of the events in the trace.
+const (
+	// CategoryExecution spans the whole execution and its setup.
+	CategoryExecution = "execution"
+	// CategoryStep spans the execution of a step in the graph.
+	CategoryStep = "step"
+	// CategorySubStep spans a part of a step, like a multi-stage test pod
+	// or the acquisition of leases.
+	CategorySubStep = "substep"
+	// CategoryInputs spans the time a step was blocked on its inputs.
+	CategoryInputs = "inputs"
+	// CategoryPending spans the time a Pod or a Build waited to start.
+	CategoryPending = "pending"
+	// CategoryBuild spans the time an image was being built.
+	CategoryBuild = "build"
+)
+
+const (
+	phaseComplete = "X"
+	phaseMetadata = "M"
+
+	// processID is the only process in the trace, as ci-operator is one
+	processID = 1
+	// executionThreadID holds the events for the execution itself, steps
+	// are numbered after it
+	executionThreadID = 0
+)
+
+// Event is an entry in the trace.
+type Event struct {
+	Name     string `json:"name"`
+	Category string `json:"cat,omitempty"`
+	Phase    string `json:"ph"`
+	// Timestamp is the start of the event in microseconds since the
+	// beginning of the execution.
+	Timestamp int64 `json:"ts"`
+	// Duration is the length of the event in microseconds.
+	Duration  int64             `json:"dur,omitempty"`
+	ProcessID int               `json:"pid"`
+	ThreadID  int               `json:"tid"`
+	Args      map[string]string `json:"args,omitempty"`
+}
+
+// Trace is the top-level object of the trace format.
+type Trace struct {
+	TraceEvents     []Event `json:"traceEvents"`
+	DisplayTimeUnit string  `json:"displayTimeUnit,omitempty"`
+}
+
+// Execution holds everything that is known about an execution of ci-operator
+// once it has finished.
+type Execution struct {
+	// Start is the time at which ci-operator started.
+	Start time.Time
+	// GraphStart is the time at which the execution of the graph started.
+	GraphStart time.Time
+	// End is the time at which ci-operator finished.
+	End time.Time
+	// Steps are the details of all steps in the graph.
+	Steps api.CIOperatorStepGraph
+	// Pods and Builds are the objects in the test namespace, from which the
+	// time spent pending and building is determined.
+	Pods   []corev1.Pod
+	Builds []buildapi.Build
+}
+
+// Trace determines the timeline of the execution.
+func (e *Execution) Trace() *Trace {
+	t := &Trace{TraceEvents: []Event{}, DisplayTimeUnit: "ms"}
+	t.metadata(executionThreadID, "ci-operator")
+	t.span(executionThreadID, "ci-operator", CategoryExecution, e.Start, e.End, e.Start, nil)
+	if !e.GraphStart.IsZero() {
+		t.span(executionThreadID, "setup", CategoryExecution, e.Start, e.GraphStart, e.Start, nil)
+	}
+
+	var ordered []api.CIOperatorStepDetails
+	for _, step := range e.Steps {
+		// steps that never ran have no place in the timeline
+		if step.StartedAt != nil && step.FinishedAt != nil {
+			ordered = append(ordered, step)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].StartedAt.Before(*ordered[j].StartedAt)
+	})
+	threads := map[string]int{}
+	finished := map[string]time.Time{}
+	for i, step := range ordered {
+		threads[step.StepName] = i + 1
+		for _, subStep := range step.Substeps {
+			threads[subStep.StepName] = i + 1
+		}
+		finished[step.StepName] = *step.FinishedAt
+	}
+
+	for _, step := range ordered {
+		thread := threads[step.StepName]
+		t.metadata(thread, step.StepName)
+		if !e.GraphStart.IsZero() && step.StartedAt.After(e.GraphStart) {
+			var blockedOn string
+			var last time.Time
+			for _, dependency := range step.Dependencies {
+				if finishedAt := finished[dependency]; finishedAt.After(last) {
+					blockedOn, last = dependency, finishedAt
+				}
+			}
+			var args map[string]string
+			if blockedOn != "" {
+				args = map[string]string{"blocked_on": blockedOn}
+			}
+			t.span(thread, "waiting for inputs", CategoryInputs, e.GraphStart, *step.StartedAt, e.Start, args)
+		}
+		t.span(thread, step.StepName, CategoryStep, *step.StartedAt, *step.FinishedAt, e.Start, detailArgs(step.CIOperatorStepDetailInfo))
+		for _, subStep := range step.Substeps {
+			if subStep.StartedAt == nil || subStep.FinishedAt == nil {
+				continue
+			}
+			t.span(thread, subStep.StepName, CategorySubStep, *subStep.StartedAt, *subStep.FinishedAt, e.Start, detailArgs(subStep))
+		}
+	}
+
+	for _, pod := range e.Pods {
+		if _, isBuildPod := pod.Annotations[buildapi.BuildAnnotation]; isBuildPod {
+			// builds are traced from the Build objects
+			continue
+		}
+		thread, ok := threads[pod.Name]
+		if !ok {
+			continue
+		}
+		if started := podStarted(pod); !started.IsZero() {
+			t.span(thread, pod.Name, CategoryPending, pod.CreationTimestamp.Time, started, e.Start, map[string]string{"pod": pod.Name})
+		}
+	}
+
+	for _, build := range e.Builds {
+		thread, ok := threads[build.Name]
+		if !ok {
+			thread, ok = threads[build.Labels[steps.CreatesLabel]]
+		}
+		if !ok || build.Status.StartTimestamp == nil {
+			continue
+		}
+		args := map[string]string{"build": build.Name}
+		t.span(thread, build.Name, CategoryPending, build.CreationTimestamp.Time, build.Status.StartTimestamp.Time, e.Start, args)
+		if build.Status.CompletionTimestamp != nil {
+			t.span(thread, build.Name, CategoryBuild, build.Status.StartTimestamp.Time, build.Status.CompletionTimestamp.Time, e.Start, args)
+		}
+	}
+	return t
+}
+
+// LoadDurations reads a trace from a file and determines how long each step
+// took to execute.
+func LoadDurations(path string) (map[string]time.Duration, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trace: %w", err)
+	}
+	var t Trace
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse trace: %w", err)
+	}
+	durations := map[string]time.Duration{}
+	for _, event := range t.TraceEvents {
+		if event.Category == CategoryStep {
+			durations[event.Name] = time.Duration(event.Duration) * time.Microsecond
+		}
+	}
+	return durations, nil
+}
+
+// metadata names the row for a thread.
+func (t *Trace) metadata(thread int, name string) {
+	t.TraceEvents = append(t.TraceEvents, Event{
+		Name:      "thread_name",
+		Phase:     phaseMetadata,
+		ProcessID: processID,
+		ThreadID:  thread,
+		Args:      map[string]string{"name": name},
+	})
+}
+
+func (t *Trace) span(thread int, name, category string, start, end, origin time.Time, args map[string]string) {
+	if end.Before(start) {
+		return
+	}
+	t.TraceEvents = append(t.TraceEvents, Event{
+		Name:      name,
+		Category:  category,
+		Phase:     phaseComplete,
+		Timestamp: start.Sub(origin).Microseconds(),
+		Duration:  end.Sub(start).Microseconds(),
+		ProcessID: processID,
+		ThreadID:  thread,
+		Args:      args,
+	})
+}
+
+func detailArgs(info api.CIOperatorStepDetailInfo) map[string]string {
+	args := map[string]string{}
+	if info.Description != "" {
+		args["description"] = info.Description
+	}
+	if info.Failed != nil && *info.Failed {
+		args["failed"] = "true"
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return args
+}
+
+// podStarted determines when the first container of the Pod started running,
+// which is when the Pod stopped pending.
+func podStarted(pod corev1.Pod) time.Time {
+	var started time.Time
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		var at time.Time
+		switch {
+		case status.State.Running != nil:
+			at = status.State.Running.StartedAt.Time
+		case status.State.Terminated != nil:
+			at = status.State.Terminated.StartedAt.Time
+		case status.LastTerminationState.Terminated != nil:
+			at = status.LastTerminationState.Terminated.StartedAt.Time
+		}
+		if !at.IsZero() && (started.IsZero() || at.Before(started)) {
+			started = at
+		}
+	}
+	return started
+}
@@ -0,0 +1,121 @@
+package trace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	buildapi "github.com/openshift/api/build/v1"
+
+	"github.com/openshift/ci-tools/pkg/api"
+	"github.com/openshift/ci-tools/pkg/testhelper"
+)
+
+func TestTrace(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+	failed := true
+	execution := Execution{
+		Start:      start,
+		GraphStart: at(5),
+		End:        at(120),
+		Steps: api.CIOperatorStepGraph{
+			{
+				CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{
+					StepName:     "e2e",
+					Description:  "Run multi-stage test e2e",
+					Dependencies: []string{"src", "release:latest"},
+					StartedAt:    ptr(at(30)),
+					FinishedAt:   ptr(at(115)),
+					Failed:       &failed,
+				},
+				Substeps: []api.CIOperatorStepDetailInfo{
+					{StepName: "e2e-lease", Description: "Acquire leases for test e2e", StartedAt: ptr(at(30)), FinishedAt: ptr(at(50))},
+					{StepName: "e2e-test", Description: "Run pod e2e-test", StartedAt: ptr(at(50)), FinishedAt: ptr(at(115)), Failed: &failed},
+				},
+			},
+			{
+				CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{
+					StepName:   "src",
+					StartedAt:  ptr(at(5)),
+					FinishedAt: ptr(at(20)),
+				},
+			},
+			{
+				CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{
+					StepName:   "release:latest",
+					StartedAt:  ptr(at(5)),
+					FinishedAt: ptr(at(30)),
+				},
+			},
+			{
+				CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{
+					StepName: "never-ran",
+				},
+			},
+		},
+		Pods: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "e2e-test", CreationTimestamp: metav1.NewTime(at(50))},
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{StartedAt: metav1.NewTime(at(60))}}}},
+					ContainerStatuses:     []corev1.ContainerStatus{{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{StartedAt: metav1.NewTime(at(61))}}}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "src-build", Annotations: map[string]string{buildapi.BuildAnnotation: "src"}, CreationTimestamp: metav1.NewTime(at(6))},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "unrelated", CreationTimestamp: metav1.NewTime(at(6))},
+			},
+		},
+		Builds: []buildapi.Build{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "src-amd64", Labels: map[string]string{"creates": "src"}, CreationTimestamp: metav1.NewTime(at(5))},
+				Status: buildapi.BuildStatus{
+					StartTimestamp:      &metav1.Time{Time: at(8)},
+					CompletionTimestamp: &metav1.Time{Time: at(19)},
+				},
+			},
+		},
+	}
+	testhelper.CompareWithFixture(t, execution.Trace())
+}
+
+func TestLoadDurations(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	finished := start.Add(90 * time.Second)
+	execution := Execution{
+		Start: start,
+		End:   finished,
+		Steps: api.CIOperatorStepGraph{{
+			CIOperatorStepDetailInfo: api.CIOperatorStepDetailInfo{StepName: "src", StartedAt: &start, FinishedAt: &finished},
+			Substeps:                 []api.CIOperatorStepDetailInfo{{StepName: "src-build", StartedAt: &start, FinishedAt: &finished}},
+		}},
+	}
+	raw, err := json.Marshal(execution.Trace())
+	if err != nil {
+		t.Fatalf("failed to marshal trace: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), Filename)
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatalf("failed to write trace: %v", err)
+	}
+	durations, err := LoadDurations(path)
+	if err != nil {
+		t.Fatalf("failed to load durations: %v", err)
+	}
+	if diff := cmp.Diff(map[string]time.Duration{"src": 90 * time.Second}, durations); diff != "" {
+		t.Errorf("unexpected durations: %s", diff)
+	}
+}
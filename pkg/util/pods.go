@@ -20,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -155,13 +156,20 @@ func WaitForPodCompletion(ctx context.Context, podClient kubernetes.PodClient, n
 
 func waitForPodCompletionOrTimeout(ctx context.Context, podClient kubernetes.PodClient, namespace, name string, completed map[string]time.Time, notifier ContainerNotifier, flags WaitForPodFlag) (*corev1.Pod, error) {
 	var ret atomic.Pointer[corev1.Pod]
+	// the tails of the logs of failed containers, to classify the failure
+	logs := map[string]string{}
 	var eg *errgroup.Group
 	eg, ctx = errgroup.WithContext(ctx)
 	pendingCtx, cancel := context.WithCancel(ctx)
 	pendingCheck := func() error {
 		timeout := podClient.GetPendingTimeout()
 		if pod, err := checkPendingPeriodic(pendingCtx.Done(), timeout, &ret); err != nil {
-			err = fmt.Errorf("pod pending for more than %s: %w: %s\n%s", timeout, err, getReasonsForUnreadyContainers(pod), getEventsForPod(ctx, pod, podClient))
+			reasons, events := getReasonsForUnreadyContainers(pod), getEventsForPod(ctx, pod, podClient)
+			err = results.WithEvidence(
+				fmt.Errorf("pod pending for more than %s: %w: %s\n%s", timeout, err, reasons, events),
+				results.Evidence{Source: results.SourceTerminationMessage, Text: reasons},
+				results.Evidence{Source: results.SourceEvent, Text: events},
+			)
 			logrus.Info(err)
 			notifier.Complete(pod.Name)
 			return err
@@ -177,7 +185,7 @@ func waitForPodCompletionOrTimeout(ctx context.Context, podClient kubernetes.Pod
 			if ret.Swap(pod) == nil {
 				eg.Go(pendingCheck)
 			}
-			return processPodEvent(ctx, podClient, completed, notifier, flags, pod)
+			return processPodEvent(ctx, podClient, completed, logs, notifier, flags, pod)
 		}, 0); err != nil {
 			if errors.Is(err, wait.ErrWaitTimeout) {
 				err = ctx.Err()
@@ -198,6 +206,7 @@ func processPodEvent(
 	ctx context.Context,
 	podClient kubernetes.PodClient,
 	completed map[string]time.Time,
+	logs map[string]string,
 	notifier ContainerNotifier,
 	flags WaitForPodFlag,
 	pod *corev1.Pod,
@@ -205,18 +214,53 @@ func processPodEvent(
 	if pod.Spec.RestartPolicy == corev1.RestartPolicyAlways {
 		return true, nil
 	}
-	podLogNewFailedContainers(podClient, pod, completed, notifier)
+	podLogNewFailedContainers(podClient, pod, completed, logs, notifier)
 	podLogDeletion(ctx, podClient, flags, *pod)
 	if podJobIsOK(pod) {
 		logrus.Debugf("Pod %s succeeded after %s", pod.Name, podDuration(pod).Truncate(time.Second))
 		return true, nil
 	}
 	if podJobIsFailed(pod) {
-		return true, AppendLogToError(fmt.Errorf("the pod %s/%s failed after %s (failed containers: %s): %s", pod.Namespace, pod.Name, podDuration(pod).Truncate(time.Second), strings.Join(failedContainerNames(pod), ", "), podReason(pod)), podMessages(pod))
+		messages := podMessages(pod)
+		err := AppendLogToError(fmt.Errorf("the pod %s/%s failed after %s (failed containers: %s): %s", pod.Namespace, pod.Name, podDuration(pod).Truncate(time.Second), strings.Join(failedContainerNames(pod), ", "), podReason(pod)), messages)
+		evidence := []results.Evidence{{Source: results.SourceTerminationMessage, Text: messages}}
+		for _, name := range sets.List(sets.KeySet(logs)) {
+			evidence = append(evidence, results.Evidence{Source: results.SourceLog, Text: logs[name]})
+		}
+		if failedOutsideContainers(pod) {
+			evidence = append(evidence, results.Evidence{Source: results.SourceEvent, Text: getEventsForPod(ctx, pod, podClient)})
+		}
+		return true, results.WithEvidence(err, evidence...)
 	}
 	return false, nil
 }
 
+// failedOutsideContainers determines whether a failure of the pod is not
+// explained by its containers exiting with an error, so that the events of
+// the pod may tell why it failed.
+func failedOutsideContainers(pod *corev1.Pod) bool {
+	if pod.Status.Reason != "" {
+		return true
+	}
+	for _, status := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		if state := status.State.Terminated; state != nil && state.ExitCode != 0 && (state.Reason == "Error" || state.Reason == "OOMKilled") {
+			return false
+		}
+	}
+	return true
+}
+
+// logTailBytes is how much of the end of the log of a failed container is
+// kept to classify the failure.
+const logTailBytes = 64 * 1024
+
+func logTail(log []byte) string {
+	if len(log) > logTailBytes {
+		log = log[len(log)-logTailBytes:]
+	}
+	return string(log)
+}
+
 // podReason returns the pod's reason and message for exit or tries to find one from the pod.
 func podReason(pod *corev1.Pod) string {
 	reason := pod.Status.Reason
@@ -474,7 +518,9 @@ func containerNamesInState(pod corev1.Pod, p func(corev1.ContainerStatus) bool)
 	return names
 }
 
-func podLogNewFailedContainers(podClient kubernetes.PodClient, pod *corev1.Pod, completed map[string]time.Time, notifier ContainerNotifier) {
+// podLogNewFailedContainers prints the logs of containers which failed since
+// the last call and keeps their tails in logs.
+func podLogNewFailedContainers(podClient kubernetes.PodClient, pod *corev1.Pod, completed map[string]time.Time, logs map[string]string, notifier ContainerNotifier) {
 	var statuses []corev1.ContainerStatus
 	statuses = append(statuses, pod.Status.InitContainerStatuses...)
 	statuses = append(statuses, pod.Status.ContainerStatuses...)
@@ -498,15 +544,16 @@ func podLogNewFailedContainers(podClient kubernetes.PodClient, pod *corev1.Pod,
 		if s, err := podClient.GetLogs(pod.Namespace, pod.Name, &corev1.PodLogOptions{
 			Container: status.Name,
 		}).Stream(context.TODO()); err == nil {
-			logs := &bytes.Buffer{}
-			if _, err := io.Copy(logs, s); err != nil {
+			output := &bytes.Buffer{}
+			if _, err := io.Copy(output, s); err != nil {
 				logrus.WithError(err).Warnf("Unable to copy log output from failed pod container %s.", status.Name)
 			}
 			if err := s.Close(); err != nil {
 				logrus.WithError(err).Warnf("Unable to close log output from failed pod container %s.", status.Name)
 			}
 			logrus.Infof("Logs for container %s in pod %s:", status.Name, pod.Name)
-			logrus.Info(logs.String())
+			logrus.Info(output.String())
+			logs[status.Name] = logTail(output.Bytes())
 		} else {
 			logrus.WithError(err).Warnf("error: Unable to retrieve logs from failed pod container %s.", status.Name)
 		}
@@ -235,3 +235,40 @@ func TestCheckPending(t *testing.T) {
 		})
 	}
 }
+
+func TestFailedOutsideContainers(t *testing.T) {
+	terminated := func(reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name: "test",
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: reason},
+			},
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		status   corev1.PodStatus
+		expected bool
+	}{{
+		name:   "container exited with an error",
+		status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{terminated("Error")}},
+	}, {
+		name:   "container was killed for using too much memory",
+		status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{terminated("OOMKilled")}},
+	}, {
+		name:     "container could not run",
+		status:   corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{terminated("ContainerCannotRun")}},
+		expected: true,
+	}, {
+		name:     "pod was evicted",
+		status:   corev1.PodStatus{Reason: "Evicted", ContainerStatuses: []corev1.ContainerStatus{terminated("Error")}},
+		expected: true,
+	}, {
+		name:     "no container terminated",
+		expected: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			testhelper.Diff(t, "failed outside containers", failedOutsideContainers(&corev1.Pod{Status: tc.status}), tc.expected)
+		})
+	}
+}
@@ -623,6 +623,7 @@ func (v *Validator) validateTestConfigurationType(
 		for i, s := range testConfig.Post {
 			validationErrors = append(validationErrors, v.validateLiteralTestStep(context.addField("post").addIndex(i), testStagePost, s, claimRelease)...)
 		}
+		validationErrors = append(validationErrors, validateParallelGroups(context, testConfig)...)
 	}
 	if typeCount == 0 {
 		validationErrors = append(validationErrors, fmt.Errorf("%s has no type, you may want to specify 'container' for a container based test", fieldRoot))
@@ -647,21 +648,31 @@ func (v *Validator) validateTestSteps(context *context, stage testStage, steps [
 		if s.LiteralTestStep != nil {
 			ret = append(ret, v.validateLiteralTestStep(contextI, stage, *s.LiteralTestStep, claimRelease)...)
 		}
+		if s.Parallel != nil {
+			ret = append(ret, v.validateTestSteps(contextI.addField("parallel"), stage, s.ParallelSteps(), claimRelease)...)
+		}
 	}
 	return
 }
 
 func validateTestStep(context *context, step api.TestStep) (ret []error) {
-	if (step.LiteralTestStep != nil && step.Reference != nil) ||
-		(step.LiteralTestStep != nil && step.Chain != nil) ||
-		(step.Reference != nil && step.Chain != nil) {
-		ret = append(ret, context.errorf("only one of `ref`, `chain`, or a literal test step can be set"))
+	var set int
+	for _, isSet := range []bool{step.LiteralTestStep != nil, step.Reference != nil, step.Chain != nil, step.Parallel != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set > 1 {
+		ret = append(ret, context.errorf("only one of `ref`, `chain`, `parallel`, or a literal test step can be set"))
 		return
 	}
-	if step.LiteralTestStep == nil && step.Reference == nil && step.Chain == nil {
-		ret = append(ret, context.errorf("a reference, chain, or literal test step is required"))
+	if set == 0 {
+		ret = append(ret, context.errorf("a reference, chain, parallel group, or literal test step is required"))
 		return
 	}
+	if step.Parallel != nil && len(step.Parallel) == 0 {
+		ret = append(ret, context.addField("parallel").errorf("must contain at least one step"))
+	}
 	if step.Reference != nil {
 		if len(*step.Reference) == 0 {
 			ret = append(ret, context.addField("ref").errorf("length cannot be 0"))
@@ -680,6 +691,11 @@ func validateTestStep(context *context, step api.TestStep) (ret []error) {
 			context.namesSeen.Insert(*step.Chain)
 		}
 	}
+	if step.When != "" {
+		if err := api.ValidateWhen(step.When); err != nil {
+			ret = append(ret, context.addField("when").errorf("%v", err))
+		}
+	}
 	return
 }
 
@@ -707,6 +723,14 @@ func (v *Validator) validateLiteralTestStep(context *context, stage testStage, s
 	if step.BestEffort != nil && *step.BestEffort && step.Timeout == nil {
 		ret = append(ret, fmt.Errorf("test %s contains best_effort without timeout", step.As))
 	}
+	if step.Retry != nil {
+		ret = append(ret, validateStepRetry(context.addField("retry"), *step.Retry)...)
+	}
+	if step.When != "" {
+		if err := api.ValidateWhen(step.When); err != nil {
+			ret = append(ret, context.addField("when").errorf("%v", err))
+		}
+	}
 
 	ret = append(ret, validateResourceRequirements(string(context.field)+".resources", step.Resources)...)
 	ret = append(ret, validateCredentials(string(context.field), step.Credentials)...)
@@ -731,6 +755,58 @@ func (v *Validator) validateLiteralTestStep(context *context, stage testStage, s
 	return ret
 }
 
+// validateParallelGroups verifies that the members of each parallel group are
+// consecutive steps of a single phase which agree on being best-effort: the
+// failure of a group is that of its members.
+func validateParallelGroups(context *context, config *api.MultiStageTestConfigurationLiteral) (ret []error) {
+	phases := map[string]string{}
+	bestEffort := map[string]bool{}
+	for _, phase := range []struct {
+		name  string
+		steps []api.LiteralTestStep
+	}{{name: "pre", steps: config.Pre}, {name: "test", steps: config.Test}, {name: "post", steps: config.Post}} {
+		var previous string
+		for i, step := range phase.steps {
+			group := step.ParallelGroup
+			if group == "" {
+				previous = group
+				continue
+			}
+			stepContext := context.addField(phase.name).addIndex(i).addField("parallel_group")
+			isBestEffort := step.BestEffort != nil && *step.BestEffort
+			switch other, seen := phases[group]; {
+			case strings.TrimSpace(group) == "":
+				ret = append(ret, stepContext.errorf("cannot be blank"))
+			case seen && other != phase.name:
+				ret = append(ret, stepContext.errorf("group %q is already used in %s, groups cannot span phases", group, other))
+			case seen && group != previous:
+				ret = append(ret, stepContext.errorf("steps of group %q must be consecutive", group))
+			case seen && bestEffort[group] != isBestEffort:
+				ret = append(ret, stepContext.errorf("steps of group %q must either all be best_effort or none", group))
+			case !seen:
+				phases[group], bestEffort[group] = phase.name, isBestEffort
+			}
+			previous = group
+		}
+	}
+	return ret
+}
+
+func validateStepRetry(context *context, retry api.StepRetry) (ret []error) {
+	if retry.Attempts < 1 {
+		ret = append(ret, context.addField("attempts").errorf("must be at least 1"))
+	}
+	if retry.Backoff != nil && retry.Backoff.Duration < 0 {
+		ret = append(ret, context.addField("backoff").errorf("cannot be negative"))
+	}
+	for i, reason := range retry.Reasons {
+		if reason == "" {
+			ret = append(ret, context.addField("reasons").addIndex(i).errorf("cannot be empty"))
+		}
+	}
+	return ret
+}
+
 func validateFromAndFromImage(
 	context *context,
 	from string,
@@ -1016,7 +1016,7 @@ func TestValidateTestSteps(t *testing.T) {
 			Reference: &myReference,
 		}},
 		errs: []error{
-			errors.New("test[0]: only one of `ref`, `chain`, or a literal test step can be set"),
+			errors.New("test[0]: only one of `ref`, `chain`, `parallel`, or a literal test step can be set"),
 		},
 	}, {
 		name: "Step with same name as reference",
@@ -1122,6 +1122,76 @@ func TestValidateTestSteps(t *testing.T) {
 		errs: []error{
 			errors.New("test best-effort contains best_effort without timeout"),
 		},
+	}, {
+		name: "valid retry policy",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "as",
+				From:      "from",
+				Commands:  "commands",
+				Resources: resources,
+				Retry:     &api.StepRetry{Attempts: 3, Backoff: defaultDuration, ExitCodes: []int32{1}, Reasons: []string{"pod_pending"}},
+			},
+		}},
+	}, {
+		name: "invalid retry policy",
+		steps: []api.TestStep{{
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "as",
+				From:      "from",
+				Commands:  "commands",
+				Resources: resources,
+				Retry:     &api.StepRetry{Backoff: &prowv1.Duration{Duration: -time.Minute}, Reasons: []string{""}},
+			},
+		}},
+		errs: []error{
+			errors.New("test[0].retry.attempts: must be at least 1"),
+			errors.New("test[0].retry.backoff: cannot be negative"),
+			errors.New("test[0].retry.reasons[0]: cannot be empty"),
+		},
+	}, {
+		name: "valid parallel group",
+		steps: []api.TestStep{{
+			Parallel: []api.ParallelTestStep{{
+				Reference: &myReference,
+			}, {
+				LiteralTestStep: &api.LiteralTestStep{
+					As:        "as",
+					From:      "from",
+					Commands:  "commands",
+					Resources: resources,
+				},
+			}},
+		}},
+	}, {
+		name: "invalid parallel groups",
+		steps: []api.TestStep{{
+			Parallel: []api.ParallelTestStep{},
+		}, {
+			Parallel: []api.ParallelTestStep{{}},
+		}},
+		errs: []error{
+			errors.New("test[0].parallel: must contain at least one step"),
+			errors.New("test[1].parallel[0]: a reference, chain, parallel group, or literal test step is required"),
+		},
+	}, {
+		name: "invalid conditions",
+		steps: []api.TestStep{{
+			Reference: &myReference,
+			When:      "FIPS_ENABLED &&",
+		}, {
+			LiteralTestStep: &api.LiteralTestStep{
+				As:        "as",
+				From:      "from",
+				Commands:  "commands",
+				Resources: resources,
+				When:      `CLUSTER_TYPE = "aws"`,
+			},
+		}},
+		errs: []error{
+			errors.New(`test[0].when: invalid condition "FIPS_ENABLED &&": unexpected end of expression`),
+			errors.New(`test[1].when: invalid condition "CLUSTER_TYPE = \"aws\"": unexpected character '='`),
+		},
 	}, {
 		name: "cluster claim release",
 		steps: []api.TestStep{{
@@ -1517,6 +1587,56 @@ func TestValidateLeases(t *testing.T) {
 	}
 }
 
+func TestValidateParallelGroups(t *testing.T) {
+	yes := true
+	step := func(as, group string, bestEffort *bool) api.LiteralTestStep {
+		return api.LiteralTestStep{As: as, ParallelGroup: group, BestEffort: bestEffort}
+	}
+	for _, tc := range []struct {
+		name     string
+		test     api.MultiStageTestConfigurationLiteral
+		expected []error
+	}{{
+		name: "valid groups",
+		test: api.MultiStageTestConfigurationLiteral{
+			Pre:  []api.LiteralTestStep{step("a", "a", nil), step("b", "a", nil), step("c", "", nil)},
+			Post: []api.LiteralTestStep{step("d", "d", &yes), step("e", "d", &yes), step("f", "f", nil), step("g", "f", nil)},
+		},
+	}, {
+		name: "blank group",
+		test: api.MultiStageTestConfigurationLiteral{
+			Test: []api.LiteralTestStep{step("a", " ", nil)},
+		},
+		expected: []error{errors.New("tests[0].steps.test[0].parallel_group: cannot be blank")},
+	}, {
+		name: "group spanning phases",
+		test: api.MultiStageTestConfigurationLiteral{
+			Pre:  []api.LiteralTestStep{step("a", "a", nil)},
+			Test: []api.LiteralTestStep{step("b", "a", nil)},
+		},
+		expected: []error{errors.New("tests[0].steps.test[0].parallel_group: group \"a\" is already used in pre, groups cannot span phases")},
+	}, {
+		name: "group which is not consecutive",
+		test: api.MultiStageTestConfigurationLiteral{
+			Test: []api.LiteralTestStep{step("a", "a", nil), step("b", "", nil), step("c", "a", nil)},
+		},
+		expected: []error{errors.New("tests[0].steps.test[2].parallel_group: steps of group \"a\" must be consecutive")},
+	}, {
+		name: "group mixing best-effort steps with others",
+		test: api.MultiStageTestConfigurationLiteral{
+			Post: []api.LiteralTestStep{step("a", "a", nil), step("b", "a", &yes)},
+		},
+		expected: []error{errors.New("tests[0].steps.post[1].parallel_group: steps of group \"a\" must either all be best_effort or none")},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := validateParallelGroups(newContext("tests[0].steps", nil, nil, nil), &tc.test)
+			if diff := cmp.Diff(tc.expected, actual, testhelper.EquateErrorMessage); diff != "" {
+				t.Errorf("%s: actual does not match expected, diff: %s", tc.name, diff)
+			}
+		})
+	}
+}
+
 func TestValidateTestConfigurationType(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
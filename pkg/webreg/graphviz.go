@@ -156,6 +156,9 @@ func (b *graphBuilder) addSubgraph(label string, steps []api.TestStep) int {
 		} else if step.Chain != nil {
 			i := b.addSubgraph(*step.Chain, b.chains[*step.Chain].Steps)
 			sg.subgraphs = append(sg.subgraphs, i)
+		} else if step.Parallel != nil {
+			i := b.addSubgraph("parallel", step.ParallelSteps())
+			sg.subgraphs = append(sg.subgraphs, i)
 		}
 	}
 	i := len(b.graph.subgraphs)
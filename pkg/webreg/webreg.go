@@ -840,6 +840,8 @@ func getEnvironmentDataItems(worklist []api.TestStep, registryRefs registry.Refe
 			for _, env := range ref.Environment {
 				add(env.Name, env.Documentation, ref.As, env.Default)
 			}
+		case step.Parallel != nil:
+			worklist = append(worklist, step.ParallelSteps()...)
 		case step.Chain != nil:
 			chainName := *step.Chain
 			if !seenChains.Has(chainName) {
@@ -911,6 +913,8 @@ func getDependencyDataItems(worklist []api.TestStep, registryRefs registry.Refer
 			for _, dep := range ref.Dependencies {
 				add(dep.Name, dep.Env, ref.As)
 			}
+		case step.Parallel != nil:
+			worklist = append(worklist, step.ParallelSteps()...)
 		case step.Chain != nil:
 			chainName := *step.Chain
 			if !seenChains.Has(chainName) {
@@ -614,6 +614,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # all previous `pre` and `test` steps were successful. The given step must explicitly\n" +
 	"            # ask for being skipped by setting the OptionalOnSuccess flag to true.\n" +
 	"            allow_skip_on_success: false\n" +
+	"            # CachePre defines if the results of the `pre` steps can be reused by later\n" +
+	"            # jobs with the same inputs.\n" +
+	"            cache_pre: false\n" +
 	"            # ClusterProfile defines the profile/cloud provider for end-to-end test steps.\n" +
 	"            cluster_profile: ' '\n" +
 	"            # Dependencies holds override values for dependency parameters.\n" +
@@ -764,6 +767,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # ParallelGroup is the name of the group of steps this step is executed\n" +
+	"                  # concurrently with. Consecutive steps with the same group are executed\n" +
+	"                  # at the same time. It is set when parallel steps are resolved. The steps\n" +
+	"                  # of a group belong to the same phase and are either all best-effort or\n" +
+	"                  # none of them is.\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -774,11 +783,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # These are directly used in creating the Pods that execute the Job.\n" +
 	"                    requests:\n" +
 	"                        \"\": \"\"\n" +
+	"                  # Retry defines if and how this step is retried when it fails.\n" +
+	"                  retry:\n" +
+	"                    # Attempts is the maximum number of times the step is executed, including\n" +
+	"                    # the first execution.\n" +
+	"                    attempts: 0\n" +
+	"                    # Backoff is how long to wait before the first retry. The wait time is\n" +
+	"                    # doubled for each subsequent retry.\n" +
+	"                    backoff: 0s\n" +
+	"                    # ExitCodes lists the exit codes of the step container that allow a\n" +
+	"                    # retry.\n" +
+	"                    exit_codes:\n" +
+	"                        - 0\n" +
+	"                    # Reasons lists the failure reasons that allow a retry, e.g. `pod_pending`.\n" +
+	"                    # When neither exit codes nor reasons are set, every failure is retried.\n" +
+	"                    reasons:\n" +
+	"                        - \"\"\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"                  # When is a condition on the test parameters which determines whether this\n" +
+	"                  # step is executed, e.g. `CLUSTER_TYPE == \"aws\" && !FIPS_ENABLED`. The step\n" +
+	"                  # is skipped when it evaluates to false. Conditions are evaluated right\n" +
+	"                  # before the step would be executed, after the parameters passed to\n" +
+	"                  # ci-operator are applied.\n" +
+	"                  when: ' '\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"            pre:\n" +
 	"                - # As is the name of the LiteralTestStep.\n" +
@@ -857,6 +888,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # ParallelGroup is the name of the group of steps this step is executed\n" +
+	"                  # concurrently with. Consecutive steps with the same group are executed\n" +
+	"                  # at the same time. It is set when parallel steps are resolved. The steps\n" +
+	"                  # of a group belong to the same phase and are either all best-effort or\n" +
+	"                  # none of them is.\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -867,11 +904,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # These are directly used in creating the Pods that execute the Job.\n" +
 	"                    requests:\n" +
 	"                        \"\": \"\"\n" +
+	"                  # Retry defines if and how this step is retried when it fails.\n" +
+	"                  retry:\n" +
+	"                    # Attempts is the maximum number of times the step is executed, including\n" +
+	"                    # the first execution.\n" +
+	"                    attempts: 0\n" +
+	"                    # Backoff is how long to wait before the first retry. The wait time is\n" +
+	"                    # doubled for each subsequent retry.\n" +
+	"                    backoff: 0s\n" +
+	"                    # ExitCodes lists the exit codes of the step container that allow a\n" +
+	"                    # retry.\n" +
+	"                    exit_codes:\n" +
+	"                        - 0\n" +
+	"                    # Reasons lists the failure reasons that allow a retry, e.g. `pod_pending`.\n" +
+	"                    # When neither exit codes nor reasons are set, every failure is retried.\n" +
+	"                    reasons:\n" +
+	"                        - \"\"\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"                  # When is a condition on the test parameters which determines whether this\n" +
+	"                  # step is executed, e.g. `CLUSTER_TYPE == \"aws\" && !FIPS_ENABLED`. The step\n" +
+	"                  # is skipped when it evaluates to false. Conditions are evaluated right\n" +
+	"                  # before the step would be executed, after the parameters passed to\n" +
+	"                  # ci-operator are applied.\n" +
+	"                  when: ' '\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
 	"            test:\n" +
 	"                - # As is the name of the LiteralTestStep.\n" +
@@ -950,6 +1009,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                  # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"                  # applicable to `post` steps.\n" +
 	"                  optional_on_success: false\n" +
+	"                  # ParallelGroup is the name of the group of steps this step is executed\n" +
+	"                  # concurrently with. Consecutive steps with the same group are executed\n" +
+	"                  # at the same time. It is set when parallel steps are resolved. The steps\n" +
+	"                  # of a group belong to the same phase and are either all best-effort or\n" +
+	"                  # none of them is.\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
 	"                  resources:\n" +
 	"                    # Limits are resource limits applied to an individual step in the job.\n" +
@@ -960,11 +1025,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # These are directly used in creating the Pods that execute the Job.\n" +
 	"                    requests:\n" +
 	"                        \"\": \"\"\n" +
+	"                  # Retry defines if and how this step is retried when it fails.\n" +
+	"                  retry:\n" +
+	"                    # Attempts is the maximum number of times the step is executed, including\n" +
+	"                    # the first execution.\n" +
+	"                    attempts: 0\n" +
+	"                    # Backoff is how long to wait before the first retry. The wait time is\n" +
+	"                    # doubled for each subsequent retry.\n" +
+	"                    backoff: 0s\n" +
+	"                    # ExitCodes lists the exit codes of the step container that allow a\n" +
+	"                    # retry.\n" +
+	"                    exit_codes:\n" +
+	"                        - 0\n" +
+	"                    # Reasons lists the failure reasons that allow a retry, e.g. `pod_pending`.\n" +
+	"                    # When neither exit codes nor reasons are set, every failure is retried.\n" +
+	"                    reasons:\n" +
+	"                        - \"\"\n" +
 	"                  # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"                  # in the test container instead of being executed directly via bash\n" +
 	"                  run_as_script: false\n" +
 	"                  # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"                  timeout: 0s\n" +
+	"                  # When is a condition on the test parameters which determines whether this\n" +
+	"                  # step is executed, e.g. `CLUSTER_TYPE == \"aws\" && !FIPS_ENABLED`. The step\n" +
+	"                  # is skipped when it evaluates to false. Conditions are evaluated right\n" +
+	"                  # before the step would be executed, after the parameters passed to\n" +
+	"                  # ci-operator are applied.\n" +
+	"                  when: ' '\n" +
 	"            # Override job timeout\n" +
 	"            timeout: 0s\n" +
 	"        # MinimumInterval to wait between two runs of the job. Consecutive\n" +
@@ -1044,6 +1131,7 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              name: ' '\n" +
 	"        # ShardCount describes the number of jobs that should be generated as shards for this test\n" +
 	"        # Each generated job will be a duplication, but contain a suffix and the necessary SHARD_ARGS will be passed to the steps\n" +
+	"        # Every container of the test also gets its shard as SHARD_INDEX, starting at 1, and SHARD_COUNT\n" +
 	"        # Only applicable to presubmits and periodics\n" +
 	"        shard_count: 0\n" +
 	"        # SkipIfOnlyChanged is a regex that will result in the test being skipped if all changed files match that regex.\n" +
@@ -1057,6 +1145,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"            # all previous `pre` and `test` steps were successful. The given step must explicitly\n" +
 	"            # ask for being skipped by setting the OptionalOnSuccess flag to true.\n" +
 	"            allow_skip_on_success: false\n" +
+	"            # CachePre defines if the results of the `pre` steps can be reused by later\n" +
+	"            # jobs with the same inputs. When set, a successful `pre` phase stores the\n" +
+	"            # content of the shared directory, which is restored instead of running the\n" +
+	"            # `pre` steps when a job with the same input hash, configuration and leased\n" +
+	"            # resources runs again. The `post` steps must not tear down what the `pre`\n" +
+	"            # steps set up for the cached result to be usable.\n" +
+	"            cache_pre: false\n" +
 	"            # ClusterProfile defines the profile/cloud provider for end-to-end test steps.\n" +
 	"            cluster_profile: ' '\n" +
 	"            # Dependencies holds override values for dependency parameters.\n" +
@@ -1152,6 +1247,87 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  # Parallel is a group of steps which are executed concurrently. Steps of\n" +
+	"                  # chains in the group are also executed concurrently.\n" +
+	"                  parallel:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - as: ' '\n" +
+	"                      best_effort: false\n" +
+	"                      # Chain is the name of a step chain reference.\n" +
+	"                      chain: \"\"\n" +
+	"                      # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"                      # will be injected into this step.\n" +
+	"                      cli: ' '\n" +
+	"                      commands: ' '\n" +
+	"                      credentials:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - mount_path: ' '\n" +
+	"                          name: ' '\n" +
+	"                          namespace: ' '\n" +
+	"                      dependencies:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - env: ' '\n" +
+	"                          name: ' '\n" +
+	"                      dnsConfig:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        nameservers:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - \"\"\n" +
+	"                        searches:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - \"\"\n" +
+	"                      env:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - default: \"\"\n" +
+	"                          documentation: ' '\n" +
+	"                          name: ' '\n" +
+	"                      from: ' '\n" +
+	"                      from_image:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        as: ' '\n" +
+	"                        name: ' '\n" +
+	"                        namespace: ' '\n" +
+	"                        tag: ' '\n" +
+	"                      grace_period: 0s\n" +
+	"                      leases:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - env: ' '\n" +
+	"                          resource_type: ' '\n" +
+	"                      no_kubeconfig: false\n" +
+	"                      node_architecture: \"\"\n" +
+	"                      # Observers are the observers that should be running\n" +
+	"                      observers:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      optional_on_success: false\n" +
+	"                      parallel_group: ' '\n" +
+	"                      # Reference is the name of a step reference.\n" +
+	"                      ref: \"\"\n" +
+	"                      # Resources defines the resource requirements for the step.\n" +
+	"                      resources:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        limits:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
+	"                        requests:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
+	"                      retry:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        attempts: 0\n" +
+	"                        backoff: 0s\n" +
+	"                        exit_codes:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - 0\n" +
+	"                        reasons:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - \"\"\n" +
+	"                      run_as_script: false\n" +
+	"                      timeout: 0s\n" +
+	"                      # When is a condition on the test parameters which determines whether the\n" +
+	"                      # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"                      when: ' '\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -1163,8 +1339,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    requests:\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    attempts: 0\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    reasons:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  run_as_script: false\n" +
 	"                  timeout: 0s\n" +
+	"                  # When is a condition on the test parameters which determines whether the\n" +
+	"                  # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"                  when: ' '\n" +
 	"            # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"            pre:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -1217,6 +1406,87 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  # Parallel is a group of steps which are executed concurrently. Steps of\n" +
+	"                  # chains in the group are also executed concurrently.\n" +
+	"                  parallel:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - as: ' '\n" +
+	"                      best_effort: false\n" +
+	"                      # Chain is the name of a step chain reference.\n" +
+	"                      chain: \"\"\n" +
+	"                      # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"                      # will be injected into this step.\n" +
+	"                      cli: ' '\n" +
+	"                      commands: ' '\n" +
+	"                      credentials:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - mount_path: ' '\n" +
+	"                          name: ' '\n" +
+	"                          namespace: ' '\n" +
+	"                      dependencies:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - env: ' '\n" +
+	"                          name: ' '\n" +
+	"                      dnsConfig:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        nameservers:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - \"\"\n" +
+	"                        searches:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - \"\"\n" +
+	"                      env:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - default: \"\"\n" +
+	"                          documentation: ' '\n" +
+	"                          name: ' '\n" +
+	"                      from: ' '\n" +
+	"                      from_image:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        as: ' '\n" +
+	"                        name: ' '\n" +
+	"                        namespace: ' '\n" +
+	"                        tag: ' '\n" +
+	"                      grace_period: 0s\n" +
+	"                      leases:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - env: ' '\n" +
+	"                          resource_type: ' '\n" +
+	"                      no_kubeconfig: false\n" +
+	"                      node_architecture: \"\"\n" +
+	"                      # Observers are the observers that should be running\n" +
+	"                      observers:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      optional_on_success: false\n" +
+	"                      parallel_group: ' '\n" +
+	"                      # Reference is the name of a step reference.\n" +
+	"                      ref: \"\"\n" +
+	"                      # Resources defines the resource requirements for the step.\n" +
+	"                      resources:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        limits:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
+	"                        requests:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
+	"                      retry:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        attempts: 0\n" +
+	"                        backoff: 0s\n" +
+	"                        exit_codes:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - 0\n" +
+	"                        reasons:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - \"\"\n" +
+	"                      run_as_script: false\n" +
+	"                      timeout: 0s\n" +
+	"                      # When is a condition on the test parameters which determines whether the\n" +
+	"                      # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"                      when: ' '\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -1228,8 +1498,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    requests:\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    attempts: 0\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    reasons:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  run_as_script: false\n" +
 	"                  timeout: 0s\n" +
+	"                  # When is a condition on the test parameters which determines whether the\n" +
+	"                  # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"                  when: ' '\n" +
 	"            # Test is the array of test steps that define the actual test.\n" +
 	"            test:\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
@@ -1282,6 +1565,87 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    - \"\"\n" +
 	"                  optional_on_success: false\n" +
+	"                  # Parallel is a group of steps which are executed concurrently. Steps of\n" +
+	"                  # chains in the group are also executed concurrently.\n" +
+	"                  parallel:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - as: ' '\n" +
+	"                      best_effort: false\n" +
+	"                      # Chain is the name of a step chain reference.\n" +
+	"                      chain: \"\"\n" +
+	"                      # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"                      # will be injected into this step.\n" +
+	"                      cli: ' '\n" +
+	"                      commands: ' '\n" +
+	"                      credentials:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - mount_path: ' '\n" +
+	"                          name: ' '\n" +
+	"                          namespace: ' '\n" +
+	"                      dependencies:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - env: ' '\n" +
+	"                          name: ' '\n" +
+	"                      dnsConfig:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        nameservers:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - \"\"\n" +
+	"                        searches:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - \"\"\n" +
+	"                      env:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - default: \"\"\n" +
+	"                          documentation: ' '\n" +
+	"                          name: ' '\n" +
+	"                      from: ' '\n" +
+	"                      from_image:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        as: ' '\n" +
+	"                        name: ' '\n" +
+	"                        namespace: ' '\n" +
+	"                        tag: ' '\n" +
+	"                      grace_period: 0s\n" +
+	"                      leases:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - env: ' '\n" +
+	"                          resource_type: ' '\n" +
+	"                      no_kubeconfig: false\n" +
+	"                      node_architecture: \"\"\n" +
+	"                      # Observers are the observers that should be running\n" +
+	"                      observers:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                      optional_on_success: false\n" +
+	"                      parallel_group: ' '\n" +
+	"                      # Reference is the name of a step reference.\n" +
+	"                      ref: \"\"\n" +
+	"                      # Resources defines the resource requirements for the step.\n" +
+	"                      resources:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        limits:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
+	"                        requests:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            \"\": \"\"\n" +
+	"                      retry:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        attempts: 0\n" +
+	"                        backoff: 0s\n" +
+	"                        exit_codes:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - 0\n" +
+	"                        reasons:\n" +
+	"                            # LiteralTestStep is a full test step definition.\n" +
+	"                            - \"\"\n" +
+	"                      run_as_script: false\n" +
+	"                      timeout: 0s\n" +
+	"                      # When is a condition on the test parameters which determines whether the\n" +
+	"                      # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"                      when: ' '\n" +
+	"                  parallel_group: ' '\n" +
 	"                  # Reference is the name of a step reference.\n" +
 	"                  ref: \"\"\n" +
 	"                  # Resources defines the resource requirements for the step.\n" +
@@ -1293,8 +1657,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                    requests:\n" +
 	"                        # LiteralTestStep is a full test step definition.\n" +
 	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    attempts: 0\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    reasons:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
 	"                  run_as_script: false\n" +
 	"                  timeout: 0s\n" +
+	"                  # When is a condition on the test parameters which determines whether the\n" +
+	"                  # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"                  when: ' '\n" +
 	"            # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"            # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
 	"            workflow: \"\"\n" +
@@ -1482,6 +1859,9 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # all previous `pre` and `test` steps were successful. The given step must explicitly\n" +
 	"        # ask for being skipped by setting the OptionalOnSuccess flag to true.\n" +
 	"        allow_skip_on_success: false\n" +
+	"        # CachePre defines if the results of the `pre` steps can be reused by later\n" +
+	"        # jobs with the same inputs.\n" +
+	"        cache_pre: false\n" +
 	"        # ClusterProfile defines the profile/cloud provider for end-to-end test steps.\n" +
 	"        cluster_profile: ' '\n" +
 	"        # Dependencies holds override values for dependency parameters.\n" +
@@ -1632,6 +2012,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # ParallelGroup is the name of the group of steps this step is executed\n" +
+	"              # concurrently with. Consecutive steps with the same group are executed\n" +
+	"              # at the same time. It is set when parallel steps are resolved. The steps\n" +
+	"              # of a group belong to the same phase and are either all best-effort or\n" +
+	"              # none of them is.\n" +
+	"              parallel_group: ' '\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1642,11 +2028,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # These are directly used in creating the Pods that execute the Job.\n" +
 	"                requests:\n" +
 	"                    \"\": \"\"\n" +
+	"              # Retry defines if and how this step is retried when it fails.\n" +
+	"              retry:\n" +
+	"                # Attempts is the maximum number of times the step is executed, including\n" +
+	"                # the first execution.\n" +
+	"                attempts: 0\n" +
+	"                # Backoff is how long to wait before the first retry. The wait time is\n" +
+	"                # doubled for each subsequent retry.\n" +
+	"                backoff: 0s\n" +
+	"                # ExitCodes lists the exit codes of the step container that allow a\n" +
+	"                # retry.\n" +
+	"                exit_codes:\n" +
+	"                    - 0\n" +
+	"                # Reasons lists the failure reasons that allow a retry, e.g. `pod_pending`.\n" +
+	"                # When neither exit codes nor reasons are set, every failure is retried.\n" +
+	"                reasons:\n" +
+	"                    - \"\"\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"              # When is a condition on the test parameters which determines whether this\n" +
+	"              # step is executed, e.g. `CLUSTER_TYPE == \"aws\" && !FIPS_ENABLED`. The step\n" +
+	"              # is skipped when it evaluates to false. Conditions are evaluated right\n" +
+	"              # before the step would be executed, after the parameters passed to\n" +
+	"              # ci-operator are applied.\n" +
+	"              when: ' '\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"        pre:\n" +
 	"            - # As is the name of the LiteralTestStep.\n" +
@@ -1725,6 +2133,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # ParallelGroup is the name of the group of steps this step is executed\n" +
+	"              # concurrently with. Consecutive steps with the same group are executed\n" +
+	"              # at the same time. It is set when parallel steps are resolved. The steps\n" +
+	"              # of a group belong to the same phase and are either all best-effort or\n" +
+	"              # none of them is.\n" +
+	"              parallel_group: ' '\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1735,11 +2149,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # These are directly used in creating the Pods that execute the Job.\n" +
 	"                requests:\n" +
 	"                    \"\": \"\"\n" +
+	"              # Retry defines if and how this step is retried when it fails.\n" +
+	"              retry:\n" +
+	"                # Attempts is the maximum number of times the step is executed, including\n" +
+	"                # the first execution.\n" +
+	"                attempts: 0\n" +
+	"                # Backoff is how long to wait before the first retry. The wait time is\n" +
+	"                # doubled for each subsequent retry.\n" +
+	"                backoff: 0s\n" +
+	"                # ExitCodes lists the exit codes of the step container that allow a\n" +
+	"                # retry.\n" +
+	"                exit_codes:\n" +
+	"                    - 0\n" +
+	"                # Reasons lists the failure reasons that allow a retry, e.g. `pod_pending`.\n" +
+	"                # When neither exit codes nor reasons are set, every failure is retried.\n" +
+	"                reasons:\n" +
+	"                    - \"\"\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"              # When is a condition on the test parameters which determines whether this\n" +
+	"              # step is executed, e.g. `CLUSTER_TYPE == \"aws\" && !FIPS_ENABLED`. The step\n" +
+	"              # is skipped when it evaluates to false. Conditions are evaluated right\n" +
+	"              # before the step would be executed, after the parameters passed to\n" +
+	"              # ci-operator are applied.\n" +
+	"              when: ' '\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
 	"        test:\n" +
 	"            - # As is the name of the LiteralTestStep.\n" +
@@ -1818,6 +2254,12 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"              # flag is set to true in MultiStageTestConfiguration. This option is\n" +
 	"              # applicable to `post` steps.\n" +
 	"              optional_on_success: false\n" +
+	"              # ParallelGroup is the name of the group of steps this step is executed\n" +
+	"              # concurrently with. Consecutive steps with the same group are executed\n" +
+	"              # at the same time. It is set when parallel steps are resolved. The steps\n" +
+	"              # of a group belong to the same phase and are either all best-effort or\n" +
+	"              # none of them is.\n" +
+	"              parallel_group: ' '\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
 	"              resources:\n" +
 	"                # Limits are resource limits applied to an individual step in the job.\n" +
@@ -1828,11 +2270,33 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # These are directly used in creating the Pods that execute the Job.\n" +
 	"                requests:\n" +
 	"                    \"\": \"\"\n" +
+	"              # Retry defines if and how this step is retried when it fails.\n" +
+	"              retry:\n" +
+	"                # Attempts is the maximum number of times the step is executed, including\n" +
+	"                # the first execution.\n" +
+	"                attempts: 0\n" +
+	"                # Backoff is how long to wait before the first retry. The wait time is\n" +
+	"                # doubled for each subsequent retry.\n" +
+	"                backoff: 0s\n" +
+	"                # ExitCodes lists the exit codes of the step container that allow a\n" +
+	"                # retry.\n" +
+	"                exit_codes:\n" +
+	"                    - 0\n" +
+	"                # Reasons lists the failure reasons that allow a retry, e.g. `pod_pending`.\n" +
+	"                # When neither exit codes nor reasons are set, every failure is retried.\n" +
+	"                reasons:\n" +
+	"                    - \"\"\n" +
 	"              # RunAsScript defines if this step should be executed as a script mounted\n" +
 	"              # in the test container instead of being executed directly via bash\n" +
 	"              run_as_script: false\n" +
 	"              # Timeout is how long the we will wait before aborting a job with SIGINT.\n" +
 	"              timeout: 0s\n" +
+	"              # When is a condition on the test parameters which determines whether this\n" +
+	"              # step is executed, e.g. `CLUSTER_TYPE == \"aws\" && !FIPS_ENABLED`. The step\n" +
+	"              # is skipped when it evaluates to false. Conditions are evaluated right\n" +
+	"              # before the step would be executed, after the parameters passed to\n" +
+	"              # ci-operator are applied.\n" +
+	"              when: ' '\n" +
 	"        # Override job timeout\n" +
 	"        timeout: 0s\n" +
 	"      # MinimumInterval to wait between two runs of the job. Consecutive\n" +
@@ -1912,6 +2376,7 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"          name: ' '\n" +
 	"      # ShardCount describes the number of jobs that should be generated as shards for this test\n" +
 	"      # Each generated job will be a duplication, but contain a suffix and the necessary SHARD_ARGS will be passed to the steps\n" +
+	"      # Every container of the test also gets its shard as SHARD_INDEX, starting at 1, and SHARD_COUNT\n" +
 	"      # Only applicable to presubmits and periodics\n" +
 	"      shard_count: 0\n" +
 	"      # SkipIfOnlyChanged is a regex that will result in the test being skipped if all changed files match that regex.\n" +
@@ -1925,6 +2390,13 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"        # all previous `pre` and `test` steps were successful. The given step must explicitly\n" +
 	"        # ask for being skipped by setting the OptionalOnSuccess flag to true.\n" +
 	"        allow_skip_on_success: false\n" +
+	"        # CachePre defines if the results of the `pre` steps can be reused by later\n" +
+	"        # jobs with the same inputs. When set, a successful `pre` phase stores the\n" +
+	"        # content of the shared directory, which is restored instead of running the\n" +
+	"        # `pre` steps when a job with the same input hash, configuration and leased\n" +
+	"        # resources runs again. The `post` steps must not tear down what the `pre`\n" +
+	"        # steps set up for the cached result to be usable.\n" +
+	"        cache_pre: false\n" +
 	"        # ClusterProfile defines the profile/cloud provider for end-to-end test steps.\n" +
 	"        cluster_profile: ' '\n" +
 	"        # Dependencies holds override values for dependency parameters.\n" +
@@ -2020,6 +2492,87 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              # Parallel is a group of steps which are executed concurrently. Steps of\n" +
+	"              # chains in the group are also executed concurrently.\n" +
+	"              parallel:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - as: ' '\n" +
+	"                  best_effort: false\n" +
+	"                  # Chain is the name of a step chain reference.\n" +
+	"                  chain: \"\"\n" +
+	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"                  # will be injected into this step.\n" +
+	"                  cli: ' '\n" +
+	"                  commands: ' '\n" +
+	"                  credentials:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - mount_path: ' '\n" +
+	"                      name: ' '\n" +
+	"                      namespace: ' '\n" +
+	"                  dependencies:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - env: ' '\n" +
+	"                      name: ' '\n" +
+	"                  dnsConfig:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    nameservers:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    searches:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                  env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - default: \"\"\n" +
+	"                      documentation: ' '\n" +
+	"                      name: ' '\n" +
+	"                  from: ' '\n" +
+	"                  from_image:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    as: ' '\n" +
+	"                    name: ' '\n" +
+	"                    namespace: ' '\n" +
+	"                    tag: ' '\n" +
+	"                  grace_period: 0s\n" +
+	"                  leases:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - env: ' '\n" +
+	"                      resource_type: ' '\n" +
+	"                  no_kubeconfig: false\n" +
+	"                  node_architecture: \"\"\n" +
+	"                  # Observers are the observers that should be running\n" +
+	"                  observers:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  optional_on_success: false\n" +
+	"                  parallel_group: ' '\n" +
+	"                  # Reference is the name of a step reference.\n" +
+	"                  ref: \"\"\n" +
+	"                  # Resources defines the resource requirements for the step.\n" +
+	"                  resources:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    limits:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                    requests:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    attempts: 0\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    reasons:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                  run_as_script: false\n" +
+	"                  timeout: 0s\n" +
+	"                  # When is a condition on the test parameters which determines whether the\n" +
+	"                  # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"                  when: ' '\n" +
+	"              parallel_group: ' '\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -2031,8 +2584,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                requests:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
+	"              retry:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                attempts: 0\n" +
+	"                backoff: 0s\n" +
+	"                exit_codes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                reasons:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              run_as_script: false\n" +
 	"              timeout: 0s\n" +
+	"              # When is a condition on the test parameters which determines whether the\n" +
+	"              # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"              when: ' '\n" +
 	"        # Pre is the array of test steps run to set up the environment for the test.\n" +
 	"        pre:\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
@@ -2085,6 +2651,87 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              # Parallel is a group of steps which are executed concurrently. Steps of\n" +
+	"              # chains in the group are also executed concurrently.\n" +
+	"              parallel:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - as: ' '\n" +
+	"                  best_effort: false\n" +
+	"                  # Chain is the name of a step chain reference.\n" +
+	"                  chain: \"\"\n" +
+	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"                  # will be injected into this step.\n" +
+	"                  cli: ' '\n" +
+	"                  commands: ' '\n" +
+	"                  credentials:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - mount_path: ' '\n" +
+	"                      name: ' '\n" +
+	"                      namespace: ' '\n" +
+	"                  dependencies:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - env: ' '\n" +
+	"                      name: ' '\n" +
+	"                  dnsConfig:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    nameservers:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    searches:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                  env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - default: \"\"\n" +
+	"                      documentation: ' '\n" +
+	"                      name: ' '\n" +
+	"                  from: ' '\n" +
+	"                  from_image:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    as: ' '\n" +
+	"                    name: ' '\n" +
+	"                    namespace: ' '\n" +
+	"                    tag: ' '\n" +
+	"                  grace_period: 0s\n" +
+	"                  leases:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - env: ' '\n" +
+	"                      resource_type: ' '\n" +
+	"                  no_kubeconfig: false\n" +
+	"                  node_architecture: \"\"\n" +
+	"                  # Observers are the observers that should be running\n" +
+	"                  observers:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  optional_on_success: false\n" +
+	"                  parallel_group: ' '\n" +
+	"                  # Reference is the name of a step reference.\n" +
+	"                  ref: \"\"\n" +
+	"                  # Resources defines the resource requirements for the step.\n" +
+	"                  resources:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    limits:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                    requests:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    attempts: 0\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    reasons:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                  run_as_script: false\n" +
+	"                  timeout: 0s\n" +
+	"                  # When is a condition on the test parameters which determines whether the\n" +
+	"                  # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"                  when: ' '\n" +
+	"              parallel_group: ' '\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -2096,8 +2743,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                requests:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
+	"              retry:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                attempts: 0\n" +
+	"                backoff: 0s\n" +
+	"                exit_codes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                reasons:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              run_as_script: false\n" +
 	"              timeout: 0s\n" +
+	"              # When is a condition on the test parameters which determines whether the\n" +
+	"              # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"              when: ' '\n" +
 	"        # Test is the array of test steps that define the actual test.\n" +
 	"        test:\n" +
 	"            # LiteralTestStep is a full test step definition.\n" +
@@ -2150,6 +2810,87 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                # LiteralTestStep is a full test step definition.\n" +
 	"                - \"\"\n" +
 	"              optional_on_success: false\n" +
+	"              # Parallel is a group of steps which are executed concurrently. Steps of\n" +
+	"              # chains in the group are also executed concurrently.\n" +
+	"              parallel:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                - as: ' '\n" +
+	"                  best_effort: false\n" +
+	"                  # Chain is the name of a step chain reference.\n" +
+	"                  chain: \"\"\n" +
+	"                  # Cli is the (optional) name of the release from which the `oc` binary\n" +
+	"                  # will be injected into this step.\n" +
+	"                  cli: ' '\n" +
+	"                  commands: ' '\n" +
+	"                  credentials:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - mount_path: ' '\n" +
+	"                      name: ' '\n" +
+	"                      namespace: ' '\n" +
+	"                  dependencies:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - env: ' '\n" +
+	"                      name: ' '\n" +
+	"                  dnsConfig:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    nameservers:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                    searches:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                  env:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - default: \"\"\n" +
+	"                      documentation: ' '\n" +
+	"                      name: ' '\n" +
+	"                  from: ' '\n" +
+	"                  from_image:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    as: ' '\n" +
+	"                    name: ' '\n" +
+	"                    namespace: ' '\n" +
+	"                    tag: ' '\n" +
+	"                  grace_period: 0s\n" +
+	"                  leases:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - env: ' '\n" +
+	"                      resource_type: ' '\n" +
+	"                  no_kubeconfig: false\n" +
+	"                  node_architecture: \"\"\n" +
+	"                  # Observers are the observers that should be running\n" +
+	"                  observers:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
+	"                  optional_on_success: false\n" +
+	"                  parallel_group: ' '\n" +
+	"                  # Reference is the name of a step reference.\n" +
+	"                  ref: \"\"\n" +
+	"                  # Resources defines the resource requirements for the step.\n" +
+	"                  resources:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    limits:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                    requests:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        \"\": \"\"\n" +
+	"                  retry:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    attempts: 0\n" +
+	"                    backoff: 0s\n" +
+	"                    exit_codes:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - 0\n" +
+	"                    reasons:\n" +
+	"                        # LiteralTestStep is a full test step definition.\n" +
+	"                        - \"\"\n" +
+	"                  run_as_script: false\n" +
+	"                  timeout: 0s\n" +
+	"                  # When is a condition on the test parameters which determines whether the\n" +
+	"                  # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"                  when: ' '\n" +
+	"              parallel_group: ' '\n" +
 	"              # Reference is the name of a step reference.\n" +
 	"              ref: \"\"\n" +
 	"              # Resources defines the resource requirements for the step.\n" +
@@ -2161,8 +2902,21 @@ const ciOperatorReferenceYaml = "# The list of base images describe\n" +
 	"                requests:\n" +
 	"                    # LiteralTestStep is a full test step definition.\n" +
 	"                    \"\": \"\"\n" +
+	"              retry:\n" +
+	"                # LiteralTestStep is a full test step definition.\n" +
+	"                attempts: 0\n" +
+	"                backoff: 0s\n" +
+	"                exit_codes:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - 0\n" +
+	"                reasons:\n" +
+	"                    # LiteralTestStep is a full test step definition.\n" +
+	"                    - \"\"\n" +
 	"              run_as_script: false\n" +
 	"              timeout: 0s\n" +
+	"              # When is a condition on the test parameters which determines whether the\n" +
+	"              # step or all steps of the chain are executed. See LiteralTestStep.When.\n" +
+	"              when: ' '\n" +
 	"        # Workflow is the name of the workflow to be used for this configuration. For fields defined in both\n" +
 	"        # the config and the workflow, the fields from the config will override what is set in Workflow.\n" +
 	"        workflow: \"\"\n" +